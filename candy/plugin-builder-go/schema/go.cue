@@ -0,0 +1,7 @@
+// Self-contained input schema for the builder:go capability — references no base def, so it
+// compiles standalone (the SDK's serve-side compile). A builder authors no plugin_input (it is
+// TRIGGERED by detection — a candy's go.mod — and tuned by the candy's own go: block, never by
+// an authored plugin field), so this def carries no fields; it ships so the schema travels with
+// the plugin (non-empty, base ++ plugin splice).
+#GoBuilderInput: {
+}
@@ -55,6 +55,8 @@ type BuilderInput struct {
 
 	Path_contribution []string `yaml:"path_contribution,omitempty" json:"path_contribution,omitempty"`
 
+	Lock_query string `yaml:"lock_query,omitempty" json:"lock_query,omitempty"`
+
 	Kind string `yaml:"kind,omitempty" json:"kind"`
 
 	Privileged bool `yaml:"privileged,omitempty" json:"privileged"`
@@ -37,6 +37,7 @@
 	copy_artifact?: [...#BdCopy]
 	copy_binary?: #BdCopy
 	path_contribution?: [...(string & !="")]
+	lock_query?:      string
 	kind:             *"layer" | "bootstrap"
 	privileged:       *false | true
 	output_artifact?: string & =~"^/"
@@ -42,6 +42,7 @@ type CDPClient struct {
 	mu      sync.Mutex
 	pending map[int]chan cdpMessage
 	done    chan struct{}
+	onEvent func(method string, params json.RawMessage)
 }
 
 // NewCDPClient connects to a CDP WebSocket endpoint and starts reading messages.
@@ -60,7 +61,8 @@ func NewCDPClient(wsURL string) (*CDPClient, error) {
 	return c, nil
 }
 
-// readLoop reads messages from the WebSocket and dispatches responses to pending callers.
+// readLoop reads messages from the WebSocket and dispatches responses to pending callers
+// and events to the OnEvent handler.
 func (c *CDPClient) readLoop() {
 	defer close(c.done)
 	for {
@@ -75,6 +77,15 @@ func (c *CDPClient) readLoop() {
 			c.mu.Unlock()
 			return
 		}
+		if msg.ID == 0 && msg.Method != "" {
+			c.mu.Lock()
+			fn := c.onEvent
+			c.mu.Unlock()
+			if fn != nil {
+				fn(msg.Method, msg.Params)
+			}
+			continue
+		}
 		if msg.ID != 0 && msg.Method == "" {
 			c.mu.Lock()
 			ch, ok := c.pending[msg.ID]
@@ -89,6 +100,15 @@ func (c *CDPClient) readLoop() {
 	}
 }
 
+// OnEvent registers fn to receive every CDP event (an id-less message such as
+// Network.requestWillBeSent) the connection delivers. fn runs on the read loop, so it
+// must not block or Call back into the client.
+func (c *CDPClient) OnEvent(fn func(method string, params json.RawMessage)) {
+	c.mu.Lock()
+	c.onEvent = fn
+	c.mu.Unlock()
+}
+
 // Call sends a CDP method call and waits for the response (up to 30s timeout).
 func (c *CDPClient) Call(method string, params any) (json.RawMessage, error) {
 	id := int(c.nextID.Add(1))
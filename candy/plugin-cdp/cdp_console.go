@@ -0,0 +1,292 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/overthinkos/overthink/charly/spec"
+)
+
+// cdp_console.go is the console-collection group: a per-tab collector subscribed to
+// Runtime.consoleAPICalled + Runtime.exceptionThrown, so a page that throws or
+// console.error()s fails its check even while every DOM assertion still passes.
+//
+// `console-start` attaches the collector explicitly (right after `open`, before the
+// steps that exercise the page); `console` reads it — attaching one first when the tab
+// has none — and fails when an entry at or above `console_level:` (default error) is
+// not matched by a `console_allow:` regexp. Runtime.enable makes V8 replay the console
+// messages and uncaught exceptions it buffered since the page's context was created, so
+// even a collector attached late sees everything since the tab was opened (or last
+// navigated). Like the network recorder, the collector lives for the plugin process —
+// i.e. the whole `charly check` run — and accumulates across later `console` steps.
+
+// consoleCollectors holds the live collectors, keyed by the tab's WebSocket debugger URL.
+var consoleCollectors = struct {
+	sync.Mutex
+	m map[string]*consoleCollector
+}{m: map[string]*consoleCollector{}}
+
+// consoleEntry is one console call or uncaught exception.
+type consoleEntry struct {
+	Time   time.Time
+	Level  string // "error" | "warning" | "info" | "debug" (console type folded)
+	Source string // "console" | "exception"
+	Text   string
+	URL    string
+	Line   int // 1-based; 0 = unknown
+}
+
+// String renders the entry as one log line.
+func (e consoleEntry) String() string {
+	s := fmt.Sprintf("%s [%s] %s: %s", e.Time.Format(time.RFC3339Nano), e.Level, e.Source, e.Text)
+	if e.URL != "" {
+		s += fmt.Sprintf(" (%s:%d)", e.URL, e.Line)
+	}
+	return s
+}
+
+// consoleCollector ingests Runtime.* events for one tab.
+type consoleCollector struct {
+	client  *CDPClient
+	mu      sync.Mutex
+	entries []consoleEntry
+}
+
+type cdpRemoteObject struct {
+	Type        string          `json:"type"`
+	Subtype     string          `json:"subtype"`
+	Value       json.RawMessage `json:"value"`
+	Description string          `json:"description"`
+}
+
+type cdpStackTrace struct {
+	CallFrames []struct {
+		URL        string `json:"url"`
+		LineNumber int    `json:"lineNumber"`
+	} `json:"callFrames"`
+}
+
+// handle is the CDPClient.OnEvent callback; unrelated events are ignored.
+func (c *consoleCollector) handle(method string, params json.RawMessage) {
+	var e consoleEntry
+	switch method {
+	case "Runtime.consoleAPICalled":
+		var ev struct {
+			Type       string            `json:"type"`
+			Args       []cdpRemoteObject `json:"args"`
+			Timestamp  float64           `json:"timestamp"` // ms since epoch
+			StackTrace *cdpStackTrace    `json:"stackTrace"`
+		}
+		if json.Unmarshal(params, &ev) != nil {
+			return
+		}
+		args := make([]string, 0, len(ev.Args))
+		for _, a := range ev.Args {
+			args = append(args, remoteObjectText(a))
+		}
+		e = consoleEntry{
+			Time:   time.UnixMilli(int64(ev.Timestamp)).UTC(),
+			Level:  consoleLevel(ev.Type),
+			Source: "console",
+			Text:   strings.Join(args, " "),
+		}
+		if ev.StackTrace != nil && len(ev.StackTrace.CallFrames) > 0 {
+			e.URL = ev.StackTrace.CallFrames[0].URL
+			e.Line = ev.StackTrace.CallFrames[0].LineNumber + 1
+		}
+	case "Runtime.exceptionThrown":
+		var ev struct {
+			Timestamp float64 `json:"timestamp"`
+			Details   struct {
+				Text       string           `json:"text"`
+				URL        string           `json:"url"`
+				LineNumber int              `json:"lineNumber"`
+				Exception  *cdpRemoteObject `json:"exception"`
+			} `json:"exceptionDetails"`
+		}
+		if json.Unmarshal(params, &ev) != nil {
+			return
+		}
+		text := ev.Details.Text
+		if ev.Details.Exception != nil && ev.Details.Exception.Description != "" {
+			// The description carries "TypeError: x is undefined\n    at ..." — keep the
+			// first line; the text is only "Uncaught".
+			desc, _, _ := strings.Cut(ev.Details.Exception.Description, "\n")
+			text = strings.TrimSpace(text + " " + desc)
+		}
+		e = consoleEntry{
+			Time:   time.UnixMilli(int64(ev.Timestamp)).UTC(),
+			Level:  "error",
+			Source: "exception",
+			Text:   text,
+			URL:    ev.Details.URL,
+		}
+		if e.URL != "" {
+			e.Line = ev.Details.LineNumber + 1
+		}
+	default:
+		return
+	}
+	c.mu.Lock()
+	c.entries = append(c.entries, e)
+	c.mu.Unlock()
+}
+
+// consoleLevel folds the console API call types onto four levels.
+func consoleLevel(typ string) string {
+	switch typ {
+	case "error", "assert":
+		return "error"
+	case "warning":
+		return "warning"
+	case "debug", "trace":
+		return "debug"
+	}
+	return "info"
+}
+
+// remoteObjectText renders a console argument the way DevTools prints it: a string
+// primitive bare, other primitives as JSON, objects by their description.
+func remoteObjectText(o cdpRemoteObject) string {
+	if len(o.Value) > 0 {
+		var s string
+		if json.Unmarshal(o.Value, &s) == nil {
+			return s
+		}
+		return string(o.Value)
+	}
+	if o.Description != "" {
+		return o.Description
+	}
+	return o.Type
+}
+
+func (c *consoleCollector) snapshot() []consoleEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]consoleEntry(nil), c.entries...)
+}
+
+// attachConsole returns the tab's collector, attaching one when restart is set or the
+// tab has none yet.
+func attachConsole(wsURL string, restart bool) (*consoleCollector, error) {
+	consoleCollectors.Lock()
+	defer consoleCollectors.Unlock()
+	if old := consoleCollectors.m[wsURL]; old != nil {
+		if !restart {
+			return old, nil
+		}
+		old.client.Close()
+		delete(consoleCollectors.m, wsURL)
+	}
+	client, err := NewCDPClient(wsURL)
+	if err != nil {
+		return nil, err
+	}
+	col := &consoleCollector{client: client}
+	client.OnEvent(col.handle)
+	// The replayed buffer is delivered before the enable response, so the collector
+	// already holds it when Call returns.
+	if _, err := client.Call("Runtime.enable", nil); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("enabling runtime domain: %w", err)
+	}
+	consoleCollectors.m[wsURL] = col
+	return col, nil
+}
+
+// runConsoleStart (re)attaches the tab's console collector.
+func runConsoleStart(ep *cdpEndpoint, tabID string) (string, error) {
+	wsURL, err := resolveTabWS(ep.URL, tabID)
+	if err != nil {
+		return "", err
+	}
+	col, err := attachConsole(wsURL, true)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Collecting console output on tab %s (%d buffered entries)\n", tabID, len(col.snapshot())), nil
+}
+
+// runConsole returns the tab's collected console log (also written to the step's
+// artifact, when declared) and fails on any disallowed entry at or above the level.
+func runConsole(ep *cdpEndpoint, op *spec.Op) (string, error) {
+	wsURL, err := resolveTabWS(ep.URL, op.Tab)
+	if err != nil {
+		return "", err
+	}
+	col, err := attachConsole(wsURL, false)
+	if err != nil {
+		return "", err
+	}
+	entries := col.snapshot()
+	var b strings.Builder
+	for _, e := range entries {
+		b.WriteString(e.String())
+		b.WriteByte('\n')
+	}
+	log := b.String()
+	if op.Artifact != "" {
+		if err := os.MkdirAll(filepath.Dir(op.Artifact), 0o755); err != nil {
+			return "", err
+		}
+		if err := os.WriteFile(op.Artifact, []byte(log), 0o644); err != nil {
+			return "", fmt.Errorf("writing console log: %w", err)
+		}
+	}
+	if err := evalConsole(entries, op.ConsoleLevel, op.ConsoleAllow); err != nil {
+		if op.Artifact != "" {
+			return "", fmt.Errorf("%w (full log: %s)", err, op.Artifact)
+		}
+		return "", err
+	}
+	if log == "" {
+		return fmt.Sprintf("no console output on tab %s\n", op.Tab), nil
+	}
+	return log, nil
+}
+
+// consoleRank orders the levels for the console_level threshold.
+var consoleRank = map[string]int{"debug": 0, "info": 1, "warning": 2, "error": 3}
+
+// evalConsole fails when any entry at or above level (default "error") matches none of
+// the allow regexps, listing every offending entry.
+func evalConsole(entries []consoleEntry, level string, allow []string) error {
+	if level == "" {
+		level = "error"
+	}
+	res := make([]*regexp.Regexp, 0, len(allow))
+	for _, a := range allow {
+		re, err := regexp.Compile(a)
+		if err != nil {
+			return fmt.Errorf("console_allow: bad pattern %q: %w", a, err)
+		}
+		res = append(res, re)
+	}
+	var bad []string
+	for _, e := range entries {
+		if consoleRank[e.Level] < consoleRank[level] || allowedConsole(e, res) {
+			continue
+		}
+		bad = append(bad, fmt.Sprintf("[%s] %s: %s", e.Level, e.Source, e.Text))
+	}
+	if len(bad) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d console %s(s) not in console_allow: %s", len(bad), level, strings.Join(bad, "; "))
+}
+
+func allowedConsole(e consoleEntry, res []*regexp.Regexp) bool {
+	for _, re := range res {
+		if re.MatchString(e.Text) || (e.URL != "" && re.MatchString(e.URL)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// collectSample feeds a collector the Runtime.* events of a page that logs, warns,
+// errors twice (one from a noisy third-party widget), and throws once.
+func collectSample() []consoleEntry {
+	c := &consoleCollector{}
+	ev := func(method, params string) {
+		c.handle(method, json.RawMessage(params))
+	}
+	ev("Runtime.consoleAPICalled", `{"type":"log","timestamp":1700000000000,
+		"args":[{"type":"string","value":"booted in"},{"type":"number","value":42,"description":"42"}]}`)
+	ev("Runtime.consoleAPICalled", `{"type":"warning","timestamp":1700000000100,"args":[{"type":"string","value":"deprecated API"}]}`)
+	ev("Runtime.consoleAPICalled", `{"type":"error","timestamp":1700000000200,
+		"args":[{"type":"string","value":"widget: quota exceeded"}],
+		"stackTrace":{"callFrames":[{"url":"https://cdn.widget.test/w.js","lineNumber":9}]}}`)
+	ev("Runtime.consoleAPICalled", `{"type":"assert","timestamp":1700000000300,
+		"args":[{"type":"object","className":"Object","description":"Object"}]}`)
+	ev("Runtime.exceptionThrown", `{"timestamp":1700000000400,"exceptionDetails":{"text":"Uncaught","url":"https://app.test/main.js","lineNumber":41,
+		"exception":{"type":"object","subtype":"error","description":"TypeError: x is undefined\n    at main.js:42"}}}`)
+	ev("Runtime.executionContextCreated", `{"context":{"id":1}}`)
+	return c.snapshot()
+}
+
+func TestConsoleCollector_Events(t *testing.T) {
+	entries := collectSample()
+	if len(entries) != 5 {
+		t.Fatalf("entries = %d, want 5", len(entries))
+	}
+	if got := entries[0].Text; got != "booted in 42" {
+		t.Errorf("log text = %q", got)
+	}
+	if entries[3].Level != "error" || entries[3].Text != "Object" {
+		t.Errorf("assert entry = %+v", entries[3])
+	}
+	want := "2023-11-14T22:13:20.4Z [error] exception: Uncaught TypeError: x is undefined (https://app.test/main.js:42)"
+	if got := entries[4].String(); got != want {
+		t.Errorf("exception line = %q, want %q", got, want)
+	}
+}
+
+func TestEvalConsole(t *testing.T) {
+	entries := collectSample()
+	cases := []struct {
+		name    string
+		level   string
+		allow   []string
+		wantErr string
+	}{
+		{name: "errors fail by default", wantErr: "3 console error(s) not in console_allow"},
+		{name: "allow by url and text", allow: []string{`cdn\.widget\.test`, `^Object$`, `TypeError`}},
+		{name: "warning threshold", level: "warning", allow: []string{`widget`, `Object`, `TypeError`}, wantErr: "[warning] console: deprecated API"},
+		{name: "bad pattern", allow: []string{`(`}, wantErr: "console_allow: bad pattern"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := evalConsole(entries, tc.level, tc.allow)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("err = %v, want containing %q", err, tc.wantErr)
+			}
+		})
+	}
+	if err := evalConsole(nil, "", nil); err != nil {
+		t.Errorf("empty log: %v", err)
+	}
+}
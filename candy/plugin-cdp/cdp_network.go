@@ -0,0 +1,587 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/overthinkos/overthink/charly/spec"
+)
+
+// cdp_network.go is the network-capture group: `network-start` opens a dedicated CDP
+// WebSocket on the tab, enables the Network domain, and accumulates every request the
+// page makes; `network-stop` closes that recording, writes it as a HAR 1.2 file to the
+// step's `artifact:`, and evaluates the step's `network_expect:` / `network_allow_hosts:`
+// assertions over it.
+//
+// The recording lives in this plugin process, which the host keeps alive for the whole
+// `charly check` run — so start and stop must be steps of the SAME run (a stop with no
+// live recording on the tab fails). Everything between them (a click, an eval that
+// navigates, a plain wait) is captured.
+
+// networkRecorders holds the live recordings, keyed by the tab's WebSocket debugger URL
+// (so tab "1" and the same tab's UUID share one recording).
+var networkRecorders = struct {
+	sync.Mutex
+	m map[string]*networkRecorder
+}{m: map[string]*networkRecorder{}}
+
+// networkEntry is one request/response exchange. A redirect hop is its own entry (the
+// 30x response) followed by a fresh entry for the redirected request, as in HAR.
+type networkEntry struct {
+	RequestID    string
+	Wall         time.Time // request start, wall clock
+	StartTS      float64   // request start, CDP monotonic seconds
+	ResponseTS   float64   // response headers received (0 = none)
+	EndTS        float64   // loading finished / failed (0 = still pending)
+	Method       string
+	URL          string
+	ReqHeaders   map[string]string
+	PostData     string
+	ResourceType string
+	Status       int
+	StatusText   string
+	Protocol     string
+	MimeType     string
+	RespHeaders  map[string]string
+	BodySize     int64
+	RedirectURL  string
+	Error        string
+}
+
+// DurationMS is the request's total time in milliseconds, or -1 while it is pending.
+func (e *networkEntry) DurationMS() float64 {
+	if e.EndTS == 0 {
+		return -1
+	}
+	return (e.EndTS - e.StartTS) * 1000
+}
+
+// networkRecorder ingests Network.* events for one tab.
+type networkRecorder struct {
+	client  *CDPClient
+	mu      sync.Mutex
+	entries []*networkEntry
+	byID    map[string]*networkEntry
+}
+
+func newNetworkRecorder() *networkRecorder {
+	return &networkRecorder{byID: map[string]*networkEntry{}}
+}
+
+type cdpRequest struct {
+	URL      string            `json:"url"`
+	Method   string            `json:"method"`
+	Headers  map[string]string `json:"headers"`
+	PostData string            `json:"postData"`
+}
+
+type cdpResponse struct {
+	URL               string            `json:"url"`
+	Status            int               `json:"status"`
+	StatusText        string            `json:"statusText"`
+	Headers           map[string]string `json:"headers"`
+	MimeType          string            `json:"mimeType"`
+	Protocol          string            `json:"protocol"`
+	EncodedDataLength int64             `json:"encodedDataLength"`
+}
+
+// handle is the CDPClient.OnEvent callback; unrelated events are ignored.
+func (r *networkRecorder) handle(method string, params json.RawMessage) {
+	var ev struct {
+		RequestID         string       `json:"requestId"`
+		Timestamp         float64      `json:"timestamp"`
+		WallTime          float64      `json:"wallTime"`
+		Type              string       `json:"type"`
+		Request           *cdpRequest  `json:"request"`
+		Response          *cdpResponse `json:"response"`
+		RedirectResponse  *cdpResponse `json:"redirectResponse"`
+		EncodedDataLength int64        `json:"encodedDataLength"`
+		ErrorText         string       `json:"errorText"`
+	}
+	if !strings.HasPrefix(method, "Network.") || json.Unmarshal(params, &ev) != nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e := r.byID[ev.RequestID]
+	switch method {
+	case "Network.requestWillBeSent":
+		if ev.Request == nil {
+			return
+		}
+		if e != nil && ev.RedirectResponse != nil {
+			e.setResponse(ev.RedirectResponse, ev.Timestamp)
+			e.RedirectURL = ev.Request.URL
+			e.EndTS = ev.Timestamp
+		}
+		ne := &networkEntry{
+			RequestID:    ev.RequestID,
+			Wall:         time.UnixMilli(int64(math.Round(ev.WallTime * 1000))).UTC(),
+			StartTS:      ev.Timestamp,
+			Method:       ev.Request.Method,
+			URL:          ev.Request.URL,
+			ReqHeaders:   ev.Request.Headers,
+			PostData:     ev.Request.PostData,
+			ResourceType: ev.Type,
+		}
+		r.entries = append(r.entries, ne)
+		r.byID[ev.RequestID] = ne
+	case "Network.responseReceived":
+		if e != nil && ev.Response != nil {
+			e.setResponse(ev.Response, ev.Timestamp)
+		}
+	case "Network.loadingFinished":
+		if e != nil {
+			e.EndTS = ev.Timestamp
+			e.BodySize = ev.EncodedDataLength
+		}
+	case "Network.loadingFailed":
+		if e != nil {
+			e.EndTS = ev.Timestamp
+			e.Error = ev.ErrorText
+		}
+	}
+}
+
+func (e *networkEntry) setResponse(resp *cdpResponse, ts float64) {
+	e.ResponseTS = ts
+	e.Status = resp.Status
+	e.StatusText = resp.StatusText
+	e.Protocol = resp.Protocol
+	e.MimeType = resp.MimeType
+	e.RespHeaders = resp.Headers
+	e.BodySize = resp.EncodedDataLength
+}
+
+// snapshot returns a copy of the recorded entries in request order.
+func (r *networkRecorder) snapshot() []networkEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]networkEntry, len(r.entries))
+	for i, e := range r.entries {
+		out[i] = *e
+	}
+	return out
+}
+
+// runNetworkStart begins (or restarts) recording the tab's network traffic.
+func runNetworkStart(ep *cdpEndpoint, tabID string) (string, error) {
+	wsURL, err := resolveTabWS(ep.URL, tabID)
+	if err != nil {
+		return "", err
+	}
+	if old := takeNetworkRecorder(wsURL); old != nil {
+		old.client.Close()
+	}
+	client, err := NewCDPClient(wsURL)
+	if err != nil {
+		return "", err
+	}
+	rec := newNetworkRecorder()
+	rec.client = client
+	client.OnEvent(rec.handle)
+	if _, err := client.Call("Network.enable", nil); err != nil {
+		client.Close()
+		return "", fmt.Errorf("enabling network domain: %w", err)
+	}
+	networkRecorders.Lock()
+	networkRecorders.m[wsURL] = rec
+	networkRecorders.Unlock()
+	return fmt.Sprintf("Recording network traffic on tab %s\n", tabID), nil
+}
+
+func takeNetworkRecorder(wsURL string) *networkRecorder {
+	networkRecorders.Lock()
+	defer networkRecorders.Unlock()
+	rec := networkRecorders.m[wsURL]
+	delete(networkRecorders.m, wsURL)
+	return rec
+}
+
+// runNetworkStop ends the tab's recording, writes the HAR artifact, prints one
+// "STATUS METHOD URL (Nms)" line per request, and evaluates the network assertions.
+func runNetworkStop(ep *cdpEndpoint, op *spec.Op) (string, error) {
+	wsURL, err := resolveTabWS(ep.URL, op.Tab)
+	if err != nil {
+		return "", err
+	}
+	rec := takeNetworkRecorder(wsURL)
+	if rec == nil {
+		return "", fmt.Errorf("no network recording on tab %s (run network-start earlier in the same check run)", op.Tab)
+	}
+	_, _ = rec.client.Call("Network.disable", nil)
+	rec.client.Close()
+	entries := rec.snapshot()
+
+	data, err := json.MarshalIndent(buildHAR(entries), "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("encoding HAR: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(op.Artifact), 0o755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(op.Artifact, data, 0o644); err != nil {
+		return "", fmt.Errorf("writing HAR: %w", err)
+	}
+
+	var b strings.Builder
+	for i := range entries {
+		b.WriteString(entrySummary(&entries[i]))
+		b.WriteByte('\n')
+	}
+	fmt.Fprintf(&b, "HAR: %s (%d entries)\n", op.Artifact, len(entries))
+	if err := evalNetworkExpect(entries, op.NetworkExpect, op.NetworkAllowHosts); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// entrySummary renders one entry as "STATUS METHOD URL (Nms)"; a failed request shows
+// its error text, a pending one "pending".
+func entrySummary(e *networkEntry) string {
+	status := fmt.Sprint(e.Status)
+	switch {
+	case e.Error != "":
+		status = "ERR(" + e.Error + ")"
+	case e.EndTS == 0 && e.Status == 0:
+		status = "pending"
+	}
+	d := "pending"
+	if ms := e.DurationMS(); ms >= 0 {
+		d = fmt.Sprintf("%.0fms", ms)
+	}
+	return fmt.Sprintf("%s %s %s (%s)", status, e.Method, e.URL, d)
+}
+
+// ---------------------------------------------------------------------------
+// Assertions
+// ---------------------------------------------------------------------------
+
+// evalNetworkExpect checks every network_expect entry and the network_allow_hosts list
+// against the recording, returning all failures joined.
+func evalNetworkExpect(entries []networkEntry, expects []spec.CdpNetworkExpect, allowHosts []string) error {
+	var fails []string
+	for i, x := range expects {
+		if err := evalOneExpect(entries, x); err != nil {
+			fails = append(fails, fmt.Sprintf("network_expect[%d]: %v", i, err))
+		}
+	}
+	if len(allowHosts) > 0 {
+		seen := map[string]bool{}
+		for i := range entries {
+			u, err := url.Parse(entries[i].URL)
+			if err != nil || !networkScheme(u.Scheme) {
+				continue
+			}
+			h := strings.ToLower(u.Hostname())
+			if seen[h] || hostAllowed(h, allowHosts) {
+				continue
+			}
+			seen[h] = true
+			fails = append(fails, fmt.Sprintf("network_allow_hosts: request to forbidden host %s (%s %s)", h, entries[i].Method, entries[i].URL))
+		}
+	}
+	if len(fails) > 0 {
+		return fmt.Errorf("%s", strings.Join(fails, "; "))
+	}
+	return nil
+}
+
+func evalOneExpect(entries []networkEntry, x spec.CdpNetworkExpect) error {
+	var re *regexp.Regexp
+	if x.URL != "" {
+		var err error
+		if re, err = regexp.Compile(x.URL); err != nil {
+			return fmt.Errorf("bad url pattern: %w", err)
+		}
+	}
+	var matched []*networkEntry
+	for i := range entries {
+		e := &entries[i]
+		if re != nil && !re.MatchString(e.URL) {
+			continue
+		}
+		if x.Method != "" && !strings.EqualFold(x.Method, e.Method) {
+			continue
+		}
+		if x.Host != "" {
+			u, err := url.Parse(e.URL)
+			if err != nil || !hostAllowed(strings.ToLower(u.Hostname()), []string{x.Host}) {
+				continue
+			}
+		}
+		matched = append(matched, e)
+	}
+	desc := describeExpect(x)
+	if x.Absent {
+		if len(matched) > 0 {
+			return fmt.Errorf("%d request(s) matched %s, want none (first: %s)", len(matched), desc, entrySummary(matched[0]))
+		}
+		return nil
+	}
+	if len(matched) == 0 {
+		return fmt.Errorf("no request matched %s", desc)
+	}
+	for _, e := range matched {
+		if x.Status != 0 && e.Status != x.Status {
+			continue
+		}
+		if x.MaxMS > 0 {
+			if ms := e.DurationMS(); ms < 0 || ms > float64(x.MaxMS) {
+				continue
+			}
+		}
+		return nil
+	}
+	var got []string
+	for _, e := range matched {
+		got = append(got, entrySummary(e))
+	}
+	return fmt.Errorf("%d request(s) matched %s but none returned%s (got: %s)",
+		len(matched), desc, describeOutcome(x), strings.Join(got, "; "))
+}
+
+func describeExpect(x spec.CdpNetworkExpect) string {
+	var parts []string
+	if x.Method != "" {
+		parts = append(parts, "method="+strings.ToUpper(x.Method))
+	}
+	if x.Host != "" {
+		parts = append(parts, "host="+x.Host)
+	}
+	if x.URL != "" {
+		parts = append(parts, "url=~"+x.URL)
+	}
+	if len(parts) == 0 {
+		return "(any request)"
+	}
+	return strings.Join(parts, " ")
+}
+
+func describeOutcome(x spec.CdpNetworkExpect) string {
+	s := ""
+	if x.Status != 0 {
+		s += fmt.Sprintf(" status %d", x.Status)
+	}
+	if x.MaxMS > 0 {
+		s += fmt.Sprintf(" within %dms", x.MaxMS)
+	}
+	return s
+}
+
+// hostAllowed reports whether host matches one of patterns: an exact hostname, or
+// "*.example.com" (example.com itself and any subdomain).
+func hostAllowed(host string, patterns []string) bool {
+	for _, p := range patterns {
+		p = strings.ToLower(strings.TrimSpace(p))
+		if suffix, ok := strings.CutPrefix(p, "*."); ok {
+			if host == suffix || strings.HasSuffix(host, "."+suffix) {
+				return true
+			}
+			continue
+		}
+		if host == p {
+			return true
+		}
+	}
+	return false
+}
+
+// networkScheme is true for the schemes that leave the browser (data:, blob:, and
+// extension URLs are never subject to network_allow_hosts).
+func networkScheme(s string) bool {
+	switch s {
+	case "http", "https", "ws", "wss":
+		return true
+	}
+	return false
+}
+
+// ---------------------------------------------------------------------------
+// HAR 1.2
+// ---------------------------------------------------------------------------
+
+type harLog struct {
+	Log harBody `json:"log"`
+}
+
+type harBody struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	ResourceType    string      `json:"_resourceType,omitempty"`
+	Error           string      `json:"_error,omitempty"`
+}
+
+type harNV struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harRequest struct {
+	Method      string       `json:"method"`
+	URL         string       `json:"url"`
+	HTTPVersion string       `json:"httpVersion"`
+	Headers     []harNV      `json:"headers"`
+	QueryString []harNV      `json:"queryString"`
+	Cookies     []harNV      `json:"cookies"`
+	HeadersSize int          `json:"headersSize"`
+	BodySize    int          `json:"bodySize"`
+	PostData    *harPostData `json:"postData,omitempty"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harResponse struct {
+	Status      int        `json:"status"`
+	StatusText  string     `json:"statusText"`
+	HTTPVersion string     `json:"httpVersion"`
+	Headers     []harNV    `json:"headers"`
+	Cookies     []harNV    `json:"cookies"`
+	Content     harContent `json:"content"`
+	RedirectURL string     `json:"redirectURL"`
+	HeadersSize int        `json:"headersSize"`
+	BodySize    int64      `json:"bodySize"`
+}
+
+type harContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// buildHAR renders the recording as a HAR 1.2 log. Pending requests carry an
+// empty response and zero time: the spec allows -1 only for the optional
+// blocked/dns/connect/ssl timings, never for send, wait or receive.
+func buildHAR(entries []networkEntry) harLog {
+	out := harLog{Log: harBody{
+		Version: "1.2",
+		Creator: harCreator{Name: "charly plugin-cdp", Version: "1"},
+		Entries: make([]harEntry, 0, len(entries)),
+	}}
+	for i := range entries {
+		e := &entries[i]
+		he := harEntry{
+			StartedDateTime: e.Wall.Format(time.RFC3339Nano),
+			Time:            roundMS(e.DurationMS()),
+			Request: harRequest{
+				Method:      e.Method,
+				URL:         e.URL,
+				HTTPVersion: httpVersion(e.Protocol),
+				Headers:     harHeaders(e.ReqHeaders),
+				QueryString: harQuery(e.URL),
+				Cookies:     []harNV{},
+				HeadersSize: -1,
+				BodySize:    len(e.PostData),
+			},
+			Response: harResponse{
+				Status:      e.Status,
+				StatusText:  e.StatusText,
+				HTTPVersion: httpVersion(e.Protocol),
+				Headers:     harHeaders(e.RespHeaders),
+				Cookies:     []harNV{},
+				Content:     harContent{Size: e.BodySize, MimeType: e.MimeType},
+				RedirectURL: e.RedirectURL,
+				HeadersSize: -1,
+				BodySize:    e.BodySize,
+			},
+			ResourceType: strings.ToLower(e.ResourceType),
+			Error:        e.Error,
+		}
+		if e.PostData != "" {
+			he.Request.PostData = &harPostData{MimeType: e.ReqHeaders["Content-Type"], Text: e.PostData}
+		}
+		if e.EndTS != 0 {
+			wait := he.Time
+			if e.ResponseTS != 0 {
+				wait = roundMS((e.ResponseTS - e.StartTS) * 1000)
+			}
+			wait = math.Max(wait, 0)
+			he.Timings = harTimings{Wait: wait, Receive: math.Max(roundMS(he.Time-wait), 0)}
+		} else {
+			he.Time = 0
+		}
+		out.Log.Entries = append(out.Log.Entries, he)
+	}
+	return out
+}
+
+func roundMS(ms float64) float64 {
+	if ms < 0 {
+		return -1
+	}
+	return math.Round(ms*1000) / 1000
+}
+
+// httpVersion maps CDP's protocol names ("h2", "http/1.1") to HAR's form.
+func httpVersion(p string) string {
+	switch p {
+	case "":
+		return "HTTP/1.1"
+	case "h2":
+		return "HTTP/2"
+	case "h3":
+		return "HTTP/3"
+	}
+	return strings.ToUpper(p)
+}
+
+func harHeaders(h map[string]string) []harNV {
+	out := make([]harNV, 0, len(h))
+	for k, v := range h {
+		out = append(out, harNV{Name: k, Value: v})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+func harQuery(raw string) []harNV {
+	out := []harNV{}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return out
+	}
+	q := u.Query()
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range q[k] {
+			out = append(out, harNV{Name: k, Value: v})
+		}
+	}
+	return out
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/overthinkos/overthink/charly/spec"
+)
+
+// recordSample feeds a recorder the Network.* events of a page load: a 302 redirect to
+// the app, an API call, a failed tracker request, and a still-pending long poll.
+func recordSample(t *testing.T) []networkEntry {
+	t.Helper()
+	r := newNetworkRecorder()
+	ev := func(method, params string) {
+		r.handle(method, json.RawMessage(params))
+	}
+	ev("Network.requestWillBeSent", `{"requestId":"1","timestamp":100.0,"wallTime":1700000000.5,"type":"Document",
+		"request":{"url":"http://app.test/","method":"GET","headers":{"Accept":"text/html"}}}`)
+	ev("Network.requestWillBeSent", `{"requestId":"1","timestamp":100.02,"wallTime":1700000000.52,"type":"Document",
+		"request":{"url":"https://app.test/home?lang=en","method":"GET"},
+		"redirectResponse":{"status":302,"statusText":"Found","headers":{"Location":"https://app.test/home?lang=en"}}}`)
+	ev("Network.responseReceived", `{"requestId":"1","timestamp":100.05,"type":"Document",
+		"response":{"url":"https://app.test/home?lang=en","status":200,"statusText":"OK","mimeType":"text/html","protocol":"h2","headers":{"Content-Type":"text/html"}}}`)
+	ev("Network.loadingFinished", `{"requestId":"1","timestamp":100.08,"encodedDataLength":4096}`)
+	ev("Network.requestWillBeSent", `{"requestId":"2","timestamp":100.1,"wallTime":1700000000.6,"type":"Fetch",
+		"request":{"url":"https://api.app.test/v1/items","method":"POST","postData":"{}","headers":{"Content-Type":"application/json"}}}`)
+	ev("Network.responseReceived", `{"requestId":"2","timestamp":100.2,"type":"Fetch",
+		"response":{"url":"https://api.app.test/v1/items","status":201,"statusText":"Created","mimeType":"application/json"}}`)
+	ev("Network.loadingFinished", `{"requestId":"2","timestamp":100.22,"encodedDataLength":120}`)
+	ev("Network.requestWillBeSent", `{"requestId":"3","timestamp":100.3,"wallTime":1700000000.8,"type":"Script",
+		"request":{"url":"https://tracker.example.com/t.js","method":"GET"}}`)
+	ev("Network.loadingFailed", `{"requestId":"3","timestamp":100.31,"errorText":"net::ERR_BLOCKED_BY_CLIENT"}`)
+	ev("Network.requestWillBeSent", `{"requestId":"4","timestamp":100.4,"wallTime":1700000000.9,"type":"XHR",
+		"request":{"url":"https://api.app.test/poll","method":"GET"}}`)
+	ev("Page.loadEventFired", `{"timestamp":100.5}`)
+	return r.snapshot()
+}
+
+func TestNetworkRecorder_Events(t *testing.T) {
+	entries := recordSample(t)
+	if len(entries) != 5 {
+		t.Fatalf("entries = %d, want 5 (redirect hop + 4 requests)", len(entries))
+	}
+	hop := entries[0]
+	if hop.Status != 302 || hop.RedirectURL != "https://app.test/home?lang=en" || hop.EndTS != 100.02 {
+		t.Errorf("redirect hop = %+v", hop)
+	}
+	if got := entries[1].DurationMS(); got < 59.9 || got > 60.1 {
+		t.Errorf("document duration = %v, want 60ms", got)
+	}
+	if entries[3].Error != "net::ERR_BLOCKED_BY_CLIENT" {
+		t.Errorf("failed request error = %q", entries[3].Error)
+	}
+	if entries[4].DurationMS() != -1 {
+		t.Errorf("pending request duration = %v, want -1", entries[4].DurationMS())
+	}
+	if got := entrySummary(&entries[2]); got != "201 POST https://api.app.test/v1/items (120ms)" {
+		t.Errorf("summary = %q", got)
+	}
+}
+
+func TestBuildHAR(t *testing.T) {
+	data, err := json.Marshal(buildHAR(recordSample(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var har struct {
+		Log struct {
+			Version string `json:"version"`
+			Entries []struct {
+				StartedDateTime string  `json:"startedDateTime"`
+				Time            float64 `json:"time"`
+				Request         struct {
+					HTTPVersion string  `json:"httpVersion"`
+					QueryString []harNV `json:"queryString"`
+					PostData    *struct {
+						MimeType string `json:"mimeType"`
+					} `json:"postData"`
+				} `json:"request"`
+				Response struct {
+					Status      int    `json:"status"`
+					RedirectURL string `json:"redirectURL"`
+				} `json:"response"`
+				Timings harTimings `json:"timings"`
+			} `json:"entries"`
+		} `json:"log"`
+	}
+	if err := json.Unmarshal(data, &har); err != nil {
+		t.Fatal(err)
+	}
+	if har.Log.Version != "1.2" || len(har.Log.Entries) != 5 {
+		t.Fatalf("version %q, %d entries", har.Log.Version, len(har.Log.Entries))
+	}
+	doc := har.Log.Entries[1]
+	if doc.StartedDateTime != "2023-11-14T22:13:20.52Z" || doc.Request.HTTPVersion != "HTTP/2" {
+		t.Errorf("document entry = %+v", doc)
+	}
+	if len(doc.Request.QueryString) != 1 || doc.Request.QueryString[0] != (harNV{"lang", "en"}) {
+		t.Errorf("queryString = %+v", doc.Request.QueryString)
+	}
+	if doc.Timings.Wait != 30 || doc.Timings.Receive != 30 {
+		t.Errorf("timings = %+v, want wait 30 / receive 30", doc.Timings)
+	}
+	if har.Log.Entries[0].Response.RedirectURL == "" {
+		t.Error("redirect hop lost its redirectURL")
+	}
+	if pd := har.Log.Entries[2].Request.PostData; pd == nil || pd.MimeType != "application/json" {
+		t.Errorf("postData = %+v", pd)
+	}
+	if p := har.Log.Entries[4]; p.Time != 0 || p.Timings != (harTimings{}) {
+		t.Errorf("pending entry = %+v, want zero time/timings", p)
+	}
+}
+
+func TestEvalNetworkExpect(t *testing.T) {
+	entries := recordSample(t)
+	cases := []struct {
+		name    string
+		expects []spec.CdpNetworkExpect
+		allow   []string
+		wantErr string
+	}{
+		{name: "status within budget", expects: []spec.CdpNetworkExpect{{URL: `/v1/items$`, Method: "post", Status: 201, MaxMS: 150}}},
+		{name: "redirect hop matches", expects: []spec.CdpNetworkExpect{{Host: "app.test", Status: 302}}},
+		{name: "too slow", expects: []spec.CdpNetworkExpect{{URL: `/v1/items`, MaxMS: 50}},
+			wantErr: "none returned within 50ms (got: 201 POST"},
+		{name: "wrong status", expects: []spec.CdpNetworkExpect{{URL: `/v1/items`, Status: 200}},
+			wantErr: "none returned status 200"},
+		{name: "pending never meets max_ms", expects: []spec.CdpNetworkExpect{{URL: `/poll`, MaxMS: 100000}},
+			wantErr: "(pending)"},
+		{name: "no match", expects: []spec.CdpNetworkExpect{{URL: `/missing`}}, wantErr: "no request matched url=~/missing"},
+		{name: "forbidden host absent fails", expects: []spec.CdpNetworkExpect{{Host: "*.example.com", Absent: true}},
+			wantErr: "network_expect[0]: 1 request(s) matched host=*.example.com, want none"},
+		{name: "absent passes", expects: []spec.CdpNetworkExpect{{Host: "ads.test", Absent: true}}},
+		{name: "bad regexp", expects: []spec.CdpNetworkExpect{{URL: `(`}}, wantErr: "bad url pattern"},
+		{name: "allow list", allow: []string{"*.app.test"}, wantErr: "forbidden host tracker.example.com"},
+		{name: "allow list covers all", allow: []string{"*.app.test", "tracker.example.com"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := evalNetworkExpect(entries, tc.expects, tc.allow)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("err = %v, want containing %q", err, tc.wantErr)
+			}
+		})
+	}
+}
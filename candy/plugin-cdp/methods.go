@@ -82,6 +82,10 @@ var requiredModifiers = map[string][]string{
 	"spa-key":       {"tab", "key"},
 	"spa-key-combo": {"tab", "combo"},
 	"spa-mouse":     {"tab", "x", "y"},
+	"network-start": {"tab"},
+	"network-stop":  {"tab", "artifact"},
+	"console-start": {"tab"},
+	"console":       {"tab"},
 }
 
 func modifierZero(op *spec.Op, name string) bool {
@@ -129,6 +133,8 @@ func checkRequiredModifiers(method string, op *spec.Op) error {
 // its captured output. A returned error is the verb FAILING (the in-tree CLI Run()
 // returning an error → exit 1); provider.go maps it through the exit_status / stderr
 // matchers. The HTTP methods (status/open/list/close) hit the /json surface directly;
+// the network-capture and console-collection groups manage their own long-lived tab
+// connections (cdp_network.go, cdp_console.go);
 // every other method opens a per-tab CDP WebSocket.
 func dispatch(ep *cdpEndpoint, op *spec.Op) (string, error) {
 	method := string(op.Cdp)
@@ -145,6 +151,14 @@ func dispatch(ep *cdpEndpoint, op *spec.Op) (string, error) {
 		return runList(ep)
 	case "close":
 		return runClose(ep, op.Tab)
+	case "network-start":
+		return runNetworkStart(ep, op.Tab)
+	case "network-stop":
+		return runNetworkStop(ep, op)
+	case "console-start":
+		return runConsoleStart(ep, op.Tab)
+	case "console":
+		return runConsole(ep, op)
 	}
 
 	// WebSocket methods: connect the tab.
@@ -119,8 +119,9 @@ func (provider) Invoke(_ context.Context, req *pb.InvokeRequest) (*pb.InvokeRepl
 		return resultJSON("fail", fmt.Sprintf("cdp: %s: stderr: %v (got: %s)", method, err, preview(stderr)))
 	}
 
-	// Artifact validators run for the artifact-producing screenshot method.
-	if method == "screenshot" {
+	// Artifact validators run for the artifact-producing methods (screenshot PNG,
+	// network-stop HAR, console log).
+	if method == "screenshot" || method == "network-stop" || (method == "console" && op.Artifact != "") {
 		if err := sdk.RunArtifactValidators(&op); err != nil {
 			return resultJSON("fail", fmt.Sprintf("cdp: %s: %v", method, err))
 		}
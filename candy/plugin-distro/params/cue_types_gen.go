@@ -40,6 +40,10 @@ type DistroInput struct {
 
 	Version string `yaml:"version,omitempty" json:"version,omitempty"`
 
+	// package_arch lists the architectures the distro publishes packages for;
+	// absent = every architecture.
+	Package_arch []string `yaml:"package_arch,omitempty" json:"package_arch,omitempty"`
+
 	Bootstrap DsBootstrap `yaml:"bootstrap,omitempty" json:"bootstrap,omitempty"`
 
 	Workaround []string `yaml:"workaround,omitempty" json:"workaround,omitempty"`
@@ -94,6 +98,10 @@ type DsFormat struct {
 	Secondary bool `yaml:"secondary,omitempty" json:"secondary,omitempty"`
 
 	Local_pkg DsLocalPkg `yaml:"local_pkg,omitempty" json:"local_pkg,omitempty"`
+
+	Lock_query string `yaml:"lock_query,omitempty" json:"lock_query,omitempty"`
+
+	Pin_template string `yaml:"pin_template,omitempty" json:"pin_template,omitempty"`
 }
 
 // reproduces #PhaseSet / #PhaseTemplates (schema/_common.cue) standalone.
@@ -33,6 +33,9 @@
 	inherits?:         string & =~"^[a-z0-9]+(-[a-z0-9]+)*$"
 	inherit_packages?: bool
 	version?:          string & =~"^[0-9]+(\\.[0-9]+)*$"
+	// package_arch lists the architectures the distro publishes packages for;
+	// absent = every architecture.
+	package_arch?: [...("amd64" | "arm64")]
 	bootstrap?:        #DsBootstrap
 	workaround?: [...string]
 	format?: {[string]: #DsFormat}
@@ -61,6 +64,8 @@
 	validate?: [...#DsFormatRule]
 	secondary?: bool
 	local_pkg?: #DsLocalPkg
+	lock_query?:   string
+	pin_template?: string
 }
 
 #DsFormatRule: {
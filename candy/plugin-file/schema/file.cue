@@ -61,4 +61,4 @@
 #FileMatcher: (string | bool | number | #FileMatchOp)
 
 // #FileMatchOp mirrors the base #MatchOpMap: exactly one matcher operator key.
-#FileMatchOp: {equals: _} | {not_equals: _} | {contains: _} | {not_contains: _} | {matches: _} | {not_matches: _} | {lt: _} | {le: _} | {gt: _} | {ge: _}
+#FileMatchOp: {equals: _} | {not_equals: _} | {contains: _} | {not_contains: _} | {matches: _} | {not_matches: _} | {lt: _} | {le: _} | {gt: _} | {ge: _} | {json: _}
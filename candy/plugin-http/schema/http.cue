@@ -51,4 +51,4 @@
 #HttpMatcher: (string | bool | number | #HttpMatchOp)
 
 // #HttpMatchOp mirrors the base #MatchOpMap: exactly one matcher operator key.
-#HttpMatchOp: {equals: _} | {not_equals: _} | {contains: _} | {not_contains: _} | {matches: _} | {not_matches: _} | {lt: _} | {le: _} | {gt: _} | {ge: _}
+#HttpMatchOp: {equals: _} | {not_equals: _} | {contains: _} | {not_contains: _} | {matches: _} | {not_matches: _} | {lt: _} | {le: _} | {gt: _} | {ge: _} | {json: _}
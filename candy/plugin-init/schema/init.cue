@@ -1,5 +1,5 @@
 // The BUILT-IN `init` plugin's OWN CUE schema — the typed input for the `init` KIND
-// (the init-system vocabulary: supervisord/systemd, formerly a core `init:` kind
+// (the init-system vocabulary: supervisord/systemd/openrc, formerly a core `init:` kind
 // decoded into the typed core map uf.Init). SINGLE SOURCE for this plugin's params,
 // used two ways (the same contract the package-group/agent/module/sidecar/distro/builder
 // plugins and core `spec` use):
@@ -24,10 +24,10 @@
 	candy_field?: [...(string & !="")]
 	candy_file?: [...(string & !="")]
 	depends_candy?: string & !=""
-	requires_capability?: [...(string & =~"^[a-z][a-z0-9_]*$")]
+	requires_capability?: [...(string & =~"^[a-z][a-z0-9_]*(:[a-z0-9]+)?$")]
 
 	// The one mandatory field: the build model.
-	model: "fragment_assembly" | "file_copy"
+	model: "fragment_assembly" | "file_copy" | "file_render"
 
 	header_file?:    string & !=""
 	fragment_dir?:   string & !=""
@@ -45,4 +45,4 @@
 #KernelParamMatcher: (string | bool | number | #KernelParamMatchOp)
 
 // #KernelParamMatchOp mirrors the base #MatchOpMap: exactly one matcher operator key.
-#KernelParamMatchOp: {equals: _} | {not_equals: _} | {contains: _} | {not_contains: _} | {matches: _} | {not_matches: _} | {lt: _} | {le: _} | {gt: _} | {ge: _}
+#KernelParamMatchOp: {equals: _} | {not_equals: _} | {contains: _} | {not_contains: _} | {matches: _} | {not_matches: _} | {lt: _} | {le: _} | {gt: _} | {ge: _} | {json: _}
@@ -34,4 +34,4 @@
 #MatchingMatcher: (string | bool | number | #MatchingMatchOp)
 
 // #MatchingMatchOp mirrors the base #MatchOpMap: exactly one matcher operator key.
-#MatchingMatchOp: {equals: _} | {not_equals: _} | {contains: _} | {not_contains: _} | {matches: _} | {not_matches: _} | {lt: _} | {le: _} | {gt: _} | {ge: _}
+#MatchingMatchOp: {equals: _} | {not_equals: _} | {contains: _} | {not_contains: _} | {matches: _} | {not_matches: _} | {lt: _} | {le: _} | {gt: _} | {ge: _} | {json: _}
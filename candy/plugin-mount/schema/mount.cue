@@ -45,4 +45,4 @@
 #MountMatcher: (string | bool | number | #MountMatchOp)
 
 // #MountMatchOp mirrors the base #MatchOpMap: exactly one matcher operator key.
-#MountMatchOp: {equals: _} | {not_equals: _} | {contains: _} | {not_contains: _} | {matches: _} | {not_matches: _} | {lt: _} | {le: _} | {gt: _} | {ge: _}
+#MountMatchOp: {equals: _} | {not_equals: _} | {contains: _} | {not_contains: _} | {matches: _} | {not_matches: _} | {lt: _} | {le: _} | {gt: _} | {ge: _} | {json: _}
@@ -1,8 +1,8 @@
 // Package pkgverb is the importable, COMPILED-IN host-coupled `package` verb: the
 // TYPED-STEP state-provision verb (Go package pkgverb — `package` is a keyword). Three
 // roles on the charly/plugin/kit contract:
-//   - CheckVerbProvider: rpm -q / dpkg -s / pacman -Q probe + optional version match.
-//   - ProvisionActor (runtime act): render the dnf/apt-get/pacman install shell.
+//   - CheckVerbProvider: rpm -q / dpkg -s / pacman -Q / apk info probe + optional version match.
+//   - ProvisionActor (runtime act): render the dnf/apt-get/pacman/apk install shell.
 //   - StepProvider (build/deploy act): lower into a SystemPackagesStep (the host
 //     materializer resolves the format + cross-distro name, keeps Reverse() in package
 //     main). Relocated out of charly's module (formerly charly/plugin/builtins/package +
@@ -40,7 +40,7 @@ type verb struct{}
 
 func (verb) Reserved() string { return "package" }
 
-// RunVerb (do:assert) probes installed/version via rpm/dpkg/pacman through the live
+// RunVerb (do:assert) probes installed/version via rpm/dpkg/pacman/apk through the live
 // CheckContext. Mirrors r.runPackage.
 func (verb) RunVerb(ctx context.Context, cc kit.CheckContext, op *spec.Op) kit.Result {
 	var in params.PackageInput
@@ -52,7 +52,7 @@ func (verb) RunVerb(ctx context.Context, cc kit.CheckContext, op *spec.Op) kit.R
 	name := kit.ResolvePackageName(in.Package, in.PackageMap, cc.Distros())
 	pkgQ := kit.ShellQuote(name)
 	probe := fmt.Sprintf(
-		`rpm -q %[1]s >/dev/null 2>&1 || (dpkg -s %[1]s 2>/dev/null | grep -q "^Status:.*install ok installed") || pacman -Q %[1]s >/dev/null 2>&1`,
+		`rpm -q %[1]s >/dev/null 2>&1 || (dpkg -s %[1]s 2>/dev/null | grep -q "^Status:.*install ok installed") || pacman -Q %[1]s >/dev/null 2>&1 || apk info -e %[1]s >/dev/null 2>&1`,
 		pkgQ)
 	_, stderr, exit, err := cc.Exec().RunCapture(ctx, probe)
 	if err != nil {
@@ -67,7 +67,7 @@ func (verb) RunVerb(ctx context.Context, cc kit.CheckContext, op *spec.Op) kit.R
 	}
 	if len(in.Versions) > 0 {
 		versionProbe := fmt.Sprintf(
-			`rpm -q --qf '%%{VERSION}\n' %[1]s 2>/dev/null || dpkg -s %[1]s 2>/dev/null | awk '/^Version:/{print $2; exit}' || pacman -Q %[1]s 2>/dev/null | awk '{print $2}'`,
+			`rpm -q --qf '%%{VERSION}\n' %[1]s 2>/dev/null || { command -v apk >/dev/null 2>&1 && apk list -I %[1]s 2>/dev/null | awk -v p=%[1]s '{sub("^" p "-", "", $1); sub("-r[0-9]+$", "", $1); print $1; exit}'; } || dpkg -s %[1]s 2>/dev/null | awk '/^Version:/{print $2; exit}' || pacman -Q %[1]s 2>/dev/null | awk '{print $2}'`,
 			pkgQ)
 		ver, _, exit, err := cc.Exec().RunCapture(ctx, versionProbe)
 		if err != nil || exit != 0 {
@@ -91,6 +91,7 @@ func (verb) RenderProvisionScript(op *spec.Op, distros []string) (string, bool)
 	return fmt.Sprintf(`if command -v dnf >/dev/null 2>&1; then dnf install -y %[1]s; `+
 		`elif command -v apt-get >/dev/null 2>&1; then apt-get update && apt-get install -y %[1]s; `+
 		`elif command -v pacman >/dev/null 2>&1; then pacman -S --noconfirm %[1]s; `+
+		`elif command -v apk >/dev/null 2>&1; then apk add --no-cache %[1]s; `+
 		`else echo "no supported package manager" >&2; exit 1; fi`, name), true
 }
 
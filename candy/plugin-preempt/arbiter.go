@@ -72,7 +72,8 @@ func invokeArbiter(ctx context.Context, exec *sdk.Executor, in spec.ArbiterInvok
 		active, err := a.AcquireShared(in.Claimant, in.Tokens, in.ClaimAddr, in.Transient)
 		return spec.ArbiterInvokeReply{Active: active, Error: errStr(err)}
 	case spec.ArbiterActionRelease:
-		return spec.ArbiterInvokeReply{Error: errStr(a.ReleaseClaimant(in.Claimant, in.Success))}
+		lease, err := a.releaseClaimant(in.Claimant, in.Success)
+		return spec.ArbiterInvokeReply{Lease: lease, Error: errStr(err)}
 	case spec.ArbiterActionStatus:
 		ledger, stranded, err := a.Status()
 		return spec.ArbiterInvokeReply{Ledger: ledger, Stranded: stranded, Error: errStr(err)}
@@ -418,15 +419,23 @@ func (a *ResourceArbiter) applyMode(tokens []string, mode string) error {
 
 // ReleaseClaimant restores the holders a claimant's lease stopped and removes the lease.
 func (a *ResourceArbiter) ReleaseClaimant(claimant string, success bool) error {
+	_, err := a.releaseClaimant(claimant, success)
+	return err
+}
+
+// releaseClaimant is ReleaseClaimant returning the claimant's lease — released, or retained on a
+// partial restore; nil when it held none — so the in-core proxy journals preempt.release without
+// a second Status() round-trip.
+func (a *ResourceArbiter) releaseClaimant(claimant string, success bool) (*spec.PreemptLease, error) {
 	unlock, lerr := a.acquireArbiterLock()
 	if lerr != nil {
-		return fmt.Errorf("acquiring resource-arbiter lock: %w", lerr)
+		return nil, fmt.Errorf("acquiring resource-arbiter lock: %w", lerr)
 	}
 	defer func() { _ = unlock() }()
 
 	ledger, err := a.loadLedger()
 	if err != nil {
-		return err
+		return nil, err
 	}
 	idx := -1
 	for i, lz := range ledger.Leases {
@@ -436,7 +445,7 @@ func (a *ResourceArbiter) ReleaseClaimant(claimant string, success bool) error {
 		}
 	}
 	if idx < 0 {
-		return nil
+		return nil, nil
 	}
 	lease := ledger.Leases[idx]
 	remaining := make([]spec.PreemptLease, 0, len(ledger.Leases)-1)
@@ -444,10 +453,10 @@ func (a *ResourceArbiter) ReleaseClaimant(claimant string, success bool) error {
 	remaining = append(remaining, ledger.Leases[idx+1:]...)
 
 	if !a.releaseLeaseEffects(lease, remaining, success) {
-		return fmt.Errorf("could not restore all holders for %q — lease retained; retry with `charly preempt restore %s`", claimant, claimant)
+		return &lease, fmt.Errorf("could not restore all holders for %q — lease retained; retry with `charly preempt restore %s`", claimant, claimant)
 	}
 	ledger.Leases = remaining
-	return a.saveLedger(ledger)
+	return &lease, a.saveLedger(ledger)
 }
 
 // releaseLeaseEffects applies the side-effects of removing lease from a ledger whose post-removal
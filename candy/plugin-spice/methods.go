@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/overthinkos/overthink/charly/plugin/kit"
 	"github.com/overthinkos/overthink/charly/spec"
 )
 
@@ -17,20 +18,24 @@ import (
 // output through the shared sdk matcher pipeline + sdk.RunArtifactValidators (the
 // host-side matcher step does not run for an out-of-process verb). The
 // SPICE wire behaviour, the PC-AT scancode tables, and the status tokens are
-// unchanged, so a bed authored against the in-tree verb passes unchanged.
+// unchanged, so a bed authored against the in-tree verb passes unchanged. find-image /
+// click-image locate a reference image in the display frame via the shared
+// kit.LocateTemplate (identical across vnc/spice/wl).
 
 // requiredModifiers mirrors the in-tree spiceMethods required-field specs (the host's
 // validate-time + runtime required-modifier check keyed off the former in-proc
 // live-verb seam, which an external verb is not — so the check moves HERE, at
 // dispatch). click/mouse need x+y, type needs text, key needs the key name,
-// screenshot/cursor need the artifact output path.
+// screenshot/cursor need the artifact output path, find-image/click-image the template.
 var requiredModifiers = map[string][]string{
-	"screenshot": {"artifact"},
-	"cursor":     {"artifact"},
-	"click":      {"x", "y"},
-	"mouse":      {"x", "y"},
-	"type":       {"text"},
-	"key":        {"key"},
+	"screenshot":  {"artifact"},
+	"cursor":      {"artifact"},
+	"click":       {"x", "y"},
+	"mouse":       {"x", "y"},
+	"type":        {"text"},
+	"key":         {"key"},
+	"find-image":  {"template"},
+	"click-image": {"template"},
 }
 
 func modifierZero(op *spec.Op, name string) bool {
@@ -45,6 +50,8 @@ func modifierZero(op *spec.Op, name string) bool {
 		return op.Text == ""
 	case "key":
 		return op.KeyName == ""
+	case "template":
+		return op.Template == ""
 	}
 	return false
 }
@@ -86,6 +93,10 @@ func dispatch(s *SpiceSession, op *spec.Op) (string, error) {
 		return runType(s, op.Text)
 	case "key":
 		return runKey(s, op.KeyName)
+	case "find-image":
+		return runFindImage(s, op, false)
+	case "click-image":
+		return runFindImage(s, op, true)
 	}
 	return "", fmt.Errorf("unknown spice method %q", method)
 }
@@ -167,6 +178,39 @@ func runCursor(s *SpiceSession, artifact string) (string, error) {
 	return "", fmt.Errorf("no cursor data within %s", 5*time.Second)
 }
 
+// runFindImage waits for a display frame, locates op.Template in it (the shared kit
+// matcher), and returns the match as one JSON line ({"x","y",…,"score"}) so a
+// `capture_extract: json:.x` feeds a later step. click-image also clicks the match
+// center with op.Button.
+func runFindImage(s *SpiceSession, op *spec.Op, click bool) (string, error) {
+	if err := s.WaitForDisplay(5 * time.Second); err != nil {
+		return "", err
+	}
+	img := s.Display()
+	if img == nil {
+		return "", fmt.Errorf("no display frame available")
+	}
+	m, err := kit.LocateTemplate(img, op)
+	if err != nil {
+		return "", err
+	}
+	if click {
+		if err := s.WaitForInputs(5 * time.Second); err != nil {
+			return "", err
+		}
+		btn, err := spiceButtonCode(op.Button)
+		if err != nil {
+			return "", err
+		}
+		in := s.Inputs()
+		in.MousePosition(uint32(m.X), uint32(m.Y))
+		in.MouseDown(btn, uint32(m.X), uint32(m.Y))
+		time.Sleep(50 * time.Millisecond)
+		in.MouseUp(btn, uint32(m.X), uint32(m.Y))
+	}
+	return m.JSON(), nil
+}
+
 // runClick presses + releases a mouse button at (x,y).
 func runClick(s *SpiceSession, op *spec.Op) (string, error) {
 	if err := s.WaitForInputs(5 * time.Second); err != nil {
@@ -127,8 +127,9 @@ func (provider) Invoke(_ context.Context, req *pb.InvokeRequest) (*pb.InvokeRepl
 		return resultJSON("fail", fmt.Sprintf("spice: %s: stderr: %v (got: %s)", method, err, preview(stderr)))
 	}
 
-	// Artifact validators run for the two artifact-producing methods.
-	if method == "screenshot" || method == "cursor" {
+	// Artifact validators run for the two artifact-producing methods, and for a
+	// find-image/click-image that saved its searched frame.
+	if method == "screenshot" || method == "cursor" || (op.Artifact != "" && (method == "find-image" || method == "click-image")) {
 		if err := sdk.RunArtifactValidators(&op); err != nil {
 			return resultJSON("fail", fmt.Sprintf("spice: %s: %v", method, err))
 		}
@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/overthinkos/overthink/charly/plugin/kit"
 	"github.com/overthinkos/overthink/charly/spec"
 )
 
@@ -24,20 +25,25 @@ import (
 // Two in-tree extras did NOT move: `vnc passwd` (wayvnc auth is provisioned at DEPLOY time
 // by the wayvnc / sway-desktop-vnc candy, not the check verb) and the `vnc click`
 // --from-cdp/--from-sway/--from-x11 CLI-only coordinate-translation flags (the declarative
-// `vnc: click` uses x/y desktop-absolute coordinates directly).
+// `vnc: click` uses x/y desktop-absolute coordinates directly). When the target moves,
+// find-image/click-image locate a reference image in a fresh frame instead — the matcher
+// is the shared kit.LocateTemplate, identical across vnc/spice/wl.
 
 // requiredModifiers mirrors the in-tree vncMethods required-field specs (the host's
 // validate-time + runtime required-modifier check keyed off the former in-proc live-verb seam,
 // which an external verb is not — so the check moves HERE, at dispatch).
 // click/mouse need x+y, type needs text, key needs the key name, screenshot needs the
-// artifact output path, rfb needs the RFB sub-method.
+// artifact output path, rfb needs the RFB sub-method, find-image/click-image need the
+// template to locate.
 var requiredModifiers = map[string][]string{
-	"screenshot": {"artifact"},
-	"click":      {"x", "y"},
-	"mouse":      {"x", "y"},
-	"type":       {"text"},
-	"key":        {"key"},
-	"rfb":        {"method"},
+	"screenshot":  {"artifact"},
+	"click":       {"x", "y"},
+	"mouse":       {"x", "y"},
+	"type":        {"text"},
+	"key":         {"key"},
+	"rfb":         {"method"},
+	"find-image":  {"template"},
+	"click-image": {"template"},
 }
 
 func modifierZero(op *spec.Op, name string) bool {
@@ -54,6 +60,8 @@ func modifierZero(op *spec.Op, name string) bool {
 		return op.KeyName == ""
 	case "method":
 		return op.Method == ""
+	case "template":
+		return op.Template == ""
 	}
 	return false
 }
@@ -101,6 +109,10 @@ func dispatch(ep *vncEndpoint, op *spec.Op) (string, error) {
 		return runKey(c, op.KeyName)
 	case "rfb":
 		return runRfb(c, op)
+	case "find-image":
+		return runFindImage(c, op, false)
+	case "click-image":
+		return runFindImage(c, op, true)
 	}
 	return "", fmt.Errorf("unknown vnc method %q", method)
 }
@@ -138,6 +150,28 @@ func runScreenshot(c *VNCClient, artifact string) (string, error) {
 	return fmt.Sprintf("Screenshot saved to %s (%dx%d)", artifact, bnd.Dx(), bnd.Dy()), nil
 }
 
+// runFindImage captures a fresh framebuffer, locates op.Template in it (the shared
+// kit matcher), and returns the match as one JSON line ({"x","y",…,"score"}) so a
+// `capture_extract: json:.x` feeds a later step. click-image also clicks the match
+// center with op.Button.
+func runFindImage(c *VNCClient, op *spec.Op, click bool) (string, error) {
+	img, err := c.Screenshot()
+	if err != nil {
+		return "", fmt.Errorf("capturing framebuffer: %w", err)
+	}
+	m, err := kit.LocateTemplate(img, op)
+	if err != nil {
+		return "", err
+	}
+	if click {
+		if err := c.PointerClick(uint16(m.X), uint16(m.Y), vncButton(op.Button)); err != nil {
+			return "", fmt.Errorf("clicking at (%d, %d): %w", m.X, m.Y, err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return m.JSON(), nil
+}
+
 // runClick sends a pointer click at the (desktop-absolute) coordinates.
 func runClick(c *VNCClient, op *spec.Op) (string, error) {
 	if err := c.PointerClick(uint16(op.X), uint16(op.Y), vncButton(op.Button)); err != nil {
@@ -122,8 +122,9 @@ func (provider) Invoke(_ context.Context, req *pb.InvokeRequest) (*pb.InvokeRepl
 		return resultJSON("fail", fmt.Sprintf("vnc: %s: stderr: %v (got: %s)", method, err, preview(stderr)))
 	}
 
-	// Artifact validators run for the artifact-producing screenshot method.
-	if method == "screenshot" {
+	// Artifact validators run for the artifact-producing screenshot method, and for a
+	// find-image/click-image that saved its searched frame.
+	if method == "screenshot" || (op.Artifact != "" && (method == "find-image" || method == "click-image")) {
 		if err := sdk.RunArtifactValidators(&op); err != nil {
 			return resultJSON("fail", fmt.Sprintf("vnc: %s: %v", method, err))
 		}
@@ -65,6 +65,8 @@ var requiredModifiers = map[string][]string{
 	"sway-resize":    {"target"},
 	"sway-layout":    {"target"},
 	"sway-workspace": {"target"},
+	"find-image":     {"template"},
+	"click-image":    {"template"},
 }
 
 func modifierZero(op *spec.Op, name string) bool {
@@ -93,6 +95,8 @@ func modifierZero(op *spec.Op, name string) bool {
 		return op.Action == ""
 	case "artifact":
 		return op.Artifact == ""
+	case "template":
+		return op.Template == ""
 	}
 	return false
 }
@@ -124,6 +128,10 @@ func dispatch(ctx context.Context, ex *sdk.Executor, op *spec.Op) (string, error
 		return wlScreenshot(ctx, ex, op)
 	case "clipboard":
 		return wlClipboard(ctx, ex, op)
+	case "find-image":
+		return wlFindImage(ctx, ex, op, false)
+	case "click-image":
+		return wlFindImage(ctx, ex, op, true)
 	// side-effect actions
 	case "click":
 		return wlClick(ctx, ex, op)
@@ -383,6 +391,18 @@ func wlAtspi(ctx context.Context, ex *sdk.Executor, op *spec.Op) (string, error)
 // off the venue over the reverse channel (GetFile), and writes it to op.Artifact (the host
 // path) BEFORE the provider's RunArtifactValidators reads it.
 func wlScreenshot(ctx context.Context, ex *sdk.Executor, op *spec.Op) (string, error) {
+	data, err := wlCaptureFrame(ctx, ex)
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(op.Artifact, data, 0o644); err != nil {
+		return "", fmt.Errorf("writing screenshot to %s: %w", op.Artifact, err)
+	}
+	return fmt.Sprintf("Screenshot saved to %s (%d bytes)", op.Artifact, len(data)), nil
+}
+
+// wlCaptureFrame grabs one desktop frame in the venue and returns the PNG bytes.
+func wlCaptureFrame(ctx context.Context, ex *sdk.Executor) ([]byte, error) {
 	var captureCmd string
 	switch {
 	case ex.VenueHasTool(ctx, "pixelflux-screenshot"):
@@ -390,20 +410,42 @@ func wlScreenshot(ctx context.Context, ex *sdk.Executor, op *spec.Op) (string, e
 	case ex.VenueHasTool(ctx, "grim"):
 		captureCmd = "grim -o HEADLESS-1 " + kit.ShellQuote(screenshotVenuePath)
 	default:
-		return "", fmt.Errorf("no screenshot tool available (need pixelflux-screenshot or grim)")
+		return nil, fmt.Errorf("no screenshot tool available (need pixelflux-screenshot or grim)")
 	}
 	if _, err := wlCapture(ctx, ex, captureCmd); err != nil {
-		return "", fmt.Errorf("capturing screenshot: %w", err)
+		return nil, fmt.Errorf("capturing screenshot: %w", err)
 	}
 	data, err := ex.GetFile(ctx, screenshotVenuePath, false)
 	if err != nil {
-		return "", fmt.Errorf("pulling screenshot: %w (file: %s)", err, screenshotVenuePath)
-	}
-	if err := os.WriteFile(op.Artifact, data, 0o644); err != nil {
-		return "", fmt.Errorf("writing screenshot to %s: %w", op.Artifact, err)
+		return nil, fmt.Errorf("pulling screenshot: %w (file: %s)", err, screenshotVenuePath)
 	}
 	_ = ex.VenueRunSilent(ctx, "rm -f "+kit.ShellQuote(screenshotVenuePath))
-	return fmt.Sprintf("Screenshot saved to %s (%d bytes)", op.Artifact, len(data)), nil
+	return data, nil
+}
+
+// wlFindImage captures a fresh frame, locates op.Template in it (the shared kit matcher),
+// and returns the match as one JSON line ({"x","y",…,"score"}) so a
+// `capture_extract: json:.x` feeds a later step. click-image also clicks the match center
+// with op.Button (the same wlrctl path as `wl: click`).
+func wlFindImage(ctx context.Context, ex *sdk.Executor, op *spec.Op, click bool) (string, error) {
+	data, err := wlCaptureFrame(ctx, ex)
+	if err != nil {
+		return "", err
+	}
+	img, err := kit.DecodeImage(data)
+	if err != nil {
+		return "", err
+	}
+	m, err := kit.LocateTemplate(img, op)
+	if err != nil {
+		return "", err
+	}
+	if click {
+		if _, err := wlClickAt(ctx, ex, "click-image", m.X, m.Y, op.Button); err != nil {
+			return "", err
+		}
+	}
+	return m.JSON(), nil
 }
 
 // wlClipboard reads or writes the Wayland clipboard via wl-clipboard.
@@ -435,21 +477,27 @@ func wlClipboard(ctx context.Context, ex *sdk.Executor, op *spec.Op) (string, er
 // ---------------------------------------------------------------------------
 
 func wlClick(ctx context.Context, ex *sdk.Executor, op *spec.Op) (string, error) {
+	return wlClickAt(ctx, ex, "click", op.X, op.Y, op.Button)
+}
+
+// wlClickAt moves the pointer to (x, y) and clicks button — shared by click and
+// click-image (method names the caller in the KWin refusal).
+func wlClickAt(ctx context.Context, ex *sdk.Executor, method string, x, y int, button string) (string, error) {
 	if detectCompositor(ctx, ex) == "kwin" {
-		return "", errKWinPointerUnsupported("click")
+		return "", errKWinPointerUnsupported(method)
 	}
-	btn := wlButton(op.Button)
+	btn := wlButton(button)
 	if btn == "" {
-		return "", fmt.Errorf("unknown button %q (valid: left, right, middle)", op.Button)
+		return "", fmt.Errorf("unknown button %q (valid: left, right, middle)", button)
 	}
 	cmd := fmt.Sprintf(
 		"wlrctl pointer move -10000 -10000 && wlrctl pointer move %d %d && sleep 0.05 && wlrctl pointer click %s",
-		op.X, op.Y, btn,
+		x, y, btn,
 	)
 	if _, err := wlCapture(ctx, ex, cmd); err != nil {
-		return "", fmt.Errorf("clicking at (%d, %d): %w", op.X, op.Y, err)
+		return "", fmt.Errorf("clicking at (%d, %d): %w", x, y, err)
 	}
-	return fmt.Sprintf("Clicked %s at (%d, %d)", btnName(op.Button), op.X, op.Y), nil
+	return fmt.Sprintf("Clicked %s at (%d, %d)", btnName(button), x, y), nil
 }
 
 func wlDoubleClick(ctx context.Context, ex *sdk.Executor, op *spec.Op) (string, error) {
@@ -37,6 +37,8 @@ func TestCheckRequiredModifiers(t *testing.T) {
 		{"sway-tree", spec.Op{Wl: "sway-tree"}, ""},
 		{"overlay-show", spec.Op{Wl: "overlay-show"}, "text"},
 		{"overlay-show", spec.Op{Wl: "overlay-show", Text: "hello"}, ""},
+		{"find-image", spec.Op{Wl: "find-image"}, "template"},
+		{"click-image", spec.Op{Wl: "click-image", Template: "/tmp/ok.png"}, ""},
 	}
 	for _, tc := range cases {
 		err := sdk.CheckRequiredModifiers(tc.method, &tc.op, requiredModifiers, modifierZero)
@@ -51,10 +51,15 @@ func TestArbiterExternalizedDispatch_AcquirePersistsAndSurfaces(t *testing.T) {
 		t.Fatalf("lease did not surface the claimant + token: %+v", lz)
 	}
 
-	// Release restores (no holders → no-op) and clears the lease.
+	// Release restores (no holders → no-op) and clears the lease; the reply's lease is journaled.
+	journal := withEventJournal(t, "")
 	if rerr := newResourceArbiter().ReleaseClaimant(claimant, true); rerr != nil {
 		t.Fatalf("proxy ReleaseClaimant: %v", rerr)
 	}
+	evs, _, _ := readJournalFrom(journal, 0)
+	if len(evs) != 1 || evs[0].Type != EventPreemptRelease || evs[0].Deploy != claimant || evs[0].Detail["tokens"] != "test-lock" {
+		t.Fatalf("release events = %+v", evs)
+	}
 	ledger, _, _ = newResourceArbiter().Status()
 	if len(ledger.Leases) != 0 {
 		t.Fatalf("lease should be gone after release, got %+v", ledger.Leases)
@@ -47,13 +47,17 @@ func (h *arbiterHostServer) dispatch(action string, params []byte) ([]byte, erro
 		if err := json.Unmarshal(params, &req); err != nil {
 			return nil, fmt.Errorf("arbiter stop seam: decode: %w", err)
 		}
-		return marshalJSON(spec.ArbiterErrReply{Error: errString(h.stopAndWait(req.Addr))})
+		err := h.stopAndWait(req.Addr)
+		recordOutcome(holderEvent(EventPreemptStop, req.Addr), err)
+		return marshalJSON(spec.ArbiterErrReply{Error: errString(err)})
 	case spec.ArbiterSeamStart:
 		var req spec.ArbiterHolderReq
 		if err := json.Unmarshal(params, &req); err != nil {
 			return nil, fmt.Errorf("arbiter start seam: decode: %w", err)
 		}
-		return marshalJSON(spec.ArbiterErrReply{Error: errString(holderStart(req.Addr))})
+		err := holderStart(req.Addr)
+		recordOutcome(holderEvent(EventPreemptRestore, req.Addr), err)
+		return marshalJSON(spec.ArbiterErrReply{Error: errString(err)})
 	case spec.ArbiterSeamSwitch:
 		var req spec.ArbiterSwitchReq
 		if err := json.Unmarshal(params, &req); err != nil {
@@ -112,3 +116,9 @@ func (h *arbiterHostServer) stopAndWait(addr spec.HolderAddr) error {
 	}
 	return nil
 }
+
+// holderEvent is the journal event of the arbiter stopping (preempt.stop) or
+// restoring (preempt.restore) one holder.
+func holderEvent(typ string, addr spec.HolderAddr) Event {
+	return Event{Type: typ, Deploy: addr.Name, Kind: SubstrateKind(addr.Target)}
+}
@@ -0,0 +1,336 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// box_audit.go — `charly box audit <box>`, an offline vulnerability report of a
+// built box.
+//
+// The inventory is the box's SBOM (readBoxSBOM): distro packages and the
+// pixi/npm/cargo builder outputs read from the image by the lock_query
+// scripts, each attributed to its candy of origin. Every component whose source
+// maps onto an OSV ecosystem — the distro for rpm/deb/pac/apk packages,
+// builderEcosystems for builder outputs — is matched against a local OSV
+// database (osv.go); aur, localpkg and download components, and ecosystems the
+// database has no records for, are reported as not covered.
+//
+// The `audit:` block (AuditConfig) of the box, else of `defaults:`, names the
+// database, the default --fail-on gate and the accepted findings (`ignore:`,
+// each with an optional expiry date). With --fail-on the command exits non-zero
+// when a finding at or above that severity (or of unknown severity) remains,
+// so a check step running `charly box audit <box> --fail-on high` with
+// `exit_status: 0` gates a bed.
+
+// BoxAuditCmd implements `charly box audit`.
+type BoxAuditCmd struct {
+	Box    string `arg:"" help:"Box name (must be built)"`
+	DB     string `long:"db" help:"OSV database: a directory of OSV JSON records or a .tar/.tar.gz/.tgz/.zip of one (default: audit.db)"`
+	FailOn string `long:"fail-on" help:"Exit non-zero on a finding at or above this severity, or of unknown severity: none|low|medium|high|critical (default: audit.fail_on, else none)"`
+	Format string `long:"format" default:"text" enum:"text,json" help:"Output format (text|json)"`
+}
+
+func (c *BoxAuditCmd) Run() error {
+	dir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	gen, err := NewGenerator(dir, "", boxResolveOpts([]string{c.Box}, false))
+	if err != nil {
+		return err
+	}
+	img, ok := gen.Boxes[c.Box]
+	if !ok {
+		return fmt.Errorf("box %q not found", c.Box)
+	}
+	policy := gen.Config.auditPolicy(c.Box)
+	dbPath, failOn := c.DB, c.FailOn
+	if policy != nil {
+		if dbPath == "" && policy.Db != "" {
+			dbPath = filepath.Join(dir, policy.Db)
+		}
+		if failOn == "" {
+			failOn = policy.FailOn
+		}
+	}
+	if dbPath == "" {
+		return fmt.Errorf("no OSV database: pass --db or set audit.db under defaults:")
+	}
+	if failOn != "" && failOn != "none" && severityRank(failOn) < 1 {
+		return fmt.Errorf("--fail-on must be one of none|low|medium|high|critical, got %q", failOn)
+	}
+	db, err := loadOSVDB(dbPath)
+	if err != nil {
+		return err
+	}
+	rt, err := ResolveRuntime()
+	if err != nil {
+		return err
+	}
+	ref, err := resolveLocalImageRef(rt.BuildEngine, c.Box)
+	if err != nil {
+		return fmt.Errorf("box %s is not built (run `charly box build %s` first): %w", c.Box, c.Box, err)
+	}
+	sbom, err := readBoxSBOM(rt.BuildEngine, gen, c.Box, ref)
+	if err != nil {
+		return err
+	}
+	distro := ""
+	if len(img.Distro) > 0 {
+		distro = img.Distro[0]
+	}
+	report := auditBox(sbom, distro, db, policy, time.Now())
+	report.Database = dbPath
+	for _, e := range report.Expired {
+		fmt.Fprintf(os.Stderr, "Warning: audit.ignore %s expired on %s\n", e.Id, e.Until)
+	}
+	if err := writeAuditReport(os.Stdout, report, c.Format); err != nil {
+		return err
+	}
+	return report.gate(failOn)
+}
+
+// auditPolicy returns the effective audit: block of a box — its own, else the
+// project default.
+func (c *Config) auditPolicy(box string) *AuditConfig {
+	if c == nil {
+		return nil
+	}
+	if b, ok := c.Box[box]; ok && b.Audit != nil {
+		return b.Audit
+	}
+	return c.Defaults.Audit
+}
+
+// auditReport is the result of auditing one image.
+type auditReport struct {
+	Box       string              `json:"box"`
+	Image     string              `json:"image"`
+	Database  string              `json:"database"`
+	Records   int                 `json:"records"`
+	Checked   int                 `json:"checked"`
+	Findings  []auditFinding      `json:"findings"`
+	Ignored   []auditFinding      `json:"ignored,omitempty"`
+	Expired   []AuditIgnoreConfig `json:"expired_ignores,omitempty"`
+	Uncovered map[string]int      `json:"uncovered,omitempty"` // source → components not checked
+}
+
+// auditFinding is one vulnerability of one installed component.
+type auditFinding struct {
+	ID        string             `json:"id"`
+	Aliases   []string           `json:"aliases,omitempty"`
+	Summary   string             `json:"summary,omitempty"`
+	Severity  string             `json:"severity"`
+	Score     float64            `json:"cvss_score,omitempty"`
+	Package   string             `json:"package"`
+	Version   string             `json:"version"`
+	Source    string             `json:"source"`
+	Ecosystem string             `json:"ecosystem"`
+	Fixed     string             `json:"fixed,omitempty"`
+	Candy     []string           `json:"candy,omitempty"` // empty = base image
+	Ignore    *AuditIgnoreConfig `json:"ignore,omitempty"`
+}
+
+// auditBox matches an SBOM against the database. distro is the box's primary
+// distro tag (debian:13); now decides which ignore entries have expired.
+func auditBox(sbom *boxSBOM, distro string, db *osvDB, policy *AuditConfig, now time.Time) *auditReport {
+	report := &auditReport{Box: sbom.Box, Image: sbom.Image, Records: db.Records, Uncovered: map[string]int{}}
+	var ignores []AuditIgnoreConfig
+	if policy != nil {
+		today := now.Format(time.DateOnly)
+		for _, ig := range policy.Ignore {
+			if ig.Until != "" && ig.Until < today {
+				report.Expired = append(report.Expired, ig)
+				continue
+			}
+			ignores = append(ignores, ig)
+		}
+	}
+	for _, comp := range sbom.Components {
+		eco, release := auditEcosystem(comp.Source, distro)
+		if eco == "" || db.index[eco] == nil || comp.Version == "" {
+			report.Uncovered[comp.Source]++
+			continue
+		}
+		report.Checked++
+		for _, m := range db.lookup(eco, release, auditPackageName(db, eco, comp), comp.Version) {
+			severity, score := osvSeverityOf(m)
+			f := auditFinding{
+				ID: m.Record.ID, Aliases: m.Record.Aliases, Summary: m.Record.Summary,
+				Severity: severity, Score: score,
+				Package: comp.Name, Version: comp.Version, Source: comp.Source,
+				Ecosystem: m.Eco, Fixed: m.Fixed, Candy: comp.Candy,
+			}
+			if ig := matchAuditIgnore(ignores, f); ig != nil {
+				f.Ignore = ig
+				report.Ignored = append(report.Ignored, f)
+				continue
+			}
+			report.Findings = append(report.Findings, f)
+		}
+	}
+	for _, list := range [][]auditFinding{report.Findings, report.Ignored} {
+		sort.SliceStable(list, func(i, j int) bool {
+			if ri, rj := severityRank(list[i].Severity), severityRank(list[j].Severity); ri != rj {
+				return ri > rj
+			}
+			if list[i].ID != list[j].ID {
+				return list[i].ID < list[j].ID
+			}
+			return list[i].Package < list[j].Package
+		})
+	}
+	return report
+}
+
+// auditEcosystem returns the OSV ecosystem key (and release, for distro
+// packages) of a component source, or "" when none applies.
+func auditEcosystem(source, distro string) (eco, release string) {
+	switch source {
+	case "rpm", "deb", "pac", "apk":
+		name, version, _ := strings.Cut(distro, ":")
+		if e, ok := distroEcosystems[name]; ok {
+			return e, version
+		}
+		return name, version
+	}
+	return builderEcosystems[source], ""
+}
+
+// auditPackageName is the name comp's advisories are keyed by. Debian and
+// Ubuntu records name the SOURCE package (libssl3 is covered under openssl),
+// so a component's source package is looked up when the database knows it,
+// falling back to the binary name.
+func auditPackageName(db *osvDB, eco string, comp sbomComponent) string {
+	if comp.SourcePackage != "" && len(db.index[eco][osvPackageKey(eco, comp.SourcePackage)]) > 0 {
+		return comp.SourcePackage
+	}
+	return comp.Name
+}
+
+// matchAuditIgnore returns the ignore entry covering a finding: its ID or an
+// alias, and its package when the entry names one.
+func matchAuditIgnore(ignores []AuditIgnoreConfig, f auditFinding) *AuditIgnoreConfig {
+	for i, ig := range ignores {
+		if ig.Package != "" && ig.Package != f.Package {
+			continue
+		}
+		if ig.Id == f.ID || slices.Contains(f.Aliases, ig.Id) {
+			return &ignores[i]
+		}
+	}
+	return nil
+}
+
+// gate returns an error when a finding is at or above the failOn severity
+// ("" or none never fails). A finding of unknown severity counts against any
+// threshold: an advisory that carries no rating is not evidence it is minor.
+func (r *auditReport) gate(failOn string) error {
+	threshold := severityRank(failOn)
+	if failOn == "" || failOn == "none" || threshold < 0 {
+		return nil
+	}
+	n := 0
+	for _, f := range r.Findings {
+		if rank := severityRank(f.Severity); rank >= threshold || rank <= 0 {
+			n++
+		}
+	}
+	if n > 0 {
+		return fmt.Errorf("box %s: %d finding(s) at or above %s", r.Box, n, failOn)
+	}
+	return nil
+}
+
+// writeAuditReport renders the report; text groups the findings by candy of
+// origin (a component declared by several candies is listed under each).
+func writeAuditReport(w io.Writer, r *auditReport, format string) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	case "text", "":
+	default:
+		return fmt.Errorf("unknown audit format %q (want text or json)", format)
+	}
+	fmt.Fprintf(w, "box %s (%s): %d components checked against %d OSV records\n", r.Box, r.Image, r.Checked, r.Records)
+	byCandy := map[string][]auditFinding{}
+	for _, f := range r.Findings {
+		if len(f.Candy) == 0 {
+			byCandy[""] = append(byCandy[""], f)
+		}
+		for _, cn := range f.Candy {
+			byCandy[cn] = append(byCandy[cn], f)
+		}
+	}
+	for _, cn := range sortedMapKeys(byCandy) {
+		if cn == "" {
+			continue
+		}
+		fmt.Fprintf(w, "\ncandy %s (%d):\n", cn, len(byCandy[cn]))
+		writeAuditFindings(w, byCandy[cn])
+	}
+	if base := byCandy[""]; len(base) > 0 {
+		fmt.Fprintf(w, "\nbase image (%d):\n", len(base))
+		writeAuditFindings(w, base)
+	}
+	if len(r.Ignored) > 0 {
+		fmt.Fprintf(w, "\nignored (%d):\n", len(r.Ignored))
+		for _, f := range r.Ignored {
+			until := "no expiry"
+			if f.Ignore.Until != "" {
+				until = "until " + f.Ignore.Until
+			}
+			fmt.Fprintf(w, "  %s %s %s (%s)", f.ID, f.Package, f.Version, until)
+			if f.Ignore.Reason != "" {
+				fmt.Fprintf(w, ": %s", f.Ignore.Reason)
+			}
+			fmt.Fprintln(w)
+		}
+	}
+	if len(r.Uncovered) > 0 {
+		var parts []string
+		for _, src := range sortedMapKeys(r.Uncovered) {
+			parts = append(parts, fmt.Sprintf("%s %d", src, r.Uncovered[src]))
+		}
+		fmt.Fprintf(w, "\nnot covered by the database: %s\n", strings.Join(parts, ", "))
+	}
+	counts := map[string]int{}
+	for _, f := range r.Findings {
+		counts[f.Severity]++
+	}
+	var summary []string
+	for i := len(severityLevels) - 1; i >= 0; i-- {
+		if n := counts[severityLevels[i]]; n > 0 {
+			summary = append(summary, fmt.Sprintf("%d %s", n, severityLevels[i]))
+		}
+	}
+	if len(summary) == 0 {
+		fmt.Fprintln(w, "\nno findings")
+		return nil
+	}
+	fmt.Fprintf(w, "\n%d findings: %s\n", len(r.Findings), strings.Join(summary, ", "))
+	return nil
+}
+
+func writeAuditFindings(w io.Writer, findings []auditFinding) {
+	for _, f := range findings {
+		fmt.Fprintf(w, "  %-8s %s %s %s (%s)", strings.ToUpper(f.Severity), f.ID, f.Package, f.Version, f.Source)
+		if f.Fixed != "" {
+			fmt.Fprintf(w, " fixed in %s", f.Fixed)
+		}
+		if f.Summary != "" {
+			fmt.Fprintf(w, " — %s", f.Summary)
+		}
+		fmt.Fprintln(w)
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testAuditSBOM() *boxSBOM {
+	return &boxSBOM{
+		Box:   "app",
+		Image: "ghcr.io/test/app:2026.100.1",
+		Components: []sbomComponent{
+			{Name: "openssl", Version: "3.5.0-2", Source: "deb"},
+			{Name: "lodash", Version: "4.17.20", Source: "npm", Candy: []string{"web", "docs"}},
+			{Name: "pyyaml", Version: "6.0", Source: "pixi", Candy: []string{"py"}},
+			{Name: "yay", Version: "12.0", Source: "aur", Candy: []string{"aur-tools"}},
+		},
+	}
+}
+
+func TestAuditBox(t *testing.T) {
+	dir := t.TempDir()
+	writeOSVRecords(t, dir, testOSVRecords)
+	db, err := loadOSVDB(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	policy := &AuditConfig{Ignore: []AuditIgnoreConfig{
+		{Id: "GHSA-x", Package: "lodash", Until: "2026-10-16", Reason: "patched in the web candy next sprint"},
+		{Id: "CVE-2026-1000", Until: "2026-10-15"},
+	}}
+
+	r := auditBox(testAuditSBOM(), "debian:13", db, policy, now)
+	if r.Checked != 3 || r.Uncovered["aur"] != 1 {
+		t.Errorf("checked %d, uncovered %v", r.Checked, r.Uncovered)
+	}
+	// The expired alias entry no longer hides DSA-1; the lodash entry runs
+	// through its until: day.
+	if len(r.Findings) != 1 || r.Findings[0].ID != "DSA-1" || r.Findings[0].Severity != "critical" {
+		t.Fatalf("findings = %+v", r.Findings)
+	}
+	if len(r.Ignored) != 1 || r.Ignored[0].ID != "GHSA-x" {
+		t.Errorf("ignored = %+v", r.Ignored)
+	}
+	if len(r.Expired) != 1 || r.Expired[0].Id != "CVE-2026-1000" {
+		t.Errorf("expired = %+v", r.Expired)
+	}
+
+	if err := r.gate("critical"); err == nil {
+		t.Error("critical finding passed --fail-on critical")
+	}
+	if err := r.gate("none"); err != nil {
+		t.Errorf("--fail-on none failed: %v", err)
+	}
+	// An unrated advisory fails every threshold rather than slipping below low.
+	unrated := &auditReport{Box: "web", Findings: []auditFinding{{ID: "OSV-9", Severity: "unknown"}}}
+	for _, level := range []string{"low", "high", "critical"} {
+		if err := unrated.gate(level); err == nil {
+			t.Errorf("unknown-severity finding passed --fail-on %s", level)
+		}
+	}
+	if err := unrated.gate("none"); err != nil {
+		t.Errorf("--fail-on none failed on an unknown finding: %v", err)
+	}
+
+	// A day later the lodash finding is back, listed under both its candies.
+	r = auditBox(testAuditSBOM(), "debian:13", db, policy, now.AddDate(0, 0, 1))
+	if len(r.Findings) != 2 || r.Findings[1].ID != "GHSA-x" {
+		t.Fatalf("findings after expiry = %+v", r.Findings)
+	}
+	var buf bytes.Buffer
+	if err := writeAuditReport(&buf, r, "text"); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"candy docs (1):\n  MEDIUM   GHSA-x lodash 4.17.20 (npm) fixed in 4.17.21 — prototype pollution",
+		"candy web (1):",
+		"base image (1):\n  CRITICAL DSA-1 openssl 3.5.0-2 (deb) fixed in 3.5.1-1 — openssl overflow",
+		"not covered by the database: aur 1",
+		"2 findings: 1 critical, 1 medium",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report lacks %q:\n%s", want, out)
+		}
+	}
+}
+
+// Debian advisories are keyed by source package: a binary package is looked
+// up under the source the SBOM recorded for it, and by its own name when the
+// database has nothing under that source.
+func TestAuditBox_DebSourcePackage(t *testing.T) {
+	dir := t.TempDir()
+	writeOSVRecords(t, dir, testOSVRecords)
+	db, err := loadOSVDB(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sbom := &boxSBOM{Box: "app", Components: []sbomComponent{
+		{Name: "libssl3", Version: "3.5.0-2", Source: "deb", SourcePackage: "openssl"},
+		{Name: "openssl", Version: "3.5.0-2", Source: "deb", SourcePackage: "unknown-src"},
+	}}
+	r := auditBox(sbom, "debian:13", db, nil, time.Now())
+	if len(r.Findings) != 2 || r.Findings[0].Package != "libssl3" || r.Findings[1].Package != "openssl" {
+		t.Fatalf("findings = %+v, want DSA-1 for libssl3 (via openssl) and openssl (by its own name)", r.Findings)
+	}
+}
+
+func TestValidateAuditPolicy(t *testing.T) {
+	cfg := &Config{
+		Defaults: BoxConfig{Audit: &AuditConfig{FailOn: "severe", Ignore: []AuditIgnoreConfig{{Id: "CVE-1", Until: "next week"}}}},
+		Box:      map[string]BoxConfig{"app": {Audit: &AuditConfig{FailOn: "high", Ignore: []AuditIgnoreConfig{{Until: "2026-12-31"}}}}},
+	}
+	errs := &ValidationError{}
+	validateAuditPolicy(cfg, errs)
+	if len(errs.Errors) != 3 {
+		t.Errorf("validateAuditPolicy errors = %v, want 3", errs.Errors)
+	}
+}
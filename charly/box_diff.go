@@ -0,0 +1,510 @@
+package main
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+)
+
+// box_diff.go — `charly box diff <ref-a> <ref-b>`, what changed between two
+// built versions of a box.
+//
+// Both images are loaded from local container storage the same way
+// `charly box merge` loads them (loadImageFromDaemon), so the comparison reads
+// the saved image, not a running container. Three views:
+//
+//   - labels: the ai.opencharly.* contract parsed through MetadataFromLabels —
+//     candies (with their CalVer), ports, volumes, services, secrets and the
+//     baked plan steps of the LabelDescriptionSet, plus a raw catch-all for
+//     every other ai.opencharly.* label;
+//   - packages: the distro and builder package sets, read with the same
+//     lock_query scripts `charly box lock` runs (queryBoxVersions), one
+//     throwaway container per image (--no-packages skips them);
+//   - files: the flattened filesystem (whiteouts applied), compared by type,
+//     mode, link target and content digest, with sizes.
+
+// BoxDiffCmd implements `charly box diff`.
+type BoxDiffCmd struct {
+	RefA     string `arg:"" name:"ref-a" help:"Old image (full ref or short name resolved against local container storage)"`
+	RefB     string `arg:"" name:"ref-b" help:"New image (full ref or short name resolved against local container storage)"`
+	Format   string `long:"format" default:"text" enum:"text,json" help:"Output format (text|json)"`
+	Packages bool   `long:"packages" default:"true" negatable:"" help:"Compare installed package sets via lock_query in throwaway containers (--no-packages to skip)"`
+}
+
+func (c *BoxDiffCmd) Run() error {
+	rt, err := ResolveRuntime()
+	if err != nil {
+		return err
+	}
+	var distroCfg *DistroConfig
+	var builderCfg *BuilderConfig
+	if c.Packages {
+		distroCfg, builderCfg = boxDiffVocabulary()
+	}
+	snaps := make([]*boxSnapshot, 2)
+	for i, in := range []string{c.RefA, c.RefB} {
+		ref, err := resolveLocalImageRef(rt.RunEngine, in)
+		if err != nil {
+			return err
+		}
+		s, err := loadBoxSnapshot(rt.RunEngine, ref)
+		if err != nil {
+			return err
+		}
+		if c.Packages {
+			if err := s.queryPackages(rt.RunEngine, distroCfg, builderCfg); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %v (package sets not compared)\n", err)
+			}
+		}
+		snaps[i] = s
+	}
+	return writeBoxDiff(os.Stdout, diffBoxSnapshots(snaps[0], snaps[1]), c.Format)
+}
+
+// boxDiffVocabulary returns the distro and builder vocabulary whose lock_query
+// scripts read the package sets: the project's when run inside one, else the
+// binary's embedded defaults (box diff never needs a charly.yml).
+func boxDiffVocabulary() (*DistroConfig, *BuilderConfig) {
+	if dir, err := os.Getwd(); err == nil {
+		if uf, present, err := LoadUnified(dir); err == nil && present {
+			return uf.ProjectDistroConfig(), uf.ProjectBuilderConfig()
+		}
+	}
+	uf, err := embeddedDefaults()
+	if err != nil {
+		return nil, nil
+	}
+	return uf.ProjectDistroConfig(), uf.ProjectBuilderConfig()
+}
+
+// boxSnapshot is everything box diff compares about one image.
+type boxSnapshot struct {
+	Ref     string
+	Digest  string
+	Labels  map[string]string
+	Meta    *BoxMetadata                 // nil for a non-opencharly image
+	Package map[string]map[string]string // "package" (distro) or builder name → name → version
+	Files   map[string]boxFile
+}
+
+// boxFile is one non-directory entry of the flattened filesystem.
+type boxFile struct {
+	Type   string
+	Mode   int64
+	Size   int64
+	Link   string
+	Digest string
+}
+
+// loadBoxSnapshot saves ref out of the engine and reads its labels and files.
+func loadBoxSnapshot(engine, ref string) (*boxSnapshot, error) {
+	img, cleanup, _, err := loadImageFromDaemon(ref, engine)
+	if err != nil {
+		return nil, fmt.Errorf("loading %s: %w", ref, err)
+	}
+	defer cleanup()
+	return snapshotImage(ref, img)
+}
+
+// snapshotImage reads the labels and flattened filesystem of img.
+func snapshotImage(ref string, img v1.Image) (*boxSnapshot, error) {
+	s := &boxSnapshot{Ref: ref}
+	if d, err := img.Digest(); err == nil {
+		s.Digest = d.String()
+	}
+	cf, err := img.ConfigFile()
+	if err != nil {
+		return nil, fmt.Errorf("reading config of %s: %w", ref, err)
+	}
+	s.Labels = cf.Config.Labels
+	if s.Meta, err = MetadataFromLabels(s.Labels); err != nil {
+		return nil, fmt.Errorf("%s: %w", ref, err)
+	}
+	if s.Files, err = imageFiles(img); err != nil {
+		return nil, fmt.Errorf("reading filesystem of %s: %w", ref, err)
+	}
+	return s, nil
+}
+
+// imageFiles walks the flattened filesystem of img (mutate.Extract applies the
+// whiteouts) and records every non-directory entry by absolute path.
+func imageFiles(img v1.Image) (map[string]boxFile, error) {
+	rc := mutate.Extract(img)
+	defer rc.Close() //nolint:errcheck
+	files := map[string]boxFile{}
+	tr := tar.NewReader(rc)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		f := boxFile{Mode: hdr.Mode & 0o7777, Link: hdr.Linkname}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			continue
+		case tar.TypeReg:
+			h := sha256.New()
+			n, err := io.Copy(h, tr)
+			if err != nil {
+				return nil, err
+			}
+			f.Type, f.Size, f.Digest = "file", n, hex.EncodeToString(h.Sum(nil))
+		case tar.TypeSymlink:
+			f.Type = "symlink"
+		case tar.TypeLink:
+			f.Type = "hardlink"
+		default:
+			f.Type = "special"
+		}
+		files[path.Clean("/"+hdr.Name)] = f
+	}
+}
+
+// queryPackages fills s.Package from the lock_query scripts of the format the
+// image's ai.opencharly.platform.* labels name.
+func (s *boxSnapshot) queryPackages(engine string, distroCfg *DistroConfig, builderCfg *BuilderConfig) error {
+	if s.Meta == nil || len(s.Meta.BuildFormat) == 0 {
+		return fmt.Errorf("%s carries no %s label", s.Ref, LabelPlatformFormat)
+	}
+	img := &ResolvedBox{
+		Name:          s.Meta.Box,
+		Pkg:           s.Meta.BuildFormat[0],
+		DistroDef:     distroCfg.ResolveDistro(s.Meta.Distro),
+		BuilderConfig: builderCfg,
+	}
+	entry, err := queryBoxVersions(engine, img, s.Ref)
+	if err != nil {
+		return err
+	}
+	s.Package = map[string]map[string]string{"package": entry.Package}
+	for b, m := range entry.Builder {
+		s.Package[b] = m
+	}
+	return nil
+}
+
+// boxDiff is the JSON shape of `charly box diff --format json`.
+type boxDiff struct {
+	A       boxDiffSide        `json:"a"`
+	B       boxDiffSide        `json:"b"`
+	Candy   diffSet            `json:"candy"`
+	Port    diffSet            `json:"port"`
+	Volume  diffSet            `json:"volume"`
+	Service diffSet            `json:"service"`
+	Secret  diffSet            `json:"secret"`
+	Plan    diffSet            `json:"plan"`
+	Label   diffSet            `json:"label"`
+	Package map[string]diffSet `json:"package,omitempty"`
+	File    fileDiff           `json:"file"`
+}
+
+type boxDiffSide struct {
+	Ref     string `json:"ref"`
+	Digest  string `json:"digest,omitempty"`
+	Version string `json:"version,omitempty"`
+}
+
+// diffSet is a keyed comparison: From is the value in A, To the value in B.
+type diffSet struct {
+	Added   []diffEntry `json:"added,omitempty"`
+	Removed []diffEntry `json:"removed,omitempty"`
+	Changed []diffEntry `json:"changed,omitempty"`
+}
+
+type diffEntry struct {
+	Name string `json:"name"`
+	From string `json:"from,omitempty"`
+	To   string `json:"to,omitempty"`
+}
+
+func (d diffSet) empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// fileDiff compares the flattened filesystems; Bytes is the net size change.
+type fileDiff struct {
+	Added   []fileDelta `json:"added,omitempty"`
+	Removed []fileDelta `json:"removed,omitempty"`
+	Changed []fileDelta `json:"changed,omitempty"`
+	Bytes   int64       `json:"bytes"`
+}
+
+// fileDelta is one file entry; Size is its size in B (in A when removed) and
+// OldSize its size in A when changed. Change names what differs: content,
+// mode, type or target.
+type fileDelta struct {
+	Path    string `json:"path"`
+	Type    string `json:"type"`
+	Size    int64  `json:"size"`
+	OldSize int64  `json:"old_size,omitempty"`
+	Change  string `json:"change,omitempty"`
+}
+
+// diffMaps compares two name → value maps, sorted by name.
+func diffMaps(a, b map[string]string) diffSet {
+	var d diffSet
+	for _, k := range sortedMapKeys(a) {
+		if bv, ok := b[k]; !ok {
+			d.Removed = append(d.Removed, diffEntry{Name: k, From: a[k]})
+		} else if bv != a[k] {
+			d.Changed = append(d.Changed, diffEntry{Name: k, From: a[k], To: bv})
+		}
+	}
+	for _, k := range sortedMapKeys(b) {
+		if _, ok := a[k]; !ok {
+			d.Added = append(d.Added, diffEntry{Name: k, To: b[k]})
+		}
+	}
+	return d
+}
+
+// boxDiffFacets keys the label-derived facets of one image. Structured values
+// (services, plans) are represented by a short fingerprint of their JSON so
+// any field change shows; plans also carry their step count.
+type boxDiffFacets struct {
+	candy, port, volume, service, secret, plan, label map[string]string
+}
+
+// boxDiffCoveredLabels are the labels whose content the typed facets compare;
+// every other ai.opencharly.* label falls into the raw catch-all.
+var boxDiffCoveredLabels = map[string]bool{
+	LabelVersion: true, LabelCandyVersion: true, LabelPort: true, LabelVolume: true,
+	LabelService: true, LabelSecret: true, LabelDescription: true,
+}
+
+func snapshotFacets(s *boxSnapshot) boxDiffFacets {
+	f := boxDiffFacets{
+		candy: map[string]string{}, port: map[string]string{}, volume: map[string]string{},
+		service: map[string]string{}, secret: map[string]string{}, plan: map[string]string{},
+		label: map[string]string{},
+	}
+	for k, v := range s.Labels {
+		if strings.HasPrefix(k, "ai.opencharly.") && !boxDiffCoveredLabels[k] {
+			f.label[k] = labelDiffValue(v)
+		}
+	}
+	m := s.Meta
+	if m == nil {
+		return f
+	}
+	for k, v := range m.CandyVersion {
+		f.candy[k] = v
+	}
+	for _, p := range m.Port {
+		f.port[p] = ""
+	}
+	for _, v := range m.Volume {
+		f.volume[v.VolumeName] = v.ContainerPath
+	}
+	for _, svc := range m.Service {
+		f.service[svc.Name] = jsonFingerprint(svc)
+	}
+	for _, sec := range m.Secret {
+		f.secret[sec.Name] = sec.Target
+	}
+	if m.Description != nil {
+		for section, entries := range map[string][]LabeledDescription{
+			"candy": m.Description.Candy, "box": m.Description.Box, "deploy": m.Description.Deploy,
+		} {
+			for _, e := range entries {
+				key := e.Origin
+				if _, dup := f.plan[key]; dup || key == "" {
+					key = section + "/" + e.Origin
+				}
+				f.plan[key] = fmt.Sprintf("%d steps %s", len(e.Plan), jsonFingerprint(e))
+			}
+		}
+	}
+	return f
+}
+
+// labelDiffValue keeps short scalar label values readable and fingerprints the
+// JSON blobs.
+func labelDiffValue(v string) string {
+	if len(v) <= 64 && !strings.ContainsAny(v, "\n{[") {
+		return v
+	}
+	return jsonFingerprint(v)
+}
+
+// jsonFingerprint is a 12-hex-digit digest of v's JSON encoding.
+func jsonFingerprint(v any) string {
+	b, _ := json.Marshal(v)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:6])
+}
+
+// diffBoxSnapshots compares a (old) against b (new).
+func diffBoxSnapshots(a, b *boxSnapshot) *boxDiff {
+	d := &boxDiff{A: snapshotSide(a), B: snapshotSide(b)}
+	fa, fb := snapshotFacets(a), snapshotFacets(b)
+	d.Candy = diffMaps(fa.candy, fb.candy)
+	d.Port = diffMaps(fa.port, fb.port)
+	d.Volume = diffMaps(fa.volume, fb.volume)
+	d.Service = diffMaps(fa.service, fb.service)
+	d.Secret = diffMaps(fa.secret, fb.secret)
+	d.Plan = diffMaps(fa.plan, fb.plan)
+	d.Label = diffMaps(fa.label, fb.label)
+	if a.Package != nil && b.Package != nil {
+		d.Package = map[string]diffSet{}
+		kinds := map[string]bool{}
+		for k := range a.Package {
+			kinds[k] = true
+		}
+		for k := range b.Package {
+			kinds[k] = true
+		}
+		for k := range kinds {
+			if ds := diffMaps(a.Package[k], b.Package[k]); !ds.empty() {
+				d.Package[k] = ds
+			}
+		}
+	}
+	d.File = diffFiles(a.Files, b.Files)
+	return d
+}
+
+func snapshotSide(s *boxSnapshot) boxDiffSide {
+	side := boxDiffSide{Ref: s.Ref, Digest: s.Digest}
+	if s.Meta != nil {
+		side.Version = s.Meta.Version
+	}
+	return side
+}
+
+// diffFiles compares two flattened filesystems, sorted by path.
+func diffFiles(a, b map[string]boxFile) fileDiff {
+	var d fileDiff
+	for _, p := range sortedMapKeys(a) {
+		fa := a[p]
+		fb, ok := b[p]
+		if !ok {
+			d.Removed = append(d.Removed, fileDelta{Path: p, Type: fa.Type, Size: fa.Size})
+			d.Bytes -= fa.Size
+			continue
+		}
+		var change string
+		switch {
+		case fa.Type != fb.Type:
+			change = "type"
+		case fa.Digest != fb.Digest:
+			change = "content"
+		case fa.Link != fb.Link:
+			change = "target"
+		case fa.Mode != fb.Mode:
+			change = "mode"
+		default:
+			continue
+		}
+		d.Changed = append(d.Changed, fileDelta{Path: p, Type: fb.Type, Size: fb.Size, OldSize: fa.Size, Change: change})
+		d.Bytes += fb.Size - fa.Size
+	}
+	for _, p := range sortedMapKeys(b) {
+		if _, ok := a[p]; !ok {
+			d.Added = append(d.Added, fileDelta{Path: p, Type: b[p].Type, Size: b[p].Size})
+			d.Bytes += b[p].Size
+		}
+	}
+	return d
+}
+
+// writeBoxDiff renders d as text or JSON.
+func writeBoxDiff(w io.Writer, d *boxDiff, format string) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(d)
+	case "text", "":
+	default:
+		return fmt.Errorf("unknown diff format %q (want text or json)", format)
+	}
+	fmt.Fprintf(w, "--- %s", d.A.Ref)
+	if d.A.Version != "" {
+		fmt.Fprintf(w, " (%s)", d.A.Version)
+	}
+	fmt.Fprintf(w, "\n+++ %s", d.B.Ref)
+	if d.B.Version != "" {
+		fmt.Fprintf(w, " (%s)", d.B.Version)
+	}
+	fmt.Fprintln(w)
+	for _, sec := range []struct {
+		title string
+		set   diffSet
+	}{
+		{"candies", d.Candy}, {"ports", d.Port}, {"volumes", d.Volume},
+		{"services", d.Service}, {"secrets", d.Secret}, {"plan steps", d.Plan},
+		{"other labels", d.Label},
+	} {
+		writeDiffSet(w, sec.title, sec.set)
+	}
+	keys := make([]string, 0, len(d.Package))
+	for k := range d.Package {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		title := "packages"
+		if k != "package" {
+			title = "builder " + k
+		}
+		writeDiffSet(w, title, d.Package[k])
+	}
+	f := d.File
+	if len(f.Added)+len(f.Removed)+len(f.Changed) == 0 {
+		fmt.Fprintln(w, "\nfiles: no changes")
+		return nil
+	}
+	sign := "+"
+	if f.Bytes < 0 {
+		sign = "-"
+	}
+	fmt.Fprintf(w, "\nfiles: %d added, %d removed, %d changed (%s%s)\n",
+		len(f.Added), len(f.Removed), len(f.Changed), sign, humanBytes(max(f.Bytes, -f.Bytes)))
+	for _, e := range f.Added {
+		fmt.Fprintf(w, "  + %s (%s)\n", e.Path, humanBytes(e.Size))
+	}
+	for _, e := range f.Removed {
+		fmt.Fprintf(w, "  - %s (%s)\n", e.Path, humanBytes(e.Size))
+	}
+	for _, e := range f.Changed {
+		fmt.Fprintf(w, "  ~ %s (%s: %s → %s)\n", e.Path, e.Change, humanBytes(e.OldSize), humanBytes(e.Size))
+	}
+	return nil
+}
+
+func writeDiffSet(w io.Writer, title string, d diffSet) {
+	if d.empty() {
+		return
+	}
+	fmt.Fprintf(w, "\n%s:\n", title)
+	for _, e := range d.Added {
+		fmt.Fprintf(w, "  + %s%s\n", e.Name, diffValueSuffix(e.To))
+	}
+	for _, e := range d.Removed {
+		fmt.Fprintf(w, "  - %s%s\n", e.Name, diffValueSuffix(e.From))
+	}
+	for _, e := range d.Changed {
+		fmt.Fprintf(w, "  ~ %s %s → %s\n", e.Name, e.From, e.To)
+	}
+}
+
+func diffValueSuffix(v string) string {
+	if v == "" {
+		return ""
+	}
+	return " " + v
+}
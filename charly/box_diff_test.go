@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/crane"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+)
+
+// testDiffImage stacks one layer per file map onto an empty image and sets
+// its labels.
+func testDiffImage(t *testing.T, labels map[string]string, layers ...map[string][]byte) v1.Image {
+	t.Helper()
+	img := empty.Image
+	for _, files := range layers {
+		l, err := crane.Layer(files)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if img, err = mutate.AppendLayers(img, l); err != nil {
+			t.Fatal(err)
+		}
+	}
+	img, err := mutate.Config(img, v1.Config{Labels: labels})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return img
+}
+
+func testDiffLabels(t *testing.T, version string, candies map[string]string, ports []string, plan []Step) map[string]string {
+	t.Helper()
+	enc := func(v any) string {
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+	return map[string]string{
+		LabelVersion:                       version,
+		LabelBox:                           "app",
+		LabelCandyVersion:                  enc(candies),
+		LabelPort:                          enc(ports),
+		LabelPlatformFormat:                `["rpm"]`,
+		LabelDescription:                   enc(LabelDescriptionSet{Candy: []LabeledDescription{{Origin: "candy:web", Plan: plan}}}),
+		LabelUser:                          "user",
+		"org.opencontainers.image.created": version,
+	}
+}
+
+func TestBoxDiff(t *testing.T) {
+	base := map[string][]byte{
+		"etc/app.conf":   []byte("port=8080\n"),
+		"usr/bin/tool":   []byte("v1"),
+		"usr/share/gone": []byte("bye"),
+	}
+	a := testDiffImage(t,
+		testDiffLabels(t, "2026.100.1", map[string]string{"web": "2026.100.1", "old": "2026.1.1"}, []string{"8080"}, []Step{{Run: "a"}}),
+		base)
+	b := testDiffImage(t,
+		testDiffLabels(t, "2026.101.1", map[string]string{"web": "2026.101.1", "new": "2026.101.1"}, []string{"8080", "9090"}, []Step{{Run: "a"}, {Run: "b"}}),
+		base,
+		map[string][]byte{
+			"etc/app.conf":       []byte("port=9090\n"),
+			"usr/share/.wh.gone": nil,
+			"usr/lib/new.so":     []byte("0123456789"),
+		})
+
+	sa, err := snapshotImage("localhost/app:a", a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sb, err := snapshotImage("localhost/app:b", b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := sb.Files["/usr/share/gone"]; ok {
+		t.Error("whiteout not applied: /usr/share/gone still present in b")
+	}
+	sa.Package = map[string]map[string]string{"package": {"bash": "5.2", "git": "2.46"}}
+	sb.Package = map[string]map[string]string{"package": {"bash": "5.3", "curl": "8.9"}, "npm": {"typescript": "5.6"}}
+
+	d := diffBoxSnapshots(sa, sb)
+	if d.A.Version != "2026.100.1" || d.B.Version != "2026.101.1" {
+		t.Errorf("versions = %q → %q", d.A.Version, d.B.Version)
+	}
+	wantCandy := diffSet{
+		Added:   []diffEntry{{Name: "new", To: "2026.101.1"}},
+		Removed: []diffEntry{{Name: "old", From: "2026.1.1"}},
+		Changed: []diffEntry{{Name: "web", From: "2026.100.1", To: "2026.101.1"}},
+	}
+	if !reflect.DeepEqual(d.Candy, wantCandy) {
+		t.Errorf("candy = %+v, want %+v", d.Candy, wantCandy)
+	}
+	if want := (diffSet{Added: []diffEntry{{Name: "9090"}}}); !reflect.DeepEqual(d.Port, want) {
+		t.Errorf("port = %+v, want %+v", d.Port, want)
+	}
+	if len(d.Plan.Changed) != 1 || d.Plan.Changed[0].Name != "candy:web" || !strings.HasPrefix(d.Plan.Changed[0].To, "2 steps ") {
+		t.Errorf("plan = %+v, want candy:web changed to 2 steps", d.Plan)
+	}
+	// Non-contract labels are ignored; unchanged contract labels do not show.
+	if !d.Label.empty() {
+		t.Errorf("label = %+v, want no changes", d.Label)
+	}
+	wantPkg := map[string]diffSet{
+		"package": {
+			Added:   []diffEntry{{Name: "curl", To: "8.9"}},
+			Removed: []diffEntry{{Name: "git", From: "2.46"}},
+			Changed: []diffEntry{{Name: "bash", From: "5.2", To: "5.3"}},
+		},
+		"npm": {Added: []diffEntry{{Name: "typescript", To: "5.6"}}},
+	}
+	if !reflect.DeepEqual(d.Package, wantPkg) {
+		t.Errorf("package = %+v, want %+v", d.Package, wantPkg)
+	}
+	wantFile := fileDiff{
+		Added:   []fileDelta{{Path: "/usr/lib/new.so", Type: "file", Size: 10}},
+		Removed: []fileDelta{{Path: "/usr/share/gone", Type: "file", Size: 3}},
+		Changed: []fileDelta{{Path: "/etc/app.conf", Type: "file", Size: 10, OldSize: 10, Change: "content"}},
+		Bytes:   7,
+	}
+	if !reflect.DeepEqual(d.File, wantFile) {
+		t.Errorf("file = %+v, want %+v", d.File, wantFile)
+	}
+
+	var text bytes.Buffer
+	if err := writeBoxDiff(&text, d, "text"); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"+++ localhost/app:b (2026.101.1)",
+		"  ~ web 2026.100.1 → 2026.101.1",
+		"builder npm:\n  + typescript 5.6",
+		"files: 1 added, 1 removed, 1 changed (+7 B)",
+		"  - /usr/share/gone (3 B)",
+	} {
+		if !strings.Contains(text.String(), want) {
+			t.Errorf("text output missing %q:\n%s", want, text.String())
+		}
+	}
+	var js bytes.Buffer
+	if err := writeBoxDiff(&js, d, "json"); err != nil {
+		t.Fatal(err)
+	}
+	var back boxDiff
+	if err := json.Unmarshal(js.Bytes(), &back); err != nil {
+		t.Fatalf("json output does not parse: %v", err)
+	}
+	if !reflect.DeepEqual(back.File, d.File) {
+		t.Errorf("json file diff = %+v, want %+v", back.File, d.File)
+	}
+}
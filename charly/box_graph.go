@@ -0,0 +1,372 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// box_graph.go — `charly box graph`: the layering decisions of graph.go and
+// intermediates.go made visible. The graph is computed exactly as `charly box
+// build` computes it (ResolveAllBox → ComputeIntermediates → ResolveBoxLevels,
+// per-box candy order via Generator.globalOrderForBox), then rendered as
+// Graphviz DOT, Mermaid or JSON.
+
+// BoxGraphCmd renders the candy dependency graph, the box→base chain, the
+// auto-generated intermediates and the parallel build levels.
+type BoxGraphCmd struct {
+	Box     string `arg:"" optional:"" help:"Limit the graph to this box and every box it builds on (default: all boxes)"`
+	Format  string `long:"format" enum:"dot,mermaid,json" default:"dot" help:"Output format: dot (Graphviz), mermaid, or json"`
+	NoCandy bool   `long:"no-candy" help:"Draw boxes only — omit candy nodes and candy dependency edges"`
+}
+
+func (c *BoxGraphCmd) Run() error {
+	dir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	cfg, err := LoadConfig(dir)
+	if err != nil {
+		return err
+	}
+	calverTag := ComputeCalVer()
+	boxes, err := cfg.ResolveAllBox(calverTag, dir, ResolveOpts{})
+	if err != nil {
+		return err
+	}
+	layers, err := ScanAllCandyWithConfig(dir, cfg)
+	if err != nil {
+		return err
+	}
+	boxes, err = ComputeIntermediates(boxes, layers, cfg, calverTag)
+	if err != nil {
+		return err
+	}
+	g, err := buildBoxGraph(boxes, layers, c.Box)
+	if err != nil {
+		return err
+	}
+	if c.NoCandy {
+		g.Candies = nil
+	}
+	return writeBoxGraph(os.Stdout, g, c.Format)
+}
+
+// boxGraph is the rendered model; its JSON form is the `--format json` output.
+type boxGraph struct {
+	Levels  [][]string      `json:"levels"` // parallel build levels, level 0 first
+	Boxes   []boxGraphBox   `json:"boxes"`  // ordered by level, then name
+	Candies []boxGraphCandy `json:"candies,omitempty"`
+}
+
+type boxGraphBox struct {
+	Name     string   `json:"name"`
+	Base     string   `json:"base"`
+	External bool     `json:"external_base,omitempty"` // base is an OCI ref, not a box
+	Auto     bool     `json:"auto,omitempty"`          // auto-generated intermediate
+	Level    int      `json:"level"`
+	Builders []string `json:"builders,omitempty"` // builder boxes that must be built first
+	Candy    []string `json:"candy"`              // candies this box's own layers install, in install order
+}
+
+type boxGraphCandy struct {
+	Name    string   `json:"name"`
+	Require []string `json:"require,omitempty"` // direct dependencies, through content-less composing candies
+	Boxes   []string `json:"boxes"`             // boxes whose own layers install this candy
+	// Rebuilds is every box a change to this candy rebuilds: the installing boxes
+	// plus everything built on top of them (base and builder edges).
+	Rebuilds []string `json:"rebuilds"`
+}
+
+// buildBoxGraph computes the graph for boxes (intermediates already applied).
+// A non-empty only limits it to that box and its transitive dependencies; level
+// numbers stay those of the full build.
+func buildBoxGraph(boxes map[string]*ResolvedBox, layers map[string]*Candy, only string) (*boxGraph, error) {
+	deps := make(map[string][]string, len(boxes))
+	for name, img := range boxes {
+		deps[name] = boxDirectDeps(name, img, boxes, BoxNeedsBuilder(img, boxes, layers))
+	}
+	levels, err := ResolveBoxLevels(boxes, layers)
+	if err != nil {
+		return nil, err
+	}
+
+	keep := make(map[string]bool, len(boxes))
+	if only == "" {
+		for name := range boxes {
+			keep[name] = true
+		}
+	} else {
+		if _, ok := boxes[only]; !ok {
+			return nil, fmt.Errorf("unknown box %q", only)
+		}
+		var add func(name string)
+		add = func(name string) {
+			if keep[name] {
+				return
+			}
+			keep[name] = true
+			for _, dep := range deps[name] {
+				add(dep)
+			}
+		}
+		add(only)
+	}
+
+	globalOrder, err := GlobalCandyOrder(boxes, layers)
+	if err != nil {
+		return nil, err
+	}
+	gen := &Generator{Boxes: boxes, Candies: layers, GlobalOrder: globalOrder}
+
+	g := &boxGraph{}
+	installedBy := make(map[string][]string)
+	for lvl, level := range levels {
+		var kept []string
+		for _, name := range level {
+			if !keep[name] {
+				continue
+			}
+			kept = append(kept, name)
+			img := boxes[name]
+			var parentCandies map[string]bool
+			if !img.IsExternalBase {
+				if parentCandies, err = CandyProvidedByBox(img.Base, boxes, layers); err != nil {
+					return nil, err
+				}
+			}
+			own, err := gen.globalOrderForBox(img.Candy, parentCandies)
+			if err != nil {
+				return nil, fmt.Errorf("box %s: %w", name, err)
+			}
+			b := boxGraphBox{Name: name, Base: img.Base, External: img.IsExternalBase, Auto: img.Auto, Level: lvl, Candy: own}
+			for _, dep := range deps[name] {
+				if img.IsExternalBase || dep != img.Base {
+					b.Builders = append(b.Builders, dep)
+				}
+			}
+			for _, cn := range own {
+				installedBy[cn] = append(installedBy[cn], name)
+			}
+			g.Boxes = append(g.Boxes, b)
+		}
+		if len(kept) > 0 {
+			g.Levels = append(g.Levels, kept)
+		}
+	}
+
+	dependents := make(map[string][]string)
+	for name, ds := range deps {
+		for _, dep := range ds {
+			dependents[dep] = append(dependents[dep], name)
+		}
+	}
+	for _, cn := range sortedMapKeys(installedBy) {
+		rebuild := make(map[string]bool)
+		var walk func(name string)
+		walk = func(name string) {
+			if rebuild[name] || !keep[name] {
+				return
+			}
+			rebuild[name] = true
+			for _, d := range dependents[name] {
+				walk(d)
+			}
+		}
+		for _, b := range installedBy[cn] {
+			walk(b)
+		}
+		g.Candies = append(g.Candies, boxGraphCandy{
+			Name:     cn,
+			Require:  candyGraphDeps(cn, layers, installedBy),
+			Boxes:    installedBy[cn],
+			Rebuilds: sortedMapKeys(rebuild),
+		})
+	}
+	return g, nil
+}
+
+// candyGraphDeps returns name's direct require/include edges restricted to the
+// candies present in the graph. A content-less composing candy never becomes a
+// node (ResolveCandyOrder drops it), so its included candies stand in for it —
+// the same substitution ResolveCandyOrder's resolveDepEdges makes.
+func candyGraphDeps(name string, layers map[string]*Candy, present map[string][]string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	var visit func(dep string)
+	visit = func(dep string) {
+		if seen[dep] {
+			return
+		}
+		seen[dep] = true
+		if _, ok := present[dep]; ok {
+			out = append(out, dep)
+			return
+		}
+		if layer, ok := layers[dep]; ok {
+			for _, inc := range layer.IncludedCandy {
+				visit(inc.Bare())
+			}
+		}
+	}
+	layer, ok := layers[name]
+	if !ok {
+		return nil
+	}
+	for _, ref := range layer.Require {
+		visit(ref.Bare())
+	}
+	for _, inc := range layer.IncludedCandy {
+		visit(inc.Bare())
+	}
+	sortStrings(out)
+	return out
+}
+
+// writeBoxGraph renders g in the given format ("dot", "mermaid" or "json").
+func writeBoxGraph(w io.Writer, g *boxGraph, format string) error {
+	switch format {
+	case "json":
+		data, err := json.MarshalIndent(g, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%s\n", data)
+		return err
+	case "mermaid":
+		writeBoxGraphMermaid(w, g)
+	default:
+		writeBoxGraphDOT(w, g)
+	}
+	return nil
+}
+
+// writeBoxGraphDOT emits a Graphviz digraph: one cluster per build level, boxes
+// as boxes (auto intermediates dashed), external bases as plain notes, candies as
+// ellipses. Edges point from the dependent to what it needs, drawn bottom-up.
+// Node IDs are kind-prefixed because a box and a candy often share a name.
+func writeBoxGraphDOT(w io.Writer, g *boxGraph) {
+	q := strconv.Quote
+	fmt.Fprintln(w, "digraph charly {")
+	fmt.Fprintln(w, "\trankdir=BT;")
+	fmt.Fprintln(w, "\tnode [fontname=\"Helvetica\"];")
+	for i, level := range g.Levels {
+		fmt.Fprintf(w, "\tsubgraph cluster_level_%d {\n", i)
+		fmt.Fprintf(w, "\t\tlabel=%s;\n", q(fmt.Sprintf("level %d", g.Boxes[boxGraphIndex(g, level[0])].Level)))
+		fmt.Fprintln(w, "\t\tstyle=dotted;")
+		for _, name := range level {
+			b := g.Boxes[boxGraphIndex(g, name)]
+			if b.Auto {
+				fmt.Fprintf(w, "\t\t%s [label=%s, shape=box, style=dashed];\n", q("box:"+name), q(name+"\n(auto)"))
+			} else {
+				fmt.Fprintf(w, "\t\t%s [label=%s, shape=box];\n", q("box:"+name), q(name))
+			}
+		}
+		fmt.Fprintln(w, "\t}")
+	}
+	external := map[string]bool{}
+	for _, b := range g.Boxes {
+		if b.External && !external[b.Base] {
+			external[b.Base] = true
+			fmt.Fprintf(w, "\t%s [label=%s, shape=note];\n", q("ext:"+b.Base), q(b.Base))
+		}
+	}
+	for _, c := range g.Candies {
+		fmt.Fprintf(w, "\t%s [label=%s, shape=ellipse];\n", q("candy:"+c.Name), q(c.Name))
+	}
+	for _, b := range g.Boxes {
+		base := "box:" + b.Base
+		if b.External {
+			base = "ext:" + b.Base
+		}
+		fmt.Fprintf(w, "\t%s -> %s [label=\"base\"];\n", q("box:"+b.Name), q(base))
+		for _, bl := range b.Builders {
+			fmt.Fprintf(w, "\t%s -> %s [label=\"builder\", style=dotted];\n", q("box:"+b.Name), q("box:"+bl))
+		}
+		if g.Candies != nil {
+			for _, cn := range b.Candy {
+				fmt.Fprintf(w, "\t%s -> %s [color=gray];\n", q("box:"+b.Name), q("candy:"+cn))
+			}
+		}
+	}
+	for _, c := range g.Candies {
+		for _, dep := range c.Require {
+			fmt.Fprintf(w, "\t%s -> %s;\n", q("candy:"+c.Name), q("candy:"+dep))
+		}
+	}
+	fmt.Fprintln(w, "}")
+}
+
+// writeBoxGraphMermaid emits a Mermaid flowchart with the same shape as the DOT
+// output. Mermaid IDs must be plain words, so nodes get positional IDs (bN, xN,
+// cN) and carry the real name as their label.
+func writeBoxGraphMermaid(w io.Writer, g *boxGraph) {
+	label := func(s string) string { return `"` + strings.ReplaceAll(s, `"`, "#quot;") + `"` }
+	boxID := make(map[string]string, len(g.Boxes))
+	for i, b := range g.Boxes {
+		boxID[b.Name] = fmt.Sprintf("b%d", i)
+	}
+	extID := map[string]string{}
+	candyID := make(map[string]string, len(g.Candies))
+	for i, c := range g.Candies {
+		candyID[c.Name] = fmt.Sprintf("c%d", i)
+	}
+
+	fmt.Fprintln(w, "flowchart BT")
+	var auto []string
+	for i, level := range g.Levels {
+		lvl := g.Boxes[boxGraphIndex(g, level[0])].Level
+		fmt.Fprintf(w, "\tsubgraph level_%d [%s]\n", i, label(fmt.Sprintf("level %d", lvl)))
+		for _, name := range level {
+			fmt.Fprintf(w, "\t\t%s[%s]\n", boxID[name], label(name))
+			if g.Boxes[boxGraphIndex(g, name)].Auto {
+				auto = append(auto, boxID[name])
+			}
+		}
+		fmt.Fprintln(w, "\tend")
+	}
+	for _, b := range g.Boxes {
+		if b.External {
+			if _, ok := extID[b.Base]; !ok {
+				extID[b.Base] = fmt.Sprintf("x%d", len(extID))
+				fmt.Fprintf(w, "\t%s[/%s/]\n", extID[b.Base], label(b.Base))
+			}
+		}
+	}
+	for _, c := range g.Candies {
+		fmt.Fprintf(w, "\t%s([%s])\n", candyID[c.Name], label(c.Name))
+	}
+	for _, b := range g.Boxes {
+		base := boxID[b.Base]
+		if b.External {
+			base = extID[b.Base]
+		}
+		fmt.Fprintf(w, "\t%s -->|base| %s\n", boxID[b.Name], base)
+		for _, bl := range b.Builders {
+			fmt.Fprintf(w, "\t%s -.->|builder| %s\n", boxID[b.Name], boxID[bl])
+		}
+		if g.Candies != nil {
+			for _, cn := range b.Candy {
+				fmt.Fprintf(w, "\t%s --- %s\n", boxID[b.Name], candyID[cn])
+			}
+		}
+	}
+	for _, c := range g.Candies {
+		for _, dep := range c.Require {
+			fmt.Fprintf(w, "\t%s --> %s\n", candyID[c.Name], candyID[dep])
+		}
+	}
+	if len(auto) > 0 {
+		fmt.Fprintln(w, "\tclassDef auto stroke-dasharray: 5 5")
+		fmt.Fprintf(w, "\tclass %s auto\n", strings.Join(auto, ","))
+	}
+}
+
+// boxGraphIndex returns the index of the named box in g.Boxes.
+func boxGraphIndex(g *boxGraph, name string) int {
+	return slices.IndexFunc(g.Boxes, func(b boxGraphBox) bool { return b.Name == name })
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testGraphBoxes is a two-level catalog: app and ml build on base, ml also
+// needs the builder box for its format packages.
+func testGraphBoxes() (map[string]*ResolvedBox, map[string]*Candy) {
+	layers := map[string]*Candy{
+		"pixi":   {Name: "pixi"},
+		"python": {Name: "python", Require: toCandyRefs([]string{"pixi"})},
+		"web":    {Name: "web", Require: toCandyRefs([]string{"python"})},
+		"torch":  {Name: "torch", Require: toCandyRefs([]string{"python"}), HasPackageJson: true},
+	}
+	boxes := map[string]*ResolvedBox{
+		"base":    {Name: "base", Base: "quay.io/fedora/fedora:43", IsExternalBase: true, Candy: []string{"python"}},
+		"builder": {Name: "builder", Base: "quay.io/fedora/fedora:43", IsExternalBase: true},
+		"app":     {Name: "app", Base: "base", Candy: []string{"web"}},
+		"ml":      {Name: "ml", Base: "base", Candy: []string{"torch"}, Builder: BuilderMap{"pixi": "builder"}, Auto: true},
+	}
+	return boxes, layers
+}
+
+func TestBuildBoxGraph(t *testing.T) {
+	boxes, layers := testGraphBoxes()
+	g, err := buildBoxGraph(boxes, layers, "")
+	if err != nil {
+		t.Fatalf("buildBoxGraph: %v", err)
+	}
+	if want := [][]string{{"base", "builder"}, {"app", "ml"}}; !reflect.DeepEqual(g.Levels, want) {
+		t.Errorf("Levels = %v, want %v", g.Levels, want)
+	}
+	byName := map[string]boxGraphBox{}
+	for _, b := range g.Boxes {
+		byName[b.Name] = b
+	}
+	if got := byName["base"].Candy; !reflect.DeepEqual(got, []string{"pixi", "python"}) {
+		t.Errorf("base candy = %v, want [pixi python]", got)
+	}
+	// Parent-provided candies are not re-installed by the child.
+	if got := byName["app"].Candy; !reflect.DeepEqual(got, []string{"web"}) {
+		t.Errorf("app candy = %v, want [web]", got)
+	}
+	if ml := byName["ml"]; !ml.Auto || ml.Level != 1 {
+		t.Errorf("ml = %+v, want auto at level 1", ml)
+	}
+
+	candies := map[string]boxGraphCandy{}
+	for _, c := range g.Candies {
+		candies[c.Name] = c
+	}
+	// A python change rebuilds base and everything on top of it — not builder.
+	if got := candies["python"].Rebuilds; !reflect.DeepEqual(got, []string{"app", "base", "ml"}) {
+		t.Errorf("python rebuilds = %v, want [app base ml]", got)
+	}
+	if got := candies["web"].Require; !reflect.DeepEqual(got, []string{"python"}) {
+		t.Errorf("web require = %v, want [python]", got)
+	}
+}
+
+func TestBuildBoxGraphFiltered(t *testing.T) {
+	boxes, layers := testGraphBoxes()
+	g, err := buildBoxGraph(boxes, layers, "app")
+	if err != nil {
+		t.Fatalf("buildBoxGraph: %v", err)
+	}
+	if want := [][]string{{"base"}, {"app"}}; !reflect.DeepEqual(g.Levels, want) {
+		t.Errorf("Levels = %v, want %v", g.Levels, want)
+	}
+	for _, c := range g.Candies {
+		if c.Name == "torch" {
+			t.Error("filtered graph should not contain ml's candies")
+		}
+	}
+	if _, err := buildBoxGraph(boxes, layers, "nope"); err == nil {
+		t.Error("expected error for unknown box")
+	}
+}
+
+func TestWriteBoxGraph(t *testing.T) {
+	boxes, layers := testGraphBoxes()
+	g, err := buildBoxGraph(boxes, layers, "")
+	if err != nil {
+		t.Fatalf("buildBoxGraph: %v", err)
+	}
+
+	var dot bytes.Buffer
+	if err := writeBoxGraph(&dot, g, "dot"); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"digraph charly {",
+		"subgraph cluster_level_1 {",
+		`"box:ml" [label="ml\n(auto)", shape=box, style=dashed];`,
+		`"box:base" -> "ext:quay.io/fedora/fedora:43" [label="base"];`,
+		`"box:ml" -> "box:builder" [label="builder", style=dotted];`,
+		`"box:app" -> "candy:web" [color=gray];`,
+		`"candy:web" -> "candy:python";`,
+	} {
+		if !strings.Contains(dot.String(), want) {
+			t.Errorf("dot output missing %q:\n%s", want, dot.String())
+		}
+	}
+
+	var mm bytes.Buffer
+	if err := writeBoxGraph(&mm, g, "mermaid"); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"flowchart BT",
+		`subgraph level_0 ["level 0"]`,
+		"-->|base|",
+		"-.->|builder|",
+		"class b3 auto",
+	} {
+		if !strings.Contains(mm.String(), want) {
+			t.Errorf("mermaid output missing %q:\n%s", want, mm.String())
+		}
+	}
+
+	var js bytes.Buffer
+	if err := writeBoxGraph(&js, g, "json"); err != nil {
+		t.Fatal(err)
+	}
+	var back boxGraph
+	if err := json.Unmarshal(js.Bytes(), &back); err != nil {
+		t.Fatalf("json output does not parse: %v", err)
+	}
+	if !reflect.DeepEqual(&back, g) {
+		t.Errorf("json round trip differs:\n%s", js.String())
+	}
+}
@@ -0,0 +1,382 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/overthinkos/overthink/charly/spec"
+)
+
+// box_lock.go — charly.lock, the committed package-version lockfile.
+//
+// `charly box lock` reads the exact versions a BUILT image carries — the distro
+// packages through the format's lock_query, the pixi/npm/cargo builder outputs
+// through each builder's lock_query — and records them per box. The generator
+// then pins every declared package of the primary format to the recorded version
+// (the format's pin_template), so a rebuild installs what the lock says instead
+// of whatever the mirrors serve that day. A package the lock does not know stays
+// unpinned; builder outputs are recorded for review and staleness only (pixi.lock
+// / package.json pins are the builders' own mechanism).
+//
+// Refreshing is `charly box lock --refresh`: rebuild ignoring the lock
+// (`charly box build --unlocked`), then record again. `charly box validate`
+// warns when the lock no longer matches the candy lists.
+
+// BoxLockFile is the lockfile name, at the project root beside charly.yml.
+const BoxLockFile = "charly.lock"
+
+// boxLockVersion is the lockfile schema version.
+const boxLockVersion = 1
+
+// BoxLock is the parsed charly.lock.
+type BoxLock struct {
+	Version int                      `yaml:"version"`
+	Box     map[string]*BoxLockEntry `yaml:"box"`
+}
+
+// BoxLockEntry is one box's recorded state.
+type BoxLockEntry struct {
+	Image   string                       `yaml:"image"`  // image ref the versions were read from
+	Format  string                       `yaml:"format"` // primary package format (rpm/deb/pac/apk)
+	Candy   []string                     `yaml:"candy"`  // every candy in the box's base chain, sorted
+	Package map[string]string            `yaml:"package,omitempty"`
+	Builder map[string]map[string]string `yaml:"builder,omitempty"` // builder → output → version
+	// Source maps a package to its distro source package when the lock_query
+	// reports one (deb: libssl3 → openssl). Read for the SBOM and audit only,
+	// never written to charly.lock.
+	Source map[string]string `yaml:"-"`
+}
+
+// LoadBoxLock reads dir/charly.lock. A missing file is not an error: it returns
+// nil and the build stays unpinned.
+func LoadBoxLock(dir string) (*BoxLock, error) {
+	path := filepath.Join(dir, BoxLockFile)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var lock BoxLock
+	if err := yaml.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if lock.Version != boxLockVersion {
+		return nil, fmt.Errorf("%s: unsupported version %d (want %d)", path, lock.Version, boxLockVersion)
+	}
+	if lock.Box == nil {
+		lock.Box = map[string]*BoxLockEntry{}
+	}
+	return &lock, nil
+}
+
+// SaveBoxLock writes the lock to dir/charly.lock.
+func SaveBoxLock(dir string, lock *BoxLock) error {
+	data, err := yaml.Marshal(lock)
+	if err != nil {
+		return err
+	}
+	header := "# charly.lock — generated by `charly box lock`; commit it, do not edit.\n"
+	return atomicWriteFile(filepath.Join(dir, BoxLockFile), append([]byte(header), data...), 0o644)
+}
+
+// BoxLockCmd implements `charly box lock`.
+type BoxLockCmd struct {
+	Boxes   []string `arg:"" optional:"" help:"Boxes to lock (default: all enabled). Other boxes' entries are kept."`
+	Refresh bool     `long:"refresh" help:"Rebuild the boxes ignoring charly.lock (newest mirror packages) before recording"`
+}
+
+func (c *BoxLockCmd) Run() error {
+	dir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	boxes := normalizeBoxArgs(c.Boxes)
+	if c.Refresh {
+		if err := dispatchBoxBuild(spec.BuildRequest{Boxes: boxes, Dir: dir, Unlocked: true}); err != nil {
+			return fmt.Errorf("refresh build: %w", err)
+		}
+	}
+	gen, err := NewGenerator(dir, "", boxResolveOpts(boxes, false))
+	if err != nil {
+		return err
+	}
+	order, err := ResolveBoxOrder(gen.Boxes, gen.Candies)
+	if err != nil {
+		return err
+	}
+	if len(boxes) > 0 {
+		if order, err = filterBox(order, boxes, gen.Boxes); err != nil {
+			return err
+		}
+	}
+	rt, err := ResolveRuntime()
+	if err != nil {
+		return err
+	}
+
+	lock := gen.Lock
+	if lock == nil {
+		lock = &BoxLock{Version: boxLockVersion, Box: map[string]*BoxLockEntry{}}
+	}
+	for _, name := range order {
+		img := gen.Boxes[name]
+		// Auto intermediates are not recorded: their layers are part of every
+		// consumer image (see Generator.lockedPackages), and their names move
+		// with the catalog.
+		if img.Auto {
+			continue
+		}
+		// Boxes pulled in through an import namespace belong to that project's lock.
+		if _, ok := gen.Config.Box[name]; !ok {
+			continue
+		}
+		entry, err := recordBoxLock(rt.BuildEngine, gen, name)
+		if err != nil {
+			return err
+		}
+		lock.Box[name] = entry
+		fmt.Fprintf(os.Stderr, "Locked %s: %d package(s) from %s\n", name, len(entry.Package), entry.Image)
+	}
+	return SaveBoxLock(dir, lock)
+}
+
+// recordBoxLock reads one built box's versions into a lock entry.
+func recordBoxLock(engine string, gen *Generator, name string) (*BoxLockEntry, error) {
+	img := gen.Boxes[name]
+	ref, err := resolveLocalImageRef(engine, name)
+	if err != nil {
+		return nil, fmt.Errorf("box %s is not built (run `charly box build %s` first): %w", name, name, err)
+	}
+	entry, err := queryBoxVersions(engine, img, ref)
+	if err != nil {
+		return nil, err
+	}
+	chain, err := gen.Config.boxCandyChain(gen.Candies, name)
+	if err != nil {
+		return nil, err
+	}
+	entry.Image = ref
+	entry.Format = img.Pkg
+	entry.Candy = slices.Sorted(slices.Values(chain))
+	return entry, nil
+}
+
+// queryBoxVersions runs the format and builder lock_query scripts against ref.
+// Every query runs in ONE throwaway container (the image's own user, so
+// builder queries see its HOME), each in a subshell behind a marker line.
+// Shared by `charly box lock` and `charly box sbom`.
+func queryBoxVersions(engine string, img *ResolvedBox, ref string) (*BoxLockEntry, error) {
+	var script strings.Builder
+	if img.DistroDef != nil {
+		if f := img.DistroDef.Format[img.Pkg]; f != nil && f.LockQuery != "" {
+			fmt.Fprintf(&script, "echo '%s package'\n(\n%s\n)\n", boxLockMarker, f.LockQuery)
+		}
+	}
+	if img.BuilderConfig != nil {
+		for _, bn := range sortedMapKeys(img.BuilderConfig.Builder) {
+			if q := img.BuilderConfig.Builder[bn].LockQuery; q != "" {
+				fmt.Fprintf(&script, "echo '%s builder %s'\n(\n%s\n)\n", boxLockMarker, bn, q)
+			}
+		}
+	}
+	if script.Len() == 0 {
+		return nil, fmt.Errorf("box %s: format %q declares no lock_query", img.Name, img.Pkg)
+	}
+	cmd := exec.Command(EngineBinary(engine), "run", "--rm", "--entrypoint", "sh", ref, "-c", script.String())
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("box %s: querying %s: %w: %s", img.Name, ref, err, strings.TrimSpace(stderr.String()))
+	}
+	return parseBoxLockOutput(out), nil
+}
+
+const boxLockMarker = "#charly-lock"
+
+// parseBoxLockOutput splits the marker-delimited query output into the entry's
+// package and builder maps. Query lines are "name<TAB>version", a package line
+// optionally with a third "<TAB>source-package" column; anything else (blank
+// lines, tool chatter) is skipped.
+func parseBoxLockOutput(out []byte) *BoxLockEntry {
+	entry := &BoxLockEntry{Package: map[string]string{}}
+	var cur map[string]string
+	inPackage := false
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := sc.Text()
+		if rest, ok := strings.CutPrefix(line, boxLockMarker+" "); ok {
+			kind, builder, _ := strings.Cut(rest, " ")
+			inPackage = kind == "package"
+			switch kind {
+			case "package":
+				cur = entry.Package
+			case "builder":
+				if entry.Builder == nil {
+					entry.Builder = map[string]map[string]string{}
+				}
+				cur = map[string]string{}
+				entry.Builder[builder] = cur
+			}
+			continue
+		}
+		name, version, ok := strings.Cut(line, "\t")
+		if !ok || cur == nil || name == "" || version == "" {
+			continue
+		}
+		version, source, _ := strings.Cut(version, "\t")
+		name, version, source = strings.TrimSpace(name), strings.TrimSpace(version), strings.TrimSpace(source)
+		if version == "" {
+			continue
+		}
+		cur[name] = version
+		if inPackage && source != "" && source != name {
+			if entry.Source == nil {
+				entry.Source = map[string]string{}
+			}
+			entry.Source[name] = source
+		}
+	}
+	for b, m := range entry.Builder {
+		if len(m) == 0 {
+			delete(entry.Builder, b)
+		}
+	}
+	if len(entry.Builder) == 0 {
+		entry.Builder = nil
+	}
+	return entry
+}
+
+// lockedPackages returns the recorded versions that apply to img. A box with
+// its own entry uses it; an auto intermediate (or an unlocked base) borrows the
+// entry of a box built on top of it — that image contains the same layers.
+func (g *Generator) lockedPackages(img *ResolvedBox) map[string]string {
+	if g.Lock == nil {
+		return nil
+	}
+	if e := g.Lock.Box[img.Name]; e != nil && e.Format == img.Pkg {
+		return e.Package
+	}
+	for _, name := range sortedMapKeys(g.Lock.Box) {
+		e := g.Lock.Box[name]
+		if e.Format == img.Pkg && boxChainContains(g.Boxes, name, img.Name) {
+			return e.Package
+		}
+	}
+	return nil
+}
+
+// boxChainContains reports whether ancestor is on box's base chain.
+func boxChainContains(boxes map[string]*ResolvedBox, box, ancestor string) bool {
+	seen := map[string]bool{}
+	for cur, ok := boxes[box]; ok && !cur.IsExternalBase && !seen[cur.Name]; cur, ok = boxes[cur.Base] {
+		seen[cur.Name] = true
+		if cur.Base == ancestor {
+			return true
+		}
+	}
+	return false
+}
+
+// pinPackages rewrites the packages the lock knows into the format's pinned
+// install spec (pin_template); the rest pass through unchanged.
+func (g *Generator) pinPackages(img *ResolvedBox, formatDef *FormatDef, pkgs []string) []string {
+	if formatDef == nil || formatDef.PinTemplate == "" {
+		return pkgs
+	}
+	versions := g.lockedPackages(img)
+	if len(versions) == 0 {
+		return pkgs
+	}
+	out := make([]string, len(pkgs))
+	for i, p := range pkgs {
+		out[i] = p
+		v, ok := versions[p]
+		if !ok {
+			continue
+		}
+		pinned, err := RenderTemplate(img.Pkg+"-pin", formatDef.PinTemplate, struct{ Name, Version string }{p, v})
+		if err != nil {
+			continue
+		}
+		out[i] = strings.TrimSpace(pinned)
+	}
+	return out
+}
+
+// lockPackageName reports whether a declared package entry is a plain package
+// name the lock can key on — not a path, URL, repo-qualified or virtual spec.
+func lockPackageName(p string) bool {
+	return p != "" && !strings.ContainsAny(p, "/:@()<>= ")
+}
+
+// validateBoxLock warns (never fails) when charly.lock is stale: a box without
+// an entry, a box whose candy chain changed since it was locked, declared
+// packages the lock has no version for, or entries for boxes that are gone.
+func validateBoxLock(cfg *Config, layers map[string]*Candy, dir string, opts ResolveOpts) {
+	lock, err := LoadBoxLock(dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		return
+	}
+	if lock == nil {
+		return
+	}
+	for _, name := range cfg.BoxNames() {
+		img := cfg.Box[name]
+		if !img.IsEnabled() && !opts.shouldIncludeDisabled(name) {
+			continue
+		}
+		entry := lock.Box[name]
+		if entry == nil {
+			fmt.Fprintf(os.Stderr, "Warning: box %q has no entry in %s (run `charly box lock %s`)\n", name, BoxLockFile, name)
+			continue
+		}
+		chain, err := cfg.boxCandyChain(layers, name)
+		if err != nil {
+			continue
+		}
+		if !slices.Equal(slices.Sorted(slices.Values(chain)), entry.Candy) {
+			fmt.Fprintf(os.Stderr, "Warning: box %q: candy list changed since it was locked (run `charly box lock --refresh %s`)\n", name, name)
+			continue
+		}
+		resolved, err := cfg.ResolveBox(name, "test", dir, opts)
+		if err != nil || resolved.Pkg != entry.Format {
+			continue
+		}
+		var missing []string
+		for _, cn := range chain {
+			layer, ok := layers[cn]
+			if !ok {
+				continue
+			}
+			pkgs, _, _ := resolveCascadePackages(layer, resolved)
+			for _, p := range pkgs {
+				if _, ok := entry.Package[p]; !ok && lockPackageName(p) && !slices.Contains(missing, p) {
+					missing = append(missing, p)
+				}
+			}
+		}
+		if len(missing) > 0 {
+			fmt.Fprintf(os.Stderr, "Warning: box %q: %d declared package(s) not in %s: %s (run `charly box lock --refresh %s`)\n",
+				name, len(missing), BoxLockFile, strings.Join(missing, ", "), name)
+		}
+	}
+	for _, name := range sortedMapKeys(lock.Box) {
+		if _, ok := cfg.Box[name]; !ok {
+			fmt.Fprintf(os.Stderr, "Warning: %s has an entry for unknown box %q\n", BoxLockFile, name)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseBoxLockOutput(t *testing.T) {
+	out := []byte("#charly-lock package\n" +
+		"bash\t5.2.26-3.fc43\n" +
+		"curl\t8.9.1-2.fc43\n" +
+		"libssl3\t3.5.0-2\topenssl\n" +
+		"openssl\t3.5.0-2\topenssl\n" +
+		"\n" +
+		"stray chatter\n" +
+		"#charly-lock builder pixi\n" +
+		"numpy\t2.1.0\n" +
+		"#charly-lock builder npm\n")
+	got := parseBoxLockOutput(out)
+	want := &BoxLockEntry{
+		Package: map[string]string{"bash": "5.2.26-3.fc43", "curl": "8.9.1-2.fc43", "libssl3": "3.5.0-2", "openssl": "3.5.0-2"},
+		Builder: map[string]map[string]string{"pixi": {"numpy": "2.1.0"}},
+		Source:  map[string]string{"libssl3": "openssl"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseBoxLockOutput = %+v, want %+v", got, want)
+	}
+}
+
+func TestPinPackages(t *testing.T) {
+	boxes := map[string]*ResolvedBox{
+		"base": {Name: "base", Base: "quay.io/fedora/fedora:43", IsExternalBase: true, Pkg: "rpm", Auto: true},
+		"app":  {Name: "app", Base: "base", Pkg: "rpm"},
+	}
+	g := &Generator{
+		Boxes: boxes,
+		Lock: &BoxLock{Version: boxLockVersion, Box: map[string]*BoxLockEntry{
+			"app": {Format: "rpm", Package: map[string]string{"bash": "5.2.26-3.fc43", "curl": "8.9.1-2.fc43"}},
+		}},
+	}
+	rpm := &FormatDef{PinTemplate: "{{.Name}}-{{.Version}}"}
+	pkgs := []string{"bash", "git", "/tmp/local.rpm"}
+
+	want := []string{"bash-5.2.26-3.fc43", "git", "/tmp/local.rpm"}
+	if got := g.pinPackages(boxes["app"], rpm, pkgs); !reflect.DeepEqual(got, want) {
+		t.Errorf("app = %v, want %v", got, want)
+	}
+	// The auto intermediate borrows the entry of the box built on it.
+	if got := g.pinPackages(boxes["base"], rpm, pkgs); !reflect.DeepEqual(got, want) {
+		t.Errorf("base = %v, want %v", got, want)
+	}
+	// No pin_template, or no lock: packages pass through.
+	if got := g.pinPackages(boxes["app"], &FormatDef{}, pkgs); !reflect.DeepEqual(got, pkgs) {
+		t.Errorf("no template = %v, want %v", got, pkgs)
+	}
+	g.Lock = nil
+	if got := g.pinPackages(boxes["app"], rpm, pkgs); !reflect.DeepEqual(got, pkgs) {
+		t.Errorf("unlocked = %v, want %v", got, pkgs)
+	}
+}
+
+func TestBoxLockRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	if lock, err := LoadBoxLock(dir); err != nil || lock != nil {
+		t.Fatalf("LoadBoxLock(empty) = %v, %v; want nil, nil", lock, err)
+	}
+	lock := &BoxLock{Version: boxLockVersion, Box: map[string]*BoxLockEntry{
+		"app": {
+			Image:   "localhost/app:latest",
+			Format:  "deb",
+			Candy:   []string{"python", "web"},
+			Package: map[string]string{"bash": "5.2.15-2+b7"},
+			Builder: map[string]map[string]string{"npm": {"typescript": "5.6.2"}},
+		},
+	}}
+	if err := SaveBoxLock(dir, lock); err != nil {
+		t.Fatal(err)
+	}
+	back, err := LoadBoxLock(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(back, lock) {
+		t.Errorf("round trip = %+v, want %+v", back, lock)
+	}
+}
@@ -0,0 +1,515 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// box_sbom.go — `charly box sbom`, the software bill of materials of a built box.
+//
+// The component inventory is read from the IMAGE, not the config: the distro
+// package database and the pixi/npm/cargo builder outputs through the same
+// lock_query scripts `charly box lock` runs (queryBoxVersions). Config only
+// supplies provenance — each component is attributed to the candy that declared
+// it (resolveCascadePackages, the aur section, the builder detect files); a
+// package no candy declared came with the base image. localpkg packages and
+// `download:` steps are not visible to any package manager and are taken from
+// the candy manifests. Two renderings: SPDX 2.3 (candies are SPDX packages that
+// CONTAIN their components) and CycloneDX 1.5 (candy as a component property).
+//
+// `charly box sbom --attach` and `charly box build --push --sbom` attach both
+// documents to the pushed image as OCI referrers (oras attach).
+
+// SBOM media types, also the referrer artifact types.
+const (
+	sbomSPDXMediaType      = "application/spdx+json"
+	sbomCycloneDXMediaType = "application/vnd.cyclonedx+json"
+)
+
+// BoxSbomCmd implements `charly box sbom`.
+type BoxSbomCmd struct {
+	Box    string `arg:"" help:"Box name (must be built)"`
+	Format string `long:"format" default:"spdx" enum:"spdx,cyclonedx" help:"SBOM format (spdx|cyclonedx)"`
+	Output string `short:"o" long:"output" help:"Write to file instead of stdout"`
+	Attach bool   `long:"attach" help:"Attach SPDX and CycloneDX SBOMs to the box's pushed image as OCI referrers (requires oras)"`
+}
+
+func (c *BoxSbomCmd) Run() error {
+	dir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	gen, err := NewGenerator(dir, "", boxResolveOpts([]string{c.Box}, false))
+	if err != nil {
+		return err
+	}
+	img, ok := gen.Boxes[c.Box]
+	if !ok {
+		return fmt.Errorf("box %q not found", c.Box)
+	}
+	rt, err := ResolveRuntime()
+	if err != nil {
+		return err
+	}
+	if c.Attach {
+		return attachBoxSBOM(rt.BuildEngine, gen, c.Box, img.FullTag)
+	}
+	ref, err := resolveLocalImageRef(rt.BuildEngine, c.Box)
+	if err != nil {
+		return fmt.Errorf("box %s is not built (run `charly box build %s` first): %w", c.Box, c.Box, err)
+	}
+	sbom, err := readBoxSBOM(rt.BuildEngine, gen, c.Box, ref)
+	if err != nil {
+		return err
+	}
+	w := io.Writer(os.Stdout)
+	if c.Output != "" {
+		f, err := os.Create(c.Output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	return writeBoxSBOM(w, sbom, c.Format)
+}
+
+// boxSBOM is the format-neutral inventory of one image.
+type boxSBOM struct {
+	Box        string
+	Version    string
+	Image      string // image ref the inventory was read from
+	ImageID    string
+	Arch       string // `uname -m` form, substituted into download URLs
+	Distro     string // purl namespace of distro packages
+	Created    time.Time
+	Candies    []sbomCandy
+	Components []sbomComponent
+}
+
+type sbomCandy struct {
+	Name    string
+	Version string
+}
+
+// sbomComponent is one installed thing. Source is the package format (rpm, deb,
+// pac, apk, aur), the builder (pixi, npm, cargo), "localpkg" or "download".
+// Candy lists the candies of origin; empty means the base image.
+type sbomComponent struct {
+	Name          string
+	Version       string
+	Source        string
+	SourcePackage string // distro source package when it differs from Name (deb: libssl3 → openssl)
+	Candy         []string
+	URL           string // download source (downloads only)
+}
+
+// readBoxSBOM queries the image at ref and attributes what it finds.
+func readBoxSBOM(engine string, gen *Generator, name, ref string) (*boxSBOM, error) {
+	img := gen.Boxes[name]
+	entry, err := queryBoxVersions(engine, img, ref)
+	if err != nil {
+		return nil, err
+	}
+	out, err := exec.Command(EngineBinary(engine), "image", "inspect", "--format", "{{.Id}} {{.Architecture}}", ref).Output()
+	if err != nil {
+		return nil, fmt.Errorf("inspecting %s: %w", ref, err)
+	}
+	id, arch, _ := strings.Cut(strings.TrimSpace(string(out)), " ")
+	chain, err := gen.Config.boxCandyChain(gen.Candies, name)
+	if err != nil {
+		return nil, err
+	}
+	sbom := newBoxSBOM(img, chain, gen.Candies, entry, unameArch(arch))
+	sbom.Image = ref
+	sbom.ImageID = id
+	sbom.Created = time.Now().UTC()
+	return sbom, nil
+}
+
+// newBoxSBOM builds the inventory from the queried versions and the candy chain.
+func newBoxSBOM(img *ResolvedBox, chain []string, layers map[string]*Candy, entry *BoxLockEntry, arch string) *boxSBOM {
+	sbom := &boxSBOM{Box: img.Name, Version: img.EffectiveVersion, Arch: arch}
+	if len(img.Distro) > 0 {
+		sbom.Distro, _, _ = strings.Cut(img.Distro[0], ":")
+	}
+
+	declared := map[string][]string{} // package → candies
+	aur := map[string]bool{}
+	var builders map[string]*BuilderDef
+	if img.BuilderConfig != nil {
+		builders = img.BuilderConfig.Builder
+	}
+	builderCandies := map[string][]string{}
+	for _, cn := range slices.Sorted(slices.Values(chain)) {
+		layer, ok := layers[cn]
+		if !ok {
+			continue
+		}
+		sbom.Candies = append(sbom.Candies, sbomCandy{Name: cn, Version: layer.Version})
+		pkgs, _, _ := resolveCascadePackages(layer, img)
+		if s := layer.FormatSection("aur"); s != nil {
+			for _, p := range s.Packages {
+				aur[p] = true
+				pkgs = append(pkgs, p)
+			}
+		}
+		for _, p := range pkgs {
+			if !slices.Contains(declared[p], cn) {
+				declared[p] = append(declared[p], cn)
+			}
+		}
+		for _, bn := range sortedMapKeys(builders) {
+			if candyNeedsBuilderStep(layer, builders[bn]) {
+				builderCandies[bn] = append(builderCandies[bn], cn)
+			}
+		}
+		if src := layer.LocalPkg(img.Pkg); src != "" {
+			sbom.Components = append(sbom.Components, sbomComponent{
+				Name: filepath.Base(src), Version: layer.Version, Source: "localpkg", Candy: []string{cn},
+			})
+		}
+		for _, op := range layer.runOps() {
+			if op.Download == "" {
+				continue
+			}
+			u := strings.NewReplacer("${BUILD_ARCH}", arch, "$BUILD_ARCH", arch).Replace(op.Download)
+			name := u
+			if parsed, err := url.Parse(u); err == nil {
+				name = path.Base(parsed.Path)
+			}
+			sbom.Components = append(sbom.Components, sbomComponent{
+				Name: name, Source: "download", Candy: []string{cn}, URL: u,
+			})
+		}
+	}
+
+	if entry != nil {
+		for _, p := range sortedMapKeys(entry.Package) {
+			source := img.Pkg
+			if aur[p] {
+				source = "aur"
+			}
+			sbom.Components = append(sbom.Components, sbomComponent{
+				Name: p, Version: entry.Package[p], Source: source, SourcePackage: entry.Source[p], Candy: declared[p],
+			})
+		}
+		for _, bn := range sortedMapKeys(entry.Builder) {
+			for _, p := range sortedMapKeys(entry.Builder[bn]) {
+				sbom.Components = append(sbom.Components, sbomComponent{
+					Name: p, Version: entry.Builder[bn][p], Source: bn, Candy: builderCandies[bn],
+				})
+			}
+		}
+	}
+	return sbom
+}
+
+// purl returns the package URL of a component.
+func (s *boxSBOM) purl(c sbomComponent) string {
+	typ, ns := "generic", ""
+	switch c.Source {
+	case "rpm", "deb", "apk":
+		typ, ns = c.Source, s.Distro
+	case "pac":
+		typ, ns = "alpm", s.Distro
+	case "aur":
+		typ, ns = "alpm", "aur"
+	case "npm", "cargo":
+		typ = c.Source
+	case "pixi":
+		typ = "conda"
+	}
+	var b strings.Builder
+	b.WriteString("pkg:" + typ + "/")
+	if ns != "" {
+		b.WriteString(purlEscape(ns) + "/")
+	}
+	name := c.Name
+	if scope, rest, ok := strings.Cut(name, "/"); ok && typ == "npm" {
+		b.WriteString(purlEscape(scope) + "/")
+		name = rest
+	}
+	b.WriteString(purlEscape(name))
+	if c.Version != "" {
+		b.WriteString("@" + purlEscape(c.Version))
+	}
+	if c.URL != "" {
+		b.WriteString("?download_url=" + url.QueryEscape(c.URL))
+	}
+	return b.String()
+}
+
+// purlEscape percent-encodes a purl path segment; "@" separates the version, so
+// it is escaped too.
+func purlEscape(s string) string {
+	return strings.ReplaceAll(url.PathEscape(s), "@", "%40")
+}
+
+// writeBoxSBOM renders the inventory in the requested format.
+func writeBoxSBOM(w io.Writer, s *boxSBOM, format string) error {
+	var doc any
+	switch format {
+	case "spdx":
+		doc = s.spdx()
+	case "cyclonedx":
+		doc = s.cycloneDX()
+	default:
+		return fmt.Errorf("unknown SBOM format %q (want spdx or cyclonedx)", format)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// --- SPDX 2.3 ---
+
+type spdxDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+	Packages          []spdxPackage      `json:"packages"`
+	Relationships     []spdxRelationship `json:"relationships"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	Name                  string            `json:"name"`
+	SPDXID                string            `json:"SPDXID"`
+	VersionInfo           string            `json:"versionInfo,omitempty"`
+	DownloadLocation      string            `json:"downloadLocation"`
+	FilesAnalyzed         bool              `json:"filesAnalyzed"`
+	PrimaryPackagePurpose string            `json:"primaryPackagePurpose,omitempty"`
+	ExternalRefs          []spdxExternalRef `json:"externalRefs,omitempty"`
+	Comment               string            `json:"comment,omitempty"`
+}
+
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type spdxRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+var spdxIDUnsafe = regexp.MustCompile(`[^A-Za-z0-9.-]+`)
+
+func spdxID(kind, name string) string {
+	return "SPDXRef-" + kind + "-" + spdxIDUnsafe.ReplaceAllString(name, "-")
+}
+
+func (s *boxSBOM) spdx() *spdxDocument {
+	root := spdxID("Box", s.Box)
+	doc := &spdxDocument{
+		SPDXVersion:       "SPDX-2.3",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              s.Box,
+		DocumentNamespace: "https://opencharly.ai/spdx/" + url.PathEscape(s.Box) + "/" + s.serial(),
+		CreationInfo: spdxCreationInfo{
+			Created:  s.Created.Format(time.RFC3339),
+			Creators: []string{"Tool: charly-" + CharlyVersion()},
+		},
+		Packages: []spdxPackage{{
+			Name:                  s.Box,
+			SPDXID:                root,
+			VersionInfo:           s.Version,
+			DownloadLocation:      "NOASSERTION",
+			PrimaryPackagePurpose: "CONTAINER",
+			Comment:               strings.TrimSpace("image " + s.Image + " " + s.ImageID),
+		}},
+		Relationships: []spdxRelationship{{SPDXElementID: "SPDXRef-DOCUMENT", RelationshipType: "DESCRIBES", RelatedSPDXElement: root}},
+	}
+	for _, c := range s.Candies {
+		id := spdxID("Candy", c.Name)
+		doc.Packages = append(doc.Packages, spdxPackage{
+			Name: c.Name, SPDXID: id, VersionInfo: c.Version, DownloadLocation: "NOASSERTION",
+			PrimaryPackagePurpose: "SOURCE", Comment: "charly candy",
+		})
+		doc.Relationships = append(doc.Relationships, spdxRelationship{SPDXElementID: root, RelationshipType: "CONTAINS", RelatedSPDXElement: id})
+	}
+	seen := map[string]int{}
+	for _, c := range s.Components {
+		id := spdxID(c.Source, c.Name)
+		if n := seen[id]; n > 0 {
+			id = fmt.Sprintf("%s-%d", id, n)
+		}
+		seen[spdxID(c.Source, c.Name)]++
+		loc := "NOASSERTION"
+		purpose := "LIBRARY"
+		if c.Source == "download" {
+			loc, purpose = c.URL, "FILE"
+		}
+		doc.Packages = append(doc.Packages, spdxPackage{
+			Name: c.Name, SPDXID: id, VersionInfo: c.Version, DownloadLocation: loc,
+			PrimaryPackagePurpose: purpose,
+			ExternalRefs:          []spdxExternalRef{{ReferenceCategory: "PACKAGE-MANAGER", ReferenceType: "purl", ReferenceLocator: s.purl(c)}},
+			Comment:               "source: " + c.Source,
+		})
+		if len(c.Candy) == 0 {
+			doc.Relationships = append(doc.Relationships, spdxRelationship{SPDXElementID: root, RelationshipType: "CONTAINS", RelatedSPDXElement: id})
+		}
+		for _, cn := range c.Candy {
+			doc.Relationships = append(doc.Relationships, spdxRelationship{SPDXElementID: spdxID("Candy", cn), RelationshipType: "CONTAINS", RelatedSPDXElement: id})
+		}
+	}
+	return doc
+}
+
+// --- CycloneDX 1.5 ---
+
+type cdxDocument struct {
+	BOMFormat    string          `json:"bomFormat"`
+	SpecVersion  string          `json:"specVersion"`
+	SerialNumber string          `json:"serialNumber"`
+	Version      int             `json:"version"`
+	Metadata     cdxMetadata     `json:"metadata"`
+	Components   []cdxComponent  `json:"components"`
+	Dependencies []cdxDependency `json:"dependencies"`
+}
+
+type cdxMetadata struct {
+	Timestamp string       `json:"timestamp"`
+	Tools     cdxTools     `json:"tools"`
+	Component cdxComponent `json:"component"`
+}
+
+type cdxTools struct {
+	Components []cdxComponent `json:"components"`
+}
+
+type cdxComponent struct {
+	Type       string        `json:"type"`
+	BOMRef     string        `json:"bom-ref,omitempty"`
+	Name       string        `json:"name"`
+	Version    string        `json:"version,omitempty"`
+	PURL       string        `json:"purl,omitempty"`
+	Properties []cdxProperty `json:"properties,omitempty"`
+}
+
+type cdxProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type cdxDependency struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn,omitempty"`
+}
+
+func (s *boxSBOM) cycloneDX() *cdxDocument {
+	root := "box:" + s.Box
+	doc := &cdxDocument{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  "1.5",
+		SerialNumber: "urn:uuid:" + s.serial(),
+		Version:      1,
+		Metadata: cdxMetadata{
+			Timestamp: s.Created.Format(time.RFC3339),
+			Tools:     cdxTools{Components: []cdxComponent{{Type: "application", Name: "charly", Version: CharlyVersion()}}},
+			Component: cdxComponent{Type: "container", BOMRef: root, Name: s.Box, Version: s.Version},
+		},
+		Components: []cdxComponent{},
+	}
+	if s.Image != "" {
+		doc.Metadata.Component.Properties = []cdxProperty{{Name: "ai.opencharly:image", Value: s.Image}, {Name: "ai.opencharly:image-id", Value: s.ImageID}}
+	}
+	dep := cdxDependency{Ref: root}
+	seen := map[string]int{}
+	for _, c := range s.Components {
+		ref := s.purl(c)
+		if n := seen[ref]; n > 0 {
+			ref = fmt.Sprintf("%s#%d", ref, n)
+		}
+		seen[s.purl(c)]++
+		typ := "library"
+		if c.Source == "download" {
+			typ = "file"
+		}
+		props := []cdxProperty{{Name: "ai.opencharly:source", Value: c.Source}}
+		for _, cn := range c.Candy {
+			props = append(props, cdxProperty{Name: "ai.opencharly:candy", Value: cn})
+		}
+		doc.Components = append(doc.Components, cdxComponent{
+			Type: typ, BOMRef: ref, Name: c.Name, Version: c.Version, PURL: s.purl(c), Properties: props,
+		})
+		dep.DependsOn = append(dep.DependsOn, ref)
+	}
+	doc.Dependencies = []cdxDependency{dep}
+	return doc
+}
+
+// serial is a name-based (version 5 shaped, RFC 4122 variant) UUID stable for
+// one image (its ID) so the SPDX namespace and the CycloneDX serial of the
+// same image agree. CycloneDX validates it against the RFC 4122 pattern.
+func (s *boxSBOM) serial() string {
+	sum := sha256.Sum256([]byte(s.Box + "\x00" + s.ImageID))
+	sum[6] = sum[6]&0x0f | 0x50
+	sum[8] = sum[8]&0x3f | 0x80
+	h := hex.EncodeToString(sum[:16])
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:32]
+}
+
+// attachBoxSBOM reads the SBOM of the pushed image ref and attaches both
+// renderings to it as OCI referrers with `oras attach`.
+func attachBoxSBOM(engine string, gen *Generator, name, ref string) error {
+	oras, err := exec.LookPath("oras")
+	if err != nil {
+		return fmt.Errorf("attaching SBOM to %s needs oras on PATH: %w", ref, err)
+	}
+	sbom, err := readBoxSBOM(engine, gen, name, ref)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.MkdirTemp("", "charly-sbom-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+	for _, f := range []struct{ format, file, mediaType string }{
+		{"spdx", "sbom.spdx.json", sbomSPDXMediaType},
+		{"cyclonedx", "sbom.cdx.json", sbomCycloneDXMediaType},
+	} {
+		out, err := os.Create(filepath.Join(tmp, f.file))
+		if err != nil {
+			return err
+		}
+		err = writeBoxSBOM(out, sbom, f.format)
+		out.Close()
+		if err != nil {
+			return err
+		}
+		cmd := exec.Command(oras, "attach", "--artifact-type", f.mediaType, ref, f.file+":"+f.mediaType)
+		cmd.Dir = tmp
+		cmd.Stdout = os.Stderr
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("oras attach %s to %s: %w", f.file, ref, err)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Attached SBOM (%d components) to %s\n", len(sbom.Components), ref)
+	return nil
+}
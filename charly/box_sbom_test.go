@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+var cdxSerialPattern = regexp.MustCompile(`^urn:uuid:[0-9a-f]{8}-[0-9a-f]{4}-[1-5][0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+// testSBOM is a fedora box whose chain is tools (git, a localpkg, a download)
+// and web (npm outputs); bash came with the base image.
+func testSBOM() *boxSBOM {
+	layers := map[string]*Candy{
+		"tools": {
+			Name: "tools", Version: "2026.100.1200",
+			topPackages: []string{"git"},
+			localpkg:    map[string]string{"rpm": "pkg/charly"},
+			plan: []Step{{Run: "build", Op: Op{
+				Download: "https://example.com/rel/tool-${BUILD_ARCH}.tar.gz", To: "/usr/local",
+			}}},
+		},
+		"web": {Name: "web", Version: "2026.101.900", HasPackageJson: true},
+	}
+	img := &ResolvedBox{
+		Name: "app", EffectiveVersion: "2026.101.900", Pkg: "rpm", Distro: []string{"fedora:43", "fedora"},
+		BuilderConfig: &BuilderConfig{Builder: map[string]*BuilderDef{"npm": {DetectFiles: []string{"package.json"}}}},
+	}
+	entry := &BoxLockEntry{
+		Package: map[string]string{"bash": "5.2.26-3.fc43", "git": "2.46.0-1.fc43"},
+		Builder: map[string]map[string]string{"npm": {"@scope/cli": "1.2.0"}},
+	}
+	s := newBoxSBOM(img, []string{"web", "tools"}, layers, entry, "x86_64")
+	s.ImageID = "sha256:0123"
+	s.Created = time.Date(2026, 4, 10, 12, 0, 0, 0, time.UTC)
+	return s
+}
+
+func TestNewBoxSBOM(t *testing.T) {
+	s := testSBOM()
+	got := map[string]sbomComponent{}
+	for _, c := range s.Components {
+		got[c.Source+":"+c.Name] = c
+	}
+	want := map[string]sbomComponent{
+		"localpkg:charly":             {Name: "charly", Version: "2026.100.1200", Source: "localpkg", Candy: []string{"tools"}},
+		"download:tool-x86_64.tar.gz": {Name: "tool-x86_64.tar.gz", Source: "download", Candy: []string{"tools"}, URL: "https://example.com/rel/tool-x86_64.tar.gz"},
+		"rpm:bash":                    {Name: "bash", Version: "5.2.26-3.fc43", Source: "rpm"},
+		"rpm:git":                     {Name: "git", Version: "2.46.0-1.fc43", Source: "rpm", Candy: []string{"tools"}},
+		"npm:@scope/cli":              {Name: "@scope/cli", Version: "1.2.0", Source: "npm", Candy: []string{"web"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("components = %+v\nwant %+v", got, want)
+	}
+	if want := []sbomCandy{{"tools", "2026.100.1200"}, {"web", "2026.101.900"}}; !reflect.DeepEqual(s.Candies, want) {
+		t.Errorf("candies = %+v, want %+v", s.Candies, want)
+	}
+}
+
+func TestBoxSBOMPurl(t *testing.T) {
+	s := testSBOM()
+	for _, tc := range []struct {
+		c    sbomComponent
+		want string
+	}{
+		{sbomComponent{Name: "git", Version: "2.46.0-1.fc43", Source: "rpm"}, "pkg:rpm/fedora/git@2.46.0-1.fc43"},
+		{sbomComponent{Name: "yay", Version: "12.3.5-1", Source: "aur"}, "pkg:alpm/aur/yay@12.3.5-1"},
+		{sbomComponent{Name: "@scope/cli", Version: "1.2.0", Source: "npm"}, "pkg:npm/%40scope/cli@1.2.0"},
+		{sbomComponent{Name: "numpy", Version: "2.1.0", Source: "pixi"}, "pkg:conda/numpy@2.1.0"},
+		{sbomComponent{Name: "t.tgz", Source: "download", URL: "https://x/t.tgz"}, "pkg:generic/t.tgz?download_url=https%3A%2F%2Fx%2Ft.tgz"},
+	} {
+		if got := s.purl(tc.c); got != tc.want {
+			t.Errorf("purl(%s) = %q, want %q", tc.c.Name, got, tc.want)
+		}
+	}
+}
+
+func TestWriteBoxSBOM(t *testing.T) {
+	s := testSBOM()
+
+	var spdx bytes.Buffer
+	if err := writeBoxSBOM(&spdx, s, "spdx"); err != nil {
+		t.Fatal(err)
+	}
+	var sd spdxDocument
+	if err := json.Unmarshal(spdx.Bytes(), &sd); err != nil {
+		t.Fatalf("spdx output does not parse: %v", err)
+	}
+	if sd.SPDXVersion != "SPDX-2.3" || len(sd.Packages) != 1+2+5 {
+		t.Errorf("spdx: version %q, %d packages; want SPDX-2.3 with 8", sd.SPDXVersion, len(sd.Packages))
+	}
+	for _, want := range []spdxRelationship{
+		{"SPDXRef-Box-app", "CONTAINS", "SPDXRef-rpm-bash"},
+		{"SPDXRef-Candy-tools", "CONTAINS", "SPDXRef-rpm-git"},
+		{"SPDXRef-Candy-web", "CONTAINS", "SPDXRef-npm--scope-cli"},
+	} {
+		found := false
+		for _, r := range sd.Relationships {
+			found = found || r == want
+		}
+		if !found {
+			t.Errorf("spdx missing relationship %+v", want)
+		}
+	}
+
+	var cdx bytes.Buffer
+	if err := writeBoxSBOM(&cdx, s, "cyclonedx"); err != nil {
+		t.Fatal(err)
+	}
+	var cd cdxDocument
+	if err := json.Unmarshal(cdx.Bytes(), &cd); err != nil {
+		t.Fatalf("cyclonedx output does not parse: %v", err)
+	}
+	if cd.BOMFormat != "CycloneDX" || len(cd.Components) != 5 || len(cd.Dependencies[0].DependsOn) != 5 {
+		t.Errorf("cyclonedx: %s with %d components", cd.BOMFormat, len(cd.Components))
+	}
+	if !strings.HasSuffix(sd.DocumentNamespace, strings.TrimPrefix(cd.SerialNumber, "urn:uuid:")) {
+		t.Errorf("spdx namespace %q and cyclonedx serial %q disagree", sd.DocumentNamespace, cd.SerialNumber)
+	}
+	// The CycloneDX 1.5 schema's serialNumber pattern (RFC 4122 variant bits).
+	if !cdxSerialPattern.MatchString(cd.SerialNumber) {
+		t.Errorf("cyclonedx serial %q does not match the schema pattern", cd.SerialNumber)
+	}
+	for _, id := range []string{"sha256:0", "sha256:1", "sha256:2", "sha256:3", "sha256:4", "sha256:5"} {
+		if serial := "urn:uuid:" + (&boxSBOM{Box: "web", ImageID: id}).serial(); !cdxSerialPattern.MatchString(serial) {
+			t.Errorf("serial for %s = %q, not RFC 4122", id, serial)
+		}
+	}
+
+	if err := writeBoxSBOM(&cdx, s, "swid"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
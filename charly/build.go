@@ -15,6 +15,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"text/template"
 	"time"
 
@@ -37,12 +38,29 @@ type BuildCmd struct {
 	PodmanJobs      int      `long:"podman-jobs" help:"Stages per podman build (0=auto: min(NCPU, defaults.podman_jobs_cap))" env:"CHARLY_PODMAN_JOBS"`
 	IncludeDisabled bool     `long:"include-disabled" help:"Build boxes with enabled: false in charly.yml (does not modify the file). Use for one-off operational rebuilds without flipping authored config."`
 	DevLocalPkg     bool     `long:"dev-local-pkg" help:"Build localpkg candies (the charly toolchain) from LOCAL in-development source instead of downloading the published release. Set automatically for disposable check-bed image builds so a bed tests in-development code; never on a production box build."`
+	Unlocked        bool     `long:"unlocked" help:"Ignore charly.lock: install the newest packages the mirrors serve instead of the locked versions (charly box lock --refresh re-records them)"`
+	SBOM            bool     `long:"sbom" help:"With --push: attach SPDX and CycloneDX SBOMs to every pushed image as OCI referrers (requires oras)"`
+	Sign            string   `long:"sign" placeholder:"KEY" help:"With --push: sign every pushed image with this credential-store signing key (charly box keygen)"`
+	Profile         bool     `long:"profile" help:"Profile the build: per-box/stage/instruction wall time, cache hits, builder stages, pulled bytes and the critical path, written to .build/profile.json and .build/profile.trace.json (Chrome trace)"`
 
 	// podmanJobsCap is the resolved ceiling for the auto podman-jobs calc,
 	// sourced from defaults.podman_jobs_cap in Run() (0 → podmanJobsCapFallback).
 	// Not a CLI flag — the cap is a project-wide config knob; per-build
 	// overrides go through --podman-jobs / CHARLY_PODMAN_JOBS.
 	podmanJobsCap int
+
+	// profile collects --profile timings; nil when not profiling.
+	profile *buildProfiler
+
+	// buildHashes maps each selected box to its content hash (build_hash.go);
+	// skipped records the boxes satisfied by an existing image of that hash.
+	buildHashes map[string]string
+	skipMu      sync.Mutex
+	skipped     map[string]buildHashHit
+
+	// archVariants are the generator's per-arch Containerfile variants
+	// (Generator.ArchContainerfiles), built per platform on push.
+	archVariants map[string]map[string]string
 }
 
 // ensureBuilderImageBuilt resolves an internal builder-image name to its newest
@@ -132,6 +150,10 @@ func (c *BuildCmd) Run() error {
 		Dir:             dir,
 		IncludeDisabled: c.IncludeDisabled,
 		DevLocalPkg:     c.DevLocalPkg,
+		Unlocked:        c.Unlocked,
+		SBOM:            c.SBOM,
+		Sign:            c.Sign,
+		Profile:         c.Profile,
 		Push:            c.Push,
 		Platform:        c.Platform,
 		Cache:           c.Cache,
@@ -169,6 +191,13 @@ func runBoxBuild(req spec.BuildRequest) ([]string, error) {
 		PodmanJobs:      req.PodmanJobs,
 		IncludeDisabled: req.IncludeDisabled,
 		DevLocalPkg:     req.DevLocalPkg,
+		Unlocked:        req.Unlocked,
+		SBOM:            req.SBOM,
+		Sign:            req.Sign,
+		Profile:         req.Profile,
+	}
+	if c.Profile {
+		c.profile = newBuildProfiler()
 	}
 
 	// Generate Containerfiles via the shared box-selection rule. An empty selection builds
@@ -180,6 +209,7 @@ func runBoxBuild(req spec.BuildRequest) ([]string, error) {
 	// any root image. Remote (`@github…`) refs were already dispatched to buildRemote by
 	// BuildCmd.Run, so these are local names.
 	resolveOpts := boxResolveOpts(c.Boxes, c.IncludeDisabled)
+	resolveOpts.Platform = c.Platform
 	gen, err := NewGenerator(dir, c.Tag, resolveOpts)
 	if err != nil {
 		return nil, err
@@ -192,6 +222,9 @@ func runBoxBuild(req spec.BuildRequest) ([]string, error) {
 	// LOCAL in-development source; production boxes download the published release. The check-bed
 	// runner passes --dev-local-pkg (see check_bed_run.go).
 	gen.DevLocalPkg = c.DevLocalPkg
+	if c.Unlocked {
+		gen.Lock = nil
+	}
 	if err := gen.Generate(); err != nil {
 		return nil, fmt.Errorf("generating build files: %w", err)
 	}
@@ -207,6 +240,9 @@ func runBoxBuild(req spec.BuildRequest) ([]string, error) {
 	}
 
 	engine, buildEngine, built, err := c.buildImages(dir, gen)
+	if c.profile != nil {
+		c.profile.finishProfile(dir, gen)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -225,6 +261,9 @@ func runBoxBuild(req spec.BuildRequest) ([]string, error) {
 		}
 		fmt.Fprintf(os.Stderr, "\n=== Pushing images ===\n")
 		for _, name := range order {
+			if hit, ok := c.skippedBuild(name); ok && hit.Remote {
+				continue // already tagged in the registry by skipByBuildHash
+			}
 			img := gen.Boxes[name]
 			tags := imageTags(name, img, gen.Config)
 			if err := c.pushImage(dir, tags); err != nil {
@@ -233,6 +272,37 @@ func runBoxBuild(req spec.BuildRequest) ([]string, error) {
 		}
 	}
 
+	// Signatures and SBOM referrers go on the pushed tag, after the push so the
+	// registry has the subject.
+	if c.Push && (c.SBOM || c.Sign != "") {
+		order, err := ResolveBoxOrder(gen.Boxes, gen.Candies)
+		if err != nil {
+			return nil, err
+		}
+		if len(c.Boxes) > 0 {
+			if order, err = filterBox(order, c.Boxes, gen.Boxes); err != nil {
+				return nil, err
+			}
+		}
+		for _, name := range order {
+			ref := gen.Boxes[name].FullTag
+			if c.Sign != "" {
+				d, err := signImageRef(ref, c.Sign)
+				if err != nil {
+					return nil, fmt.Errorf("signing %s: %w", ref, err)
+				}
+				fmt.Fprintf(os.Stderr, "Signed %s (%s)\n", ref, d)
+			}
+			// A registry hit shares its digest (and referrers) with the
+			// earlier push; there is no local image to read the SBOM from.
+			if hit, ok := c.skippedBuild(name); c.SBOM && !(ok && hit.Remote) {
+				if err := attachBoxSBOM(buildEngine, gen, name, ref); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
 	// Reusable-artifact retention: prune old CalVer tags per image down to defaults.keep_images
 	// (in-use images skipped; rmi without -f). Skipped for push runs. keep_images: 0 / absent
 	// disables. See `charly clean`.
@@ -272,10 +342,15 @@ func runBoxGenerate(req spec.BuildRequest) ([]string, error) {
 	// enabled box" (idempotent — BuildCmd/GenerateCmd already normalized), and a named selection
 	// scopes the resolved set (and, with --include-disabled, relaxes the gate for those names).
 	boxes := normalizeBoxArgs(req.Boxes)
-	gen, err := NewGenerator(dir, req.Tag, boxResolveOpts(boxes, req.IncludeDisabled))
+	opts := boxResolveOpts(boxes, req.IncludeDisabled)
+	opts.Platform = req.Platform
+	gen, err := NewGenerator(dir, req.Tag, opts)
 	if err != nil {
 		return nil, err
 	}
+	if req.Unlocked {
+		gen.Lock = nil
+	}
 	// No lock: Generate() writes the shared .build/ tree race-free via atomic staging
 	// (build_stage_atomic.go), so concurrent generates in one dir are safe.
 	if err := gen.Generate(); err != nil {
@@ -284,6 +359,9 @@ func runBoxGenerate(req spec.BuildRequest) ([]string, error) {
 	written := make([]string, 0, len(gen.Containerfiles))
 	for name := range gen.Containerfiles {
 		written = append(written, filepath.Join(dir, ".build", name, "Containerfile"))
+		for arch := range gen.ArchContainerfiles[name] {
+			written = append(written, filepath.Join(dir, ".build", name, "Containerfile."+arch))
+		}
 	}
 	sort.Strings(written)
 	return written, nil
@@ -431,6 +509,7 @@ func (c *BuildCmd) buildImages(dir string, gen *Generator) (string, string, []st
 	}
 
 	var built []string
+	c.archVariants = gen.ArchContainerfiles
 
 	if len(c.Boxes) > 0 {
 		// Filtered build: use sequential order
@@ -442,14 +521,17 @@ func (c *BuildCmd) buildImages(dir string, gen *Generator) (string, string, []st
 		if err != nil {
 			return "", "", nil, err
 		}
+		c.buildHashes = c.computeBuildHashes(dir, gen, order, platform)
 		for _, name := range order {
 			img := gen.Boxes[name]
-			content := gen.Containerfiles[name]
+			content := gen.containerfileFor(name, platform)
 			if err := c.buildImage(engine, dir, name, img, gen.Config, platform, rt.BuildEngine, content); err != nil {
 				return "", "", nil, fmt.Errorf("building %s: %w", name, err)
 			}
 			built = append(built, img.FullTag)
-			mergeAfterBuild(name, img)
+			if _, skipped := c.skippedBuild(name); !skipped {
+				mergeAfterBuild(name, img)
+			}
 		}
 	} else {
 		// Full build: use level-based parallelism
@@ -462,6 +544,7 @@ func (c *BuildCmd) buildImages(dir string, gen *Generator) (string, string, []st
 		if jobs < 1 {
 			jobs = jobsFallback
 		}
+		c.buildHashes = c.computeBuildHashes(dir, gen, slices.Concat(levels...), platform)
 
 		for i, level := range levels {
 			fmt.Fprintf(os.Stderr, "\n=== Build level %d/%d (%d images) ===\n", i+1, len(levels), len(level))
@@ -470,7 +553,7 @@ func (c *BuildCmd) buildImages(dir string, gen *Generator) (string, string, []st
 				// Single image, no need for goroutine overhead
 				name := level[0]
 				img := gen.Boxes[name]
-				content := gen.Containerfiles[name]
+				content := gen.containerfileFor(name, platform)
 				if err := c.buildImage(engine, dir, name, img, gen.Config, platform, rt.BuildEngine, content); err != nil {
 					return "", "", nil, fmt.Errorf("building %s: %w", name, err)
 				}
@@ -480,7 +563,7 @@ func (c *BuildCmd) buildImages(dir string, gen *Generator) (string, string, []st
 
 				for _, name := range level {
 					img := gen.Boxes[name]
-					content := gen.Containerfiles[name]
+					content := gen.containerfileFor(name, platform)
 					g.Go(func() error {
 						if err := c.buildImage(engine, dir, name, img, gen.Config, platform, rt.BuildEngine, content); err != nil {
 							return fmt.Errorf("building %s: %w", name, err)
@@ -497,7 +580,9 @@ func (c *BuildCmd) buildImages(dir string, gen *Generator) (string, string, []st
 			// Merge this level before building the next so children
 			// start from a merged (fewer-layer) base image.
 			for _, name := range level {
-				mergeAfterBuild(name, gen.Boxes[name])
+				if _, skipped := c.skippedBuild(name); !skipped {
+					mergeAfterBuild(name, gen.Boxes[name])
+				}
 				built = append(built, gen.Boxes[name].FullTag)
 			}
 		}
@@ -545,33 +630,71 @@ func (c *BuildCmd) buildImage(engine, dir, name string, img *ResolvedBox, cfg *C
 	}
 	defer func() { _ = buildUnlock() }()
 
+	// Checked under the lock, so a concurrent build of the same content that
+	// just finished is reused rather than rebuilt.
+	if c.skipByBuildHash(engine, engineName, name, img, tags) {
+		return nil
+	}
+
 	// Pre-build phase for `from: builder:<name>` images: run the named
 	// kind:bootstrap builder in a privileged container, capture its
 	// rootfs.tar.gz into .build/<image>/<builder>.tar.gz so the
 	// Containerfile's ADD <builder>.tar.gz / step finds it.
+	var prof *boxProfile
+	var pulls []string
+	if c.profile != nil {
+		prof = c.profile.beginBox(name, containerfileContent)
+		pulls = c.profile.missingBases(engineName, prof)
+		defer func() { c.profile.endBox(engineName, prof, pulls) }()
+	}
+
 	if strings.HasPrefix(img.From, "builder:") {
+		start := time.Now()
 		if err := c.runPrivilegedBootstrap(engineName, dir, name, img); err != nil {
 			return err
 		}
+		if prof != nil {
+			prof.BootstrapMs = time.Since(start).Milliseconds()
+		}
 	}
 
-	var args []string
-
+	var builds []archBuild
 	if c.Push {
-		args = c.buildPushArgs(engine, tags, img.Platforms, engineName, name, img.Registry)
+		builds = c.pushBuilds(engine, engineName, name, img, tags, containerfileContent)
 	} else {
-		args = c.buildLocalArgs(engine, tags, platform, name, img.Registry)
+		builds = []archBuild{{args: c.buildLocalArgs(engine, tags, platform, name, img.Registry), content: containerfileContent}}
 	}
 
-	fmt.Fprintf(os.Stderr, "\n--- Building %s ---\n", name)
+	for _, run := range builds {
+		args := withBuildHashLabel(run.args, c.buildHashes[name])
+		if run.platform != "" {
+			fmt.Fprintf(os.Stderr, "\n--- Building %s (%s) ---\n", name, run.platform)
+		} else {
+			fmt.Fprintf(os.Stderr, "\n--- Building %s ---\n", name)
+		}
 
-	cmd := exec.Command(args[0], args[1:]...)
-	cmd.Dir = dir
-	cmd.Stdin = strings.NewReader(containerfileContent)
-	cmd.Stdout = os.Stderr
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("%s build failed: %w", engine, err)
+		cmd := exec.Command(args[0], args[1:]...)
+		cmd.Dir = dir
+		cmd.Stdin = strings.NewReader(run.content)
+		cmd.Stdout = os.Stderr
+		cmd.Stderr = os.Stderr
+		if prof != nil {
+			w := prof.writer(os.Stderr)
+			cmd.Stdout, cmd.Stderr = w, w
+		}
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("%s build failed: %w", engine, err)
+		}
+	}
+	if len(builds) > 1 && engineName != "podman" {
+		if err := joinArchTags(tags, img.Platforms); err != nil {
+			return err
+		}
+	}
+	if hash := c.buildHashes[name]; hash != "" && !c.Push {
+		if err := recordBuildHash(hash, name, img.FullTag); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: recording build hash for %s: %v\n", name, err)
+		}
 	}
 
 	return nil
@@ -894,6 +1017,72 @@ func (c *BuildCmd) buildPushArgs(_ string, tags []string, platforms []string, en
 	return c.buildDockerPushArgs(tags, platforms, name, registry)
 }
 
+// archBuild is one engine invocation of a box build: its argv, the
+// Containerfile piped to it and, for a per-platform build, the platform.
+type archBuild struct {
+	args     []string
+	content  string
+	platform string
+}
+
+// pushBuilds plans the push-build invocations of box name. A box without
+// per-arch Containerfile variants is one multi-platform build. A box with
+// variants builds each platform from its own Containerfile: podman adds each
+// build to the same --manifest list; docker pushes per-arch tags
+// (<tag>-<arch>) that joinArchTags then assembles into the manifest list.
+func (c *BuildCmd) pushBuilds(engine, engineName, name string, img *ResolvedBox, tags []string, content string) []archBuild {
+	variants := c.archVariants[name]
+	if len(variants) == 0 {
+		return []archBuild{{args: c.buildPushArgs(engine, tags, img.Platforms, engineName, name, img.Registry), content: content}}
+	}
+	builds := make([]archBuild, 0, len(img.Platforms))
+	for _, p := range img.Platforms {
+		arch := platformArch(p)
+		runTags := tags
+		if engineName != "podman" {
+			runTags = archTags(tags, arch)
+		}
+		run := archBuild{args: c.buildPushArgs(engine, runTags, []string{p}, engineName, name, img.Registry), content: content, platform: p}
+		if v, ok := variants[arch]; ok {
+			run.content = v
+		}
+		builds = append(builds, run)
+	}
+	return builds
+}
+
+// archTags suffixes every tag with -<arch> (the per-arch images a docker
+// manifest list is assembled from).
+func archTags(tags []string, arch string) []string {
+	out := make([]string, len(tags))
+	for i, t := range tags {
+		out[i] = t + "-" + arch
+	}
+	return out
+}
+
+// joinArchTags assembles the pushed per-arch docker images into one manifest
+// list under each tag.
+func joinArchTags(tags, platforms []string) error {
+	for _, tag := range tags {
+		args := []string{"buildx", "imagetools", "create", "-t", tag}
+		seen := map[string]bool{}
+		for _, p := range platforms {
+			if arch := platformArch(p); !seen[arch] {
+				seen[arch] = true
+				args = append(args, tag+"-"+arch)
+			}
+		}
+		cmd := exec.Command("docker", args...)
+		cmd.Stdout = os.Stderr
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("creating manifest list %s: %w", tag, err)
+		}
+	}
+	return nil
+}
+
 func (c *BuildCmd) buildDockerPushArgs(tags []string, platforms []string, name, registry string) []string {
 	args := []string{"docker", "buildx", "build", "--push", "-f", "-"}
 	for _, tag := range tags {
@@ -0,0 +1,575 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/crane"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+)
+
+// Content-addressed build skipping. Every generated Containerfile is hashed
+// together with everything it reads from the build context (COPY/ADD and
+// bind-mount sources under the project dir), the bootstrap builder it runs,
+// the target platform, and — in place of the per-build CalVer tags — the
+// hashes of the internal boxes it builds FROM. The hash is stamped on the
+// image as LabelBuildHash and recorded in a per-user index, so a box whose
+// hash another build already produced (this machine, or a CI runner that
+// pushed to the registry) is tagged instead of rebuilt.
+
+// buildHashVersion is folded into every hash; bump it when the hashed inputs
+// change shape so old labels stop matching.
+const buildHashVersion = "charly-build-hash/v1"
+
+// buildIndexFile is the local hash index under the user cache dir
+// (~/.cache/charly/build-index.json).
+const buildIndexFile = "build-index.json"
+
+// buildHashRegistryDepth bounds the registry probe to the newest N CalVer
+// tags of a repo — older tags are unlikely to match and each probe is a
+// config fetch.
+const buildHashRegistryDepth = 8
+
+// buildHasher computes the build hash of every box in a generated build,
+// memoizing per box (a child needs its parent's hash) and per context path
+// (candy dirs are shared by many boxes).
+type buildHasher struct {
+	dir      string
+	gen      *Generator
+	platform func(name string) string
+
+	boxes map[string]string
+	paths map[string]string
+}
+
+func newBuildHasher(dir string, gen *Generator, platform func(name string) string) *buildHasher {
+	return &buildHasher{
+		dir:      dir,
+		gen:      gen,
+		platform: platform,
+		boxes:    make(map[string]string),
+		paths:    make(map[string]string),
+	}
+}
+
+// hash returns the build hash of box name.
+func (h *buildHasher) hash(name string) (string, error) {
+	if s, ok := h.boxes[name]; ok {
+		return s, nil
+	}
+	img, ok := h.gen.Boxes[name]
+	if !ok {
+		return "", fmt.Errorf("unknown box %q", name)
+	}
+	content, ok := h.gen.Containerfiles[name]
+	if !ok {
+		return "", fmt.Errorf("box %q has no generated Containerfile", name)
+	}
+
+	// Swap internal FullTags (which carry this build's CalVer tag) for the
+	// referenced box's own hash, so the hash is tag-independent but still
+	// changes when a parent's content does.
+	var refs []string
+	for other, o := range h.gen.Boxes {
+		if o.FullTag != "" && strings.Contains(content, o.FullTag) {
+			refs = append(refs, other)
+		}
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		return len(h.gen.Boxes[refs[i]].FullTag) > len(h.gen.Boxes[refs[j]].FullTag)
+	})
+	variants := h.gen.ArchContainerfiles[name]
+	arches := make([]string, 0, len(variants))
+	for arch := range variants {
+		arches = append(arches, arch)
+	}
+	sort.Strings(arches)
+	variantContent := make([]string, len(arches))
+	for i, arch := range arches {
+		variantContent[i] = variants[arch]
+	}
+	for _, other := range refs {
+		token := "box:" + other
+		if other != name {
+			dep, err := h.hash(other)
+			if err != nil {
+				return "", err
+			}
+			token += "@" + dep
+		}
+		content = strings.ReplaceAll(content, h.gen.Boxes[other].FullTag, token)
+		for i := range variantContent {
+			variantContent[i] = strings.ReplaceAll(variantContent[i], h.gen.Boxes[other].FullTag, token)
+		}
+	}
+
+	sum := sha256.New()
+	fmt.Fprintf(sum, "%s\nplatform %s\n", buildHashVersion, h.platform(name))
+	if strings.HasPrefix(img.From, "builder:") {
+		bootstrap, err := bootstrapHashInput(img)
+		if err != nil {
+			return "", fmt.Errorf("hashing bootstrap of %s: %w", name, err)
+		}
+		fmt.Fprintf(sum, "bootstrap %s\n", bootstrap)
+	}
+	fmt.Fprintf(sum, "containerfile %d\n%s\n", len(content), content)
+	for i, arch := range arches {
+		fmt.Fprintf(sum, "containerfile.%s %d\n%s\n", arch, len(variantContent[i]), variantContent[i])
+	}
+	for _, src := range containerfileContextSources(strings.Join(append([]string{content}, variantContent...), "\n")) {
+		if isBootstrapArtifact(name, img, src) {
+			continue // produced by the bootstrap run, hashed via its inputs above
+		}
+		d, err := h.hashPath(src)
+		if err != nil {
+			return "", fmt.Errorf("hashing %s context %s: %w", name, src, err)
+		}
+		fmt.Fprintf(sum, "context %s %s\n", src, d)
+	}
+	s := hex.EncodeToString(sum.Sum(nil))
+	h.boxes[name] = s
+	return s, nil
+}
+
+// bootstrapHashInput is the identity of a `from: builder:<name>` box's
+// privileged bootstrap: the builder definition, the distro it bootstraps and
+// the builder image it runs in.
+func bootstrapHashInput(img *ResolvedBox) (string, error) {
+	builderName := strings.TrimPrefix(img.From, "builder:")
+	var def *BuilderDef
+	if img.BuilderConfig != nil {
+		def = img.BuilderConfig.Builder[builderName]
+	}
+	b, err := json.Marshal(struct {
+		From    string
+		Image   string
+		Builder *BuilderDef
+		Distro  *DistroDef
+	}{img.From, img.BootstrapBuilderImage, def, img.DistroDef})
+	if err != nil {
+		return "", err
+	}
+	d := sha256.Sum256(b)
+	return hex.EncodeToString(d[:]), nil
+}
+
+// isBootstrapArtifact reports whether src is the rootfs tarball that
+// runPrivilegedBootstrap writes before the build.
+func isBootstrapArtifact(name string, img *ResolvedBox, src string) bool {
+	if !strings.HasPrefix(img.From, "builder:") {
+		return false
+	}
+	builderName := strings.TrimPrefix(img.From, "builder:")
+	return filepath.Clean(src) == filepath.Join(".build", name, builderName+".tar.gz")
+}
+
+// hashPath digests one context source relative to the project dir: a file's
+// mode and content, a directory's sorted tree, a symlink's target. Globs
+// expand to their sorted matches. A missing source hashes as "missing" — the
+// build itself reports the error.
+func (h *buildHasher) hashPath(src string) (string, error) {
+	if s, ok := h.paths[src]; ok {
+		return s, nil
+	}
+	matches := []string{filepath.Join(h.dir, src)}
+	if strings.ContainsAny(src, "*?[") {
+		var err error
+		if matches, err = filepath.Glob(filepath.Join(h.dir, src)); err != nil {
+			return "", err
+		}
+		sort.Strings(matches)
+	}
+	sum := sha256.New()
+	found := false
+	for _, root := range matches {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			found = true
+			rel, _ := filepath.Rel(h.dir, path)
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			switch {
+			case d.Type()&fs.ModeSymlink != 0:
+				target, err := os.Readlink(path)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(sum, "L %s %s\n", rel, target)
+			case d.IsDir():
+				fmt.Fprintf(sum, "D %s %o\n", rel, info.Mode().Perm())
+			default:
+				f, err := os.Open(path)
+				if err != nil {
+					return err
+				}
+				fd := sha256.New()
+				_, err = io.Copy(fd, f)
+				_ = f.Close()
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(sum, "F %s %o %x\n", rel, info.Mode().Perm(), fd.Sum(nil))
+			}
+			return nil
+		})
+		if err != nil && !os.IsNotExist(err) {
+			return "", err
+		}
+	}
+	s := "missing"
+	if found {
+		s = hex.EncodeToString(sum.Sum(nil))
+	}
+	h.paths[src] = s
+	return s, nil
+}
+
+// containerfileContextSources lists the build-context paths a Containerfile
+// reads: COPY/ADD sources without --from (URLs and heredocs excluded) and
+// RUN bind-mount sources without from=. Continuation lines are joined first.
+func containerfileContextSources(content string) []string {
+	var out []string
+	seen := map[string]bool{}
+	add := func(src string) {
+		if src == "" || seen[src] {
+			return
+		}
+		seen[src] = true
+		out = append(out, src)
+	}
+	var logical []string
+	var cur strings.Builder
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if cur.Len() == 0 && (trimmed == "" || strings.HasPrefix(trimmed, "#")) {
+			continue
+		}
+		if strings.HasSuffix(trimmed, "\\") {
+			cur.WriteString(strings.TrimSuffix(trimmed, "\\"))
+			cur.WriteString(" ")
+			continue
+		}
+		cur.WriteString(trimmed)
+		logical = append(logical, cur.String())
+		cur.Reset()
+	}
+
+	for _, line := range logical {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		switch strings.ToUpper(fields[0]) {
+		case "COPY", "ADD":
+			var args []string
+			fromStage := false
+			for i, f := range fields[1:] {
+				if strings.HasPrefix(f, "--") {
+					fromStage = fromStage || strings.HasPrefix(f, "--from=")
+					continue
+				}
+				if strings.HasPrefix(f, "[") {
+					_ = json.Unmarshal([]byte(strings.Join(fields[1+i:], " ")), &args)
+					break
+				}
+				args = append(args, f)
+			}
+			if fromStage || len(args) < 2 {
+				continue
+			}
+			for _, src := range args[:len(args)-1] {
+				if strings.HasPrefix(src, "<<") || strings.Contains(src, "://") {
+					continue
+				}
+				add(src)
+			}
+		case "RUN":
+			for _, f := range fields[1:] {
+				if !strings.HasPrefix(f, "--") {
+					break
+				}
+				opts, ok := strings.CutPrefix(f, "--mount=")
+				if !ok {
+					continue
+				}
+				kv := map[string]string{"type": "bind"}
+				for _, o := range strings.Split(opts, ",") {
+					k, v, _ := strings.Cut(o, "=")
+					kv[k] = v
+				}
+				if kv["type"] == "bind" && kv["from"] == "" {
+					src := kv["source"]
+					if src == "" {
+						src = kv["src"]
+					}
+					add(src)
+				}
+			}
+		}
+	}
+	return out
+}
+
+// buildIndexEntry is one build recorded in the local index.
+type buildIndexEntry struct {
+	Box   string    `json:"box"`
+	Ref   string    `json:"ref"`
+	Built time.Time `json:"built"`
+}
+
+// buildIndexMu serializes index read-modify-write across the parallel builds
+// of one level.
+var buildIndexMu sync.Mutex
+
+func buildIndexPath() (string, error) {
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cache, "charly", buildIndexFile), nil
+}
+
+// loadBuildIndex reads the hash → build index. A missing or unreadable index
+// is empty: it only ever short-cuts the label scan.
+func loadBuildIndex() map[string]buildIndexEntry {
+	index := map[string]buildIndexEntry{}
+	path, err := buildIndexPath()
+	if err != nil {
+		return index
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return index
+	}
+	_ = json.Unmarshal(data, &index)
+	return index
+}
+
+// recordBuildHash adds (or refreshes) hash → ref in the local index.
+func recordBuildHash(hash, box, ref string) error {
+	buildIndexMu.Lock()
+	defer buildIndexMu.Unlock()
+	path, err := buildIndexPath()
+	if err != nil {
+		return err
+	}
+	index := loadBuildIndex()
+	index[hash] = buildIndexEntry{Box: box, Ref: ref, Built: time.Now().UTC()}
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicWriteFile(path, append(data, '\n'), 0o644)
+}
+
+// findLocalBuildHash returns a local image ref carrying hash: the indexed ref
+// when it still exists with that label, else any local image labelled with it.
+func findLocalBuildHash(engine, hash string) string {
+	if e, ok := loadBuildIndex()[hash]; ok {
+		if labels, err := InspectLabels(engine, e.Ref); err == nil && labels[LabelBuildHash] == hash {
+			return e.Ref
+		}
+	}
+	images, err := ListLocalImages(engine)
+	if err != nil {
+		return ""
+	}
+	for _, img := range images {
+		if img.Labels[LabelBuildHash] == hash && len(img.Names) > 0 {
+			return img.Names[0]
+		}
+	}
+	return ""
+}
+
+// FindRemoteBuildHash returns a ref in repo whose image config carries hash,
+// probing the newest buildHashRegistryDepth CalVer tags. Package-level var
+// for testability (same pattern as ListLocalImages).
+var FindRemoteBuildHash = defaultFindRemoteBuildHash
+
+func defaultFindRemoteBuildHash(repo, hash string) (string, error) {
+	tags, err := crane.ListTags(repo)
+	if err != nil {
+		return "", err
+	}
+	var calver []string
+	for _, t := range tags {
+		if extractCalVerTag(repo+":"+t) != "" {
+			calver = append(calver, t)
+		}
+	}
+	sort.Slice(calver, func(i, j int) bool { return compareCalVer(calver[i], calver[j]) > 0 })
+	for i, t := range calver {
+		if i == buildHashRegistryDepth {
+			break
+		}
+		ref := repo + ":" + t
+		raw, err := crane.Config(ref)
+		if err != nil {
+			continue
+		}
+		var cfg v1.ConfigFile
+		if json.Unmarshal(raw, &cfg) == nil && cfg.Config.Labels[LabelBuildHash] == hash {
+			return ref, nil
+		}
+	}
+	return "", nil
+}
+
+// buildHashHit is an existing image whose build hash matches the box about to
+// be built.
+type buildHashHit struct {
+	Ref    string
+	Remote bool // found in the registry rather than local storage
+}
+
+// findBuildHash looks for an existing build of hash. Local storage is
+// consulted for local builds only (a push build must land in the registry);
+// the registry is probed when the box has one and caching is on.
+func (c *BuildCmd) findBuildHash(engineName string, img *ResolvedBox, hash string) (buildHashHit, bool) {
+	if !c.Push {
+		if ref := findLocalBuildHash(engineName, hash); ref != "" {
+			return buildHashHit{Ref: ref}, true
+		}
+	}
+	if img.Registry == "" || c.Cache == "none" {
+		return buildHashHit{}, false
+	}
+	repo := img.FullTag
+	if i := strings.LastIndex(repo, ":"); i > strings.LastIndex(repo, "/") {
+		repo = repo[:i]
+	}
+	ref, err := FindRemoteBuildHash(repo, hash)
+	if err != nil || ref == "" {
+		return buildHashHit{}, false
+	}
+	return buildHashHit{Ref: ref, Remote: true}, true
+}
+
+// reuseBuild makes hit available under the box's new tags instead of
+// building: a local tag for local builds (pulling a registry hit first), a
+// registry-side tag for push builds.
+func reuseBuild(engine string, push bool, hit buildHashHit, tags []string) error {
+	if push {
+		for _, tag := range tags {
+			t := tag
+			if i := strings.LastIndex(tag, ":"); i > strings.LastIndex(tag, "/") {
+				t = tag[i+1:]
+			}
+			if err := crane.Tag(hit.Ref, t); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if hit.Remote {
+		cmd := exec.Command(engine, "pull", hit.Ref)
+		cmd.Stdout = os.Stderr
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("pulling %s: %w", hit.Ref, err)
+		}
+	}
+	for _, tag := range tags {
+		if tag == hit.Ref {
+			continue
+		}
+		if out, err := exec.Command(engine, "tag", hit.Ref, tag).CombinedOutput(); err != nil {
+			return fmt.Errorf("tagging %s as %s: %w: %s", hit.Ref, tag, err, strings.TrimSpace(string(out)))
+		}
+	}
+	return nil
+}
+
+// skipByBuildHash tries to satisfy box name from an existing build of its
+// hash. Returns true when the box was skipped; a failed reuse is a warning
+// and falls back to a normal build.
+func (c *BuildCmd) skipByBuildHash(engine, engineName, name string, img *ResolvedBox, tags []string) bool {
+	hash := c.buildHashes[name]
+	if hash == "" || c.NoCache {
+		return false
+	}
+	hit, ok := c.findBuildHash(engineName, img, hash)
+	if !ok {
+		return false
+	}
+	if err := reuseBuild(engine, c.Push, hit, tags); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: reusing %s for %s: %v; building instead\n", hit.Ref, name, err)
+		return false
+	}
+	where := "local image"
+	if hit.Remote {
+		where = "registry image"
+	}
+	fmt.Fprintf(os.Stderr, "\n--- Skipping %s: build hash %s matches %s %s ---\n", name, hash[:12], where, hit.Ref)
+	if !c.Push {
+		if err := recordBuildHash(hash, name, img.FullTag); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: recording build hash for %s: %v\n", name, err)
+		}
+	}
+
+	c.skipMu.Lock()
+	defer c.skipMu.Unlock()
+	if c.skipped == nil {
+		c.skipped = make(map[string]buildHashHit)
+	}
+	c.skipped[name] = hit
+	return true
+}
+
+// skippedBuild reports whether box name was satisfied by an existing build.
+func (c *BuildCmd) skippedBuild(name string) (buildHashHit, bool) {
+	c.skipMu.Lock()
+	defer c.skipMu.Unlock()
+	hit, ok := c.skipped[name]
+	return hit, ok
+}
+
+// computeBuildHashes hashes every box selected for this build (and,
+// transitively, the internal boxes they build FROM). A box that cannot be
+// hashed is built as before, just without skip support.
+func (c *BuildCmd) computeBuildHashes(dir string, gen *Generator, names []string, platform string) map[string]string {
+	h := newBuildHasher(dir, gen, func(name string) string {
+		if c.Push {
+			return strings.Join(gen.Boxes[name].Platforms, ",")
+		}
+		return platform
+	})
+	out := make(map[string]string, len(names))
+	for _, name := range names {
+		s, err := h.hash(name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: build hash for %s: %v\n", name, err)
+			continue
+		}
+		out[name] = s
+	}
+	return out
+}
+
+// withBuildHashLabel inserts the build-hash label ahead of the trailing
+// build-context argument.
+func withBuildHashLabel(args []string, hash string) []string {
+	if hash == "" || len(args) == 0 {
+		return args
+	}
+	out := make([]string, 0, len(args)+2)
+	out = append(out, args[:len(args)-1]...)
+	out = append(out, "--label", LabelBuildHash+"="+hash)
+	return append(out, args[len(args)-1])
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestContainerfileContextSources(t *testing.T) {
+	content := `FROM ghcr.io/org/base:1 AS web-pixi-build
+COPY --chown=1000:1000 .build/_candy/web.1/pixi.toml \
+    .build/_candy/web.1/pixi.lock /home/user/
+COPY --from=web-pixi-build /home/user/.pixi /home/user/.pixi
+# COPY .build/commented /nowhere
+ADD https://example.com/x.tar.gz /tmp/
+COPY ["with space/a", "/dst/"]
+RUN --mount=type=cache,dst=/var/cache --mount=type=bind,source=.build/scripts,target=/s sh /s/run
+RUN --mount=type=bind,from=stage,source=/,target=/ctx true
+COPY <<EOF /etc/motd
+hi
+EOF
+`
+	got := containerfileContextSources(content)
+	want := []string{
+		".build/_candy/web.1/pixi.toml",
+		".build/_candy/web.1/pixi.lock",
+		"with space/a",
+		".build/scripts",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("sources = %q, want %q", got, want)
+	}
+}
+
+// testHashGenerator builds a two-box generator (app FROM base) with every
+// internal ref carrying tag.
+func testHashGenerator(tag string) *Generator {
+	base := &ResolvedBox{FullTag: "ghcr.io/org/base:" + tag}
+	app := &ResolvedBox{FullTag: "ghcr.io/org/app:" + tag}
+	return &Generator{
+		Boxes: map[string]*ResolvedBox{"base": base, "app": app},
+		Containerfiles: map[string]string{
+			"base": "FROM quay.io/fedora/fedora:43\nCOPY .build/_candy/os.1/ /ctx/\n",
+			"app":  "FROM " + base.FullTag + "\nCOPY .build/_candy/web.1/app.conf /etc/app.conf\n",
+		},
+	}
+}
+
+func TestBuildHash(t *testing.T) {
+	dir := t.TempDir()
+	write := func(rel, body string) {
+		t.Helper()
+		p := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(".build/_candy/os.1/setup.sh", "echo os")
+	write(".build/_candy/web.1/app.conf", "port=8080")
+
+	hashes := func(tag, platform string) map[string]string {
+		h := newBuildHasher(dir, testHashGenerator(tag), func(string) string { return platform })
+		out := map[string]string{}
+		for _, name := range []string{"app", "base"} {
+			s, err := h.hash(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out[name] = s
+		}
+		return out
+	}
+
+	first := hashes("2026.100.1", "linux/amd64")
+	if first["app"] == first["base"] {
+		t.Fatal("app and base hash equal")
+	}
+	if again := hashes("2026.101.7", "linux/amd64"); !reflect.DeepEqual(again, first) {
+		t.Errorf("hash depends on the CalVer tag: %v vs %v", again, first)
+	}
+	if arm := hashes("2026.100.1", "linux/arm64"); arm["app"] == first["app"] {
+		t.Error("hash ignores the platform")
+	}
+
+	// A change in base's context changes base and, through the FROM, app.
+	write(".build/_candy/os.1/setup.sh", "echo os v2")
+	changed := hashes("2026.100.1", "linux/amd64")
+	if changed["base"] == first["base"] || changed["app"] == first["app"] {
+		t.Errorf("context change not propagated: %v vs %v", changed, first)
+	}
+}
+
+func TestFindLocalBuildHash(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	origList, origInspect := ListLocalImages, InspectLabels
+	t.Cleanup(func() { ListLocalImages, InspectLabels = origList, origInspect })
+
+	local := map[string]map[string]string{
+		"ghcr.io/org/app:2026.100.1": {LabelBuildHash: "aaa"},
+		"ghcr.io/org/app:2026.99.1":  {LabelBuildHash: "bbb"},
+	}
+	ListLocalImages = func(string) ([]LocalImageInfo, error) {
+		var out []LocalImageInfo
+		for _, ref := range slices.Sorted(maps.Keys(local)) {
+			out = append(out, LocalImageInfo{Names: []string{ref}, Labels: local[ref]})
+		}
+		return out, nil
+	}
+	InspectLabels = func(_, ref string) (map[string]string, error) {
+		return local[ref], nil
+	}
+
+	// Found by label scan without an index entry.
+	if got := findLocalBuildHash("podman", "bbb"); got != "ghcr.io/org/app:2026.99.1" {
+		t.Errorf("label scan = %q", got)
+	}
+	if got := findLocalBuildHash("podman", "ccc"); got != "" {
+		t.Errorf("unknown hash matched %q", got)
+	}
+
+	// The index wins when its ref still carries the hash...
+	local["ghcr.io/org/app:2026.101.1"] = map[string]string{LabelBuildHash: "aaa"}
+	if err := recordBuildHash("aaa", "app", "ghcr.io/org/app:2026.101.1"); err != nil {
+		t.Fatal(err)
+	}
+	if got := findLocalBuildHash("podman", "aaa"); got != "ghcr.io/org/app:2026.101.1" {
+		t.Errorf("indexed lookup = %q", got)
+	}
+	// ...and is ignored once the ref was re-tagged to different content.
+	local["ghcr.io/org/app:2026.101.1"] = map[string]string{LabelBuildHash: "zzz"}
+	if got := findLocalBuildHash("podman", "aaa"); got != "ghcr.io/org/app:2026.100.1" {
+		t.Errorf("stale index entry used: %q", got)
+	}
+}
+
+func TestWithBuildHashLabel(t *testing.T) {
+	got := withBuildHashLabel([]string{"podman", "build", "-f", "-", "."}, "abc")
+	want := []string{"podman", "build", "-f", "-", "--label", LabelBuildHash + "=abc", "."}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("args = %q, want %q", got, want)
+	}
+	if got := withBuildHashLabel([]string{"podman", "."}, ""); len(got) != 2 {
+		t.Errorf("empty hash added a label: %q", got)
+	}
+}
@@ -0,0 +1,630 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// build_profile.go — `charly box build --profile`.
+//
+// The profiler rides on the engine's own build output: buildImage tees the
+// podman/docker stream through a profileWriter, which turns step lines into
+// timed instructions. podman prints `[i/n] STEP k/m: <instruction>` (no [i/n]
+// for a single-stage file) and `--> Using cache <id>` / `--> <id>` when a step
+// ends; BuildKit's plain progress prints `#<v> [<stage> k/m] <instruction>`
+// followed by `#<v> CACHED` or `#<v> DONE <secs>s`. podman's `-->` lines carry
+// no stage prefix, so with --podman-jobs > 1 they are attributed to the most
+// recently started step — good enough for a profile, not an audit.
+//
+// Instructions are mapped back to candies through the Containerfile the build
+// was fed: `# Layer: <candy>` comments in the main stage and the
+// `<candy>-<builder>-build` / `<candy>-extract-<i>` stage names. Base images
+// the build had to pull are measured (engine image inspect) once per run. The
+// critical path is the longest chain of measured box builds through the
+// ResolveBoxLevels dependency graph.
+//
+// Output: .build/profile.json (this file's buildProfile) and
+// .build/profile.trace.json (Chrome trace-event format, one track per stage;
+// open in chrome://tracing or ui.perfetto.dev), plus a summary on stderr.
+
+const (
+	profileJSONFile  = "profile.json"
+	profileTraceFile = "profile.trace.json"
+	profileTopCandy  = 10
+)
+
+// buildProfiler collects the timings of one `charly box build --profile`.
+type buildProfiler struct {
+	mu      sync.Mutex
+	started time.Time
+	boxes   map[string]*boxProfile
+	pulled  map[string]bool // base refs already measured this run
+}
+
+func newBuildProfiler() *buildProfiler {
+	return &buildProfiler{started: time.Now(), boxes: map[string]*boxProfile{}, pulled: map[string]bool{}}
+}
+
+// buildProfile is the JSON shape of .build/profile.json.
+type buildProfile struct {
+	Started      time.Time      `json:"started"`
+	Ms           int64          `json:"ms"`
+	Levels       [][]string     `json:"levels"`
+	CriticalPath []string       `json:"critical_path"`
+	CriticalMs   int64          `json:"critical_ms"`
+	CacheHits    int            `json:"cache_hits"`
+	CacheMisses  int            `json:"cache_misses"`
+	PulledBytes  int64          `json:"pulled_bytes"`
+	Builders     []builderTotal `json:"builders,omitempty"`
+	Candies      []candyTotal   `json:"candies"`
+	Boxes        []*boxProfile  `json:"boxes"`
+}
+
+type boxProfile struct {
+	Box         string          `json:"box"`
+	Level       int             `json:"level"`
+	Start       time.Time       `json:"start"`
+	End         time.Time       `json:"end"`
+	Ms          int64           `json:"ms"`
+	BootstrapMs int64           `json:"bootstrap_ms,omitempty"`
+	CacheHits   int             `json:"cache_hits"`
+	CacheMisses int             `json:"cache_misses"`
+	Pulled      []pulledImage   `json:"pulled,omitempty"`
+	Stages      []*stageProfile `json:"stages"`
+
+	file   []containerfileStage
+	byKey  map[string]*stageProfile // stage index or BuildKit stage name
+	vertex map[string]*stepProfile  // BuildKit vertex id → step
+	open   []*stepProfile           // podman steps not yet ended, in start order
+}
+
+type stageProfile struct {
+	Index   int            `json:"index"`
+	Name    string         `json:"name,omitempty"`
+	Candy   string         `json:"candy,omitempty"`
+	Builder string         `json:"builder,omitempty"`
+	Start   time.Time      `json:"start"`
+	End     time.Time      `json:"end"`
+	Ms      int64          `json:"ms"`
+	Steps   []*stepProfile `json:"steps"`
+}
+
+// stepProfile is one Containerfile instruction. Cache is "hit", "miss", or
+// empty for FROM (nothing to cache).
+type stepProfile struct {
+	Step        int       `json:"step"`
+	Instruction string    `json:"instruction"`
+	Candy       string    `json:"candy,omitempty"`
+	Cache       string    `json:"cache,omitempty"`
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+	Ms          int64     `json:"ms"`
+
+	stage *stageProfile
+	done  bool
+}
+
+type pulledImage struct {
+	Ref   string `json:"ref"`
+	Bytes int64  `json:"bytes"`
+}
+
+type candyTotal struct {
+	Candy  string `json:"candy"`
+	Ms     int64  `json:"ms"`
+	Steps  int    `json:"steps"`
+	Cached int    `json:"cached"`
+}
+
+type builderTotal struct {
+	Builder string `json:"builder"`
+	Ms      int64  `json:"ms"`
+	Stages  int    `json:"stages"`
+}
+
+// containerfileStage is one FROM block of a generated Containerfile with the
+// candy each instruction belongs to.
+type containerfileStage struct {
+	Name         string
+	Base         string
+	Candy        string
+	Builder      string
+	Instructions []containerfileInstruction
+}
+
+type containerfileInstruction struct {
+	Text  string
+	Candy string
+}
+
+var (
+	builderStageRe = regexp.MustCompile(`^(.+)-([a-z0-9_]+)-build$`)
+	extractStageRe = regexp.MustCompile(`^(.+)-extract-\d+$`)
+)
+
+// parseContainerfileStages splits a generated Containerfile into stages and
+// attributes every instruction to a candy. Continuation lines fold into their
+// instruction, so indices line up with the engine's STEP k/m numbering.
+func parseContainerfileStages(content string) []containerfileStage {
+	var stages []containerfileStage
+	var cur *containerfileStage
+	candy := ""
+	var pending strings.Builder
+	flush := func() {
+		text := strings.TrimSpace(pending.String())
+		pending.Reset()
+		if text == "" {
+			return
+		}
+		if kw, rest, _ := strings.Cut(text, " "); strings.EqualFold(kw, "FROM") {
+			fields := strings.Fields(rest)
+			st := containerfileStage{}
+			for i := 0; i < len(fields); i++ {
+				if strings.HasPrefix(fields[i], "--") {
+					continue
+				}
+				if st.Base == "" {
+					st.Base = fields[i]
+				} else if strings.EqualFold(fields[i], "AS") && i+1 < len(fields) {
+					st.Name = fields[i+1]
+					break
+				}
+			}
+			if m := builderStageRe.FindStringSubmatch(st.Name); m != nil {
+				st.Candy, st.Builder = m[1], m[2]
+			} else if m := extractStageRe.FindStringSubmatch(st.Name); m != nil {
+				st.Candy = m[1]
+			} else if st.Base == "scratch" {
+				st.Candy = st.Name // data staging stage named after its candy
+			}
+			stages = append(stages, st)
+			cur = &stages[len(stages)-1]
+			candy = st.Candy
+		}
+		if cur != nil {
+			cur.Instructions = append(cur.Instructions, containerfileInstruction{Text: text, Candy: candy})
+		}
+	}
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if pending.Len() == 0 {
+			if name, ok := strings.CutPrefix(trimmed, "# Layer: "); ok && cur != nil && cur.Builder == "" {
+				candy = strings.TrimSpace(name)
+				continue
+			}
+			if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+				continue
+			}
+		}
+		if c, ok := strings.CutSuffix(line, "\\"); ok {
+			pending.WriteString(c)
+			pending.WriteString(" ")
+			continue
+		}
+		pending.WriteString(line)
+		flush()
+	}
+	flush()
+	return stages
+}
+
+// beginBox starts the profile of one box build. containerfile is the content
+// piped to the engine; it drives candy attribution and pull accounting.
+func (p *buildProfiler) beginBox(name, containerfile string) *boxProfile {
+	bp := &boxProfile{
+		Box:    name,
+		Start:  time.Now(),
+		file:   parseContainerfileStages(containerfile),
+		byKey:  map[string]*stageProfile{},
+		vertex: map[string]*stepProfile{},
+	}
+	p.mu.Lock()
+	p.boxes[name] = bp
+	p.mu.Unlock()
+	return bp
+}
+
+// missingBases returns the external base refs of the box's Containerfile not
+// yet in local storage — the ones this build will pull.
+func (p *buildProfiler) missingBases(engineName string, bp *boxProfile) []string {
+	local := map[string]bool{}
+	for _, st := range bp.file {
+		if st.Name != "" {
+			local[st.Name] = true
+		}
+	}
+	var refs []string
+	for _, st := range bp.file {
+		ref := st.Base
+		if ref == "" || ref == "scratch" || local[ref] || strings.Contains(ref, "$") {
+			continue
+		}
+		p.mu.Lock()
+		seen := p.pulled[ref]
+		p.pulled[ref] = true
+		p.mu.Unlock()
+		if !seen && !LocalImageExists(engineName, ref) {
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
+// endBox closes the box profile and measures the bases it pulled.
+func (p *buildProfiler) endBox(engineName string, bp *boxProfile, pulled []string) {
+	bp.finish(time.Now())
+	for _, ref := range pulled {
+		out, err := exec.Command(EngineBinary(engineName), "image", "inspect", "--format", "{{.Size}}", ref).Output()
+		if err != nil {
+			continue
+		}
+		if n, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64); err == nil {
+			bp.Pulled = append(bp.Pulled, pulledImage{Ref: ref, Bytes: n})
+		}
+	}
+}
+
+// writer tees the engine output to out while profiling it.
+func (bp *boxProfile) writer(out io.Writer) io.Writer {
+	return &profileWriter{box: bp, out: out}
+}
+
+type profileWriter struct {
+	box *boxProfile
+	out io.Writer
+	buf []byte
+}
+
+func (w *profileWriter) Write(b []byte) (int, error) {
+	w.buf = append(w.buf, b...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.box.observe(string(w.buf[:i]), time.Now())
+		w.buf = w.buf[i+1:]
+	}
+	return w.out.Write(b)
+}
+
+var (
+	podmanStepRe   = regexp.MustCompile(`^(?:\[(\d+)/\d+\] )?STEP (\d+)/\d+: (.*)$`)
+	buildkitStepRe = regexp.MustCompile(`^#(\d+) \[([^\]\s]+) (\d+)/\d+\] (.*)$`)
+	buildkitDoneRe = regexp.MustCompile(`^#(\d+) (CACHED|DONE ([0-9.]+)s)$`)
+)
+
+// observe feeds one output line, seen at t, into the profile.
+func (bp *boxProfile) observe(line string, t time.Time) {
+	line = strings.TrimRight(line, "\r")
+	if m := podmanStepRe.FindStringSubmatch(line); m != nil {
+		stageIdx := 0
+		if m[1] != "" {
+			stageIdx, _ = strconv.Atoi(m[1])
+			stageIdx--
+		}
+		step, _ := strconv.Atoi(m[2])
+		st := bp.stage(strconv.Itoa(stageIdx), stageIdx, "")
+		// A new STEP ends the stage's previous one.
+		for _, s := range bp.open {
+			if s.stage == st && !s.done {
+				s.close(t)
+			}
+		}
+		s := bp.startStep(st, step, m[3], t)
+		bp.open = append(bp.open, s)
+		return
+	}
+	if rest, ok := strings.CutPrefix(line, "--> "); ok {
+		for i := len(bp.open) - 1; i >= 0; i-- {
+			if s := bp.open[i]; !s.done {
+				if strings.HasPrefix(rest, "Using cache") {
+					s.Cache = "hit"
+				}
+				s.close(t)
+				break
+			}
+		}
+		return
+	}
+	if m := buildkitStepRe.FindStringSubmatch(line); m != nil {
+		if _, seen := bp.vertex[m[1]]; seen {
+			return
+		}
+		idx := -1
+		for i, fs := range bp.file {
+			if fs.Name == m[2] {
+				idx = i
+			}
+		}
+		if n, ok := strings.CutPrefix(m[2], "stage-"); ok && idx < 0 {
+			idx, _ = strconv.Atoi(n)
+		}
+		step, _ := strconv.Atoi(m[3])
+		bp.vertex[m[1]] = bp.startStep(bp.stage(m[2], idx, m[2]), step, m[4], t)
+		return
+	}
+	if m := buildkitDoneRe.FindStringSubmatch(line); m != nil {
+		s, ok := bp.vertex[m[1]]
+		if !ok || s.done {
+			return
+		}
+		if m[2] == "CACHED" {
+			s.Cache = "hit"
+			s.close(t)
+			return
+		}
+		if secs, err := strconv.ParseFloat(m[3], 64); err == nil {
+			s.close(s.Start.Add(time.Duration(secs * float64(time.Second))))
+		} else {
+			s.close(t)
+		}
+	}
+}
+
+// stage returns the stage profile for key, creating it from the parsed
+// Containerfile stage idx (-1 when unknown).
+func (bp *boxProfile) stage(key string, idx int, name string) *stageProfile {
+	if st, ok := bp.byKey[key]; ok {
+		return st
+	}
+	st := &stageProfile{Index: idx, Name: name}
+	if idx >= 0 && idx < len(bp.file) {
+		fs := bp.file[idx]
+		st.Name, st.Candy, st.Builder = fs.Name, fs.Candy, fs.Builder
+	}
+	bp.byKey[key] = st
+	bp.Stages = append(bp.Stages, st)
+	return st
+}
+
+func (bp *boxProfile) startStep(st *stageProfile, step int, text string, t time.Time) *stepProfile {
+	s := &stepProfile{Step: step, Instruction: text, Cache: "miss", Start: t, stage: st}
+	if kw, _, _ := strings.Cut(text, " "); strings.EqualFold(kw, "FROM") {
+		s.Cache = ""
+	}
+	if st.Index >= 0 && st.Index < len(bp.file) && step >= 1 && step <= len(bp.file[st.Index].Instructions) {
+		s.Candy = bp.file[st.Index].Instructions[step-1].Candy
+	}
+	if len(s.Instruction) > 120 {
+		s.Instruction = s.Instruction[:117] + "..."
+	}
+	st.Steps = append(st.Steps, s)
+	return s
+}
+
+func (s *stepProfile) close(t time.Time) {
+	s.End, s.Ms, s.done = t, t.Sub(s.Start).Milliseconds(), true
+}
+
+// finish ends every open step at t and totals the box.
+func (bp *boxProfile) finish(t time.Time) {
+	bp.End, bp.Ms = t, t.Sub(bp.Start).Milliseconds()
+	for _, st := range bp.Stages {
+		for _, s := range st.Steps {
+			if !s.done {
+				s.close(t)
+			}
+			if st.Start.IsZero() || s.Start.Before(st.Start) {
+				st.Start = s.Start
+			}
+			if s.End.After(st.End) {
+				st.End = s.End
+			}
+			switch s.Cache {
+			case "hit":
+				bp.CacheHits++
+			case "miss":
+				bp.CacheMisses++
+			}
+		}
+		st.Ms = st.End.Sub(st.Start).Milliseconds()
+	}
+	bp.open = nil
+}
+
+// report assembles the run profile: levels and critical path through deps
+// (box → the boxes it builds on), cache and pull totals, per-builder and
+// per-candy aggregates.
+func (p *buildProfiler) report(levels [][]string, deps map[string][]string) *buildProfile {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	r := &buildProfile{Started: p.started, Ms: time.Since(p.started).Milliseconds(), Levels: levels}
+	for i, level := range levels {
+		for _, name := range level {
+			if bp, ok := p.boxes[name]; ok {
+				bp.Level = i
+				r.Boxes = append(r.Boxes, bp)
+			}
+		}
+	}
+	r.CriticalPath, r.CriticalMs = criticalPath(levels, deps, p.boxes)
+
+	candies := map[string]*candyTotal{}
+	builders := map[string]*builderTotal{}
+	for _, bp := range r.Boxes {
+		r.CacheHits += bp.CacheHits
+		r.CacheMisses += bp.CacheMisses
+		for _, pi := range bp.Pulled {
+			r.PulledBytes += pi.Bytes
+		}
+		for _, st := range bp.Stages {
+			if st.Builder != "" {
+				bt := builders[st.Builder]
+				if bt == nil {
+					bt = &builderTotal{Builder: st.Builder}
+					builders[st.Builder] = bt
+				}
+				bt.Ms += st.Ms
+				bt.Stages++
+			}
+			for _, s := range st.Steps {
+				if s.Candy == "" {
+					continue
+				}
+				ct := candies[s.Candy]
+				if ct == nil {
+					ct = &candyTotal{Candy: s.Candy}
+					candies[s.Candy] = ct
+				}
+				ct.Ms += s.Ms
+				ct.Steps++
+				if s.Cache == "hit" {
+					ct.Cached++
+				}
+			}
+		}
+	}
+	for _, ct := range candies {
+		r.Candies = append(r.Candies, *ct)
+	}
+	sort.Slice(r.Candies, func(i, j int) bool {
+		if r.Candies[i].Ms != r.Candies[j].Ms {
+			return r.Candies[i].Ms > r.Candies[j].Ms
+		}
+		return r.Candies[i].Candy < r.Candies[j].Candy
+	})
+	for _, bt := range builders {
+		r.Builders = append(r.Builders, *bt)
+	}
+	sort.Slice(r.Builders, func(i, j int) bool { return r.Builders[i].Builder < r.Builders[j].Builder })
+	return r
+}
+
+// criticalPath is the chain of profiled boxes with the largest summed build
+// time, walking levels in order so every dependency is finished first.
+func criticalPath(levels [][]string, deps map[string][]string, boxes map[string]*boxProfile) ([]string, int64) {
+	finish := map[string]int64{}
+	prev := map[string]string{}
+	var end string
+	for _, level := range levels {
+		for _, name := range level {
+			bp, ok := boxes[name]
+			if !ok {
+				continue
+			}
+			best := int64(-1)
+			for _, d := range deps[name] {
+				if f, ok := finish[d]; ok && f > best {
+					best, prev[name] = f, d
+				}
+			}
+			finish[name] = max(best, 0) + bp.Ms
+			if end == "" || finish[name] > finish[end] {
+				end = name
+			}
+		}
+	}
+	if end == "" {
+		return nil, 0
+	}
+	var path []string
+	for n := end; n != ""; n = prev[n] {
+		path = append([]string{n}, path...)
+	}
+	return path, finish[end]
+}
+
+// chromeTrace renders the profile in Chrome trace-event format: a process per
+// box, a thread per stage, complete ("X") events for stages and steps.
+func (r *buildProfile) chromeTrace() map[string]any {
+	us := func(t time.Time) int64 { return t.Sub(r.Started).Microseconds() }
+	var events []map[string]any
+	for pid, bp := range r.Boxes {
+		events = append(events,
+			map[string]any{"name": "process_name", "ph": "M", "pid": pid + 1, "args": map[string]any{"name": bp.Box}},
+			map[string]any{"name": bp.Box, "cat": "box", "ph": "X", "pid": pid + 1, "tid": 0,
+				"ts": us(bp.Start), "dur": bp.End.Sub(bp.Start).Microseconds(), "args": map[string]any{"level": bp.Level}})
+		for tid, st := range bp.Stages {
+			label := st.Name
+			if label == "" {
+				label = fmt.Sprintf("stage %d", st.Index)
+			}
+			events = append(events,
+				map[string]any{"name": "thread_name", "ph": "M", "pid": pid + 1, "tid": tid + 1, "args": map[string]any{"name": label}},
+				map[string]any{"name": label, "cat": "stage", "ph": "X", "pid": pid + 1, "tid": tid + 1,
+					"ts": us(st.Start), "dur": st.End.Sub(st.Start).Microseconds()})
+			for _, s := range st.Steps {
+				events = append(events, map[string]any{"name": s.Instruction, "cat": "step", "ph": "X", "pid": pid + 1, "tid": tid + 1,
+					"ts": us(s.Start), "dur": s.End.Sub(s.Start).Microseconds(),
+					"args": map[string]any{"candy": s.Candy, "cache": s.Cache}})
+			}
+		}
+	}
+	return map[string]any{"traceEvents": events, "displayTimeUnit": "ms"}
+}
+
+// writeProfile writes profile.json and profile.trace.json under buildDir.
+func writeProfile(buildDir string, r *buildProfile) error {
+	for file, v := range map[string]any{profileJSONFile: r, profileTraceFile: r.chromeTrace()} {
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := atomicWriteFile(filepath.Join(buildDir, file), append(data, '\n'), 0o644); err != nil {
+			return fmt.Errorf("writing %s: %w", file, err)
+		}
+	}
+	return nil
+}
+
+// printProfileSummary prints the wall time, critical path, cache ratio, pulled
+// bytes, builder-stage totals and the slowest candies.
+func printProfileSummary(w io.Writer, r *buildProfile) {
+	ms := func(n int64) time.Duration {
+		return (time.Duration(n) * time.Millisecond).Round(100 * time.Millisecond)
+	}
+	fmt.Fprintf(w, "\n=== Build profile ===\n")
+	fmt.Fprintf(w, "Wall time:     %s (%d boxes, %d levels)\n", ms(r.Ms), len(r.Boxes), len(r.Levels))
+	if len(r.CriticalPath) > 0 {
+		fmt.Fprintf(w, "Critical path: %s (%s)\n", ms(r.CriticalMs), strings.Join(r.CriticalPath, " → "))
+	}
+	if total := r.CacheHits + r.CacheMisses; total > 0 {
+		fmt.Fprintf(w, "Cache:         %d hits, %d misses (%d%% hit)\n", r.CacheHits, r.CacheMisses, r.CacheHits*100/total)
+	}
+	fmt.Fprintf(w, "Pulled:        %s\n", humanBytes(r.PulledBytes))
+	for _, bt := range r.Builders {
+		fmt.Fprintf(w, "Builder %-6s %s across %d stage(s)\n", bt.Builder+":", ms(bt.Ms), bt.Stages)
+	}
+	if len(r.Candies) > 0 {
+		fmt.Fprintf(w, "Slowest candies:\n")
+		for i, ct := range r.Candies {
+			if i == profileTopCandy {
+				break
+			}
+			fmt.Fprintf(w, "  %10s  %-24s %d steps, %d cached\n", ms(ct.Ms), ct.Candy, ct.Steps, ct.Cached)
+		}
+	}
+}
+
+// finishProfile computes the dependency graph of the profiled run, writes the
+// profile files and prints the summary.
+func (p *buildProfiler) finishProfile(dir string, gen *Generator) {
+	levels, err := ResolveBoxLevels(gen.Boxes, gen.Candies)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: build profile: %v\n", err)
+		return
+	}
+	deps := map[string][]string{}
+	for name, img := range gen.Boxes {
+		deps[name] = boxDirectDeps(name, img, gen.Boxes, BoxNeedsBuilder(img, gen.Boxes, gen.Candies))
+	}
+	r := p.report(levels, deps)
+	buildDir := filepath.Join(dir, ".build")
+	if err := writeProfile(buildDir, r); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: build profile: %v\n", err)
+	}
+	printProfileSummary(os.Stderr, r)
+	fmt.Fprintf(os.Stderr, "Profile: %s, %s\n", filepath.Join(buildDir, profileJSONFile), filepath.Join(buildDir, profileTraceFile))
+}
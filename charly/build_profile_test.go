@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testProfileContainerfile = `# generated
+FROM ghcr.io/org/pixi:1 AS web-pixi-build
+COPY web/pixi.toml /home/user/
+RUN pixi install \
+    --frozen
+
+FROM scratch AS web
+COPY web/data/ /
+
+FROM ghcr.io/org/base:2026.1.1
+USER root
+
+# Layer: tools
+RUN dnf install -y git
+COPY --from=web-pixi-build /home/user/.pixi /home/user/.pixi
+
+# Layer: web
+RUN echo hi
+`
+
+func TestParseContainerfileStages(t *testing.T) {
+	stages := parseContainerfileStages(testProfileContainerfile)
+	if len(stages) != 3 {
+		t.Fatalf("stages = %d, want 3", len(stages))
+	}
+	if s := stages[0]; s.Name != "web-pixi-build" || s.Candy != "web" || s.Builder != "pixi" || len(s.Instructions) != 3 {
+		t.Errorf("builder stage = %+v", s)
+	}
+	if s := stages[1]; s.Base != "scratch" || s.Candy != "web" {
+		t.Errorf("data stage = %+v", s)
+	}
+	var got []string
+	for _, in := range stages[2].Instructions {
+		got = append(got, in.Candy)
+	}
+	if want := []string{"", "", "tools", "tools", "web"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("main stage candies = %q, want %q", got, want)
+	}
+	if stages[2].Base != "ghcr.io/org/base:2026.1.1" {
+		t.Errorf("main base = %q", stages[2].Base)
+	}
+}
+
+// feed plays output lines into bp, one second apart from t0.
+func feed(bp *boxProfile, t0 time.Time, lines ...string) time.Time {
+	t := t0
+	for _, l := range lines {
+		t = t.Add(time.Second)
+		bp.observe(l, t)
+	}
+	return t
+}
+
+func TestBoxProfilePodman(t *testing.T) {
+	p := newBuildProfiler()
+	bp := p.beginBox("app", testProfileContainerfile)
+	t0 := bp.Start
+	end := feed(bp, t0,
+		"[1/3] STEP 1/3: FROM ghcr.io/org/pixi:1 AS web-pixi-build",
+		"[1/3] STEP 2/3: COPY web/pixi.toml /home/user/",
+		"--> Using cache 1a2b3c",
+		"[1/3] STEP 3/3: RUN pixi install --frozen",
+		"Resolving environment...",
+		"--> 4d5e6f",
+		"[3/3] STEP 1/5: FROM ghcr.io/org/base:2026.1.1",
+		"[3/3] STEP 3/5: RUN dnf install -y git",
+		"--> 778899",
+		"[3/3] STEP 5/5: RUN echo hi",
+		"--> Using cache aabbcc",
+	)
+	bp.finish(end)
+
+	if bp.CacheHits != 2 || bp.CacheMisses != 2 {
+		t.Errorf("cache = %d hits, %d misses; want 2, 2", bp.CacheHits, bp.CacheMisses)
+	}
+	if len(bp.Stages) != 2 || bp.Stages[0].Builder != "pixi" || bp.Stages[1].Index != 2 {
+		t.Fatalf("stages = %+v", bp.Stages)
+	}
+	pixi := bp.Stages[0].Steps[2]
+	if pixi.Candy != "web" || pixi.Ms != 2000 || pixi.Cache != "miss" {
+		t.Errorf("pixi step = %+v, want web, 2000ms, miss", pixi)
+	}
+	if s := bp.Stages[1].Steps[1]; s.Candy != "tools" || s.Ms != 1000 {
+		t.Errorf("dnf step = %+v, want tools, 1000ms", s)
+	}
+	if s := bp.Stages[1].Steps[0]; s.Cache != "" || s.Ms != 1000 {
+		t.Errorf("FROM step = %+v, want no cache state, ended by the next STEP", s)
+	}
+}
+
+func TestBoxProfileBuildKit(t *testing.T) {
+	p := newBuildProfiler()
+	bp := p.beginBox("app", testProfileContainerfile)
+	end := feed(bp, bp.Start,
+		"#4 [web-pixi-build 3/3] RUN pixi install --frozen",
+		"#4 0.512 Resolving environment",
+		"#6 [stage-2 3/5] RUN dnf install -y git",
+		"#6 CACHED",
+		"#4 DONE 42.5s",
+	)
+	bp.finish(end)
+	st := bp.Stages[0]
+	if st.Name != "web-pixi-build" || st.Steps[0].Ms != 42500 || st.Steps[0].Candy != "web" {
+		t.Errorf("pixi stage = %+v / %+v", st, st.Steps[0])
+	}
+	if s := bp.Stages[1].Steps[0]; s.Cache != "hit" || s.Candy != "tools" {
+		t.Errorf("dnf step = %+v, want a tools cache hit", s)
+	}
+}
+
+func TestBuildProfileReport(t *testing.T) {
+	p := newBuildProfiler()
+	t0 := time.Date(2026, 4, 10, 12, 0, 0, 0, time.UTC)
+	p.started = t0
+	mk := func(name string, start, secs int, candy string) {
+		bp := &boxProfile{Box: name, Start: t0.Add(time.Duration(start) * time.Second)}
+		st := &stageProfile{Index: 0}
+		st.Steps = []*stepProfile{{Step: 2, Instruction: "RUN x", Candy: candy, Cache: "miss", Start: bp.Start, stage: st}}
+		bp.Stages = []*stageProfile{st}
+		st.Steps[0].close(bp.Start.Add(time.Duration(secs) * time.Second))
+		bp.finish(bp.Start.Add(time.Duration(secs) * time.Second))
+		p.boxes[name] = bp
+	}
+	mk("base", 0, 10, "os")
+	mk("python", 10, 30, "python")
+	mk("node", 10, 5, "node")
+	mk("jupyter", 40, 20, "python")
+	levels := [][]string{{"base"}, {"node", "python"}, {"jupyter"}}
+	deps := map[string][]string{"python": {"base"}, "node": {"base"}, "jupyter": {"python", "node"}}
+
+	r := p.report(levels, deps)
+	if want := []string{"base", "python", "jupyter"}; !reflect.DeepEqual(r.CriticalPath, want) || r.CriticalMs != 60000 {
+		t.Errorf("critical path = %v (%dms), want %v (60000ms)", r.CriticalPath, r.CriticalMs, want)
+	}
+	if r.Candies[0].Candy != "python" || r.Candies[0].Ms != 50000 || r.Candies[0].Steps != 2 {
+		t.Errorf("slowest candy = %+v, want python 50000ms over 2 steps", r.Candies[0])
+	}
+	if r.Boxes[2].Box != "python" || r.Boxes[2].Level != 1 {
+		t.Errorf("boxes not in level order: %s at level %d", r.Boxes[2].Box, r.Boxes[2].Level)
+	}
+
+	dir := t.TempDir()
+	if err := writeProfile(dir, r); err != nil {
+		t.Fatal(err)
+	}
+	var trace struct {
+		TraceEvents []map[string]any `json:"traceEvents"`
+	}
+	data, err := os.ReadFile(filepath.Join(dir, profileTraceFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &trace); err != nil || len(trace.TraceEvents) != 4*5 {
+		t.Errorf("trace: %d events, err %v; want 20", len(trace.TraceEvents), err)
+	}
+	var summary bytes.Buffer
+	printProfileSummary(&summary, r)
+	if !strings.Contains(summary.String(), "Critical path: 1m0s (base → python → jupyter)") {
+		t.Errorf("summary:\n%s", summary.String())
+	}
+}
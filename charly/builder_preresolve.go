@@ -4,6 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/overthinkos/overthink/charly/spec"
 )
@@ -178,7 +181,8 @@ func ensureBuildersConnected(ctx context.Context, cfg *Config, dir string, words
 // invokeBuilderCollect Invokes the builder plugin's OpCollectContext, returning the
 // builder-specific stage-context keys. The host fills the generic descriptor it can derive
 // without builder-specific knowledge: the candy/builder/home always, plus the builder's
-// detect-config package section (Packages/Replaces) used today only by aur.
+// detect-config package section (Packages/Replaces) used today only by aur, and a go.mod
+// candy's module path + go: block (Module/Main/Ldflags).
 func invokeBuilderCollect(ctx context.Context, prov Provider, word string, layer *Candy, bDef *BuilderDef, img *ResolvedBox) (map[string]any, error) {
 	in := spec.BuilderCollectInput{Candy: layer.Name, Builder: word, Home: img.Home}
 	if bDef.DetectConfig != "" {
@@ -191,6 +195,11 @@ func invokeBuilderCollect(ctx context.Context, prov Provider, word string, layer
 			}
 		}
 	}
+	if layer.GoBuildable() {
+		in.Module = goModulePath(filepath.Join(layer.SourceDir, "go.mod"))
+		in.Main = layer.GoMain()
+		in.Ldflags = layer.GoLdflags()
+	}
 	params, err := marshalJSON(in)
 	if err != nil {
 		return nil, fmt.Errorf("marshal collect-context input: %w", err)
@@ -223,3 +232,20 @@ func invokeBuilderReverse(ctx context.Context, prov Provider, word, candy string
 	}
 	return reply.ReverseOps, nil
 }
+
+// goModulePath returns the module path declared by the go.mod at path, or "" when it is
+// unreadable or has no module directive. Only the binary name of a root (".") main
+// package depends on it, so a miss just drops that binary from the teardown list.
+func goModulePath(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], `"`)
+		}
+	}
+	return ""
+}
@@ -228,7 +228,7 @@ func UserScopeBindMounts(hostHome string) (map[string]string, error) {
 	return out, nil
 }
 
-// UserScopeEnv returns the env-var set that pixi/npm/cargo need to
+// UserScopeEnv returns the env-var set that pixi/npm/cargo/go need to
 // write into the bind-mounted directories rather than the builder's
 // own home paths.
 func UserScopeEnv(hostHome string) map[string]string {
@@ -237,6 +237,8 @@ func UserScopeEnv(hostHome string) map[string]string {
 		"RATTLER_CACHE_DIR": filepath.Join(hostHome, ".cache", "charly", "rattler"),
 		"NPM_CONFIG_PREFIX": filepath.Join(hostHome, ".npm-global"),
 		"CARGO_HOME":        filepath.Join(hostHome, ".cargo"),
+		"GOMODCACHE":        filepath.Join(hostHome, ".cache", "charly", "go-mod"),
+		"GOCACHE":           filepath.Join(hostHome, ".cache", "charly", "go-build"),
 	}
 }
 
@@ -74,6 +74,22 @@ func TestRenderCargoScript(t *testing.T) {
 	}
 }
 
+func TestRenderGoScript(t *testing.T) {
+	// RawStageContext as the go plugin's OpCollectContext returns it (JSON-decoded on the wire).
+	s := builderStepWithDef(t, "go", map[string]any{
+		"main":    []any{"./cmd/mytool", "./cmd/my tool"},
+		"ldflags": "-s -X main.version=1.2",
+	})
+	out, err := renderBuilderScript(s, "/home/user")
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	want := `go build -trimpath -buildvcs=false -ldflags='-s -X main.version=1.2' -o "$HOME/.local/bin/" ./cmd/mytool './cmd/my tool'`
+	if !strings.Contains(out, want) {
+		t.Errorf("missing go build line %q:\n%s", want, out)
+	}
+}
+
 func TestRenderAurScriptPackages(t *testing.T) {
 	s := builderStepWithDef(t, "aur", map[string]any{
 		"packages": []string{"some-pkg", "another-pkg"},
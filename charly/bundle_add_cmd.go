@@ -122,7 +122,14 @@ func deployDelArgv(name string) []string {
 //
 // For a flat name (no children, no dots) the behavior is unchanged —
 // exactly one target's Emit() call.
-func (c *BundleAddCmd) Run() error {
+func (c *BundleAddCmd) Run() (err error) {
+	defer func() {
+		ev := Event{Type: EventBundleAdd, Deploy: c.Name}
+		if c.Ref != "" {
+			ev.Detail = map[string]string{"ref": c.Ref}
+		}
+		recordOutcome(ev, err)
+	}()
 	dir, err := os.Getwd()
 	if err != nil {
 		return fmt.Errorf("getwd: %w", err)
@@ -567,7 +574,8 @@ func deriveChildExecutorForPath(path string, node *BundleNode, parentExec Deploy
 // still works for ref-based deploys without a charly.yml entry: a node
 // is synthesized from the classified target so the resolver has a
 // target: to dispatch on.
-func (c *BundleDelCmd) Run() error {
+func (c *BundleDelCmd) Run() (err error) {
+	defer func() { recordOutcome(Event{Type: EventBundleDel, Deploy: c.Name}, err) }()
 	paths, err := DefaultLedgerPaths()
 	if err != nil {
 		return err
@@ -324,12 +324,18 @@ func vmDeployEntryKeys(dc *BundleConfig, deployName string) []string {
 // hostArchRuntime returns runtime.GOARCH translated to the libvirt/
 // QEMU canonical form (amd64 → x86_64, arm64 → aarch64).
 func hostArchRuntime() string {
-	switch runtime.GOARCH {
+	return unameArch(runtime.GOARCH)
+}
+
+// unameArch translates a GOARCH / OCI architecture name to the kernel's
+// `uname -m` form (amd64 → x86_64, arm64 → aarch64).
+func unameArch(goarch string) string {
+	switch goarch {
 	case "amd64":
 		return "x86_64"
 	case "arm64":
 		return "aarch64"
 	default:
-		return runtime.GOARCH
+		return goarch
 	}
 }
@@ -19,6 +19,8 @@ type BundleCmd struct {
 	Add BundleAddCmd `cmd:"" help:"Apply a deploy: 'host' targets the local system; any other name targets a container"`
 	Del BundleDelCmd `cmd:"" help:"Tear down a deploy by name"`
 
+	Drift BundleDriftCmd `cmd:"" help:"Diff deployments against their declaration (quadlets, image, ports, secrets, host ledger); exit 2 on drift"`
+
 	FromImage BundleFromBoxCmd `cmd:"" name:"from-box" help:"Source-less deploy from a built image's baked OCI labels (no charly.yml project). Pod by default; --cluster targets K8s"`
 
 	Export BundleExportCmd `cmd:"" help:"Export effective config as charly.yml"`
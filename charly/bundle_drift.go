@@ -0,0 +1,622 @@
+package main
+
+// bundle_drift.go — `charly bundle drift [name]`, the diff between what a
+// deployment declares and what is live.
+//
+// A name resolves as a dotted node path (ResolveNodePath), and the walk
+// descends into the tree the way `charly bundle add` deploys it: a group is
+// checked through its members, a pod nested under a local node is checked by
+// its flattened container name, and anything nested inside a pod or VM is
+// listed as unchecked.
+//
+// Pod deployments: the quadlet set is regenerated from the image labels and
+// charly.yml through the env, env-file and sidecar resolution `charly config`
+// uses (renderDeployQuadlets: the unit's own Image= line and charly path, no
+// CLI flags) and compared with the files under quadletDir() — app .container,
+// .pod, sidecar .container files, line by line. The running
+// container is then checked against the regenerated units: the image ref it was
+// created from and the image ID that ref resolves to now, the published ports
+// (every PublishPort= of the set), and every Secret= the set references —
+// present, equal to its declared source (credential store / env) and not
+// replaced after the container started.
+//
+// Local deployments: the install ledger (install_ledger.go) is the record of
+// what `charly bundle add` applied. Every recorded ReverseOp whose effect can
+// be observed on the host — files, directories, env.d and repo files, unit
+// files and drop-ins, enabled services, managed rc-file blocks, pixi envs —
+// is probed; a reverted one is drift. Package, cargo, npm, copr and plugin
+// script ops are counted but not probed. Remote (host: user@machine) local
+// deploys and the other substrates are listed as unchecked.
+//
+// Exit status: 0 when nothing drifted, 2 (CheckFailedError) when a deployment
+// drifted, 1 when a deployment could not be checked — so a cron job running
+// `charly bundle drift --format json` can alert on a non-zero exit.
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BundleDriftCmd implements `charly bundle drift`.
+type BundleDriftCmd struct {
+	Name     string `arg:"" optional:"" help:"Deployment to check (default: every deployment in charly.yml)"`
+	Instance string `short:"i" long:"instance" help:"Instance name"`
+	Format   string `long:"format" default:"text" enum:"text,json" help:"Output format (text|json)"`
+}
+
+// Drift states of one deployment.
+const (
+	driftInSync      = "in-sync"
+	driftDrifted     = "drifted"
+	driftNotDeployed = "not-deployed"
+	driftUnchecked   = "unchecked"
+	driftError       = "error"
+)
+
+// driftReport is the result of one `charly bundle drift` run.
+type driftReport struct {
+	Checked     time.Time     `json:"checked"`
+	Deployments []deployDrift `json:"deployments"`
+	Drifted     int           `json:"drifted"`
+	Errors      int           `json:"errors"`
+}
+
+// deployDrift is the drift of one deployment.
+type deployDrift struct {
+	Deploy   string         `json:"deploy"`
+	Target   string         `json:"target"`
+	Status   string         `json:"status"`
+	Note     string         `json:"note,omitempty"`
+	Findings []driftFinding `json:"findings,omitempty"`
+	Unprobed int            `json:"unprobed_ops,omitempty"` // local: recorded ops drift cannot observe
+}
+
+// driftFinding is one difference between declared and live state.
+type driftFinding struct {
+	Kind    string   `json:"kind"`    // unit | image | port | secret | ledger
+	Subject string   `json:"subject"` // unit file, image ref, container port, secret, candy
+	Message string   `json:"message"`
+	Diff    []string `json:"diff,omitempty"` // unit: "-" declared, "+" live
+}
+
+func (d *deployDrift) add(kind, subject, format string, args ...any) {
+	d.Findings = append(d.Findings, driftFinding{Kind: kind, Subject: subject, Message: fmt.Sprintf(format, args...)})
+}
+
+// settle derives the status from the findings unless one is already set.
+func (d *deployDrift) settle() {
+	if d.Status != "" {
+		return
+	}
+	if len(d.Findings) > 0 {
+		d.Status = driftDrifted
+	} else {
+		d.Status = driftInSync
+	}
+}
+
+func (c *BundleDriftCmd) Run() error {
+	dir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	root, err := resolveTreeRoot(dir)
+	if err != nil {
+		return err
+	}
+	keys := sortedMapKeys(root)
+	if c.Name != "" {
+		key := deployKey(c.Name, c.Instance)
+		if _, _, err := ResolveNodePath(root, key); err != nil {
+			return fmt.Errorf("no deployment %q in charly.yml: %w", key, err)
+		}
+		keys = []string{key}
+	}
+	rt, err := ResolveRuntime()
+	if err != nil {
+		return err
+	}
+	w := &driftWalk{
+		rt:    rt,
+		dc:    loadDeployConfigForRead("charly bundle drift"),
+		root:  root,
+		seen:  map[string]bool{},
+		check: &driftReport{Checked: time.Now().UTC()},
+	}
+	if w.paths, err = DefaultLedgerPaths(); err != nil {
+		return err
+	}
+	for _, key := range keys {
+		node, ancestors, _ := ResolveNodePath(root, key)
+		top := node
+		if len(ancestors) > 0 {
+			top = ancestors[0]
+		}
+		exec, err := rootExecutorForDeployNode(top)
+		if err != nil {
+			w.add(deployDrift{Deploy: key, Target: classifyNodeTarget(node, key), Status: driftError, Note: err.Error()})
+			continue
+		}
+		_, onHost := exec.(ShellExecutor)
+		offHost := "remote host (" + top.Host + "): drift is probed on this machine only"
+		parts := splitDottedPath(key)
+		for i, a := range ancestors {
+			if t := classifyNodeTarget(a, strings.Join(parts[:i+1], ".")); onHost && t != "local" {
+				onHost, offHost = false, fmt.Sprintf("runs inside %s %s: drift is probed on this machine only", t, strings.Join(parts[:i+1], "."))
+			}
+		}
+		w.node(key, node, onHost, offHost)
+	}
+	report := w.check
+
+	if err := writeDriftReport(os.Stdout, report, c.Format); err != nil {
+		return err
+	}
+	if report.Drifted > 0 {
+		return &CheckFailedError{Failed: report.Drifted, Msg: fmt.Sprintf("%d deployment(s) drifted from their declaration", report.Drifted)}
+	}
+	if report.Errors > 0 {
+		return fmt.Errorf("%d deployment(s) could not be checked", report.Errors)
+	}
+	return nil
+}
+
+// driftWalk checks a deployment tree: nested children and group members are
+// resolved like `charly bundle add` deploys them.
+type driftWalk struct {
+	rt    *ResolvedRuntime
+	dc    *BundleConfig
+	paths *LedgerPaths
+	root  map[string]BundleNode
+	seen  map[string]bool
+	check *driftReport
+}
+
+func (w *driftWalk) add(d deployDrift) {
+	d.settle()
+	switch d.Status {
+	case driftDrifted:
+		w.check.Drifted++
+	case driftError:
+		w.check.Errors++
+	}
+	w.check.Deployments = append(w.check.Deployments, d)
+}
+
+// node checks the deployment at path and descends into it. A group has no
+// workload of its own: its members (folded to top-level entries, see
+// bundle_members.go) are checked in its place. onHost is false for anything
+// that does not run on this machine — under a remote host, or nested inside a
+// pod or VM — and such a node is listed as unchecked with offHost as the note.
+func (w *driftWalk) node(path string, node *BundleNode, onHost bool, offHost string) {
+	if node == nil || w.seen[path] {
+		return
+	}
+	w.seen[path] = true
+	if node.IsGroup() {
+		for _, m := range sortedMemberKeys(node.Members) {
+			member := node.Members[m]
+			if folded, ok := w.root[m]; ok {
+				member = &folded
+			}
+			w.node(m, member, onHost, offHost)
+		}
+		return
+	}
+	target := classifyNodeTarget(node, path)
+	switch {
+	case !onHost:
+		w.add(deployDrift{Deploy: path, Target: target, Status: driftUnchecked, Note: offHost})
+	case target == "pod":
+		// A nested pod deploys under its flattened name (pod_deploy_lifecycle.go).
+		key := path
+		if strings.Contains(path, ".") {
+			key = NestedContainerName(path)
+		}
+		d := podDrift(w.rt, w.dc, key)
+		d.Deploy = path
+		w.add(d)
+	case target == "local":
+		w.add(localDrift(w.paths, path))
+	default:
+		w.add(deployDrift{Deploy: path, Target: target, Status: driftUnchecked, Note: "drift detection covers pod and local deployments"})
+	}
+	// Only a local node's children share this machine; anything nested in a
+	// pod or VM runs inside it (deriveChildExecutorForPath).
+	for _, k := range sortedNestedKeys(node.Children) {
+		childOnHost, note := onHost, offHost
+		if target != "local" {
+			childOnHost, note = false, fmt.Sprintf("runs inside %s %s: drift is probed on this machine only", target, path)
+		}
+		w.node(path+"."+k, node.Children[k], childOnHost, note)
+	}
+}
+
+// podDrift compares a pod deployment's regenerated quadlet set with its unit
+// files and its running container.
+func podDrift(rt *ResolvedRuntime, dc *BundleConfig, key string) deployDrift {
+	d := deployDrift{Deploy: key, Target: "pod"}
+	box, inst := parseDeployKey(key)
+	engine := ResolveBoxEngineForDeploy(box, inst, rt.RunEngine)
+	qdir, err := quadletDir()
+	if err != nil {
+		d.Status, d.Note = driftError, err.Error()
+		return d
+	}
+	appUnit := filepath.Join(qdir, quadletFilenameInstance(box, inst))
+	direct := IsDirectDeploy(box, inst)
+	snap, snapErr := NewEngineClient(engine).Snapshot(containerNameInstance(box, inst))
+	if snapErr != nil {
+		snap = nil
+	}
+
+	imageRef, _ := extractQuadletImageLine(appUnit)
+	if imageRef == "" && snap != nil {
+		imageRef = snap.ImageRef
+	}
+	if imageRef == "" {
+		if !fileExists(appUnit) && snap == nil {
+			d.Status = driftNotDeployed
+			return d
+		}
+		d.Status, d.Note = driftError, "cannot determine the deployed image"
+		return d
+	}
+	rendered, err := renderDeployQuadlets(rt, dc, key, imageRef, extractQuadletCharlyBin(appUnit))
+	if err != nil {
+		d.Status, d.Note = driftError, err.Error()
+		return d
+	}
+
+	if direct {
+		d.Note = "direct-mode deploy: no quadlet units to compare"
+	} else {
+		d.Findings = append(d.Findings, unitDrift(qdir, rendered.Units, podDeployUnitFiles(qdir, box, inst))...)
+	}
+	if pin := resolveDeployBoxName(box, inst); looksLikeFullRef(pin) && pin != imageRef && resolveDeployResolvedImage(box, inst) != imageRef {
+		d.add("image", imageRef, "unit runs %s, charly.yml pins %s", imageRef, pin)
+	}
+
+	if snap == nil {
+		if d.Note == "" {
+			d.Note = "container not present: runtime state not compared"
+		}
+	} else {
+		d.Findings = append(d.Findings, containerImageDrift(engine, imageRef, snap)...)
+		if snap.NetworkMode != "host" {
+			d.Findings = append(d.Findings, portDrift(publishedPorts(rendered.Units), snap.Ports)...)
+		}
+	}
+	if EngineBinary(engine) == "podman" {
+		d.Findings = append(d.Findings, secretDrift(engine, box, inst, rendered.Secrets, snap)...)
+	}
+	return d
+}
+
+// unitDrift diffs the regenerated units against the files in qdir. live lists
+// the unit files the deployment owns there (podDeployUnitFiles).
+func unitDrift(qdir string, want []renderedUnit, live []string) []driftFinding {
+	var out []driftFinding
+	wanted := map[string]bool{}
+	for _, u := range want {
+		wanted[u.Name] = true
+		data, err := os.ReadFile(filepath.Join(qdir, u.Name))
+		if err != nil {
+			out = append(out, driftFinding{Kind: "unit", Subject: u.Name, Message: "unit file is missing"})
+			continue
+		}
+		if diff := lineDiff(u.Content, string(data)); len(diff) > 0 {
+			out = append(out, driftFinding{Kind: "unit", Subject: u.Name, Message: fmt.Sprintf("unit file differs from its declaration (%d lines)", len(diff)), Diff: diff})
+		}
+	}
+	for _, name := range live {
+		if !wanted[name] {
+			out = append(out, driftFinding{Kind: "unit", Subject: name, Message: "unit file is not part of the declaration"})
+		}
+	}
+	return out
+}
+
+// lineDiff returns the lines of want missing from got ("-") and the lines of
+// got missing from want ("+"), in file order around their longest common
+// subsequence.
+func lineDiff(want, got string) []string {
+	a := strings.Split(strings.TrimSuffix(want, "\n"), "\n")
+	b := strings.Split(strings.TrimSuffix(got, "\n"), "\n")
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	var out []string
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			out = append(out, "-"+a[i])
+			i++
+		default:
+			out = append(out, "+"+b[j])
+			j++
+		}
+	}
+	return out
+}
+
+// containerImageDrift compares the image a container was created from with
+// the unit's Image= ref and with what that ref resolves to now.
+func containerImageDrift(engine, imageRef string, snap *ContainerSnapshot) []driftFinding {
+	if snap.ImageRef != "" && snap.ImageRef != imageRef {
+		return []driftFinding{{Kind: "image", Subject: imageRef, Message: fmt.Sprintf("container runs %s, the unit declares %s", snap.ImageRef, imageRef)}}
+	}
+	running := strings.TrimPrefix(snap.ImageID, "sha256:")
+	current := strings.TrimPrefix(engineImageID(engine, imageRef), "sha256:")
+	if running != "" && current != "" && running != current {
+		return []driftFinding{{Kind: "image", Subject: imageRef, Message: fmt.Sprintf("container runs image %s, %s now resolves to %s (restart to roll it out)", shortImageID(running), imageRef, shortImageID(current))}}
+	}
+	return nil
+}
+
+// publishedPorts returns the PublishPort= mappings of a unit set.
+func publishedPorts(units []renderedUnit) []PortMapping {
+	var raw []string
+	for _, u := range units {
+		for line := range strings.SplitSeq(u.Content, "\n") {
+			if v, ok := strings.CutPrefix(line, "PublishPort="); ok {
+				raw = append(raw, v)
+			}
+		}
+	}
+	return parsePortStrings(raw)
+}
+
+// portDrift compares declared port mappings with the container's published
+// ones, keyed by container port and protocol.
+func portDrift(want, live []PortMapping) []driftFinding {
+	key := func(p PortMapping) string {
+		proto := p.Proto
+		if proto == "" {
+			proto = "tcp"
+		}
+		return strconv.Itoa(p.CtrPort) + "/" + proto
+	}
+	host := func(p PortMapping) string {
+		if p.HostIP == "" || p.HostIP == "0.0.0.0" || p.HostIP == "::" {
+			return strconv.Itoa(p.HostPort)
+		}
+		return net.JoinHostPort(p.HostIP, strconv.Itoa(p.HostPort))
+	}
+	liveByKey := map[string]PortMapping{}
+	for _, p := range live {
+		liveByKey[key(p)] = p
+	}
+	var out []driftFinding
+	seen := map[string]bool{}
+	for _, w := range want {
+		k := key(w)
+		seen[k] = true
+		l, ok := liveByKey[k]
+		switch {
+		case !ok:
+			out = append(out, driftFinding{Kind: "port", Subject: k, Message: fmt.Sprintf("not published (declared on %s)", host(w))})
+		case host(l) != host(w):
+			out = append(out, driftFinding{Kind: "port", Subject: k, Message: fmt.Sprintf("published on %s, declared on %s", host(l), host(w))})
+		}
+	}
+	for _, k := range sortedMapKeys(liveByKey) {
+		if !seen[k] {
+			out = append(out, driftFinding{Kind: "port", Subject: k, Message: fmt.Sprintf("published on %s but not declared", host(liveByKey[k]))})
+		}
+	}
+	return out
+}
+
+// podmanSecretInfo is the part of `podman secret inspect` drift compares.
+type podmanSecretInfo struct {
+	CreatedAt  time.Time `json:"CreatedAt"`
+	UpdatedAt  time.Time `json:"UpdatedAt"`
+	SecretData string    `json:"SecretData"` // only with --showsecret (podman ≥ 4.5)
+}
+
+// inspectPodmanSecret returns a podman secret, or nil when it does not exist.
+func inspectPodmanSecret(engine, name string) *podmanSecretInfo {
+	bin := EngineBinary(engine)
+	out, err := exec.Command(bin, "secret", "inspect", "--showsecret", name).Output()
+	if err != nil {
+		if out, err = exec.Command(bin, "secret", "inspect", name).Output(); err != nil {
+			return nil
+		}
+	}
+	var infos []podmanSecretInfo
+	if json.Unmarshal(out, &infos) != nil || len(infos) == 0 {
+		return nil
+	}
+	return &infos[0]
+}
+
+// secretDrift checks every declared secret: it exists, it holds the value its
+// declared source resolves to (when that resolves and podman shows secret
+// data), and it was not replaced after the container started. Values are
+// compared, never reported.
+func secretDrift(engine, box, inst string, secrets []CollectedSecret, snap *ContainerSnapshot) []driftFinding {
+	var out []driftFinding
+	seen := map[string]bool{}
+	for _, s := range secrets {
+		if seen[s.Name] {
+			continue
+		}
+		seen[s.Name] = true
+		info := inspectPodmanSecret(engine, s.Name)
+		if info == nil {
+			out = append(out, driftFinding{Kind: "secret", Subject: s.Name, Message: "podman secret does not exist"})
+			continue
+		}
+		if val, source := resolveSecretValue(s, box, inst); val != "" && info.SecretData != "" && val != info.SecretData {
+			out = append(out, driftFinding{Kind: "secret", Subject: s.Name, Message: fmt.Sprintf("podman secret differs from its declared source (%s)", source)})
+			continue
+		}
+		changed := info.CreatedAt
+		if info.UpdatedAt.After(changed) {
+			changed = info.UpdatedAt
+		}
+		if snap != nil && !snap.StartedAt.IsZero() && changed.After(snap.StartedAt) {
+			out = append(out, driftFinding{Kind: "secret", Subject: s.Name, Message: fmt.Sprintf("podman secret replaced at %s, after the container started (%s)", changed.Format(time.RFC3339), snap.StartedAt.Format(time.RFC3339))})
+		}
+	}
+	return out
+}
+
+// localDrift probes the recorded effects of a local deployment's candies.
+func localDrift(paths *LedgerPaths, name string) deployDrift {
+	d := deployDrift{Deploy: name, Target: "local"}
+	rec, err := ReadDeployRecord(paths, computeDeployID(name, nil, nil))
+	if err != nil {
+		d.Status, d.Note = driftError, err.Error()
+		return d
+	}
+	if rec == nil {
+		d.Status = driftNotDeployed
+		return d
+	}
+	for _, candy := range rec.Candy {
+		cr, err := ReadCandyRecord(paths, candy)
+		if err != nil {
+			d.Status, d.Note = driftError, err.Error()
+			return d
+		}
+		if cr == nil {
+			d.add("ledger", candy, "candy record is missing from the ledger")
+			continue
+		}
+		if !slices.Contains(cr.DeployedBy, rec.DeployID) {
+			d.add("ledger", candy, "candy record no longer lists this deployment")
+		}
+		for _, op := range cr.ReverseOps {
+			msgs, probed := reverseOpDrift(op)
+			if !probed {
+				d.Unprobed++
+			}
+			for _, msg := range msgs {
+				d.add("ledger", candy, "%s", msg)
+			}
+		}
+	}
+	return d
+}
+
+// systemctlIsEnabled returns `systemctl [--user] is-enabled <unit>`.
+var systemctlIsEnabled = func(user bool, unit string) string {
+	args := []string{"is-enabled", unit}
+	if user {
+		args = append([]string{"--user"}, args...)
+	}
+	out, _ := exec.Command("systemctl", args...).Output()
+	return strings.TrimSpace(string(out))
+}
+
+// reverseOpDrift returns how the host no longer carries what a recorded
+// ReverseOp would undo; probed is false for ops whose effect is not observed.
+func reverseOpDrift(op ReverseOp) (drift []string, probed bool) {
+	switch op.Kind {
+	case ReverseOpRmFileSystem, ReverseOpRmFileUser, ReverseOpRmDirRecursive, ReverseOpServiceRemove,
+		ReverseOpRemoveDropin, ReverseOpRemoveEnvdFile, ReverseOpRemoveRepoFile:
+		for _, path := range op.Targets {
+			if _, err := os.Stat(path); os.IsNotExist(err) {
+				drift = append(drift, fmt.Sprintf("%s is gone (%s)", path, op.Kind))
+			}
+		}
+	case ReverseOpPixiEnvRemove:
+		home, _ := os.UserHomeDir()
+		for _, env := range op.Targets {
+			if _, err := os.Stat(filepath.Join(home, ".pixi", "envs", env)); os.IsNotExist(err) {
+				drift = append(drift, fmt.Sprintf("pixi env %s is gone", env))
+			}
+		}
+	case ReverseOpServiceDisable:
+		for _, unit := range op.Targets {
+			switch state := systemctlIsEnabled(op.Scope == ScopeUser, unit); state {
+			case "disabled", "masked", "not-found", "":
+				if state == "" {
+					state = "unknown to systemd"
+				}
+				drift = append(drift, fmt.Sprintf("service %s is %s", unit, state))
+			}
+		}
+	case ReverseOpRemoveManaged:
+		begin, _ := markersForTag(op.Extra["marker"])
+		for _, path := range op.Targets {
+			data, err := os.ReadFile(path)
+			if err != nil || !strings.Contains(string(data), begin) {
+				drift = append(drift, fmt.Sprintf("managed block %q is missing from %s", op.Extra["marker"], path))
+			}
+		}
+	case ReverseOpRestoreEnabled:
+		// Records the prior state of a unit, not an effect of the deploy.
+	default:
+		return nil, false
+	}
+	return drift, true
+}
+
+// writeDriftReport renders the report.
+func writeDriftReport(w io.Writer, r *driftReport, format string) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	case "text", "":
+	default:
+		return fmt.Errorf("unknown drift format %q (want text or json)", format)
+	}
+	if len(r.Deployments) == 0 {
+		fmt.Fprintln(w, "No deployments in charly.yml")
+		return nil
+	}
+	for _, d := range r.Deployments {
+		status := d.Status
+		if d.Status == driftDrifted {
+			status = fmt.Sprintf("drifted (%d)", len(d.Findings))
+		}
+		fmt.Fprintf(w, "%-32s %-6s %s", d.Deploy, d.Target, status)
+		if d.Note != "" {
+			fmt.Fprintf(w, ": %s", d.Note)
+		}
+		fmt.Fprintln(w)
+		for _, f := range d.Findings {
+			fmt.Fprintf(w, "  %s %s: %s\n", f.Kind, f.Subject, f.Message)
+			for _, line := range f.Diff {
+				fmt.Fprintf(w, "    %s\n", line)
+			}
+		}
+		if d.Unprobed > 0 {
+			fmt.Fprintf(w, "  (%d recorded ops not probed: packages, cargo/npm installs, copr, plugin scripts)\n", d.Unprobed)
+		}
+	}
+	fmt.Fprintf(w, "\n%d deployment(s), %d drifted", len(r.Deployments), r.Drifted)
+	if r.Errors > 0 {
+		fmt.Fprintf(w, ", %d could not be checked", r.Errors)
+	}
+	fmt.Fprintln(w)
+	return nil
+}
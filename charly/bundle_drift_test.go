@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLineDiff(t *testing.T) {
+	want := "[Container]\nImage=ghcr.io/test/app:1\nEnvironment=A=1\nPublishPort=127.0.0.1:8080:80\n"
+	got := "[Container]\nImage=ghcr.io/test/app:1\nEnvironment=A=2\nPublishPort=127.0.0.1:8080:80\nVolume=/data:/data\n"
+	diff := lineDiff(want, got)
+	wantDiff := []string{"-Environment=A=1", "+Environment=A=2", "+Volume=/data:/data"}
+	if !reflect.DeepEqual(diff, wantDiff) {
+		t.Errorf("lineDiff = %q, want %q", diff, wantDiff)
+	}
+	if diff := lineDiff(want, want); len(diff) != 0 {
+		t.Errorf("identical units differ: %q", diff)
+	}
+}
+
+func TestUnitDrift(t *testing.T) {
+	qdir := t.TempDir()
+	for name, content := range map[string]string{
+		"charly-app.container": "[Container]\nImage=ghcr.io/test/app:1\n",
+		"charly-app.pod":       "[Pod]\n",
+	} {
+		if err := os.WriteFile(filepath.Join(qdir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []renderedUnit{
+		{Name: "charly-app.container", Content: "[Container]\nImage=ghcr.io/test/app:2\n"},
+		{Name: "charly-app-tailscale.container", Content: "[Container]\n"},
+	}
+	got := unitDrift(qdir, want, []string{"charly-app.container", "charly-app.pod"})
+	if len(got) != 3 {
+		t.Fatalf("unitDrift = %+v", got)
+	}
+	if got[0].Subject != "charly-app.container" || !reflect.DeepEqual(got[0].Diff, []string{"-Image=ghcr.io/test/app:2", "+Image=ghcr.io/test/app:1"}) {
+		t.Errorf("edited unit: %+v", got[0])
+	}
+	if got[1].Message != "unit file is missing" || got[2].Message != "unit file is not part of the declaration" {
+		t.Errorf("missing/extra units: %+v", got[1:])
+	}
+}
+
+func TestPortDrift(t *testing.T) {
+	units := []renderedUnit{{Content: "PublishPort=127.0.0.1:8080:80\nPublishPort=127.0.0.1:5353:53/udp\nPublishPort=127.0.0.1:9000:9000\n"}}
+	live := []PortMapping{
+		{HostIP: "127.0.0.1", HostPort: 8080, CtrPort: 80, Proto: "tcp"},
+		{HostIP: "0.0.0.0", HostPort: 5353, CtrPort: 53, Proto: "udp"},
+		{HostIP: "127.0.0.1", HostPort: 2222, CtrPort: 22, Proto: "tcp"},
+	}
+	var got []string
+	for _, f := range portDrift(publishedPorts(units), live) {
+		got = append(got, f.Subject+": "+f.Message)
+	}
+	want := []string{
+		"53/udp: published on 5353, declared on 127.0.0.1:5353",
+		"9000/tcp: not published (declared on 127.0.0.1:9000)",
+		"22/tcp: published on 127.0.0.1:2222 but not declared",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("portDrift = %q, want %q", got, want)
+	}
+}
+
+func TestLocalDrift(t *testing.T) {
+	root := t.TempDir()
+	paths := &LedgerPaths{Root: root, Deploys: filepath.Join(root, "deploys"), Candies: filepath.Join(root, "layers"), LockFile: filepath.Join(root, ".lock")}
+	kept := filepath.Join(root, "kept.conf")
+	rc := filepath.Join(root, "bashrc")
+	for path, content := range map[string]string{kept: "x\n", rc: "export PATH\n"} {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if d := localDrift(paths, "host"); d.Status != driftNotDeployed {
+		t.Errorf("no record: status %q", d.Status)
+	}
+
+	id := computeDeployID("host", nil, nil)
+	if err := WriteDeployRecord(paths, &DeployRecord{DeployID: id, Image: "host", Target: "local", Candy: []string{"ripgrep", "gone"}, DeployedAt: "2026-10-16T12:00:00Z"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteCandyRecord(paths, &CandyRecord{Candy: "ripgrep", DeployedBy: []string{id}, DeployedAt: "2026-10-16T12:00:00Z", ReverseOps: []ReverseOp{
+		{Kind: ReverseOpRmFileUser, Targets: []string{kept, filepath.Join(root, "removed.conf")}},
+		{Kind: ReverseOpRemoveManaged, Targets: []string{rc}, Extra: map[string]string{"marker": "ripgrep"}},
+		{Kind: ReverseOpPackageRemove, Format: "rpm", Targets: []string{"ripgrep"}},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	d := localDrift(paths, "host")
+	d.settle()
+	if d.Status != driftDrifted || d.Unprobed != 1 {
+		t.Fatalf("status %q, unprobed %d", d.Status, d.Unprobed)
+	}
+	var got []string
+	for _, f := range d.Findings {
+		got = append(got, f.Subject+": "+f.Message)
+	}
+	want := []string{
+		"ripgrep: " + filepath.Join(root, "removed.conf") + " is gone (rm-file-user)",
+		"ripgrep: managed block \"ripgrep\" is missing from " + rc,
+		"gone: candy record is missing from the ledger",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findings = %q, want %q", got, want)
+	}
+
+	var buf bytes.Buffer
+	r := &driftReport{Deployments: []deployDrift{d, {Deploy: "vm1", Target: "vm", Status: driftUnchecked, Note: "drift detection covers pod and local deployments"}}, Drifted: 1}
+	if err := writeDriftReport(&buf, r, "text"); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"drifted (3)", "  ledger gone: candy record is missing from the ledger", "(1 recorded ops not probed", "2 deployment(s), 1 drifted"} {
+		if !strings.Contains(buf.String(), line) {
+			t.Errorf("report lacks %q:\n%s", line, buf.String())
+		}
+	}
+}
+
+// The walk descends into group members and nested children: a group is
+// replaced by its members (once, even when they are also top-level entries),
+// a local node's children are checked on this machine, and anything nested in
+// a VM is listed as unchecked with the venue it runs inside.
+func TestDriftWalk(t *testing.T) {
+	dir := t.TempDir()
+	root := map[string]BundleNode{
+		"grp":  {Members: map[string]*BundleNode{"web": {Target: "k8s"}}},
+		"web":  {Target: "k8s", MemberOf: "grp"},
+		"host": {Target: "local", Children: map[string]*BundleNode{"ctl": {Target: "vm"}}},
+		"vm1":  {Target: "vm", Children: map[string]*BundleNode{"inner": {Target: "local"}}},
+	}
+	w := &driftWalk{
+		root:  root,
+		seen:  map[string]bool{},
+		check: &driftReport{},
+		paths: &LedgerPaths{Root: dir, Deploys: filepath.Join(dir, "deploys"), Candies: filepath.Join(dir, "layers"), LockFile: filepath.Join(dir, ".lock")},
+	}
+	for _, key := range sortedMapKeys(root) {
+		node := root[key]
+		w.node(key, &node, true, "")
+	}
+	got := map[string]string{}
+	for _, d := range w.check.Deployments {
+		got[d.Deploy] = d.Status + " " + d.Note
+	}
+	want := map[string]string{
+		"web":       driftUnchecked + " drift detection covers pod and local deployments",
+		"host":      driftNotDeployed + " ",
+		"host.ctl":  driftUnchecked + " drift detection covers pod and local deployments",
+		"vm1":       driftUnchecked + " drift detection covers pod and local deployments",
+		"vm1.inner": driftUnchecked + " runs inside vm vm1: drift is probed on this machine only",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("walk = %v\nwant %v", got, want)
+	}
+}
+
+// The charly binary a deployed unit recorded is read back from its mount line.
+func TestExtractQuadletCharlyBin(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "charly-app.container")
+	mustWrite(t, path, "[Service]\nExecStartPre=/opt/charly/bin/charly config mount app -i b\n")
+	if got := extractQuadletCharlyBin(path); got != "/opt/charly/bin/charly" {
+		t.Errorf("charly bin = %q", got)
+	}
+	mustWrite(t, path, "[Service]\nRestart=always\n")
+	if got := extractQuadletCharlyBin(path); got != "" {
+		t.Errorf("charly bin without a mount line = %q", got)
+	}
+}
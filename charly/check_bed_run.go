@@ -306,6 +306,22 @@ func runCheckBed(exe, name string, node BundleNode, opts bedRunOpts) (*bedRunRes
 		fmt.Fprintf(os.Stderr, "charly check run %s: testing LOCAL candies (%s += %s)\n", name, RepoOverrideEnv, pair)
 	}
 
+	// Export the run directory to the nested `charly check ...` subprocesses so a
+	// failing artifact_baseline comparison drops its diff image next to the step
+	// logs (resolveCheckImagePaths). Absolute — the children may chdir.
+	// Scoped + restored like the override above.
+	if absLog, err := filepath.Abs(logDir); err == nil {
+		old, had := os.LookupEnv(CheckRunDirEnv)
+		_ = os.Setenv(CheckRunDirEnv, absLog)
+		defer func() {
+			if had {
+				_ = os.Setenv(CheckRunDirEnv, old)
+			} else {
+				_ = os.Unsetenv(CheckRunDirEnv)
+			}
+		}()
+	}
+
 	// Resource arbitration (the "preemptible" axis): if this bed claims a host
 	// resource — EXCLUSIVE (requires_exclusive — sole use, e.g. a passthrough
 	// GPU VM) or SHARED (requires_shared — refcounted, e.g. a GPU shared across
@@ -179,7 +179,7 @@ type CheckCmd struct {
 type CheckLiveCmd struct {
 	Box      string   `arg:"" help:"Box name"`
 	Instance string   `short:"i" long:"instance" help:"Instance name"`
-	Format   string   `long:"format" default:"text" help:"Output format: text, json, tap"`
+	Format   string   `long:"format" default:"text" help:"Output format: text, json, tap, junit, sarif"`
 	Filter   []string `long:"filter" help:"Only run checks with these verbs (repeatable)"`
 	Section  string   `long:"section" help:"Only run this section: candy, box, or deploy"`
 }
@@ -1131,7 +1131,7 @@ func (c *CheckLiveCmd) runGroupCheck() error {
 // or `charly box build <name>` first if the image isn't in local storage yet.
 type CheckBoxCmd struct {
 	Image  string   `arg:"" help:"Image reference (full ref or short name resolved against local container storage; never reads charly.yml)"`
-	Format string   `long:"format" default:"text" help:"Output format: text, json, tap, yaml"`
+	Format string   `long:"format" default:"text" help:"Output format: text, json, tap, junit, sarif, yaml"`
 	Filter []string `long:"filter" help:"Only run checks with these verbs (repeatable)"`
 }
 
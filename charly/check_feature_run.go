@@ -56,6 +56,8 @@ func reportSteps(w io.Writer, results []StepResult, format string) int {
 		FormatStepResultsTAP(w, results)
 	case "junit":
 		_ = FormatStepResultsJUnit(w, results)
+	case "sarif":
+		_ = FormatStepResultsSARIF(w, results)
 	default:
 		FormatStepResultsText(w, results)
 	}
@@ -107,7 +109,7 @@ type BoxFeatureCmd struct {
 // `charly check box`.
 type BoxFeatureRunCmd struct {
 	Image  string `arg:"" help:"Image reference (full ref or short name resolved against local container storage)"`
-	Format string `long:"format" default:"text" help:"Output format: text, json, tap, junit"`
+	Format string `long:"format" default:"text" help:"Output format: text, json, tap, junit, sarif"`
 	Tag    string `long:"tag" help:"Only run steps matching this tag expression (e.g. 'smoke and not slow')"`
 	Strict bool   `long:"strict" help:"Treat prose-only (unbound) steps as failures instead of skips"`
 }
@@ -169,7 +171,7 @@ type CheckFeatureCmd struct {
 type CheckFeatureRunCmd struct {
 	Box      string `arg:"" help:"Deployment name (a box-backed pod deployment)"`
 	Instance string `short:"i" long:"instance" help:"Instance name"`
-	Format   string `long:"format" default:"text" help:"Output format: text, json, tap, junit"`
+	Format   string `long:"format" default:"text" help:"Output format: text, json, tap, junit, sarif"`
 	Tag      string `long:"tag" help:"Only run steps matching this tag expression"`
 	Agent    string `long:"agent" help:"kind:agent entry to use as the prose-step grader (default: the sole configured agent)"`
 	Timeout  string `long:"timeout" help:"Per-grader-call wall-clock cap (Go duration; default 5m or the ai entry's timeout)"`
@@ -15,6 +15,7 @@ package main
 // ceiling — as long as the AI keeps improving, the run continues.
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -1347,15 +1348,38 @@ func writeReport(layout RunLayout, r *FinalReport) error {
 		return err
 	}
 	resultPath := filepath.Join(layout.ResultsDir(), "result-"+r.Calver+".yml")
-	return os.WriteFile(resultPath, data, 0o644)
+	if err := os.WriteFile(resultPath, data, 0o644); err != nil {
+		return err
+	}
+	// CI-consumable siblings of the YAML report (JUnit XML + SARIF), so a
+	// dashboard can ingest the same verdicts without a converter.
+	var junit, sarif bytes.Buffer
+	if err := FormatFinalReportJUnit(&junit, r); err != nil {
+		return err
+	}
+	if err := FormatFinalReportSARIF(&sarif, r); err != nil {
+		return err
+	}
+	base := filepath.Join(layout.ResultsDir(), "result-"+r.Calver)
+	if err := os.WriteFile(base+".junit.xml", junit.Bytes(), 0o644); err != nil {
+		return err
+	}
+	return os.WriteFile(base+".sarif", sarif.Bytes(), 0o644)
 }
 
 // printHarnessReport renders a summary of the run to stdout.
 func printHarnessReport(w *os.File, r *FinalReport, format string) {
-	if format == "yaml" {
+	switch format {
+	case "yaml":
 		data, _ := yaml.Marshal(r)
 		_, _ = w.Write(data)
 		return
+	case "junit":
+		_ = FormatFinalReportJUnit(w, r)
+		return
+	case "sarif":
+		_ = FormatFinalReportSARIF(w, r)
+		return
 	}
 	fmt.Fprintf(w, "harness: score=%s ai=%s exit=%s iterations=%d best=%d/%d\n",
 		r.Score, r.Agent, r.ExitReason, r.IterationsRun, r.BestScore, r.Summary.Input)
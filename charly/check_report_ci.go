@@ -0,0 +1,528 @@
+package main
+
+// check_report_ci.go — JUnit XML + SARIF 2.1.0 renderers for check results.
+//
+// Three result shapes feed CI dashboards and code-scanning views:
+//
+//   - []StepResult  — `charly check box|live`, `charly box|check feature run`
+//     (RunPlan output, description_run.go)
+//   - []CheckResult — bare Op runs (Runner.Run, checkrun.go)
+//   - *FinalReport  — the `iterate:` harness aggregate (check_loop.go), one
+//     suite per iteration plus the final verdicts
+//
+// All three are normalized into ciCase rows first, so the JUnit and SARIF
+// writers are shared and a step renders identically whichever runner produced
+// it. Suites group by section (candy / box / deploy, derived from the
+// collection-time Origin convention in labelset.go) and then by Origin.
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ciCase is one renderable test point, independent of the runner that
+// produced it.
+type ciCase struct {
+	Suite        string // optional prefix, e.g. "iter3" for harness iterations
+	Origin       string
+	Name         string
+	Verb         string
+	StepID       string
+	Status       CheckStatus
+	Message      string
+	Elapsed      time.Duration
+	TotalElapsed time.Duration
+	// IterElapsed is the measured wall time of the whole harness iteration
+	// the step ran in — the harness does not time steps individually.
+	IterElapsed time.Duration
+	Attempts    int
+	Captured    string
+	SkipReason  string
+	Detail      string // extra failure body lines (verdict, fingerprints, …)
+}
+
+// originSection maps a collection-time origin (`candy:<name>`, `box:<name>`,
+// `deploy-default`, `deploy-local:<name>`) onto its LabelDescriptionSet
+// section. Unknown or empty origins land in "check".
+func originSection(origin string) string {
+	switch {
+	case strings.HasPrefix(origin, "candy:"):
+		return "candy"
+	case strings.HasPrefix(origin, "box:"):
+		return "box"
+	case strings.HasPrefix(origin, "deploy"):
+		return "deploy"
+	}
+	return "check"
+}
+
+// suiteName is the JUnit suite / SARIF logical-location name for a case:
+// `[<suite>/]<section>/<origin>`.
+func (c *ciCase) suiteName() string {
+	name := originSection(c.Origin)
+	if c.Origin != "" {
+		name += "/" + c.Origin
+	}
+	if c.Suite != "" {
+		name = c.Suite + "/" + name
+	}
+	return name
+}
+
+// wallTime is the case's reported duration — the whole retry loop when the
+// check had an `eventually:` modifier, else the single attempt.
+func (c *ciCase) wallTime() time.Duration {
+	if c.TotalElapsed > 0 {
+		return c.TotalElapsed
+	}
+	return c.Elapsed
+}
+
+// skipReason is the explicit dependency-cascade reason when present, else
+// the runner's skip message.
+func (c *ciCase) skipReason() string {
+	return firstNonEmpty(c.SkipReason, c.Message)
+}
+
+func ciCasesFromSteps(results []StepResult) []ciCase {
+	out := make([]ciCase, 0, len(results))
+	for _, s := range results {
+		out = append(out, ciCase{
+			Origin:       s.Origin,
+			Name:         strings.TrimSpace(s.Keyword + " " + s.Text),
+			Verb:         s.Result.Verb,
+			StepID:       s.StepID,
+			Status:       s.Result.Status,
+			Message:      s.Result.Message,
+			Elapsed:      s.Result.Elapsed,
+			TotalElapsed: s.Result.TotalElapsed,
+			Attempts:     s.Result.Attempts,
+			Captured:     s.Result.CapturedValue,
+		})
+	}
+	return out
+}
+
+func ciCasesFromChecks(results []CheckResult) []ciCase {
+	out := make([]ciCase, 0, len(results))
+	for _, r := range results {
+		c := ciCase{
+			Verb:         r.Verb,
+			Status:       r.Status,
+			Message:      r.Message,
+			Elapsed:      r.Elapsed,
+			TotalElapsed: r.TotalElapsed,
+			Attempts:     r.Attempts,
+			Captured:     r.CapturedValue,
+		}
+		if r.Op != nil {
+			c.Origin = r.Op.Origin
+			c.StepID = r.Op.ID
+			subject := firstNonEmpty(r.Op.PluginInputStr("file"), r.Op.PluginInputStr("http"), r.Op.Command, r.Op.PluginInputStr("command"), r.Op.PluginInputStr("addr"))
+			c.Name = strings.TrimSpace(r.Verb + " " + subject)
+		}
+		if c.Name == "" {
+			c.Name = r.Verb
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// ciCasesFromFinalReport flattens an `iterate:` harness report: every
+// iteration's step verdicts become an `iter<k>` suite, and the final verdicts
+// a `final` suite, so a dashboard can chart a step across iterations. Only the
+// iteration as a whole is timed, so cases carry no step time of their own; the
+// iteration's measured duration rides along as the iteration_elapsed property.
+func ciCasesFromFinalReport(r *FinalReport) []ciCase {
+	if r == nil {
+		return nil
+	}
+	var out []ciCase
+	for _, it := range r.Iterations {
+		suite := "iter" + strconv.Itoa(it.K)
+		if it.Phase > 0 {
+			suite = fmt.Sprintf("phase%d/%s", it.Phase, suite)
+		}
+		elapsed, _ := time.ParseDuration(it.TestDuration)
+		for _, v := range it.Step {
+			c := ciCaseFromVerdict(suite, v)
+			c.IterElapsed = elapsed
+			out = append(out, c)
+		}
+	}
+	for _, v := range r.FinalStep {
+		out = append(out, ciCaseFromVerdict("final", v))
+	}
+	return out
+}
+
+// ciCaseFromVerdict maps a harness StepVerdict onto a pass/fail/skip test
+// point: a passing step is a pass unless the verdict says its body was
+// tampered with; a skipped step carries its dep-unmet reason.
+func ciCaseFromVerdict(suite string, v StepVerdict) ciCase {
+	c := ciCase{
+		Suite:      suite,
+		Origin:     v.Origin,
+		Name:       v.ID,
+		StepID:     v.ID,
+		SkipReason: v.SkippedReason,
+		Detail: fmt.Sprintf("Verdict: %s\nBaseline: %s\nFinal: %s",
+			v.Verdict, firstNonEmpty(v.Baseline, "-"), firstNonEmpty(v.Final, "-")),
+	}
+	switch {
+	case v.Verdict == VerdictSkipped || v.Final == "skip" || v.Final == "skipped":
+		c.Status = TestSkip
+		c.Message = "skipped"
+	case v.Final == "pass" && v.Verdict != VerdictTampered:
+		c.Status = TestPass
+		c.Message = string(v.Verdict)
+	default:
+		c.Status = TestFail
+		c.Message = fmt.Sprintf("%s (baseline=%s final=%s)", v.Verdict, firstNonEmpty(v.Baseline, "-"), firstNonEmpty(v.Final, "-"))
+	}
+	return c
+}
+
+// ---------------------------------------------------------------------------
+// JUnit XML
+// ---------------------------------------------------------------------------
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitProperties struct {
+	Property []junitProperty `xml:"property"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Body    string `xml:",chardata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+type junitTestCase struct {
+	XMLName    xml.Name         `xml:"testcase"`
+	Name       string           `xml:"name,attr"`
+	Classname  string           `xml:"classname,attr"`
+	Time       float64          `xml:"time,attr"`
+	Properties *junitProperties `xml:"properties,omitempty"`
+	Failure    *junitFailure    `xml:"failure,omitempty"`
+	Skipped    *junitSkipped    `xml:"skipped,omitempty"`
+	SystemOut  string           `xml:"system-out,omitempty"`
+}
+
+type junitTestSuite struct {
+	XMLName  xml.Name        `xml:"testsuite"`
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Time     float64         `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr,omitempty"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     float64          `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+// ciProperties returns the per-case metadata surfaced as JUnit <property>
+// elements and SARIF result properties. Only non-zero values are included.
+func (c *ciCase) ciProperties() []junitProperty {
+	var props []junitProperty
+	add := func(k, v string) {
+		if v != "" {
+			props = append(props, junitProperty{Name: k, Value: v})
+		}
+	}
+	add("section", originSection(c.Origin))
+	add("origin", c.Origin)
+	add("step_id", c.StepID)
+	add("verb", c.Verb)
+	if c.Attempts > 0 {
+		add("attempts", strconv.Itoa(c.Attempts))
+	}
+	if c.Elapsed > 0 {
+		add("elapsed", c.Elapsed.Round(time.Millisecond).String())
+	}
+	if c.TotalElapsed > 0 {
+		add("total_elapsed", c.TotalElapsed.Round(time.Millisecond).String())
+	}
+	if c.IterElapsed > 0 {
+		add("iteration_elapsed", c.IterElapsed.Round(time.Millisecond).String())
+	}
+	add("captured_value", c.Captured)
+	if c.Status == TestSkip {
+		add("skip_reason", c.skipReason())
+	}
+	return props
+}
+
+// writeJUnit renders cases as a <testsuites> document. Suites appear in
+// first-seen order so the report reads in plan order.
+func writeJUnit(w io.Writer, name string, cases []ciCase) error {
+	var order []string
+	bySuite := map[string]*junitTestSuite{}
+	for i := range cases {
+		c := &cases[i]
+		key := c.suiteName()
+		suite := bySuite[key]
+		if suite == nil {
+			suite = &junitTestSuite{Name: key}
+			bySuite[key] = suite
+			order = append(order, key)
+		}
+		elapsed := c.wallTime().Seconds()
+		tc := junitTestCase{
+			Name:      c.Name,
+			Classname: firstNonEmpty(c.Origin, originSection(c.Origin)),
+			Time:      elapsed,
+		}
+		if props := c.ciProperties(); len(props) > 0 {
+			tc.Properties = &junitProperties{Property: props}
+		}
+		switch c.Status {
+		case TestFail:
+			body := []string{"Verb: " + c.Verb, "Step ID: " + c.StepID}
+			if c.Attempts > 1 {
+				body = append(body, fmt.Sprintf("Attempts: %d over %s", c.Attempts, c.wallTime().Round(time.Millisecond)))
+			}
+			if c.Detail != "" {
+				body = append(body, c.Detail)
+			}
+			tc.Failure = &junitFailure{Message: c.Message, Type: c.Verb, Body: strings.Join(body, "\n")}
+			suite.Failures++
+		case TestSkip:
+			tc.Skipped = &junitSkipped{Message: c.skipReason()}
+			suite.Skipped++
+		default:
+			tc.SystemOut = c.Message
+		}
+		suite.Cases = append(suite.Cases, tc)
+		suite.Time += elapsed
+	}
+
+	doc := junitTestSuites{Name: name}
+	for _, o := range order {
+		s := bySuite[o]
+		s.Tests = len(s.Cases)
+		doc.Tests += s.Tests
+		doc.Failures += s.Failures
+		doc.Skipped += s.Skipped
+		doc.Time += s.Time
+		doc.Suites = append(doc.Suites, *s)
+	}
+
+	fmt.Fprintln(w, `<?xml version="1.0" encoding="UTF-8"?>`)
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	fmt.Fprintln(w)
+	return nil
+}
+
+// ---------------------------------------------------------------------------
+// SARIF 2.1.0
+// ---------------------------------------------------------------------------
+
+const sarifSchemaURI = "https://json.schemastore.org/sarif-2.1.0.json"
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	Name             string       `json:"name,omitempty"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri,omitempty"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+type sarifLocation struct {
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifResult struct {
+	RuleID     string          `json:"ruleId"`
+	RuleIndex  int             `json:"ruleIndex"`
+	Kind       string          `json:"kind"`
+	Level      string          `json:"level"`
+	Message    sarifMessage    `json:"message"`
+	Locations  []sarifLocation `json:"locations,omitempty"`
+	Properties map[string]any  `json:"properties,omitempty"`
+}
+
+type sarifInvocation struct {
+	ExecutionSuccessful bool `json:"executionSuccessful"`
+}
+
+type sarifRun struct {
+	Tool        sarifTool         `json:"tool"`
+	Invocations []sarifInvocation `json:"invocations"`
+	Results     []sarifResult     `json:"results"`
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+// writeSARIF renders cases as a single-run SARIF 2.1.0 log. Each verb is a
+// rule (`charly.check/<verb>`); every case becomes a result whose kind is
+// pass / fail / notApplicable (skip), so code-scanning views see the full
+// run and not only the failures. Checks have no source file position, so
+// results are anchored to a logical location — `<section>/<origin>` — instead.
+func writeSARIF(w io.Writer, cases []ciCase) error {
+	driver := sarifDriver{
+		Name:           "charly",
+		Version:        CharlyVersion(),
+		InformationURI: "https://github.com/overthinkos/overthink",
+		Rules:          []sarifRule{},
+	}
+	ruleIdx := map[string]int{}
+	results := make([]sarifResult, 0, len(cases))
+	fails := 0
+	for i := range cases {
+		c := &cases[i]
+		verb := firstNonEmpty(c.Verb, "step")
+		ruleID := "charly.check/" + verb
+		idx, ok := ruleIdx[ruleID]
+		if !ok {
+			idx = len(driver.Rules)
+			ruleIdx[ruleID] = idx
+			driver.Rules = append(driver.Rules, sarifRule{
+				ID:               ruleID,
+				Name:             verb,
+				ShortDescription: sarifMessage{Text: "charly check verb " + verb},
+			})
+		}
+		res := sarifResult{
+			RuleID:    ruleID,
+			RuleIndex: idx,
+			Message:   sarifMessage{Text: strings.TrimSpace(c.Name + ": " + c.Message)},
+			Locations: []sarifLocation{{LogicalLocations: []sarifLogicalLocation{{
+				Name:               firstNonEmpty(c.Origin, originSection(c.Origin)),
+				FullyQualifiedName: c.suiteName(),
+				Kind:               "module",
+			}}}},
+			Properties: map[string]any{},
+		}
+		switch c.Status {
+		case TestFail:
+			fails++
+			res.Kind, res.Level = "fail", "error"
+		case TestSkip:
+			res.Kind, res.Level = "notApplicable", "note"
+			res.Message.Text = strings.TrimSpace(c.Name + ": skipped: " + c.skipReason())
+		default:
+			res.Kind, res.Level = "pass", "none"
+		}
+		for _, p := range c.ciProperties() {
+			res.Properties[p.Name] = p.Value
+		}
+		if c.Attempts > 0 {
+			res.Properties["attempts"] = c.Attempts
+		}
+		if c.Detail != "" {
+			res.Properties["detail"] = c.Detail
+		}
+		results = append(results, res)
+	}
+	doc := sarifLog{
+		Schema:  sarifSchemaURI,
+		Version: "2.1.0",
+		Runs: []sarifRun{{
+			Tool:        sarifTool{Driver: driver},
+			Invocations: []sarifInvocation{{ExecutionSuccessful: fails == 0}},
+			Results:     results,
+		}},
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// ---------------------------------------------------------------------------
+// Public entry points
+// ---------------------------------------------------------------------------
+
+// FormatStepResultsSARIF emits a SARIF 2.1.0 log for code-scanning views.
+func FormatStepResultsSARIF(w io.Writer, results []StepResult) error {
+	return writeSARIF(w, ciCasesFromSteps(results))
+}
+
+// FormatResultsJUnit emits JUnit XML for bare Op results. Returns the number
+// of failures (same contract as FormatResultsText).
+func FormatResultsJUnit(w io.Writer, results []CheckResult) int {
+	_ = writeJUnit(w, "charly check", ciCasesFromChecks(results))
+	return countCheckFails(results)
+}
+
+// FormatResultsSARIF emits a SARIF 2.1.0 log for bare Op results. Returns the
+// number of failures.
+func FormatResultsSARIF(w io.Writer, results []CheckResult) int {
+	_ = writeSARIF(w, ciCasesFromChecks(results))
+	return countCheckFails(results)
+}
+
+// FormatFinalReportJUnit emits JUnit XML for an `iterate:` harness report —
+// one suite per iteration (and section/origin), plus the final verdicts.
+func FormatFinalReportJUnit(w io.Writer, r *FinalReport) error {
+	name := "charly check run"
+	if r != nil && r.Score != "" {
+		name += " " + r.Score
+	}
+	return writeJUnit(w, name, ciCasesFromFinalReport(r))
+}
+
+// FormatFinalReportSARIF emits a SARIF 2.1.0 log for an `iterate:` harness
+// report.
+func FormatFinalReportSARIF(w io.Writer, r *FinalReport) error {
+	return writeSARIF(w, ciCasesFromFinalReport(r))
+}
+
+func countCheckFails(results []CheckResult) int {
+	n := 0
+	for _, r := range results {
+		if r.Status == TestFail {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+func ciSampleSteps() []StepResult {
+	return []StepResult{
+		{Keyword: "check", Text: "redis answers", Origin: "candy:redis", StepID: "redis-ping",
+			Result: CheckResult{Verb: "command", Status: TestPass, Message: "PONG", Elapsed: 20 * time.Millisecond,
+				Attempts: 3, TotalElapsed: 2 * time.Second, CapturedValue: "PONG"}},
+		{Keyword: "check", Text: "port open", Origin: "candy:redis", StepID: "redis-port",
+			Result: CheckResult{Verb: "addr", Status: TestFail, Message: "connection refused", Elapsed: time.Second}},
+		{Keyword: "check", Text: "ui reachable", Origin: "deploy-default", StepID: "ui",
+			Result: CheckResult{Verb: "http", Status: TestSkip, Message: "needs runtime context"}},
+		{Keyword: "check", Text: "box banner", Origin: "box:demo", StepID: "banner",
+			Result: CheckResult{Verb: "file", Status: TestPass}},
+	}
+}
+
+// JUnit groups steps into suites by section + origin and carries attempts,
+// captured values and skip reasons as properties.
+func TestFormatStepResultsJUnit_GroupsBySectionAndOrigin(t *testing.T) {
+	var buf bytes.Buffer
+	if err := FormatStepResultsJUnit(&buf, ciSampleSteps()); err != nil {
+		t.Fatal(err)
+	}
+	var doc junitTestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("unmarshal: %v\n%s", err, buf.String())
+	}
+	if doc.Tests != 4 || doc.Failures != 1 || doc.Skipped != 1 {
+		t.Errorf("totals = %d/%d/%d, want 4/1/1", doc.Tests, doc.Failures, doc.Skipped)
+	}
+	var names []string
+	for _, s := range doc.Suites {
+		names = append(names, s.Name)
+	}
+	want := []string{"candy/candy:redis", "deploy/deploy-default", "box/box:demo"}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Errorf("suites = %v, want %v", names, want)
+	}
+	redis := doc.Suites[0]
+	if redis.Tests != 2 || redis.Failures != 1 {
+		t.Errorf("redis suite = %+v", redis)
+	}
+	if got := redis.Cases[0].Time; got != 2 {
+		t.Errorf("eventually case time = %v, want TotalElapsed (2s)", got)
+	}
+	out := buf.String()
+	for _, w := range []string{`name="attempts" value="3"`, `name="captured_value" value="PONG"`,
+		`name="skip_reason" value="needs runtime context"`, `<failure message="connection refused" type="addr">`} {
+		if !strings.Contains(out, w) {
+			t.Errorf("junit missing %q\n%s", w, out)
+		}
+	}
+}
+
+// SARIF emits one rule per verb and a pass/fail/notApplicable result per step.
+func TestFormatStepResultsSARIF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := FormatStepResultsSARIF(&buf, ciSampleSteps()); err != nil {
+		t.Fatal(err)
+	}
+	var doc sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if doc.Version != "2.1.0" || len(doc.Runs) != 1 {
+		t.Fatalf("bad envelope: %+v", doc)
+	}
+	run := doc.Runs[0]
+	if len(run.Tool.Driver.Rules) != 4 {
+		t.Errorf("rules = %d, want 4 (command/addr/http/file)", len(run.Tool.Driver.Rules))
+	}
+	if run.Invocations[0].ExecutionSuccessful {
+		t.Error("executionSuccessful should be false with a failing step")
+	}
+	kinds := map[string]string{}
+	for _, r := range run.Results {
+		kinds[r.RuleID] = r.Kind + "/" + r.Level
+		if r.Locations[0].LogicalLocations[0].FullyQualifiedName == "" {
+			t.Errorf("result %s has no logical location", r.RuleID)
+		}
+	}
+	if kinds["charly.check/addr"] != "fail/error" || kinds["charly.check/http"] != "notApplicable/note" || kinds["charly.check/file"] != "pass/none" {
+		t.Errorf("kinds = %v", kinds)
+	}
+}
+
+// Bare Op results (no plan step) still render and report their fail count.
+func TestFormatResultsJUnitAndSARIF_CheckResults(t *testing.T) {
+	results := []CheckResult{
+		{Op: &Op{Plugin: "file", Origin: "box:demo", PluginInput: map[string]any{"file": "/x"}}, Verb: "file", Status: TestPass},
+		{Op: cmdOpP("false"), Verb: "command", Status: TestFail, Message: "exit 1"},
+	}
+	var junit, sarif bytes.Buffer
+	if n := FormatResultsJUnit(&junit, results); n != 1 {
+		t.Errorf("junit fails = %d, want 1", n)
+	}
+	if n := FormatResultsSARIF(&sarif, results); n != 1 {
+		t.Errorf("sarif fails = %d, want 1", n)
+	}
+	if !strings.Contains(junit.String(), `<testsuite name="box/box:demo"`) || !strings.Contains(junit.String(), `<testsuite name="check"`) {
+		t.Errorf("junit suites wrong:\n%s", junit.String())
+	}
+}
+
+// The iterate harness report renders one suite per iteration plus the final
+// verdicts; a tampered-but-passing step is a failure, a dep-unmet step a skip.
+func TestFormatFinalReportJUnit(t *testing.T) {
+	r := &FinalReport{
+		Score: "demo",
+		Iterations: []IterationState{{K: 1, TestDuration: "2s", Step: []StepVerdict{
+			{ID: "a", Origin: "candy:x", Verdict: VerdictSolved, Baseline: "fail", Final: "pass"},
+			{ID: "b", Origin: "candy:x", Verdict: VerdictTampered, Baseline: "fail", Final: "pass"},
+		}}},
+		FinalStep: []StepVerdict{
+			{ID: "a", Origin: "candy:x", Verdict: VerdictSolved, Final: "pass"},
+			{ID: "c", Origin: "box:y", Verdict: VerdictSkipped, Final: "skipped", SkippedReason: "dep-unmet: a"},
+		},
+	}
+	var buf bytes.Buffer
+	if err := FormatFinalReportJUnit(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	var doc junitTestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Name != "charly check run demo" || doc.Tests != 4 || doc.Failures != 1 || doc.Skipped != 1 {
+		t.Errorf("doc = %s %d/%d/%d", doc.Name, doc.Tests, doc.Failures, doc.Skipped)
+	}
+	// Steps are not timed individually: no invented per-step share of the
+	// iteration, whose measured duration is a property instead.
+	if doc.Suites[0].Name != "iter1/candy/candy:x" || doc.Suites[0].Time != 0 || doc.Suites[0].Cases[0].Time != 0 {
+		t.Errorf("iteration suite = %+v", doc.Suites[0])
+	}
+	if !strings.Contains(buf.String(), `<property name="iteration_elapsed" value="2s">`) {
+		t.Errorf("iteration duration missing:\n%s", buf.String())
+	}
+	if !strings.Contains(buf.String(), `<skipped message="dep-unmet: a">`) {
+		t.Errorf("skip reason missing:\n%s", buf.String())
+	}
+}
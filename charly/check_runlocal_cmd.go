@@ -70,7 +70,7 @@ type CheckRunLocalCmd struct {
 	Tag         string `name:"tag" help:"tag expression to narrow plan steps"`
 	DryRun      bool   `name:"dry-run" help:"Render scope+prompt then exit; no AI invocation, no rebuild"`
 	SkipRebuild bool   `name:"skip-rebuild" help:"Skip per-iteration rebuild (source-only steps)"`
-	Format      string `enum:"text,yaml" default:"text" help:"Report format on stdout"`
+	Format      string `enum:"text,yaml,junit,sarif" default:"text" help:"Report format on stdout"`
 	NoLock      bool   `name:"no-lock" hidden:"" help:"Skip flock (tests only)"`
 	KeepRepo    bool   `name:"keep-repo" help:"Don't delete the per-run repo clone after the run completes (debugging only — clones are ~100MB)"`
 	ProjectDir  string `name:"project-dir" hidden:"" help:"Override project root (default: cwd or /workspace)"`
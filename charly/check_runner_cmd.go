@@ -15,6 +15,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"strconv"
+	"time"
 )
 
 // The HarnessCmd top-level type was deleted in the check-cutover. Its
@@ -76,7 +77,7 @@ type CheckRunCmd struct {
 	DryRun           bool   `name:"dry-run" help:"Render scope+prompt without rebuild"`
 	SkipRebuild      bool   `name:"skip-rebuild" help:"Source-only steps"`
 	KeepRepo         bool   `name:"keep-repo" help:"Don't delete the per-run repo clone after the run (~100MB; debugging only)"`
-	Format           string `name:"format" enum:"text,yaml" default:"text" help:"Output format"`
+	Format           string `name:"format" enum:"text,yaml,junit,sarif" default:"text" help:"Output format"`
 }
 
 func (c *CheckRunCmd) Run() error {
@@ -122,11 +123,13 @@ func (c *CheckRunCmd) Run() error {
 		if exeErr != nil {
 			exe = os.Args[0]
 		}
+		start := time.Now()
 		res, runErr := runCheckBed(exe, c.Name, bedNode, bedRunOpts{Keep: c.Keep, NoRebuild: c.NoRebuild, CheckLevel: bedCheckLevel(uf, bedNode)})
 		if res != nil {
 			fmt.Fprintf(os.Stderr, "charly check run %s: %s (steps=%d)\n",
 				c.Name, summaryStatus(res.OK), len(res.Step))
 		}
+		recordOutcome(checkRunEvent(c.Name, res, time.Since(start)), runErr)
 		// Propagate the check-fail exit code (2) when the bed failed at a check
 		// step, so `charly check run <bed>` distinguishes "the thing under test
 		// is broken" from an infra failure (build/deploy/vm-create) at exit 1.
@@ -139,6 +142,24 @@ func (c *CheckRunCmd) Run() error {
 	return c.runIterateEntity(uf, node, hasNode, cwd)
 }
 
+// checkRunEvent is the journal event of one bed run: its CalVer, duration and
+// the first failed step.
+func checkRunEvent(bed string, res *bedRunResult, took time.Duration) Event {
+	ev := Event{Type: EventCheckRun, Deploy: bed,
+		Detail: map[string]string{"duration": took.Truncate(time.Second).String()}}
+	if res == nil {
+		return ev
+	}
+	ev.Detail["calver"] = res.CalVer
+	for _, st := range res.Step {
+		if !st.OK {
+			ev.Detail["failed_step"] = st.Name
+			break
+		}
+	}
+	return ev
+}
+
 // runIterateEntity drives the iterate: AI iteration loop for the named entity:
 // it resolves the sandbox target, generates a run ID, builds the run-local
 // argv, performs the disposable-pod preflight, and dispatches to the host/pod/
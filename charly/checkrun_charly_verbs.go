@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
 )
@@ -48,6 +49,76 @@ func (r *Runner) resolveCheckApk(apk, origin string) (string, error) {
 	return resolveApkPath(apk, dir)
 }
 
+// CheckRunDirEnv carries the active `charly check run` directory
+// (.check/<bed>/<calver>, absolute) to the nested check subprocesses. Set by
+// runCheckBed; read by resolveCheckImagePaths to place diff images.
+const CheckRunDirEnv = "CHARLY_CHECK_RUN_DIR"
+
+// resolveCheckImagePaths anchors a display step's image paths HOST-side, before the
+// Op is marshalled to its plugin (which sees neither CandyDirs nor the run
+// directory): the artifact_baseline golden image and the find-image/click-image
+// template. Both are committed fixtures, exactly like a committed APK, so a
+// relative path authored in a candy resolves against that candy's source tree and
+// one authored in a box or deploy against the project directory. An unset
+// artifact_diff defaults into the check run directory (CheckRunDirEnv) when one is
+// active; a relative one is placed there too. Returns c unchanged when the step
+// declares neither path.
+func (r *Runner) resolveCheckImagePaths(c *Op) (*Op, error) {
+	if c.ArtifactBaseline == "" && c.Template == "" {
+		return c, nil
+	}
+	cc := *c
+	var err error
+	if cc.Template, err = r.anchorCheckFixture("template", c.Template, c.Origin); err != nil {
+		return nil, err
+	}
+	if c.ArtifactBaseline == "" {
+		return &cc, nil
+	}
+	if cc.ArtifactBaseline, err = r.anchorCheckFixture("artifact_baseline", c.ArtifactBaseline, c.Origin); err != nil {
+		return nil, err
+	}
+	if runDir := os.Getenv(CheckRunDirEnv); runDir != "" {
+		switch {
+		case cc.ArtifactDiff == "":
+			name := cc.ID
+			if name == "" {
+				name = strings.TrimSuffix(filepath.Base(cc.Artifact), filepath.Ext(cc.Artifact))
+			}
+			cc.ArtifactDiff = filepath.Join(runDir, name+".diff.png")
+		case !filepath.IsAbs(cc.ArtifactDiff):
+			cc.ArtifactDiff = filepath.Join(runDir, cc.ArtifactDiff)
+		}
+	}
+	return &cc, nil
+}
+
+// anchorCheckFixture resolves one relative fixture path (field names it in errors)
+// against the authoring candy's source tree, or the project directory for a
+// non-candy origin. Unlike a committed APK the file need not exist yet — a missing
+// artifact_baseline is recorded on first run.
+func (r *Runner) anchorCheckFixture(field, path, origin string) (string, error) {
+	if path == "" || filepath.IsAbs(path) {
+		return path, nil
+	}
+	key, ok := strings.CutPrefix(origin, "candy:")
+	if !ok {
+		wd, err := os.Getwd()
+		if err != nil {
+			return "", fmt.Errorf("%s %q: %w", field, path, err)
+		}
+		return filepath.Join(wd, path), nil
+	}
+	dir := r.CandyDirs[key]
+	if dir == "" {
+		if r.CandyScanErr != nil {
+			return "", fmt.Errorf("%s %q (candy %q): candy source-dir scan failed: %w", field, path, key, r.CandyScanErr)
+		}
+		return "", fmt.Errorf("%s %q: candy %q is absent from the source scan (%d candies scanned) — cannot anchor the fixture", field, path, key, len(r.CandyDirs))
+	}
+	return filepath.Join(dir, path), nil
+}
+
 // noVmDisplayDeviceErr is the substring the VM-target resolver (charly/vm_target.go)
 // emits when a VM declares no graphics device of the requested kind ("VM <name> has
 // no SPICE/VNC graphics device declared in vm.yml") — the signal for a legitimate N/A
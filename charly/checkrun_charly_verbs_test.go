@@ -2,6 +2,9 @@ package main
 
 import (
 	"context"
+	"errors"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -208,3 +211,48 @@ func TestShortNameMatchesRef(t *testing.T) {
 // with the rest of the kube verb. The `json: true` step modifier is now read
 // directly off the Op by candy/plugin-kube's runRaw (op.JSON → the full JSON List
 // document vs the `<namespace>/<name>` line form).
+
+// TestResolveCheckImagePaths covers the host-side anchoring of a display step's
+// golden image and find-image template: candy-authored paths resolve against the
+// candy's source dir (failing hard when it cannot), box/deploy ones against the
+// project dir, and the diff image defaults into the active check run directory.
+func TestResolveCheckImagePaths(t *testing.T) {
+	candyDir := t.TempDir()
+	r := &Runner{CandyDirs: map[string]string{"desk": candyDir}}
+	t.Setenv(CheckRunDirEnv, "")
+
+	got, err := r.resolveCheckImagePaths(&Op{Origin: "candy:desk", ArtifactBaseline: "tests/golden/home.png", Artifact: "/tmp/home.png"})
+	if err != nil || got.ArtifactBaseline != filepath.Join(candyDir, "tests/golden/home.png") {
+		t.Errorf("candy baseline = (%q,%v)", got.ArtifactBaseline, err)
+	}
+	if got.ArtifactDiff != "" {
+		t.Errorf("no run dir: artifact_diff = %q, want unset (SDK default next to artifact)", got.ArtifactDiff)
+	}
+
+	wd, _ := os.Getwd()
+	got, err = r.resolveCheckImagePaths(&Op{Origin: "box:demo", ArtifactBaseline: "golden/a.png"})
+	if err != nil || got.ArtifactBaseline != filepath.Join(wd, "golden/a.png") {
+		t.Errorf("box baseline = (%q,%v), want project-relative", got.ArtifactBaseline, err)
+	}
+
+	got, err = r.resolveCheckImagePaths(&Op{Origin: "candy:desk", Template: "tests/ok-button.png"})
+	if err != nil || got.Template != filepath.Join(candyDir, "tests/ok-button.png") || got.ArtifactDiff != "" {
+		t.Errorf("candy template = (%+v,%v)", got, err)
+	}
+
+	r2 := &Runner{CandyScanErr: errors.New("boom")}
+	if _, err := r2.resolveCheckImagePaths(&Op{Origin: "candy:desk", ArtifactBaseline: "g.png"}); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("scan-error path = %v, want error mentioning the scan failure", err)
+	}
+
+	runDir := t.TempDir()
+	t.Setenv(CheckRunDirEnv, runDir)
+	got, _ = r.resolveCheckImagePaths(&Op{Origin: "candy:desk", ID: "home-shot", ArtifactBaseline: "/abs/g.png", Artifact: "/tmp/home.png"})
+	if got.ArtifactBaseline != "/abs/g.png" || got.ArtifactDiff != filepath.Join(runDir, "home-shot.diff.png") {
+		t.Errorf("run-dir default = (%q,%q)", got.ArtifactBaseline, got.ArtifactDiff)
+	}
+	got, _ = r.resolveCheckImagePaths(&Op{Origin: "candy:desk", ArtifactBaseline: "/abs/g.png", Artifact: "/tmp/home.png", ArtifactDiff: "d/x.png"})
+	if got.ArtifactDiff != filepath.Join(runDir, "d/x.png") {
+		t.Errorf("relative artifact_diff = %q, want under run dir", got.ArtifactDiff)
+	}
+}
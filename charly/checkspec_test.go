@@ -451,6 +451,10 @@ func TestApplyCaptureExtract(t *testing.T) {
 		{"no match", "no number here", `[0-9]+`, "", true},
 		{"invalid regex", "anything", `(unclosed`, "", true},
 		{"empty pattern passes through", "raw value", "", "raw value", false},
+		{"json path", `{"items":[{"metadata":{"name":"web-0"}}]}`, "json:.items[0].metadata.name", "web-0", false},
+		{"json path numeric", `{"count": 3}`, "json: $.count", "3", false},
+		{"json path no match", `{"items":[]}`, "json:.items[0].metadata.name", "", true},
+		{"json path on non-json", "plain text", "json:.a", "", true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -12,7 +12,7 @@ type CmdCmd struct {
 	Box      string `arg:"" help:"Box name"`
 	Command  string `arg:"" help:"Command to execute"`
 	Instance string `short:"i" long:"instance" help:"Instance name"`
-	Notify   bool   `long:"notify" negatable:"" default:"true" help:"Send desktop notification on completion (--no-notify to disable)"`
+	Notify   bool   `long:"notify" negatable:"" default:"true" help:"Send a desktop notification and record a cmd.run event (charly events) on completion (--no-notify to disable)"`
 	Sidecar  string `long:"sidecar" help:"Run in the named SIDECAR container (charly-<box>[-<instance>]-<sidecar>) instead of the app container"`
 }
 
@@ -66,6 +66,8 @@ func (c *CmdCmd) Run() error {
 		sendVenueNotification(ContainerChain(engine, name),
 			fmt.Sprintf("charly: command %s", status),
 			fmt.Sprintf("%s (%s)", c.Command, elapsed))
+		recordOutcome(Event{Type: EventCmdRun, Deploy: deployKey(c.Box, c.Instance),
+			Detail: map[string]string{"command": c.Command, "duration": elapsed.String()}}, runErr)
 	}
 
 	return runErr
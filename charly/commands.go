@@ -5,8 +5,10 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
+	"time"
 )
 
 // StatusCmd is defined in status.go
@@ -17,6 +19,11 @@ type LogsCmd struct {
 	Follow   bool   `short:"f" long:"follow" help:"Follow log output"`
 	Instance string `short:"i" long:"instance" help:"Instance name for running multiple containers of the same box"`
 	Sidecar  string `long:"sidecar" help:"Show the named SIDECAR container's logs instead of the app container's"`
+	Since    string `long:"since" help:"Show entries from this time on (duration ago like 15m, RFC3339, or YYYY-MM-DD[ HH:MM:SS])"`
+	Until    string `long:"until" help:"Show entries up to this time (same forms as --since)"`
+	Grep     string `long:"grep" help:"Only show entries whose message matches this regexp"`
+	Format   string `long:"format" enum:"text,json" default:"text" help:"Output format: text, or json (one object per line with a parsed timestamp)"`
+	Merge    bool   `long:"merge" help:"Merge the app container, every sidecar and every pod peer of a group into one time-ordered stream"`
 }
 
 func (c *LogsCmd) Run() error {
@@ -27,16 +34,38 @@ func (c *LogsCmd) Run() error {
 	}
 
 	boxName := resolveBoxName(c.Box)
+	if c.Merge && c.Sidecar != "" {
+		return fmt.Errorf("--merge already includes every sidecar; drop --sidecar")
+	}
+	now := time.Now()
+	q := logQuery{Follow: c.Follow}
+	if q.Since, err = parseLogTime(c.Since, now); err != nil {
+		return fmt.Errorf("--since: %w", err)
+	}
+	if q.Until, err = parseLogTime(c.Until, now); err != nil {
+		return fmt.Errorf("--until: %w", err)
+	}
+	if c.Grep != "" {
+		if q.Grep, err = regexp.Compile(c.Grep); err != nil {
+			return fmt.Errorf("--grep: %w", err)
+		}
+	}
+	quadlet := rt.RunMode == "quadlet"
+	// Parse the output only when something needs it; otherwise hand the terminal to
+	// journalctl / the engine as before. journalctl filters by pattern itself.
+	if c.Merge || c.Format == "json" || (c.Grep != "" && !quadlet) {
+		engineFor := func(box, instance string) string {
+			return EngineBinary(ResolveBoxEngineForDeploy(box, instance, rt.RunEngine))
+		}
+		return runLogQuery(os.Stdout, resolveLogSources(boxName, c.Instance, c.Sidecar, c.Merge, quadlet, engineFor), q, c.Format)
+	}
 
-	if rt.RunMode == "quadlet" {
+	if quadlet {
 		svc := serviceNameInstance(boxName, c.Instance)
 		if c.Sidecar != "" {
 			svc = SidecarContainerNameInstance(boxName, c.Instance, c.Sidecar) + ".service"
 		}
-		args := []string{"--user", "-u", svc}
-		if c.Follow {
-			args = append(args, "-f")
-		}
+		args := append([]string{"--user", "-u", svc}, q.journalArgs()...)
 		cmd := exec.Command("journalctl", args...)
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
@@ -53,10 +82,7 @@ func (c *LogsCmd) Run() error {
 	if c.Sidecar != "" {
 		name = SidecarContainerNameInstance(boxName, c.Instance, c.Sidecar)
 	}
-	args := []string{"logs"}
-	if c.Follow {
-		args = append(args, "-f")
-	}
+	args := append([]string{"logs"}, q.engineArgs()...)
 	args = append(args, name)
 	cmd := exec.Command(engine, args...)
 	cmd.Stdout = os.Stdout
@@ -82,6 +108,9 @@ func (c *LogsCmd) Run() error {
 // env, tunnel) is preserved across updates. Per the user's directive:
 // "Any config changes should be done via charly config only" — this verb
 // updates ARTIFACTS, charly config updates CONFIG.
+//
+// --verify / --rollback / --undo gate and revert the update; see
+// update_rollback.go.
 type UpdateCmd struct {
 	Box       string `arg:"" help:"Deploy name (resolved via charly.yml) OR box name. For deploys, the target's update strategy is auto-selected (pod=systemctl restart with new image; vm=in-guest candy re-apply; local=idempotent re-apply)."`
 	Tag       string `long:"tag" help:"Image CalVer tag (empty = newest local CalVer resolved via the ai.opencharly.version OCI label)"`
@@ -90,6 +119,9 @@ type UpdateCmd struct {
 	Seed      bool   `long:"seed" default:"true" negatable:"" help:"Sync data from new image into bind-backed volumes (default: true)"`
 	ForceSeed bool   `long:"force-seed" help:"Overwrite existing data in volumes (default: only add new files)"`
 	DataFrom  string `long:"data-from" help:"Sync data from this data image instead"`
+	Verify    bool   `long:"verify" help:"Run the deployment's runtime checks (charly check live) after the update; fail when any check fails"`
+	Rollback  bool   `long:"rollback" help:"Pod deploys: snapshot volumes first and restore the previous image, units and volumes when the update or a post-update check fails (implies --verify)"`
+	Undo      bool   `long:"undo" help:"Pod deploys: restore the state recorded before the last update instead of updating"`
 }
 
 // Run dispatches `charly update <name>` to the target-specific update
@@ -105,7 +137,13 @@ func (c *UpdateCmd) Run() error {
 		return fmt.Errorf("remote refs are not accepted here; run 'charly box pull %s' first", c.Box)
 	}
 	c.Box, c.Instance = canonicalizeDeployArg(c.Box, c.Instance)
-	return c.dispatchByDeployTarget()
+	err := c.dispatchByDeployTarget()
+	ev := Event{Type: EventDeployUpdate, Deploy: deployKey(c.Box, c.Instance)}
+	if c.Undo {
+		ev.Detail = map[string]string{"action": "undo"}
+	}
+	recordOutcome(ev, err)
+	return err
 }
 
 // RemoveCmd removes a service container
@@ -117,8 +155,11 @@ type RemoveCmd struct {
 	Env        []string `short:"e" long:"env" sep:"none" help:"Set env var for hooks (KEY=VALUE)"`
 }
 
-func (c *RemoveCmd) Run() error {
+func (c *RemoveCmd) Run() (err error) {
 	c.Box, c.Instance = canonicalizeDeployArg(c.Box, c.Instance)
+	defer func() {
+		recordOutcome(Event{Type: EventDeployRemove, Deploy: deployKey(c.Box, c.Instance), Kind: SubstratePod}, err)
+	}()
 	// Releasing a persistent exclusive claim restores any holder this deploy
 	// preempted (no-op if no lease / gated by an outer orchestrator).
 	defer releaseResourceClaim(deployKey(c.Box, c.Instance))
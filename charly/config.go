@@ -5,6 +5,7 @@ import (
 	"maps"
 	"slices"
 	"sort"
+	"strings"
 )
 
 // Config represents the charly.yml configuration projection
@@ -82,13 +83,20 @@ type ResolvedBox struct {
 	From                  string
 	BootstrapBuilderImage string
 	Platforms             []string
-	Tag                   string
-	Registry              string
-	Pkg                   string   // primary build format (first entry in BuildFormats) — for cache mounts, bootstrap
-	Distro                []string // resolved distro tags: ["fedora:43", "fedora"]
-	BuildFormats          []string // resolved build formats: ["rpm"] or ["pac", "aur"] — all installed in order
-	Tags                  []string // union: ["all"] + Distro + BuildFormats — for task matching
-	Candy                 []string
+	// ExplicitPlatforms is true when Platforms came from the box or defaults
+	// `platform:` (or --platform) rather than the built-in default; only then
+	// does validation insist every candy supports every platform.
+	ExplicitPlatforms bool
+	// Arch is the architecture the box's primary Containerfile targets — the
+	// first platform's. Selects `distro: <tag>@<arch>` candy blocks.
+	Arch         string
+	Tag          string
+	Registry     string
+	Pkg          string   // primary build format (first entry in BuildFormats) — for cache mounts, bootstrap
+	Distro       []string // resolved distro tags: ["fedora:43", "fedora"]
+	BuildFormats []string // resolved build formats: ["rpm"] or ["pac", "aur"] — all installed in order
+	Tags         []string // union: ["all"] + Distro + BuildFormats — for task matching
+	Candy        []string
 
 	// User configuration
 	User string // username
@@ -211,6 +219,9 @@ type ResolveOpts struct {
 	// SAME pipeline (per-entity-version arbitration + SourceDir population); a local
 	// add_candy ref is already covered by ScanCandy and is a no-op here.
 	ExtraCandyRefs []string
+	// Platform, when set, overrides every box's platforms
+	// (`charly box generate|validate|build --platform linux/arm64`; comma-separated).
+	Platform string
 }
 
 // shouldIncludeDisabled reports whether name's disabled gate should be
@@ -266,7 +277,7 @@ func (c *Config) ResolveBox(name string, calverTag string, dir string, opts Reso
 		return nil, err
 	}
 
-	c.resolvePlatforms(resolved, img)
+	c.resolvePlatforms(resolved, img, opts.Platform)
 
 	c.resolveTag(resolved, img, calverTag)
 
@@ -381,6 +392,7 @@ func (c *Config) ResolveBox(name string, calverTag string, dir string, opts Reso
 		resolved.Distro = distroCfg.expandPackageInheritance(resolved.Distro)
 		resolved.DistroDef = distroCfg.ResolveDistro(resolved.Distro)
 	}
+	narrowPlatforms(resolved)
 
 	// Reconcile user_policy against the distro's base_user declaration.
 	// Must run after DistroDef is resolved. Updates resolved.User/UID/GID/
@@ -466,14 +478,39 @@ func (c *Config) resolveBase(resolved *ResolvedBox, img BoxConfig, name string)
 }
 
 // resolvePlatforms resolves a box's target platforms
-// (image -> defaults -> linux/amd64+arm64). Split out of ResolveBox.
-func (c *Config) resolvePlatforms(resolved *ResolvedBox, img BoxConfig) {
-	resolved.Platforms = img.Platforms
-	if len(resolved.Platforms) == 0 {
+// (--platform -> image -> defaults -> linux/amd64+arm64). Split out of ResolveBox.
+func (c *Config) resolvePlatforms(resolved *ResolvedBox, img BoxConfig, override string) {
+	resolved.ExplicitPlatforms = true
+	switch {
+	case override != "":
+		resolved.Platforms = strings.Split(override, ",")
+	case len(img.Platforms) > 0:
+		resolved.Platforms = img.Platforms
+	case len(c.Defaults.Platforms) > 0:
 		resolved.Platforms = c.Defaults.Platforms
-	}
-	if len(resolved.Platforms) == 0 {
+	default:
 		resolved.Platforms = []string{"linux/amd64", "linux/arm64"}
+		resolved.ExplicitPlatforms = false
+	}
+	resolved.Arch = platformArch(resolved.Platforms[0])
+}
+
+// narrowPlatforms drops the built-in default platforms the box's distro does
+// not publish packages for (the distro `package_arch:` list). Explicit platforms are
+// left alone for validation to reject.
+func narrowPlatforms(resolved *ResolvedBox) {
+	if resolved.ExplicitPlatforms || resolved.DistroDef == nil || len(resolved.DistroDef.PackageArch) == 0 {
+		return
+	}
+	var kept []string
+	for _, p := range resolved.Platforms {
+		if archListed(resolved.DistroDef.PackageArch, platformArch(p)) {
+			kept = append(kept, p)
+		}
+	}
+	if len(kept) > 0 {
+		resolved.Platforms = kept
+		resolved.Arch = platformArch(kept[0])
 	}
 }
 
@@ -175,13 +175,19 @@ func (c *BoxConfigSetupCmd) resolveDeployRef() (deployBoxName, imageRef string)
 // prepareQuadletEnv resolves the EnvironmentFile= path for the quadlet:
 // CLI --env-file > charly.yml env_file > workspace .env. Split out of runConfig.
 func (c *BoxConfigSetupCmd) prepareQuadletEnv(dc *BundleConfig, bindMounts []ResolvedBindMount) string {
+	return resolveQuadletEnvFile(dc, deployKey(c.Box, c.Instance), c.EnvFile, bindMounts)
+}
+
+// resolveQuadletEnvFile is prepareQuadletEnv for an arbitrary deploy key;
+// renderDeployQuadlets calls it with no CLI --env-file.
+func resolveQuadletEnvFile(dc *BundleConfig, key, cliEnvFile string, bindMounts []ResolvedBindMount) string {
 	var quadletEnvFile string
-	if c.EnvFile != "" {
-		quadletEnvFile, _ = filepath.Abs(c.EnvFile)
+	if cliEnvFile != "" {
+		quadletEnvFile, _ = filepath.Abs(cliEnvFile)
 	}
 	// Check charly.yml env_file
 	if quadletEnvFile == "" && dc != nil {
-		if overlay, ok := dc.Bundle[deployKey(c.Box, c.Instance)]; ok && overlay.EnvFile != "" {
+		if overlay, ok := dc.Bundle[key]; ok && overlay.EnvFile != "" {
 			quadletEnvFile = expandHostHome(overlay.EnvFile)
 		}
 	}
@@ -197,20 +203,77 @@ func (c *BoxConfigSetupCmd) prepareQuadletEnv(dc *BundleConfig, bindMounts []Res
 	return quadletEnvFile
 }
 
+// resolveDeployEnv resolves the env of one deployment from global provides +
+// labels/charly.yml + workspace .env + CLI --env-file / -e, NO_PROXY-enriched.
+// Returns the global env too: it stays inline when the quadlet uses
+// EnvironmentFile= (see quadletInlineEnv). Pass the deploy key (box with
+// instance), not the bare box, so an instance consumer like
+// `versa/ecovoyage` doesn't pick up the base `versa` deploy's provides.
+func resolveDeployEnv(dc *BundleConfig, meta *BoxMetadata, box, instance string, bindMounts []ResolvedBindMount, cliEnvFile string, cliEnv []string) (globalEnv, envVars []string, err error) {
+	ctrName := containerNameInstance(box, instance)
+	acceptedEnv := AcceptedEnvSet(meta.EnvAccept, meta.EnvRequire)
+	globalEnv = dc.GlobalEnvForImage(deployKey(box, instance), ctrName, acceptedEnv)
+	envVars, err = ResolveEnvVars(globalEnv, meta.Env, "", workspaceBindHost(bindMounts), cliEnvFile, cliEnv)
+	if err != nil {
+		return nil, nil, err
+	}
+	return globalEnv, enrichNoProxy(envVars, dc.DeployedContainerNames()), nil
+}
+
+// quadletInlineEnv is the inline Environment= list of a quadlet that reads its
+// file-sourced vars through EnvironmentFile=: global provides (resolved at
+// config time, not in the env file), CLI -e flags and auto-detected env.
+func quadletInlineEnv(globalEnv, cliEnv []string, detected DetectedDevices) []string {
+	env := append([]string{}, globalEnv...)
+	env = append(env, cliEnv...)
+	return appendAutoDetectedEnv(env, detected)
+}
+
 // resolveSidecars resolves sidecars (embedded templates + charly.yml +
 // --sidecar flags), routes CLI -e flags to the matching sidecar (mutating
 // c.Env to the app-only set), and provisions sidecar secrets (appending any
 // fallback env to envVars). Returns the deploy sidecar defs, the resolved
 // sidecars, and the (possibly extended) env var list. Split out of runConfig.
 func (c *BoxConfigSetupCmd) resolveSidecars(dc *BundleConfig, rt *ResolvedRuntime, autoGen bool, envVars []string) (map[string]SidecarDef, []ResolvedSidecar, []string, error) {
+	deploySidecars, resolvedSidecars, appEnv, err := resolveDeploySidecars(dc, c.Box, c.Instance, c.Sidecar, c.Env)
+	if err != nil {
+		return nil, nil, envVars, err
+	}
+	// Replace c.Env with app-only env vars (sidecar vars saved to charly.yml)
+	c.Env = appEnv
+
+	// Provision sidecar secrets as podman secrets
+	for i, sc := range resolvedSidecars {
+		if len(sc.Secret) > 0 {
+			scSecrets, _ := ApplySecretRefresh(sc.Secret, c.RefreshSecret)
+			scProvisioned, scFallback, scErr := ProvisionPodmanSecrets(rt.RunEngine, c.Box, c.Instance, scSecrets, autoGen)
+			if scErr != nil {
+				fmt.Fprintf(os.Stderr, "Warning: could not provision sidecar %s secrets: %v\n", sc.Name, scErr)
+			}
+			resolvedSidecars[i].Secret = scProvisioned
+			for _, kv := range scFallback {
+				envVars = appendEnvUnique(envVars, kv)
+			}
+		}
+	}
+
+	return deploySidecars, resolvedSidecars, envVars, nil
+}
+
+// resolveDeploySidecars is the provisioning-free half of resolveSidecars:
+// it merges --sidecar names into the charly.yml sidecars of box/instance,
+// routes sidecar-related CLI -e flags to their sidecar and resolves the
+// result against the embedded templates. Returns the deploy sidecar defs,
+// the resolved sidecars and the app-only CLI env.
+func resolveDeploySidecars(dc *BundleConfig, box, instance string, sidecarFlags, cliEnv []string) (map[string]SidecarDef, []ResolvedSidecar, []string, error) {
 	var deploySidecars map[string]SidecarDef
 	if dc != nil {
-		if overlay, ok := dc.Bundle[deployKey(c.Box, c.Instance)]; ok {
+		if overlay, ok := dc.Bundle[deployKey(box, instance)]; ok {
 			deploySidecars = overlay.Sidecar
 		}
 	}
 	// Merge --sidecar flags into deploy sidecars
-	for _, scName := range c.Sidecar {
+	for _, scName := range sidecarFlags {
 		if deploySidecars == nil {
 			deploySidecars = make(map[string]SidecarDef)
 		}
@@ -218,81 +281,60 @@ func (c *BoxConfigSetupCmd) resolveSidecars(dc *BundleConfig, rt *ResolvedRuntim
 			deploySidecars[scName] = SidecarDef{} // empty override, inherits from template
 		}
 	}
+	if len(deploySidecars) == 0 {
+		return deploySidecars, nil, cliEnv, nil
+	}
 
-	var resolvedSidecars []ResolvedSidecar
-	var mergedSidecarDefs map[string]SidecarDef
-	if len(deploySidecars) > 0 {
-		// Route CLI -e flags: sidecar-related env vars go to the sidecar, not the app
-		sidecarEnvKeys := SidecarEnvKey(deploySidecars)
-		var appEnv, sidecarEnvOverrides []string
-		for _, e := range c.Env {
-			key := e
-			if before, _, ok := strings.Cut(e, "="); ok {
-				key = before
-			}
-			if scName, ok := sidecarEnvKeys[key]; ok {
-				// Route to sidecar
-				if deploySidecars[scName].Env == nil {
-					def := deploySidecars[scName]
-					def.Env = make(map[string]string)
-					deploySidecars[scName] = def
-				}
+	// Route CLI -e flags: sidecar-related env vars go to the sidecar, not the app
+	sidecarEnvKeys := SidecarEnvKey(deploySidecars)
+	var appEnv, sidecarEnvOverrides []string
+	for _, e := range cliEnv {
+		key := e
+		if before, _, ok := strings.Cut(e, "="); ok {
+			key = before
+		}
+		if scName, ok := sidecarEnvKeys[key]; ok {
+			// Route to sidecar
+			if deploySidecars[scName].Env == nil {
 				def := deploySidecars[scName]
-				if _, after, ok := strings.Cut(e, "="); ok {
-					def.Env[key] = after
-				}
+				def.Env = make(map[string]string)
 				deploySidecars[scName] = def
-				sidecarEnvOverrides = append(sidecarEnvOverrides, e)
-			} else {
-				appEnv = append(appEnv, e)
 			}
-		}
-		// Replace c.Env with app-only env vars (sidecar vars saved to charly.yml)
-		c.Env = appEnv
-
-		// Resolve: embedded templates + project root sidecar: + per-deploy overrides
-		var resolveErr error
-		mergedSidecarDefs, resolveErr = ResolveSidecarsForConfig(sidecarTemplatesOf(dc), deploySidecars)
-		if resolveErr != nil {
-			return nil, nil, envVars, fmt.Errorf("resolving sidecars: %w", resolveErr)
-		}
-		if len(mergedSidecarDefs) > 0 {
-			var rsErr error
-			resolvedSidecars, rsErr = ResolveSidecar(mergedSidecarDefs, c.Box, c.Instance)
-			if rsErr != nil {
-				return nil, nil, envVars, fmt.Errorf("resolving sidecars: %w", rsErr)
+			def := deploySidecars[scName]
+			if _, after, ok := strings.Cut(e, "="); ok {
+				def.Env[key] = after
 			}
+			deploySidecars[scName] = def
+			sidecarEnvOverrides = append(sidecarEnvOverrides, e)
+		} else {
+			appEnv = append(appEnv, e)
 		}
+	}
 
-		// Log routed env vars
-		if len(sidecarEnvOverrides) > 0 {
-			for _, e := range sidecarEnvOverrides {
-				key := e
-				if before, _, ok := strings.Cut(e, "="); ok {
-					key = before
-				}
-				scName := sidecarEnvKeys[key]
-				fmt.Fprintf(os.Stderr, "Routed %s to sidecar %s\n", key, scName)
-			}
+	// Resolve: embedded templates + project root sidecar: + per-deploy overrides
+	var resolvedSidecars []ResolvedSidecar
+	mergedSidecarDefs, resolveErr := ResolveSidecarsForConfig(sidecarTemplatesOf(dc), deploySidecars)
+	if resolveErr != nil {
+		return nil, nil, cliEnv, fmt.Errorf("resolving sidecars: %w", resolveErr)
+	}
+	if len(mergedSidecarDefs) > 0 {
+		var rsErr error
+		resolvedSidecars, rsErr = ResolveSidecar(mergedSidecarDefs, box, instance)
+		if rsErr != nil {
+			return nil, nil, cliEnv, fmt.Errorf("resolving sidecars: %w", rsErr)
 		}
 	}
 
-	// Provision sidecar secrets as podman secrets
-	for i, sc := range resolvedSidecars {
-		if len(sc.Secret) > 0 {
-			scSecrets, _ := ApplySecretRefresh(sc.Secret, c.RefreshSecret)
-			scProvisioned, scFallback, scErr := ProvisionPodmanSecrets(rt.RunEngine, c.Box, c.Instance, scSecrets, autoGen)
-			if scErr != nil {
-				fmt.Fprintf(os.Stderr, "Warning: could not provision sidecar %s secrets: %v\n", sc.Name, scErr)
-			}
-			resolvedSidecars[i].Secret = scProvisioned
-			for _, kv := range scFallback {
-				envVars = appendEnvUnique(envVars, kv)
-			}
+	// Log routed env vars
+	for _, e := range sidecarEnvOverrides {
+		key := e
+		if before, _, ok := strings.Cut(e, "="); ok {
+			key = before
 		}
+		fmt.Fprintf(os.Stderr, "Routed %s to sidecar %s\n", key, sidecarEnvKeys[key])
 	}
 
-	return deploySidecars, resolvedSidecars, envVars, nil
+	return deploySidecars, resolvedSidecars, appEnv, nil
 }
 
 //nolint:gocyclo // sequential charly-config deploy pipeline (ref resolution → metadata merge → ports → env/secrets → sidecars → quadlet/systemd write → data seed → hooks); phases consume the previous phase's locals (meta/dc/envVars/ports). Major phases extracted (resolveDeployRef/prepareQuadletEnv/resolveSidecars); the residual orchestration is irreducibly above threshold without unwieldy multi-value param passing
@@ -474,17 +516,10 @@ func (c *BoxConfigSetupCmd) runConfig(rt *ResolvedRuntime) error {
 	}
 
 	// Resolve env vars from global provides + labels + charly.yml + CLI.
-	// Pass deployKey (box-with-instance) — NOT bare c.Box — so an
-	// instance consumer like `versa/ecovoyage` doesn't pick up the base
-	// `versa` deploy's provides, and vice versa.
-	ctrName := containerNameInstance(c.Box, c.Instance)
-	acceptedEnv := AcceptedEnvSet(meta.EnvAccept, meta.EnvRequire)
-	globalEnv := dc.GlobalEnvForImage(deployKey(c.Box, c.Instance), ctrName, acceptedEnv)
-	envVars, envErr := ResolveEnvVars(globalEnv, meta.Env, "", workspaceBindHost(bindMounts), c.EnvFile, c.Env)
+	globalEnv, envVars, envErr := resolveDeployEnv(dc, meta, c.Box, c.Instance, bindMounts, c.EnvFile, c.Env)
 	if envErr != nil {
 		return envErr
 	}
-	envVars = enrichNoProxy(envVars, dc.DeployedContainerNames())
 
 	// Enforce env_requires — hard error before writing anything
 	if len(meta.EnvRequire) > 0 {
@@ -619,9 +654,7 @@ func (c *BoxConfigSetupCmd) runConfig(rt *ResolvedRuntime) error {
 	// Provides vars (from env_provides) are NOT in the env file — they're resolved
 	// at charly config time from charly.yml and must remain as inline Environment= entries.
 	if quadletEnvFile != "" {
-		qcfg.Env = append([]string{}, globalEnv...)
-		qcfg.Env = append(qcfg.Env, c.Env...)
-		qcfg.Env = appendAutoDetectedEnv(qcfg.Env, detected)
+		qcfg.Env = quadletInlineEnv(globalEnv, c.Env, detected)
 	}
 
 	// Persist deployment state to charly.yml (source of truth).
@@ -1089,7 +1122,9 @@ type BoxConfigMountCmd struct {
 }
 
 func (c *BoxConfigMountCmd) Run() error {
-	return encMount(c.Box, c.Instance, c.Volume)
+	err := encMount(c.Box, c.Instance, c.Volume)
+	recordOutcome(encVolumeEvent(EventConfigMount, c.Box, c.Instance, c.Volume), err)
+	return err
 }
 
 // BoxConfigUnmountCmd unmounts encrypted volumes.
@@ -1100,7 +1135,20 @@ type BoxConfigUnmountCmd struct {
 }
 
 func (c *BoxConfigUnmountCmd) Run() error {
-	return encUnmount(c.Box, c.Instance, c.Volume)
+	err := encUnmount(c.Box, c.Instance, c.Volume)
+	recordOutcome(encVolumeEvent(EventConfigUnmount, c.Box, c.Instance, c.Volume), err)
+	return err
+}
+
+// encVolumeEvent is the journal event of a config mount/unmount; volume is
+// empty when the verb covered every encrypted volume of the deployment.
+func encVolumeEvent(typ, box, instance, volume string) Event {
+	box, instance = canonicalizeDeployArg(box, instance)
+	ev := Event{Type: typ, Deploy: deployKey(box, instance)}
+	if volume != "" {
+		ev.Detail = map[string]string{"volume": volume}
+	}
+	return ev
 }
 
 // BoxConfigPasswdCmd changes the gocryptfs password.
@@ -1611,195 +1659,19 @@ func updateAllDeployedQuadlets(rt *ResolvedRuntime, skipBox string) error {
 		if imageRef == "" {
 			imageRef = resolveShellImageRef("", boxName, "")
 		}
-		meta, err := ExtractMetadata("podman", imageRef)
-		if err != nil || meta == nil {
-			fmt.Fprintf(os.Stderr, "Warning: could not read metadata for %s, skipping quadlet update\n", key)
-			continue
-		}
-
-		// Apply charly.yml overrides (instance-aware). Key by the deploy-key
-		// base (boxName from parseDeployKey), not meta.Box — a bed /
-		// Pattern-B entry carries a key distinct from its baked image label.
-		MergeDeployOntoMetadata(meta, dc, boxName, instance)
-
-		// Resolve env vars with updated global env. Pass deployKey so an
-		// instance's quadlet doesn't pick up another instance's provides.
-		updateCtrName := containerNameInstance(boxName, instance)
-		updateAccepted := AcceptedEnvSet(meta.EnvAccept, meta.EnvRequire)
-		globalEnv := dc.GlobalEnvForImage(deployKey(boxName, instance), updateCtrName, updateAccepted)
-		envVars, err := ResolveEnvVars(globalEnv, meta.Env, "", "", "", nil)
+		rendered, err := renderDeployQuadlets(rt, dc, key, imageRef, "")
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: could not resolve env for %s: %v\n", key, err)
+			fmt.Fprintf(os.Stderr, "Warning: %v, skipping quadlet update\n", err)
 			continue
 		}
-		envVars = enrichNoProxy(envVars, dc.DeployedContainerNames())
-
-		// Resolve network
-		resolvedNetwork, _ := ResolveNetwork(meta.Network, rt.RunEngine)
-
-		// Detect devices for GPU config
-		detected := DetectHostDevices()
-
-		// Build volumes from metadata
-		var deployVolumes []DeployVolumeConfig
-		var deploySidecars map[string]SidecarDef
-		if overlay, ok := dc.Bundle[key]; ok {
-			deployVolumes = overlay.Volume
-			deploySidecars = overlay.Sidecar
-		}
-		volumes, bindMounts := ResolveVolumeBacking(boxName, instance, meta.Volume, deployVolumes, meta.Home, rt.EncryptedStoragePath, rt.VolumesPath)
-
-		// Resolve env file
-		var quadletEnvFile string
-		if overlay, ok := dc.Bundle[key]; ok && overlay.EnvFile != "" {
-			quadletEnvFile = expandHostHome(overlay.EnvFile)
-		}
-		if quadletEnvFile == "" {
-			if wsHost := workspaceBindHost(bindMounts); wsHost != "" {
-				wsEnvPath := filepath.Join(wsHost, ".env")
-				if _, statErr := os.Stat(wsEnvPath); statErr == nil {
-					quadletEnvFile = wsEnvPath
-				}
-			}
-		}
-
-		// Merge security
-		security := meta.Security
-		if !security.Privileged {
-			security.Devices = appendUnique(security.Devices, detected.Devices...)
-			if detected.AMDGPU {
-				security.GroupAdd = appendGroupsForAMDGPU(security.GroupAdd)
-			}
-		}
-		envVars = appendAutoDetectedEnv(envVars, detected)
-
-		// Collect secrets from labels (for quadlet Secret= directives).
-		//
-		// Two sources: candy-owned secrets from meta.Secret (existing, unchanged)
-		// and credential-backed secrets synthesized from meta.SecretAccept /
-		// meta.SecretRequire (new in the credential-backed-secrets feature).
-		// Both flow through the same cfg.Secrets slice and the same Secret=
-		// emission at quadlet.go:100-106.
-		//
-		// This mirrors the Run() flow exactly. Without this merge, --update-all
-		// regenerations would drop credential-backed Secret= directives from
-		// consumer quadlets, causing `secret_requires` entrypoints to crashloop
-		// on missing env vars. Plan §2.3. See regression caught during the
-		// live-system testing session: charly-openwebui went FATAL after an
-		// `charly config immich-ml --update-all` wiped its credential Secret= lines.
-		provisioned := CollectSecretsFromLabels(boxName, meta.Secret)
-		credBacked, credResolutions := CollectCandySecretAccepts(boxName, instance, meta)
-		provisioned = append(provisioned, credBacked...)
-
-		// Mirror Run()'s checkMissingSecretRequires — but downgrade to a
-		// warning instead of a hard error, because --update-all should not
-		// abort the regeneration of unrelated quadlets just because one
-		// consumer is missing a required credential. The consumer will
-		// crashloop on restart if the value is truly missing, which is the
-		// user-visible signal. For secret_requires this is strictly
-		// informational.
-		if len(meta.SecretRequire) > 0 {
-			missing := 0
-			for _, r := range credResolutions {
-				if r.Required && !r.Resolved {
-					missing++
-				}
-			}
-			if missing > 0 {
-				fmt.Fprintf(os.Stderr, "Warning: %s has %d unresolved secret_requires entries (quadlet regenerated; image may crashloop on restart)\n", key, missing)
-			}
-		}
-
-		charlyBin, _ := os.Executable()
-		backend := resolveSecretBackend()
-		isKeyring := backend == "keyring" || backend == "auto" || backend == ""
-
-		// NOTE: previously this block re-resolved imageRef via
-		// resolveShellImageRef(meta.Registry, boxName, "") — i.e. it
-		// overwrote the operator-chosen Image= line at the LAST minute
-		// before emitting the quadlet. That fresh resolution is the
-		// exact cross-pollution path described in the comment near the
-		// extractQuadletImageLine call earlier in this function. We
-		// keep the existing-quadlet-derived imageRef unchanged here so
-		// the operator's deliberate Image= choice survives the env-
-		// refresh pass intact.
-
-		// Resolve tunnel config from metadata (includes charly.yml overrides)
-		var tunnelCfg *TunnelConfig
-		if meta.Tunnel != nil {
-			tunnelCfg = TunnelConfigFromMetadata(meta)
-		}
-
-		// Resolve sidecars from charly.yml for pod mode
-		var resolvedSidecars []ResolvedSidecar
-		podName := ""
-		if len(deploySidecars) > 0 {
-			mergedDefs, resolveErr := ResolveSidecarsForConfig(sidecarTemplatesOf(dc), deploySidecars)
-			if resolveErr == nil && len(mergedDefs) > 0 {
-				var rsErr error
-				resolvedSidecars, rsErr = ResolveSidecar(mergedDefs, boxName, instance)
-				if rsErr != nil {
-					fmt.Fprintf(os.Stderr, "Warning: resolving sidecars for %s: %v\n", key, rsErr)
-					continue
-				}
-				podName = PodNameInstance(boxName, instance)
-			}
-		}
-
-		qcfg := QuadletConfig{
-			BoxName:         boxName,
-			Instance:        instance,
-			ImageRef:        imageRef,
-			Home:            meta.Home,
-			Ports:           meta.Port,
-			Volumes:         volumes,
-			BindMounts:      bindMounts,
-			GPU:             detected.GPU || deployNodeSharesGPU(dc.Bundle[key], gatherResources()),
-			BindAddress:     rt.BindAddress,
-			Tunnel:          tunnelCfg,
-			UID:             meta.UID,
-			GID:             meta.GID,
-			Env:             envVars,
-			EnvFile:         quadletEnvFile,
-			Security:        security,
-			Network:         resolvedNetwork,
-			Status:          meta.Status,
-			Info:            meta.Info,
-			Entrypoint:      resolveEntrypointFromMeta(meta),
-			Secrets:         provisioned,
-			CharlyBin:       charlyBin,
-			EncryptedMounts: hasEncryptedBindMounts(bindMounts),
-			KeyringBackend:  isKeyring,
-			PodName:         podName,
-			Sidecar:         resolvedSidecars,
-		}
-
-		// Suppress file-sourced env vars if using EnvFile.
-		// Keep provides env vars — they're not in the env file.
-		if quadletEnvFile != "" {
-			qcfg.Env = append([]string{}, globalEnv...)
-			qcfg.Env = appendAutoDetectedEnv(qcfg.Env, detected)
-		}
-
-		content := generateQuadlet(qcfg)
-		if err := os.WriteFile(qpath, []byte(content), 0600); err != nil {
+		if err := os.WriteFile(qpath, []byte(rendered.Units[0].Content), 0600); err != nil {
 			fmt.Fprintf(os.Stderr, "Warning: could not update quadlet for %s: %v\n", key, err)
 			continue
 		}
-
-		// Regenerate pod and sidecar files when sidecars are configured
-		if len(resolvedSidecars) > 0 {
-			podContent := generatePodQuadlet(qcfg)
-			podPath := filepath.Join(qdir, podQuadletFilenameInstance(boxName, instance))
-			if err := os.WriteFile(podPath, []byte(podContent), 0600); err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: could not update pod file for %s: %v\n", key, err)
-			}
-			for _, sc := range resolvedSidecars {
-				scContent := generateSidecarQuadlet(sc, podName)
-				scPath := filepath.Join(qdir, sidecarQuadletFilenameInstance(boxName, instance, sc.Name))
-				if err := os.WriteFile(scPath, []byte(scContent), 0600); err != nil {
-					fmt.Fprintf(os.Stderr, "Warning: could not update sidecar file for %s/%s: %v\n", key, sc.Name, err)
-				}
+		// Pod and sidecar files follow the app unit when sidecars are configured
+		for _, u := range rendered.Units[1:] {
+			if err := os.WriteFile(filepath.Join(qdir, u.Name), []byte(u.Content), 0600); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: could not update %s for %s: %v\n", u.Name, key, err)
 			}
 		}
 
@@ -1819,6 +1691,189 @@ func updateAllDeployedQuadlets(rt *ResolvedRuntime, skipBox string) error {
 	return nil
 }
 
+// renderedUnit is one generated quadlet file.
+type renderedUnit struct {
+	Name    string // file name under quadletDir()
+	Content string
+}
+
+// renderedDeploy is the generated quadlet set of one pod deployment.
+type renderedDeploy struct {
+	Units   []renderedUnit    // app .container first, then the .pod and sidecar .container files
+	Secrets []CollectedSecret // every Secret= the units reference (app, then sidecars)
+}
+
+// renderDeployQuadlets regenerates the quadlet files of one deployed pod from
+// its image labels and charly.yml: the app .container first, then — when
+// sidecars are configured — the .pod and the sidecar .container files. imageRef
+// is the Image= to render (callers pass the deployed unit's own line). Env,
+// env file and sidecars go through the same helpers as runConfig, with no CLI
+// flags (resolveDeployEnv, resolveQuadletEnvFile, resolveDeploySidecars).
+// Secrets are listed as declared, not provisioned: a secret charly config
+// could not create falls back to inline env there, which drift then reports.
+// charlyBin is the binary the ExecStartPre mount line names; "" renders this
+// one (os.Executable). Shared by updateAllDeployedQuadlets and `charly bundle
+// drift`, which passes the path the deployed unit recorded so a different
+// install location of charly is not reported as unit drift.
+//
+//nolint:gocyclo // the per-deploy resolution chain of updateAllDeployedQuadlets (metadata → env → volumes → secrets → sidecars → QuadletConfig); each step is a peer
+func renderDeployQuadlets(rt *ResolvedRuntime, dc *BundleConfig, key, imageRef, charlyBin string) (*renderedDeploy, error) {
+	boxName, instance := parseDeployKey(key)
+	meta, err := ExtractMetadata("podman", imageRef)
+	if err != nil || meta == nil {
+		return nil, fmt.Errorf("could not read metadata for %s", key)
+	}
+
+	// Apply charly.yml overrides (instance-aware). Key by the deploy-key
+	// base (boxName from parseDeployKey), not meta.Box — a bed /
+	// Pattern-B entry carries a key distinct from its baked image label.
+	MergeDeployOntoMetadata(meta, dc, boxName, instance)
+
+	// Resolve network
+	resolvedNetwork, _ := ResolveNetwork(meta.Network, rt.RunEngine)
+
+	// Detect devices for GPU config
+	detected := DetectHostDevices()
+
+	// Build volumes from metadata
+	var deployVolumes []DeployVolumeConfig
+	if overlay, ok := dc.Bundle[key]; ok {
+		deployVolumes = overlay.Volume
+	}
+	volumes, bindMounts := ResolveVolumeBacking(boxName, instance, meta.Volume, deployVolumes, meta.Home, rt.EncryptedStoragePath, rt.VolumesPath)
+
+	// Env, env file and sidecars resolve exactly as in runConfig, minus the
+	// CLI flags: their persisted effect is already in charly.yml.
+	globalEnv, envVars, err := resolveDeployEnv(dc, meta, boxName, instance, bindMounts, "", nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not resolve env for %s: %w", key, err)
+	}
+	quadletEnvFile := resolveQuadletEnvFile(dc, key, "", bindMounts)
+
+	// Merge security
+	security := meta.Security
+	if !security.Privileged {
+		security.Devices = appendUnique(security.Devices, detected.Devices...)
+		if detected.AMDGPU {
+			security.GroupAdd = appendGroupsForAMDGPU(security.GroupAdd)
+		}
+	}
+	envVars = appendAutoDetectedEnv(envVars, detected)
+
+	// Collect secrets from labels (for quadlet Secret= directives).
+	//
+	// Two sources: candy-owned secrets from meta.Secret (existing, unchanged)
+	// and credential-backed secrets synthesized from meta.SecretAccept /
+	// meta.SecretRequire (new in the credential-backed-secrets feature).
+	// Both flow through the same cfg.Secrets slice and the same Secret=
+	// emission at quadlet.go:100-106.
+	//
+	// This mirrors the Run() flow exactly. Without this merge, --update-all
+	// regenerations would drop credential-backed Secret= directives from
+	// consumer quadlets, causing `secret_requires` entrypoints to crashloop
+	// on missing env vars. Plan §2.3. See regression caught during the
+	// live-system testing session: charly-openwebui went FATAL after an
+	// `charly config immich-ml --update-all` wiped its credential Secret= lines.
+	provisioned := CollectSecretsFromLabels(boxName, meta.Secret)
+	credBacked, credResolutions := CollectCandySecretAccepts(boxName, instance, meta)
+	provisioned = append(provisioned, credBacked...)
+
+	// Mirror Run()'s checkMissingSecretRequires — but downgrade to a
+	// warning instead of a hard error, because --update-all should not
+	// abort the regeneration of unrelated quadlets just because one
+	// consumer is missing a required credential. The consumer will
+	// crashloop on restart if the value is truly missing, which is the
+	// user-visible signal. For secret_requires this is strictly
+	// informational.
+	if len(meta.SecretRequire) > 0 {
+		missing := 0
+		for _, r := range credResolutions {
+			if r.Required && !r.Resolved {
+				missing++
+			}
+		}
+		if missing > 0 {
+			fmt.Fprintf(os.Stderr, "Warning: %s has %d unresolved secret_requires entries (quadlet regenerated; image may crashloop on restart)\n", key, missing)
+		}
+	}
+
+	if charlyBin == "" {
+		charlyBin, _ = os.Executable()
+	}
+	backend := resolveSecretBackend()
+	isKeyring := backend == "keyring" || backend == "auto" || backend == ""
+
+	// NOTE: imageRef is rendered as passed — never re-resolved via
+	// resolveShellImageRef(meta.Registry, boxName, ""). That fresh
+	// resolution is the cross-pollution path described at the
+	// extractQuadletImageLine call in updateAllDeployedQuadlets; keeping
+	// the caller's ref lets the operator's deliberate Image= choice survive
+	// the env-refresh pass intact.
+
+	// Resolve tunnel config from metadata (includes charly.yml overrides)
+	var tunnelCfg *TunnelConfig
+	if meta.Tunnel != nil {
+		tunnelCfg = TunnelConfigFromMetadata(meta)
+	}
+
+	// Resolve sidecars from charly.yml for pod mode
+	_, resolvedSidecars, _, err := resolveDeploySidecars(dc, boxName, instance, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", key, err)
+	}
+	podName := ""
+	if len(resolvedSidecars) > 0 {
+		podName = PodNameInstance(boxName, instance)
+	}
+
+	qcfg := QuadletConfig{
+		BoxName:         boxName,
+		Instance:        instance,
+		ImageRef:        imageRef,
+		Home:            meta.Home,
+		Ports:           meta.Port,
+		Volumes:         volumes,
+		BindMounts:      bindMounts,
+		GPU:             detected.GPU || deployNodeSharesGPU(dc.Bundle[key], gatherResources()),
+		BindAddress:     rt.BindAddress,
+		Tunnel:          tunnelCfg,
+		UID:             meta.UID,
+		GID:             meta.GID,
+		Env:             envVars,
+		EnvFile:         quadletEnvFile,
+		Security:        security,
+		Network:         resolvedNetwork,
+		Status:          meta.Status,
+		Info:            meta.Info,
+		Entrypoint:      resolveEntrypointFromMeta(meta),
+		Secrets:         provisioned,
+		CharlyBin:       charlyBin,
+		EncryptedMounts: hasEncryptedBindMounts(bindMounts),
+		KeyringBackend:  isKeyring,
+		PodName:         podName,
+		Sidecar:         resolvedSidecars,
+	}
+
+	// Suppress file-sourced env vars if using EnvFile.
+	// Keep provides env vars — they're not in the env file.
+	if quadletEnvFile != "" {
+		qcfg.Env = quadletInlineEnv(globalEnv, nil, detected)
+	}
+
+	out := &renderedDeploy{
+		Units:   []renderedUnit{{Name: quadletFilenameInstance(boxName, instance), Content: generateQuadlet(qcfg)}},
+		Secrets: provisioned,
+	}
+	if len(resolvedSidecars) > 0 {
+		out.Units = append(out.Units, renderedUnit{Name: podQuadletFilenameInstance(boxName, instance), Content: generatePodQuadlet(qcfg)})
+		for _, sc := range resolvedSidecars {
+			out.Units = append(out.Units, renderedUnit{Name: sidecarQuadletFilenameInstance(boxName, instance, sc.Name), Content: generateSidecarQuadlet(sc, podName)})
+			out.Secrets = append(out.Secrets, sc.Secret...)
+		}
+	}
+	return out, nil
+}
+
 // sortedStringMapKeys returns the keys of a string map in sorted order.
 func sortedStringMapKeys(m map[string]string) []string {
 	keys := make([]string, 0, len(m))
@@ -161,6 +161,24 @@ func TestCueTightening_RejectsAndAccepts(t *testing.T) {
 			candy(candyHead + "  plan:\n  - check: c\n    plugin: file\n    plugin_input:\n      file: /x\n    context: [weird]\n"), true},
 		{"candy check bad matcher op rejected", "candy",
 			candy(candyHead + "  plan:\n  - check: c\n    command: x\n    context: [runtime]\n    stdout:\n    - mystery: \"?\"\n"), true},
+		{"candy check json matcher accepted", "candy",
+			candy(candyHead + "  plan:\n  - check: c\n    command: x\n    context: [runtime]\n    stdout:\n    - json: {path: .status, equals: ok}\n"), false},
+		{"candy check screenshot baseline accepted", "candy",
+			candy(candyHead + "  plan:\n  - check: c\n    vnc: screenshot\n    artifact: /tmp/s.png\n    artifact_baseline: tests/golden/s.png\n    artifact_max_diff: 0.5\n    artifact_ignore: [\"0,0,200x30\"]\n    context: [deploy]\n"), false},
+		{"candy check screenshot bad ignore region rejected", "candy",
+			candy(candyHead + "  plan:\n  - check: c\n    vnc: screenshot\n    artifact: /tmp/s.png\n    artifact_baseline: s.png\n    artifact_ignore: [\"top-bar\"]\n    context: [deploy]\n"), true},
+		{"candy check vnc click-image accepted", "candy",
+			candy(candyHead + "  plan:\n  - check: c\n    vnc: click-image\n    template: tests/ok-button.png\n    match_threshold: 0.85\n    capture: ok_btn\n    capture_extract: \"json:.x\"\n    context: [deploy]\n"), false},
+		{"candy check match_threshold out-of-range rejected", "candy",
+			candy(candyHead + "  plan:\n  - check: c\n    wl: find-image\n    template: t.png\n    match_threshold: 2\n    context: [deploy]\n"), true},
+		{"candy check cdp network-stop expectations accepted", "candy",
+			candy(candyHead + "  plan:\n  - check: c\n    cdp: network-stop\n    tab: \"1\"\n    artifact: /tmp/page.har\n    network_expect:\n    - url: /api/items$\n      status: 200\n      max_ms: 500\n    - host: \"*.tracker.test\"\n      absent: true\n    network_allow_hosts: [app.test]\n    context: [deploy]\n"), false},
+		{"candy check cdp network_expect bad status rejected", "candy",
+			candy(candyHead + "  plan:\n  - check: c\n    cdp: network-stop\n    tab: \"1\"\n    artifact: /tmp/page.har\n    network_expect:\n    - url: x\n      status: 42\n    context: [deploy]\n"), true},
+		{"candy check cdp console allow-list accepted", "candy",
+			candy(candyHead + "  plan:\n  - check: c\n    cdp: console\n    tab: \"1\"\n    artifact: /tmp/console.log\n    console_allow: [\"favicon\\\\.ico\"]\n    context: [deploy]\n"), false},
+		{"candy check cdp bogus console_level rejected", "candy",
+			candy(candyHead + "  plan:\n  - check: c\n    cdp: console\n    tab: \"1\"\n    console_level: fatal\n    context: [deploy]\n"), true},
 		{"candy check mcp bogus method rejected", "candy",
 			candy(candyHead + "  plan:\n  - check: c\n    mcp: bogus\n    context: [deploy]\n"), true},
 		{"candy check spice bogus method rejected", "candy",
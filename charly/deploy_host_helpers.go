@@ -52,18 +52,20 @@ func renderHostPackageCommand(distroCfg *DistroConfig, s *SystemPackagesStep) (s
 }
 
 // hostBuilderContext is the template context for a builder's phase.install.host cell. The
-// HOME/PIXI_CACHE_DIR/NPM_CONFIG_PREFIX/CARGO_HOME values are injected by BuilderRunOpts.Env
-// (the cells read them as $HOME/$CARGO_HOME), so the only template-visible datum is the
-// package list (consumed by the aur cell).
+// HOME/PIXI_CACHE_DIR/NPM_CONFIG_PREFIX/CARGO_HOME/GOMODCACHE values are injected by
+// BuilderRunOpts.Env (the cells read them as $HOME/$CARGO_HOME), so the only template-visible
+// data are the package list (consumed by the aur cell) and the go main packages + ldflags.
 type hostBuilderContext struct {
 	HostHome string
 	Packages []string
+	Main     []string
+	Ldflags  string
 }
 
 // renderBuilderScript turns a BuilderStep into the bash script that runs inside the builder
 // container — the host-side (deploy) analog of the build-time multi-stage, fully config-driven:
 // it renders the builder's phase.install.host cell via the SAME RenderTemplate engine
-// (text/template). HOME/PIXI_CACHE_DIR/NPM_CONFIG_PREFIX/CARGO_HOME are injected by
+// (text/template). HOME/PIXI_CACHE_DIR/NPM_CONFIG_PREFIX/CARGO_HOME/GOMODCACHE are injected by
 // BuilderRunOpts.Env before the script starts.
 func renderBuilderScript(s *BuilderStep, hostHome string) (string, error) {
 	if s.BuilderDef == nil {
@@ -76,6 +78,10 @@ func renderBuilderScript(s *BuilderStep, hostHome string) (string, error) {
 	ctx := hostBuilderContext{
 		HostHome: hostHome,
 		Packages: extractStringSlice(s.RawStageContext, "packages"),
+		Main:     extractStringSlice(s.RawStageContext, "main"),
+	}
+	if v, ok := s.RawStageContext["ldflags"].(string); ok {
+		ctx.Ldflags = v
 	}
 	script, err := RenderTemplate(s.Builder+"-host", tmpl, ctx)
 	if err != nil {
@@ -116,6 +116,11 @@ func (t *PodDeployTarget) renderOverlayServices(overlayCandies []string) (string
 	if !anySvc {
 		return "", "", nil
 	}
+	// The append below concatenates into one assembled config; an init that
+	// installs one file per service (openrc) has nothing to append to.
+	if initDef.Model != "fragment_assembly" {
+		return "", "", fmt.Errorf("overlay services need a fragment_assembly init; box %s uses %s", t.Box.Name, initName)
+	}
 	overlayImageName := "overlay-" + t.DeployName
 	// Point the Generator at the overlay build dir so generateInitFragments
 	// writes fragments there. OverlayBuildDir is already relative to the
@@ -4,7 +4,10 @@ import (
 	"fmt"
 	"maps"
 	"regexp"
+	"strings"
 	"sync"
+
+	"github.com/overthinkos/overthink/charly/plugin/sdk"
 )
 
 // ScenarioContext carries per-plan-run mutable state across the
@@ -197,6 +200,10 @@ func CaptureFromResult(rawValue, resultMessage string) string {
 // returns the first submatch group (or the whole match if no groups
 // exist). Returns an error if the pattern is invalid or doesn't match.
 //
+// A `json:` prefix switches from regex to a jq/JSONPath query over a JSON
+// value (`capture_extract: "json:.items[0].metadata.name"`), evaluated by
+// the same sdk.JSONQueryString the `json:` matcher uses.
+//
 // Used by the runner when a check sets `capture_extract:` alongside
 // `capture:` — see checkrun.go's post-dispatch capture block. A failed
 // match deliberately surfaces as an error so the caller can FAIL the
@@ -205,6 +212,13 @@ func ApplyCaptureExtract(value, pattern string) (string, error) {
 	if pattern == "" {
 		return value, nil
 	}
+	if query, ok := strings.CutPrefix(pattern, "json:"); ok {
+		out, err := sdk.JSONQueryString(value, strings.TrimSpace(query))
+		if err != nil {
+			return "", fmt.Errorf("capture_extract %q: %w", pattern, err)
+		}
+		return out, nil
+	}
 	re, err := regexp.Compile(pattern)
 	if err != nil {
 		return "", fmt.Errorf("invalid capture_extract regex %q: %w", pattern, err)
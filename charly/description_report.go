@@ -2,7 +2,6 @@ package main
 
 import (
 	"encoding/json"
-	"encoding/xml"
 	"fmt"
 	"io"
 	"strings"
@@ -79,85 +78,9 @@ func FormatStepResultsTAP(w io.Writer, results []StepResult) {
 }
 
 // FormatStepResultsJUnit emits JUnit XML for CI dashboards. Steps surface as
-// <testcase> grouped by origin into <testsuite>s.
+// <testcase>s grouped into <testsuite>s by section and origin; attempts,
+// captured values and skip reasons ride along as <property> elements. The
+// shared writer lives in check_report_ci.go.
 func FormatStepResultsJUnit(w io.Writer, results []StepResult) error {
-	type junitFailure struct {
-		Message string `xml:"message,attr"`
-		Body    string `xml:",chardata"`
-	}
-	type junitSkipped struct {
-		Message string `xml:"message,attr"`
-	}
-	type junitTestCase struct {
-		XMLName   xml.Name      `xml:"testcase"`
-		Name      string        `xml:"name,attr"`
-		Classname string        `xml:"classname,attr"`
-		Time      float64       `xml:"time,attr"`
-		Failure   *junitFailure `xml:"failure,omitempty"`
-		Skipped   *junitSkipped `xml:"skipped,omitempty"`
-	}
-	type junitTestSuite struct {
-		XMLName  xml.Name        `xml:"testsuite"`
-		Name     string          `xml:"name,attr"`
-		Tests    int             `xml:"tests,attr"`
-		Failures int             `xml:"failures,attr"`
-		Skipped  int             `xml:"skipped,attr"`
-		Time     float64         `xml:"time,attr"`
-		Cases    []junitTestCase `xml:"testcase"`
-	}
-	type junitTestSuites struct {
-		XMLName xml.Name         `xml:"testsuites"`
-		Suites  []junitTestSuite `xml:"testsuite"`
-	}
-
-	// Group steps by origin (preserving first-seen order).
-	var order []string
-	byOrigin := map[string]*junitTestSuite{}
-	for i := range results {
-		step := results[i]
-		suite := byOrigin[step.Origin]
-		if suite == nil {
-			suite = &junitTestSuite{Name: step.Origin}
-			byOrigin[step.Origin] = suite
-			order = append(order, step.Origin)
-		}
-		elapsed := step.Result.Elapsed.Seconds()
-		if step.Result.TotalElapsed > 0 {
-			elapsed = step.Result.TotalElapsed.Seconds()
-		}
-		tc := junitTestCase{
-			Name:      step.Keyword + " " + step.Text,
-			Classname: step.Origin,
-			Time:      elapsed,
-		}
-		switch step.Result.Status {
-		case TestFail:
-			tc.Failure = &junitFailure{
-				Message: step.Result.Message,
-				Body:    "Verb: " + step.Result.Verb + "\nStep ID: " + step.StepID,
-			}
-			suite.Failures++
-		case TestSkip:
-			tc.Skipped = &junitSkipped{Message: step.Result.Message}
-			suite.Skipped++
-		}
-		suite.Cases = append(suite.Cases, tc)
-		suite.Time += elapsed
-	}
-
-	var suites junitTestSuites
-	for _, o := range order {
-		s := byOrigin[o]
-		s.Tests = len(s.Cases)
-		suites.Suites = append(suites.Suites, *s)
-	}
-
-	fmt.Fprintln(w, `<?xml version="1.0" encoding="UTF-8"?>`)
-	enc := xml.NewEncoder(w)
-	enc.Indent("", "  ")
-	if err := enc.Encode(suites); err != nil {
-		return err
-	}
-	fmt.Fprintln(w)
-	return nil
+	return writeJUnit(w, "charly check", ciCasesFromSteps(results))
 }
@@ -93,9 +93,47 @@ func TestCascade_FormatFamilyLevel(t *testing.T) {
 		t.Errorf("pac family: arch=%v cachyos=%v, want both [sddm]", arch, cachyos)
 	}
 
-	// cascadeTagChain order: distro chain, then format tag (least-specific) last.
-	if got := cascadeTagChain(fmtImg("pac", "cachyos")); !reflect.DeepEqual(got, []string{"cachyos", "pac"}) {
-		t.Errorf("cascadeTagChain = %v, want [cachyos pac]", got)
+	// cascadeTagChain order: distro chain, then format tag (least-specific) last,
+	// each level preceded by its arch-qualified form.
+	img := fmtImg("pac", "cachyos")
+	img.Arch = "amd64"
+	if got, want := cascadeTagChain(img), []string{"cachyos@amd64", "cachyos", "pac@amd64", "pac"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("cascadeTagChain = %v, want %v", got, want)
+	}
+}
+
+// TestCascade_ArchQualifiedBlocks proves a `distro: <tag>@<arch>:` block adds
+// packages (and overrides repos) only when generating for that architecture.
+func TestCascade_ArchQualifiedBlocks(t *testing.T) {
+	l := deriveCandy(t, `
+name: t
+distro:
+  fedora:
+    package: [common]
+    repo:
+      - name: x86
+  fedora@arm64:
+    package: [arm-only]
+    repo:
+      - name: arm
+`)
+	if !l.HasArchSections() {
+		t.Fatal("HasArchSections = false")
+	}
+	for arch, want := range map[string][]string{
+		"amd64": {"common"},
+		"arm64": {"common", "arm-only"},
+	} {
+		img := fmtImg("rpm", "fedora:43", "fedora")
+		img.Arch = arch
+		pkgs, raw, _ := resolveCascadePackages(l, img)
+		if !reflect.DeepEqual(pkgs, want) {
+			t.Errorf("%s packages = %v, want %v", arch, pkgs, want)
+		}
+		wantRepo := map[string]string{"amd64": "x86", "arm64": "arm"}[arch]
+		if repos := toMapSlice(raw["repo"]); len(repos) != 1 || repos[0]["name"] != wantRepo {
+			t.Errorf("%s repo = %v, want %s", arch, raw["repo"], wantRepo)
+		}
 	}
 }
 
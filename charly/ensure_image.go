@@ -64,6 +64,9 @@ func EnsureImagePresent(ctx context.Context, image string, cfg *Config, projectD
 	// refs the input is already the storage key.
 	if ref, _ := resolveImageRefForEnsure(image, cfg, projectDir); ref != "" {
 		if LocalImageExists("podman", ref) {
+			if err := enforceLocalImageTrust(ref, cfg, projectDir); err != nil {
+				return err
+			}
 			fmt.Fprintf(os.Stderr, "ensure-image: %s present\n", ref)
 			return nil
 		}
@@ -74,6 +77,19 @@ func EnsureImagePresent(ctx context.Context, image string, cfg *Config, projectD
 	// to a registry ref via cfg.
 	pullRef, perr := pullRefForEnsure(image, cfg, projectDir)
 	if perr == nil && pullRef != "" {
+		// A trust policy verifies the signature first and pins the pull to the
+		// verified digest; a rejection is final (no build fallback).
+		verified, terr := enforceImageTrust(pullRef, cfg, projectDir)
+		if terr != nil {
+			return terr
+		}
+		if verified != "" {
+			fmt.Fprintf(os.Stderr, "ensure-image: pulling %s\n", verified)
+			if err := podmanPullForEnsure(ctx, verified); err != nil {
+				return fmt.Errorf("ensure-image %q: pulling verified %s: %w", image, verified, err)
+			}
+			return podmanTagAlias(ctx, verified, pullRef)
+		}
 		fmt.Fprintf(os.Stderr, "ensure-image: pulling %s\n", pullRef)
 		if err := podmanPullForEnsure(ctx, pullRef); err == nil {
 			return nil
@@ -0,0 +1,518 @@
+package main
+
+// events.go — the lifecycle event journal behind `charly events`.
+//
+// Every verb that changes a deployment appends one Event to an append-only
+// JSON-lines journal, ~/.local/share/charly/events/journal.jsonl:
+//
+//	bundle.add, bundle.del            charly bundle add / del
+//	deploy.start, deploy.stop,        charly start / stop / restart / update / remove
+//	deploy.restart, deploy.update,
+//	deploy.remove
+//	deploy.restarted                  a restart `charly status` observed (systemd,
+//	                                  engine, libvirt or k8s counters moved)
+//	preempt.acquire, preempt.release  the resource arbiter's leases (preempt.go)
+//	preempt.stop, preempt.restore     a holder the arbiter stopped / brought back
+//	vm.snapshot                       charly vm snapshot create / delete / revert / promote
+//	config.mount, config.unmount      charly config mount / unmount
+//	check.run                         a kind:check bed run (charly check run <bed>)
+//	cmd.run                           charly cmd --notify
+//
+// Recording is best-effort: a journal or sink failure is a stderr warning and
+// never fails the verb that emitted the event. The journal rotates to
+// journal.jsonl.1 at eventJournalMax, so it holds between one and two files'
+// worth of history.
+//
+// The event_sinks: list of ~/.config/charly/config.yml forwards each recorded
+// event, optionally filtered by type and deployment, to a webhook (the event
+// JSON, or {"text": …} for chat incoming-webhooks) or to a command (the event
+// JSON on stdin, CHARLY_EVENT_* in the environment). Only the journal append
+// is inline: delivery runs in a detached `charly __event-deliver` process, so
+// a slow sink never holds up the verb, arbiter seam or status collection that
+// recorded the event. Its warnings land in sinks.log beside the journal.
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	// eventJournalMax is the size at which the journal rotates.
+	eventJournalMax = 8 << 20
+	// eventSinkTimeout bounds one sink delivery.
+	eventSinkTimeout = 10 * time.Second
+	// envEventSink marks a sink command's environment, so the charly verbs it
+	// runs journal their events without feeding them back into the sinks.
+	envEventSink = "CHARLY_EVENT_SINK"
+)
+
+// Event types. A type is <area>.<what>; filters match a whole type or an area.
+const (
+	EventBundleAdd       = "bundle.add"
+	EventBundleDel       = "bundle.del"
+	EventDeployStart     = "deploy.start"
+	EventDeployStop      = "deploy.stop"
+	EventDeployRestart   = "deploy.restart"
+	EventDeployUpdate    = "deploy.update"
+	EventDeployRemove    = "deploy.remove"
+	EventDeployRestarted = "deploy.restarted"
+	EventPreemptAcquire  = "preempt.acquire"
+	EventPreemptRelease  = "preempt.release"
+	EventPreemptStop     = "preempt.stop"
+	EventPreemptRestore  = "preempt.restore"
+	EventVmSnapshot      = "vm.snapshot"
+	EventConfigMount     = "config.mount"
+	EventConfigUnmount   = "config.unmount"
+	EventCheckRun        = "check.run"
+	EventCmdRun          = "cmd.run"
+)
+
+// Event is one journal entry.
+type Event struct {
+	Time    time.Time         `json:"time"`
+	Type    string            `json:"type"`
+	Deploy  string            `json:"deploy,omitempty"` // deploy key: <box>[/<instance>]
+	Kind    SubstrateKind     `json:"kind,omitempty"`
+	Result  string            `json:"result,omitempty"` // "ok" | "failed"
+	Message string            `json:"message,omitempty"`
+	Detail  map[string]string `json:"detail,omitempty"`
+	Host    string            `json:"host,omitempty"`
+}
+
+// EventSink is one event_sinks: entry of the runtime config. Exactly one of
+// Webhook and Exec is set; Types and Deploy filter like `charly events`.
+type EventSink struct {
+	Name    string   `yaml:"name,omitempty" json:"name,omitempty"`
+	Webhook string   `yaml:"webhook,omitempty" json:"webhook,omitempty"` // POST URL
+	Format  string   `yaml:"format,omitempty" json:"format,omitempty"`   // webhook body: "json" (default) | "text"
+	Exec    string   `yaml:"exec,omitempty" json:"exec,omitempty"`       // sh -c command
+	Types   []string `yaml:"types,omitempty" json:"types,omitempty"`
+	Deploy  []string `yaml:"deploy,omitempty" json:"deploy,omitempty"`
+}
+
+// eventJournalPath returns the journal file. A var so tests can redirect it.
+var eventJournalPath = func() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("determining home directory: %w", err)
+	}
+	return filepath.Join(home, ".local", "share", "charly", "events", "journal.jsonl"), nil
+}
+
+// recordEvent stamps an event, appends it to the journal and hands it to the
+// configured sinks it matches (dispatchEvent). Best-effort.
+func recordEvent(ev Event) {
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+	ev.Time = ev.Time.UTC()
+	if ev.Host == "" {
+		ev.Host, _ = os.Hostname()
+	}
+	if p, err := eventJournalPath(); err == nil {
+		if err := appendEvent(p, ev); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: recording %s event: %v\n", ev.Type, err)
+		}
+	}
+	if os.Getenv(envEventSink) != "" {
+		return
+	}
+	if cfg, err := LoadRuntimeConfig(); err == nil && slices.ContainsFunc(cfg.EventSinks, func(s EventSink) bool { return s.matches(ev) }) {
+		dispatchEvent(ev)
+	}
+}
+
+// dispatchEvent starts the sink delivery of a recorded event. A var so tests
+// deliver inline.
+var dispatchEvent = spawnEventDelivery
+
+// spawnEventDelivery starts eventDeliveryCommand without waiting for it; the
+// child is only reaped should this process outlive it.
+func spawnEventDelivery(ev Event) {
+	cmd, err := eventDeliveryCommand(ev)
+	if err == nil {
+		if p, perr := eventJournalPath(); perr == nil {
+			if f, ferr := os.OpenFile(filepath.Join(filepath.Dir(p), "sinks.log"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644); ferr == nil {
+				cmd.Stderr = f
+				defer f.Close() //nolint:errcheck
+			}
+		}
+		err = cmd.Start()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: delivering %s event: %v\n", ev.Type, err)
+		return
+	}
+	go func() { _ = cmd.Wait() }()
+}
+
+// eventDeliveryCommand is `charly __event-deliver <event JSON>` in a session
+// of its own, so it outlives the recording verb and its terminal's signals.
+func eventDeliveryCommand(ev Event) (*exec.Cmd, error) {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return nil, err
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(self, "__event-deliver", string(data))
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	return cmd, nil
+}
+
+// EventDeliverInternalCmd delivers one recorded event to the event_sinks of
+// the runtime config — the detached half of recordEvent.
+type EventDeliverInternalCmd struct {
+	Event string `arg:"" help:"The recorded event as JSON"`
+}
+
+func (c *EventDeliverInternalCmd) Run() error {
+	var ev Event
+	if err := json.Unmarshal([]byte(c.Event), &ev); err != nil {
+		return fmt.Errorf("decoding event: %w", err)
+	}
+	cfg, err := LoadRuntimeConfig()
+	if err != nil {
+		return err
+	}
+	deliverEvent(cfg.EventSinks, ev)
+	return nil
+}
+
+// recordOutcome records the event of a verb that finished with err.
+func recordOutcome(ev Event, err error) {
+	if err != nil {
+		ev.Result, ev.Message = "failed", err.Error()
+	} else {
+		ev.Result = "ok"
+	}
+	recordEvent(ev)
+}
+
+// appendEvent writes one line to the journal under its lock, rotating it
+// first once it reached eventJournalMax.
+func appendEvent(journal string, ev Event) error {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(journal), 0o755); err != nil {
+		return err
+	}
+	release, err := acquireFileLock(journal+".lock", true)
+	if err != nil {
+		return err
+	}
+	defer release() //nolint:errcheck
+	if fi, err := os.Stat(journal); err == nil && fi.Size() >= eventJournalMax {
+		if err := os.Rename(journal, journal+".1"); err != nil {
+			return fmt.Errorf("rotating %s: %w", journal, err)
+		}
+	}
+	f, err := os.OpenFile(journal, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// readJournalFrom decodes the complete lines of a journal file from offset on
+// and returns the offset after the last one; a trailing partial line is left
+// for the next read. Malformed lines are skipped. A missing file reads empty.
+func readJournalFrom(file string, offset int64) ([]Event, int64, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, offset, nil
+		}
+		return nil, offset, err
+	}
+	defer f.Close() //nolint:errcheck
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, offset, err
+	}
+	var out []Event
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err != nil {
+			// io.EOF: whatever is left is a line still being written.
+			if err == io.EOF {
+				return out, offset, nil
+			}
+			return out, offset, err
+		}
+		offset += int64(len(line))
+		var ev Event
+		if json.Unmarshal(line, &ev) == nil && ev.Type != "" {
+			out = append(out, ev)
+		}
+	}
+}
+
+// eventFilter selects events by deployment, type and age.
+type eventFilter struct {
+	Deploy []string // deploy keys; a bare <box> matches every instance of it
+	Types  []string // exact types, areas ("deploy" = deploy.*) or path.Match globs
+	Since  time.Time
+}
+
+func (f eventFilter) match(ev Event) bool {
+	if !f.Since.IsZero() && ev.Time.Before(f.Since) {
+		return false
+	}
+	if len(f.Deploy) > 0 && !matchAny(f.Deploy, func(d string) bool {
+		return ev.Deploy == d || (!strings.Contains(d, "/") && strings.HasPrefix(ev.Deploy, d+"/"))
+	}) {
+		return false
+	}
+	if len(f.Types) > 0 && !matchAny(f.Types, func(t string) bool {
+		if ev.Type == t || strings.HasPrefix(ev.Type, t+".") {
+			return true
+		}
+		ok, _ := path.Match(t, ev.Type)
+		return ok
+	}) {
+		return false
+	}
+	return true
+}
+
+func matchAny(patterns []string, match func(string) bool) bool {
+	for _, p := range patterns {
+		if match(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// eventText renders an event as one line, without its timestamp: the body of
+// the text view and of the "text" webhook format.
+func eventText(ev Event) string {
+	var b strings.Builder
+	b.WriteString(ev.Type)
+	if ev.Deploy != "" {
+		b.WriteString(" " + ev.Deploy)
+	}
+	if ev.Result != "" {
+		b.WriteString(" " + ev.Result)
+	}
+	if ev.Message != "" {
+		b.WriteString(": " + ev.Message)
+	}
+	return b.String()
+}
+
+// deliverEvent hands an event to every sink whose filter it passes.
+func deliverEvent(sinks []EventSink, ev Event) {
+	for _, s := range sinks {
+		if !s.matches(ev) {
+			continue
+		}
+		if err := s.deliver(ev); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: event sink %s: %v\n", s.label(), err)
+		}
+	}
+}
+
+func (s EventSink) matches(ev Event) bool {
+	return eventFilter{Deploy: s.Deploy, Types: s.Types}.match(ev)
+}
+
+func (s EventSink) label() string {
+	switch {
+	case s.Name != "":
+		return s.Name
+	case s.Webhook != "":
+		return s.Webhook
+	}
+	return s.Exec
+}
+
+func (s EventSink) deliver(ev Event) error {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), eventSinkTimeout)
+	defer cancel()
+	switch {
+	case s.Webhook != "":
+		body := data
+		switch s.Format {
+		case "", "json":
+		case "text":
+			if body, err = json.Marshal(map[string]string{"text": "charly@" + ev.Host + ": " + eventText(ev)}); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unknown format %q (want json or text)", s.Format)
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.Webhook, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close() //nolint:errcheck
+		_, _ = io.Copy(io.Discard, resp.Body)
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("POST %s: %s", s.Webhook, resp.Status)
+		}
+		return nil
+	case s.Exec != "":
+		cmd := exec.CommandContext(ctx, "sh", "-c", s.Exec)
+		cmd.Stdin = bytes.NewReader(data)
+		cmd.Env = append(os.Environ(),
+			envEventSink+"=1",
+			"CHARLY_EVENT_TYPE="+ev.Type,
+			"CHARLY_EVENT_DEPLOY="+ev.Deploy,
+			"CHARLY_EVENT_RESULT="+ev.Result,
+			"CHARLY_EVENT_MESSAGE="+ev.Message,
+			"CHARLY_EVENT_TEXT="+eventText(ev),
+		)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+		}
+		return nil
+	}
+	return fmt.Errorf("neither webhook nor exec set")
+}
+
+// EventsCmd prints the lifecycle event journal.
+type EventsCmd struct {
+	Deploy   string   `arg:"" optional:"" help:"Only events of this deployment (a bare box name matches every instance)"`
+	Instance string   `short:"i" long:"instance" help:"Instance name"`
+	Type     []string `short:"t" long:"type" help:"Only these event types: a type (deploy.start), an area (deploy, preempt) or a glob (vm.*)"`
+	Since    string   `long:"since" help:"Only events from this time on (duration ago like 15m, RFC3339, or YYYY-MM-DD[ HH:MM:SS])"`
+	Tail     int      `short:"n" long:"tail" default:"50" help:"Show the last N matching events (0 = all)"`
+	Follow   bool     `short:"f" long:"follow" help:"Keep printing events as they are recorded"`
+	JSON     bool     `long:"json" help:"One JSON object per line"`
+}
+
+func (c *EventsCmd) Run() error {
+	journal, err := eventJournalPath()
+	if err != nil {
+		return err
+	}
+	filter := eventFilter{Types: c.Type}
+	if c.Deploy != "" {
+		box, inst := canonicalizeDeployArg(c.Deploy, c.Instance)
+		filter.Deploy = []string{deployKey(box, inst)}
+	}
+	if filter.Since, err = parseLogTime(c.Since, time.Now()); err != nil {
+		return err
+	}
+
+	rotated, _, err := readJournalFrom(journal+".1", 0)
+	if err != nil {
+		return err
+	}
+	current, offset, err := readJournalFrom(journal, 0)
+	if err != nil {
+		return err
+	}
+	var shown []Event
+	for _, ev := range append(rotated, current...) {
+		if filter.match(ev) {
+			shown = append(shown, ev)
+		}
+	}
+	if c.Tail > 0 && len(shown) > c.Tail {
+		shown = shown[len(shown)-c.Tail:]
+	}
+	for _, ev := range shown {
+		if err := c.print(ev); err != nil {
+			return err
+		}
+	}
+	if !c.Follow {
+		if len(shown) == 0 && !c.JSON {
+			fmt.Fprintln(os.Stderr, "No events recorded.")
+		}
+		return nil
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return followJournal(ctx, journal, offset, 500*time.Millisecond, func(ev Event) error {
+		if !filter.match(ev) {
+			return nil
+		}
+		return c.print(ev)
+	})
+}
+
+func (c *EventsCmd) print(ev Event) error {
+	if c.JSON {
+		return json.NewEncoder(os.Stdout).Encode(ev)
+	}
+	_, err := fmt.Printf("%s  %s\n", ev.Time.Local().Format("2006-01-02 15:04:05"), eventText(ev))
+	return err
+}
+
+// followJournal polls the journal for lines appended after offset until ctx
+// ends. A rotation (the file at the journal path was replaced) first drains
+// the rotated file from the old offset, then continues at the new file's start.
+func followJournal(ctx context.Context, journal string, offset int64, every time.Duration, emit func(Event) error) error {
+	prev, _ := os.Stat(journal)
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		cur, err := os.Stat(journal)
+		if err != nil {
+			continue
+		}
+		if prev != nil && !os.SameFile(prev, cur) {
+			if old, err := os.Stat(journal + ".1"); err == nil && os.SameFile(prev, old) {
+				evs, _, _ := readJournalFrom(journal+".1", offset)
+				for _, ev := range evs {
+					if err := emit(ev); err != nil {
+						return err
+					}
+				}
+			}
+			offset = 0
+		}
+		prev = cur
+		evs, next, err := readJournalFrom(journal, offset)
+		if err != nil {
+			return err
+		}
+		offset = next
+		for _, ev := range evs {
+			if err := emit(ev); err != nil {
+				return err
+			}
+		}
+	}
+}
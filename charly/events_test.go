@@ -0,0 +1,231 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// withEventJournal redirects the journal and the runtime config into a temp dir
+// and runs the detached sink delivery (`charly __event-deliver`) inline.
+func withEventJournal(t *testing.T, config string) string {
+	t.Helper()
+	dir := t.TempDir()
+	journal := filepath.Join(dir, "events", "journal.jsonl")
+	prevJournal, prevConfig, prevDispatch := eventJournalPath, RuntimeConfigPath, dispatchEvent
+	eventJournalPath = func() (string, error) { return journal, nil }
+	cfg := filepath.Join(dir, "config.yml")
+	RuntimeConfigPath = func() (string, error) { return cfg, nil }
+	dispatchEvent = func(ev Event) {
+		cmd, err := eventDeliveryCommand(ev)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cmd.Args[1] != "__event-deliver" || cmd.SysProcAttr == nil || !cmd.SysProcAttr.Setsid {
+			t.Fatalf("delivery command %v (%+v)", cmd.Args, cmd.SysProcAttr)
+		}
+		if err := (&EventDeliverInternalCmd{Event: cmd.Args[2]}).Run(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Cleanup(func() { eventJournalPath, RuntimeConfigPath, dispatchEvent = prevJournal, prevConfig, prevDispatch })
+	if err := os.WriteFile(cfg, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return journal
+}
+
+func TestRecordEvent_JournalAndSinks(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		posted []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		posted = append(posted, string(body))
+		mu.Unlock()
+	}))
+	defer srv.Close()
+	out := filepath.Join(t.TempDir(), "exec.out")
+	journal := withEventJournal(t, `event_sinks:
+  - name: chat
+    webhook: `+srv.URL+`
+    format: text
+    types: [deploy]
+  - exec: 'echo "$CHARLY_EVENT_TYPE $CHARLY_EVENT_DEPLOY $CHARLY_EVENT_RESULT" >> `+out+`; cat >> `+out+`.json'
+    deploy: [app]
+`)
+
+	recordOutcome(Event{Type: EventDeployStart, Deploy: "app/blue", Kind: SubstratePod}, nil)
+	recordOutcome(Event{Type: EventVmSnapshot, Deploy: "arch", Detail: map[string]string{"action": "create"}}, os.ErrPermission)
+	recordOutcome(Event{Type: EventBundleAdd, Deploy: "app"}, nil)
+
+	evs, offset, err := readJournalFrom(journal, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi, _ := os.Stat(journal); len(evs) != 3 || offset != fi.Size() {
+		t.Fatalf("journal = %+v (offset %d)", evs, offset)
+	}
+	if evs[0].Result != "ok" || evs[0].Host == "" || evs[0].Time.IsZero() || evs[1].Result != "failed" || evs[1].Message != os.ErrPermission.Error() {
+		t.Errorf("recorded = %+v", evs)
+	}
+
+	// The webhook saw only the deploy.* event, as chat text.
+	if len(posted) != 1 {
+		t.Fatalf("webhook posts = %q", posted)
+	}
+	var msg map[string]string
+	if err := json.Unmarshal([]byte(posted[0]), &msg); err != nil || !strings.HasSuffix(msg["text"], ": deploy.start app/blue ok") {
+		t.Errorf("webhook body = %s", posted[0])
+	}
+	// The exec sink saw both events of deployment app, the JSON on stdin.
+	data, _ := os.ReadFile(out)
+	if got := string(data); got != "deploy.start app/blue ok\nbundle.add app ok\n" {
+		t.Errorf("exec sink env = %q", got)
+	}
+	data, _ = os.ReadFile(out + ".json")
+	if strings.Count(string(data), `"type":`) != 2 {
+		t.Errorf("exec sink stdin = %s", data)
+	}
+
+	// A sink command's own events are journaled but not fed back to the sinks.
+	t.Setenv(envEventSink, "1")
+	recordEvent(Event{Type: EventDeployStop, Deploy: "app"})
+	if len(posted) != 1 {
+		t.Errorf("sink-originated event delivered: %q", posted)
+	}
+}
+
+func TestAppendEvent_Rotates(t *testing.T) {
+	journal := filepath.Join(t.TempDir(), "journal.jsonl")
+	if err := appendEvent(journal, Event{Type: EventDeployStart}); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Truncate(journal, eventJournalMax); err != nil {
+		t.Fatal(err)
+	}
+	if err := appendEvent(journal, Event{Type: EventDeployStop}); err != nil {
+		t.Fatal(err)
+	}
+	evs, _, _ := readJournalFrom(journal, 0)
+	old, _, _ := readJournalFrom(journal+".1", 0)
+	if len(evs) != 1 || evs[0].Type != EventDeployStop || len(old) != 1 || old[0].Type != EventDeployStart {
+		t.Errorf("after rotation: current %+v, rotated %+v", evs, old)
+	}
+}
+
+func TestEventFilter(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	ev := Event{Time: now, Type: EventPreemptStop, Deploy: "app/blue"}
+	for _, tc := range []struct {
+		f    eventFilter
+		want bool
+	}{
+		{eventFilter{}, true},
+		{eventFilter{Deploy: []string{"app"}}, true},
+		{eventFilter{Deploy: []string{"app/blue"}}, true},
+		{eventFilter{Deploy: []string{"app/green"}}, false},
+		{eventFilter{Deploy: []string{"ap"}}, false},
+		{eventFilter{Types: []string{"preempt"}}, true},
+		{eventFilter{Types: []string{"preempt.stop"}}, true},
+		{eventFilter{Types: []string{"*.stop"}}, true},
+		{eventFilter{Types: []string{"pre"}}, false},
+		{eventFilter{Types: []string{"deploy", "vm"}}, false},
+		{eventFilter{Since: now.Add(-time.Minute)}, true},
+		{eventFilter{Since: now.Add(time.Minute)}, false},
+	} {
+		if got := tc.f.match(ev); got != tc.want {
+			t.Errorf("%+v.match = %v, want %v", tc.f, got, tc.want)
+		}
+	}
+	if got := eventText(Event{Type: EventCheckRun, Deploy: "bed", Result: "failed", Message: "checks failed"}); got != "check.run bed failed: checks failed" {
+		t.Errorf("eventText = %q", got)
+	}
+}
+
+func TestFollowJournal(t *testing.T) {
+	journal := filepath.Join(t.TempDir(), "journal.jsonl")
+	if err := appendEvent(journal, Event{Type: "a.seen"}); err != nil {
+		t.Fatal(err)
+	}
+	_, offset, _ := readJournalFrom(journal, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	got := make(chan string, 8)
+	done := make(chan error, 1)
+	go func() {
+		done <- followJournal(ctx, journal, offset, 10*time.Millisecond, func(ev Event) error {
+			got <- ev.Type
+			return nil
+		})
+	}()
+	next := func() string {
+		select {
+		case typ := <-got:
+			return typ
+		case <-time.After(5 * time.Second):
+			t.Fatal("no event followed")
+			return ""
+		}
+	}
+
+	if err := appendEvent(journal, Event{Type: "b.new"}); err != nil {
+		t.Fatal(err)
+	}
+	if typ := next(); typ != "b.new" {
+		t.Errorf("followed %q", typ)
+	}
+	// A partial line is held back until it is complete.
+	f, _ := os.OpenFile(journal, os.O_APPEND|os.O_WRONLY, 0o644)
+	_, _ = f.WriteString(`{"type":"c.split"`)
+	time.Sleep(50 * time.Millisecond)
+	_, _ = f.WriteString("}\n")
+	_ = f.Close()
+	if typ := next(); typ != "c.split" {
+		t.Errorf("followed %q", typ)
+	}
+	// Rotation: the new file is read from its start.
+	if err := os.Rename(journal, journal+".1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := appendEvent(journal, Event{Type: "d.rotated"}); err != nil {
+		t.Fatal(err)
+	}
+	if typ := next(); typ != "d.rotated" {
+		t.Errorf("followed %q", typ)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("followJournal = %v", err)
+	}
+}
+
+func TestJournalRestarts(t *testing.T) {
+	journal := withEventJournal(t, "")
+	journalRestarts([]DeploymentStatus{
+		{Kind: SubstratePod, Image: "app", Instance: "blue", Resources: &ResourceUsage{newRestarts: 2, LastExit: "OOM"}},
+		{Kind: SubstratePod, Image: "db", Resources: &ResourceUsage{Restarts: 5}},
+		{Kind: SubstrateVM, Image: "host", Nested: []DeploymentStatus{
+			{Kind: SubstratePod, Image: "inner", Resources: &ResourceUsage{newRestarts: 1}},
+		}},
+		{Kind: SubstrateLocal, Image: localDeployLabel(3), Container: "dev-tools", Resources: &ResourceUsage{newRestarts: 1}},
+	})
+	evs, _, _ := readJournalFrom(journal, 0)
+	if len(evs) != 3 {
+		t.Fatalf("events = %+v", evs)
+	}
+	if evs[0].Deploy != "app/blue" || evs[0].Type != EventDeployRestarted || evs[1].Deploy != "inner" || evs[2].Deploy != "dev-tools" ||
+		evs[0].Message != "restarted 2× since the last status check, last exit OOM" {
+		t.Errorf("events = %+v", evs)
+	}
+}
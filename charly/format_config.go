@@ -126,6 +126,10 @@ func (dc *DistroConfig) resolveInherits(def *DistroDef, maxDepth int) *DistroDef
 	if version == "" {
 		version = resolved.Version
 	}
+	packageArch := def.PackageArch
+	if len(packageArch) == 0 {
+		packageArch = resolved.PackageArch
+	}
 
 	if def.Bootstrap.InstallCmd != "" {
 		// Child has its own bootstrap. Merge inherited optional sub-blocks
@@ -137,6 +141,7 @@ func (dc *DistroConfig) resolveInherits(def *DistroDef, maxDepth int) *DistroDef
 		merged := &DistroDef{
 			Inherits:        def.Inherits,
 			Version:         version,
+			PackageArch:     packageArch,
 			Bootstrap:       def.Bootstrap,
 			Workarounds:     def.Workarounds,
 			Format:          formats,
@@ -158,6 +163,7 @@ func (dc *DistroConfig) resolveInherits(def *DistroDef, maxDepth int) *DistroDef
 	merged := &DistroDef{
 		Inherits:        def.Inherits,
 		Version:         version,
+		PackageArch:     packageArch,
 		Bootstrap:       resolved.Bootstrap,
 		Workarounds:     resolved.Workarounds,
 		Format:          formats,
@@ -91,24 +91,24 @@ func TestLoadDistroConfigFromFile(t *testing.T) {
 func TestAllFormatNames(t *testing.T) {
 	dc := testDistroConfig()
 	names := dc.AllFormatNames()
-	if len(names) != 4 {
-		t.Errorf("expected 4 format names, got %d: %v", len(names), names)
+	if len(names) != 5 {
+		t.Errorf("expected 5 format names, got %d: %v", len(names), names)
 	}
 	// Should be sorted
-	if names[0] != "aur" || names[1] != "deb" || names[2] != "pac" || names[3] != "rpm" {
+	if names[0] != "apk" || names[1] != "aur" || names[2] != "deb" || names[3] != "pac" || names[4] != "rpm" {
 		t.Errorf("format names not sorted: %v", names)
 	}
 }
 
 func TestValidFormat(t *testing.T) {
 	dc := testDistroConfig()
-	for _, name := range []string{"rpm", "deb", "pac", "aur"} {
+	for _, name := range []string{"rpm", "deb", "pac", "aur", "apk"} {
 		if !dc.ValidFormat(name) {
 			t.Errorf("expected format %q to be valid", name)
 		}
 	}
-	if dc.ValidFormat("apk") {
-		t.Error("apk should not be valid in default config")
+	if dc.ValidFormat("nix") {
+		t.Error("nix should not be valid in default config")
 	}
 }
 
@@ -292,7 +292,7 @@ func TestDistroDefPrimaryFormat(t *testing.T) {
 func TestFormatForDistroID(t *testing.T) {
 	cases := map[string]string{
 		"fedora": "rpm", "rhel": "rpm", "ubuntu": "deb", "debian": "deb",
-		"arch": "pac", "cachyos": "pac", "endeavouros": "pac", "unknown-distro": "",
+		"arch": "pac", "cachyos": "pac", "endeavouros": "pac", "alpine": "apk", "unknown-distro": "",
 	}
 	for id, want := range cases {
 		if got := formatForDistroID(id); got != want {
@@ -313,7 +313,7 @@ func TestDistroConfigFindFormat(t *testing.T) {
 	if err != nil {
 		t.Fatalf("LoadBuildConfigForBox: %v", err)
 	}
-	for _, f := range []string{"rpm", "deb", "pac"} {
+	for _, f := range []string{"rpm", "deb", "pac", "apk"} {
 		fd := dc.FindFormat(f)
 		if fd == nil {
 			t.Errorf("FindFormat(%q) = nil, want a FormatDef", f)
@@ -213,6 +213,9 @@ type BuildStageContext struct {
 	Options        []string // for config-detected builders (aur)
 	HasBuildScript bool     // true if candy has a build script (e.g., build.sh)
 	BuildScript    string   // build script filename
+	Main           []string // go main packages (go.mod candies)
+	Ldflags        string   // go -ldflags (go.mod candies)
+	Cross          bool     // the box targets a non-host arch (ResolvedBox.crossBuilds)
 }
 
 // RenderTemplate renders a Go text/template with the given context.
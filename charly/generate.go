@@ -26,7 +26,11 @@ type Generator struct {
 	Boxes          map[string]*ResolvedBox
 	BuildDir       string
 	Containerfiles map[string]string // cached content per image (used by charly build to pipe via stdin)
-	GlobalOrder    []string          // popularity-weighted global candy order for cache optimization
+	// ArchContainerfiles holds the per-architecture variants (box → arch →
+	// content) of boxes whose packages differ by architecture; see
+	// generateArchVariants. Absent arches build from Containerfiles.
+	ArchContainerfiles map[string]map[string]string
+	GlobalOrder        []string // popularity-weighted global candy order for cache optimization
 
 	// RequestedBoxes scopes which Containerfiles Generate() writes: when
 	// non-empty, only the named boxes and their transitive deps (Base + format
@@ -45,6 +49,11 @@ type Generator struct {
 	// a production box build leaves it false. See renderLocalPkgImageInstall.
 	DevLocalPkg bool
 
+	// Lock is the project's charly.lock (nil when absent or ignored with
+	// --unlocked): primary-format packages it records are pinned to the locked
+	// version. See box_lock.go.
+	Lock *BoxLock
+
 	// externalBuilderReplies caches each candy's external-builder OpResolve reply
 	// for ONE image: emitExternalBuilderStages populates it (writing the pre-main-FROM
 	// stage) and emitExternalBuilderArtifacts reads it (writing the post-main-FROM
@@ -215,7 +224,13 @@ func NewGenerator(dir string, tag string, opts ResolveOpts) (*Generator, error)
 		return nil, fmt.Errorf("computing global candy order: %w", err)
 	}
 
+	lock, err := LoadBoxLock(dir)
+	if err != nil {
+		return nil, err
+	}
+
 	g := &Generator{
+		Lock:           lock,
 		Dir:            dir,
 		Config:         cfg,
 		Candies:        layers,
@@ -409,7 +424,9 @@ func (g *Generator) writeContextIgnore() error {
 	return nil
 }
 
-// generateContainerfile generates a Containerfile for a single image
+// generateContainerfile generates a Containerfile for a single image, plus a
+// Containerfile.<arch> for each further target architecture whose packages
+// differ (arch-qualified candy distro: blocks).
 func (g *Generator) generateContainerfile(boxName string) error {
 	// imageDir is NOT wiped here. A destructive RemoveAll+regenerate races
 	// concurrent builds of a SHARED base image (two parallel beds both regenerate
@@ -419,6 +436,87 @@ func (g *Generator) generateContainerfile(boxName string) error {
 	// dirs for REMOVED images.
 	imageDir := filepath.Join(g.BuildDir, boxName)
 
+	content, err := g.renderContainerfile(boxName)
+	if err != nil {
+		return err
+	}
+	// Ensure the image dir exists (it is no longer wiped at function start).
+	if err := os.MkdirAll(imageDir, 0755); err != nil {
+		return err
+	}
+	g.Containerfiles[boxName] = content
+	if err := writeContainerfile(filepath.Join(imageDir, "Containerfile"), content); err != nil {
+		return err
+	}
+	return g.generateArchVariants(boxName, content)
+}
+
+// generateArchVariants renders the box for each of its target architectures
+// other than the primary one (img.Arch) and writes Containerfile.<arch> where
+// the result differs. Only consulted when some candy carries an
+// arch-qualified distro: block — otherwise every architecture shares the one
+// Containerfile and the engine's --platform does the rest.
+func (g *Generator) generateArchVariants(boxName, primary string) error {
+	img := g.Boxes[boxName]
+	arches := boxArches(img)
+	if len(arches) < 2 || img.DataImage || !g.anyArchSections() {
+		return nil
+	}
+	imageDir := filepath.Join(g.BuildDir, boxName)
+	saved := img.Arch
+	defer func() { img.Arch = saved }()
+	for _, arch := range arches {
+		if arch == saved {
+			continue
+		}
+		img.Arch = arch
+		content, err := g.renderContainerfile(boxName)
+		if err != nil {
+			return fmt.Errorf("rendering %s variant: %w", arch, err)
+		}
+		path := filepath.Join(imageDir, "Containerfile."+arch)
+		if content == primary {
+			_ = os.Remove(path) // stale variant from an earlier generate
+			continue
+		}
+		content = strings.Replace(content, "/Containerfile (generated", "/Containerfile."+arch+" (generated", 1)
+		if g.ArchContainerfiles == nil {
+			g.ArchContainerfiles = make(map[string]map[string]string)
+		}
+		if g.ArchContainerfiles[boxName] == nil {
+			g.ArchContainerfiles[boxName] = make(map[string]string)
+		}
+		g.ArchContainerfiles[boxName][arch] = content
+		if err := writeContainerfile(path, content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// anyArchSections reports whether any loaded candy has an arch-qualified
+// distro: block.
+func (g *Generator) anyArchSections() bool {
+	for _, layer := range g.Candies {
+		if layer.HasArchSections() {
+			return true
+		}
+	}
+	return false
+}
+
+// containerfileFor returns the Containerfile to build box name for platform:
+// its Containerfile.<arch> variant when one was generated, else the primary.
+func (g *Generator) containerfileFor(name, platform string) string {
+	if v, ok := g.ArchContainerfiles[name][platformArch(platform)]; ok {
+		return v
+	}
+	return g.Containerfiles[name]
+}
+
+// renderContainerfile renders the Containerfile of a single image for its
+// target architecture (img.Arch).
+func (g *Generator) renderContainerfile(boxName string) (string, error) {
 	img := g.Boxes[boxName]
 	var b strings.Builder
 
@@ -431,18 +529,18 @@ func (g *Generator) generateContainerfile(boxName string) error {
 		var err error
 		parentCandies, err = CandyProvidedByBox(img.Base, g.Boxes, g.Candies)
 		if err != nil {
-			return err
+			return "", err
 		}
 	}
 
 	candyOrder, err := g.globalOrderForBox(img.Candy, parentCandies)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	// Data images: minimal FROM scratch with only data staging + labels
 	if img.DataImage {
-		return g.generateDataImageContainerfile(boxName, img, candyOrder, imageDir)
+		return g.renderDataImageContainerfile(boxName, img, candyOrder)
 	}
 
 	// ARG for base image must come first (before any FROM). For
@@ -456,7 +554,7 @@ func (g *Generator) generateContainerfile(boxName string) error {
 
 	// Emit per-candy multi-stage build stages — fully config-driven from the embedded builder: vocabulary.
 	if err := g.emitBuilderStages(&b, boxName, img, candyOrder); err != nil {
-		return err
+		return "", err
 	}
 
 	// Emit per-candy EXTERNAL builder stages — the build-time BUILDER leg: a candy
@@ -464,7 +562,7 @@ func (g *Generator) generateContainerfile(boxName string) error {
 	// provider's OpResolve stage spliced here, pre-main-FROM (the artifacts COPY
 	// follows post-main-FROM via emitExternalBuilderArtifacts).
 	if err := g.emitExternalBuilderStages(&b, img, candyOrder); err != nil {
-		return err
+		return "", err
 	}
 
 	// Emit extraction stages for candies with extract field
@@ -475,11 +573,11 @@ func (g *Generator) generateContainerfile(boxName string) error {
 	// don't recompute.
 	caps, capsErr := AggregateCandyCapabilities(g.Candies, candyOrder)
 	if capsErr != nil {
-		return capsErr
+		return "", capsErr
 	}
 	img.CandyCaps = caps
 	if missing := CheckRequiredCapabilities(g.Candies, candyOrder, caps); len(missing) > 0 {
-		return CandyCapabilitiesError(g.Candies, candyOrder, missing)
+		return "", CandyCapabilitiesError(g.Candies, candyOrder, missing)
 	}
 
 	// Detect active init systems from candies (driven by the embedded init: vocabulary config)
@@ -495,13 +593,13 @@ func (g *Generator) generateContainerfile(boxName string) error {
 	// Detect route/traefik candies and emit the traefik-routes scratch stage.
 	hasRoutes, hasTraefik, err := g.emitTraefikRouteStage(&b, boxName, img, candyOrder)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	// Emit init system stages and learn which inits received fragment content.
 	initHasFragments, err := g.emitInitFragmentStages(&b, boxName, img, candyOrder, activeInits)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	// Main image
@@ -551,7 +649,7 @@ func (g *Generator) generateContainerfile(boxName string) error {
 	// deployed source/toolchain-less container can run an external plugin its
 	// in-container charly needs (e.g. charly-mcp → plugin-mcp for `charly mcp serve`).
 	if err := g.emitBakedPlugins(&b, boxName, candyOrder); err != nil {
-		return err
+		return "", err
 	}
 
 	// Copy extracted files from multi-stage builds
@@ -570,13 +668,13 @@ func (g *Generator) generateContainerfile(boxName string) error {
 		var werr error
 		inUserMode, werr = g.writeCandySteps(&b, candyName, img, isLast && !needsRootAfter)
 		if werr != nil {
-			return werr
+			return "", werr
 		}
 	}
 
 	// Assemble init system configs (driven by the embedded init: vocabulary templates)
 	if err := g.emitInitAssembly(&b, img, candyOrder, activeInits, initHasFragments); err != nil {
-		return err
+		return "", err
 	}
 
 	// Copy traefik dynamic routes if needed
@@ -602,16 +700,7 @@ func (g *Generator) generateContainerfile(boxName string) error {
 	// of the Containerfile.
 	g.writeLabels(&b, boxName, candyOrder, img)
 
-	// Ensure the image dir exists (it is no longer wiped at function start).
-	if err := os.MkdirAll(imageDir, 0755); err != nil {
-		return err
-	}
-
-	content := b.String()
-	g.Containerfiles[boxName] = content
-
-	containerfile := filepath.Join(imageDir, "Containerfile")
-	return writeContainerfile(containerfile, content)
+	return b.String(), nil
 }
 
 // writeContainerfile validates the rendered Containerfile (catching Go-template
@@ -665,7 +754,7 @@ func (g *Generator) emitBuilderStages(b *strings.Builder, boxName string, img *R
 	builderNames := img.BuilderConfig.BuilderNames()
 	for _, builderName := range builderNames {
 		builderDef := img.BuilderConfig.Builder[builderName]
-		if builderDef.Inline {
+		if builderDef.Inline && !g.crossBuildsInline(img, builderName, builderDef) {
 			continue // inline builders handled in writeCandySteps
 		}
 		external := externalizedBuilders[builderName]
@@ -709,6 +798,23 @@ func (g *Generator) emitBuilderStages(b *strings.Builder, boxName string, img *R
 	return nil
 }
 
+// crossBuildsInline reports whether inline builder name (cargo) renders as a
+// separate cross-compiling stage for img instead of a RUN in the main image:
+// the box targets an architecture other than the build host's and names a
+// builder image for it (`builder: {cargo: <box>}`), which the stage runs on
+// the build platform (see kit.BuilderResolve). A native build stays inline.
+func (g *Generator) crossBuildsInline(img *ResolvedBox, name string, def *BuilderDef) bool {
+	if !def.Inline || !externalizedBuilders[name] || !img.crossBuilds() {
+		return false
+	}
+	builder := img.Builder.BuilderFor(name)
+	if builder == "" || builder == img.Name {
+		return false
+	}
+	_, ok := g.Boxes[builder]
+	return ok
+}
+
 // resolveDetectionBuilder Invokes an externalized detection-builder plugin's OpResolve build
 // leg for one (candy, builder), returning the rendered BuilderResolveReply (Stage +
 // CopyArtifacts + CopyBinary). The host computes the render context host-side (buildStageContext
@@ -752,9 +858,12 @@ func builderResolveInputFrom(candyName, builderName string, builderDef *BuilderD
 		BuildScript:      ctx.BuildScript,
 		Packages:         ctx.Packages,
 		Options:          ctx.Options,
+		Main:             ctx.Main,
+		Ldflags:          ctx.Ldflags,
 		CacheMountsOwned: RenderCacheMounts(ctx.CacheMounts, ctx.UID, ctx.GID, " \\\n    ", true),
 		CacheMountsAuto:  RenderCacheMountsAuto(ctx.CacheMounts, ctx.UID, ctx.GID, " \\\n    ", false),
 		Inline:           builderDef.Inline,
+		Cross:            ctx.Cross,
 	}
 }
 
@@ -984,7 +1093,7 @@ func (g *Generator) emitBuilderArtifacts(b *strings.Builder, img *ResolvedBox, c
 	builderNames := img.BuilderConfig.BuilderNames()
 	for _, builderName := range builderNames {
 		builderDef := img.BuilderConfig.Builder[builderName]
-		if builderDef.Inline {
+		if builderDef.Inline && !g.crossBuildsInline(img, builderName, builderDef) {
 			continue
 		}
 		external := externalizedBuilders[builderName]
@@ -1210,6 +1319,18 @@ func (g *Generator) emitInitFragmentStages(b *strings.Builder, boxName string, i
 				hasFragments = true
 				break
 			}
+			// file_render: HasInit alone is not enough — a packaged-only candy
+			// binds the init but renders no file.
+			if def.Model == "file_render" && layer.HasInit(initName) {
+				rendered, err := renderCandyServices(img, candyName, layer, initName, def)
+				if err != nil {
+					return nil, err
+				}
+				if len(rendered) > 0 {
+					hasFragments = true
+					break
+				}
+			}
 		}
 		initHasFragments[initName] = hasFragments
 		if !hasFragments {
@@ -1260,16 +1381,30 @@ func (g *Generator) emitInitFragmentStages(b *strings.Builder, boxName string, i
 					b.WriteString(copyLine + "\n")
 				}
 			}
+			// File render model: copy each rendered per-service file
+			if def.Model == "file_render" && layer.HasInit(initName) {
+				rendered, err := renderCandyServices(img, candyName, layer, initName, def)
+				if err != nil {
+					return nil, err
+				}
+				for _, r := range rendered {
+					copyLine, err := initRenderStageFragmentCopy(def, boxName, r.fileName())
+					if err != nil {
+						return nil, fmt.Errorf("rendering service copy for %s/%s: %w", initName, candyName, err)
+					}
+					b.WriteString(copyLine + "\n")
+				}
+			}
 		}
 		b.WriteString("\n")
 	}
 	return initHasFragments, nil
 }
 
-// generateDataImageContainerfile produces a minimal FROM scratch Containerfile
+// renderDataImageContainerfile produces a minimal FROM scratch Containerfile
 // with only data staging COPY instructions and OCI labels. No runtime, no init,
 // no packages, no builder stages.
-func (g *Generator) generateDataImageContainerfile(boxName string, img *ResolvedBox, candyOrder []string, imageDir string) error {
+func (g *Generator) renderDataImageContainerfile(boxName string, img *ResolvedBox, candyOrder []string) (string, error) {
 	var b strings.Builder
 
 	fmt.Fprintf(&b, "# .build/%s/Containerfile (generated -- do not edit)\n\n", boxName)
@@ -1351,13 +1486,7 @@ func (g *Generator) generateDataImageContainerfile(boxName string, img *Resolved
 
 	b.WriteString("\n")
 
-	// Write to disk
-	if err := os.MkdirAll(imageDir, 0755); err != nil {
-		return err
-	}
-	content := b.String()
-	g.Containerfiles[boxName] = content
-	return writeContainerfile(filepath.Join(imageDir, "Containerfile"), content)
+	return b.String(), nil
 }
 
 // resolveBaseImage returns the full base image reference.
@@ -1705,51 +1834,13 @@ func (g *Generator) generateInitFragments(boxName, initName string, def *InitDef
 			// Concatenate every service entry in this candy that binds to this init
 			// into ONE fragment file per candy, matching the Containerfile's
 			// stage_fragment_copy naming convention (NN-<candy>.conf).
+			rendered, err := renderCandyServices(img, candyName, layer, initName, def)
+			if err != nil {
+				return err
+			}
 			var candyBuf strings.Builder
-			for j := range layer.Service() {
-				entry := &layer.Service()[j]
-				// Per-distro filter: skip entries whose distro: list excludes
-				// this box's distro (the modular virtqemud/virtnetworkd vs
-				// monolithic libvirtd split — see serviceEntryAppliesToDistro).
-				if img != nil && !serviceEntryAppliesToDistro(entry, img.Distro) {
-					continue
-				}
-				// Per-entry routing: only render entries this init can handle.
-				if entry.IsPackaged() {
-					if def.ServiceSchema == nil || !def.ServiceSchema.SupportsPackaged {
-						continue
-					}
-				} else {
-					if def.ServiceSchema == nil || def.ServiceSchema.ServiceTemplate == "" {
-						continue
-					}
-				}
-				ctx := ServiceRenderContext{
-					Name:             entry.Name,
-					Candy:            candyName,
-					Exec:             entry.Exec,
-					Env:              entry.Env,
-					EnvList:          mapToKeyValueSlice(entry.Env),
-					Restart:          entry.Restart,
-					WorkingDirectory: entry.WorkingDirectory,
-					User:             entry.User,
-					After:            entry.After,
-					Before:           entry.Before,
-					Stdout:           entry.Stdout,
-					StopTimeout:      entry.StopTimeout,
-					Scope:            entry.EffectiveScope(),
-				}
-				rendered, err := RenderService(entry, def, ctx)
-				if err != nil {
-					return fmt.Errorf("rendering service %s/%s/%s: %w", initName, candyName, entry.Name, err)
-				}
-				content := rendered.UnitText
-				if content == "" {
-					content = rendered.DropinText
-				}
-				if content == "" {
-					continue
-				}
+			for _, r := range rendered {
+				content := r.content()
 				if candyBuf.Len() > 0 && !strings.HasSuffix(candyBuf.String(), "\n\n") {
 					if !strings.HasSuffix(candyBuf.String(), "\n") {
 						candyBuf.WriteString("\n")
@@ -1770,6 +1861,20 @@ func (g *Generator) generateInitFragments(boxName, initName string, def *InitDef
 			}
 		}
 
+		// File render model: one file per service, named after its unit path
+		// (/etc/init.d/<name> → <name>), installed as-is by the assembly step.
+		if def.Model == "file_render" {
+			rendered, err := renderCandyServices(img, candyName, layer, initName, def)
+			if err != nil {
+				return err
+			}
+			for _, r := range rendered {
+				if err := atomicWriteFile(filepath.Join(fragDir, r.fileName()), []byte(r.content()), 0644); err != nil {
+					return err
+				}
+			}
+		}
+
 		// Port relay fragments (unchanged — use candy position in filename to
 		// match Containerfile's stage_fragment_copy naming).
 		if initHasRelayTemplate(def) && len(layer.PortRelayPorts) > 0 {
@@ -1803,6 +1908,58 @@ func (g *Generator) generateInitFragments(boxName, initName string, def *InitDef
 	return nil
 }
 
+// renderCandyServices renders every service: entry of one candy that binds to
+// the given init (per-entry routing: use_packaged needs supports_packaged, a
+// custom exec needs a service_template) and skips entries whose distro: list
+// excludes the box. Entries that render no body (a packaged unit without
+// overrides) are dropped — their enablement is system_enable_template's job.
+func renderCandyServices(img *ResolvedBox, candyName string, layer *Candy, initName string, def *InitDef) ([]*RenderedService, error) {
+	var out []*RenderedService
+	for j := range layer.Service() {
+		entry := &layer.Service()[j]
+		// Per-distro filter: skip entries whose distro: list excludes
+		// this box's distro (the modular virtqemud/virtnetworkd vs
+		// monolithic libvirtd split — see serviceEntryAppliesToDistro).
+		if img != nil && !serviceEntryAppliesToDistro(entry, img.Distro) {
+			continue
+		}
+		// Per-entry routing: only render entries this init can handle.
+		if entry.IsPackaged() {
+			if def.ServiceSchema == nil || !def.ServiceSchema.SupportsPackaged {
+				continue
+			}
+		} else {
+			if def.ServiceSchema == nil || def.ServiceSchema.ServiceTemplate == "" {
+				continue
+			}
+		}
+		ctx := ServiceRenderContext{
+			Name:             entry.Name,
+			Candy:            candyName,
+			Exec:             entry.Exec,
+			Env:              entry.Env,
+			EnvList:          mapToKeyValueSlice(entry.Env),
+			Restart:          entry.Restart,
+			WorkingDirectory: entry.WorkingDirectory,
+			User:             entry.User,
+			After:            entry.After,
+			Before:           entry.Before,
+			Stdout:           entry.Stdout,
+			StopTimeout:      entry.StopTimeout,
+			Scope:            entry.EffectiveScope(),
+		}
+		rendered, err := RenderService(entry, def, ctx)
+		if err != nil {
+			return nil, fmt.Errorf("rendering service %s/%s/%s: %w", initName, candyName, entry.Name, err)
+		}
+		if rendered.content() == "" {
+			continue
+		}
+		out = append(out, rendered)
+	}
+	return out, nil
+}
+
 // mapToKeyValueSlice deterministically sorts a map into []KeyValue for
 // template iteration. Matches the existing ServiceRenderContext contract.
 func mapToKeyValueSlice(m map[string]string) []KeyValue {
@@ -1853,6 +2010,7 @@ func (g *Generator) writeCandySteps(b *strings.Builder, candyName string, img *R
 	if pkgs, raw, matched := resolveCascadePackages(layer, img); (matched || len(pkgs) > 0) && img.DistroDef != nil {
 		if formatDef := img.DistroDef.Format[img.Pkg]; formatDef != nil {
 			ctx := NewInstallContext(raw, formatDef.CacheMount)
+			ctx.Packages = g.pinPackages(img, formatDef, ctx.Packages)
 			if rendered, err := RenderTemplate(img.Pkg+"-install", formatDef.InstallTemplate, ctx); err == nil {
 				b.WriteString(rendered)
 			}
@@ -1941,7 +2099,7 @@ func (g *Generator) writeCandySteps(b *strings.Builder, candyName string, img *R
 	if img.BuilderConfig != nil {
 		for _, bName := range img.BuilderConfig.BuilderNames() {
 			bDef := img.BuilderConfig.Builder[bName]
-			if !bDef.Inline {
+			if !bDef.Inline || g.crossBuildsInline(img, bName, bDef) {
 				continue
 			}
 			external := externalizedBuilders[bName]
@@ -2138,6 +2296,7 @@ func (g *Generator) buildStageContext(layer *Candy, builderName string, builderD
 		Home:        img.Home,
 		User:        img.User,
 		CacheMounts: builderDef.CacheMount,
+		Cross:       img.crossBuilds(),
 	}
 
 	// Resolve manifest and install command for file-detected builders (pixi)
@@ -2189,6 +2348,12 @@ func (g *Generator) buildStageContext(layer *Candy, builderName string, builderD
 		}
 	}
 
+	// For go.mod candies, the main packages + ldflags come from the candy's go: block
+	if layer.GoBuildable() {
+		ctx.Main = layer.GoMain()
+		ctx.Ldflags = layer.GoLdflags()
+	}
+
 	return ctx
 }
 
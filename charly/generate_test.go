@@ -330,6 +330,72 @@ func TestGenerateInitFragments(t *testing.T) {
 	}
 }
 
+// file_render (openrc): every rendered service lands in its own file named after
+// its unit path, and the scratch stage COPYs exactly those files. A candy whose
+// only entry is packaged binds the init but contributes no file.
+func TestGenerateInitFragments_FileRender(t *testing.T) {
+	tmpDir := t.TempDir()
+	g := &Generator{
+		BuildDir: tmpDir,
+		Candies: map[string]*Candy{
+			"web": {
+				Name:        "web",
+				InitSystems: map[string]bool{"openrc": true},
+				service: []ServiceEntry{
+					{Name: "web", Exec: "web serve"},
+					{Name: "web-worker", Exec: "web work"},
+				},
+			},
+			"sshd": {
+				Name:        "sshd",
+				InitSystems: map[string]bool{"openrc": true},
+				service:     []ServiceEntry{{Name: "sshd", UsePackaged: "sshd.service"}},
+			},
+		},
+		Boxes: map[string]*ResolvedBox{"tiny": {Name: "tiny"}},
+	}
+	openrcDef := &InitDef{
+		Model:             "file_render",
+		FragmentDir:       "openrc",
+		StageName:         "openrc-services",
+		StageFragmentCopy: "COPY .build/{{.BoxName}}/{{.FragmentDir}}/{{.FileName}} /openrc/{{.FileName}}",
+		ServiceSchema: &ServiceSchemaDef{
+			SupportsPackaged: true,
+			ServiceTemplate:  "#!/sbin/openrc-run\ncommand_args={{shquote .Exec}}\n",
+			UnitPathTemplate: "/etc/init.d/{{.Name}}",
+		},
+	}
+
+	var b strings.Builder
+	has, err := g.emitInitFragmentStages(&b, "tiny", g.Boxes["tiny"], []string{"sshd", "web"}, map[string]*InitDef{"openrc": openrcDef})
+	if err != nil {
+		t.Fatalf("emitInitFragmentStages() error = %v", err)
+	}
+	if !has["openrc"] {
+		t.Fatal("openrc should report fragments")
+	}
+	stage := b.String()
+	for _, want := range []string{
+		"FROM scratch AS openrc-services\n",
+		"COPY .build/tiny/openrc/web /openrc/web\n",
+		"COPY .build/tiny/openrc/web-worker /openrc/web-worker\n",
+	} {
+		if !strings.Contains(stage, want) {
+			t.Errorf("stage missing %q; got:\n%s", want, stage)
+		}
+	}
+	if strings.Contains(stage, "/openrc/sshd") {
+		t.Errorf("packaged sshd renders no script and must not be copied; got:\n%s", stage)
+	}
+	data, err := os.ReadFile(tmpDir + "/tiny/openrc/web-worker")
+	if err != nil {
+		t.Fatalf("reading rendered web-worker script: %v", err)
+	}
+	if got := string(data); got != "#!/sbin/openrc-run\ncommand_args='web work'\n" {
+		t.Errorf("web-worker script = %q", got)
+	}
+}
+
 func TestGenerateRelayInitFragments(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -484,6 +550,35 @@ func TestPacTemplateBasic(t *testing.T) {
 	}
 }
 
+func TestApkTemplateWithRepo(t *testing.T) {
+	alpine := testDistroDef("alpine")
+	apk := alpine.Format["apk"]
+	ctx := NewInstallContext(map[string]any{
+		"package": []any{"curl", "jq@edge"},
+		"repo": []any{map[string]any{
+			"url": "https://dl-cdn.alpinelinux.org/alpine/edge/community",
+			"tag": "edge",
+			"key": "https://example.test/keys/edge-4a6a0840.rsa.pub",
+		}},
+	}, apk.CacheMount)
+	out, err := RenderTemplate("apk-test", apk.InstallTemplate, ctx)
+	if err != nil {
+		t.Fatalf("render error: %v", err)
+	}
+	for _, want := range []string{
+		`wget -qP /etc/apk/keys "https://example.test/keys/edge-4a6a0840.rsa.pub" && \`,
+		`echo "@edge https://dl-cdn.alpinelinux.org/alpine/edge/community" >> /etc/apk/repositories && \`,
+		"apk add --no-cache \\\n      curl \\\n      jq@edge",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("apk install step missing %q; got:\n%s", want, out)
+		}
+	}
+	if !strings.HasPrefix(out, "RUN \\\n") {
+		t.Errorf("apk install step should open a plain RUN; got:\n%s", out)
+	}
+}
+
 func TestAurBuilderStageTemplate(t *testing.T) {
 	builderCfg := testBuilderCfg()
 	aurBuilder := builderCfg.Builder["aur"]
@@ -196,7 +196,8 @@ func ResolveCandyOrder(requested []string, layers map[string]*Candy, parentCandi
 }
 
 // BoxNeedsBuilder returns true if any of the box's own resolved candies
-// (excluding parent-provided) have pixi.toml, package.json, or Cargo.toml.
+// (excluding parent-provided) have pixi.toml, package.json, Cargo.toml, or a
+// go.mod outside a plugin candy.
 // When candies is nil, falls back to unconditional builder dependency.
 func BoxNeedsBuilder(img *ResolvedBox, boxes map[string]*ResolvedBox, layers map[string]*Candy) bool {
 	if layers == nil {
@@ -225,7 +226,7 @@ func BoxNeedsBuilder(img *ResolvedBox, boxes map[string]*ResolvedBox, layers map
 			continue
 		}
 		// Check file-based builder triggers
-		if layer.PixiManifest() != "" || layer.HasPackageJson || layer.HasCargoToml {
+		if layer.PixiManifest() != "" || layer.HasPackageJson || layer.HasCargoToml || layer.GoBuildable() {
 			return true
 		}
 		// Check config-based builder triggers (any format with a matching builder)
@@ -15,6 +15,14 @@ type BoxCmd struct {
 	Build    BuildCmd      `cmd:"" help:"Build container boxes"`
 	Generate GenerateCmd   `cmd:"" help:"Write .build/ (Containerfiles) for the named boxes (default: all enabled)"`
 	Inspect  InspectCmd    `cmd:"" help:"Print resolved config for a box (JSON)"`
+	Keygen   BoxKeygenCmd  `cmd:"" help:"Generate an image-signing key pair in the credential store (writes <name>.pub)"`
+	Sign     BoxSignCmd    `cmd:"" help:"Sign a pushed box image (or an oci:<dir> layout) with a credential-store key (cosign format)"`
+	Verify   BoxVerifyCmd  `cmd:"" help:"Verify a box image's signature against the trust policy's keys"`
+	Sbom     BoxSbomCmd    `cmd:"" help:"Print the SPDX or CycloneDX SBOM of a built box (distro packages, builder outputs, localpkg, downloads — each with its candy)"`
+	Audit    BoxAuditCmd   `cmd:"" help:"Report known vulnerabilities of a built box's packages from a local OSV database, per candy (--fail-on gates on severity)"`
+	Diff     BoxDiffCmd    `cmd:"" help:"Compare two built versions of a box: ai.opencharly.* labels (candies, ports, volumes, services, secrets, plan steps), package sets and files (text or json)"`
+	Lock     BoxLockCmd    `cmd:"" help:"Record the package versions of built boxes in charly.lock (generate pins to it; --refresh rebuilds unpinned first)"`
+	Graph    BoxGraphCmd   `cmd:"" help:"Render the candy, box and intermediate build graph with its parallel levels (dot, mermaid or json)"`
 	List     ListCmd       `cmd:"" help:"List components from charly.yml"`
 	Merge    MergeCmd      `cmd:"" help:"Merge small layers in a built container image"`
 	New      NewCmd        `cmd:"" help:"Scaffold new components"`
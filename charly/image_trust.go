@@ -0,0 +1,676 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/match"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+// image_trust.go — image signing and the trust policy.
+//
+// Signatures use cosign's key-based format, so `cosign verify --key <name>.pub`
+// accepts what charly signs and vice versa: a "simple signing" JSON payload
+// naming the subject's manifest digest, signed with ECDSA P-256 over its
+// SHA-256, stored as a layer (signature base64 in the layer annotation) of the
+// OCI image tagged `sha256-<hex>.sig` next to the subject. Signing keys live in
+// the credential store under charly/signing (private PKCS#8 PEM at <name>, the
+// public PEM at <name>.pub); `charly box keygen` creates them.
+//
+// A subject is a registry ref or an OCI image layout directory
+// (`oci:<dir>[:<tag>]`), so signing and verification work fully offline.
+//
+// The policy is the `trust:` block (TrustConfig) of a box, else of `defaults:`.
+// EnsureImagePresent — the pull seam behind `charly box pull`, `charly bundle
+// add` and `charly bundle from-box` — verifies before it pulls (and then pulls
+// the verified digest), and re-verifies a present image that came from a
+// registry. An image with no registry digest is trusted only when the local
+// build index (build_hash.go) records charly building that exact image, or
+// when the policy sets allow_local.
+
+const (
+	signingCredService        = "charly/signing"
+	cosignPayloadMediaType    = "application/vnd.dev.cosignproject.cosign/simplesigning.v1+json"
+	cosignSignatureAnnotation = "dev.cosignproject.cosign/signature"
+	cosignSignatureType       = "cosign container image signature"
+	ociRefNameAnnotation      = "org.opencontainers.image.ref.name"
+	ociLayoutRefPrefix        = "oci:"
+)
+
+// Trust policy modes (TrustConfig.Policy).
+const (
+	trustOff     = "off"
+	trustWarn    = "warn"
+	trustEnforce = "enforce"
+)
+
+// errUnsigned reports a subject with no signature image at all.
+var errUnsigned = errors.New("image is not signed")
+
+// BoxKeygenCmd implements `charly box keygen`.
+type BoxKeygenCmd struct {
+	Name   string `arg:"" optional:"" default:"default" help:"Signing key name in the credential store"`
+	Output string `short:"o" long:"output" help:"Also write the public key to this file (default: <name>.pub in the current directory)"`
+	Force  bool   `long:"force" help:"Replace an existing key of the same name"`
+}
+
+func (c *BoxKeygenCmd) Run() error {
+	store := DefaultCredentialStore()
+	if !c.Force {
+		if v, _ := store.Get(signingCredService, c.Name); v != "" {
+			return fmt.Errorf("signing key %q already exists (use --force to replace it)", c.Name)
+		}
+	}
+	pub, err := generateSigningKey(store, c.Name)
+	if err != nil {
+		return err
+	}
+	out := c.Output
+	if out == "" {
+		out = c.Name + ".pub"
+	}
+	if err := os.WriteFile(out, pub, 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Generated signing key %q in %s (public key: %s)\n", c.Name, store.Name(), out)
+	return nil
+}
+
+// BoxSignCmd implements `charly box sign`.
+type BoxSignCmd struct {
+	Ref string `arg:"" help:"Box name (signs its pushed tag), full registry ref, or oci:<dir>[:<tag>]"`
+	Key string `long:"key" default:"default" help:"Signing key name in the credential store"`
+}
+
+func (c *BoxSignCmd) Run() error {
+	ref, err := trustSubjectRef(c.Ref)
+	if err != nil {
+		return err
+	}
+	d, err := signImageRef(ref, c.Key)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Signed %s (%s) with key %q\n", ref, d, c.Key)
+	return nil
+}
+
+// BoxVerifyCmd implements `charly box verify`.
+type BoxVerifyCmd struct {
+	Ref string   `arg:"" help:"Box name (verifies its pushed tag), full registry ref, or oci:<dir>[:<tag>]"`
+	Key []string `long:"key" help:"Trusted key (credential-store name or PEM file); repeatable (default: the trust policy's keys)"`
+}
+
+func (c *BoxVerifyCmd) Run() error {
+	dir, _ := os.Getwd()
+	cfg, _ := LoadConfig(dir)
+	ref, err := trustSubjectRef(c.Ref)
+	if err != nil {
+		return err
+	}
+	keys := c.Key
+	if len(keys) == 0 {
+		if p := cfg.trustPolicyForRef(ref); p != nil {
+			keys = p.Key
+		}
+	}
+	if len(keys) == 0 {
+		return fmt.Errorf("no trusted keys: pass --key or set trust.key in charly.yml")
+	}
+	pubs, err := loadTrustKeys(keys, dir)
+	if err != nil {
+		return err
+	}
+	repo, err := openSignatureRepo(ref)
+	if err != nil {
+		return err
+	}
+	d, err := verifyImageSignature(repo, pubs)
+	if err != nil {
+		return fmt.Errorf("%s: %w", ref, err)
+	}
+	fmt.Printf("%s@%s: verified\n", strings.TrimPrefix(repo.identity(), ociLayoutRefPrefix), d)
+	return nil
+}
+
+// trustSubjectRef maps a box name to its pushed tag; full and oci: refs pass.
+func trustSubjectRef(input string) (string, error) {
+	if strings.HasPrefix(input, ociLayoutRefPrefix) || looksLikeFullRef(input) {
+		return input, nil
+	}
+	dir, _ := os.Getwd()
+	cfg, err := LoadConfig(dir)
+	if err != nil {
+		return "", fmt.Errorf("short name %q requires a project directory with charly.yml: %w", input, err)
+	}
+	resolved, err := cfg.ResolveBox(input, "", dir, ResolveOpts{})
+	if err != nil {
+		return "", err
+	}
+	return resolved.FullTag, nil
+}
+
+// --- keys ---
+
+// generateSigningKey creates an ECDSA P-256 key pair, stores both halves in
+// the credential store and returns the public PEM.
+func generateSigningKey(store CredentialStore, keyName string) ([]byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	priv := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	pub := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER})
+	if err := store.Set(signingCredService, keyName, string(priv)); err != nil {
+		return nil, fmt.Errorf("storing signing key %q: %w", keyName, err)
+	}
+	if err := store.Set(signingCredService, keyName+".pub", string(pub)); err != nil {
+		return nil, fmt.Errorf("storing public key %q: %w", keyName, err)
+	}
+	return pub, nil
+}
+
+// loadSigningKey reads a private signing key from the credential store.
+func loadSigningKey(keyName string) (*ecdsa.PrivateKey, error) {
+	v, err := DefaultCredentialStore().Get(signingCredService, keyName)
+	if err != nil || v == "" {
+		return nil, fmt.Errorf("signing key %q not found in the credential store (run `charly box keygen %s`)", keyName, keyName)
+	}
+	block, _ := pem.Decode([]byte(v))
+	if block == nil {
+		return nil, fmt.Errorf("signing key %q: not PEM", keyName)
+	}
+	k, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("signing key %q: %w", keyName, err)
+	}
+	ec, ok := k.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("signing key %q: not an ECDSA key", keyName)
+	}
+	return ec, nil
+}
+
+// loadTrustKeys resolves trust.key entries: a PEM file (an entry with a path
+// separator or a .pub/.pem suffix, relative to dir) or a credential-store name.
+func loadTrustKeys(keys []string, dir string) ([]*ecdsa.PublicKey, error) {
+	var out []*ecdsa.PublicKey
+	for _, k := range keys {
+		var data []byte
+		if strings.ContainsRune(k, os.PathSeparator) || strings.HasSuffix(k, ".pub") || strings.HasSuffix(k, ".pem") {
+			path := k
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(dir, path)
+			}
+			b, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("trust key %q: %w", k, err)
+			}
+			data = b
+		} else {
+			v, err := DefaultCredentialStore().Get(signingCredService, k+".pub")
+			if err != nil || v == "" {
+				return nil, fmt.Errorf("trust key %q not found in the credential store", k)
+			}
+			data = []byte(v)
+		}
+		block, _ := pem.Decode(data)
+		if block == nil {
+			return nil, fmt.Errorf("trust key %q: not PEM", k)
+		}
+		pk, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("trust key %q: %w", k, err)
+		}
+		ec, ok := pk.(*ecdsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("trust key %q: not an ECDSA public key", k)
+		}
+		out = append(out, ec)
+	}
+	return out, nil
+}
+
+// --- signature storage ---
+
+// signatureRepo is where a subject and its signature image live.
+type signatureRepo interface {
+	// digest resolves the subject's manifest (or index) digest.
+	digest() (v1.Hash, error)
+	// identity is the docker-reference recorded in signed payloads.
+	identity() string
+	// signatures returns the signature image of d, nil when there is none.
+	signatures(d v1.Hash) (v1.Image, error)
+	writeSignatures(d v1.Hash, img v1.Image) error
+}
+
+// signatureTag is cosign's tag for the signatures of d.
+func signatureTag(d v1.Hash) string {
+	return d.Algorithm + "-" + d.Hex + ".sig"
+}
+
+// openSignatureRepo opens a registry ref or an oci:<dir>[:<tag>] layout.
+func openSignatureRepo(ref string) (signatureRepo, error) {
+	if rest, ok := strings.CutPrefix(ref, ociLayoutRefPrefix); ok {
+		dir, tag := rest, ""
+		if i := strings.LastIndex(rest, ":"); i > strings.LastIndex(rest, "/") {
+			dir, tag = rest[:i], rest[i+1:]
+		}
+		p, err := layout.FromPath(dir)
+		if err != nil {
+			return nil, fmt.Errorf("opening OCI layout %s: %w", dir, err)
+		}
+		return &layoutSignatureRepo{path: p, tag: tag}, nil
+	}
+	r, err := name.ParseReference(ref)
+	if err != nil {
+		return nil, fmt.Errorf("parsing reference %q: %w", ref, err)
+	}
+	return &registrySignatureRepo{ref: r, opts: []remote.Option{remote.WithAuthFromKeychain(authn.DefaultKeychain)}}, nil
+}
+
+type registrySignatureRepo struct {
+	ref  name.Reference
+	opts []remote.Option
+}
+
+func (r *registrySignatureRepo) digest() (v1.Hash, error) {
+	desc, err := remote.Head(r.ref, r.opts...)
+	if err != nil {
+		return v1.Hash{}, fmt.Errorf("resolving %s: %w", r.ref, err)
+	}
+	return desc.Digest, nil
+}
+
+func (r *registrySignatureRepo) identity() string { return r.ref.Context().Name() }
+
+func (r *registrySignatureRepo) signatures(d v1.Hash) (v1.Image, error) {
+	img, err := remote.Image(r.ref.Context().Tag(signatureTag(d)), r.opts...)
+	var terr *transport.Error
+	if errors.As(err, &terr) && terr.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	return img, err
+}
+
+func (r *registrySignatureRepo) writeSignatures(d v1.Hash, img v1.Image) error {
+	return remote.Write(r.ref.Context().Tag(signatureTag(d)), img, r.opts...)
+}
+
+type layoutSignatureRepo struct {
+	path layout.Path
+	tag  string
+}
+
+func (l *layoutSignatureRepo) digest() (v1.Hash, error) {
+	idx, err := l.path.ImageIndex()
+	if err != nil {
+		return v1.Hash{}, err
+	}
+	im, err := idx.IndexManifest()
+	if err != nil {
+		return v1.Hash{}, err
+	}
+	var found []v1.Descriptor
+	for _, desc := range im.Manifests {
+		ref := desc.Annotations[ociRefNameAnnotation]
+		if strings.HasSuffix(ref, ".sig") {
+			continue
+		}
+		if l.tag == "" || ref == l.tag {
+			found = append(found, desc)
+		}
+	}
+	switch {
+	case len(found) == 1:
+		return found[0].Digest, nil
+	case l.tag == "" && len(found) > 1:
+		return v1.Hash{}, fmt.Errorf("OCI layout %s holds %d images; name one with oci:<dir>:<tag>", l.path, len(found))
+	default:
+		return v1.Hash{}, fmt.Errorf("OCI layout %s: no image tagged %q", l.path, l.tag)
+	}
+}
+
+func (l *layoutSignatureRepo) identity() string { return ociLayoutRefPrefix + string(l.path) }
+
+func (l *layoutSignatureRepo) signatures(d v1.Hash) (v1.Image, error) {
+	idx, err := l.path.ImageIndex()
+	if err != nil {
+		return nil, err
+	}
+	im, err := idx.IndexManifest()
+	if err != nil {
+		return nil, err
+	}
+	for _, desc := range im.Manifests {
+		if desc.Annotations[ociRefNameAnnotation] == signatureTag(d) {
+			return idx.Image(desc.Digest)
+		}
+	}
+	return nil, nil
+}
+
+func (l *layoutSignatureRepo) writeSignatures(d v1.Hash, img v1.Image) error {
+	tag := signatureTag(d)
+	return l.path.ReplaceImage(img, match.Annotation(ociRefNameAnnotation, tag),
+		layout.WithAnnotations(map[string]string{ociRefNameAnnotation: tag}))
+}
+
+// --- sign / verify ---
+
+// cosignPayload is the "simple signing" document cosign signs.
+type cosignPayload struct {
+	Critical struct {
+		Identity struct {
+			DockerReference string `json:"docker-reference"`
+		} `json:"identity"`
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+		Type string `json:"type"`
+	} `json:"critical"`
+	Optional map[string]string `json:"optional"`
+}
+
+// signImageRef signs ref with a credential-store key.
+func signImageRef(ref, keyName string) (v1.Hash, error) {
+	key, err := loadSigningKey(keyName)
+	if err != nil {
+		return v1.Hash{}, err
+	}
+	repo, err := openSignatureRepo(ref)
+	if err != nil {
+		return v1.Hash{}, err
+	}
+	return signImage(repo, key)
+}
+
+// signImage appends a signature of the subject to its signature image.
+func signImage(repo signatureRepo, key *ecdsa.PrivateKey) (v1.Hash, error) {
+	d, err := repo.digest()
+	if err != nil {
+		return v1.Hash{}, err
+	}
+	var p cosignPayload
+	p.Critical.Identity.DockerReference = repo.identity()
+	p.Critical.Image.DockerManifestDigest = d.String()
+	p.Critical.Type = cosignSignatureType
+	payload, err := json.Marshal(p)
+	if err != nil {
+		return v1.Hash{}, err
+	}
+	sum := sha256.Sum256(payload)
+	sig, err := ecdsa.SignASN1(rand.Reader, key, sum[:])
+	if err != nil {
+		return v1.Hash{}, err
+	}
+
+	base, err := repo.signatures(d)
+	if err != nil {
+		return v1.Hash{}, err
+	}
+	if base == nil {
+		base = mutate.ConfigMediaType(mutate.MediaType(empty.Image, types.OCIManifestSchema1), types.OCIConfigJSON)
+	}
+	img, err := mutate.Append(base, mutate.Addendum{
+		Layer:       static.NewLayer(payload, cosignPayloadMediaType),
+		Annotations: map[string]string{cosignSignatureAnnotation: base64.StdEncoding.EncodeToString(sig)},
+	})
+	if err != nil {
+		return v1.Hash{}, err
+	}
+	return d, repo.writeSignatures(d, img)
+}
+
+// verifyImageSignature checks that the subject carries a signature by one of
+// keys over a payload naming its digest. Returns the verified digest.
+func verifyImageSignature(repo signatureRepo, keys []*ecdsa.PublicKey) (v1.Hash, error) {
+	d, err := repo.digest()
+	if err != nil {
+		return v1.Hash{}, err
+	}
+	sigs, err := repo.signatures(d)
+	if err != nil {
+		return v1.Hash{}, fmt.Errorf("reading signatures: %w", err)
+	}
+	if sigs == nil {
+		return v1.Hash{}, errUnsigned
+	}
+	m, err := sigs.Manifest()
+	if err != nil {
+		return v1.Hash{}, err
+	}
+	for _, desc := range m.Layers {
+		if desc.MediaType != cosignPayloadMediaType {
+			continue
+		}
+		sig, err := base64.StdEncoding.DecodeString(desc.Annotations[cosignSignatureAnnotation])
+		if err != nil {
+			continue
+		}
+		layer, err := sigs.LayerByDigest(desc.Digest)
+		if err != nil {
+			return v1.Hash{}, err
+		}
+		rc, err := layer.Compressed()
+		if err != nil {
+			return v1.Hash{}, err
+		}
+		payload, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return v1.Hash{}, err
+		}
+		var p cosignPayload
+		if json.Unmarshal(payload, &p) != nil || p.Critical.Type != cosignSignatureType || p.Critical.Image.DockerManifestDigest != d.String() {
+			continue
+		}
+		sum := sha256.Sum256(payload)
+		for _, k := range keys {
+			if ecdsa.VerifyASN1(k, sum[:], sig) {
+				return d, nil
+			}
+		}
+	}
+	return v1.Hash{}, fmt.Errorf("no signature of %s by a trusted key", d)
+}
+
+// --- policy ---
+
+// trustPolicy returns the effective trust: block of a box — its own, else the
+// project default. Nil (or policy off) means no verification.
+func (c *Config) trustPolicy(box string) *TrustConfig {
+	if c == nil {
+		return nil
+	}
+	if b, ok := c.Box[box]; ok && b.Trust != nil {
+		return b.Trust
+	}
+	return c.Defaults.Trust
+}
+
+// trustPolicyForRef maps an image ref to the box whose policy applies (by its
+// basename, like the build fallback) and returns that policy.
+func (c *Config) trustPolicyForRef(ref string) *TrustConfig {
+	if c == nil {
+		return nil
+	}
+	return c.trustPolicy(buildableShortName(ref, c))
+}
+
+// enforceImageTrust verifies ref against its trust policy. It returns the
+// verified digest ref (repo@sha256:…) to pull, or "" when no policy applies or
+// a warn policy let an unverified image through.
+func enforceImageTrust(ref string, cfg *Config, projectDir string) (string, error) {
+	return applyTrustPolicy(cfg.trustPolicyForRef(ref), ref, projectDir)
+}
+
+func applyTrustPolicy(policy *TrustConfig, ref, projectDir string) (string, error) {
+	if policy == nil || policy.Policy == "" || policy.Policy == trustOff {
+		return "", nil
+	}
+	verified, err := verifyTrustedRef(ref, policy, projectDir)
+	if err != nil {
+		if policy.Policy == trustWarn {
+			fmt.Fprintf(os.Stderr, "Warning: trust: %s: %v\n", ref, err)
+			return "", nil
+		}
+		return "", fmt.Errorf("trust policy rejects %s: %w", ref, err)
+	}
+	fmt.Fprintf(os.Stderr, "trust: %s verified (%s)\n", ref, verified)
+	return verified, nil
+}
+
+func verifyTrustedRef(ref string, policy *TrustConfig, projectDir string) (string, error) {
+	if len(policy.Key) == 0 {
+		return "", fmt.Errorf("trust policy %q lists no keys", policy.Policy)
+	}
+	keys, err := loadTrustKeys(policy.Key, projectDir)
+	if err != nil {
+		return "", err
+	}
+	repo, err := openSignatureRepo(ref)
+	if err != nil {
+		return "", err
+	}
+	d, err := verifyImageSignature(repo, keys)
+	if err != nil {
+		return "", err
+	}
+	return repo.identity() + "@" + d.String(), nil
+}
+
+// enforceLocalImageTrust re-verifies a present local image against its trust
+// policy — see applyLocalTrustPolicy.
+func enforceLocalImageTrust(ref string, cfg *Config, projectDir string) error {
+	return applyLocalTrustPolicy(cfg.trustPolicyForRef(ref), ref, projectDir)
+}
+
+// localImage is the part of `podman image inspect` the local trust check reads.
+type localImage struct {
+	ID          string            `json:"Id"`
+	RepoDigests []string          `json:"RepoDigests"`
+	Labels      map[string]string `json:"Labels"`
+}
+
+func parseLocalImageInspect(out []byte) (localImage, error) {
+	var inspect []localImage
+	if err := json.Unmarshal(out, &inspect); err != nil {
+		return localImage{}, fmt.Errorf("parsing image inspect: %w", err)
+	}
+	if len(inspect) == 0 {
+		return localImage{}, fmt.Errorf("image inspect returned nothing")
+	}
+	return inspect[0], nil
+}
+
+// localBuildRecorded reports whether the build index holds img's build hash
+// for a ref that still resolves to img's own ID — a recorded `charly box build`
+// of this very image, not just an image claiming the label.
+func localBuildRecorded(img localImage) bool {
+	hash := img.Labels[LabelBuildHash]
+	if hash == "" || img.ID == "" {
+		return false
+	}
+	e, ok := loadBuildIndex()[hash]
+	if !ok {
+		return false
+	}
+	out, err := localImageInspect(e.Ref)
+	if err != nil {
+		return false
+	}
+	built, err := parseLocalImageInspect(out)
+	return err == nil && built.ID == img.ID
+}
+
+// localImageInspect returns `podman image inspect` JSON for ref.
+// Package-level for test injection.
+var localImageInspect = func(ref string) ([]byte, error) {
+	return exec.Command("podman", "image", "inspect", ref).Output()
+}
+
+// applyLocalTrustPolicy verifies a local image: one of its repo digests in
+// ref's repository must verify. An image with no repo digest at all passes
+// only under allow_local, or when localBuildRecorded finds it in the build
+// index — a label alone proves nothing, anyone can set one at build time.
+// Everything the check cannot establish (unparsable ref, failed inspect, a
+// digest-less foreign image) fails an enforce policy and warns under warn.
+func applyLocalTrustPolicy(policy *TrustConfig, ref, projectDir string) error {
+	if policy == nil || policy.Policy == "" || policy.Policy == trustOff {
+		return nil
+	}
+	reject := func(err error) error {
+		if policy.Policy == trustWarn {
+			fmt.Fprintf(os.Stderr, "Warning: trust: local %s: %v\n", ref, err)
+			return nil
+		}
+		return fmt.Errorf("trust policy rejects local %s: %w", ref, err)
+	}
+	r, err := name.ParseReference(ref)
+	if err != nil {
+		return reject(err)
+	}
+	out, err := localImageInspect(ref)
+	if err != nil {
+		return reject(fmt.Errorf("inspecting image: %w", err))
+	}
+	img, err := parseLocalImageInspect(out)
+	if err != nil {
+		return reject(err)
+	}
+	if len(img.RepoDigests) == 0 {
+		if policy.AllowLocal || localBuildRecorded(img) {
+			return nil
+		}
+		return reject(fmt.Errorf("no repo digest and no local build record (set trust.allow_local to accept local images)"))
+	}
+	var candidates []string
+	for _, d := range img.RepoDigests {
+		if strings.HasPrefix(d, r.Context().Name()+"@") {
+			candidates = append(candidates, d)
+		}
+	}
+	if len(candidates) == 0 {
+		return reject(fmt.Errorf("no repo digest in %s (have %s)", r.Context().Name(), strings.Join(img.RepoDigests, ", ")))
+	}
+	var lastErr error
+	for _, d := range candidates {
+		if _, lastErr = verifyTrustedRef(d, policy, projectDir); lastErr == nil {
+			return nil
+		}
+	}
+	return reject(lastErr)
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"io"
+	"log"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// testSubjectLayout writes a random image tagged v1 into a fresh OCI layout
+// and returns its oci: ref and digest.
+func testSubjectLayout(t *testing.T) (string, v1.Hash) {
+	t.Helper()
+	dir := t.TempDir()
+	img, err := random.Image(256, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := layout.Write(dir, empty.Index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.AppendImage(img, layout.WithAnnotations(map[string]string{ociRefNameAnnotation: "v1"})); err != nil {
+		t.Fatal(err)
+	}
+	d, _ := img.Digest()
+	return ociLayoutRefPrefix + dir + ":v1", d
+}
+
+func pushTestImage(ref string, img v1.Image) error {
+	r, err := name.ParseReference(ref)
+	if err != nil {
+		return err
+	}
+	return remote.Write(r, img)
+}
+
+func TestSignVerifyRegistry(t *testing.T) {
+	store := installFakeCredentialStore(t)
+	if _, err := generateSigningKey(store, "ci"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := generateSigningKey(store, "other"); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "http://")
+	img, _ := random.Image(256, 1)
+	ref := host + "/charly/app:v1"
+	if err := pushTestImage(ref, img); err != nil {
+		t.Fatal(err)
+	}
+	unsigned := host + "/charly/app:v2"
+	img2, _ := random.Image(256, 1)
+	if err := pushTestImage(unsigned, img2); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := signImageRef(ref, "ci")
+	if err != nil {
+		t.Fatalf("signImageRef: %v", err)
+	}
+	if want, _ := img.Digest(); d != want {
+		t.Errorf("signed digest = %s, want %s", d, want)
+	}
+	// A second signer appends to the same signature image.
+	if _, err := signImageRef(ref, "other"); err != nil {
+		t.Fatalf("second signImageRef: %v", err)
+	}
+
+	repo, err := openSignatureRepo(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range []string{"ci", "other"} {
+		keys, err := loadTrustKeys([]string{k}, t.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := verifyImageSignature(repo, keys); err != nil {
+			t.Errorf("verify with %s: %v", k, err)
+		}
+	}
+
+	stranger, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if _, err := verifyImageSignature(repo, []*ecdsa.PublicKey{&stranger.PublicKey}); err == nil {
+		t.Error("verify with an untrusted key should fail")
+	}
+	urepo, _ := openSignatureRepo(unsigned)
+	if _, err := verifyImageSignature(urepo, []*ecdsa.PublicKey{&stranger.PublicKey}); !errors.Is(err, errUnsigned) {
+		t.Errorf("unsigned image: err = %v, want errUnsigned", err)
+	}
+}
+
+func TestSignVerifyLayout(t *testing.T) {
+	store := installFakeCredentialStore(t)
+	pub, err := generateSigningKey(store, "ci")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref, want := testSubjectLayout(t)
+	d, err := signImageRef(ref, "ci")
+	if err != nil {
+		t.Fatalf("signImageRef: %v", err)
+	}
+	if d != want {
+		t.Errorf("signed digest = %s, want %s", d, want)
+	}
+
+	// Trust keys may also be PEM files relative to the project.
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "keys"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "keys", "ci.pub"), pub, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	keys, err := loadTrustKeys([]string{"keys/ci.pub"}, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, _ := openSignatureRepo(ref)
+	if _, err := verifyImageSignature(repo, keys); err != nil {
+		t.Errorf("verify: %v", err)
+	}
+	// The untagged form picks the only image (signatures are not subjects).
+	untagged, _ := openSignatureRepo(strings.TrimSuffix(ref, ":v1"))
+	if _, err := verifyImageSignature(untagged, keys); err != nil {
+		t.Errorf("verify untagged: %v", err)
+	}
+}
+
+func TestApplyTrustPolicy(t *testing.T) {
+	store := installFakeCredentialStore(t)
+	if _, err := generateSigningKey(store, "ci"); err != nil {
+		t.Fatal(err)
+	}
+	ref, d := testSubjectLayout(t)
+	dir := t.TempDir()
+
+	if got, err := applyTrustPolicy(&TrustConfig{Policy: trustOff, Key: []string{"ci"}}, ref, dir); got != "" || err != nil {
+		t.Errorf("off: %q, %v", got, err)
+	}
+	if got, err := applyTrustPolicy(&TrustConfig{Policy: trustWarn, Key: []string{"ci"}}, ref, dir); got != "" || err != nil {
+		t.Errorf("warn on unsigned: %q, %v; want pass-through", got, err)
+	}
+	if _, err := applyTrustPolicy(&TrustConfig{Policy: trustEnforce, Key: []string{"ci"}}, ref, dir); err == nil {
+		t.Error("enforce on unsigned should fail")
+	}
+
+	if _, err := signImageRef(ref, "ci"); err != nil {
+		t.Fatal(err)
+	}
+	got, err := applyTrustPolicy(&TrustConfig{Policy: trustEnforce, Key: []string{"ci"}}, ref, dir)
+	if err != nil {
+		t.Fatalf("enforce on signed: %v", err)
+	}
+	if !strings.HasSuffix(got, "@"+d.String()) {
+		t.Errorf("verified ref = %q, want it pinned to %s", got, d)
+	}
+}
+
+func TestTrustPolicyLookup(t *testing.T) {
+	project := &TrustConfig{Policy: trustWarn, Key: []string{"ci"}}
+	own := &TrustConfig{Policy: trustEnforce, Key: []string{"release"}}
+	cfg := &Config{
+		Defaults: BoxConfig{Trust: project},
+		Box:      map[string]BoxConfig{"app": {Trust: own}, "web": {}},
+	}
+	if got := cfg.trustPolicyForRef("ghcr.io/org/app:2026.1.1"); got != own {
+		t.Errorf("app policy = %+v, want its own", got)
+	}
+	if got := cfg.trustPolicyForRef("ghcr.io/org/web:2026.1.1"); got != project {
+		t.Errorf("web policy = %+v, want the project default", got)
+	}
+	var nilCfg *Config
+	if got := nilCfg.trustPolicyForRef("ghcr.io/org/app"); got != nil {
+		t.Errorf("no project: %+v, want nil", got)
+	}
+
+	errs := &ValidationError{}
+	cfg.Box["web"] = BoxConfig{Trust: &TrustConfig{Policy: trustEnforce}}
+	cfg.Box["db"] = BoxConfig{Trust: &TrustConfig{Policy: "strict", Key: []string{"ci"}}}
+	validateTrustPolicy(cfg, errs)
+	if !errs.HasErrors() || len(errs.Errors) != 2 {
+		t.Errorf("validateTrustPolicy errors = %v, want 2", errs.Errors)
+	}
+}
+
+// A present local image under enforce must prove itself: a verified repo
+// digest in ref's repository, or no digest at all plus charly's build label.
+// Every path that cannot establish trust fails enforce and only warns under warn.
+func TestApplyLocalTrustPolicy(t *testing.T) {
+	store := installFakeCredentialStore(t)
+	if _, err := generateSigningKey(store, "ci"); err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "http://")
+	ref := host + "/charly/app:v1"
+	img, _ := random.Image(256, 1)
+	if err := pushTestImage(ref, img); err != nil {
+		t.Fatal(err)
+	}
+	d, err := signImageRef(ref, "ci")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+
+	// The build index records app:built (hash "bh") as image sha256:aaa; the
+	// recorded ref inspects to that ID, every other ref to inspectOut.
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	if err := recordBuildHash("bh", "app", "localhost/app:built"); err != nil {
+		t.Fatal(err)
+	}
+	var inspectOut []byte
+	var inspectErr error
+	orig := localImageInspect
+	localImageInspect = func(r string) ([]byte, error) {
+		if r == "localhost/app:built" {
+			return []byte(`[{"Id":"sha256:aaa","RepoDigests":[]}]`), nil
+		}
+		return inspectOut, inspectErr
+	}
+	defer func() { localImageInspect = orig }()
+
+	enforce := &TrustConfig{Policy: trustEnforce, Key: []string{"ci"}}
+	warn := &TrustConfig{Policy: trustWarn, Key: []string{"ci"}}
+	for _, tc := range []struct {
+		name    string
+		ref     string
+		out     string
+		err     error
+		wantErr string // "" = passes enforce
+	}{
+		{"verified digest", ref, `[{"RepoDigests":["` + host + `/charly/app@` + d.String() + `"]}]`, nil, ""},
+		{"recorded local build, no digest", ref, `[{"Id":"sha256:aaa","RepoDigests":[],"Labels":{"ai.opencharly.build.hash":"bh"}}]`, nil, ""},
+		{"box label only, no digest", ref, `[{"Id":"sha256:bbb","RepoDigests":[],"Labels":{"ai.opencharly.box":"app"}}]`, nil, "no local build record"},
+		{"recorded hash, different image", ref, `[{"Id":"sha256:bbb","RepoDigests":[],"Labels":{"ai.opencharly.build.hash":"bh"}}]`, nil, "no local build record"},
+		{"unparsable ref", "UPPER/Case:bad tag", "", nil, "could not parse"},
+		{"inspect fails", ref, "", errors.New("no such image"), "inspecting image"},
+		{"undecodable inspect", ref, "not json", nil, "parsing image inspect"},
+		{"foreign, no digest", ref, `[{"RepoDigests":null,"Labels":{}}]`, nil, "no local build record"},
+		{"digest of another repo", ref, `[{"RepoDigests":["quay.io/other/app@` + d.String() + `"]}]`, nil, "no repo digest in"},
+		{"unsigned digest", ref, `[{"RepoDigests":["` + host + `/charly/app@sha256:` + strings.Repeat("0", 64) + `"]}]`, nil, "trust policy rejects"},
+	} {
+		inspectOut, inspectErr = []byte(tc.out), tc.err
+		err := applyLocalTrustPolicy(enforce, tc.ref, dir)
+		if tc.wantErr == "" && err != nil {
+			t.Errorf("%s: enforce = %v, want pass", tc.name, err)
+		}
+		if tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)) {
+			t.Errorf("%s: enforce = %v, want error containing %q", tc.name, err, tc.wantErr)
+		}
+		if err := applyLocalTrustPolicy(warn, tc.ref, dir); err != nil {
+			t.Errorf("%s: warn = %v, want a warning only", tc.name, err)
+		}
+	}
+
+	inspectOut, inspectErr = []byte(`[{"Id":"sha256:ccc","RepoDigests":[]}]`), nil
+	allowLocal := &TrustConfig{Policy: trustEnforce, Key: []string{"ci"}, AllowLocal: true}
+	if err := applyLocalTrustPolicy(allowLocal, ref, dir); err != nil {
+		t.Errorf("allow_local: enforce = %v, want pass", err)
+	}
+}
@@ -98,7 +98,7 @@ func detectsInit(def *InitDef, ly *CandyYAML, candyPath string) bool {
 }
 
 // ResolveInitSystem determines the active init system for an image.
-// Priority: explicit override → auto-detect from candies.
+// Priority: explicit override → init_system_hint → auto-detect from candies.
 // Returns ("", nil) if no init system is needed.
 //
 // Candy capability requirements (RequiresCapabilities) are checked
@@ -152,6 +152,11 @@ func (ic *InitConfig) ResolveInitSystem(layers map[string]*Candy, candyOrder []s
 		}
 	}
 
+	// A candy that claims PID 1 via capability.init_system_hint (openrc) wins
+	if hint := caps.InitSystemHint; hint != "" && initHits[hint] {
+		return hint, ic.Init[hint]
+	}
+
 	// For bootc-flavored compositions (preserve_user) prefer systemd over supervisord
 	if caps.PreserveUser && initHits["systemd"] {
 		return "systemd", ic.Init["systemd"]
@@ -207,6 +212,11 @@ func (ic *InitConfig) ActiveInit(layers map[string]*Candy, candyOrder []string)
 		}
 	}
 
+	// An init_system_hint claims PID 1 outright: the other inits' fragments
+	// would be assembled into the image but never run.
+	if def, ok := result[caps.InitSystemHint]; ok {
+		return map[string]*InitDef{caps.InitSystemHint: def}
+	}
 	return result
 }
 
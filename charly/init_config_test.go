@@ -0,0 +1,43 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+// An openrc candy's init_system_hint claims PID 1: the openrc init resolves
+// and is the only active one, although every custom service also binds
+// supervisord. Without the hint the openrc init stays gated off.
+func TestResolveInitSystem_InitSystemHint(t *testing.T) {
+	uf, err := embeddedDefaults()
+	if err != nil {
+		t.Fatalf("embeddedDefaults: %v", err)
+	}
+	ic := uf.ProjectInitConfig()
+	if def := ic.Init["openrc"]; def == nil || !slices.Contains(def.RequiresCapability, "init_system:openrc") {
+		t.Fatalf("embedded openrc init def missing or ungated: %+v", def)
+	}
+	candies := map[string]*Candy{
+		"openrc": {Name: "openrc", capabilities: &CandyCapabilities{InitSystemHint: "openrc"}},
+		"web":    {Name: "web", service: []ServiceEntry{{Name: "web", Exec: "web serve"}}},
+	}
+	PopulateCandyInitSystem(candies, ic)
+	if !candies["web"].HasInit("openrc") || !candies["web"].HasInit("supervisord") {
+		t.Fatalf("web binds %v, want openrc and supervisord", candies["web"].InitSystems)
+	}
+
+	name, def := ic.ResolveInitSystem(candies, []string{"openrc", "web"}, "")
+	if name != "openrc" || def == nil || def.Model != "file_render" {
+		t.Errorf("ResolveInitSystem = %q (%+v), want openrc/file_render", name, def)
+	}
+	if active := ic.ActiveInit(candies, []string{"openrc", "web"}); len(active) != 1 || active["openrc"] == nil {
+		t.Errorf("ActiveInit = %v, want openrc alone", active)
+	}
+
+	if name, _ := ic.ResolveInitSystem(candies, []string{"web"}, ""); name != "supervisord" {
+		t.Errorf("without the hint ResolveInitSystem = %q, want supervisord", name)
+	}
+	if active := ic.ActiveInit(candies, []string{"web"}); active["openrc"] != nil {
+		t.Error("openrc must stay inactive without the init_system:openrc capability")
+	}
+}
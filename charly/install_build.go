@@ -582,12 +582,21 @@ func compileSystemPackageSteps(layer *Candy, img *ResolvedBox, _ HostContext) []
 // `inherit_packages: true` ancestor, so a cachyos image/VM carries [cachyos, …,
 // arch] and a `distro: arch:` block DOES reach cachyos — while ubuntu (no flag)
 // stays isolated from debian. Both knobs live entirely in the embedded vocabulary (charly/charly.yml).
+//
+// Each level is preceded by its arch-qualified form for the box's target
+// architecture (debian:13@arm64, debian:13, debian@arm64, debian, …), so a
+// `distro: debian@arm64:` block refines `debian:` on arm64 builds only.
 func cascadeTagChain(img *ResolvedBox) []string {
 	chain := append([]string(nil), img.Distro...)
 	if img.Pkg != "" {
 		chain = append(chain, img.Pkg)
 	}
-	return chain
+	arch := img.targetArch()
+	out := make([]string, 0, 2*len(chain))
+	for _, tag := range chain {
+		out = append(out, archTag(tag, arch), tag)
+	}
+	return out
 }
 
 func resolveCascadePackages(layer *Candy, img *ResolvedBox) (pkgs []string, raw map[string]any, matched bool) {
@@ -787,8 +787,10 @@ func createIntermediate(name, parentName string, uid int, pathCandies []string,
 		return
 	}
 	img.Pkg = img.BuildFormats[0]
+	img.Arch = platformArch(platforms[0])
 	// Inherit format configs from parent image (auto-intermediates share the same configs)
 	if parent, ok := result[parentName]; ok {
+		img.Arch = parent.Arch
 		img.DistroConfig = parent.DistroConfig
 		img.DistroDef = parent.DistroDef
 		img.BuilderConfig = parent.BuilderConfig
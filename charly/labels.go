@@ -95,6 +95,12 @@ const (
 	// from the built image to gate how deep the bed's acceptance runs. See
 	// check_level.go for the ladder.
 	LabelCheckLevel = "ai.opencharly.check_level"
+	// LabelBuildHash — the content hash of the generated Containerfile plus
+	// the build context it reads (see build_hash.go). Passed as a --label at
+	// build time, never written into the Containerfile itself, so the hash
+	// does not feed back into its own input. `charly box build` skips a box
+	// whose hash is already carried by a local or registry image.
+	LabelBuildHash = "ai.opencharly.build.hash"
 )
 
 // LabelVolumeEntry represents a volume in the label JSON (short name form).
@@ -271,8 +277,6 @@ func defaultInspectLabels(engine, imageRef string) (map[string]string, error) {
 // ExtractMetadata reads OCI labels from a local image and returns parsed BoxMetadata.
 // Returns nil if the image has no ai.opencharly labels.
 // Returns ErrImageNotLocal wrapped with the image ref if the image is not in local storage.
-//
-//nolint:gocyclo // uniform extraction of ~40 OCI labels (exists→unmarshal→store); flat form is the clearest representation
 func ExtractMetadata(engine, imageRef string) (*BoxMetadata, error) {
 	labels, err := InspectLabels(engine, imageRef)
 	if err != nil {
@@ -281,7 +285,16 @@ func ExtractMetadata(engine, imageRef string) (*BoxMetadata, error) {
 		}
 		return nil, err
 	}
+	return MetadataFromLabels(labels)
+}
 
+// MetadataFromLabels parses an image's OCI label map into BoxMetadata.
+// Returns nil if the labels carry no ai.opencharly.version. Split from
+// ExtractMetadata so callers holding an image config (charly box diff reads
+// it from the saved image) parse the same contract.
+//
+//nolint:gocyclo // uniform extraction of ~40 OCI labels (exists→unmarshal→store); flat form is the clearest representation
+func MetadataFromLabels(labels map[string]string) (*BoxMetadata, error) {
 	version := labels[LabelVersion]
 	if version == "" {
 		// Empty ai.opencharly.version => not an opencharly image (a plain
@@ -69,6 +69,7 @@ var candyYAMLKnownFields = map[string]bool{
 	"apk":      true,
 	"shell":    true,
 	"localpkg": true, "reboot": true,
+	"go": true, "supported_arch": true,
 }
 
 // The build vocabulary — the set of distro names and package-format names — is
@@ -151,6 +152,7 @@ func RegisterBuildVocabulary(dc *DistroConfig) {
 //	distro.arch.*            → tagSections["arch"]   (+ any .aur.* → formatSections["aur"])
 //	distro.debian-13.*       → tagSections["debian:13"]   (dash → colon)
 //	distro."debian,ubuntu".* → tagSections["debian"] + tagSections["ubuntu"]
+//	distro.fedora@arm64.*    → tagSections["fedora@arm64"]   (arch-qualified)
 func derivePackageSectionsFromCalamares(layer *Candy, ly *CandyYAML) {
 	layer.topPackages = PackageNames(ly.Package)
 
@@ -224,10 +226,15 @@ func derivePackageSectionsFromCalamares(layer *Candy, ly *CandyYAML) {
 				continue
 			}
 			// Canonicalize: bare `debian` stays `debian`; versioned `debian-13`
-			// → colon-form tag key `debian:13` (matches img.Distro tags).
-			tagKey := part
-			if i := strings.IndexByte(part, '-'); i > 0 {
-				tagKey = part[:i] + ":" + part[i+1:]
+			// → colon-form tag key `debian:13` (matches img.Distro tags). An
+			// `@<arch>` qualifier (`debian-13@arm64`) is kept as a suffix; the
+			// cascade only consults it when generating for that architecture.
+			tagKey, arch, _ := strings.Cut(part, "@")
+			if i := strings.IndexByte(tagKey, '-'); i > 0 {
+				tagKey = tagKey[:i] + ":" + tagKey[i+1:]
+			}
+			if arch != "" {
+				tagKey = archTag(tagKey, arch)
 			}
 			// Every key under `distro:` is, by the author's placement, a distro
 			// or package-format tag — so parsing is purely STRUCTURAL: each key
@@ -309,6 +316,7 @@ type Candy struct {
 	HasEnvironmentYml bool
 	HasPackageJson    bool
 	HasCargoToml      bool
+	HasGoMod          bool
 	HasSrcDir         bool
 	HasPixiLock       bool // the candy manifest has a non-empty tasks: list
 
@@ -360,6 +368,8 @@ type Candy struct {
 	apk             []ApkPackageSpec  // Android apps to install on a kind:android device (from the candy manifest apk:)
 	localpkg        map[string]string // per-format native-package source dirs (pac/rpm/deb → dir) from the candy manifest localpkg:
 	reboot          bool              // reboot the deploy target after this candy (from the candy manifest reboot:)
+	goBuild         *GoBuildConfig    // the `go` builder's main packages + ldflags (from the candy manifest go:)
+	supportedArch   []string          // architectures this candy builds for (from the candy manifest supported_arch:); empty = every architecture
 	ExternalBuilder string            // reserved word of an EXTERNAL builder plugin this candy selects (from the candy manifest external_builder:); resolved at build via OpResolve — see generate.go emitExternalBuilderStages
 	plan            []Step            // unified ordered plan (from the candy manifest plan:): run:/check:/agent-*/include:
 	artifacts       []CandyArtifact   // files to retrieve after setup (from the candy manifest artifacts:)
@@ -689,6 +699,7 @@ func scanCandy(path, name, manifest string) (*Candy, error) {
 	layer.HasEnvironmentYml = fileExists(filepath.Join(layer.SourceDir, "environment.yml"))
 	layer.HasPackageJson = fileExists(filepath.Join(layer.SourceDir, "package.json"))
 	layer.HasCargoToml = fileExists(filepath.Join(layer.SourceDir, "Cargo.toml"))
+	layer.HasGoMod = fileExists(filepath.Join(layer.SourceDir, "go.mod"))
 	layer.HasSrcDir = dirExists(filepath.Join(layer.SourceDir, "src"))
 	layer.HasPixiLock = fileExists(filepath.Join(layer.SourceDir, "pixi.lock"))
 
@@ -712,7 +723,7 @@ func scanCandy(path, name, manifest string) (*Candy, error) {
 func (l *Candy) HasInstallFiles() bool {
 	return l.HasFormatPackages() || l.HasTagPackages() || len(l.topPackages) > 0 ||
 		l.HasPixiToml || l.HasPyprojectToml || l.HasEnvironmentYml ||
-		l.HasPackageJson || l.HasCargoToml ||
+		l.HasPackageJson || l.HasCargoToml || l.GoBuildable() ||
 		l.HasTasks() || l.HasApk()
 }
 
@@ -787,6 +798,48 @@ func (l *Candy) runOps() []Op {
 // format). Empty for non-Android candies.
 func (l *Candy) Apk() []ApkPackageSpec { return l.apk }
 
+// GoBuildable reports whether the `go` builder compiles this candy: it ships a
+// go.mod and is not a plugin candy. Plugin candies (candy/plugin-*) carry a
+// go.mod for their provider binary, which the plugin machinery builds and
+// bakes itself — composing them must not pull in the go builder.
+func (l *Candy) GoBuildable() bool { return l.HasGoMod && l.Plugin == nil }
+
+// GoMain returns the main packages the `go` builder compiles for this candy —
+// the candy manifest go.main list, or the module root (".") when unset.
+func (l *Candy) GoMain() []string {
+	if l.goBuild == nil || len(l.goBuild.Main) == 0 {
+		return []string{"."}
+	}
+	return l.goBuild.Main
+}
+
+// GoLdflags returns the linker flags the `go` builder passes via -ldflags
+// (the candy manifest go.ldflags), or "".
+func (l *Candy) GoLdflags() string {
+	if l.goBuild == nil {
+		return ""
+	}
+	return l.goBuild.Ldflags
+}
+
+// SupportsArch reports whether the candy can be built for arch — true unless
+// the candy manifest supported_arch: list names other architectures only.
+func (l *Candy) SupportsArch(arch string) bool {
+	return archListed(l.supportedArch, arch)
+}
+
+// HasArchSections reports whether any of the candy's distro: blocks is
+// architecture-qualified (`fedora@arm64`), i.e. whether its packages can differ
+// between the architectures one box targets.
+func (l *Candy) HasArchSections() bool {
+	for tag := range l.tagSections {
+		if strings.Contains(tag, "@") {
+			return true
+		}
+	}
+	return false
+}
+
 // LocalPkg returns the candy's native-package SOURCE dir for the given package
 // FORMAT (pac/rpm/deb), or "" when the candy declares none for that format. See
 // LocalPkgInstallStep.
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
@@ -217,6 +218,61 @@ func TestCandyCargoTool(t *testing.T) {
 	}
 }
 
+func TestCandyGoTool(t *testing.T) {
+	layers, err := ScanCandy("testdata")
+	if err != nil {
+		t.Fatalf("ScanCandy() error = %v", err)
+	}
+
+	goTool := layers["go-tool"]
+	if goTool == nil {
+		t.Fatal("go-tool candy not found")
+	}
+	if !goTool.HasGoMod || !goTool.HasInstallFiles() {
+		t.Error("go-tool should have go.mod as its install file")
+	}
+	if got := goTool.GoMain(); !reflect.DeepEqual(got, []string{"./cmd/go-tool"}) {
+		t.Errorf("GoMain() = %v, want [./cmd/go-tool]", got)
+	}
+	if got := goTool.GoLdflags(); got != "-s -w" {
+		t.Errorf("GoLdflags() = %q, want -s -w", got)
+	}
+	if got := layers["cargo-tool"].GoMain(); !reflect.DeepEqual(got, []string{"."}) {
+		t.Errorf("GoMain() without go: = %v, want [.]", got)
+	}
+	if got := goModulePath(filepath.Join(goTool.SourceDir, "go.mod")); got != "example.com/go-tool" {
+		t.Errorf("goModulePath() = %q, want example.com/go-tool", got)
+	}
+}
+
+// A plugin candy ships a go.mod for its provider binary; composing it must not
+// select the go builder in any of the detection paths.
+func TestPluginCandyGoModSkipsGoBuilder(t *testing.T) {
+	goDef := &BuilderDef{DetectFiles: []string{"go.mod"}}
+	plugin := &Candy{Name: "plugin-cdp", HasGoMod: true, Plugin: &CandyPluginDecl{}}
+	tool := &Candy{Name: "go-tool", HasGoMod: true}
+
+	if plugin.GoBuildable() || candyHasFile(plugin, "go.mod") || candyNeedsBuilderStep(plugin, goDef) ||
+		(&Generator{}).candyNeedsBuilder(nil, plugin, goDef) {
+		t.Error("plugin candy with go.mod should not trigger the go builder")
+	}
+	if !candyNeedsBuilderStep(tool, goDef) || !(&Generator{}).candyNeedsBuilder(nil, tool, goDef) {
+		t.Error("ordinary go.mod candy should trigger the go builder")
+	}
+
+	layers := map[string]*Candy{"plugin-cdp": plugin, "go-tool": tool}
+	boxes := map[string]*ResolvedBox{
+		"chrome": {Name: "chrome", Base: "ext:1", IsExternalBase: true, Candy: []string{"plugin-cdp"}},
+		"tools":  {Name: "tools", Base: "ext:1", IsExternalBase: true, Candy: []string{"go-tool"}},
+	}
+	if BoxNeedsBuilder(boxes["chrome"], boxes, layers) {
+		t.Error("box composing only a plugin candy should not need a builder")
+	}
+	if !BoxNeedsBuilder(boxes["tools"], boxes, layers) {
+		t.Error("box composing a go.mod candy should need a builder")
+	}
+}
+
 func TestHasInstallFiles(t *testing.T) {
 	// Format-section detection depends on RegisterBuildVocabulary being called first
 	// (so unknown top-level keys get routed to FormatSections, not discarded).
@@ -241,8 +297,8 @@ func TestCandyNames(t *testing.T) {
 	}
 
 	names := CandyNames(layers)
-	if len(names) != 7 {
-		t.Errorf("CandyNames() returned %d names, want 7", len(names))
+	if len(names) != 8 {
+		t.Errorf("CandyNames() returned %d names, want 8", len(names))
 	}
 
 	// Should be sorted
@@ -0,0 +1,322 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// logs_query.go — the structured path behind `charly logs`: time windows, filtering,
+// JSON output and the merged multi-container stream. The plain single-container
+// `charly logs <box>` stays a straight journalctl / `<engine> logs` passthrough
+// (LogsCmd.Run); this path is taken when the output has to be parsed — JSON,
+// --merge, or a --grep the backend cannot apply itself.
+//
+// Every source is read with timestamps (journalctl -o json, `<engine> logs
+// --timestamps`), so entries from the app container, its sidecars and a group's
+// peers interleave by time — the view needed to correlate e.g. a check-bed app
+// failure with its tailscale and traefik sidecars.
+
+// logSource is one container whose log is read.
+type logSource struct {
+	Label     string // container name, used as the line prefix
+	Container string
+	Unit      string // systemd user unit (quadlet mode); "" reads via the engine
+	Engine    string // engine binary (direct mode)
+}
+
+// logEntry is one parsed log line.
+type logEntry struct {
+	Time    time.Time `json:"time"`
+	Source  string    `json:"source"`
+	Message string    `json:"message"`
+}
+
+// logQuery carries the per-source filter options.
+type logQuery struct {
+	Since, Until time.Time
+	Grep         *regexp.Regexp
+	Follow       bool
+}
+
+// parseLogTime accepts a Go duration (that long ago: "90s", "15m", "2h"), RFC3339,
+// "2006-01-02 15:04:05", "2006-01-02T15:04:05" or a bare date (local time).
+func parseLogTime(s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q (want a duration like 15m, RFC3339, or YYYY-MM-DD[ HH:MM:SS])", s)
+}
+
+// resolveLogSources lists the containers to read. Without merge that is the app
+// container, or the named sidecar. With merge it is the app container plus every
+// sidecar, and — for a deployment with `peer:` members, or a targetless group —
+// every pod member with its sidecars too.
+func resolveLogSources(boxName, instance, sidecar string, merge bool, quadlet bool, engineFor func(box, instance string) string) []logSource {
+	src := func(box, inst, sc string) logSource {
+		name := containerNameInstance(box, inst)
+		if sc != "" {
+			name = SidecarContainerNameInstance(box, inst, sc)
+		}
+		s := logSource{Label: name, Container: name}
+		if quadlet {
+			s.Unit = name + ".service"
+		} else {
+			s.Engine = engineFor(box, inst)
+		}
+		return s
+	}
+	if !merge {
+		return []logSource{src(boxName, instance, sidecar)}
+	}
+	withSidecars := func(box, inst string) []logSource {
+		out := []logSource{src(box, inst, "")}
+		for _, sc := range resolveSidecarNames(box, inst) {
+			out = append(out, src(box, inst, sc))
+		}
+		return out
+	}
+	dc := loadDeployConfigForRead("charly logs")
+	node, ok := dc.Bundle[deployKey(boxName, instance)]
+	var out []logSource
+	if !ok || !node.IsGroup() {
+		out = withSidecars(boxName, instance)
+	}
+	if ok {
+		for _, m := range sortedMemberKeys(node.Members) {
+			if isPodMember(node.Members[m]) {
+				out = append(out, withSidecars(m, "")...)
+			}
+		}
+	}
+	return out
+}
+
+// journalArgs renders the query as journalctl flags — the window and the
+// pattern are applied server-side.
+func (q logQuery) journalArgs() []string {
+	var args []string
+	if !q.Since.IsZero() {
+		args = append(args, "--since", q.Since.Local().Format("2006-01-02 15:04:05"))
+	}
+	if !q.Until.IsZero() {
+		args = append(args, "--until", q.Until.Local().Format("2006-01-02 15:04:05"))
+	}
+	if q.Grep != nil {
+		args = append(args, "--grep", q.Grep.String())
+	}
+	if q.Follow {
+		args = append(args, "-f")
+	}
+	return args
+}
+
+// engineArgs renders the query as `<engine> logs` flags; the engine applies the
+// window only, so a pattern is matched client-side (logQuery.keep).
+func (q logQuery) engineArgs() []string {
+	var args []string
+	if !q.Since.IsZero() {
+		args = append(args, "--since", q.Since.Format(time.RFC3339))
+	}
+	if !q.Until.IsZero() {
+		args = append(args, "--until", q.Until.Format(time.RFC3339))
+	}
+	if q.Follow {
+		args = append(args, "-f")
+	}
+	return args
+}
+
+// command builds the timestamped reader for one source.
+func (s logSource) command(q logQuery) *exec.Cmd {
+	if s.Unit != "" {
+		args := []string{"--user", "-u", s.Unit, "-o", "json", "--no-pager"}
+		return exec.Command("journalctl", append(args, q.journalArgs()...)...)
+	}
+	args := append([]string{"logs", "--timestamps"}, q.engineArgs()...)
+	return exec.Command(s.Engine, append(args, s.Container)...)
+}
+
+// parse turns one raw output line into an entry. ok is false for lines that
+// carry nothing (journal records without a MESSAGE).
+func (s logSource) parse(line string, prev time.Time) (logEntry, bool) {
+	if s.Unit != "" {
+		return parseJournalLine(s.Label, line)
+	}
+	return parseTimestampedLine(s.Label, line, prev), true
+}
+
+// parseTimestampedLine splits an `<engine> logs --timestamps` line. A line
+// without a parseable timestamp (a continuation of a multi-line message) keeps
+// the previous entry's time so it sorts next to it.
+func parseTimestampedLine(label, line string, prev time.Time) logEntry {
+	if ts, msg, ok := strings.Cut(line, " "); ok {
+		if t, err := time.Parse(time.RFC3339Nano, ts); err == nil {
+			return logEntry{Time: t, Source: label, Message: msg}
+		}
+	}
+	return logEntry{Time: prev, Source: label, Message: line}
+}
+
+// parseJournalLine decodes one `journalctl -o json` record. MESSAGE is a string,
+// or an array of bytes when the payload is not valid UTF-8.
+func parseJournalLine(label, line string) (logEntry, bool) {
+	var rec struct {
+		Realtime string          `json:"__REALTIME_TIMESTAMP"`
+		Message  json.RawMessage `json:"MESSAGE"`
+	}
+	if json.Unmarshal([]byte(line), &rec) != nil || len(rec.Message) == 0 {
+		return logEntry{}, false
+	}
+	e := logEntry{Source: label}
+	if us, err := strconv.ParseInt(rec.Realtime, 10, 64); err == nil {
+		e.Time = time.UnixMicro(us)
+	}
+	var s string
+	if json.Unmarshal(rec.Message, &s) == nil {
+		e.Message = s
+		return e, true
+	}
+	var raw []byte
+	var ints []int
+	if json.Unmarshal(rec.Message, &ints) != nil {
+		return logEntry{}, false
+	}
+	for _, b := range ints {
+		raw = append(raw, byte(b))
+	}
+	e.Message = strings.TrimRight(string(raw), "\n")
+	return e, true
+}
+
+// keep applies the client-side filters the backend could not: the pattern for
+// engine sources and the until bound (inclusive window on both ends).
+func (q logQuery) keep(s logSource, e logEntry) bool {
+	if s.Unit == "" && q.Grep != nil && !q.Grep.MatchString(e.Message) {
+		return false
+	}
+	if !q.Until.IsZero() && !e.Time.IsZero() && e.Time.After(q.Until) {
+		return false
+	}
+	return true
+}
+
+// readLogSource streams one source's entries to emit.
+func readLogSource(s logSource, q logQuery, emit func(logEntry)) error {
+	cmd := s.command(q)
+	pr, pw := io.Pipe()
+	cmd.Stdout = pw
+	cmd.Stderr = pw // engines replay the container's stderr on their own stderr
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("%s: %w", s.Label, err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		err := cmd.Wait()
+		pw.Close()
+		done <- err
+	}()
+	sc := bufio.NewScanner(pr)
+	sc.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	var prev time.Time
+	for sc.Scan() {
+		e, ok := s.parse(sc.Text(), prev)
+		if !ok || !q.keep(s, e) {
+			continue
+		}
+		prev = e.Time
+		emit(e)
+	}
+	_, _ = io.Copy(io.Discard, pr) // an over-long line stops the scanner; don't wedge the writer
+	if err := <-done; err != nil {
+		return fmt.Errorf("%s: %w", s.Label, err)
+	}
+	return nil
+}
+
+// runLogQuery reads every source and writes the entries to w. A bounded read is
+// collected and stably sorted by time; a follow interleaves entries as they
+// arrive (a live stream cannot be globally ordered).
+func runLogQuery(w io.Writer, sources []logSource, q logQuery, format string) error {
+	width := 0
+	for _, s := range sources {
+		width = max(width, len(s.Label))
+	}
+	prefix := len(sources) > 1
+	var mu sync.Mutex
+	var collected []logEntry
+	emit := func(e logEntry) {
+		mu.Lock()
+		defer mu.Unlock()
+		if q.Follow {
+			writeLogEntry(w, e, format, prefix, width)
+			return
+		}
+		collected = append(collected, e)
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(sources))
+	for i, s := range sources {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = readLogSource(s, q, emit)
+		}()
+	}
+	wg.Wait()
+
+	sort.SliceStable(collected, func(i, j int) bool { return collected[i].Time.Before(collected[j].Time) })
+	for _, e := range collected {
+		writeLogEntry(w, e, format, prefix, width)
+	}
+	var failed []string
+	for _, err := range errs {
+		if err != nil {
+			failed = append(failed, err.Error())
+		}
+	}
+	if len(failed) > 0 {
+		// A missing sidecar must not hide the rest of the stream: report after it.
+		return fmt.Errorf("reading logs failed for %d source(s): %s", len(failed), strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// writeLogEntry renders one entry: a JSON object per line, or text with the
+// timestamp (and, for a merged stream, the aligned source) in front.
+func writeLogEntry(w io.Writer, e logEntry, format string, prefix bool, width int) {
+	if format == "json" {
+		data, _ := json.Marshal(e)
+		fmt.Fprintf(w, "%s\n", data)
+		return
+	}
+	ts := "-"
+	if !e.Time.IsZero() {
+		ts = e.Time.Format(time.RFC3339Nano)
+	}
+	if prefix {
+		fmt.Fprintf(w, "%-*s | %s %s\n", width, e.Source, ts, e.Message)
+		return
+	}
+	fmt.Fprintf(w, "%s %s\n", ts, e.Message)
+}
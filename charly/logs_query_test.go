@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseLogTime(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	cases := map[string]time.Time{
+		"15m":                  now.Add(-15 * time.Minute),
+		"2026-03-01T10:00:00Z": time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC),
+		"2026-03-01 10:00:00":  time.Date(2026, 3, 1, 10, 0, 0, 0, time.Local),
+		"2026-03-01":           time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local),
+	}
+	for in, want := range cases {
+		got, err := parseLogTime(in, now)
+		if err != nil || !got.Equal(want) {
+			t.Errorf("parseLogTime(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	if _, err := parseLogTime("yesterday-ish", now); err == nil {
+		t.Error("expected an error for an unparseable time")
+	}
+}
+
+func TestParseJournalLine(t *testing.T) {
+	e, ok := parseJournalLine("charly-app", `{"__REALTIME_TIMESTAMP":"1700000000123456","MESSAGE":"listening on :8080"}`)
+	if !ok || e.Message != "listening on :8080" || !e.Time.Equal(time.UnixMicro(1700000000123456)) {
+		t.Errorf("string message = %+v, %v", e, ok)
+	}
+	// Non-UTF-8 payloads arrive as a byte array.
+	e, ok = parseJournalLine("charly-app", `{"__REALTIME_TIMESTAMP":"1700000000000000","MESSAGE":[104,105,255,10]}`)
+	if !ok || e.Message != "hi\xff" {
+		t.Errorf("byte message = %q, %v", e.Message, ok)
+	}
+	if _, ok := parseJournalLine("charly-app", `{"__REALTIME_TIMESTAMP":"1"}`); ok {
+		t.Error("a record without MESSAGE must be skipped")
+	}
+}
+
+func TestLogQueryArgs(t *testing.T) {
+	since := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	q := logQuery{Since: since, Grep: regexp.MustCompile("panic"), Follow: true}
+	if got, want := q.engineArgs(), []string{"--since", "2026-03-01T10:00:00Z", "-f"}; !slices.Equal(got, want) {
+		t.Errorf("engineArgs = %v, want %v", got, want)
+	}
+	got := q.journalArgs()
+	if !slices.Contains(got, "--grep") || !slices.Contains(got, "panic") || got[len(got)-1] != "-f" {
+		t.Errorf("journalArgs = %v", got)
+	}
+}
+
+// Two sources merge into one time-ordered stream; the pattern is applied to
+// engine sources client-side, continuation lines keep their entry's time, and
+// each line carries its source.
+func TestRunLogQuery_Merge(t *testing.T) {
+	dir := t.TempDir()
+	fake := func(name, out string) string {
+		p := filepath.Join(dir, name)
+		mustWrite(t, p, "#!/bin/sh\ncat <<'EOF'\n"+out+"EOF\n")
+		if err := os.Chmod(p, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	app := fake("app", "2026-03-01T10:00:01Z app up\n2026-03-01T10:00:03Z app error: upstream gone\n  at handler\n")
+	ts := fake("ts", "2026-03-01T10:00:02Z tailscale error: derp timeout\n2026-03-01T10:00:04Z tailscale ok\n")
+	sources := []logSource{
+		{Label: "charly-app", Container: "charly-app", Engine: app},
+		{Label: "charly-app-tailscale", Container: "charly-app-tailscale", Engine: ts},
+	}
+
+	var buf bytes.Buffer
+	if err := runLogQuery(&buf, sources, logQuery{}, "text"); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	want := []string{
+		"charly-app           | 2026-03-01T10:00:01Z app up",
+		"charly-app-tailscale | 2026-03-01T10:00:02Z tailscale error: derp timeout",
+		"charly-app           | 2026-03-01T10:00:03Z app error: upstream gone",
+		"charly-app           | 2026-03-01T10:00:03Z   at handler",
+		"charly-app-tailscale | 2026-03-01T10:00:04Z tailscale ok",
+	}
+	if !slices.Equal(lines, want) {
+		t.Errorf("merged text:\n%s\nwant:\n%s", strings.Join(lines, "\n"), strings.Join(want, "\n"))
+	}
+
+	buf.Reset()
+	if err := runLogQuery(&buf, sources, logQuery{Grep: regexp.MustCompile("error")}, "json"); err != nil {
+		t.Fatal(err)
+	}
+	var got []logEntry
+	for _, l := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var e logEntry
+		if err := json.Unmarshal([]byte(l), &e); err != nil {
+			t.Fatalf("bad json line %q: %v", l, err)
+		}
+		got = append(got, e)
+	}
+	if len(got) != 2 || got[0].Source != "charly-app-tailscale" || got[1].Message != "app error: upstream gone" {
+		t.Errorf("filtered json = %+v", got)
+	}
+}
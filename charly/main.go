@@ -128,6 +128,11 @@ type CLI struct {
 	SettingsInternal SettingsCmd `cmd:"" name:"__settings" hidden:"" help:"internal: runtime config get/set/list (the externalized charly settings plugin forwards here)"`
 	CandyInternal    CandyCmd    `cmd:"" name:"__candy" hidden:"" help:"internal: candy.yml authoring (the externalized charly candy plugin forwards here)"`
 
+	// __event-deliver hands one recorded event to the event_sinks: recordEvent (events.go)
+	// spawns it detached, so a slow webhook or sink command never blocks the verb that
+	// recorded the event — only the journal append stays inline.
+	EventDeliver EventDeliverInternalCmd `cmd:"" name:"__event-deliver" hidden:"" help:"internal: deliver one recorded event to the event_sinks (recordEvent runs it detached)"`
+
 	Migrate MigrateCmd `cmd:"" help:"Migrate any opencharly config up to the latest schema CalVer (single idempotent chain — no sub-verbs)"`
 	// Every non-machinery command — the deploy-lifecycle + leaf-domain set (alias,
 	// ssh, start, stop, status, restart, update, remove, logs,
@@ -156,6 +161,8 @@ type GenerateCmd struct {
 	Boxes           []string `arg:"" optional:"" help:"Boxes to generate (default: all enabled). The sentinel 'all' is equivalent to passing no argument."`
 	Tag             string   `long:"tag" help:"Override tag (default: CalVer)"`
 	IncludeDisabled bool     `long:"include-disabled" help:"Generate boxes with enabled: false in charly.yml (does not modify the file). Scoped to the named boxes when any are given."`
+	Unlocked        bool     `long:"unlocked" help:"Ignore charly.lock: emit unpinned package installs"`
+	Platform        string   `long:"platform" help:"Generate for these target platforms instead of each box's (e.g. linux/arm64; comma-separated)"`
 }
 
 func (c *GenerateCmd) Run() error {
@@ -174,12 +181,15 @@ func (c *GenerateCmd) Run() error {
 		Tag:             c.Tag,
 		Dir:             dir,
 		IncludeDisabled: c.IncludeDisabled,
+		Unlocked:        c.Unlocked,
+		Platform:        c.Platform,
 	})
 }
 
 // ValidateCmd validates charly.yml and candies
 type ValidateCmd struct {
-	IncludeDisabled bool `long:"include-disabled" help:"Include boxes with enabled: false in validation (does not modify charly.yml)"`
+	IncludeDisabled bool   `long:"include-disabled" help:"Include boxes with enabled: false in validation (does not modify charly.yml)"`
+	Platform        string `long:"platform" help:"Validate every box against these target platforms (e.g. linux/arm64; comma-separated)"`
 }
 
 func (c *ValidateCmd) Run() error {
@@ -212,7 +222,7 @@ func (c *ValidateCmd) Run() error {
 	// Populate init systems on candies from the embedded build vocabulary
 	PopulateCandyInitSystem(layers, defaultInitCfg)
 
-	return Validate(cfg, layers, dir, ResolveOpts{IncludeDisabled: c.IncludeDisabled})
+	return Validate(cfg, layers, dir, ResolveOpts{IncludeDisabled: c.IncludeDisabled, Platform: c.Platform})
 }
 
 // InspectCmd prints resolved config for an image
@@ -0,0 +1,710 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// osv.go — an offline OSV (https://ossf.github.io/osv-schema/) vulnerability
+// database and the version matching `charly box audit` runs against it.
+//
+// The database is a directory of OSV JSON records, or a .tar/.tar.gz/.tgz/.zip
+// of one — osv.dev publishes gs://osv-vulnerabilities/<ecosystem>/all.zip, which
+// is loaded as-is. Nothing is fetched. Records are indexed by (ecosystem, package
+// name); the ecosystem key is the part before the first ":" ("Debian:12" →
+// debian), its release suffix is checked against the box's distro version.
+//
+// Versions are compared per ecosystem: dpkg ordering for Debian/Ubuntu, rpm
+// ordering for the rpm distros, semver for npm and crates.io, and rpm-style
+// segment ordering (numeric runs numerically, alpha runs lexically) for the rest.
+
+// osvRecord is the subset of an OSV record the audit reads.
+type osvRecord struct {
+	ID               string         `json:"id"`
+	Aliases          []string       `json:"aliases"`
+	Summary          string         `json:"summary"`
+	Withdrawn        string         `json:"withdrawn"`
+	Severity         []osvSeverity  `json:"severity"`
+	Affected         []osvAffected  `json:"affected"`
+	DatabaseSpecific map[string]any `json:"database_specific"`
+}
+
+type osvSeverity struct {
+	Type  string `json:"type"`
+	Score string `json:"score"`
+}
+
+type osvAffected struct {
+	Package struct {
+		Ecosystem string `json:"ecosystem"`
+		Name      string `json:"name"`
+	} `json:"package"`
+	Severity          []osvSeverity  `json:"severity"`
+	Ranges            []osvRange     `json:"ranges"`
+	Versions          []string       `json:"versions"`
+	EcosystemSpecific map[string]any `json:"ecosystem_specific"`
+}
+
+type osvRange struct {
+	Type   string     `json:"type"`
+	Events []osvEvent `json:"events"`
+}
+
+type osvEvent struct {
+	Introduced   string `json:"introduced,omitempty"`
+	Fixed        string `json:"fixed,omitempty"`
+	LastAffected string `json:"last_affected,omitempty"`
+	Limit        string `json:"limit,omitempty"`
+}
+
+// osvEntry is one indexed affected[] item with its record.
+type osvEntry struct {
+	Record   *osvRecord
+	Affected *osvAffected
+}
+
+// osvDB is a loaded database indexed by ecosystem key and package name.
+type osvDB struct {
+	Records int
+	index   map[string]map[string][]osvEntry
+}
+
+// loadOSVDB loads every OSV record under path (a directory or an archive).
+func loadOSVDB(path string) (*osvDB, error) {
+	db := &osvDB{index: map[string]map[string][]osvEntry{}}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("OSV database: %w", err)
+	}
+	switch {
+	case fi.IsDir():
+		err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || !strings.HasSuffix(p, ".json") {
+				return err
+			}
+			data, err := os.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			return db.add(p, data)
+		})
+	case strings.HasSuffix(path, ".zip"):
+		err = db.loadZip(path)
+	case strings.HasSuffix(path, ".tar"), strings.HasSuffix(path, ".tar.gz"), strings.HasSuffix(path, ".tgz"):
+		err = db.loadTar(path)
+	default:
+		return nil, fmt.Errorf("OSV database %s: want a directory or a .tar, .tar.gz, .tgz or .zip", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("OSV database %s: %w", path, err)
+	}
+	return db, nil
+}
+
+func (db *osvDB) loadZip(path string) error {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || !strings.HasSuffix(f.Name, ".json") {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+		if err := db.add(f.Name, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (db *osvDB) loadTar(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if !strings.HasSuffix(path, ".tar") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if hdr.Typeflag != tar.TypeReg || !strings.HasSuffix(hdr.Name, ".json") {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return err
+		}
+		if err := db.add(hdr.Name, data); err != nil {
+			return err
+		}
+	}
+}
+
+// add parses one record and indexes its affected packages. Withdrawn records
+// are dropped.
+func (db *osvDB) add(name string, data []byte) error {
+	rec := &osvRecord{}
+	if err := json.Unmarshal(data, rec); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	if rec.ID == "" || rec.Withdrawn != "" {
+		return nil
+	}
+	db.Records++
+	for i := range rec.Affected {
+		a := &rec.Affected[i]
+		eco := osvEcosystemKey(a.Package.Ecosystem)
+		if db.index[eco] == nil {
+			db.index[eco] = map[string][]osvEntry{}
+		}
+		pkg := osvPackageKey(eco, a.Package.Name)
+		db.index[eco][pkg] = append(db.index[eco][pkg], osvEntry{Record: rec, Affected: a})
+	}
+	return nil
+}
+
+// osvEcosystemKey is the index key of an OSV ecosystem: its name before any
+// release suffix, lower-cased without spaces ("Rocky Linux:9" → rockylinux).
+func osvEcosystemKey(ecosystem string) string {
+	base, _, _ := strings.Cut(ecosystem, ":")
+	return strings.ToLower(strings.ReplaceAll(base, " ", ""))
+}
+
+// osvEcosystemRelease returns the release of an ecosystem ("Debian:12" → 12,
+// "Alpine:v3.20" → 3.20, "Ubuntu:Pro:22.04:LTS" → 22.04), or "".
+func osvEcosystemRelease(ecosystem string) string {
+	parts := strings.Split(ecosystem, ":")
+	for _, p := range parts[1:] {
+		p = strings.TrimPrefix(p, "v")
+		if p != "" && p[0] >= '0' && p[0] <= '9' {
+			return p
+		}
+	}
+	return ""
+}
+
+// osvPackageKey normalizes a package name for lookup; PyPI names compare
+// case- and separator-insensitively (PEP 503).
+func osvPackageKey(eco, name string) string {
+	if eco == "pypi" {
+		return strings.NewReplacer("_", "-", ".", "-").Replace(strings.ToLower(name))
+	}
+	return name
+}
+
+// distroEcosystems maps charly distro names onto OSV ecosystem keys where the
+// two differ; any other distro is looked up under its own name.
+var distroEcosystems = map[string]string{
+	"rhel":      "redhat",
+	"rocky":     "rockylinux",
+	"alma":      "almalinux",
+	"almalinux": "almalinux",
+}
+
+// builderEcosystems maps builders onto OSV ecosystem keys. pixi installs
+// conda-forge packages, whose Python packages carry their PyPI names.
+var builderEcosystems = map[string]string{
+	"npm":   "npm",
+	"cargo": "crates.io",
+	"pixi":  "pypi",
+}
+
+// osvMatch is one vulnerability affecting one installed version.
+type osvMatch struct {
+	Record *osvRecord
+	Fixed  string // first fixed version after the installed one, if any
+	Eco    string // ecosystem as written in the record
+	Entry  *osvAffected
+}
+
+// lookup returns the records affecting pkg at version in ecosystem eco. release,
+// when set, must match the ecosystem release of a release-qualified entry.
+func (db *osvDB) lookup(eco, release, pkg, version string) []osvMatch {
+	var out []osvMatch
+	seen := map[string]bool{}
+	for _, e := range db.index[eco][osvPackageKey(eco, pkg)] {
+		if seen[e.Record.ID] {
+			continue
+		}
+		if r := osvEcosystemRelease(e.Affected.Package.Ecosystem); r != "" && release != "" && !releaseMatches(r, release) {
+			continue
+		}
+		affected, fixed := osvAffects(e.Affected, eco, version)
+		if !affected {
+			continue
+		}
+		seen[e.Record.ID] = true
+		out = append(out, osvMatch{Record: e.Record, Fixed: fixed, Eco: e.Affected.Package.Ecosystem, Entry: e.Affected})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Record.ID < out[j].Record.ID })
+	return out
+}
+
+// releaseMatches compares an ecosystem release with a distro version on their
+// common leading components ("3.20" matches "3.20.1", "12" matches "12").
+func releaseMatches(a, b string) bool {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] != bs[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// osvAffects reports whether version is affected by an affected[] entry: listed
+// in versions, or inside an ECOSYSTEM/SEMVER range. GIT ranges name commits and
+// are skipped. fixed is the range's fix version above the installed one.
+func osvAffects(a *osvAffected, eco, version string) (affected bool, fixed string) {
+	for _, v := range a.Versions {
+		if v == version {
+			affected = true
+		}
+	}
+	cmp := osvComparator(eco)
+	for _, r := range a.Ranges {
+		if r.Type == "GIT" {
+			continue
+		}
+		c := cmp
+		if r.Type == "SEMVER" {
+			c = compareSemverFull
+		}
+		in, fix := inOSVRange(r.Events, version, c)
+		if in {
+			affected = true
+			if fixed == "" {
+				fixed = fix
+			}
+		}
+	}
+	return affected, fixed
+}
+
+// inOSVRange evaluates a range's events in version order: an introduced at or
+// below the version opens it, a fixed at or below (or a last_affected below)
+// closes it.
+func inOSVRange(events []osvEvent, version string, cmp func(a, b string) int) (bool, string) {
+	evs := append([]osvEvent(nil), events...)
+	key := func(e osvEvent) string {
+		return e.Introduced + e.Fixed + e.LastAffected + e.Limit
+	}
+	sort.SliceStable(evs, func(i, j int) bool {
+		ki, kj := key(evs[i]), key(evs[j])
+		if ki == "0" || kj == "0" {
+			return ki == "0" && kj != "0"
+		}
+		return cmp(ki, kj) < 0
+	})
+	affected := false
+	fixed := ""
+	for _, e := range evs {
+		switch {
+		case e.Introduced != "":
+			if e.Introduced == "0" || cmp(version, e.Introduced) >= 0 {
+				affected = true
+			}
+		case e.Fixed != "":
+			if cmp(version, e.Fixed) >= 0 {
+				affected = false
+			} else if affected && fixed == "" {
+				fixed = e.Fixed
+			}
+		case e.LastAffected != "":
+			if cmp(version, e.LastAffected) > 0 {
+				affected = false
+			}
+		case e.Limit != "":
+			if cmp(version, e.Limit) >= 0 {
+				affected = false
+			}
+		}
+	}
+	return affected, fixed
+}
+
+// osvComparator returns the version ordering of an ecosystem key.
+func osvComparator(eco string) func(a, b string) int {
+	switch eco {
+	case "debian", "ubuntu":
+		return compareDebVersion
+	case "npm", "crates.io", "go":
+		return compareSemverFull
+	case "redhat", "rockylinux", "almalinux", "opensuse", "suse", "fedora", "mageia", "openeuler":
+		return compareRPMVersion
+	}
+	return compareSegments
+}
+
+// compareDebVersion orders Debian versions ([epoch:]upstream[-revision]).
+func compareDebVersion(a, b string) int {
+	ea, ua, ra := splitDebVersion(a)
+	eb, ub, rb := splitDebVersion(b)
+	if ea != eb {
+		return cmpInt(ea, eb)
+	}
+	if c := compareDebPart(ua, ub); c != 0 {
+		return c
+	}
+	return compareDebPart(ra, rb)
+}
+
+func splitDebVersion(v string) (epoch int, upstream, revision string) {
+	if e, rest, ok := strings.Cut(v, ":"); ok {
+		epoch, _ = strconv.Atoi(e)
+		v = rest
+	}
+	if i := strings.LastIndex(v, "-"); i >= 0 {
+		return epoch, v[:i], v[i+1:]
+	}
+	return epoch, v, ""
+}
+
+// compareDebPart is dpkg's verrevcmp: alternating non-digit runs (letters
+// before other characters, "~" before everything including the end) and
+// numeric runs.
+func compareDebPart(a, b string) int {
+	order := func(s string, i int) int {
+		switch {
+		case i >= len(s), isDigit(s[i]):
+			return 0
+		case s[i] >= 'A' && s[i] <= 'Z', s[i] >= 'a' && s[i] <= 'z':
+			return int(s[i])
+		case s[i] == '~':
+			return -1
+		}
+		return int(s[i]) + 256
+	}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		for (i < len(a) && !isDigit(a[i])) || (j < len(b) && !isDigit(b[j])) {
+			if ac, bc := order(a, i), order(b, j); ac != bc {
+				return cmpInt(ac, bc)
+			}
+			i++
+			j++
+		}
+		for i < len(a) && a[i] == '0' {
+			i++
+		}
+		for j < len(b) && b[j] == '0' {
+			j++
+		}
+		diff := 0
+		for i < len(a) && isDigit(a[i]) && j < len(b) && isDigit(b[j]) {
+			if diff == 0 {
+				diff = cmpInt(int(a[i]), int(b[j]))
+			}
+			i++
+			j++
+		}
+		if i < len(a) && isDigit(a[i]) {
+			return 1
+		}
+		if j < len(b) && isDigit(b[j]) {
+			return -1
+		}
+		if diff != 0 {
+			return diff
+		}
+	}
+	return 0
+}
+
+// compareRPMVersion orders rpm EVRs ([epoch:]version[-release]).
+func compareRPMVersion(a, b string) int {
+	ea, va, ra := splitRPMVersion(a)
+	eb, vb, rb := splitRPMVersion(b)
+	if ea != eb {
+		return cmpInt(ea, eb)
+	}
+	if c := compareSegments(va, vb); c != 0 {
+		return c
+	}
+	if ra == "" || rb == "" {
+		return 0 // a release-less bound matches every release
+	}
+	return compareSegments(ra, rb)
+}
+
+func splitRPMVersion(v string) (epoch int, version, release string) {
+	if e, rest, ok := strings.Cut(v, ":"); ok {
+		epoch, _ = strconv.Atoi(e)
+		v = rest
+	}
+	version, release, _ = strings.Cut(v, "-")
+	return epoch, version, release
+}
+
+// compareSegments is rpmvercmp: numeric runs compare numerically, alpha runs
+// lexically, a numeric run beats an alpha one, "~" sorts before everything and
+// separators only delimit.
+func compareSegments(a, b string) int {
+	for {
+		a = strings.TrimLeftFunc(a, isVersionSeparator)
+		b = strings.TrimLeftFunc(b, isVersionSeparator)
+		if strings.HasPrefix(a, "~") || strings.HasPrefix(b, "~") {
+			if !strings.HasPrefix(a, "~") {
+				return 1
+			}
+			if !strings.HasPrefix(b, "~") {
+				return -1
+			}
+			a, b = a[1:], b[1:]
+			continue
+		}
+		if a == "" || b == "" {
+			return cmpInt(len(a), len(b))
+		}
+		numeric := isDigit(a[0])
+		sa, ra := versionRun(a, numeric)
+		sb, rb := versionRun(b, numeric)
+		if sb == "" {
+			if numeric {
+				return 1 // numeric beats alpha
+			}
+			return -1
+		}
+		if numeric {
+			sa, sb = strings.TrimLeft(sa, "0"), strings.TrimLeft(sb, "0")
+			if len(sa) != len(sb) {
+				return cmpInt(len(sa), len(sb))
+			}
+		}
+		if c := strings.Compare(sa, sb); c != 0 {
+			return c
+		}
+		a, b = ra, rb
+	}
+}
+
+func versionRun(s string, numeric bool) (run, rest string) {
+	i := 0
+	for i < len(s) && s[i] != '~' && !isVersionSeparator(rune(s[i])) && isDigit(s[i]) == numeric {
+		i++
+	}
+	return s[:i], s[i:]
+}
+
+func isVersionSeparator(r rune) bool {
+	return !(r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '~')
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+func cmpInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// compareSemverFull orders semantic versions including pre-release identifiers
+// (1.0.0-rc.1 < 1.0.0); build metadata is ignored.
+func compareSemverFull(a, b string) int {
+	a, _, _ = strings.Cut(strings.TrimPrefix(a, "v"), "+")
+	b, _, _ = strings.Cut(strings.TrimPrefix(b, "v"), "+")
+	ca, pa, _ := strings.Cut(a, "-")
+	cb, pb, _ := strings.Cut(b, "-")
+	if c := compareSegments(ca, cb); c != 0 {
+		return c
+	}
+	switch {
+	case pa == pb:
+		return 0
+	case pa == "":
+		return 1
+	case pb == "":
+		return -1
+	}
+	ia, ib := strings.Split(pa, "."), strings.Split(pb, ".")
+	for i := 0; i < len(ia) && i < len(ib); i++ {
+		na, errA := strconv.Atoi(ia[i])
+		nb, errB := strconv.Atoi(ib[i])
+		switch {
+		case errA == nil && errB == nil:
+			if na != nb {
+				return cmpInt(na, nb)
+			}
+		case errA == nil:
+			return -1
+		case errB == nil:
+			return 1
+		default:
+			if c := strings.Compare(ia[i], ib[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return cmpInt(len(ia), len(ib))
+}
+
+// --- severity ---
+
+// Severity levels, lowest first; "unknown" sorts below low (the audit gate
+// still fails on it — see auditReport.gate).
+var severityLevels = []string{"unknown", "low", "medium", "high", "critical"}
+
+// severityRank returns the position of a level in severityLevels, or -1.
+func severityRank(level string) int {
+	for i, l := range severityLevels {
+		if l == level {
+			return i
+		}
+	}
+	return -1
+}
+
+// normalizeSeverity maps the textual ratings of advisories (GHSA MODERATE,
+// Red Hat Important, Debian unimportant, …) onto severityLevels.
+func normalizeSeverity(s string) string {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "critical", "urgent":
+		return "critical"
+	case "high", "important":
+		return "high"
+	case "medium", "moderate":
+		return "medium"
+	case "low", "negligible", "unimportant", "minor":
+		return "low"
+	}
+	return "unknown"
+}
+
+// osvSeverityOf returns the severity level of a match and its CVSS v3 base
+// score (0 when none): the computed CVSS v3 score, else the advisory's own
+// rating (database_specific, then the affected entry's ecosystem_specific).
+func osvSeverityOf(m osvMatch) (string, float64) {
+	for _, sevs := range [][]osvSeverity{m.Entry.Severity, m.Record.Severity} {
+		for _, s := range sevs {
+			if s.Type != "CVSS_V3" {
+				continue
+			}
+			if score, ok := cvss3BaseScore(s.Score); ok {
+				return cvssLevel(score), score
+			}
+		}
+	}
+	for _, extra := range []map[string]any{m.Record.DatabaseSpecific, m.Entry.EcosystemSpecific} {
+		if s, ok := extra["severity"].(string); ok {
+			if level := normalizeSeverity(s); level != "unknown" {
+				return level, 0
+			}
+		}
+	}
+	return "unknown", 0
+}
+
+// cvssLevel maps a CVSS base score onto its qualitative rating.
+func cvssLevel(score float64) string {
+	switch {
+	case score >= 9:
+		return "critical"
+	case score >= 7:
+		return "high"
+	case score >= 4:
+		return "medium"
+	case score > 0:
+		return "low"
+	}
+	return "unknown"
+}
+
+// cvss3BaseScore computes the CVSS v3.x base score of a vector
+// ("CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H" → 9.8).
+func cvss3BaseScore(vector string) (float64, bool) {
+	parts := strings.Split(vector, "/")
+	if len(parts) < 9 || !strings.HasPrefix(parts[0], "CVSS:3") {
+		return 0, false
+	}
+	m := map[string]string{}
+	for _, p := range parts[1:] {
+		k, v, _ := strings.Cut(p, ":")
+		m[k] = v
+	}
+	changed := m["S"] == "C"
+	weights := map[string]map[string]float64{
+		"AV": {"N": 0.85, "A": 0.62, "L": 0.55, "P": 0.2},
+		"AC": {"L": 0.77, "H": 0.44},
+		"UI": {"N": 0.85, "R": 0.62},
+		"C":  {"H": 0.56, "L": 0.22, "N": 0},
+		"I":  {"H": 0.56, "L": 0.22, "N": 0},
+		"A":  {"H": 0.56, "L": 0.22, "N": 0},
+		"PR": {"N": 0.85, "L": 0.62, "H": 0.27},
+	}
+	if changed {
+		weights["PR"] = map[string]float64{"N": 0.85, "L": 0.68, "H": 0.5}
+	}
+	w := map[string]float64{}
+	for k, table := range weights {
+		v, ok := table[m[k]]
+		if !ok {
+			return 0, false
+		}
+		w[k] = v
+	}
+	if m["S"] != "U" && !changed {
+		return 0, false
+	}
+	iss := 1 - (1-w["C"])*(1-w["I"])*(1-w["A"])
+	impact := 6.42 * iss
+	if changed {
+		impact = 7.52*(iss-0.029) - 3.25*math.Pow(iss-0.02, 15)
+	}
+	if impact <= 0 {
+		return 0, true
+	}
+	exploit := 8.22 * w["AV"] * w["AC"] * w["PR"] * w["UI"]
+	if changed {
+		return cvssRoundUp(math.Min(1.08*(impact+exploit), 10)), true
+	}
+	return cvssRoundUp(math.Min(impact+exploit, 10)), true
+}
+
+// cvssRoundUp is the CVSS v3.1 Roundup: the smallest one-decimal number at or
+// above x, computed on integers to avoid float artefacts.
+func cvssRoundUp(x float64) float64 {
+	i := int(math.Round(x * 100000))
+	if i%10000 == 0 {
+		return float64(i) / 100000
+	}
+	return float64(i/10000+1) / 10
+}
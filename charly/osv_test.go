@@ -0,0 +1,158 @@
+package main
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCompareVersions(t *testing.T) {
+	for _, tc := range []struct {
+		cmp  func(a, b string) int
+		a, b string
+		want int
+	}{
+		{compareDebVersion, "3.0.11-1~deb12u2", "3.0.11-1", -1},
+		{compareDebVersion, "1:1.0-1", "2.0-1", 1},
+		{compareDebVersion, "1.2.10", "1.2.9", 1},
+		{compareDebVersion, "1.0~rc1", "1.0", -1},
+		{compareDebVersion, "1.0a", "1.0+b1", -1},
+		{compareDebVersion, "2.36-9+deb12u4", "2.36-9+deb12u4", 0},
+		{compareRPMVersion, "3.0.9-2.fc39", "3.0.9-10.fc39", -1},
+		{compareRPMVersion, "1:2.0-1", "3.0-1", 1},
+		{compareRPMVersion, "3.0.9", "3.0.9-2.fc39", 0},
+		{compareRPMVersion, "1.0~beta", "1.0", -1},
+		{compareRPMVersion, "1.0a", "1.0.1", -1},
+		{compareSemverFull, "1.0.0-rc.1", "1.0.0", -1},
+		{compareSemverFull, "1.0.0-alpha.2", "1.0.0-alpha.10", -1},
+		{compareSemverFull, "4.17.21", "4.17.3", 1},
+		{compareSegments, "3.11.4", "3.11.10", -1},
+	} {
+		if got := tc.cmp(tc.a, tc.b); got != tc.want {
+			t.Errorf("compare(%q, %q) = %d, want %d", tc.a, tc.b, got, tc.want)
+		}
+		if got := tc.cmp(tc.b, tc.a); got != -tc.want {
+			t.Errorf("compare(%q, %q) = %d, want %d", tc.b, tc.a, got, -tc.want)
+		}
+	}
+}
+
+func TestInOSVRange(t *testing.T) {
+	events := []osvEvent{{Fixed: "1.5.0"}, {Introduced: "0"}, {Introduced: "2.0.0"}, {LastAffected: "2.1.0"}}
+	for v, want := range map[string]bool{
+		"1.0.0": true, "1.5.0": false, "1.9.9": false, "2.0.0": true, "2.1.0": true, "2.1.1": false,
+	} {
+		got, fixed := inOSVRange(events, v, compareSemverFull)
+		if got != want {
+			t.Errorf("inOSVRange(%s) = %v, want %v", v, got, want)
+		}
+		if v == "1.0.0" && fixed != "1.5.0" {
+			t.Errorf("fixed = %q, want 1.5.0", fixed)
+		}
+	}
+}
+
+func TestCVSS3BaseScore(t *testing.T) {
+	for vector, want := range map[string]float64{
+		"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H": 9.8,
+		"CVSS:3.1/AV:N/AC:L/PR:N/UI:R/S:C/C:L/I:L/A:N": 6.1,
+		"CVSS:3.0/AV:L/AC:L/PR:L/UI:N/S:U/C:H/I:N/A:N": 5.5,
+		"CVSS:3.1/AV:N/AC:H/PR:N/UI:N/S:U/C:N/I:N/A:N": 0,
+	} {
+		got, ok := cvss3BaseScore(vector)
+		if !ok || got != want {
+			t.Errorf("cvss3BaseScore(%s) = %v, %v; want %v", vector, got, ok, want)
+		}
+	}
+	if _, ok := cvss3BaseScore("CVSS:4.0/AV:N"); ok {
+		t.Error("CVSS v4 vector accepted")
+	}
+}
+
+// writeOSVRecords writes OSV records as <id>.json files into dir.
+func writeOSVRecords(t *testing.T, dir string, records map[string]string) {
+	t.Helper()
+	for id, body := range records {
+		if err := os.WriteFile(filepath.Join(dir, id+".json"), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+var testOSVRecords = map[string]string{
+	"DSA-1": `{"id": "DSA-1", "aliases": ["CVE-2026-1000"], "summary": "openssl overflow",
+		"affected": [{"package": {"ecosystem": "Debian:13", "name": "openssl"},
+			"ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "0"}, {"fixed": "3.5.1-1"}]}],
+			"ecosystem_specific": {"urgency": "high"}}],
+		"severity": [{"type": "CVSS_V3", "score": "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"}]}`,
+	"DSA-2": `{"id": "DSA-2", "affected": [{"package": {"ecosystem": "Debian:12", "name": "openssl"},
+		"ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "0"}, {"fixed": "3.0.11-1"}]}]}]}`,
+	"GHSA-x": `{"id": "GHSA-x", "summary": "prototype pollution",
+		"affected": [{"package": {"ecosystem": "npm", "name": "lodash"},
+			"ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}, {"fixed": "4.17.21"}]}]}],
+		"database_specific": {"severity": "MODERATE"}}`,
+	"PYSEC-1": `{"id": "PYSEC-1", "affected": [{"package": {"ecosystem": "PyPI", "name": "Py_YAML"},
+		"versions": ["5.3"]}]}`,
+	"GONE-1": `{"id": "GONE-1", "withdrawn": "2026-01-01T00:00:00Z", "affected": [{"package": {"ecosystem": "npm", "name": "lodash"},
+		"ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}]}]}]}`,
+}
+
+func TestOSVDBLookup(t *testing.T) {
+	dir := t.TempDir()
+	writeOSVRecords(t, dir, testOSVRecords)
+	db, err := loadOSVDB(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if db.Records != 4 {
+		t.Errorf("Records = %d, want 4 (withdrawn dropped)", db.Records)
+	}
+	// Debian:12 is another release; DSA-1's fix is above the installed version.
+	m := db.lookup("debian", "13", "openssl", "3.5.0-2")
+	if len(m) != 1 || m[0].Record.ID != "DSA-1" || m[0].Fixed != "3.5.1-1" {
+		t.Fatalf("debian lookup = %+v", m)
+	}
+	if level, score := osvSeverityOf(m[0]); level != "critical" || score != 9.8 {
+		t.Errorf("severity = %s %v", level, score)
+	}
+	if m := db.lookup("debian", "13", "openssl", "3.5.1-1"); len(m) != 0 {
+		t.Errorf("fixed version matched: %+v", m)
+	}
+	m = db.lookup("npm", "", "lodash", "4.17.20")
+	if len(m) != 1 {
+		t.Fatalf("npm lookup = %+v", m)
+	}
+	if level, _ := osvSeverityOf(m[0]); level != "medium" {
+		t.Errorf("GHSA MODERATE → %s", level)
+	}
+	if m := db.lookup("pypi", "", "py-yaml", "5.3"); len(m) != 1 {
+		t.Errorf("PEP 503 name normalization: %+v", m)
+	}
+
+	// The same records as osv.dev ships them, one zip per ecosystem.
+	zipPath := filepath.Join(t.TempDir(), "all.zip")
+	f, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for id, body := range testOSVRecords {
+		w, err := zw.Create(id + ".json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(body))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	zdb, err := loadOSVDB(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if zdb.Records != db.Records {
+		t.Errorf("zip Records = %d, want %d", zdb.Records, db.Records)
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// Multi-architecture support. A box's `platform:` list (linux/amd64,
+// linux/arm64) is the set of architectures it is built for; everything that
+// can differ per architecture is keyed by the GOARCH spelling of the
+// platform's second segment:
+//
+//   - candy `distro: <tag>@<arch>:` blocks — arch-qualified package sets and
+//     repos, consulted by the cascade (cascadeTagChain) only when generating
+//     for that architecture;
+//   - candy `supported_arch:` / distro `package_arch:` lists — the
+//     architectures they can be built for;
+//   - pixi.toml `platforms` — the conda platforms a pixi environment solves for.
+//
+// When a box targets an architecture other than the build host's, the go and
+// cargo builder stages run on the BUILD platform and cross-compile for the
+// target (FROM --platform=$BUILDPLATFORM + TARGETARCH), so an arm64 box builds
+// on an amd64 host without emulating the compilers. npm stays on the target
+// platform: its native addons must match the image's architecture.
+
+// platformArch returns the architecture of an OCI platform string
+// ("linux/arm64/v8" → "arm64"). A bare architecture is returned unchanged.
+func platformArch(platform string) string {
+	parts := strings.Split(platform, "/")
+	if len(parts) == 1 {
+		return parts[0]
+	}
+	return parts[1]
+}
+
+// archTag is the cascade tag of an arch-qualified distro block
+// (fedora, arm64 → fedora@arm64).
+func archTag(tag, arch string) string {
+	return tag + "@" + arch
+}
+
+// archListed reports whether arch is in an arch list; an empty list admits
+// every architecture.
+func archListed(list []string, arch string) bool {
+	return len(list) == 0 || slices.Contains(list, arch)
+}
+
+// boxArches returns the distinct architectures of a box's platforms in
+// declaration order.
+func boxArches(img *ResolvedBox) []string {
+	var out []string
+	for _, p := range img.Platforms {
+		if a := platformArch(p); !slices.Contains(out, a) {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// targetArch is the architecture the box's primary Containerfile is generated
+// for: its resolved Arch, else the host's.
+func (img *ResolvedBox) targetArch() string {
+	if img.Arch != "" {
+		return img.Arch
+	}
+	return runtime.GOARCH
+}
+
+// crossBuilds reports whether the box builds for an architecture other than
+// the build host's — the only case its builder stages take the cross form.
+func (img *ResolvedBox) crossBuilds() bool {
+	if img.targetArch() != runtime.GOARCH {
+		return true
+	}
+	for _, a := range boxArches(img) {
+		if a != runtime.GOARCH {
+			return true
+		}
+	}
+	return false
+}
+
+// pixiPlatformArch maps pixi / conda platform names onto architectures.
+var pixiPlatformArch = map[string]string{
+	"linux-64":      "amd64",
+	"linux-aarch64": "arm64",
+}
+
+// pixiArches returns the architectures a candy's pixi.toml solves for, or nil
+// when the manifest declares no platforms (or cannot be read — the pixi
+// install reports that).
+func pixiArches(layer *Candy) []string {
+	if !layer.HasPixiToml {
+		return nil
+	}
+	data, err := os.ReadFile(filepath.Join(layer.SourceDir, "pixi.toml"))
+	if err != nil {
+		return nil
+	}
+	var manifest struct {
+		Workspace struct {
+			Platforms []string `toml:"platforms"`
+		} `toml:"workspace"`
+		Project struct {
+			Platforms []string `toml:"platforms"`
+		} `toml:"project"`
+	}
+	if toml.Unmarshal(data, &manifest) != nil {
+		return nil
+	}
+	platforms := manifest.Workspace.Platforms
+	if len(platforms) == 0 {
+		platforms = manifest.Project.Platforms
+	}
+	var out []string
+	for _, p := range platforms {
+		if a, ok := pixiPlatformArch[p]; ok && !slices.Contains(out, a) {
+			out = append(out, a)
+		}
+	}
+	if len(platforms) > 0 && len(out) == 0 {
+		return []string{"none"} // only non-Linux platforms
+	}
+	return out
+}
+
+// validateBoxPlatforms checks that every box can be built for each
+// architecture it targets: the distro's `package_arch:` list, each candy's
+// `supported_arch:` list and each pixi candy's pixi.toml platforms must cover it. Only boxes
+// whose platforms were chosen explicitly (box or defaults `platform:`, or
+// --platform) are checked — the built-in default is best-effort.
+func validateBoxPlatforms(cfg *Config, layers map[string]*Candy, dir string, opts ResolveOpts, errs *ValidationError) {
+	names := make([]string, 0, len(cfg.Box))
+	for name := range cfg.Box {
+		names = append(names, name)
+	}
+	sortStrings(names)
+	for _, name := range names {
+		if box := cfg.Box[name]; !box.IsEnabled() && !opts.shouldIncludeDisabled(name) {
+			continue
+		}
+		img, err := cfg.ResolveBox(name, "test", dir, opts)
+		if err != nil || !img.ExplicitPlatforms {
+			continue
+		}
+		for _, arch := range boxArches(img) {
+			if img.DistroDef != nil && len(img.Distro) > 0 && !archListed(img.DistroDef.PackageArch, arch) {
+				errs.Add("box %q: platform %s: distro %s supports only %s", name, "linux/"+arch, img.Distro[0], strings.Join(img.DistroDef.PackageArch, ", "))
+			}
+		}
+		candies, err := ResolveCandyOrder(img.Candy, layers, nil)
+		if err != nil {
+			continue // reported by the candy DAG check
+		}
+		for _, candyName := range candies {
+			layer := layers[candyName]
+			if layer == nil {
+				continue
+			}
+			pixi := pixiArches(layer)
+			for _, arch := range boxArches(img) {
+				if !layer.SupportsArch(arch) {
+					errs.Add("box %q: platform %s: candy %q supports only %s", name, "linux/"+arch, candyName, strings.Join(layer.supportedArch, ", "))
+				}
+				if !archListed(pixi, arch) {
+					errs.Add("box %q: platform %s: candy %q pixi.toml platforms do not include %s", name, "linux/"+arch, candyName, pixiPlatformFor(arch))
+				}
+			}
+		}
+	}
+}
+
+// pixiPlatformFor returns the pixi platform name of arch (arm64 → linux-aarch64).
+func pixiPlatformFor(arch string) string {
+	for p, a := range pixiPlatformArch {
+		if a == arch {
+			return p
+		}
+	}
+	return "linux-" + arch
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestPlatformArch(t *testing.T) {
+	for in, want := range map[string]string{
+		"linux/amd64":    "amd64",
+		"linux/arm64/v8": "arm64",
+		"arm64":          "arm64",
+	} {
+		if got := platformArch(in); got != want {
+			t.Errorf("platformArch(%q) = %q, want %q", in, got, want)
+		}
+	}
+	img := &ResolvedBox{Platforms: []string{"linux/arm64", "linux/amd64", "linux/arm64/v8"}}
+	if got := boxArches(img); strings.Join(got, ",") != "arm64,amd64" {
+		t.Errorf("boxArches = %v", got)
+	}
+}
+
+// Only a box targeting a non-host architecture takes the cross builder
+// forms; a native build keeps cargo inline even with a cargo builder named.
+func TestCrossBuilds(t *testing.T) {
+	other := "arm64"
+	if runtime.GOARCH == "arm64" {
+		other = "amd64"
+	}
+	native := &ResolvedBox{Name: "app", Platforms: []string{"linux/" + runtime.GOARCH}, Builder: BuilderMap{"cargo": "builder"}}
+	multi := &ResolvedBox{Name: "app", Platforms: []string{"linux/" + runtime.GOARCH, "linux/" + other}, Builder: BuilderMap{"cargo": "builder"}}
+	if native.crossBuilds() || (&ResolvedBox{}).crossBuilds() {
+		t.Error("a host-arch box should not cross-build")
+	}
+	if !multi.crossBuilds() || !(&ResolvedBox{Arch: other}).crossBuilds() {
+		t.Error("a box targeting another arch should cross-build")
+	}
+
+	g := &Generator{Boxes: map[string]*ResolvedBox{"builder": {Name: "builder"}}}
+	cargo := &BuilderDef{Inline: true}
+	if g.crossBuildsInline(native, "cargo", cargo) {
+		t.Error("native cargo should stay inline")
+	}
+	if !g.crossBuildsInline(multi, "cargo", cargo) {
+		t.Error("multi-arch cargo with a builder image should render the cross stage")
+	}
+}
+
+// archGenerator builds a one-box generator whose candy installs an extra
+// package on arm64 only.
+func archGenerator(t *testing.T, platforms ...string) *Generator {
+	t.Helper()
+	layer := deriveCandy(t, "name: tools\ndistro:\n  debian:\n    package: [tool]\n  debian@arm64:\n    package: [tool-arm-firmware]\n")
+	layer.Name = "tools"
+	return &Generator{
+		BuildDir: t.TempDir(),
+		Config:   &Config{},
+		Candies:  map[string]*Candy{"tools": layer},
+		Boxes: map[string]*ResolvedBox{
+			"app": {
+				Name:           "app",
+				Base:           "debian:13",
+				IsExternalBase: true,
+				FullTag:        "ghcr.io/test/app:1",
+				Candy:          []string{"tools"},
+				Pkg:            "deb",
+				BuildFormats:   []string{"deb"},
+				Distro:         []string{"debian:13", "debian"},
+				Platforms:      platforms,
+				Arch:           platformArch(platforms[0]),
+				User:           "user",
+				UID:            1000,
+				GID:            1000,
+				Home:           "/home/user",
+				DistroDef: &DistroDef{Format: map[string]*FormatDef{
+					"deb": {InstallTemplate: "RUN apt-get install -y {{range .Packages}}{{.}} {{end}}\n"},
+				}},
+				BuilderConfig: &BuilderConfig{Builder: map[string]*BuilderDef{}},
+			},
+		},
+		Containerfiles: make(map[string]string),
+	}
+}
+
+// TestGenerateArchVariants generates a two-platform box whose candy has an
+// arm64-qualified block: the primary Containerfile targets amd64, and a
+// Containerfile.arm64 carries the arm64 packages.
+func TestGenerateArchVariants(t *testing.T) {
+	g := archGenerator(t, "linux/amd64", "linux/arm64")
+	if err := g.generateContainerfile("app"); err != nil {
+		t.Fatal(err)
+	}
+	primary := g.Containerfiles["app"]
+	if !strings.Contains(primary, "tool ") || strings.Contains(primary, "tool-arm-firmware") {
+		t.Errorf("primary (amd64) Containerfile packages wrong:\n%s", primary)
+	}
+	arm := g.ArchContainerfiles["app"]["arm64"]
+	if !strings.Contains(arm, "tool-arm-firmware") || !strings.Contains(arm, ".build/app/Containerfile.arm64 (generated") {
+		t.Errorf("arm64 variant wrong:\n%s", arm)
+	}
+	onDisk, err := os.ReadFile(filepath.Join(g.BuildDir, "app", "Containerfile.arm64"))
+	if err != nil || string(onDisk) != arm {
+		t.Errorf("Containerfile.arm64 on disk = %q, %v", onDisk, err)
+	}
+	if got := g.containerfileFor("app", "linux/arm64"); got != arm {
+		t.Error("containerfileFor(linux/arm64) is not the variant")
+	}
+	if got := g.containerfileFor("app", "linux/amd64"); got != primary {
+		t.Error("containerfileFor(linux/amd64) is not the primary")
+	}
+	if g.Boxes["app"].Arch != "amd64" {
+		t.Errorf("Arch not restored: %q", g.Boxes["app"].Arch)
+	}
+}
+
+// TestGenerateArm64Only generates an arm64-only box, as `--platform
+// linux/arm64` does on any host: the one Containerfile is the arm64 one.
+func TestGenerateArm64Only(t *testing.T) {
+	g := archGenerator(t, "linux/arm64")
+	if err := g.generateContainerfile("app"); err != nil {
+		t.Fatal(err)
+	}
+	if cf := g.Containerfiles["app"]; !strings.Contains(cf, "tool-arm-firmware") {
+		t.Errorf("arm64 Containerfile lacks the arm64 packages:\n%s", cf)
+	}
+	if len(g.ArchContainerfiles) != 0 {
+		t.Errorf("single-platform box has variants: %v", g.ArchContainerfiles)
+	}
+}
+
+func TestResolvePlatforms(t *testing.T) {
+	dir := testProjectDir(t)
+	cfg := &Config{
+		Defaults: BoxConfig{Registry: "ghcr.io/test", Build: BuildFormats{"pac"}},
+		Box: map[string]BoxConfig{
+			"arch-img": {Base: "archlinux:latest", Distro: []string{"arch"}},
+			"arm-img":  {Base: "archlinux:latest", Distro: []string{"arch"}, Platforms: []string{"linux/arm64"}},
+		},
+	}
+	img, err := cfg.ResolveBox("arch-img", "test", dir, ResolveOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The implicit amd64+arm64 default narrows to the distro's arch: list.
+	if strings.Join(img.Platforms, ",") != "linux/amd64" || img.ExplicitPlatforms || img.Arch != "amd64" {
+		t.Errorf("implicit platforms = %v explicit=%v arch=%s", img.Platforms, img.ExplicitPlatforms, img.Arch)
+	}
+	img, err = cfg.ResolveBox("arm-img", "test", dir, ResolveOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(img.Platforms, ",") != "linux/arm64" || !img.ExplicitPlatforms || img.Arch != "arm64" {
+		t.Errorf("explicit platforms = %v explicit=%v arch=%s", img.Platforms, img.ExplicitPlatforms, img.Arch)
+	}
+	img, err = cfg.ResolveBox("arch-img", "test", dir, ResolveOpts{Platform: "linux/arm64,linux/amd64"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(img.Platforms, ",") != "linux/arm64,linux/amd64" || img.Arch != "arm64" {
+		t.Errorf("--platform override = %v arch=%s", img.Platforms, img.Arch)
+	}
+}
+
+func TestValidateBoxPlatforms(t *testing.T) {
+	dir := testProjectDir(t)
+	pixiDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(pixiDir, "pixi.toml"), []byte("[workspace]\nchannels = [\"conda-forge\"]\nplatforms = [\"linux-64\"]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &Config{
+		Defaults: BoxConfig{Registry: "ghcr.io/test", Build: BuildFormats{"rpm"}},
+		Box: map[string]BoxConfig{
+			"app":      {Base: "quay.io/fedora/fedora:43", Distro: []string{"fedora"}, Candy: []string{"x86-tool", "py"}},
+			"arch-arm": {Base: "archlinux:latest", Distro: []string{"arch"}, Build: BuildFormats{"pac"}},
+		},
+	}
+	layers := map[string]*Candy{
+		"x86-tool": {Name: "x86-tool", supportedArch: []string{"amd64"}},
+		"py":       {Name: "py", HasPixiToml: true, SourceDir: pixiDir},
+	}
+
+	run := func(platform string) []string {
+		errs := &ValidationError{}
+		validateBoxPlatforms(cfg, layers, dir, ResolveOpts{Platform: platform}, errs)
+		return errs.Errors
+	}
+
+	if got := run("linux/amd64"); len(got) != 0 {
+		t.Errorf("amd64 errors: %v", got)
+	}
+	got := strings.Join(run("linux/arm64"), "\n")
+	for _, want := range []string{
+		`box "app": platform linux/arm64: candy "x86-tool" supports only amd64`,
+		`box "app": platform linux/arm64: candy "py" pixi.toml platforms do not include linux-aarch64`,
+		`box "arch-arm": platform linux/arm64: distro arch supports only amd64`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("arm64 errors missing %q:\n%s", want, got)
+		}
+	}
+
+	// The built-in default platforms are not enforced.
+	if got := run(""); len(got) != 0 {
+		t.Errorf("implicit-platform errors: %v", got)
+	}
+}
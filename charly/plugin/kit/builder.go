@@ -1,8 +1,8 @@
 package kit
 
-// builder.go — the SINGLE shared implementation of the four detection-builders'
-// DEPLOY-TIME IR shim (cargo / npm / pixi / aur), R3. Each builder is served by its OWN
-// composable plugin candy (candy/plugin-builder-<word>), but all four dispatch the SAME two
+// builder.go — the SINGLE shared implementation of the detection-builders'
+// DEPLOY-TIME IR shim (cargo / go / npm / pixi / aur), R3. Each builder is served by its OWN
+// composable plugin candy (candy/plugin-builder-<word>), but all of them dispatch the SAME two
 // pure functions here, keyed by the builder word — so the per-builder Go logic lives in ONE
 // place a leaf plugin module imports (this package depends only on the stdlib + charly/spec).
 //
@@ -12,14 +12,21 @@ package kit
 //   - BuilderCollectContext: the per-candy stage-context keys the host merges onto the base
 //     ({layer,builder,home}) to form BuilderStep.RawStageContext. Behaviour-preserving copy of
 //     the former in-proc CollectContext bodies (pixi → constant default env; aur → its section
-//     packages + replaces; cargo/npm → none, refined host-side at install time).
+//     packages + replaces; go → its main packages, ldflags and the binaries they produce;
+//     cargo/npm → none, refined host-side at install time).
 //   - BuilderReverse: the teardown ops for a resolved stage context. The builder-specific
-//     reverse-op KIND (pixi-env-remove / npm-uninstall-g / cargo-uninstall / package-remove) is
-//     exactly the logic this externalization moves out-of-process. For aur the host fills the
+//     reverse-op KIND (pixi-env-remove / npm-uninstall-g / cargo-uninstall / rm-file-user /
+//     package-remove) is exactly the logic this externalization moves out-of-process. For aur the host fills the
 //     package-remove UninstallCmd later (fillReverseUninstallCmds), so only Kind/Format/Targets/
 //     Scope are named here.
 
-import "github.com/overthinkos/overthink/charly/spec"
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/overthinkos/overthink/charly/spec"
+)
 
 // BuilderCollectContext returns the builder-specific stage-context keys for `word` given the
 // host-supplied candy descriptor. An unknown word returns nil (a custom candy builder with no
@@ -40,6 +47,29 @@ func BuilderCollectContext(word string, in spec.BuilderCollectInput) map[string]
 			ctx["replaces"] = in.Replaces
 		}
 		return ctx
+	case "go":
+		// The host build script (phase.install.host) reads main + ldflags; binaries are the
+		// absolute $HOME/.local/bin paths that script writes, for the rm-file-user teardown.
+		main := in.Main
+		if len(main) == 0 {
+			main = []string{"."}
+		}
+		ctx := map[string]any{"main": main}
+		if in.Ldflags != "" {
+			ctx["ldflags"] = in.Ldflags
+		}
+		if in.Home != "" {
+			var bins []string
+			for _, pkg := range main {
+				if name := goBinaryName(in.Module, pkg); name != "" {
+					bins = append(bins, path.Join(in.Home, ".local", "bin", name))
+				}
+			}
+			if len(bins) > 0 {
+				ctx["binaries"] = bins
+			}
+		}
+		return ctx
 	case "npm", "cargo":
 		// Globals (npm) / binaries (cargo) are read from package.json / Cargo.toml host-side at
 		// install time (best-effort) — nothing derivable from the candy manifest alone.
@@ -79,6 +109,15 @@ func BuilderReverse(word string, in spec.BuilderReverseInput) []spec.ReverseOp {
 				Scope:   spec.ScopeUser,
 			}}
 		}
+	case "go":
+		// go binaries are plain files the host build script wrote into ~/.local/bin.
+		if bins := builderCtxStringSlice(in.Context, "binaries"); len(bins) > 0 {
+			return []spec.ReverseOp{{
+				Kind:    spec.ReverseOpRmFileUser,
+				Targets: bins,
+				Scope:   spec.ScopeUser,
+			}}
+		}
 	case "aur":
 		// aur packages install into the host package DB; reverse is a package-remove (the host
 		// renders UninstallCmd from the format's uninstall_template via fillReverseUninstallCmds).
@@ -126,3 +165,29 @@ func builderCtxStringSlice(m map[string]any, key string) []string {
 	}
 	return nil
 }
+
+// goMajorVersion matches a module major-version path suffix (v2, v3, …).
+var goMajorVersion = regexp.MustCompile(`^v[2-9][0-9]*$`)
+
+// goBinaryName returns the executable name `go build -o <dir>/` gives main package pkg of
+// module: the last import-path element, skipping a major-version suffix. A relative pkg
+// resolves against the module path. Empty for a pattern (./...) or an unknown module.
+func goBinaryName(module, pkg string) string {
+	pkg = strings.TrimSuffix(pkg, "/")
+	if strings.Contains(pkg, "...") {
+		return ""
+	}
+	if pkg == "." || pkg == "" {
+		pkg = module
+	} else if rel, ok := strings.CutPrefix(pkg, "./"); ok {
+		pkg = path.Join(module, rel)
+	}
+	if pkg == "" {
+		return ""
+	}
+	name := path.Base(pkg)
+	if goMajorVersion.MatchString(name) && path.Dir(pkg) != "." {
+		name = path.Base(path.Dir(pkg))
+	}
+	return name
+}
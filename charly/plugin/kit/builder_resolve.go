@@ -1,7 +1,7 @@
 package kit
 
-// builder_resolve.go — the SINGLE shared implementation of the four detection-builders'
-// BUILD-TIME multi-stage render (pixi / npm / aur / cargo), R3. It is the build-time
+// builder_resolve.go — the SINGLE shared implementation of the detection-builders'
+// BUILD-TIME multi-stage render (pixi / npm / aur / cargo / go), R3. It is the build-time
 // counterpart of builder.go's DEPLOY-time legs (BuilderCollectContext / BuilderReverse):
 // where those carry the per-candy stage context + teardown ops out-of-process, THIS renders
 // the multi-stage build itself out-of-process (via each builder plugin's OpResolve leg), so
@@ -9,7 +9,7 @@ package kit
 // embedded builder: vocabulary (the former generate.go emitBuilderStages / emitBuilderArtifacts
 // StageTemplate render).
 //
-// The four stage templates below are the VERBATIM former embedded builder: vocabulary
+// The stage templates below started as the VERBATIM former embedded builder: vocabulary
 // stage_template / install_template strings (charly/charly.yml), relocated here as the ONE
 // source both the OUT-OF-PROCESS box-build path (the plugin's OpResolve) and the IN-PROC
 // pod-overlay build-emit (stepEmitBuilder) render. The ONLY change from the vocab text: the
@@ -37,7 +37,9 @@ import (
 // pre-rendered {{.CacheMountsOwned}}).
 const pixiStageTemplate = "FROM {{.BuilderRef}} AS {{.StageName}}\nUSER {{.UID}}\nWORKDIR {{.Home}}\n{{- if .HasLockFile}}\nCOPY --chown={{.UID}}:{{.GID}} {{.CopySrc}}/pixi.lock pixi.lock\n{{- end}}\nCOPY --chown={{.UID}}:{{.GID}} {{.CopySrc}}/{{.Manifest}} {{.Manifest}}\n{{.ManylinuxFix}}\nENV PIXI_CACHE_DIR=/tmp/pixi-cache RATTLER_CACHE_DIR=/tmp/rattler-cache\n{{- if .HasBuildScript}}\n# build.sh is COPY'd (NOT bind-mounted) so its CONTENT is part of this\n# stage's BuildKit cache key — editing build.sh (e.g. the pixelflux\n# NVENC patch) MUST invalidate the compile. A\n# `--mount=type=bind,from=<stage>,source=/build.sh` delivers the file\n# but its content NEVER enters the RUN cache key (the key is parent-SHA\n# + COPY'd manifest + RUN-text), so a changed build.sh silently reused\n# a stale compiled artifact — the \"new code not picked up\" bug. COPY\n# keys it exactly like pixi.toml / pixi.lock above.\nCOPY --chown={{.UID}}:{{.GID}} {{.CopySrc}}/{{.BuildScript}} /tmp/{{.BuildScript}}\nRUN {{.CacheMountsOwned}}{{.InstallCmd}} && bash /tmp/{{.BuildScript}} && rm -f {{.Manifest}} pixi.lock\n{{- else}}\nRUN {{.CacheMountsOwned}}{{.InstallCmd}} && rm -f {{.Manifest}} pixi.lock\n{{- end}}\n"
 
-// npmStageTemplate is the verbatim former builder.npm.stage_template.
+// npmStageTemplate is the verbatim former builder.npm.stage_template. It deliberately runs on
+// the TARGET platform, never $BUILDPLATFORM: a package's native addons (node-gyp builds and
+// prebuilt binaries alike) must come out for the architecture the image runs on.
 const npmStageTemplate = "FROM {{.BuilderRef}} AS {{.StageName}}\nUSER {{.UID}}\nWORKDIR {{.Home}}\n# Override NPM_CONFIG_PREFIX from the builder image so npm writes to\n# the TARGET image's HOME (not the builder's /home/user). Without this,\n# uid=0 target images silently get empty copy_artifacts.\nENV NPM_CONFIG_PREFIX={{.Home}}/.npm-global\nCOPY --chown={{.UID}}:{{.GID}} {{.CopySrc}}/package.json package.json\nRUN {{.CacheMountsOwned}}node -e 'var d=require(\"./package.json\").dependencies||{};for(var[n,v]of Object.entries(d))console.log(v===\"*\"?n:n+\"@\"+v)' | xargs npm install -g && rm -f package.json\n"
 
 // aurStageTemplate is the verbatim former builder.aur.stage_template (cache-mount func →
@@ -49,6 +51,20 @@ const aurStageTemplate = "FROM {{.BuilderRef}} AS {{.StageName}}\nUSER root\nRUN
 // this RUN emits IN the main image, returned as BuilderResolveReply.InlineFragment.
 const cargoInlineTemplate = "RUN --mount=type=bind,from={{.LayerStage}},source=/,target=/ctx \\\n    {{.CacheMountsOwned}}cargo install --path /ctx\n"
 
+// cargoCrossStageTemplate is cargo's stage form, used when the box targets an architecture
+// other than the build host's and names a cargo builder image (Cross + BuilderRef set): the
+// stage runs on the BUILD platform, adds the rustup target for the target platform and links
+// with the matching cross gcc, so an arm64 box compiles on an amd64 host without emulation.
+// Binaries land in /tmp/cargo-out/bin/, the artifact dir.
+const cargoCrossStageTemplate = "FROM --platform=$BUILDPLATFORM {{.BuilderRef}} AS {{.StageName}}\nARG TARGETARCH\nUSER {{.UID}}\nWORKDIR /tmp/cargo-src\nCOPY --chown={{.UID}}:{{.GID}} {{.CopySrc}}/ ./\nENV CARGO_TARGET_DIR=/tmp/cargo-target\nRUN {{.CacheMountsOwned}}case \"${TARGETARCH:-$(uname -m)}\" in \\\n      amd64|x86_64) T=x86_64-unknown-linux-gnu ;; \\\n      arm64|aarch64) T=aarch64-unknown-linux-gnu ;; \\\n      *) echo \"unsupported TARGETARCH $TARGETARCH\" >&2; exit 1 ;; \\\n    esac && \\\n    rustup target add \"$T\" && \\\n    if [ \"$T\" != \"$(rustc -vV | sed -n 's/^host: //p')\" ]; then \\\n      export \"CARGO_TARGET_$(echo \"$T\" | tr 'a-z-' 'A-Z_')_LINKER=${T%%-*}-linux-gnu-gcc\"; \\\n    fi && \\\n    cargo install --path . --target \"$T\" --root /tmp/cargo-out\n"
+
+// goStageTemplate builds a go.mod candy's main packages in the builder stage. GOOS/GOARCH come
+// from the target platform args; when the box targets a non-host architecture (Cross) the stage
+// also runs on the BUILD platform, so a multi-arch build compiles natively instead of under
+// emulation, while a native build keeps using the builder image as built for the target. Main and Ldflags arrive
+// shell-quoted (BuilderResolve). The binaries land in /tmp/go-bin/, the artifact dir.
+const goStageTemplate = "FROM {{if .Cross}}--platform=$BUILDPLATFORM {{end}}{{.BuilderRef}} AS {{.StageName}}\nARG TARGETOS\nARG TARGETARCH\nUSER {{.UID}}\nWORKDIR /tmp/go-src\nCOPY --chown={{.UID}}:{{.GID}} {{.CopySrc}}/ ./\nENV GOMODCACHE=/tmp/go-modcache GOCACHE=/tmp/go-cache CGO_ENABLED=0\nRUN {{.CacheMountsOwned}}GOOS=${TARGETOS:-linux} GOARCH=${TARGETARCH:-$(go env GOARCH)} \\\n    go build -trimpath -buildvcs=false{{if .Ldflags}} -ldflags={{.Ldflags}}{{end}} -o /tmp/go-bin/{{range .Main}} {{.}}{{end}}\n"
+
 // BuilderResolve renders `word`'s build-time multi-stage from the host-supplied context,
 // returning the pieces the host splices into the Containerfile: Stage (pre-main-FROM),
 // CopyArtifacts + CopyBinary (post-main-FROM), or InlineFragment (in-candy, inline builders).
@@ -86,11 +102,38 @@ func BuilderResolve(word string, in spec.BuilderResolveInput) (spec.BuilderResol
 			CopyArtifacts: []string{builderCopyLine(in.StageName, "/tmp/aur-pkgs/", "/tmp/aur-pkgs/", false, 0, 0)},
 		}, nil
 	case "cargo":
+		if in.Cross && in.BuilderRef != "" {
+			stage, err := renderBuilderStage("cargo-stage", cargoCrossStageTemplate, in)
+			if err != nil {
+				return zero, err
+			}
+			return spec.BuilderResolveReply{
+				Stage:         stage,
+				CopyArtifacts: []string{builderCopyLine(in.StageName, "/tmp/cargo-out/bin/", in.Home+"/.cargo/bin/", true, in.UID, in.GID)},
+			}, nil
+		}
 		frag, err := renderBuilderStage("cargo-inline", cargoInlineTemplate, in)
 		if err != nil {
 			return zero, err
 		}
 		return spec.BuilderResolveReply{InlineFragment: frag}, nil
+	case "go":
+		quoted := in
+		quoted.Main = make([]string, len(in.Main))
+		for i, m := range in.Main {
+			quoted.Main[i] = ShellQuote(m)
+		}
+		if in.Ldflags != "" {
+			quoted.Ldflags = ShellQuote(in.Ldflags)
+		}
+		stage, err := renderBuilderStage("go-stage", goStageTemplate, quoted)
+		if err != nil {
+			return zero, err
+		}
+		return spec.BuilderResolveReply{
+			Stage:         stage,
+			CopyArtifacts: []string{builderCopyLine(in.StageName, "/tmp/go-bin/", "/usr/local/bin/", false, 0, 0)},
+		}, nil
 	}
 	return zero, fmt.Errorf("kit.BuilderResolve: unknown detection-builder word %q", word)
 }
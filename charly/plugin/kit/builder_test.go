@@ -2,13 +2,14 @@ package kit
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/overthinkos/overthink/charly/spec"
 )
 
 // TestBuilderCollectContext covers the per-builder deploy-time stage-context derivation that the
-// four externalized detection-builder plugins (cargo/npm/pixi/aur) serve via OpCollectContext.
+// externalized detection-builder plugins (cargo/go/npm/pixi/aur) serve via OpCollectContext.
 func TestBuilderCollectContext(t *testing.T) {
 	cases := []struct {
 		word string
@@ -24,6 +25,12 @@ func TestBuilderCollectContext(t *testing.T) {
 			map[string]any{"packages": []string{"google-chrome"}, "replaces": []string{"chromium"}},
 		},
 		{"aur", spec.BuilderCollectInput{Candy: "x"}, map[string]any{}},
+		{
+			"go",
+			spec.BuilderCollectInput{Candy: "tools", Home: "/home/u", Module: "example.com/tools/v2", Main: []string{".", "./cmd/lint"}, Ldflags: "-s -w"},
+			map[string]any{"main": []string{".", "./cmd/lint"}, "ldflags": "-s -w", "binaries": []string{"/home/u/.local/bin/tools", "/home/u/.local/bin/lint"}},
+		},
+		{"go", spec.BuilderCollectInput{Candy: "x"}, map[string]any{"main": []string{"."}}},
 		{"unknown", spec.BuilderCollectInput{Candy: "x"}, nil},
 	}
 	for _, tc := range cases {
@@ -68,8 +75,120 @@ func TestBuilderReverse(t *testing.T) {
 		t.Fatalf("cargo reverse = %+v, want [cargo-uninstall rg fd]", ops)
 	}
 
+	// go → rm-file-user over the built binaries.
+	ops = BuilderReverse("go", spec.BuilderReverseInput{Context: map[string]any{"binaries": []any{"/home/u/.local/bin/tools"}}})
+	if len(ops) != 1 || ops[0].Kind != spec.ReverseOpRmFileUser || ops[0].Scope != spec.ScopeUser || ops[0].Targets[0] != "/home/u/.local/bin/tools" {
+		t.Fatalf("go reverse = %+v, want [rm-file-user /home/u/.local/bin/tools]", ops)
+	}
+
 	// unknown / empty → no teardown.
 	if got := BuilderReverse("unknown", spec.BuilderReverseInput{}); got != nil {
 		t.Fatalf("unknown reverse = %+v, want nil", got)
 	}
 }
+
+// TestBuilderResolveGo covers the go builder's cross-compiling stage: build-platform FROM,
+// target-platform GOOS/GOARCH, quoted main packages + ldflags, and the /tmp/go-bin artifact.
+func TestBuilderResolveGo(t *testing.T) {
+	reply, err := BuilderResolve("go", spec.BuilderResolveInput{
+		Candy: "tools", BuilderRef: "ghcr.io/org/fedora-builder:1", StageName: "tools-go-build",
+		CopySrc: "candy/tools", UID: 1000, GID: 1000,
+		Main: []string{"./cmd/tools"}, Ldflags: "-s -X main.version=1'2",
+		CacheMountsOwned: "--mount=type=cache,dst=/tmp/go-cache \\\n    ",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"FROM ghcr.io/org/fedora-builder:1 AS tools-go-build\n",
+		"COPY --chown=1000:1000 candy/tools/ ./\n",
+		"GOOS=${TARGETOS:-linux} GOARCH=${TARGETARCH:-$(go env GOARCH)}",
+		`-ldflags='-s -X main.version=1'\''2' -o /tmp/go-bin/ './cmd/tools'`,
+	} {
+		if !strings.Contains(reply.Stage, want) {
+			t.Errorf("stage missing %q:\n%s", want, reply.Stage)
+		}
+	}
+	if want := []string{"COPY --from=tools-go-build /tmp/go-bin/ /usr/local/bin/"}; !reflect.DeepEqual(reply.CopyArtifacts, want) {
+		t.Errorf("copy artifacts = %q, want %q", reply.CopyArtifacts, want)
+	}
+}
+
+// TestBuilderResolveCross covers the cross-compiling stage forms (Cross: the box targets a
+// non-host arch): cargo becomes a build-platform stage with a rustup target when the box names a
+// cargo builder image (inline otherwise), go's stage moves to the build platform, and npm stays
+// on the target platform regardless.
+func TestBuilderResolveCross(t *testing.T) {
+	in := spec.BuilderResolveInput{
+		Candy:      "rg",
+		BuilderRef: "ghcr.io/org/fedora-builder:1",
+		StageName:  "rg-cargo-build",
+		CopySrc:    "candy/rg",
+		UID:        1000,
+		GID:        1000,
+		Home:       "/home/user",
+		Inline:     true,
+		Cross:      true,
+	}
+	reply, err := BuilderResolve("cargo", in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply.InlineFragment != "" {
+		t.Errorf("cargo with a builder image rendered inline: %q", reply.InlineFragment)
+	}
+	for _, want := range []string{
+		"FROM --platform=$BUILDPLATFORM ghcr.io/org/fedora-builder:1 AS rg-cargo-build\n",
+		"arm64|aarch64) T=aarch64-unknown-linux-gnu",
+		`rustup target add "$T"`,
+		`cargo install --path . --target "$T" --root /tmp/cargo-out`,
+	} {
+		if !strings.Contains(reply.Stage, want) {
+			t.Errorf("cargo stage missing %q:\n%s", want, reply.Stage)
+		}
+	}
+	if want := []string{"COPY --from=rg-cargo-build --chown=1000:1000 /tmp/cargo-out/bin/ /home/user/.cargo/bin/"}; !reflect.DeepEqual(reply.CopyArtifacts, want) {
+		t.Errorf("cargo copy artifacts = %q, want %q", reply.CopyArtifacts, want)
+	}
+
+	// Without a builder image cargo stays an in-image RUN.
+	in.BuilderRef, in.StageName, in.LayerStage = "", "", "rg"
+	if reply, err = BuilderResolve("cargo", in); err != nil || !strings.Contains(reply.InlineFragment, "cargo install --path /ctx") || reply.Stage != "" {
+		t.Errorf("inline cargo = %+v, %v", reply, err)
+	}
+
+	// A native build keeps cargo inline even with a builder image named.
+	in.BuilderRef, in.Cross = "ghcr.io/org/fedora-builder:1", false
+	if reply, err = BuilderResolve("cargo", in); err != nil || reply.InlineFragment == "" || reply.Stage != "" {
+		t.Errorf("native cargo = %+v, %v", reply, err)
+	}
+
+	goIn := spec.BuilderResolveInput{Candy: "tools", BuilderRef: "ghcr.io/org/fedora-builder:1", StageName: "tools-go-build", Main: []string{"."}, Cross: true}
+	if reply, err = BuilderResolve("go", goIn); err != nil || !strings.HasPrefix(reply.Stage, "FROM --platform=$BUILDPLATFORM ghcr.io/org/fedora-builder:1 AS tools-go-build\n") {
+		t.Errorf("cross go stage = %q, %v", reply.Stage, err)
+	}
+
+	in = spec.BuilderResolveInput{Candy: "cli", BuilderRef: "ghcr.io/org/node:1", StageName: "cli-npm-build", CopySrc: "candy/cli", Home: "/home/user", Cross: true}
+	if reply, err = BuilderResolve("npm", in); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(reply.Stage, "FROM ghcr.io/org/node:1 AS cli-npm-build\n") || strings.Contains(reply.Stage, "BUILDPLATFORM") {
+		t.Errorf("npm stage left the target platform:\n%s", reply.Stage)
+	}
+}
+
+func TestGoBinaryName(t *testing.T) {
+	cases := []struct{ module, pkg, want string }{
+		{"example.com/tools", ".", "tools"},
+		{"example.com/tools/v3", ".", "tools"},
+		{"example.com/tools", "./cmd/lint/", "lint"},
+		{"example.com/tools", "example.com/tools/cmd/fmt", "fmt"},
+		{"example.com/tools", "./...", ""},
+		{"", ".", ""},
+	}
+	for _, tc := range cases {
+		if got := goBinaryName(tc.module, tc.pkg); got != tc.want {
+			t.Errorf("goBinaryName(%q, %q) = %q, want %q", tc.module, tc.pkg, got, tc.want)
+		}
+	}
+}
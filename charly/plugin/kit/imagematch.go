@@ -0,0 +1,304 @@
+package kit
+
+// imagematch.go — the SINGLE shared template-matching implementation behind the
+// framebuffer verbs' `find-image` / `click-image` methods (vnc / spice / wl), R3. Each
+// plugin captures a fresh frame its own way (RFB framebuffer, SPICE display channel,
+// grim/pixelflux over the reverse channel) and hands the decoded image here; the locate
+// logic, the score semantics, and the reported coordinates are identical across all
+// three, so a bed can swap its display verb without re-tuning match_threshold.
+//
+// Matching is zero-mean normalized cross-correlation on luminance — invariant to uniform
+// brightness/contrast shifts, so a theme tint or a compression pass does not break a
+// match. A full-resolution NCC over a 1080p frame is too slow for a check step, so the
+// search runs coarse-to-fine: an exhaustive pass on a box-downscaled pyramid level picks
+// the best few candidates, each refined at full resolution in a small window.
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	_ "image/jpeg" // register JPEG decoder for image.Decode
+	"image/png"
+	"math"
+	"os"
+	"sort"
+
+	"github.com/overthinkos/overthink/charly/spec"
+)
+
+// DefaultMatchThreshold is the minimum NCC score (0..1) a find-image match must reach
+// when the step sets no match_threshold.
+const DefaultMatchThreshold = 0.9
+
+// ImageMatch is one located template: Left/Top/Width/Height its box in the screen image,
+// X/Y its center (the point a click-image step clicks), Score the NCC similarity.
+type ImageMatch struct {
+	X      int     `json:"x"`
+	Y      int     `json:"y"`
+	Left   int     `json:"left"`
+	Top    int     `json:"top"`
+	Width  int     `json:"width"`
+	Height int     `json:"height"`
+	Score  float64 `json:"score"`
+}
+
+// JSON renders the match as the one-line object a find-image step prints, so a
+// `capture_extract: json:.x` pulls a coordinate into a later step.
+func (m ImageMatch) JSON() string {
+	m.Score = math.Round(m.Score*1000) / 1000
+	b, _ := json.Marshal(m)
+	return string(b)
+}
+
+// LoadImage decodes a PNG or JPEG file (a find-image template or a saved screenshot).
+func LoadImage(path string) (image.Image, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	img, err := DecodeImage(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return img, nil
+}
+
+// DecodeImage decodes PNG or JPEG bytes (a screenshot pulled off a venue).
+func DecodeImage(data []byte) (image.Image, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decoding image: %w", err)
+	}
+	return img, nil
+}
+
+// LocateTemplate is the per-step entry the three plugins share: given the frame the
+// plugin just captured, it saves the frame to op.Artifact when one is declared (so the
+// artifact validators and a failing run's post-mortem see exactly what was searched),
+// loads op.Template (anchored host-side), and finds it at op.MatchThreshold.
+func LocateTemplate(screen image.Image, op *spec.Op) (ImageMatch, error) {
+	if op.Artifact != "" {
+		f, err := os.Create(op.Artifact)
+		if err != nil {
+			return ImageMatch{}, fmt.Errorf("creating %s: %w", op.Artifact, err)
+		}
+		if err := png.Encode(f, screen); err != nil {
+			_ = f.Close()
+			return ImageMatch{}, fmt.Errorf("encoding PNG: %w", err)
+		}
+		if err := f.Close(); err != nil {
+			return ImageMatch{}, err
+		}
+	}
+	tmpl, err := LoadImage(op.Template)
+	if err != nil {
+		return ImageMatch{}, fmt.Errorf("loading template: %w", err)
+	}
+	return FindTemplate(screen, tmpl, op.MatchThreshold)
+}
+
+// FindTemplate locates tmpl inside screen and returns the best match. It fails when the
+// template is larger than the screen, carries no detail to correlate (a single flat
+// color), or the best score is below minScore (<= 0 selects DefaultMatchThreshold) —
+// the error names the best score and where it was, so a near miss is diagnosable.
+func FindTemplate(screen, tmpl image.Image, minScore float64) (ImageMatch, error) {
+	if minScore <= 0 {
+		minScore = DefaultMatchThreshold
+	}
+	sb, tb := screen.Bounds(), tmpl.Bounds()
+	if tb.Dx() > sb.Dx() || tb.Dy() > sb.Dy() {
+		return ImageMatch{}, fmt.Errorf("template %dx%d is larger than the screen %dx%d", tb.Dx(), tb.Dy(), sb.Dx(), sb.Dy())
+	}
+	s, t := toLuma(screen), toLuma(tmpl)
+	if t.flat() {
+		return ImageMatch{}, fmt.Errorf("template %dx%d is a single flat color — nothing to match", tb.Dx(), tb.Dy())
+	}
+
+	// Pyramid factor: the largest power of two (max 8) that keeps the template's short
+	// side at >= 8 px, so the coarse pass still sees its structure.
+	f := 1
+	for f < 8 && min(t.w, t.h)/(f*2) >= 8 {
+		f *= 2
+	}
+	var cands []scoredPt
+	if ct := t.shrink(f); f == 1 || ct.flat() {
+		// No usable coarse level (small template, or its detail averages away).
+		cands = []scoredPt{s.bestIn(t, 0, 0, s.w-t.w, s.h-t.h)}
+	} else {
+		for _, c := range s.shrink(f).topN(ct, 6) {
+			x0, y0 := c.x*f-f, c.y*f-f
+			cands = append(cands, s.bestIn(t, x0, y0, x0+2*f, y0+2*f))
+		}
+	}
+	best := scoredPt{score: math.Inf(-1)}
+	for _, c := range cands {
+		if c.score > best.score {
+			best = c
+		}
+	}
+	m := ImageMatch{
+		X: sb.Min.X + best.x + t.w/2, Y: sb.Min.Y + best.y + t.h/2,
+		Left: sb.Min.X + best.x, Top: sb.Min.Y + best.y, Width: t.w, Height: t.h,
+		Score: best.score,
+	}
+	if best.score < minScore {
+		return m, fmt.Errorf("template not found: best score %.3f at (%d, %d) < match_threshold %.3f", best.score, m.X, m.Y, minScore)
+	}
+	return m, nil
+}
+
+// luma is a grayscale float plane with summed-area tables for O(1) window mean/variance.
+type luma struct {
+	w, h    int
+	pix     []float64
+	sum, sq []float64 // (w+1)*(h+1) integral images of pix and pix²
+}
+
+type scoredPt struct {
+	x, y  int
+	score float64
+}
+
+func toLuma(img image.Image) *luma {
+	b := img.Bounds()
+	l := &luma{w: b.Dx(), h: b.Dy(), pix: make([]float64, b.Dx()*b.Dy())}
+	for y := 0; y < l.h; y++ {
+		for x := 0; x < l.w; x++ {
+			r, g, bl, _ := img.At(b.Min.X+x, b.Min.Y+y).RGBA()
+			l.pix[y*l.w+x] = (0.299*float64(r) + 0.587*float64(g) + 0.114*float64(bl)) / 257
+		}
+	}
+	l.integrate()
+	return l
+}
+
+func (l *luma) integrate() {
+	W := l.w + 1
+	l.sum = make([]float64, W*(l.h+1))
+	l.sq = make([]float64, W*(l.h+1))
+	for y := 0; y < l.h; y++ {
+		var rs, rq float64
+		for x := 0; x < l.w; x++ {
+			v := l.pix[y*l.w+x]
+			rs += v
+			rq += v * v
+			l.sum[(y+1)*W+x+1] = l.sum[y*W+x+1] + rs
+			l.sq[(y+1)*W+x+1] = l.sq[y*W+x+1] + rq
+		}
+	}
+}
+
+// window returns the sum and sum-of-squares of the w×h window at (x, y).
+func (l *luma) window(x, y, w, h int) (float64, float64) {
+	W := l.w + 1
+	a, b, c, d := y*W+x, y*W+x+w, (y+h)*W+x, (y+h)*W+x+w
+	return l.sum[d] - l.sum[b] - l.sum[c] + l.sum[a], l.sq[d] - l.sq[b] - l.sq[c] + l.sq[a]
+}
+
+func (l *luma) flat() bool {
+	s, q := l.window(0, 0, l.w, l.h)
+	n := float64(l.w * l.h)
+	return q-s*s/n < 1e-6*n
+}
+
+// shrink box-averages the plane by factor f.
+func (l *luma) shrink(f int) *luma {
+	out := &luma{w: l.w / f, h: l.h / f}
+	out.pix = make([]float64, out.w*out.h)
+	area := float64(f * f)
+	for y := 0; y < out.h; y++ {
+		for x := 0; x < out.w; x++ {
+			s, _ := l.window(x*f, y*f, f, f)
+			out.pix[y*out.w+x] = s / area
+		}
+	}
+	out.integrate()
+	return out
+}
+
+// ncc scores the template at screen offset (x, y). Flat screen windows score 0.
+func (l *luma) ncc(t *luma, tz []float64, tnorm float64, x, y int) float64 {
+	n := float64(t.w * t.h)
+	s, q := l.window(x, y, t.w, t.h)
+	v := q - s*s/n
+	if v <= 1e-9 {
+		return 0
+	}
+	var num float64
+	for ty := 0; ty < t.h; ty++ {
+		row := l.pix[(y+ty)*l.w+x : (y+ty)*l.w+x+t.w]
+		trow := tz[ty*t.w : (ty+1)*t.w]
+		for i, p := range row {
+			num += p * trow[i]
+		}
+	}
+	return num / (math.Sqrt(v) * tnorm)
+}
+
+// zeroMean returns the template minus its mean and the resulting L2 norm.
+func (l *luma) zeroMean() ([]float64, float64) {
+	s, _ := l.window(0, 0, l.w, l.h)
+	mean := s / float64(l.w*l.h)
+	tz := make([]float64, len(l.pix))
+	var norm float64
+	for i, p := range l.pix {
+		tz[i] = p - mean
+		norm += tz[i] * tz[i]
+	}
+	return tz, math.Sqrt(norm)
+}
+
+// bestIn exhaustively scores every offset in [x0..x1]×[y0..y1] (clamped to the valid
+// range) and returns the best.
+func (l *luma) bestIn(t *luma, x0, y0, x1, y1 int) scoredPt {
+	tz, tnorm := t.zeroMean()
+	x0, y0 = max(x0, 0), max(y0, 0)
+	x1, y1 = min(x1, l.w-t.w), min(y1, l.h-t.h)
+	best := scoredPt{score: math.Inf(-1)}
+	for y := y0; y <= y1; y++ {
+		for x := x0; x <= x1; x++ {
+			if sc := l.ncc(t, tz, tnorm, x, y); sc > best.score {
+				best = scoredPt{x, y, sc}
+			}
+		}
+	}
+	return best
+}
+
+// topN scores every offset and returns the n best, suppressing candidates within one
+// template-size of a better one so the refinement windows cover distinct regions.
+func (l *luma) topN(t *luma, n int) []scoredPt {
+	tz, tnorm := t.zeroMean()
+	all := make([]scoredPt, 0, (l.w-t.w+1)*(l.h-t.h+1))
+	for y := 0; y <= l.h-t.h; y++ {
+		for x := 0; x <= l.w-t.w; x++ {
+			all = append(all, scoredPt{x, y, l.ncc(t, tz, tnorm, x, y)})
+		}
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].score > all[j].score })
+	var out []scoredPt
+	for _, c := range all {
+		near := false
+		for _, o := range out {
+			if abs(c.x-o.x) < t.w && abs(c.y-o.y) < t.h {
+				near = true
+				break
+			}
+		}
+		if !near {
+			out = append(out, c)
+			if len(out) == n {
+				break
+			}
+		}
+	}
+	return out
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
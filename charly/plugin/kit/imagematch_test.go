@@ -0,0 +1,143 @@
+package kit
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/overthinkos/overthink/charly/spec"
+)
+
+// noiseImage is a deterministic pseudo-random RGB image — rich detail everywhere, so a
+// template cut from it has exactly one true location.
+func noiseImage(w, h int, seed int64) *image.RGBA {
+	rng := rand.New(rand.NewSource(seed))
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			img.Set(x, y, color.RGBA{R: uint8(rng.Intn(256)), G: uint8(rng.Intn(256)), B: uint8(rng.Intn(256)), A: 255})
+		}
+	}
+	return img
+}
+
+func writeTestPNG(t *testing.T, path string, img image.Image) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(f, img); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// A template cut from the screen is found at its exact offset, through the PNG
+// round-trip the plugins use, for both the coarse-to-fine path (large template)
+// and the direct full-resolution path (small template).
+func TestFindTemplate_LocatesCutout(t *testing.T) {
+	dir := t.TempDir()
+	screen := noiseImage(320, 200, 1)
+	writeTestPNG(t, filepath.Join(dir, "screen.png"), screen)
+	for _, tc := range []struct {
+		name       string
+		x, y, w, h int
+	}{
+		{"coarse-to-fine", 157, 61, 48, 40},
+		{"small direct", 13, 170, 10, 12},
+		{"bottom-right edge", 320 - 33, 200 - 21, 33, 21},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tmplPath := filepath.Join(dir, tc.name+".png")
+			writeTestPNG(t, tmplPath, screen.SubImage(image.Rect(tc.x, tc.y, tc.x+tc.w, tc.y+tc.h)))
+			s, err := LoadImage(filepath.Join(dir, "screen.png"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			tmpl, err := LoadImage(tmplPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			m, err := FindTemplate(s, tmpl, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if m.Left != tc.x || m.Top != tc.y || m.X != tc.x+tc.w/2 || m.Y != tc.y+tc.h/2 {
+				t.Errorf("match = %+v, want left/top (%d,%d)", m, tc.x, tc.y)
+			}
+			if m.Score < 0.999 {
+				t.Errorf("exact cutout score = %.4f, want ~1", m.Score)
+			}
+		})
+	}
+}
+
+// A brightness-shifted template still matches (NCC is contrast/offset invariant);
+// the reported JSON carries the center for capture_extract.
+func TestFindTemplate_BrightnessInvariantAndJSON(t *testing.T) {
+	screen := noiseImage(200, 150, 2)
+	tmpl := image.NewRGBA(image.Rect(0, 0, 30, 30))
+	for y := range 30 {
+		for x := range 30 {
+			c := screen.RGBAAt(100+x, 40+y)
+			tmpl.Set(x, y, color.RGBA{R: c.R / 2, G: c.G / 2, B: c.B / 2, A: 255})
+		}
+	}
+	m, err := FindTemplate(screen, tmpl, 0.95)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Left != 100 || m.Top != 40 {
+		t.Errorf("match = %+v, want (100,40)", m)
+	}
+	if got := m.JSON(); !strings.Contains(got, `"x":115,"y":55`) {
+		t.Errorf("JSON = %s", got)
+	}
+}
+
+// A template that is not on screen, a flat template and an oversized template all
+// fail with a diagnosable error.
+func TestFindTemplate_Failures(t *testing.T) {
+	screen := noiseImage(120, 90, 3)
+	if _, err := FindTemplate(screen, noiseImage(24, 24, 99), 0); err == nil || !strings.Contains(err.Error(), "best score") {
+		t.Errorf("absent template: err = %v, want best-score miss", err)
+	}
+	if _, err := FindTemplate(screen, image.NewRGBA(image.Rect(0, 0, 20, 20)), 0); err == nil || !strings.Contains(err.Error(), "flat") {
+		t.Errorf("flat template: err = %v", err)
+	}
+	if _, err := FindTemplate(screen, noiseImage(200, 20, 4), 0); err == nil || !strings.Contains(err.Error(), "larger") {
+		t.Errorf("oversized template: err = %v", err)
+	}
+}
+
+// LocateTemplate loads the step's template, honours match_threshold, and saves the
+// searched frame to the artifact path.
+func TestLocateTemplate(t *testing.T) {
+	dir := t.TempDir()
+	screen := noiseImage(160, 120, 5)
+	tmplPath := filepath.Join(dir, "button.png")
+	writeTestPNG(t, tmplPath, screen.SubImage(image.Rect(90, 30, 130, 60)))
+	op := &spec.Op{Template: tmplPath, Artifact: filepath.Join(dir, "frame.png"), MatchThreshold: 0.99}
+	m, err := LocateTemplate(screen, op)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.X != 110 || m.Y != 45 {
+		t.Errorf("center = (%d,%d), want (110,45)", m.X, m.Y)
+	}
+	if saved, err := LoadImage(op.Artifact); err != nil || saved.Bounds().Dx() != 160 {
+		t.Errorf("artifact frame not saved: %v", err)
+	}
+	op.Template = filepath.Join(dir, "missing.png")
+	if _, err := LocateTemplate(screen, op); err == nil || !strings.Contains(err.Error(), "loading template") {
+		t.Errorf("missing template: err = %v", err)
+	}
+}
@@ -0,0 +1,260 @@
+package sdk
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/overthinkos/overthink/charly/spec"
+)
+
+// ---------------------------------------------------------------------------
+// Baseline (golden-image) comparison
+//
+// `artifact_baseline:` turns a screenshot-producing step (cdp/wl/vnc/spice
+// screenshot, adb screencap, appium screenshot) into a visual-regression
+// assertion. The first run records the captured artifact AS the baseline; every
+// later run computes a perceptual per-pixel diff against it and fails when more
+// than `artifact_max_diff` percent of the compared pixels differ. Deleting the
+// baseline file re-records it on the next run.
+//
+// The host resolves a relative baseline against the authoring candy's source
+// tree (or the project root for box/deploy steps) and defaults `artifact_diff:`
+// into the check run directory BEFORE the Op reaches the plugin, so every
+// screenshot verb gets the same behaviour from this one implementation.
+// ---------------------------------------------------------------------------
+
+// DefaultPixelThreshold is the per-pixel perceptual distance (0..1, YIQ color
+// space) under which two pixels count as equal when artifact_pixel_threshold is
+// unset — tolerant of anti-aliasing and compression noise, strict on real
+// content changes.
+const DefaultPixelThreshold = 0.1
+
+// maxYIQDelta is the largest possible squared YIQ distance between two colors.
+const maxYIQDelta = 35215.0
+
+// ImageDiffResult summarizes one baseline comparison.
+type ImageDiffResult struct {
+	Compared int     // pixels compared (outside ignore regions)
+	Differ   int     // pixels whose perceptual distance exceeds the threshold
+	Percent  float64 // Differ / Compared * 100
+	Diff     *image.RGBA
+}
+
+// assertArtifactBaseline compares op.Artifact against op.ArtifactBaseline. A
+// missing baseline is recorded from the artifact (first run) and passes.
+func assertArtifactBaseline(op *spec.Op) error {
+	if _, err := os.Stat(op.ArtifactBaseline); os.IsNotExist(err) {
+		if err := copyArtifactFile(op.Artifact, op.ArtifactBaseline); err != nil {
+			return fmt.Errorf("artifact_baseline: recording %q: %w", op.ArtifactBaseline, err)
+		}
+		return nil
+	}
+	regions, err := ParseIgnoreRegions(op.ArtifactIgnore)
+	if err != nil {
+		return err
+	}
+	got, err := decodeImageFile(op.Artifact)
+	if err != nil {
+		return err
+	}
+	want, err := decodeImageFile(op.ArtifactBaseline)
+	if err != nil {
+		return err
+	}
+	threshold := op.ArtifactPixelThreshold
+	if threshold <= 0 {
+		threshold = DefaultPixelThreshold
+	}
+	res, err := CompareImages(want, got, threshold, regions)
+	if err != nil {
+		return fmt.Errorf("artifact_baseline %q: %w", op.ArtifactBaseline, err)
+	}
+	if res.Percent <= op.ArtifactMaxDiff {
+		return nil
+	}
+	msg := fmt.Sprintf("artifact %q differs from baseline %q: %d/%d pixels (%.3f%%) > artifact_max_diff %.3f%%",
+		op.Artifact, op.ArtifactBaseline, res.Differ, res.Compared, res.Percent, op.ArtifactMaxDiff)
+	diffPath := op.ArtifactDiff
+	if diffPath == "" {
+		diffPath = strings.TrimSuffix(op.Artifact, filepath.Ext(op.Artifact)) + ".diff.png"
+	}
+	if werr := writePNGFile(diffPath, res.Diff); werr != nil {
+		return fmt.Errorf("%s (writing diff image: %v)", msg, werr)
+	}
+	return fmt.Errorf("%s — diff image: %s", msg, diffPath)
+}
+
+// CompareImages computes a perceptual per-pixel diff of got against want.
+// Pixels inside any ignore region are skipped. The two images must share
+// dimensions. The returned Diff image renders the baseline faded to gray with
+// differing pixels in red and ignored regions tinted yellow.
+func CompareImages(want, got image.Image, threshold float64, ignore []image.Rectangle) (*ImageDiffResult, error) {
+	wb, gb := want.Bounds(), got.Bounds()
+	if wb.Dx() != gb.Dx() || wb.Dy() != gb.Dy() {
+		return nil, fmt.Errorf("dimensions differ: baseline %dx%d, artifact %dx%d", wb.Dx(), wb.Dy(), gb.Dx(), gb.Dy())
+	}
+	limit := maxYIQDelta * threshold * threshold
+	diff := image.NewRGBA(image.Rect(0, 0, wb.Dx(), wb.Dy()))
+	res := &ImageDiffResult{Diff: diff}
+	for y := 0; y < wb.Dy(); y++ {
+		for x := 0; x < wb.Dx(); x++ {
+			wc := want.At(wb.Min.X+x, wb.Min.Y+y)
+			if inRegions(x, y, ignore) {
+				diff.Set(x, y, tint(wc, color.RGBA{R: 255, G: 220, A: 255}))
+				continue
+			}
+			res.Compared++
+			if yiqDelta(wc, got.At(gb.Min.X+x, gb.Min.Y+y)) > limit {
+				res.Differ++
+				diff.Set(x, y, color.RGBA{R: 255, A: 255})
+				continue
+			}
+			diff.Set(x, y, tint(wc, color.RGBA{R: 255, G: 255, B: 255, A: 255}))
+		}
+	}
+	if res.Compared > 0 {
+		res.Percent = float64(res.Differ) * 100 / float64(res.Compared)
+	}
+	return res, nil
+}
+
+// ParseIgnoreRegions parses `artifact_ignore:` entries of the form "X,Y,WxH"
+// (pixel offsets from the top-left corner).
+func ParseIgnoreRegions(specs []string) ([]image.Rectangle, error) {
+	out := make([]image.Rectangle, 0, len(specs))
+	for _, s := range specs {
+		parts := strings.Split(strings.TrimSpace(s), ",")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("artifact_ignore: bad region %q (want X,Y,WxH)", s)
+		}
+		wh := strings.SplitN(parts[2], "x", 2)
+		if len(wh) != 2 {
+			return nil, fmt.Errorf("artifact_ignore: bad size in %q (want WxH)", s)
+		}
+		var n [4]int
+		for i, v := range []string{parts[0], parts[1], wh[0], wh[1]} {
+			iv, err := strconv.Atoi(strings.TrimSpace(v))
+			if err != nil || iv < 0 {
+				return nil, fmt.Errorf("artifact_ignore: bad number %q in %q", v, s)
+			}
+			n[i] = iv
+		}
+		out = append(out, image.Rect(n[0], n[1], n[0]+n[2], n[1]+n[3]))
+	}
+	return out, nil
+}
+
+func inRegions(x, y int, regions []image.Rectangle) bool {
+	p := image.Pt(x, y)
+	for _, r := range regions {
+		if p.In(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// yiqDelta is the squared perceptual distance between two colors in YIQ space
+// (the weighting pixelmatch uses), after blending each onto white so
+// transparency compares the way it renders.
+func yiqDelta(a, b color.Color) float64 {
+	ar, ag, ab := blendWhite(a)
+	br, bg, bb := blendWhite(b)
+	if ar == br && ag == bg && ab == bb {
+		return 0
+	}
+	y := rgb2y(ar, ag, ab) - rgb2y(br, bg, bb)
+	i := rgb2i(ar, ag, ab) - rgb2i(br, bg, bb)
+	q := rgb2q(ar, ag, ab) - rgb2q(br, bg, bb)
+	return 0.5053*y*y + 0.299*i*i + 0.1957*q*q
+}
+
+func blendWhite(c color.Color) (float64, float64, float64) {
+	r, g, b, a := c.RGBA()
+	alpha := float64(a) / 0xffff
+	blend := func(v uint32) float64 {
+		// RGBA() is alpha-premultiplied; add the white background's share.
+		return float64(v)/257 + 255*(1-alpha)
+	}
+	return blend(r), blend(g), blend(b)
+}
+
+func rgb2y(r, g, b float64) float64 { return r*0.29889531 + g*0.58662247 + b*0.11448223 }
+func rgb2i(r, g, b float64) float64 { return r*0.59597799 - g*0.27417610 - b*0.32180189 }
+func rgb2q(r, g, b float64) float64 { return r*0.21147017 - g*0.52261711 + b*0.31114694 }
+
+// tint renders c as a faded grayscale pixel mixed toward over.
+func tint(c color.Color, over color.RGBA) color.RGBA {
+	r, g, b := blendWhite(c)
+	l := rgb2y(r, g, b)
+	mix := func(o uint8) uint8 { return uint8(0.2*l + 0.8*float64(o)) }
+	return color.RGBA{R: mix(over.R), G: mix(over.G), B: mix(over.B), A: 255}
+}
+
+func decodeImageFile(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("artifact %q open: %w", path, err)
+	}
+	defer f.Close() //nolint:errcheck
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("artifact %q decode: %w", path, err)
+	}
+	return img, nil
+}
+
+func writePNGFile(path string, img image.Image) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// copyArtifactFile records a baseline. A PNG artifact is copied byte-for-byte;
+// anything else (a JPEG screencap) is re-encoded as PNG so the golden image is
+// lossless from then on.
+func copyArtifactFile(src, dst string) error {
+	if strings.EqualFold(filepath.Ext(src), ".png") {
+		in, err := os.Open(src)
+		if err != nil {
+			return err
+		}
+		defer in.Close() //nolint:errcheck
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return err
+		}
+		out, err := os.Create(dst)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, in); err != nil {
+			_ = out.Close()
+			return err
+		}
+		return out.Close()
+	}
+	img, err := decodeImageFile(src)
+	if err != nil {
+		return err
+	}
+	rgba := image.NewRGBA(img.Bounds())
+	draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+	return writePNGFile(dst, rgba)
+}
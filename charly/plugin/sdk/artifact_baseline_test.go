@@ -0,0 +1,126 @@
+package sdk
+
+import (
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/overthinkos/overthink/charly/spec"
+)
+
+// The first run records the artifact as the baseline; an identical second run
+// passes without writing a diff image.
+func TestArtifactBaseline_RecordsThenMatches(t *testing.T) {
+	dir := t.TempDir()
+	shot := filepath.Join(dir, "shot.png")
+	golden := filepath.Join(dir, "golden", "shot.png")
+	writeMixedPNG(t, shot, 40, 30, color.RGBA{R: 20, G: 40, B: 60, A: 255}, color.RGBA{R: 250, A: 255}, 5, 5)
+
+	op := &spec.Op{Artifact: shot, ArtifactBaseline: golden, ArtifactDiff: filepath.Join(dir, "diff.png")}
+	if err := RunArtifactValidators(op); err != nil {
+		t.Fatalf("first run (record): %v", err)
+	}
+	if _, err := os.Stat(golden); err != nil {
+		t.Fatalf("baseline not recorded: %v", err)
+	}
+	if err := RunArtifactValidators(op); err != nil {
+		t.Fatalf("second run (identical): %v", err)
+	}
+	if _, err := os.Stat(op.ArtifactDiff); !os.IsNotExist(err) {
+		t.Errorf("diff image written for a passing comparison")
+	}
+}
+
+// A changed region beyond artifact_max_diff fails and writes a diff image; the
+// same change passes once the region is ignored or the budget is raised.
+func TestArtifactBaseline_DiffBudgetAndIgnore(t *testing.T) {
+	dir := t.TempDir()
+	golden := filepath.Join(dir, "golden.png")
+	shot := filepath.Join(dir, "shot.png")
+	bg := color.RGBA{R: 200, G: 200, B: 200, A: 255}
+	writePNG(t, golden, 10, 10, bg)
+	// 10x10 image, a 2x2 block changed → 4% of pixels differ.
+	img := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	for y := range 10 {
+		for x := range 10 {
+			img.Set(x, y, bg)
+		}
+	}
+	for y := 6; y < 8; y++ {
+		for x := 6; x < 8; x++ {
+			img.Set(x, y, color.RGBA{B: 255, A: 255})
+		}
+	}
+	if err := writePNGFile(shot, img); err != nil {
+		t.Fatal(err)
+	}
+
+	diff := filepath.Join(dir, "run", "shot.diff.png")
+	op := &spec.Op{Artifact: shot, ArtifactBaseline: golden, ArtifactDiff: diff}
+	err := RunArtifactValidators(op)
+	if err == nil || !strings.Contains(err.Error(), "4/100 pixels") {
+		t.Fatalf("want 4/100 diff failure, got %v", err)
+	}
+	d, err := decodeImageFile(diff)
+	if err != nil {
+		t.Fatalf("diff image: %v", err)
+	}
+	if r, g, b, _ := d.At(6, 6).RGBA(); r>>8 != 255 || g != 0 || b != 0 {
+		t.Errorf("changed pixel not highlighted red in diff: %v", d.At(6, 6))
+	}
+
+	op.ArtifactMaxDiff = 5
+	if err := RunArtifactValidators(op); err != nil {
+		t.Errorf("within artifact_max_diff 5%%: %v", err)
+	}
+	op.ArtifactMaxDiff = 0
+	op.ArtifactIgnore = []string{"6,6,2x2"}
+	if err := RunArtifactValidators(op); err != nil {
+		t.Errorf("changed block ignored: %v", err)
+	}
+}
+
+// Sub-threshold noise (a 1-level channel shift) is not a difference; the
+// threshold is perceptual, so raising it to 1 tolerates everything.
+func TestCompareImages_Threshold(t *testing.T) {
+	a := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	b := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for y := range 4 {
+		for x := range 4 {
+			a.Set(x, y, color.RGBA{R: 100, G: 100, B: 100, A: 255})
+			b.Set(x, y, color.RGBA{R: 101, G: 100, B: 100, A: 255})
+		}
+	}
+	b.Set(0, 0, color.RGBA{A: 255})
+	res, err := CompareImages(a, b, DefaultPixelThreshold, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Differ != 1 || res.Compared != 16 {
+		t.Errorf("differ/compared = %d/%d, want 1/16", res.Differ, res.Compared)
+	}
+	if res, _ := CompareImages(a, b, 1, nil); res.Differ != 0 {
+		t.Errorf("threshold 1: differ = %d, want 0", res.Differ)
+	}
+	if _, err := CompareImages(a, image.NewRGBA(image.Rect(0, 0, 5, 4)), 0.1, nil); err == nil {
+		t.Error("dimension mismatch: want error")
+	}
+}
+
+func TestParseIgnoreRegions(t *testing.T) {
+	got, err := ParseIgnoreRegions([]string{"0,0,10x20", " 5,6,1x1 "})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got[0] != image.Rect(0, 0, 10, 20) || got[1] != image.Rect(5, 6, 6, 7) {
+		t.Errorf("regions = %v", got)
+	}
+	for _, bad := range []string{"1,2", "1,2,3", "a,2,3x4", "1,2,3x-4"} {
+		if _, err := ParseIgnoreRegions([]string{bad}); err == nil {
+			t.Errorf("%q: want error", bad)
+		}
+	}
+}
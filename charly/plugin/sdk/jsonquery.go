@@ -0,0 +1,603 @@
+package sdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ---------------------------------------------------------------------------
+// JSON query evaluation
+//
+// The `json:` matcher operator and the `capture_extract: "json:<query>"` form
+// both pull a value out of a serialized JSON document (an http body, a kube raw
+// reply, an mcp call result, a dbus reply) by path instead of by regex, then
+// hand the rendered result to the ordinary matchers. Like the matchers, this is
+// the SINGLE implementation shared by the core runner and out-of-tree plugins.
+//
+// Query syntax is the common subset of jq paths and JSONPath:
+//
+//	.a.b  $.a.b  a.b         object members ($ / leading dot optional)
+//	.["k.x"]  ."k.x"  ['k']  quoted member names
+//	.a[0]  .a[-1]            array index (negative counts from the end)
+//	.a[]  .a[*]  .a.*        every element / member value
+//	..name                   recursive descent (every `name` at any depth)
+//	.a[?(@.b == "x")]        JSONPath filter (==, !=, <, <=, >, >=, or bare @.b
+//	                         for "present and not null")
+//	<path> | length|keys|type  jq-style post-processing of each result
+// ---------------------------------------------------------------------------
+
+// JSONQuery evaluates query against the JSON document doc and returns every
+// matching value (decoded with encoding/json: map[string]any, []any, float64,
+// string, bool, nil). An empty result is not an error — callers decide whether
+// "no match" fails.
+func JSONQuery(doc, query string) ([]any, error) {
+	var root any
+	dec := json.NewDecoder(strings.NewReader(doc))
+	dec.UseNumber()
+	if err := dec.Decode(&root); err != nil {
+		return nil, fmt.Errorf("value is not JSON: %w", err)
+	}
+	root = normalizeJSONNumbers(root)
+
+	path, post := query, ""
+	if i := indexUnquoted(query, "|"); i >= 0 {
+		path, post = query[:i], query[i+1:]
+	}
+	steps, err := parseJSONQuery(strings.TrimSpace(path))
+	if err != nil {
+		return nil, fmt.Errorf("bad json query %q: %w", query, err)
+	}
+	vals := []any{root}
+	for _, st := range steps {
+		vals = st.apply(vals)
+	}
+	if post = strings.TrimSpace(post); post != "" {
+		return applyJSONPost(vals, post)
+	}
+	return vals, nil
+}
+
+// JSONQueryString evaluates query and renders the result as the string the
+// matchers see: a string result verbatim, a scalar canonically, an object or
+// array as compact JSON; several results are newline-joined. A query that
+// matches nothing is an error.
+func JSONQueryString(doc, query string) (string, error) {
+	vals, err := JSONQuery(doc, query)
+	if err != nil {
+		return "", err
+	}
+	if len(vals) == 0 {
+		return "", fmt.Errorf("json query %q matched nothing", query)
+	}
+	parts := make([]string, 0, len(vals))
+	for _, v := range vals {
+		parts = append(parts, renderJSONValue(v))
+	}
+	return strings.Join(parts, "\n"), nil
+}
+
+func renderJSONValue(v any) string {
+	switch x := v.(type) {
+	case string:
+		return x
+	case nil:
+		return "null"
+	case float64, bool:
+		return MatchValueString(x)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// normalizeJSONNumbers converts json.Number leaves to float64 (integers stay
+// exact up to 2^53, which covers status codes, counts and ports) so rendered
+// values read the same as MatchValueString renders an authored operand.
+func normalizeJSONNumbers(v any) any {
+	switch x := v.(type) {
+	case json.Number:
+		f, err := x.Float64()
+		if err != nil {
+			return x.String()
+		}
+		return f
+	case map[string]any:
+		for k, e := range x {
+			x[k] = normalizeJSONNumbers(e)
+		}
+	case []any:
+		for i, e := range x {
+			x[i] = normalizeJSONNumbers(e)
+		}
+	}
+	return v
+}
+
+// jsonStep is one parsed path segment.
+type jsonStep struct {
+	kind   byte // 'k' key, 'i' index, '*' wildcard, 'r' recursive key, 'f' filter
+	key    string
+	index  int
+	filter *jsonFilter
+}
+
+func (s jsonStep) apply(in []any) []any {
+	var out []any
+	for _, v := range in {
+		switch s.kind {
+		case 'k':
+			if m, ok := v.(map[string]any); ok {
+				if e, ok := m[s.key]; ok {
+					out = append(out, e)
+				}
+			}
+		case 'i':
+			if a, ok := v.([]any); ok {
+				i := s.index
+				if i < 0 {
+					i += len(a)
+				}
+				if i >= 0 && i < len(a) {
+					out = append(out, a[i])
+				}
+			}
+		case '*':
+			out = append(out, jsonChildren(v)...)
+		case 'r':
+			out = append(out, jsonDescend(v, s.key)...)
+		case 'f':
+			for _, e := range jsonChildren(v) {
+				if s.filter.match(e) {
+					out = append(out, e)
+				}
+			}
+		}
+	}
+	return out
+}
+
+// jsonChildren returns array elements in order, or object member values in
+// key order (deterministic output for wildcards over objects).
+func jsonChildren(v any) []any {
+	switch x := v.(type) {
+	case []any:
+		return x
+	case map[string]any:
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		out := make([]any, 0, len(keys))
+		for _, k := range keys {
+			out = append(out, x[k])
+		}
+		return out
+	}
+	return nil
+}
+
+// jsonDescend collects every member named key at any depth (pre-order).
+func jsonDescend(v any, key string) []any {
+	var out []any
+	if m, ok := v.(map[string]any); ok {
+		if e, ok := m[key]; ok {
+			out = append(out, e)
+		}
+	}
+	for _, c := range jsonChildren(v) {
+		out = append(out, jsonDescend(c, key)...)
+	}
+	return out
+}
+
+// jsonFilter is a parsed `?(@.path OP literal)` predicate.
+type jsonFilter struct {
+	path  []jsonStep
+	op    string // "" = existence
+	value any
+}
+
+func (f *jsonFilter) match(v any) bool {
+	vals := []any{v}
+	for _, st := range f.path {
+		vals = st.apply(vals)
+	}
+	for _, got := range vals {
+		if f.op == "" {
+			if got != nil {
+				return true
+			}
+			continue
+		}
+		if compareJSON(got, f.op, f.value) {
+			return true
+		}
+	}
+	return false
+}
+
+func compareJSON(got any, op string, want any) bool {
+	if gf, ok := got.(float64); ok {
+		if wf, ok := want.(float64); ok {
+			switch op {
+			case "==":
+				return gf == wf
+			case "!=":
+				return gf != wf
+			case "<":
+				return gf < wf
+			case "<=":
+				return gf <= wf
+			case ">":
+				return gf > wf
+			case ">=":
+				return gf >= wf
+			}
+		}
+	}
+	gs, ws := renderJSONValue(got), renderJSONValue(want)
+	switch op {
+	case "==":
+		return gs == ws
+	case "!=":
+		return gs != ws
+	case "<":
+		return gs < ws
+	case "<=":
+		return gs <= ws
+	case ">":
+		return gs > ws
+	case ">=":
+		return gs >= ws
+	}
+	return false
+}
+
+// parseJSONQuery tokenizes a path into steps.
+func parseJSONQuery(q string) ([]jsonStep, error) {
+	q = strings.TrimPrefix(q, "$")
+	if q == "" || q == "." {
+		return nil, nil
+	}
+	var steps []jsonStep
+	i := 0
+	// A bare leading member name ("a.b") is accepted as ".a.b".
+	if q[0] != '.' && q[0] != '[' {
+		q = "." + q
+	}
+	for i < len(q) {
+		switch q[i] {
+		case '.':
+			if i+1 < len(q) && q[i+1] == '.' {
+				name, n, err := readJSONName(q[i+2:])
+				if err != nil {
+					return nil, err
+				}
+				steps = append(steps, jsonStep{kind: 'r', key: name})
+				i += 2 + n
+				continue
+			}
+			i++
+			if i >= len(q) {
+				return steps, nil
+			}
+			switch q[i] {
+			case '[':
+				continue
+			case '*':
+				steps = append(steps, jsonStep{kind: '*'})
+				i++
+				continue
+			}
+			name, n, err := readJSONName(q[i:])
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, jsonStep{kind: 'k', key: name})
+			i += n
+		case '[':
+			end := matchingBracket(q, i)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated [ at offset %d", i)
+			}
+			st, err := parseJSONBracket(strings.TrimSpace(q[i+1 : end]))
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, st)
+			i = end + 1
+		default:
+			return nil, fmt.Errorf("unexpected %q at offset %d", q[i], i)
+		}
+	}
+	return steps, nil
+}
+
+// readJSONName reads a bare identifier or a double-quoted member name and
+// returns it with the number of bytes consumed.
+func readJSONName(s string) (string, int, error) {
+	if s == "" {
+		return "", 0, fmt.Errorf("missing member name")
+	}
+	if s[0] == '"' {
+		end := strings.IndexByte(s[1:], '"')
+		if end < 0 {
+			return "", 0, fmt.Errorf("unterminated quoted name")
+		}
+		return s[1 : end+1], end + 2, nil
+	}
+	n := 0
+	for n < len(s) && s[n] != '.' && s[n] != '[' && s[n] != ' ' {
+		n++
+	}
+	if n == 0 {
+		return "", 0, fmt.Errorf("missing member name")
+	}
+	return s[:n], n, nil
+}
+
+// matchingBracket returns the index of the `]` closing the `[` at open,
+// skipping brackets inside quoted strings and nested filters.
+func matchingBracket(s string, open int) int {
+	depth := 0
+	var quote byte
+	for i := open; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// indexUnquoted returns the index of the first sub in s outside a single- or
+// double-quoted segment, or -1.
+func indexUnquoted(s, sub string) int {
+	idx, _ := indexUnquotedAny(s, sub)
+	return idx
+}
+
+// indexUnquotedAny returns the leftmost position in s, outside quoted
+// segments, where one of subs starts, and which one (the first listed wins at
+// a position, so list longer operators before their prefixes).
+func indexUnquotedAny(s string, subs ...string) (int, string) {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+			continue
+		case c == '"' || c == '\'':
+			quote = c
+			continue
+		}
+		for _, sub := range subs {
+			if strings.HasPrefix(s[i:], sub) {
+				return i, sub
+			}
+		}
+	}
+	return -1, ""
+}
+
+func parseJSONBracket(body string) (jsonStep, error) {
+	switch {
+	case body == "" || body == "*":
+		return jsonStep{kind: '*'}, nil
+	case body[0] == '"' || body[0] == '\'':
+		if len(body) < 2 || body[len(body)-1] != body[0] {
+			return jsonStep{}, fmt.Errorf("unterminated quoted key %s", body)
+		}
+		return jsonStep{kind: 'k', key: body[1 : len(body)-1]}, nil
+	case body[0] == '?':
+		f, err := parseJSONFilter(body[1:])
+		if err != nil {
+			return jsonStep{}, err
+		}
+		return jsonStep{kind: 'f', filter: f}, nil
+	}
+	n, err := strconv.Atoi(body)
+	if err != nil {
+		return jsonStep{}, fmt.Errorf("bad index %q", body)
+	}
+	return jsonStep{kind: 'i', index: n}, nil
+}
+
+// parseJSONFilter parses `(@.path OP literal)` / `(@.path)`.
+func parseJSONFilter(expr string) (*jsonFilter, error) {
+	expr = strings.TrimSpace(expr)
+	expr = strings.TrimSuffix(strings.TrimPrefix(expr, "("), ")")
+	expr = strings.TrimSpace(expr)
+	if !strings.HasPrefix(expr, "@") {
+		return nil, fmt.Errorf("filter must start with @: %q", expr)
+	}
+	lhs, op, rhs := expr[1:], "", ""
+	if idx, cand := indexUnquotedAny(lhs, "==", "!=", "<=", ">=", "<", ">"); idx >= 0 {
+		lhs, op, rhs = lhs[:idx], cand, lhs[idx+len(cand):]
+	}
+	path, err := parseJSONQuery(strings.TrimSpace(lhs))
+	if err != nil {
+		return nil, err
+	}
+	f := &jsonFilter{path: path, op: op}
+	if op != "" {
+		f.value = parseJSONLiteral(strings.TrimSpace(rhs))
+	}
+	return f, nil
+}
+
+// parseJSONLiteral decodes a filter operand: a quoted string (single or
+// double quotes), a number, true/false/null, or else the raw text.
+func parseJSONLiteral(s string) any {
+	if len(s) >= 2 && (s[0] == '\'' || s[0] == '"') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f
+	}
+	switch s {
+	case "true":
+		return true
+	case "false":
+		return false
+	case "null":
+		return nil
+	}
+	return s
+}
+
+// applyJSONPost runs a jq-style post-processor over each result.
+func applyJSONPost(vals []any, post string) ([]any, error) {
+	out := make([]any, 0, len(vals))
+	for _, v := range vals {
+		switch post {
+		case "length":
+			switch x := v.(type) {
+			case []any:
+				out = append(out, float64(len(x)))
+			case map[string]any:
+				out = append(out, float64(len(x)))
+			case string:
+				out = append(out, float64(len([]rune(x))))
+			case nil:
+				out = append(out, float64(0))
+			default:
+				return nil, fmt.Errorf("length: %s has no length", renderJSONValue(v))
+			}
+		case "keys":
+			m, ok := v.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("keys: %s is not an object", renderJSONValue(v))
+			}
+			keys := make([]any, 0, len(m))
+			for _, k := range jsonSortedKeys(m) {
+				keys = append(keys, k)
+			}
+			out = append(out, keys)
+		case "type":
+			out = append(out, jsonTypeName(v))
+		default:
+			return nil, fmt.Errorf("unsupported json post-processor %q (want length, keys or type)", post)
+		}
+	}
+	return out, nil
+}
+
+func jsonSortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func jsonTypeName(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	}
+	return "object"
+}
+
+// ---------------------------------------------------------------------------
+// The `json:` matcher operator
+// ---------------------------------------------------------------------------
+
+// matchJSON evaluates the `json:` operator. Its operand is either a bare query
+// string (assert the path resolves to a non-null value) or a map carrying
+// `path:` plus any ordinary matcher operators, which are applied to the
+// rendered query result:
+//
+//	stdout:
+//	  - json: .status.phase
+//	  - json: {path: .items[0].status.phase, equals: Running}
+//	  - json: {path: '$.items[?(@.kind == "Pod")]', contains: coredns}
+func matchJSON(value string, operand any) error {
+	query, inner, err := parseJSONMatcherOperand(operand)
+	if err != nil {
+		return err
+	}
+	vals, err := JSONQuery(value, query)
+	if err != nil {
+		return err
+	}
+	if len(inner) == 0 {
+		for _, v := range vals {
+			if v != nil {
+				return nil
+			}
+		}
+		return fmt.Errorf("expected json path %s to be present", query)
+	}
+	if len(vals) == 0 {
+		return fmt.Errorf("json path %s matched nothing", query)
+	}
+	rendered, _ := JSONQueryString(value, query)
+	if err := MatchAll(rendered, inner); err != nil {
+		return fmt.Errorf("json path %s: %w", query, err)
+	}
+	return nil
+}
+
+// parseJSONMatcherOperand splits a `json:` operand into its query and the
+// matchers to apply to the result. Inner operator keys are applied in sorted
+// order so a failure message is deterministic.
+func parseJSONMatcherOperand(operand any) (string, []Matcher, error) {
+	switch x := operand.(type) {
+	case string:
+		return x, nil, nil
+	case map[string]any:
+		query := MatchValueString(firstPresent(x, "path", "query"))
+		if query == "" {
+			return "", nil, fmt.Errorf("json matcher needs a path: key")
+		}
+		var inner []Matcher
+		for _, k := range jsonSortedKeys(x) {
+			if k == "path" || k == "query" {
+				continue
+			}
+			inner = append(inner, Matcher{Op: k, Value: x[k]})
+		}
+		return query, inner, nil
+	}
+	return "", nil, fmt.Errorf("json matcher operand must be a path string or a {path: ..., <op>: ...} map, got %T", operand)
+}
+
+func firstPresent(m map[string]any, keys ...string) any {
+	for _, k := range keys {
+		if v, ok := m[k]; ok {
+			return v
+		}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package sdk
+
+import (
+	"strings"
+	"testing"
+)
+
+const jqSample = `{
+  "kind": "PodList",
+  "items": [
+    {"metadata": {"name": "coredns-1", "labels": {"app.kubernetes.io/name": "coredns"}}, "status": {"phase": "Running", "restarts": 0}},
+    {"metadata": {"name": "web-0"}, "status": {"phase": "Pending", "restarts": 4}}
+  ],
+  "total": 2
+}`
+
+// TestJSONQueryString covers the jq / JSONPath subset: members, quoted keys,
+// indices, wildcards, recursive descent, filters and post-processors.
+func TestJSONQueryString(t *testing.T) {
+	cases := []struct {
+		query string
+		want  string
+	}{
+		{".kind", "PodList"},
+		{"$.kind", "PodList"},
+		{"kind", "PodList"},
+		{".total", "2"},
+		{".items[0].metadata.name", "coredns-1"},
+		{".items[-1].metadata.name", "web-0"},
+		{".items[].status.phase", "Running\nPending"},
+		{"$.items[*].metadata.name", "coredns-1\nweb-0"},
+		{`.items[0].metadata.labels["app.kubernetes.io/name"]`, "coredns"},
+		{`.items[0].metadata.labels."app.kubernetes.io/name"`, "coredns"},
+		{"..phase", "Running\nPending"},
+		{`.items[?(@.status.phase == "Pending")].metadata.name`, "web-0"},
+		{`$.items[?(@.status.restarts > 1)].metadata.name`, "web-0"},
+		{`.items[?(@.metadata.labels)].metadata.name`, "coredns-1"},
+		{".items | length", "2"},
+		{".items[1].status | keys", `["phase","restarts"]`},
+		{".items[0].status", `{"phase":"Running","restarts":0}`},
+		{".total | type", "number"},
+	}
+	for _, tc := range cases {
+		got, err := JSONQueryString(jqSample, tc.query)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.query, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("%s = %q, want %q", tc.query, got, tc.want)
+		}
+	}
+
+	for _, bad := range []string{".nope", ".items[9]"} {
+		if _, err := JSONQueryString(jqSample, bad); err == nil || !strings.Contains(err.Error(), "matched nothing") {
+			t.Errorf("%s: want matched-nothing error, got %v", bad, err)
+		}
+	}
+	if _, err := JSONQueryString("not json", ".a"); err == nil {
+		t.Error("non-JSON value should error")
+	}
+	if _, err := JSONQueryString(jqSample, ".items[abc]"); err == nil {
+		t.Error("bad index should error")
+	}
+}
+
+// TestJSONQueryQuotedDelimiters proves a `|` inside a quoted key is not the
+// post-processor pipe and a filter operator inside a quoted literal is not the
+// filter's operator.
+func TestJSONQueryQuotedDelimiters(t *testing.T) {
+	doc := `{"a|b": {"x": [1, 2]}, "rules": [{"expr": "a<=b", "id": 1}, {"expr": "c==d", "id": 2}, {"expr": "e", "id": 3}]}`
+	cases := []struct {
+		query string
+		want  string
+	}{
+		{`.["a|b"].x`, "[1,2]"},
+		{`."a|b".x | length`, "2"},
+		{`$['a|b'].x[1]`, "2"},
+		{`.rules[?(@.expr == "c==d")].id`, "2"},
+		{`.rules[?(@.expr == 'a<=b')].id`, "1"},
+		{`.rules[?(@.expr != "a<=b")].id | type`, "number\nnumber"},
+	}
+	for _, tc := range cases {
+		got, err := JSONQueryString(doc, tc.query)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.query, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("%s = %q, want %q", tc.query, got, tc.want)
+		}
+	}
+}
+
+// TestMatchJSON exercises the `json:` matcher operator through MatchAll: a bare
+// path is an existence assertion; a {path, <op>} map applies nested matchers.
+func TestMatchJSON(t *testing.T) {
+	cases := []struct {
+		name    string
+		operand any
+		wantErr bool
+	}{
+		{"exists", ".items[0].status", false},
+		{"missing", ".items[0].spec", true},
+		{"equals", map[string]any{"path": ".items[0].status.phase", "equals": "Running"}, false},
+		{"equals fail", map[string]any{"path": ".items[1].status.phase", "equals": "Running"}, true},
+		{"numeric", map[string]any{"path": ".total", "ge": 2}, false},
+		{"numeric fail", map[string]any{"path": ".total", "gt": 2}, true},
+		{"contains over wildcard", map[string]any{"path": "$.items[*].metadata.name", "contains": "web-0"}, false},
+		{"several ops", map[string]any{"path": ".items | length", "ge": 1, "lt": 3}, false},
+		{"no match with ops", map[string]any{"path": ".nope", "equals": "x"}, true},
+		{"missing path key", map[string]any{"equals": "x"}, true},
+	}
+	for _, tc := range cases {
+		err := MatchAll(jqSample, []Matcher{{Op: "json", Value: tc.operand}})
+		if tc.wantErr && err == nil {
+			t.Errorf("%s: expected error", tc.name)
+		}
+		if !tc.wantErr && err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+		}
+	}
+}
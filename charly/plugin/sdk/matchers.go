@@ -84,6 +84,8 @@ func matchOne(value string, m Matcher) error {
 		}
 	case "lt", "le", "gt", "ge":
 		return matchNumeric(value, m)
+	case "json":
+		return matchJSON(value, m.Value)
 	default:
 		return fmt.Errorf("unsupported matcher op %q", m.Op)
 	}
@@ -0,0 +1,17 @@
+package main
+
+// checkpointCommand is the `charly checkpoint` command group as a dedicated
+// COMMAND-class provider — the same externalizable dedicated-provider pattern
+// (see plugin_command_alias.go for the full rationale). It self-registers via
+// registerDedicatedBuiltin and reaches the CLI root through
+// collectCommandPlugins() → kong.Plugins.
+type checkpointCommand struct{ builtinCommandBase }
+
+func (checkpointCommand) Reserved() string { return "checkpoint" }
+func (checkpointCommand) KongCommand() any {
+	return &struct {
+		Checkpoint CheckpointCmd `cmd:"" name:"checkpoint" help:"Checkpoint and restore running pod deployments (podman + CRIU)"`
+	}{}
+}
+
+var _ = registerDedicatedBuiltin(checkpointCommand{})
@@ -0,0 +1,17 @@
+package main
+
+// eventsCommand is the `charly events` leaf command as a dedicated COMMAND-class
+// provider — the same externalizable dedicated-provider pattern (see
+// plugin_command_alias.go for the full rationale). It self-registers via
+// registerDedicatedBuiltin and reaches the CLI root through
+// collectCommandPlugins() → kong.Plugins.
+type eventsCommand struct{ builtinCommandBase }
+
+func (eventsCommand) Reserved() string { return "events" }
+func (eventsCommand) KongCommand() any {
+	return &struct {
+		Events EventsCmd `cmd:"" name:"events" help:"Show the lifecycle event journal (deploys, preemptions, snapshots, mounts, check runs)"`
+	}{}
+}
+
+var _ = registerDedicatedBuiltin(eventsCommand{})
@@ -13,7 +13,7 @@ type volumeCommand struct{ builtinCommandBase }
 func (volumeCommand) Reserved() string { return "volume" }
 func (volumeCommand) KongCommand() any {
 	return &struct {
-		Volume VolumeCmd `cmd:"" name:"volume" help:"List or reset a deployment's charly-managed named volumes"`
+		Volume VolumeCmd `cmd:"" name:"volume" help:"List, reset, back up or restore a deployment's charly-managed volumes"`
 	}{}
 }
 
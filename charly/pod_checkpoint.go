@@ -0,0 +1,477 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// pod_checkpoint.go — CRIU checkpoint/restore for `kind: pod` deployments,
+// the pod-side counterpart of `charly vm snapshot` (vmshared/vm_snapshot.go).
+// A checkpoint is a `podman container checkpoint --export` archive (process
+// memory, rootfs diff and named volumes) recorded in a per-deployment
+// registry.json; restore imports it back into the same deployment or under a
+// new instance name — so a check bed can fork from a warmed-up state instead
+// of cold-starting every iteration.
+//
+// Storage layout, per deployment:
+//
+//   ~/.local/share/charly/pod/charly-<box>[-<instance>]/
+//   └── checkpoints/
+//       ├── registry.json                   # ALL checkpoints (source of truth)
+//       └── <name>/
+//           ├── checkpoint.tar.gz           # podman --export archive
+//           └── meta.json                   # self-describing fallback
+//
+// CRIU checkpointing needs podman running as root (rootless podman refuses
+// `container checkpoint`); the engine's error is surfaced unchanged.
+//
+// Only the main container is checkpointed, so deployments with sidecars are
+// refused: their app container joins the sidecars' pod (shared network
+// namespace), and restoring it alone would detach it from that pod's state.
+//
+// A restore runs inside the deployment's own service: a transient drop-in
+// swaps the unit's `podman run` for `container restore` (and writes the cid
+// file its ExecStop reads) for that one start, so systemd owns the restored
+// container exactly as it owns a fresh one. Restoring under a new instance
+// first configures that instance the way `charly config -i` does — quadlet
+// plus its charly.yml entry.
+
+// PodCheckpointRegistry is the on-disk schema for checkpoints/registry.json.
+// Versioned so future shape evolutions can migrate cleanly.
+type PodCheckpointRegistry struct {
+	// Version is the registry schema version. V1 is the initial release.
+	Version int `json:"version"`
+
+	// Checkpoints is the set of checkpoints known for this deployment,
+	// keyed by Name.
+	Checkpoints map[string]*PodCheckpointEntry `json:"checkpoints"`
+}
+
+// PodCheckpointEntry is one checkpoint record.
+type PodCheckpointEntry struct {
+	// Name uniquely identifies the checkpoint within this deployment.
+	Name string `json:"name"`
+
+	// Container is the checkpointed container (charly-<box>[-<instance>]).
+	Container string `json:"container"`
+
+	// Image and Version record what the container was running (the image
+	// ref and its ai.opencharly.version CalVer) — a restore needs the same
+	// image present locally.
+	Image   string `json:"image,omitempty"`
+	Version string `json:"version,omitempty"`
+
+	// Archive is the absolute path to the exported checkpoint.
+	Archive string `json:"archive"`
+
+	// Description carries the operator-supplied note.
+	Description string `json:"description,omitempty"`
+
+	// Created is the RFC3339 creation timestamp.
+	Created string `json:"created,omitempty"`
+
+	// LeftRunning records whether the container kept running after the
+	// checkpoint (--leave-running) or was stopped by it.
+	LeftRunning bool `json:"left_running,omitempty"`
+
+	// TCPEstablished records whether established TCP connections were
+	// checkpointed (restore must then pass --tcp-established too).
+	TCPEstablished bool `json:"tcp_established,omitempty"`
+
+	// Restores lists the instances this checkpoint was restored into
+	// ("" = the deployment's own base instance). Informational.
+	Restores []string `json:"restores,omitempty"`
+}
+
+// podCheckpointsDir returns the checkpoints/ directory for a deployment. It
+// only computes the path — the writers create it, so listing a deployment
+// that was never checkpointed leaves no empty directories behind.
+func podCheckpointsDir(boxName, instance string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "share", "charly", "pod", containerNameInstance(boxName, instance), "checkpoints"), nil
+}
+
+// podCheckpointSidecars reports the sidecars attached to a deployment.
+// Package-level for test injection.
+var podCheckpointSidecars = resolveSidecarNames
+
+// refuseSidecarCheckpoint rejects deployments whose app container runs in a
+// pod with sidecars — see the file header.
+func refuseSidecarCheckpoint(boxName, instance string) error {
+	if sc := podCheckpointSidecars(boxName, instance); len(sc) > 0 {
+		return fmt.Errorf("%s runs with sidecars (%s); checkpoint/restore covers a single container, not a whole pod",
+			containerNameInstance(boxName, instance), strings.Join(sc, ", "))
+	}
+	return nil
+}
+
+// loadPodCheckpointRegistry reads registry.json or returns an empty registry
+// if the file doesn't exist.
+func loadPodCheckpointRegistry(boxName, instance string) (*PodCheckpointRegistry, error) {
+	dir, err := podCheckpointsDir(boxName, instance)
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, "registry.json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &PodCheckpointRegistry{Version: 1, Checkpoints: map[string]*PodCheckpointEntry{}}, nil
+		}
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	var reg PodCheckpointRegistry
+	if err := json.Unmarshal(data, &reg); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if reg.Checkpoints == nil {
+		reg.Checkpoints = map[string]*PodCheckpointEntry{}
+	}
+	if reg.Version == 0 {
+		reg.Version = 1
+	}
+	return &reg, nil
+}
+
+// savePodCheckpointRegistry atomically writes the registry (write-temp +
+// rename so a crash mid-write doesn't truncate the file).
+func savePodCheckpointRegistry(boxName, instance string, reg *PodCheckpointRegistry) error {
+	dir, err := podCheckpointsDir(boxName, instance)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("creating checkpoints dir %s: %w", dir, err)
+	}
+	if reg.Version == 0 {
+		reg.Version = 1
+	}
+	data, err := json.MarshalIndent(reg, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling registry: %w", err)
+	}
+	path := filepath.Join(dir, "registry.json")
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("writing %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("renaming %s → %s: %w", tmp, path, err)
+	}
+	return nil
+}
+
+// PodCheckpointCreateOpts parameterizes CreatePodCheckpoint.
+type PodCheckpointCreateOpts struct {
+	Box            string
+	Instance       string
+	Name           string
+	Description    string
+	Stop           bool // stop the container after checkpointing (default: leave it running)
+	TCPEstablished bool
+}
+
+// CreatePodCheckpoint checkpoints the deployment's running container into a
+// new named, registered archive.
+func CreatePodCheckpoint(opts PodCheckpointCreateOpts) (*PodCheckpointEntry, error) {
+	if opts.Name == "" {
+		return nil, fmt.Errorf("checkpoint name is required")
+	}
+	if strings.ContainsAny(opts.Name, `/\`) || opts.Name == "." || opts.Name == ".." {
+		return nil, fmt.Errorf("invalid checkpoint name %q", opts.Name)
+	}
+	if err := refuseSidecarCheckpoint(opts.Box, opts.Instance); err != nil {
+		return nil, err
+	}
+	engine, err := podCheckpointEngine(opts.Box, opts.Instance)
+	if err != nil {
+		return nil, err
+	}
+	ctr := containerNameInstance(opts.Box, opts.Instance)
+	if !containerRunning(engine, ctr) {
+		return nil, fmt.Errorf("container %s is not running — start it first (`charly start %s`)", ctr, opts.Box)
+	}
+	reg, err := loadPodCheckpointRegistry(opts.Box, opts.Instance)
+	if err != nil {
+		return nil, err
+	}
+	if _, exists := reg.Checkpoints[opts.Name]; exists {
+		return nil, fmt.Errorf("deployment %s: checkpoint %q already exists", ctr, opts.Name)
+	}
+	dir, err := podCheckpointsDir(opts.Box, opts.Instance)
+	if err != nil {
+		return nil, err
+	}
+	archive := filepath.Join(dir, opts.Name, "checkpoint.tar.gz")
+	if err := os.MkdirAll(filepath.Dir(archive), 0o755); err != nil {
+		return nil, fmt.Errorf("creating checkpoint dir: %w", err)
+	}
+
+	entry := &PodCheckpointEntry{
+		Name:           opts.Name,
+		Container:      ctr,
+		Archive:        archive,
+		Description:    opts.Description,
+		Created:        time.Now().UTC().Format(time.RFC3339),
+		LeftRunning:    !opts.Stop,
+		TCPEstablished: opts.TCPEstablished,
+	}
+	if img, ierr := containerImageRef(engine, ctr); ierr == nil {
+		entry.Image = img
+		if labels, lerr := InspectLabels(engine, img); lerr == nil {
+			entry.Version = labels[LabelVersion]
+		}
+	}
+
+	if out, err := exec.Command(EngineBinary(engine), podCheckpointArgs(entry)...).CombinedOutput(); err != nil {
+		_ = os.RemoveAll(filepath.Dir(archive))
+		return nil, fmt.Errorf("checkpointing %s: %s", ctr, strings.TrimSpace(string(out)))
+	}
+
+	reg.Checkpoints[opts.Name] = entry
+	if err := savePodCheckpointRegistry(opts.Box, opts.Instance, reg); err != nil {
+		return nil, err
+	}
+	if err := writePodCheckpointMeta(entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// podCheckpointArgs builds the `<engine> container checkpoint` argv.
+func podCheckpointArgs(e *PodCheckpointEntry) []string {
+	args := []string{"container", "checkpoint", "--export", e.Archive}
+	if e.LeftRunning {
+		args = append(args, "--leave-running")
+	}
+	if e.TCPEstablished {
+		args = append(args, "--tcp-established")
+	}
+	return append(args, e.Container)
+}
+
+// PodCheckpointRestoreOpts parameterizes RestorePodCheckpoint.
+type PodCheckpointRestoreOpts struct {
+	Box      string
+	Instance string // the deployment the checkpoint belongs to
+	Name     string
+	// As is the instance to restore into; empty restores over the
+	// checkpointed deployment itself.
+	As      string
+	Publish []string // -p overrides for the restored container (a new instance usually needs other host ports)
+}
+
+// configureCheckpointInstance configures a new instance of boxName on
+// imageRef — its quadlet and charly.yml entry — before a checkpoint is
+// restored into it. A package var so tests can stub it.
+var configureCheckpointInstance = func(boxName, instance, imageRef string, publish []string) error {
+	cc := &BoxConfigSetupCmd{Box: boxName, Instance: instance, Port: publish, ExplicitRef: imageRef}
+	return cc.Run()
+}
+
+// RestorePodCheckpoint imports a checkpoint. Restoring over the source
+// deployment stops it through the regular stop path and replaces its
+// container; restoring under a new instance configures that instance first
+// and leaves the source untouched.
+func RestorePodCheckpoint(opts PodCheckpointRestoreOpts) (string, error) {
+	if err := refuseSidecarCheckpoint(opts.Box, opts.Instance); err != nil {
+		return "", err
+	}
+	engine, err := podCheckpointEngine(opts.Box, opts.Instance)
+	if err != nil {
+		return "", err
+	}
+	reg, err := loadPodCheckpointRegistry(opts.Box, opts.Instance)
+	if err != nil {
+		return "", err
+	}
+	entry, ok := reg.Checkpoints[opts.Name]
+	if !ok {
+		return "", fmt.Errorf("deployment %s: no checkpoint %q", containerNameInstance(opts.Box, opts.Instance), opts.Name)
+	}
+	if _, err := os.Stat(entry.Archive); err != nil {
+		return "", fmt.Errorf("checkpoint %q archive: %w", opts.Name, err)
+	}
+	target := containerNameInstance(opts.Box, opts.As)
+	bin := EngineBinary(engine)
+
+	if opts.As != opts.Instance {
+		if exec.Command(bin, "container", "exists", target).Run() == nil {
+			return "", fmt.Errorf("container %s already exists — remove it (`charly remove %s -i %s`) or pick another instance name", target, opts.Box, opts.As)
+		}
+		if err := configureCheckpointInstance(opts.Box, opts.As, entry.Image, opts.Publish); err != nil {
+			return "", fmt.Errorf("configuring instance %s: %w", opts.As, err)
+		}
+	}
+	// Configuring may already have started the fresh container (a post_enable
+	// hook, or direct mode) — the restore replaces it either way.
+	if containerRunning(engine, target) {
+		if err := stopPodService(opts.Box, opts.As); err != nil {
+			return "", err
+		}
+	}
+	if out, err := exec.Command(bin, "rm", "--ignore", target).CombinedOutput(); err != nil {
+		return "", fmt.Errorf("removing %s before restore: %s", target, strings.TrimSpace(string(out)))
+	}
+
+	args := podRestoreArgs(entry, target, opts.Publish)
+	if unit, _ := quadletExistsInstance(opts.Box, opts.As); unit {
+		if err := restoreThroughUnit(opts.Box, opts.As, bin, args, target); err != nil {
+			return "", fmt.Errorf("restoring %s from checkpoint %q: %w", target, opts.Name, err)
+		}
+	} else if out, err := exec.Command(bin, args...).CombinedOutput(); err != nil {
+		return "", fmt.Errorf("restoring %s from checkpoint %q: %s", target, opts.Name, strings.TrimSpace(string(out)))
+	}
+	entry.Restores = appendUnique(entry.Restores, opts.As)
+	if err := savePodCheckpointRegistry(opts.Box, opts.Instance, reg); err != nil {
+		return "", err
+	}
+	return target, nil
+}
+
+// podRestoreArgs builds the `<engine> container restore` argv. A restored
+// container keeps the checkpoint's static IP/MAC only when it replaces the
+// original; a forked instance must not collide with it.
+func podRestoreArgs(e *PodCheckpointEntry, target string, publish []string) []string {
+	args := []string{"container", "restore", "--import", e.Archive, "--name", target}
+	if target != e.Container {
+		args = append(args, "--ignore-static-ip", "--ignore-static-mac")
+	}
+	if e.TCPEstablished {
+		args = append(args, "--tcp-established")
+	}
+	for _, p := range publish {
+		args = append(args, "--publish", p)
+	}
+	return args
+}
+
+// podRestoreDropInFile is the transient drop-in restoreThroughUnit places in
+// the service's <unit>.d/ directory.
+const podRestoreDropInFile = "50-charly-checkpoint-restore.conf"
+
+// restoreThroughUnit starts the deployment's service once with its ExecStart
+// replaced by the restore, then removes the drop-in again: the unit stays
+// active (a oneshot that remains after exit) until `charly stop`, whose
+// ExecStop finds the restored container through the cid file, and the next
+// start is the quadlet's regular fresh `podman run`.
+func restoreThroughUnit(boxName, instance, bin string, restoreArgs []string, target string) error {
+	udir, err := systemdUserDir()
+	if err != nil {
+		return err
+	}
+	svc := serviceNameInstance(boxName, instance)
+	dropDir := filepath.Join(udir, svc+".d")
+	if err := os.MkdirAll(dropDir, 0o755); err != nil {
+		return err
+	}
+	dropIn := filepath.Join(dropDir, podRestoreDropInFile)
+	if err := os.WriteFile(dropIn, []byte(podRestoreDropIn(bin, restoreArgs, target)), 0o644); err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(dropIn)
+		_ = os.Remove(dropDir) // only when empty
+		_ = exec.Command("systemctl", "--user", "daemon-reload").Run()
+	}()
+	for _, args := range [][]string{{"--user", "daemon-reload"}, {"--user", "start", svc}} {
+		if out, err := exec.Command("systemctl", args...).CombinedOutput(); err != nil {
+			return fmt.Errorf("systemctl %s: %w\n%s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+		}
+	}
+	return nil
+}
+
+// podRestoreDropIn renders the drop-in: a oneshot (the restore detaches like
+// `podman run -d`, and there is no conmon sd-notify to wait for) that records
+// the restored container's ID where the quadlet's ExecStop expects it.
+func podRestoreDropIn(bin string, restoreArgs []string, target string) string {
+	script := shellQuoteArgs(append([]string{bin}, restoreArgs...)) + " && " +
+		shellQuoteArgs([]string{bin, "container", "inspect", "--format", "{{.Id}}", target})
+	var b strings.Builder
+	b.WriteString("# Written by charly checkpoint restore for one start; removed right after.\n")
+	b.WriteString("[Service]\n")
+	b.WriteString("Type=oneshot\n")
+	b.WriteString("RemainAfterExit=yes\n")
+	b.WriteString("Restart=no\n")
+	b.WriteString("ExecStart=\n")
+	fmt.Fprintf(&b, "ExecStart=/bin/sh -c \"%s > %%t/%%N.cid\"\n", systemdEscapeQuoted(script))
+	return b.String()
+}
+
+// systemdEscapeQuoted escapes s for a double-quoted unit-file word: backslash
+// and quote escapes, and doubled % and $ so systemd expands neither
+// specifiers nor variables inside it.
+func systemdEscapeQuoted(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "%", "%%", "$", "$$").Replace(s)
+}
+
+// ListPodCheckpoints returns the deployment's checkpoints sorted by name.
+func ListPodCheckpoints(boxName, instance string) ([]*PodCheckpointEntry, error) {
+	reg, err := loadPodCheckpointRegistry(boxName, instance)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*PodCheckpointEntry, 0, len(reg.Checkpoints))
+	for _, e := range reg.Checkpoints {
+		out = append(out, e)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+// DeletePodCheckpoint removes a checkpoint's archive and registry record.
+func DeletePodCheckpoint(boxName, instance, name string) error {
+	reg, err := loadPodCheckpointRegistry(boxName, instance)
+	if err != nil {
+		return err
+	}
+	entry, ok := reg.Checkpoints[name]
+	if !ok {
+		return fmt.Errorf("deployment %s: no checkpoint %q", containerNameInstance(boxName, instance), name)
+	}
+	if err := os.RemoveAll(filepath.Dir(entry.Archive)); err != nil {
+		return fmt.Errorf("removing checkpoint %q: %w", name, err)
+	}
+	delete(reg.Checkpoints, name)
+	return savePodCheckpointRegistry(boxName, instance, reg)
+}
+
+// writePodCheckpointMeta emits the per-checkpoint meta.json sidecar.
+func writePodCheckpointMeta(e *PodCheckpointEntry) error {
+	data, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(filepath.Dir(e.Archive), "meta.json"), data, 0o644)
+}
+
+// podCheckpointEngine resolves the deployment's engine, refusing non-pod
+// deploy targets (a VM has `charly vm snapshot`) and non-podman engines
+// (docker has no CRIU export/import).
+func podCheckpointEngine(boxName, instance string) (string, error) {
+	dc := loadDeployConfigForRead("charly checkpoint")
+	if node, ok := dc.Bundle[deployKey(boxName, instance)]; ok {
+		if t := node.Target; t != "" && t != "pod" && t != "container" {
+			return "", fmt.Errorf("%s is a target: %s deployment; checkpoints are for pod deployments (VMs use `charly vm snapshot`)", boxName, t)
+		}
+	}
+	rt, err := ResolveRuntime()
+	if err != nil {
+		return "", err
+	}
+	engine := ResolveBoxEngineForDeploy(boxName, instance, rt.RunEngine)
+	if engine != "podman" {
+		return "", fmt.Errorf("checkpoint/restore needs podman (CRIU); %s runs on %s", boxName, engine)
+	}
+	return engine, nil
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+)
+
+// pod_checkpoint_cmd.go — Kong subcommand wiring for `charly checkpoint {…}`.
+// Registered as a dedicated COMMAND-class builtin (plugin_command_checkpoint.go).
+
+// CheckpointCmd is the parent of `charly checkpoint`.
+type CheckpointCmd struct {
+	Create  CheckpointCreateCmd  `cmd:"" help:"Checkpoint a running pod deployment (CRIU; leaves it running unless --stop)"`
+	List    CheckpointListCmd    `cmd:"" help:"List a deployment's checkpoints"`
+	Delete  CheckpointDeleteCmd  `cmd:"" help:"Delete a checkpoint"`
+	Restore CheckpointRestoreCmd `cmd:"" help:"Restore a checkpoint into the same deployment or a new instance"`
+}
+
+// CheckpointCreateCmd implements `charly checkpoint create <box> <name>`.
+type CheckpointCreateCmd struct {
+	Box            string `arg:"" help:"Box name"`
+	Name           string `arg:"" help:"Checkpoint name"`
+	Instance       string `short:"i" long:"instance" help:"Instance name"`
+	Description    string `long:"description" help:"Human-facing description of the checkpoint"`
+	Stop           bool   `long:"stop" help:"Stop the container after checkpointing instead of leaving it running"`
+	TCPEstablished bool   `long:"tcp-established" help:"Checkpoint established TCP connections too"`
+}
+
+func (c *CheckpointCreateCmd) Run() error {
+	box := resolveBoxName(c.Box)
+	entry, err := CreatePodCheckpoint(PodCheckpointCreateOpts{
+		Box:            box,
+		Instance:       c.Instance,
+		Name:           c.Name,
+		Description:    c.Description,
+		Stop:           c.Stop,
+		TCPEstablished: c.TCPEstablished,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("created checkpoint %q of %s\n", entry.Name, entry.Container)
+	fmt.Printf("  archive: %s\n", entry.Archive)
+	return nil
+}
+
+// CheckpointListCmd implements `charly checkpoint list <box>`.
+type CheckpointListCmd struct {
+	Box      string `arg:"" help:"Box name"`
+	Instance string `short:"i" long:"instance" help:"Instance name"`
+	JSON     bool   `long:"json" help:"Emit JSON instead of a table"`
+}
+
+func (c *CheckpointListCmd) Run() error {
+	box := resolveBoxName(c.Box)
+	entries, err := ListPodCheckpoints(box, c.Instance)
+	if err != nil {
+		return err
+	}
+	if c.JSON {
+		return writeJSON(os.Stdout, entries)
+	}
+	if len(entries) == 0 {
+		fmt.Printf("%s: no checkpoints\n", containerNameInstance(box, c.Instance))
+		return nil
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tVERSION\tCREATED\tDESCRIPTION")
+	for _, e := range entries {
+		desc := e.Description
+		if len(desc) > 60 {
+			desc = desc[:57] + "..."
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", e.Name, e.Version, e.Created, desc)
+	}
+	return tw.Flush()
+}
+
+// CheckpointDeleteCmd implements `charly checkpoint delete <box> <name>`.
+type CheckpointDeleteCmd struct {
+	Box      string `arg:"" help:"Box name"`
+	Name     string `arg:"" help:"Checkpoint name"`
+	Instance string `short:"i" long:"instance" help:"Instance name"`
+}
+
+func (c *CheckpointDeleteCmd) Run() error {
+	box := resolveBoxName(c.Box)
+	if err := DeletePodCheckpoint(box, c.Instance, c.Name); err != nil {
+		return err
+	}
+	fmt.Printf("deleted checkpoint %q of %s\n", c.Name, containerNameInstance(box, c.Instance))
+	return nil
+}
+
+// CheckpointRestoreCmd implements `charly checkpoint restore <box> <name>`.
+type CheckpointRestoreCmd struct {
+	Box      string   `arg:"" help:"Box name"`
+	Name     string   `arg:"" help:"Checkpoint name"`
+	Instance string   `short:"i" long:"instance" help:"Instance the checkpoint was taken from"`
+	As       string   `long:"as" help:"Restore as this instance instead of replacing the checkpointed container"`
+	Publish  []string `short:"p" long:"publish" help:"Port mapping for the restored container (host:container); a new instance usually needs its own host ports"`
+}
+
+func (c *CheckpointRestoreCmd) Run() error {
+	box := resolveBoxName(c.Box)
+	as := c.As
+	if as == "" {
+		as = c.Instance
+	}
+	name, err := RestorePodCheckpoint(PodCheckpointRestoreOpts{
+		Box:      box,
+		Instance: c.Instance,
+		Name:     c.Name,
+		As:       as,
+		Publish:  c.Publish,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("restored checkpoint %q as %s\n", c.Name, name)
+	return nil
+}
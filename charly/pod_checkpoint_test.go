@@ -0,0 +1,142 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestPodCheckpointRegistry_RoundTrip(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	reg, err := loadPodCheckpointRegistry("demo", "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reg.Version != 1 || len(reg.Checkpoints) != 0 {
+		t.Fatalf("fresh registry = %+v", reg)
+	}
+	dir, _ := podCheckpointsDir("demo", "b")
+	if want := filepath.Join(os.Getenv("HOME"), ".local/share/charly/pod/charly-demo-b/checkpoints"); dir != want {
+		t.Errorf("dir = %s, want %s", dir, want)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("reading the registry created %s: %v", dir, err)
+	}
+	for _, n := range []string{"warm", "cold"} {
+		archive := filepath.Join(dir, n, "checkpoint.tar.gz")
+		mustMkdir(t, filepath.Dir(archive))
+		mustWrite(t, archive, "x")
+		reg.Checkpoints[n] = &PodCheckpointEntry{Name: n, Container: "charly-demo-b", Archive: archive}
+	}
+	if err := savePodCheckpointRegistry("demo", "b", reg); err != nil {
+		t.Fatal(err)
+	}
+	list, err := ListPodCheckpoints("demo", "b")
+	if err != nil || len(list) != 2 || list[0].Name != "cold" || list[1].Name != "warm" {
+		t.Fatalf("list = %+v, %v", list, err)
+	}
+	if err := DeletePodCheckpoint("demo", "b", "warm"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "warm")); !os.IsNotExist(err) {
+		t.Errorf("checkpoint dir survived delete: %v", err)
+	}
+	if err := DeletePodCheckpoint("demo", "b", "warm"); err == nil {
+		t.Error("expected an error deleting an unknown checkpoint")
+	}
+	if list, _ := ListPodCheckpoints("demo", "b"); len(list) != 1 {
+		t.Errorf("after delete = %d entries", len(list))
+	}
+}
+
+func TestPodCheckpointArgs(t *testing.T) {
+	e := &PodCheckpointEntry{Container: "charly-demo", Archive: "/c/warm/checkpoint.tar.gz", LeftRunning: true, TCPEstablished: true}
+	want := []string{"container", "checkpoint", "--export", "/c/warm/checkpoint.tar.gz", "--leave-running", "--tcp-established", "charly-demo"}
+	if got := podCheckpointArgs(e); !slices.Equal(got, want) {
+		t.Errorf("checkpoint args = %v", got)
+	}
+
+	// Same container: keep the static IP/MAC.
+	want = []string{"container", "restore", "--import", "/c/warm/checkpoint.tar.gz", "--name", "charly-demo", "--tcp-established"}
+	if got := podRestoreArgs(e, "charly-demo", nil); !slices.Equal(got, want) {
+		t.Errorf("in-place restore args = %v", got)
+	}
+	// A forked instance drops them and takes its own ports.
+	want = []string{"container", "restore", "--import", "/c/warm/checkpoint.tar.gz", "--name", "charly-demo-fork",
+		"--ignore-static-ip", "--ignore-static-mac", "--tcp-established", "--publish", "18080:8080"}
+	if got := podRestoreArgs(e, "charly-demo-fork", []string{"18080:8080"}); !slices.Equal(got, want) {
+		t.Errorf("fork restore args = %v", got)
+	}
+}
+
+// A deployment with sidecars is refused up front: only the app container would
+// be checkpointed, detached from the pod its sidecars share.
+func TestPodCheckpoint_RefusesSidecars(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	orig := podCheckpointSidecars
+	podCheckpointSidecars = func(string, string) []string { return []string{"tailscale"} }
+	defer func() { podCheckpointSidecars = orig }()
+
+	if _, err := CreatePodCheckpoint(PodCheckpointCreateOpts{Box: "demo", Name: "warm"}); err == nil || !strings.Contains(err.Error(), "sidecars (tailscale)") {
+		t.Errorf("create = %v, want a sidecar refusal", err)
+	}
+	if _, err := RestorePodCheckpoint(PodCheckpointRestoreOpts{Box: "demo", Name: "warm", As: "fork"}); err == nil || !strings.Contains(err.Error(), "sidecars (tailscale)") {
+		t.Errorf("restore = %v, want a sidecar refusal", err)
+	}
+}
+
+// The restore drop-in turns the unit into a oneshot that runs the restore and
+// leaves the restored container's ID where the quadlet's ExecStop reads it;
+// systemd-significant characters in the restore argv are escaped.
+func TestPodRestoreDropIn(t *testing.T) {
+	got := podRestoreDropIn("podman", []string{"container", "restore", "--import", "/c/50%/x.tar.gz", "--name", "charly-demo-fork"}, "charly-demo-fork")
+	for _, want := range []string{
+		"Type=oneshot\n", "RemainAfterExit=yes\n", "Restart=no\n", "ExecStart=\n",
+		`ExecStart=/bin/sh -c "podman container restore --import /c/50%%/x.tar.gz --name charly-demo-fork && podman container inspect --format '{{.Id}}' charly-demo-fork > %t/%N.cid"` + "\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("drop-in missing %q:\n%s", want, got)
+		}
+	}
+}
+
+// Restoring under a new instance configures it first — quadlet and charly.yml
+// entry — on the checkpointed image, with the requested ports.
+func TestRestorePodCheckpoint_ConfiguresNewInstance(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("CHARLY_RUN_ENGINE", "podman")
+	dir := t.TempDir()
+	mustWrite(t, filepath.Join(dir, "podman"), "#!/bin/sh\ncase \"$1 $2\" in\n  \"container exists\") exit 1 ;;\nesac\nexit 0\n")
+	if err := os.Chmod(filepath.Join(dir, "podman"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+":"+os.Getenv("PATH"))
+
+	cdir, _ := podCheckpointsDir("demo", "")
+	archive := filepath.Join(cdir, "warm", "checkpoint.tar.gz")
+	mustMkdir(t, filepath.Dir(archive))
+	mustWrite(t, archive, "x")
+	reg := &PodCheckpointRegistry{Version: 1, Checkpoints: map[string]*PodCheckpointEntry{
+		"warm": {Name: "warm", Container: "charly-demo", Image: "localhost/demo:2026.100.1", Archive: archive},
+	}}
+	if err := savePodCheckpointRegistry("demo", "", reg); err != nil {
+		t.Fatal(err)
+	}
+
+	var configured []string
+	orig := configureCheckpointInstance
+	configureCheckpointInstance = func(box, instance, ref string, publish []string) error {
+		configured = append([]string{box, instance, ref}, publish...)
+		return nil
+	}
+	defer func() { configureCheckpointInstance = orig }()
+
+	if _, err := RestorePodCheckpoint(PodCheckpointRestoreOpts{Box: "demo", Name: "warm", As: "fork", Publish: []string{"18080:8080"}}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"demo", "fork", "localhost/demo:2026.100.1", "18080:8080"}; !slices.Equal(configured, want) {
+		t.Errorf("configured = %v, want %v", configured, want)
+	}
+}
@@ -102,14 +102,39 @@ func (a *arbiterProxy) Status() (*spec.PreemptLedger, []string, error) {
 	return ledger, r.Stranded, nil
 }
 
-// ReleaseClaimant restores the holders a claimant's lease stopped + removes the lease.
+// ReleaseClaimant restores the holders a claimant's lease stopped + removes the lease. Releasing a
+// claimant that holds a lease is journaled as a preempt.release event (events.go) from the lease
+// the release reply carries — or, from an arbiter plugin predating the reply's lease field, from
+// the ledger read just before the release; the holders it restores journal their own
+// preempt.restore through the start seam.
 func (a *arbiterProxy) ReleaseClaimant(claimant string, success bool) error {
+	held := a.leaseOf(claimant)
 	r, err := arbiterInvoke(spec.ArbiterInvokeInput{Action: spec.ArbiterActionRelease, Claimant: claimant, Success: success})
+	if err == nil && r.Error != "" {
+		err = errors.New(r.Error)
+	}
+	lease := r.Lease
+	if lease == nil {
+		lease = held
+	}
+	if lease != nil {
+		ev := Event{Type: EventPreemptRelease, Deploy: claimant, Kind: SubstrateKind(lease.Claim.Target),
+			Detail: map[string]string{"tokens": strings.Join(lease.Tokens, ","), "success": strconv.FormatBool(success)}}
+		recordOutcome(ev, err)
+	}
+	return err
+}
+
+// leaseOf returns the claimant's active lease, nil when it holds none (or the ledger is unreadable).
+func (a *arbiterProxy) leaseOf(claimant string) *spec.PreemptLease {
+	ledger, _, err := a.Status()
 	if err != nil {
-		return err
+		return nil
 	}
-	if r.Error != "" {
-		return errors.New(r.Error)
+	for i := range ledger.Leases {
+		if ledger.Leases[i].Claimant == claimant {
+			return &ledger.Leases[i]
+		}
 	}
 	return nil
 }
@@ -196,7 +221,7 @@ func acquireSharedForClaimant(claimant string, node BundleNode, transient bool)
 
 // acquireDispatch is the shared acquire leg (R3): it Invokes verb:arbiter with the pre-computed
 // tokens + claim address, and on an active lease marks envPreemptLeaseHeld so nested
-// subprocesses skip re-acquiring.
+// subprocesses skip re-acquiring. An active or refused claim is journaled as preempt.acquire.
 func acquireDispatch(action, claimant string, tokens []string, node BundleNode, transient bool) (*Lease, error) {
 	r, err := arbiterInvoke(spec.ArbiterInvokeInput{
 		Action:    action,
@@ -205,13 +230,17 @@ func acquireDispatch(action, claimant string, tokens []string, node BundleNode,
 		ClaimAddr: holderAddrFor(claimant, node),
 		Transient: transient,
 	})
+	if err == nil && r.Error != "" {
+		err = errors.New(r.Error)
+	}
+	ev := Event{Type: EventPreemptAcquire, Deploy: claimant, Kind: SubstrateKind(node.Target),
+		Detail: map[string]string{"tokens": strings.Join(tokens, ","), "mode": strings.TrimPrefix(action, "acquire-")}}
 	if err != nil {
+		recordOutcome(ev, err)
 		return nil, err
 	}
-	if r.Error != "" {
-		return nil, errors.New(r.Error)
-	}
 	if r.Active {
+		recordOutcome(ev, nil)
 		_ = os.Setenv(envPreemptLeaseHeld, claimant)
 	}
 	return &Lease{claimant: claimant, active: r.Active}, nil
@@ -24,6 +24,7 @@ package main
 // providerRegistry.ResolveBuilder to a *grpcProvider connected at plugin-load time.
 var externalizedBuilders = map[string]bool{
 	"cargo": true,
+	"go":    true,
 	"npm":   true,
 	"pixi":  true,
 	"aur":   true,
@@ -34,6 +35,7 @@ var externalizedBuilders = map[string]bool{
 // externalDeploySubstratePlugins.
 var externalBuilderPlugins = map[string]string{
 	"cargo": "candy/plugin-builder-cargo",
+	"go":    "candy/plugin-builder-go",
 	"npm":   "candy/plugin-builder-npm",
 	"pixi":  "candy/plugin-builder-pixi",
 	"aur":   "candy/plugin-builder-aur",
@@ -163,6 +163,17 @@ func (r *Runner) invokeVerbProvider(ctx context.Context, prov Provider, word str
 			c = &cc
 		}
 	}
+	// Same for a display step's image fixtures: anchor artifact_baseline / template to
+	// the authoring candy (or project) and default artifact_diff into the run dir.
+	if c.ArtifactBaseline != "" || c.Template != "" {
+		resolved, err := r.resolveCheckImagePaths(c)
+		if err != nil {
+			res.Status = TestFail
+			res.Message = fmt.Sprintf("verb %q: %v", word, err)
+			return res
+		}
+		c = resolved
+	}
 	// Host-side per-verb preresolution via the GENERIC registry — no per-verb
 	// special-casing in this dispatch (the Uniform API Invariant). The preresolver
 	// registered under this verb word runs: cdp/vnc/mcp/spice fill an opaque endpoint
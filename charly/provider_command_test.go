@@ -124,10 +124,11 @@ func TestCommandProviders_ExtractedLeafCommands(t *testing.T) {
 
 // TestCommandProviders_DeployLifecycleCommands proves every deploy-lifecycle + remaining
 // leaf command extracted into a dedicated COMMAND-class provider (the deploy-lifecycle
-// batch: start/stop/status/restart/update/remove/logs/shell/cmd/cp/volume/service/config/
-// bundle/reap-orphans) is (1) registered in providerRegistry as a CommandProvider with the
-// matching Reserved() word, and (2) collected by collectCommandPlugins() and injected into
-// the REAL charly CLI grammar via kong.Plugins, so its subcommand path parses and selects
+// batch: start/stop/status/restart/update/remove/logs/shell/cmd/cp/volume/checkpoint/
+// service/config/bundle/reap-orphans) is (1) registered in providerRegistry as a
+// CommandProvider with the matching Reserved() word, and (2) collected by
+// collectCommandPlugins() and injected into the REAL charly CLI grammar via kong.Plugins,
+// so its subcommand path parses and selects
 // exactly as before the extraction (the Run handler — which calls the unchanged core
 // deploy/bundle machinery — is preserved verbatim). The test FAILS if any dedicated
 // registration regresses or the command seam stops wiring one of them into the root.
@@ -139,7 +140,7 @@ func TestCommandProviders_DeployLifecycleCommands(t *testing.T) {
 	}{
 		{"start", []string{"start", "mybox"}, "start <box>"},
 		{"stop", []string{"stop", "mybox"}, "stop <box>"},
-		{"status", []string{"status", "mybox"}, "status <box>"},
+		{"status", []string{"status", "mybox"}, "status show <box>"}, // default subcommand (status serve is the other)
 		{"restart", []string{"restart", "mybox"}, "restart <box>"},
 		{"update", []string{"update", "mybox"}, "update <box>"},
 		{"remove", []string{"remove", "mybox"}, "remove <box>"},
@@ -148,6 +149,8 @@ func TestCommandProviders_DeployLifecycleCommands(t *testing.T) {
 		{"cmd", []string{"cmd", "mybox", "echo hi"}, "cmd <box> <command>"},
 		{"cp", []string{"cp", "mybox", ":/a", "/b"}, "cp <box> <src> <dst>"},
 		{"volume", []string{"volume", "list", "mybox"}, "volume list <box>"},
+		{"checkpoint", []string{"checkpoint", "list", "mybox"}, "checkpoint list <box>"},
+		{"events", []string{"events", "mybox", "--type", "deploy"}, "events <deploy>"},
 		{"service", []string{"service", "status", "mybox"}, "service status <box>"},
 		{"config", []string{"config", "status", "mybox"}, "config status <box>"},
 		{"bundle", []string{"bundle", "path"}, "bundle path"},
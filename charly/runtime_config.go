@@ -35,6 +35,9 @@ type RuntimeConfig struct {
 	// re-execing commands on remote machines. Set via
 	// `charly settings set hosts.<alias> <ssh-target>`.
 	HostAliases map[string]string `yaml:"host_aliases,omitempty" json:"host_aliases,omitempty"`
+	// EventSinks forward lifecycle events (`charly events`) to webhooks or
+	// commands as they are recorded. See events.go.
+	EventSinks []EventSink `yaml:"event_sinks,omitempty" json:"event_sinks,omitempty"`
 }
 
 // RuntimeVmConfig holds user-level VM defaults
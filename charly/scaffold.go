@@ -23,7 +23,7 @@ func ScaffoldCandy(dir string, name string) error {
 	// Create a placeholder candy manifest in the canonical kind-keyed form,
 	// named via the single configurable default (UnifiedFileName).
 	candyYml := filepath.Join(candyDir, UnifiedFileName)
-	candyContent := fmt.Sprintf("# %s candy config\ncandy:\n  name: %s\n  version: %s\n  # Add packages:  charly candy add-rpm %s <pkg>   (also add-deb / add-pac / add-aur)\n  # Or add task:/env:/service:/require: — see the candy authoring guide.\n", name, name, ComputeCalVer(), name)
+	candyContent := fmt.Sprintf("# %s candy config\ncandy:\n  name: %s\n  version: %s\n  # Add packages:  charly candy add-rpm %s <pkg>   (also add-deb / add-pac / add-aur / add-apk)\n  # Or add task:/env:/service:/require: — see the candy authoring guide.\n", name, name, ComputeCalVer(), name)
 	if err := os.WriteFile(candyYml, []byte(candyContent), 0644); err != nil {
 		return fmt.Errorf("creating %s: %w", UnifiedFileName, err)
 	}
@@ -256,6 +256,7 @@ type CandyCmd struct {
 	AddDeb CandyAddPkgCmd `cmd:"add-deb" help:"Append packages to a candy's shared distro.'debian,ubuntu'.package list"`
 	AddPac CandyAddPkgCmd `cmd:"add-pac" help:"Append packages to a candy's distro.arch.package list"`
 	AddAur CandyAddPkgCmd `cmd:"add-aur" help:"Append packages to a candy's distro.arch.aur.package list"`
+	AddApk CandyAddPkgCmd `cmd:"add-apk" help:"Append packages to a candy's distro.alpine.package list"`
 }
 
 type CandySetCmd struct {
@@ -285,7 +286,7 @@ func (c *CandySetCmd) Run() error {
 	return SetByDotPath(candyYml, path, c.Value)
 }
 
-// CandyAddPkgCmd is shared between add-rpm/add-deb/add-pac/add-aur. The
+// CandyAddPkgCmd is shared between add-rpm/add-deb/add-pac/add-aur/add-apk. The
 // section name is derived from the Kong command name at runtime. Since
 // Kong dispatches to the *same* struct type for all four, we determine
 // "which section" via a back-channel: each command instance is its own
@@ -322,6 +323,8 @@ func detectPkgSection(args []string) string {
 			return "pac"
 		case "add-aur":
 			return "aur"
+		case "add-apk":
+			return "apk"
 		}
 	}
 	return "rpm"
@@ -329,7 +332,7 @@ func detectPkgSection(args []string) string {
 
 // sectionDistroPath maps an add-<fmt> section name to the `distro:` map path its
 // packages land under in the cascade schema. Packages live ONLY under the
-// `distro:` map now — `add-rpm`→fedora, `add-pac`→arch, `add-aur`→arch.aur, and
+// `distro:` map now — `add-rpm`→fedora, `add-pac`→arch, `add-aur`→arch.aur, `add-apk`→alpine, and
 // `add-deb`→the shared `debian,ubuntu` compound (the common case; per-distro or
 // per-version overrides are authored with `charly candy set distro.<tag>.package`).
 var sectionDistroPath = map[string][]string{
@@ -337,6 +340,7 @@ var sectionDistroPath = map[string][]string{
 	"deb": {"distro", "debian,ubuntu"},
 	"pac": {"distro", "arch"},
 	"aur": {"distro", "arch", "aur"},
+	"apk": {"distro", "alpine"},
 }
 
 // appendCandyPackages reads the candy manifest, appends packages to the
@@ -352,6 +352,21 @@
 	artifact_min_dimensions?:  string & =~"^[0-9]+x[0-9]+$" @go(ArtifactMinDimensions)
 	artifact_not_uniform?:     bool                         @go(ArtifactNotUniform)
 	artifact_min_cast_events?: int & >=0                    @go(ArtifactMinCastEvents,type=int)
+	// Visual regression: compare the captured image against a golden baseline
+	// (recorded on first run when absent). artifact_max_diff is the tolerated
+	// percentage of differing pixels (default 0); artifact_pixel_threshold the
+	// per-pixel perceptual distance 0..1 (default 0.1); artifact_ignore masks
+	// "X,Y,WxH" regions; artifact_diff is where a failing run writes its diff image.
+	artifact_baseline?:        string                                   @go(ArtifactBaseline)
+	artifact_max_diff?:        number & >=0 & <=100                     @go(ArtifactMaxDiff,type=float64)
+	artifact_pixel_threshold?: number & >=0 & <=1                       @go(ArtifactPixelThreshold,type=float64)
+	artifact_ignore?: [...string & =~"^[0-9]+,[0-9]+,[0-9]+x[0-9]+$"] @go(ArtifactIgnore)
+	artifact_diff?:            string                                   @go(ArtifactDiff)
+	// find-image / click-image (vnc / spice / wl): the reference image to locate in a
+	// fresh screenshot (relative = the authoring candy's tree, or the project) and the
+	// minimum normalized-correlation score 0..1 a match must reach (default 0.9).
+	template?:                 string                       @go(Template)
+	match_threshold?:          number & >=0 & <=1           @go(MatchThreshold,type=float64)
 	x?:                        int                          @go(,type=int)
 	y?:                        int                          @go(,type=int)
 	x2?:                       int                          @go(,type=int)
@@ -366,6 +381,20 @@
 	action?:                   string
 	query?:                    string
 
+	// --- cdp network capture (network-start / network-stop) ---
+	// network_expect: per-request assertions over the recording (a request matching
+	// url/host/method returned status within max_ms, or — absent: true — no such
+	// request was made); network_allow_hosts: every http(s) request's host must match
+	// one entry (exact, or "*.example.com").
+	network_expect?:      [...#CdpNetworkExpect] @go(NetworkExpect)
+	network_allow_hosts?: [...string]            @go(NetworkAllowHosts)
+
+	// --- cdp console collection (console-start / console) ---
+	// console_level: the lowest level that fails the step (default error);
+	// console_allow: regexps over an entry's text or source URL that exempt it.
+	console_level?: "error" | "warning" | "info" @go(ConsoleLevel)
+	console_allow?: [...string]                  @go(ConsoleAllow)
+
 	// --- mcp ---
 	mcp_name?: string @go(McpName)
 	tool?:     string
@@ -398,13 +427,13 @@
 // Live-container verb method allowlists — the per-verb method-name enums on core
 // #Op. A method outside the set is rejected declaratively in CUE; the per-verb
 // method contract + required-modifier checks live in each verb's out-of-process plugin.
-#CdpMethod:     ("status" | "list" | "url" | "text" | "html" | "eval" | "axtree" | "coords" | "raw" | "wait" | "screenshot" | "open" | "close" | "click" | "type" | "spa-status" | "spa-click" | "spa-type" | "spa-key" | "spa-key-combo" | "spa-mouse") @go(-)
-#WlMethod:      "status" | "toplevel" | "windows" | "geometry" | "xprop" | "atspi" | "screenshot" | "clipboard" | "click" | "double-click" | "mouse" | "scroll" | "drag" | "type" | "key" | "key-combo" | "focus" | "close" | "fullscreen" | "minimize" | "exec" | "resolution" | "overlay-list" | "overlay-status" | "overlay-show" | "overlay-hide" | "sway-tree" | "sway-workspaces" | "sway-outputs" | "sway-msg" | "sway-focus" | "sway-move" | "sway-resize" | "sway-layout" | "sway-workspace" | "sway-kill" | "sway-floating" | "sway-reload" @go(-)
+#CdpMethod:     ("status" | "list" | "url" | "text" | "html" | "eval" | "axtree" | "coords" | "raw" | "wait" | "screenshot" | "open" | "close" | "click" | "type" | "spa-status" | "spa-click" | "spa-type" | "spa-key" | "spa-key-combo" | "spa-mouse" | "network-start" | "network-stop" | "console-start" | "console") @go(-)
+#WlMethod:      "status" | "toplevel" | "windows" | "geometry" | "xprop" | "atspi" | "screenshot" | "clipboard" | "click" | "double-click" | "mouse" | "scroll" | "drag" | "type" | "key" | "key-combo" | "focus" | "close" | "fullscreen" | "minimize" | "exec" | "resolution" | "overlay-list" | "overlay-status" | "overlay-show" | "overlay-hide" | "sway-tree" | "sway-workspaces" | "sway-outputs" | "sway-msg" | "sway-focus" | "sway-move" | "sway-resize" | "sway-layout" | "sway-workspace" | "sway-kill" | "sway-floating" | "sway-reload" | "find-image" | "click-image" @go(-)
 #DbusMethod:    "list" | "call" | "introspect" | "notify" @go(-)
-#VncMethod:     "status" | "screenshot" | "click" | "mouse" | "type" | "key" | "rfb" @go(-)
+#VncMethod:     "status" | "screenshot" | "click" | "mouse" | "type" | "key" | "rfb" | "find-image" | "click-image" @go(-)
 #McpMethod:     "ping" | "servers" | "list-tools" | "list-resources" | "list-prompts" | "call" | "read" @go(-)
 #RecordMethod:  "list" | "start" | "stop" | "cmd" @go(-)
-#SpiceMethod:   "status" | "screenshot" | "cursor" | "click" | "mouse" | "type" | "key" @go(-)
+#SpiceMethod:   "status" | "screenshot" | "cursor" | "click" | "mouse" | "type" | "key" | "find-image" | "click-image" @go(-)
 #LibvirtMethod: "list" | "info" | "screenshot" | "send-key" | "passwd" | "qmp" | "domain-xml" | "console" | "events" | "guest/ping" | "guest/info" | "guest/os-info" | "guest/time" | "guest/hostname" | "guest/users" | "guest/interfaces" | "guest/disks" | "guest/fsinfo" | "guest/vcpus" | "guest/exec" | "guest/fstrim" | "snapshot/list" | "snapshot/create" | "snapshot/info" | "snapshot/revert" | "snapshot/delete" @go(-)
 #KubeMethod:    "nodes" | "wait-nodes" | "pods" | "wait-ready" | "ingress" | "ingressclass" | "storageclass" | "service" | "lb-external-ip" | "addons" | "apply" | "delete" | "raw" @go(-)
 #AdbMethod:     "devices" | "shell" | "install" | "install-app" | "uninstall" | "getprop" | "screencap" | "logcat-tail" | "wait-for-device" | "wait-ui-settled" | "current-focus" | "keyevent" @go(-)
@@ -415,13 +444,28 @@
 // matcher OR a list. (The base no longer carries a contains-default list def — that
 // shape left with the `file` verb's `contains` field and is now reproduced standalone
 // in the file plugin's #FileContains, decoded with the substring default via
-// decodeContainsList; no base #Op field uses it anymore.)
-#MatchOpMap: {equals: _} | {not_equals: _} | {contains: _} | {not_contains: _} | {matches: _} | {not_matches: _} | {lt: _} | {le: _} | {gt: _} | {ge: _}
+// decodeContainsList; no base #Op field uses it anymore.) `json:` takes a
+// jq/JSONPath query (bare string, or {path: ..., <op>: ...}) and applies the
+// nested operators to the query result (plugin/sdk/jsonquery.go).
+#MatchOpMap: {equals: _} | {not_equals: _} | {contains: _} | {not_contains: _} | {matches: _} | {not_matches: _} | {lt: _} | {le: _} | {gt: _} | {ge: _} | {json: _}
 
 #Matcher: (string | bool | number | #MatchOpMap) @go(-) // gengotypes: hand Matcher (spec/union_types.go, ported from checkspec.go)
 
 #MatcherList: (#Matcher | [...#Matcher]) @go(-) // gengotypes: hand MatcherList
 
+// One cdp network_expect assertion. url is a regexp over the full request URL, host an
+// exact hostname or "*.suffix" glob, method an HTTP method; the filters AND together.
+// Without absent, at least one matching request must exist and (when set) have
+// returned status within max_ms; with absent: true, no request may match.
+#CdpNetworkExpect: {
+	url?:    string @go(URL)
+	host?:   string
+	method?: string
+	status?: int & >=100 & <=599 @go(,type=int)
+	max_ms?: int & >=0           @go(MaxMS,type=int)
+	absent?: bool
+}
+
 // ---------------------------------------------------------------------------
 // Build-vocabulary shared shapes (distro formats + builders).
 // ---------------------------------------------------------------------------
@@ -531,6 +575,10 @@
 		name:         string & !=""
 		description?: string & !=""
 }) @go(-) // gengotypes: hand PackageItem (spec/union_types.go)
+// #Arch — a target CPU architecture, the GOARCH/OCI spelling of a box
+// `platform:` entry's second segment (linux/arm64 → arm64).
+#Arch: ("amd64" | "arm64") @go(-)
+
 #DistroPackages: {
 	package?: [...#PackageItem]
 	copr?: [...(string & !="")]
@@ -10,10 +10,10 @@
 
 // Package formats (BoxConfig.Build / BuildFormats). Named #BuildFormat to avoid
 // colliding with distro.cue's #Format (the package-format definition struct).
-#BuildFormat: "rpm" | "deb" | "pac" | "aur" @go(-)
+#BuildFormat: "rpm" | "deb" | "pac" | "aur" | "apk" @go(-)
 
 // Builder build-type slots (BoxConfig.Produce + BoxConfig.Builder keys).
-#BuildType: "pixi" | "npm" | "cargo" | "aur" @go(-)
+#BuildType: "pixi" | "npm" | "cargo" | "go" | "aur" @go(-)
 
 // MergeConfig (config.go). CLOSED.
 #BoxMerge: {
@@ -46,6 +46,42 @@
 	stop_grace?:           #Duration @go(StopGrace)
 }
 
+// TrustConfig (image_trust.go) — the image-signature policy enforced before an
+// image charly pulls from a registry is deployed (`charly box pull`, `charly
+// bundle add`, `charly bundle from-box`). CLOSED. Authored under `defaults:` for
+// the project or on a box (the box's block replaces the default wholesale).
+#BoxTrust: {
+	policy?: *"off" | "warn" | "enforce"
+	// Trusted signers: a credential-store signing-key name (its public half,
+	// `charly box keygen`) or a PEM public-key file relative to the project root.
+	key?: [...(string & !="")]
+	// Trust local images that carry no registry digest (a `podman build` or
+	// `podman load` result) without a record of charly building them.
+	allow_local?: bool @go(AllowLocal)
+}
+
+// AuditConfig (box_audit.go) — the `charly box audit` settings: the local OSV
+// database, the default --fail-on gate and the accepted findings. CLOSED.
+// Authored under `defaults:` for the project or on a box (the box's block
+// replaces the default wholesale).
+#BoxAudit: {
+	// An OSV database: a directory of OSV JSON records, or a .tar/.tar.gz/.tgz/.zip
+	// of one (osv.dev's per-ecosystem all.zip), relative to the project root.
+	db?:      string & !=""
+	fail_on?: "none" | "low" | "medium" | "high" | "critical" @go(FailOn)
+	ignore?: [...#BoxAuditIgnore]
+}
+
+// An accepted finding: a vulnerability ID (or any of its aliases), optionally
+// limited to one package, ignored until the `until:` date (YYYY-MM-DD; absent =
+// for good). An expired entry stops applying and is reported.
+#BoxAuditIgnore: {
+	id:       string & !=""
+	package?: string & !=""
+	until?:   string & =~"^[0-9]{4}-[0-9]{2}-[0-9]{2}$"
+	reason?:  string
+}
+
 // base and from are mutually exclusive; neither is also valid (scratch box).
 // The entity-level base⊻from mutual-exclusion is enforced in GO
 // (BoxConfig.HasBaseFromConflict, surfaced by validateBoxBaseFrom in validate.go
@@ -110,6 +146,8 @@
 
 	merge?: #BoxMerge @go(Merge,optional=nillable)
 	alias?: [...#BoxAlias]
+	trust?: #BoxTrust @go(Trust,optional=nillable)
+	audit?: #BoxAudit @go(Audit,optional=nillable)
 
 	plan?: [...#Step]
 	check_level?: *"noagent" | "none" | "build" | "agent" @go(CheckLevel)
@@ -23,6 +23,9 @@
 	copy_artifact?: [...#Copy] @go(CopyArtifacts)
 	copy_binary?: #Copy @go(CopyBinary,optional=nillable)
 	path_contribution?: [...(string & !="")] @go(PathContributions)
+	// lock_query lists the builder's outputs inside a built image as
+	// "name<TAB>version" lines, recorded (not pinned) by `charly box lock`.
+	lock_query?:      string @go(LockQuery)
 	kind:             *"layer" | "bootstrap"
 	privileged:       *false | true
 	output_artifact?: string & =~"^/" @go(OutputArtifact)
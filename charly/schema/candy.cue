@@ -52,6 +52,14 @@
 	// (the STEP leg). A builtin builder (pixi/cargo/npm/aur) is selected by detection
 	// files, NOT this field. See generate.go emitExternalBuilderStages.
 	external_builder?: string & !="" @go(ExternalBuilder)
+	// go — the `go` builder's knobs for a candy carrying a go.mod (the builder is
+	// still selected by detection; this block only tunes it). Absent → build the
+	// module root package with no ldflags.
+	go?: #CandyGo @go(Go,optional=nillable)
+	// supported_arch — the architectures this candy can be built for. Absent =
+	// every architecture. A box targeting an architecture outside the list fails
+	// validation instead of failing (or silently emulating) mid-build.
+	supported_arch?: [...#Arch] @go(SupportedArch,type=[]string)
 
 	// --- runtime env / local vars / PATH ---
 	// env forbids PATH (validate.go: use path_append instead). Values are
@@ -277,6 +285,12 @@
 									// exactly one of package/apk (disjunction keeps it CLOSED; matchN would open it).
 } & ({package!: _, apk?: _|_} | {apk!: _, package?: _|_}) @go(-) // gengotypes: hand ApkPackageSpec (spec/union_types.go)
 
+// #CandyGo — the `go` builder's main packages and linker flags.
+#CandyGo: {
+	main?: [...(string & !="")]
+	ldflags?: string & !=""
+}
+
 // RouteYAML — generic service-route metadata (traefik / tunnel).
 #CandyRoute: {
 	host: string & !=""
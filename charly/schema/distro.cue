@@ -8,6 +8,10 @@
 	inherits?:         string & =~"^[a-z0-9]+(-[a-z0-9]+)*$"
 	inherit_packages?: bool @go(InheritPackages)
 	version?:          string & =~"^[0-9]+(\\.[0-9]+)*$"
+	// package_arch — the architectures the distro publishes packages for.
+	// Absent = every architecture. Narrows a box's implicit platform default;
+	// an explicit platform outside it is a validation error.
+	package_arch?: [...#Arch] @go(PackageArch,type=[]string)
 	bootstrap?:        #Bootstrap
 	workaround?: [...string] @go(Workarounds)
 	format?: {[string]: #Format} @go(Format,type=map[string]*Format)
@@ -36,6 +40,13 @@
 	validate?: [...#FormatRule]
 	secondary?: bool
 	local_pkg?: #LocalPkg @go(LocalPkg,optional=nillable)
+	// lock_query lists a built image's installed packages as "name<TAB>version"
+	// lines (`charly box lock`), optionally followed by "<TAB>source-package"
+	// (deb), which `charly box audit` looks advisories up by; pin_template
+	// renders one pinned install spec from {{.Name}} / {{.Version}} (the
+	// generator pins to charly.lock).
+	lock_query?:   string @go(LockQuery)
+	pin_template?: string @go(PinTemplate)
 }
 
 #FormatRule: {
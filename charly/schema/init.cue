@@ -1,5 +1,5 @@
 // CUE schema for the `init` kind. #Init validates ONE value of the `init:` map
-// (InitDef — supervisord/systemd). CLOSED: every authored key is modeled (an
+// (InitDef — supervisord/systemd/openrc). CLOSED: every authored key is modeled (an
 // unknown key is a typo). *_template fields are Go text/template (plain
 // `string`). No #Step (init has no plan).
 
@@ -7,10 +7,16 @@
 	candy_field?: [...(string & !="")] @go(CandyFields)
 	candy_file?: [...(string & !="")] @go(CandyFiles)
 	depends_candy?: string & !="" @go(DependsCandy)
-	requires_capability?: [...(string & =~"^[a-z][a-z0-9_]*$")] @go(RequiresCapability)
+	requires_capability?: [...(string & =~"^[a-z][a-z0-9_]*(:[a-z0-9]+)?$")] @go(RequiresCapability)
 
-	// The one mandatory field: the build model.
-	model: "fragment_assembly" | "file_copy"
+	// requires_capability names are the aggregated candy capability keys — a
+	// bool capability (preserve_user) or an `init_system:<hint>` claim.
+
+	// The one mandatory field: the build model. fragment_assembly concatenates
+	// one fragment per candy into a single config (supervisord); file_copy
+	// copies detected *.service files (systemd); file_render writes each
+	// rendered service to its own file named after its unit path (openrc).
+	model: "fragment_assembly" | "file_copy" | "file_render"
 
 	header_file?:    string & !="" @go(HeaderFile)
 	fragment_dir?:   string & !="" @go(FragmentDir)
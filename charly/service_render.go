@@ -21,9 +21,12 @@ import (
 	"bytes"
 	"fmt"
 	"maps"
+	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"text/template"
+	"time"
 )
 
 // ---------------------------------------------------------------------------
@@ -48,6 +51,7 @@ type ServiceRenderContext struct {
 	WantedBy         []string // [Install] WantedBy override; empty → scope default
 	Stdout           string
 	StopTimeout      string
+	StopTimeoutSecs  int    // StopTimeout in whole seconds, for init systems that take no units
 	Scope            string // "system" | "user"
 	PackagedUnit     string // non-empty for drop-in rendering
 	Home             string // invoking user's home — for user-scope unit paths
@@ -66,6 +70,21 @@ type ServiceRenderContext struct {
 	Priority     int
 }
 
+// stopTimeoutSeconds converts a stop_timeout ("20", "20s", "1m30s") to whole
+// seconds, rounding a fractional value up. Anything else (unset, systemd's
+// "infinity") yields 0, which the templates treat as "no timeout".
+func stopTimeoutSeconds(s string) int {
+	s = strings.TrimSpace(s)
+	if n, err := strconv.Atoi(s); err == nil {
+		return max(n, 0)
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0
+	}
+	return int((d + time.Second - 1) / time.Second)
+}
+
 // KeyValue is a deterministic env-var ordering helper.
 type KeyValue struct {
 	Key   string
@@ -85,6 +104,24 @@ type RenderedService struct {
 	DropinPath string // drop-in file path when DropinText is non-empty
 }
 
+// content returns the text a build-time fragment carries: the unit body, or
+// the drop-in for a packaged entry with overrides.
+func (r *RenderedService) content() string {
+	if r.UnitText != "" {
+		return r.UnitText
+	}
+	return r.DropinText
+}
+
+// fileName returns the base name of the path content() belongs at — the
+// per-service file name of the file_render model.
+func (r *RenderedService) fileName() string {
+	if r.UnitText != "" {
+		return filepath.Base(r.UnitPath)
+	}
+	return filepath.Base(r.DropinPath)
+}
+
 // RenderService turns a ServiceEntry into a RenderedService using the
 // given init system's templates. Returns an error when the chosen init
 // system has no template for the entry's shape (custom unit on
@@ -148,6 +185,7 @@ func RenderService(entry *ServiceEntry, initDef *InitDef, ctx ServiceRenderConte
 	ctx.Restart = entry.Restart
 	ctx.Stdout = entry.Stdout
 	ctx.StopTimeout = entry.StopTimeout
+	ctx.StopTimeoutSecs = stopTimeoutSeconds(entry.StopTimeout)
 	// Lifecycle directives — passed through verbatim to the init-system template.
 	ctx.Kind = entry.Kind
 	ctx.Events = entry.Events
@@ -333,5 +371,32 @@ func serviceRenderFuncs() template.FuncMap {
 			}
 			return "0"
 		},
+		// shquote single-quotes a value for the shell-sourced OpenRC script.
+		"shquote": shQuoteArg,
+		// openrcLog maps `stdout:` to an openrc-run output_log= path. Unset /
+		// "journal" write to PID 1's stdout — the container's own log, the
+		// same default supervisord's /dev/fd/1 gives.
+		"openrcLog": func(s string) string {
+			if after, ok := strings.CutPrefix(s, "file:"); ok {
+				return after
+			}
+			switch s {
+			case "none":
+				return "/dev/null"
+			case "journal", "":
+				return "/proc/1/fd/1"
+			}
+			return s
+		},
+		// openrcDep maps an after:/before: ordering name to an OpenRC service
+		// name: the systemd spelling (dbus.service) drops its unit suffix.
+		"openrcDep": func(s string) string {
+			for _, suffix := range []string{".service", ".socket", ".target"} {
+				if name, ok := strings.CutSuffix(s, suffix); ok {
+					return name
+				}
+			}
+			return s
+		},
 	}
 }
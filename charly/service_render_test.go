@@ -1,6 +1,7 @@
 package main
 
 import (
+	"os/exec"
 	"strings"
 	"testing"
 )
@@ -308,6 +309,22 @@ func TestRestartMappingFuncs(t *testing.T) {
 	}
 }
 
+func TestStopTimeoutSeconds(t *testing.T) {
+	for in, want := range map[string]int{
+		"":         0,
+		"20":       20,
+		"20s":      20,
+		"1m30s":    90,
+		"1500ms":   2,
+		"infinity": 0,
+		"-5":       0,
+	} {
+		if got := stopTimeoutSeconds(in); got != want {
+			t.Errorf("stopTimeoutSeconds(%q) = %d, want %d", in, got, want)
+		}
+	}
+}
+
 func TestServiceEntryIsPackaged(t *testing.T) {
 	packaged := &ServiceEntry{UsePackaged: "foo.service"}
 	custom := &ServiceEntry{Exec: "/bin/foo"}
@@ -331,3 +348,58 @@ func TestServiceEntryEffectiveScope(t *testing.T) {
 		t.Errorf("explicit user scope = %q", got)
 	}
 }
+
+// The embedded openrc service_template must survive shell-hostile exec lines:
+// openrc-run sources the script, so command_args is re-read through sh here.
+func TestRenderServiceOpenRC(t *testing.T) {
+	uf, err := embeddedDefaults()
+	if err != nil {
+		t.Fatalf("embeddedDefaults: %v", err)
+	}
+	def := uf.ProjectInitConfig().Init["openrc"]
+	if def == nil {
+		t.Fatal("embedded init vocabulary has no openrc")
+	}
+	entry := &ServiceEntry{
+		Name:    "web",
+		Exec:    `/usr/bin/web --greeting "it's $HOME"`,
+		User:    "app",
+		Env:     map[string]string{"PORT": "8080"},
+		After:   []string{"dbus.service"},
+		Restart: "always",
+	}
+	rendered, err := RenderService(entry, def, ServiceRenderContext{Candy: "web"})
+	if err != nil {
+		t.Fatalf("RenderService: %v", err)
+	}
+	if rendered.UnitPath != "/etc/init.d/web" {
+		t.Errorf("UnitPath = %q, want /etc/init.d/web", rendered.UnitPath)
+	}
+	for _, want := range []string{
+		"#!/sbin/openrc-run\n",
+		"supervisor=supervise-daemon\n",
+		"command_user=app\n",
+		"output_log=/proc/1/fd/1\n",
+		"export PORT=8080\n",
+		"depend() {\n    after dbus\n}",
+	} {
+		if !strings.Contains(rendered.UnitText, want) {
+			t.Errorf("missing %q; got:\n%s", want, rendered.UnitText)
+		}
+	}
+
+	script := rendered.UnitText + "\neval \"set -- $command_args\"\nprintf '%s\\n' \"$@\"\n"
+	out, err := exec.Command("sh", "-c", script).Output()
+	if err != nil {
+		t.Fatalf("sh: %v", err)
+	}
+	want := "-c\nexec " + entry.Exec + "\n"
+	if string(out) != want {
+		t.Errorf("command_args = %q, want %q", out, want)
+	}
+
+	status, err := initRenderManagementCommand(def, "status", "")
+	if err != nil || status != "rc-status --all" {
+		t.Errorf("status command = %q (%v), want rc-status --all", status, err)
+	}
+}
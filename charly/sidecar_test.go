@@ -306,3 +306,28 @@ func writeQuadlet(t *testing.T, dir, name, content string) {
 		t.Fatalf("writing %s: %v", name, err)
 	}
 }
+
+// TestResolveDeploySidecars_RenderMatchesConfig: the sidecar defs charly config
+// persists (with CLI -e routed into them) re-render to the same sidecars with
+// no flags — the --update-all / drift path through renderDeployQuadlets.
+func TestResolveDeploySidecars_RenderMatchesConfig(t *testing.T) {
+	dc := &BundleConfig{Bundle: map[string]BundleNode{
+		"app": {Sidecar: map[string]SidecarDef{"relay": {Image: "relay:1", Env: map[string]string{"RELAY_PORT": "80"}}}},
+	}}
+	persisted, configured, appEnv, err := resolveDeploySidecars(dc, "app", "", nil, []string{"RELAY_PORT=81", "FOO=1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(appEnv, []string{"FOO=1"}) || persisted["relay"].Env["RELAY_PORT"] != "81" {
+		t.Fatalf("routing: app env %v, sidecars %+v", appEnv, persisted)
+	}
+
+	saved := &BundleConfig{Bundle: map[string]BundleNode{"app": {Sidecar: persisted}}}
+	_, rendered, _, err := resolveDeploySidecars(saved, "app", "", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rendered, configured) {
+		t.Errorf("rendered sidecars %+v, configured %+v", rendered, configured)
+	}
+}
@@ -183,5 +183,6 @@ type ArbiterInvokeReply struct {
 	Bool     bool           `json:"bool,omitempty"`     // resource-poisoned
 	Ledger   *PreemptLedger `json:"ledger,omitempty"`   // status
 	Stranded []string       `json:"stranded,omitempty"` // status
+	Lease    *PreemptLease  `json:"lease,omitempty"`    // release-claimant: the claimant's lease, nil when it held none
 	Error    string         `json:"error,omitempty"`
 }
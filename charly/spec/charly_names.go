@@ -50,6 +50,7 @@ type (
 	HooksConfig       = CandyHook
 	CandyCapabilities = CandyCapability
 	RouteYAML         = CandyRoute
+	GoBuildConfig     = CandyGo
 	VmSnapshotDecl    = VmSnapshot
 )
 
@@ -61,4 +62,7 @@ type (
 	IterateConfig     = Iterate
 	InstallOptsConfig = InstallOpts
 	SecurityConfig    = Security
+	TrustConfig       = BoxTrust
+	AuditConfig       = BoxAudit
+	AuditIgnoreConfig = BoxAuditIgnore
 )
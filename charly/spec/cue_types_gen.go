@@ -380,6 +380,28 @@ type Op struct {
 
 	ArtifactMinCastEvents int `yaml:"artifact_min_cast_events,omitempty" json:"artifact_min_cast_events,omitempty"`
 
+	// Visual regression: compare the captured image against a golden baseline
+	// (recorded on first run when absent). artifact_max_diff is the tolerated
+	// percentage of differing pixels (default 0); artifact_pixel_threshold the
+	// per-pixel perceptual distance 0..1 (default 0.1); artifact_ignore masks
+	// "X,Y,WxH" regions; artifact_diff is where a failing run writes its diff image.
+	ArtifactBaseline string `yaml:"artifact_baseline,omitempty" json:"artifact_baseline,omitempty"`
+
+	ArtifactMaxDiff float64 `yaml:"artifact_max_diff,omitempty" json:"artifact_max_diff,omitempty"`
+
+	ArtifactPixelThreshold float64 `yaml:"artifact_pixel_threshold,omitempty" json:"artifact_pixel_threshold,omitempty"`
+
+	ArtifactIgnore []string `yaml:"artifact_ignore,omitempty" json:"artifact_ignore,omitempty"`
+
+	ArtifactDiff string `yaml:"artifact_diff,omitempty" json:"artifact_diff,omitempty"`
+
+	// find-image / click-image (vnc / spice / wl): the reference image to locate in a
+	// fresh screenshot (relative = the authoring candy's tree, or the project) and the
+	// minimum normalized-correlation score 0..1 a match must reach (default 0.9).
+	Template string `yaml:"template,omitempty" json:"template,omitempty"`
+
+	MatchThreshold float64 `yaml:"match_threshold,omitempty" json:"match_threshold,omitempty"`
+
 	X int `yaml:"x,omitempty" json:"x,omitempty"`
 
 	Y int `yaml:"y,omitempty" json:"y,omitempty"`
@@ -406,6 +428,22 @@ type Op struct {
 
 	Query string `yaml:"query,omitempty" json:"query,omitempty"`
 
+	// --- cdp network capture (network-start / network-stop) ---
+	// network_expect: per-request assertions over the recording (a request matching
+	// url/host/method returned status within max_ms, or — absent: true — no such
+	// request was made); network_allow_hosts: every http(s) request's host must match
+	// one entry (exact, or "*.example.com").
+	NetworkExpect []CdpNetworkExpect `yaml:"network_expect,omitempty" json:"network_expect,omitempty"`
+
+	NetworkAllowHosts []string `yaml:"network_allow_hosts,omitempty" json:"network_allow_hosts,omitempty"`
+
+	// --- cdp console collection (console-start / console) ---
+	// console_level: the lowest level that fails the step (default error);
+	// console_allow: regexps over an entry's text or source URL that exempt it.
+	ConsoleLevel string `yaml:"console_level,omitempty" json:"console_level,omitempty"`
+
+	ConsoleAllow []string `yaml:"console_allow,omitempty" json:"console_allow,omitempty"`
+
 	// --- mcp ---
 	McpName string `yaml:"mcp_name,omitempty" json:"mcp_name,omitempty"`
 
@@ -464,11 +502,31 @@ type StrVal any /* CUE disjunction: (bool|string|number) */
 // matcher OR a list. (The base no longer carries a contains-default list def — that
 // shape left with the `file` verb's `contains` field and is now reproduced standalone
 // in the file plugin's #FileContains, decoded with the substring default via
-// decodeContainsList; no base #Op field uses it anymore.)
+// decodeContainsList; no base #Op field uses it anymore.) `json:` takes a
+// jq/JSONPath query (bare string, or {path: ..., <op>: ...}) and applies the
+// nested operators to the query result (plugin/sdk/jsonquery.go).
 type MatchOpMap map[string]any
 
 // A BuildKit cache mount. dst is the absolute in-builder cache path; sharing is
 // the BuildKit sharing mode; owned renders a uid/gid-owned cache.
+// One cdp network_expect assertion. url is a regexp over the full request URL, host an
+// exact hostname or "*.suffix" glob, method an HTTP method; the filters AND together.
+// Without absent, at least one matching request must exist and (when set) have
+// returned status within max_ms; with absent: true, no request may match.
+type CdpNetworkExpect struct {
+	URL string `yaml:"url,omitempty" json:"url,omitempty"`
+
+	Host string `yaml:"host,omitempty" json:"host,omitempty"`
+
+	Method string `yaml:"method,omitempty" json:"method,omitempty"`
+
+	Status int `yaml:"status,omitempty" json:"status,omitempty"`
+
+	MaxMS int `yaml:"max_ms,omitempty" json:"max_ms,omitempty"`
+
+	Absent bool `yaml:"absent,omitempty" json:"absent,omitempty"`
+}
+
 type CacheMount struct {
 	Dst string `yaml:"dst,omitempty" json:"dst"`
 
@@ -711,6 +769,49 @@ type Readiness struct {
 	StopGrace Duration `yaml:"stop_grace,omitempty" json:"stop_grace,omitempty"`
 }
 
+// TrustConfig (image_trust.go) — the image-signature policy enforced before an
+// image charly pulls from a registry is deployed (`charly box pull`, `charly
+// bundle add`, `charly bundle from-box`). CLOSED. Authored under `defaults:` for
+// the project or on a box (the box's block replaces the default wholesale).
+type BoxTrust struct {
+	Policy string `yaml:"policy,omitempty" json:"policy,omitempty"`
+
+	// Trusted signers: a credential-store signing-key name (its public half,
+	// `charly box keygen`) or a PEM public-key file relative to the project root.
+	Key []string `yaml:"key,omitempty" json:"key,omitempty"`
+
+	// Trust local images that carry no registry digest (a `podman build` or
+	// `podman load` result) without a record of charly building them.
+	AllowLocal bool `yaml:"allow_local,omitempty" json:"allow_local,omitempty"`
+}
+
+// AuditConfig (box_audit.go) — the `charly box audit` settings: the local OSV
+// database, the default --fail-on gate and the accepted findings. CLOSED.
+// Authored under `defaults:` for the project or on a box (the box's block
+// replaces the default wholesale).
+type BoxAudit struct {
+	// An OSV database: a directory of OSV JSON records, or a .tar/.tar.gz/.tgz/.zip
+	// of one (osv.dev's per-ecosystem all.zip), relative to the project root.
+	Db string `yaml:"db,omitempty" json:"db,omitempty"`
+
+	FailOn string `yaml:"fail_on,omitempty" json:"fail_on,omitempty"`
+
+	Ignore []BoxAuditIgnore `yaml:"ignore,omitempty" json:"ignore,omitempty"`
+}
+
+// An accepted finding: a vulnerability ID (or any of its aliases), optionally
+// limited to one package, ignored until the `until:` date (YYYY-MM-DD; absent =
+// for good). An expired entry stops applying and is reported.
+type BoxAuditIgnore struct {
+	Id string `yaml:"id" json:"id"`
+
+	Package string `yaml:"package,omitempty" json:"package,omitempty"`
+
+	Until string `yaml:"until,omitempty" json:"until,omitempty"`
+
+	Reason string `yaml:"reason,omitempty" json:"reason,omitempty"`
+}
+
 // base and from are mutually exclusive; neither is also valid (scratch box).
 // The entity-level base⊻from mutual-exclusion is enforced in GO
 // (BoxConfig.HasBaseFromConflict, surfaced by validateBoxBaseFrom in validate.go
@@ -802,6 +903,10 @@ type Box struct {
 
 	Alias []BoxAlias `yaml:"alias,omitempty" json:"alias,omitempty"`
 
+	Trust *BoxTrust `yaml:"trust,omitempty" json:"trust,omitempty"`
+
+	Audit *BoxAudit `yaml:"audit,omitempty" json:"audit,omitempty"`
+
 	Plan []Step `yaml:"plan,omitempty" json:"plan,omitempty"`
 
 	CheckLevel string `yaml:"check_level,omitempty" json:"check_level,omitempty"`
@@ -842,6 +947,10 @@ type Builder struct {
 
 	PathContributions []string `yaml:"path_contribution,omitempty" json:"path_contribution,omitempty"`
 
+	// lock_query lists the builder's outputs inside a built image as
+	// "name<TAB>version" lines, recorded (not pinned) by `charly box lock`.
+	LockQuery string `yaml:"lock_query,omitempty" json:"lock_query,omitempty"`
+
 	Kind string `yaml:"kind,omitempty" json:"kind"`
 
 	Privileged bool `yaml:"privileged,omitempty" json:"privileged"`
@@ -911,6 +1020,16 @@ type Candy struct {
 	// files, NOT this field. See generate.go emitExternalBuilderStages.
 	ExternalBuilder string `yaml:"external_builder,omitempty" json:"external_builder,omitempty"`
 
+	// go — the `go` builder's knobs for a candy carrying a go.mod (the builder is
+	// still selected by detection; this block only tunes it). Absent → build the
+	// module root package with no ldflags.
+	Go *CandyGo `yaml:"go,omitempty" json:"go,omitempty"`
+
+	// supported_arch — the architectures this candy can be built for. Absent =
+	// every architecture. A box targeting an architecture outside the list fails
+	// validation instead of failing (or silently emulating) mid-build.
+	SupportedArch []string `yaml:"supported_arch,omitempty" json:"supported_arch,omitempty"`
+
 	// --- runtime env / local vars / PATH ---
 	// env forbids PATH (validate.go: use path_append instead). Values are
 	// Go-coerced scalars (#StrVal) — an unquoted `PORT: 8080` is a string. The Go
@@ -1033,6 +1152,13 @@ type Plugin struct {
 // ProviderClass set; word is lowercase-hyphenated.
 type PluginCapability string
 
+// CandyGo — the `go` builder's main packages and linker flags.
+type CandyGo struct {
+	Main []string `yaml:"main,omitempty" json:"main,omitempty"`
+
+	Ldflags string `yaml:"ldflags,omitempty" json:"ldflags,omitempty"`
+}
+
 // RouteYAML — generic service-route metadata (traefik / tunnel).
 type CandyRoute struct {
 	Host string `yaml:"host,omitempty" json:"host"`
@@ -1535,6 +1661,11 @@ type Distro struct {
 
 	Version string `yaml:"version,omitempty" json:"version,omitempty"`
 
+	// package_arch — the architectures the distro publishes packages for.
+	// Absent = every architecture. Narrows a box's implicit platform default;
+	// an explicit platform outside it is a validation error.
+	PackageArch []string `yaml:"package_arch,omitempty" json:"package_arch,omitempty"`
+
 	Bootstrap Bootstrap `yaml:"bootstrap,omitempty" json:"bootstrap,omitempty"`
 
 	Workarounds []string `yaml:"workaround,omitempty" json:"workaround,omitempty"`
@@ -1654,6 +1785,15 @@ type Format struct {
 	Secondary bool `yaml:"secondary,omitempty" json:"secondary,omitempty"`
 
 	LocalPkg *LocalPkg `yaml:"local_pkg,omitempty" json:"local_pkg,omitempty"`
+
+	// lock_query lists a built image's installed packages as "name<TAB>version"
+	// lines (`charly box lock`), optionally followed by "<TAB>source-package"
+	// (deb), which `charly box audit` looks advisories up by; pin_template
+	// renders one pinned install spec from {{.Name}} / {{.Version}} (the
+	// generator pins to charly.lock).
+	LockQuery string `yaml:"lock_query,omitempty" json:"lock_query,omitempty"`
+
+	PinTemplate string `yaml:"pin_template,omitempty" json:"pin_template,omitempty"`
 }
 
 type FormatRule struct {
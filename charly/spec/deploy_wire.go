@@ -584,9 +584,12 @@ type BuilderResolveInput struct {
 	BuildScript      string   `json:"build_script,omitempty"`
 	Packages         []string `json:"packages,omitempty"`
 	Options          []string `json:"options,omitempty"`
+	Main             []string `json:"main,omitempty"`
+	Ldflags          string   `json:"ldflags,omitempty"`
 	CacheMountsOwned string   `json:"cache_mounts_owned,omitempty"`
 	CacheMountsAuto  string   `json:"cache_mounts_auto,omitempty"`
 	Inline           bool     `json:"inline,omitempty"`
+	Cross            bool     `json:"cross,omitempty"` // the box targets a non-host arch: stages run on $BUILDPLATFORM
 }
 
 // BuildRequest is the BUILD-ENGINE DISPATCH envelope (F10 HostBuild seam): what `charly box
@@ -597,7 +600,7 @@ type BuilderResolveInput struct {
 // reconstructed HOST-SIDE from Dir inside the registered host-builder (exactly as
 // pod_deploy_lifecycle re-runs NewGenerator(dir,…)); the fields here are the CLI-supplied inputs
 // that are NOT reconstructable from Dir alone. The generate path reads only Boxes/Tag/Dir/
-// IncludeDisabled; the build path additionally reads DevLocalPkg + the buildImages knobs
+// IncludeDisabled/Platform; the build path additionally reads DevLocalPkg + the buildImages knobs
 // (Push/Platform/Cache/NoCache/Jobs/PodmanJobs).
 type BuildRequest struct {
 	Boxes           []string `json:"boxes,omitempty"`            // positional box selection ("" → all enabled)
@@ -605,8 +608,12 @@ type BuildRequest struct {
 	Dir             string   `json:"dir,omitempty"`              // project dir the host reconstructs config from
 	IncludeDisabled bool     `json:"include_disabled,omitempty"` // --include-disabled
 	DevLocalPkg     bool     `json:"dev_local_pkg,omitempty"`    // --dev-local-pkg (localpkg from local source; build only)
+	Unlocked        bool     `json:"unlocked,omitempty"`         // --unlocked (ignore charly.lock package pins)
+	SBOM            bool     `json:"sbom,omitempty"`             // --sbom (attach SBOM referrers on push)
+	Sign            string   `json:"sign,omitempty"`             // --sign <key> (sign pushed images)
+	Profile         bool     `json:"profile,omitempty"`          // --profile (write .build/profile*.json)
 	Push            bool     `json:"push,omitempty"`             // --push (build only)
-	Platform        string   `json:"platform,omitempty"`         // --platform (build only)
+	Platform        string   `json:"platform,omitempty"`         // --platform (build + generate)
 	Cache           string   `json:"cache,omitempty"`            // --cache mode (build only)
 	NoCache         bool     `json:"no_cache,omitempty"`         // --no-cache (build only)
 	Jobs            int      `json:"jobs,omitempty"`             // --jobs outer concurrency (build only)
@@ -674,14 +681,18 @@ type OverlayBuildReply struct {
 // descriptor an external builder plugin reads to produce its per-candy stage
 // context. The host fills the generic fields it can derive without builder-specific
 // knowledge (Candy/Builder/Home always; Packages/Replaces from the builder's
-// detect-config package section, used today only by aur). A builder reads the
-// subset it needs (pixi uses none → a constant env; cargo/npm none).
+// detect-config package section, used today only by aur; Module/Main/Ldflags from a
+// go.mod candy). A builder reads the subset it needs (pixi uses none → a constant env;
+// cargo/npm none).
 type BuilderCollectInput struct {
 	Candy    string   `json:"candy"`
 	Builder  string   `json:"builder"`
 	Home     string   `json:"home,omitempty"`
 	Packages []string `json:"packages,omitempty"` // the builder's detect-config section packages (aur)
 	Replaces []string `json:"replaces,omitempty"` // aur `replaces:` — repo packages removed before pacman -U
+	Module   string   `json:"module,omitempty"`   // go.mod module path (go)
+	Main     []string `json:"main,omitempty"`     // go main packages (candy go.main, default ".")
+	Ldflags  string   `json:"ldflags,omitempty"`  // go -ldflags (candy go.ldflags)
 }
 
 // BuilderCollectReply is the OpCollectContext reply: the builder-specific stage-context
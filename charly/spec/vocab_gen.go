@@ -74,6 +74,7 @@ var DataKeys = []string{
 	"env_require",
 	"ephemeral",
 	"extract",
+	"go",
 	"hook",
 	"install_opts",
 	"iterate",
@@ -100,6 +101,7 @@ var DataKeys = []string{
 	"service",
 	"shell",
 	"ssh_arg",
+	"supported_arch",
 	"tunnel",
 	"var",
 	"volume",
@@ -118,10 +120,15 @@ var OpFields = []string{
 	"arch",
 	"arg",
 	"artifact",
+	"artifact_baseline",
+	"artifact_diff",
+	"artifact_ignore",
+	"artifact_max_diff",
 	"artifact_min_bytes",
 	"artifact_min_cast_events",
 	"artifact_min_dimensions",
 	"artifact_not_uniform",
+	"artifact_pixel_threshold",
 	"attribute",
 	"build",
 	"button",
@@ -134,6 +141,8 @@ var OpFields = []string{
 	"combo",
 	"command",
 	"comment",
+	"console_allow",
+	"console_level",
 	"content",
 	"context",
 	"copy",
@@ -172,6 +181,7 @@ var OpFields = []string{
 	"libvirt",
 	"link",
 	"manifest",
+	"match_threshold",
 	"max",
 	"mcp",
 	"mcp_name",
@@ -182,6 +192,8 @@ var OpFields = []string{
 	"mode",
 	"name",
 	"namespace",
+	"network_allow_hosts",
+	"network_expect",
 	"origin",
 	"over_id",
 	"p50",
@@ -218,6 +230,7 @@ var OpFields = []string{
 	"tab",
 	"tag",
 	"target",
+	"template",
 	"text",
 	"timeout",
 	"to",
@@ -262,10 +275,15 @@ var AuthoringVerbs = []string{
 	"arch",
 	"arg",
 	"artifact",
+	"artifact_baseline",
+	"artifact_diff",
+	"artifact_ignore",
+	"artifact_max_diff",
 	"artifact_min_bytes",
 	"artifact_min_cast_events",
 	"artifact_min_dimensions",
 	"artifact_not_uniform",
+	"artifact_pixel_threshold",
 	"attribute",
 	"build",
 	"button",
@@ -278,6 +296,8 @@ var AuthoringVerbs = []string{
 	"combo",
 	"command",
 	"comment",
+	"console_allow",
+	"console_level",
 	"content",
 	"context",
 	"copy",
@@ -315,6 +335,7 @@ var AuthoringVerbs = []string{
 	"libvirt",
 	"link",
 	"manifest",
+	"match_threshold",
 	"max",
 	"mcp",
 	"mcp_name",
@@ -325,6 +346,8 @@ var AuthoringVerbs = []string{
 	"mode",
 	"name",
 	"namespace",
+	"network_allow_hosts",
+	"network_expect",
 	"over_id",
 	"p50",
 	"p95",
@@ -360,6 +383,7 @@ var AuthoringVerbs = []string{
 	"tab",
 	"tag",
 	"target",
+	"template",
 	"text",
 	"timeout",
 	"to",
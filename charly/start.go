@@ -21,12 +21,15 @@ type StartCmd struct {
 	AutoDetectFlags `embed:""`
 }
 
-func (c *StartCmd) Run() error {
+func (c *StartCmd) Run() (err error) {
 	// Remote refs (@github.com/...) are handled exclusively by `charly box pull`.
 	if IsRemoteImageRef(StripURLScheme(c.Box)) {
 		return fmt.Errorf("remote refs are not accepted here; run 'charly box pull %s' first, then 'charly start <image-name>'", c.Box)
 	}
 	c.Box, c.Instance = canonicalizeDeployArg(c.Box, c.Instance)
+	defer func() {
+		recordOutcome(Event{Type: EventDeployStart, Deploy: deployKey(c.Box, c.Instance), Kind: SubstratePod}, err)
+	}()
 
 	// Resource arbitration: starting a pod deploy that claims requires_exclusive
 	// preempts the running holders of that resource (persistent lease —
@@ -275,8 +278,11 @@ type StopCmd struct {
 	Unmount  bool   `long:"unmount" help:"After stopping, also tear down encrypted FUSE mounts and gocryptfs scope units (charly-enc-<box>-<volume>.scope) for this box"`
 }
 
-func (c *StopCmd) Run() error {
+func (c *StopCmd) Run() (err error) {
 	c.Box, c.Instance = canonicalizeDeployArg(c.Box, c.Instance)
+	defer func() {
+		recordOutcome(Event{Type: EventDeployStop, Deploy: deployKey(c.Box, c.Instance), Kind: SubstratePod}, err)
+	}()
 	// Releasing a persistent exclusive claim restores any holder this deploy
 	// preempted (no-op if no lease / gated by an outer orchestrator).
 	defer releaseResourceClaim(deployKey(c.Box, c.Instance))
@@ -410,7 +416,11 @@ type RestartCmd struct {
 	Instance string `short:"i" long:"instance" help:"Instance name for running multiple containers of the same box"`
 }
 
-func (c *RestartCmd) Run() error {
+func (c *RestartCmd) Run() (err error) {
+	defer func() {
+		box, inst := canonicalizeDeployArg(c.Box, c.Instance)
+		recordOutcome(Event{Type: EventDeployRestart, Deploy: deployKey(box, inst), Kind: SubstratePod}, err)
+	}()
 	boxName := c.Box
 	ref := StripURLScheme(c.Box)
 	if IsRemoteImageRef(ref) {
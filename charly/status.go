@@ -6,16 +6,29 @@ import (
 	"os"
 )
 
-// StatusCmd shows the runtime status of one or all charly bundles. The
+// StatusCmd shows the runtime status of one or all charly bundles, or serves
+// it as OpenMetrics. Default subcommand (no keyword): show — so a deployment
+// named "serve" needs `charly status show serve` (see checkServeShadow). The
 // implementation lives in:
 //
 //	status_engine.go     — single-touchpoint to podman/docker (one batched ps + inspect)
 //	status_collector.go  — Collector orchestration + per-container worker pool
 //	status_probes.go     — Probe / HostProbe / GuestProbe interfaces + 7 concrete probes
 //	status_render.go     — Table / JSON / Detail renderers + cell formatters
+//	status_resources.go  — resource usage + rolling restart history
+//	status_serve.go      — `charly status serve`, the cached OpenMetrics exporter
 //
 // Orphan reaping moved to its own command (`charly reap-orphans`, see status_reap.go).
 type StatusCmd struct {
+	Serve StatusServeCmd `cmd:"serve" help:"Serve deployment status as OpenMetrics (Prometheus scrape target)"`
+	Show  StatusShowCmd  `cmd:"" default:"withargs" help:"Show service status (all if no box given); records observed restarts as deploy.restarted events, delivered to event_sinks"`
+}
+
+// StatusShowCmd shows the status table, or one deployment's detail view. It is
+// not purely read-only: each run appends a resource sample to the status
+// history and journals the restarts it observes (journalRestarts), which
+// fans out to the configured event sinks.
+type StatusShowCmd struct {
 	Box      string `arg:"" optional:"" help:"Box name (omit to list all charly containers)"`
 	Instance string `short:"i" long:"instance" help:"Instance name"`
 	All      bool   `short:"a" long:"all" help:"Include enabled-but-not-running services"`
@@ -23,7 +36,7 @@ type StatusCmd struct {
 	JSON     bool   `long:"json" help:"Output as JSON"`
 }
 
-func (c *StatusCmd) Run() error {
+func (c *StatusShowCmd) Run() error {
 	rt, err := ResolveRuntime()
 	if err != nil {
 		return err
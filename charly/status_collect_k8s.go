@@ -4,6 +4,7 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"time"
 )
 
 // K8sCollector is the kubernetes SubstrateCollector. It surfaces every
@@ -20,7 +21,9 @@ import (
 // the out-of-tree candy/plugin-kube (no host loads the plugin at `charly status`
 // time), so the former --nested live-readiness probe was dropped. A k8s
 // deployment's live health is asserted by a `kube:` check (candy/plugin-kube),
-// not the status collector.
+// not the status collector. Under --nested a generated deploy additionally
+// carries its pods' live resource usage and restart record, read through the
+// kubectl CLI (metrics-server + containerStatuses, see k8sWorkloadResources).
 type K8sCollector struct {
 	c *Collector
 }
@@ -92,6 +95,17 @@ func (k *K8sCollector) Collect(ctx context.Context, opts CollectOpts) ([]Deploym
 			row.Network = node.From
 		}
 
+		if opts.Nested && treePresent {
+			var kubeContext string
+			namespace := k8sNamespaceFor(node, spec)
+			if spec != nil {
+				kubeContext = spec.KubeconfigContext
+			}
+			if r, ok := k8sWorkloadResources(name, namespace, kubeContext); ok {
+				row.Resources = settleResources(resourceHistoryKey(SubstrateK8s, name), &r, time.Now(), false)
+			}
+		}
+
 		rows = append(rows, row)
 	}
 	return rows, nil
@@ -134,6 +148,19 @@ func k8sImageRef(name string, node BundleNode) string {
 	return name
 }
 
+// k8sNamespaceFor mirrors the generator's namespace choice: the deploy's
+// kubernetes.namespace, else the template's default_namespace ("" = the
+// kubeconfig context's own default).
+func k8sNamespaceFor(node BundleNode, spec *K8sSpec) string {
+	if node.Kubernetes != nil && node.Kubernetes.Namespace != "" {
+		return node.Kubernetes.Namespace
+	}
+	if spec != nil {
+		return spec.DefaultNamespace
+	}
+	return ""
+}
+
 // k8sSpecFor resolves the kind:k8s template referenced by node.From from the
 // unified projection. Nil when unreferenced or absent.
 func k8sSpecFor(uf *UnifiedFile, node BundleNode) *K8sSpec {
@@ -26,7 +26,8 @@ package main
 // synthesized row per deploy-id that appears in some CandyRecord.deployed_by
 // but has no DeployRecord. No deploy-id is double-counted. One
 // DeploymentStatus{Kind: local, Source: "ledger"} per deploy-id, with the
-// applied-candy count and the most-recent deployed_at surfaced.
+// applied-candy count and the most-recent deployed_at surfaced, plus the
+// resource usage of the services the deploy enabled (status_resources.go).
 
 import (
 	"context"
@@ -92,9 +93,10 @@ func (l *LocalCollector) Collect(ctx context.Context, opts CollectOpts) ([]Deplo
 	// deployed_at across the deploy record and every contributing layer.
 	type deployAgg struct {
 		candySet   map[string]bool
-		latest     string // RFC3339, newest deployed_at seen
-		fromRecord bool   // had an explicit DeployRecord
-		target     string // DeployRecord.Target ("" for synthesized)
+		latest     string          // RFC3339, newest deployed_at seen
+		fromRecord bool            // had an explicit DeployRecord
+		target     string          // DeployRecord.Target ("" for synthesized)
+		units      map[string]bool // services the deploy enabled → user scope
 	}
 	aggs := map[string]*deployAgg{}
 	get := func(id string) *deployAgg {
@@ -155,12 +157,24 @@ func (l *LocalCollector) Collect(ctx context.Context, opts CollectOpts) ([]Deplo
 			a := get(id)
 			a.candySet[rec.Candy] = true
 			a.latest = newerTimestamp(a.latest, rec.DeployedAt)
+			for _, op := range rec.ReverseOps {
+				if op.Kind != ReverseOpServiceDisable {
+					continue
+				}
+				if a.units == nil {
+					a.units = map[string]bool{}
+				}
+				for _, unit := range op.Targets {
+					a.units[unit] = op.Scope == ScopeUser
+				}
+			}
 		}
 	}
 
 	rows := make([]DeploymentStatus, 0, len(aggs))
+	now := time.Now()
 	for id, a := range aggs {
-		rows = append(rows, DeploymentStatus{
+		row := DeploymentStatus{
 			Kind:      SubstrateLocal,
 			Source:    "ledger",
 			Image:     localDeployLabel(len(a.candySet)),
@@ -168,7 +182,12 @@ func (l *LocalCollector) Collect(ctx context.Context, opts CollectOpts) ([]Deplo
 			Uptime:    formatLedgerTimestamp(a.latest),
 			Container: id,
 			RunMode:   opts.RunMode,
-		})
+		}
+		// Usage of the services the deploy enabled (/proc + systemd).
+		if r, ok := localServiceResources(a.units); ok {
+			row.Resources = settleResources(resourceHistoryKey(SubstrateLocal, id), &r, now, false)
+		}
+		rows = append(rows, row)
 	}
 
 	// Deterministic ordering by deploy-id; Collector.All re-sorts the merged
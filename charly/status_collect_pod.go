@@ -10,7 +10,8 @@ import (
 // PodCollector is the pod/container SubstrateCollector. It wraps the existing
 // podman/docker collection logic: one batched SnapshotAll, filter to charly-*,
 // quadlet-description enrichment, enabled-but-not-running quadlet append (under
-// --all), then a NumCPU*2 worker-pool fan-out over collectOne. Every row is
+// --all), then a NumCPU*2 worker-pool fan-out over collectOne, then the
+// batched resource readings (podResources). Every row is
 // stamped Kind=SubstratePod, Source="podman". This is the byte-identical
 // successor to the pre-substrate Collector.All body.
 type PodCollector struct {
@@ -83,5 +84,12 @@ func (p *PodCollector) Collect(ctx context.Context, opts CollectOpts) ([]Deploym
 		}(i)
 	}
 	wg.Wait()
+
+	// Usage + restart record: one batched stats call and one batched systemd
+	// query for the whole set.
+	usage := c.podResources(c.engine, snapshots, false)
+	for i := range results {
+		results[i].Resources = usage[snapshots[i].Name]
+	}
 	return results, nil
 }
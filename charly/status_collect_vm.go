@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"os"
 	"strings"
+	"time"
 )
 
 // VMCollector is the libvirt SubstrateCollector. It lists charly-* libvirt
@@ -73,8 +74,19 @@ func (v *VMCollector) Collect(ctx context.Context, opts CollectOpts) ([]Deployme
 		return nil, err
 	}
 	rows := make([]DeploymentStatus, 0, len(domains))
+	names := make([]string, 0, len(domains))
 	for _, d := range domains {
 		rows = append(rows, v.rowForDomain(d, opts))
+		names = append(names, d.Name)
+	}
+	// Usage: one batched domstats over every domain; libvirt reports
+	// cumulative CPU time, so the CPU percentage comes from the history.
+	readings := vmDomainStats(names)
+	now := time.Now()
+	for i, d := range domains {
+		if r, ok := readings[d.Name]; ok {
+			rows[i].Resources = settleResources(resourceHistoryKey(SubstrateVM, d.Name), &r, now, false)
+		}
 	}
 	return rows, nil
 }
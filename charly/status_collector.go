@@ -114,6 +114,7 @@ func (c *Collector) All(ctx context.Context, includeAll, nested bool) ([]Deploym
 	}
 
 	results = applyNestedOverlay(results, opts)
+	journalRestarts(results)
 
 	sort.SliceStable(results, func(i, j int) bool {
 		if results[i].Kind != results[j].Kind {
@@ -162,6 +163,9 @@ func (c *Collector) Single(ctx context.Context, image, instance string) (Deploym
 
 	cs := c.collectOne(ctx, snap)
 	cs.Secrets = ListProvisionedSecretNames(engine.Bin(), boxName)
+	// The detail view carries the history samples too; a deployment that is
+	// down still reports its restart record (quadlet mode).
+	cs.Resources = c.podResources(engine, []ContainerSnapshot{*snap}, true)[snap.Name]
 
 	// statusSingle's lifecycle resolution: when the container isn't in
 	// podman, consult systemd/quadlet to distinguish stopped vs failed vs
@@ -169,6 +173,7 @@ func (c *Collector) Single(ctx context.Context, image, instance string) (Deploym
 	if cs.Status == "" || cs.Status == "stopped" {
 		cs.Status = c.resolveSystemdState(boxName, instance)
 	}
+	journalRestarts([]DeploymentStatus{cs})
 	return cs, nil
 }
 
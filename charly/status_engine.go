@@ -7,6 +7,7 @@ import (
 	"os/exec"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // PortMapping is the structured runtime port mapping for one published port.
@@ -36,6 +37,11 @@ type ContainerSnapshot struct {
 	Ports       []PortMapping // runtime mappings from `podman ps`
 	Devices     []string      // /dev/dri/..., nvidia.com/gpu=all, ...
 	Mounts      []MountInfo   // live mounts from podman inspect .Mounts (RUNTIME truth — what the container is ACTUALLY mounting, not the OCI label default)
+	ImageID     string        // image ID the container was created from (inspect .Image)
+	StartedAt   time.Time     // inspect .State.StartedAt; zero when never started
+	Restarts    int           // inspect .RestartCount (engine restart policy)
+	OOMKilled   bool          // inspect .State.OOMKilled
+	ExitCode    int           // inspect .State.ExitCode
 }
 
 // MountInfo represents one live container mount point as reported by
@@ -127,6 +133,11 @@ func (e *EngineClient) SnapshotAll(includeAll bool) ([]ContainerSnapshot, error)
 			snap.NetworkMode = ir.NetworkMode
 			snap.Devices = ir.Devices
 			snap.Mounts = ir.Mounts
+			snap.ImageID = ir.ImageID
+			snap.StartedAt = ir.StartedAt
+			snap.Restarts = ir.Restarts
+			snap.OOMKilled = ir.OOMKilled
+			snap.ExitCode = ir.ExitCode
 		}
 		out = append(out, snap)
 	}
@@ -176,10 +187,30 @@ func (e *EngineClient) Snapshot(name string) (*ContainerSnapshot, error) {
 		snap.NetworkMode = inspects[0].NetworkMode
 		snap.Devices = inspects[0].Devices
 		snap.Mounts = inspects[0].Mounts
+		snap.ImageID = inspects[0].ImageID
+		snap.StartedAt = inspects[0].StartedAt
+		snap.Restarts = inspects[0].Restarts
+		snap.OOMKilled = inspects[0].OOMKilled
+		snap.ExitCode = inspects[0].ExitCode
 	}
 	return snap, nil
 }
 
+// Stats runs one batched `<engine> stats --no-stream` over the named
+// (running) containers and returns their usage keyed by container name. The
+// docker-compatible template fields are used so both engines answer alike.
+func (e *EngineClient) Stats(names []string) (map[string]engineStatsRow, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	args := append([]string{"stats", "--no-stream", "--format", "{{.Name}}\t{{.CPUPerc}}\t{{.MemUsage}}\t{{.BlockIO}}"}, names...)
+	out, err := exec.Command(e.bin, args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("container stats: %w", err)
+	}
+	return parseEngineStats(string(out)), nil
+}
+
 // --- Internal: ps parsing ---
 
 type enginePSRow struct {
@@ -368,6 +399,42 @@ func parseDockerPortString(s string) []PortMapping {
 	return out
 }
 
+// --- Internal: stats parsing ---
+
+// engineStatsRow is one container's `stats --no-stream` reading.
+type engineStatsRow struct {
+	CPUPercent float64
+	MemBytes   int64
+	MemLimit   int64
+	BlockRead  int64
+	BlockWrite int64
+}
+
+// parseEngineStats parses the tab-separated Name/CPUPerc/MemUsage/BlockIO
+// lines Stats asks for ("charly-app\t1.50%\t12.5MiB / 2GiB\t1.2MB / 0B").
+// Cells an engine reports as "--" stay 0.
+func parseEngineStats(out string) map[string]engineStatsRow {
+	rows := map[string]engineStatsRow{}
+	for line := range strings.SplitSeq(out, "\n") {
+		f := strings.Split(strings.TrimSpace(line), "\t")
+		if len(f) != 4 || f[0] == "" {
+			continue
+		}
+		var r engineStatsRow
+		r.CPUPercent, _ = strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(f[1]), "%"), 64)
+		if used, limit, ok := strings.Cut(f[2], "/"); ok {
+			r.MemBytes, _ = parseHumanSize(used)
+			r.MemLimit, _ = parseHumanSize(limit)
+		}
+		if read, written, ok := strings.Cut(f[3], "/"); ok {
+			r.BlockRead, _ = parseHumanSize(read)
+			r.BlockWrite, _ = parseHumanSize(written)
+		}
+		rows[strings.TrimPrefix(f[0], "/")] = r
+	}
+	return rows
+}
+
 // --- Internal: inspect parsing ---
 
 type engineInspectRow struct {
@@ -375,6 +442,11 @@ type engineInspectRow struct {
 	NetworkMode string
 	Devices     []string
 	Mounts      []MountInfo
+	ImageID     string
+	StartedAt   time.Time
+	Restarts    int
+	OOMKilled   bool
+	ExitCode    int
 }
 
 func (e *EngineClient) runInspect(names []string) ([]engineInspectRow, error) {
@@ -403,7 +475,17 @@ func parseInspect(data []byte) ([]engineInspectRow, error) {
 	}
 	out := make([]engineInspectRow, 0, len(raws))
 	for _, r := range raws {
-		row := engineInspectRow{Name: stringAt(r, "Name")}
+		row := engineInspectRow{Name: stringAt(r, "Name"), ImageID: stringAt(r, "Image")}
+		if st, ok := r["State"].(map[string]any); ok {
+			row.StartedAt, _ = time.Parse(time.RFC3339Nano, stringAt(st, "StartedAt"))
+			row.OOMKilled, _ = st["OOMKilled"].(bool)
+			if code, ok := st["ExitCode"].(float64); ok {
+				row.ExitCode = int(code)
+			}
+		}
+		if n, ok := r["RestartCount"].(float64); ok {
+			row.Restarts = int(n)
+		}
 		hc, _ := r["HostConfig"].(map[string]any)
 		if hc != nil {
 			row.NetworkMode = stringAt(hc, "NetworkMode")
@@ -15,7 +15,9 @@ import (
 // structured []PortMapping (was []string) so the JSON consumer can read host
 // vs container ports without re-parsing. Kind discriminates the substrate;
 // Nested carries multi-hop children (populated by the nested overlay); Source
-// records provenance (libvirt|ledger|adb|tree|podman).
+// records provenance (libvirt|ledger|adb|tree|podman). Resources carries the
+// measured usage and restart history where the substrate reports one (see
+// status_resources.go).
 type DeploymentStatus struct {
 	Kind      SubstrateKind      `json:"kind"`
 	Image     string             `json:"image"`
@@ -32,6 +34,7 @@ type DeploymentStatus struct {
 	Tunnel    string             `json:"tunnel,omitempty"`
 	Secrets   []string           `json:"secrets,omitempty"`
 	RunMode   string             `json:"run_mode"`
+	Resources *ResourceUsage     `json:"resources,omitempty"`
 	Nested    []DeploymentStatus `json:"nested,omitempty"`
 	Source    string             `json:"source,omitempty"` // provenance: libvirt|ledger|adb|tree|podman
 }
@@ -112,6 +115,14 @@ func RenderDetail(w io.Writer, s DeploymentStatus) error {
 	if s.Tunnel != "" {
 		fmt.Fprintf(w, "Tunnel:    %s\n", s.Tunnel)
 	}
+	if s.Resources != nil {
+		if u := resourceSummary(s.Resources); u != "" {
+			fmt.Fprintf(w, "Usage:     %s\n", u)
+		}
+		if h := healthSummary(s.Resources); h != "" {
+			fmt.Fprintf(w, "Health:    %s\n", h)
+		}
+	}
 	for i, child := range s.Nested {
 		label := "Nested:"
 		if i > 0 {
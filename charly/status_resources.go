@@ -0,0 +1,828 @@
+package main
+
+// status_resources.go — resource usage and restart history of `charly status`.
+//
+// Every substrate collector attaches a ResourceUsage to the rows it can
+// measure, from the cheapest source that substrate offers:
+//
+//	pod    one batched `podman stats --no-stream` (CPU, memory, block IO) +
+//	       the quadlet service's systemd counters (NRestarts, Result) and its
+//	       cgroup's memory.events oom_kill; outside quadlet mode the engine's
+//	       inspect RestartCount / State.OOMKilled / State.ExitCode
+//	vm     one batched `virsh domstats --raw` (cpu.time, balloon, block)
+//	k8s    `kubectl top pod` (metrics-server) + the pods' containerStatuses,
+//	       only under --nested (it reaches a live cluster)
+//	local  /proc of the MainPID of every service the deploy enabled, plus the
+//	       same systemd counters and cgroup as the pod substrate
+//
+// Each reading is appended to a small rolling history per row under
+// ~/.local/share/charly/status/history/ (at most 24h and resourceHistoryMax
+// samples, whichever is shorter — a day of five-minute runs, but only ~2.4h
+// under `charly status serve`'s 30s default interval), which turns the substrates' lifetime counters into "restarted 4×
+// in the last hour, last exit OOM" and gives substrates that only report
+// cumulative CPU time (libvirt, /proc) a CPU percentage. The history grows
+// with every `charly status` run.
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// resourceHistoryKeep bounds the rolling history by age.
+	resourceHistoryKeep = 24 * time.Hour
+	// resourceHistoryMax bounds it by count (one sample per status run or
+	// exporter refresh); it still covers resourceRecentWindow at 10s refreshes.
+	resourceHistoryMax = 288
+	// resourceRecentWindow is the window the restart summary covers.
+	resourceRecentWindow = time.Hour
+	// procClockTicks is USER_HZ, the unit of /proc/<pid>/stat CPU times.
+	procClockTicks = 100
+)
+
+// ResourceUsage is the measured resource use and restart record of one
+// deployment. Zero-valued fields the substrate cannot report are left out of
+// the text view; Restarts and OOMKills are the substrate's own counters
+// (systemd NRestarts, engine RestartCount, k8s restartCount, cgroup oom_kill).
+type ResourceUsage struct {
+	CPUPercent float64          `json:"cpu_percent"`
+	MemBytes   int64            `json:"mem_bytes"`
+	MemLimit   int64            `json:"mem_limit_bytes,omitempty"`
+	BlockRead  int64            `json:"block_read_bytes"`
+	BlockWrite int64            `json:"block_write_bytes"`
+	Restarts   int              `json:"restarts"`
+	OOMKills   int              `json:"oom_kills"`
+	LastExit   string           `json:"last_exit,omitempty"` // "OOM", "exit 1", "signal 9", "crashed", ...
+	History    *ResourceHistory `json:"history,omitempty"`
+
+	// newRestarts counts the restarts since the previous sample, for the
+	// deploy.restarted events (journalRestarts).
+	newRestarts int
+}
+
+// ResourceHistory summarises the rolling history of one deployment: the
+// restarts and OOM kills observed within Window, and how far back the history
+// reaches. Samples is only filled for the single-deployment view.
+type ResourceHistory struct {
+	Window   string           `json:"window"`
+	Since    time.Time        `json:"since"`
+	Restarts int              `json:"restarts"`
+	OOMKills int              `json:"oom_kills"`
+	Samples  []ResourceSample `json:"samples,omitempty"`
+}
+
+// ResourceSample is one recorded reading.
+type ResourceSample struct {
+	At         time.Time `json:"at"`
+	Started    time.Time `json:"started,omitzero"` // process / container start, when known
+	CPUNanos   int64     `json:"cpu_ns,omitempty"` // cumulative CPU time, when the substrate reports it
+	CPUPercent float64   `json:"cpu_percent,omitempty"`
+	MemBytes   int64     `json:"mem_bytes,omitempty"`
+	Restarts   int       `json:"restarts"`
+	OOMKills   int       `json:"oom_kills"`
+	LastExit   string    `json:"last_exit,omitempty"`
+}
+
+// resourceReading is a collector's raw measurement before the history is
+// applied: the usage plus what the history needs to derive rates.
+type resourceReading struct {
+	Usage    ResourceUsage
+	Started  time.Time
+	CPUNanos int64
+}
+
+// statusHistoryDir returns the directory of the rolling histories.
+func statusHistoryDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("determining home directory: %w", err)
+	}
+	return filepath.Join(home, ".local", "share", "charly", "status", "history"), nil
+}
+
+// resourceHistoryKey names a row's history file: substrate plus the row's
+// container / domain / deploy-id cell.
+func resourceHistoryKey(kind SubstrateKind, name string) string {
+	return string(kind) + "-" + strings.ReplaceAll(name, "/", "_")
+}
+
+// loadResourceSamples reads a row's recorded samples; a missing or unreadable
+// history starts empty.
+func loadResourceSamples(key string) []ResourceSample {
+	dir, err := statusHistoryDir()
+	if err != nil {
+		return nil
+	}
+	data, err := os.ReadFile(filepath.Join(dir, key+".json"))
+	if err != nil {
+		return nil
+	}
+	var samples []ResourceSample
+	if json.Unmarshal(data, &samples) != nil {
+		return nil
+	}
+	return samples
+}
+
+// saveResourceSamples replaces a row's history.
+func saveResourceSamples(key string, samples []ResourceSample) error {
+	dir, err := statusHistoryDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(samples)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(dir, key+".json")
+	if err := os.WriteFile(path+".tmp", data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// settleResources records a reading in the row's history and returns the
+// usage with the history applied. withSamples keeps the samples themselves in
+// the result (the single-deployment view). A history that cannot be written
+// degrades to the bare reading.
+func settleResources(key string, r *resourceReading, now time.Time, withSamples bool) *ResourceUsage {
+	u := r.Usage
+	samples := loadResourceSamples(key)
+	if u.CPUPercent == 0 && r.CPUNanos > 0 {
+		u.CPUPercent = cpuPercentSince(samples, r, now)
+	}
+	samples = appendResourceSample(samples, ResourceSample{
+		At:         now.UTC(),
+		Started:    r.Started.UTC(),
+		CPUNanos:   r.CPUNanos,
+		CPUPercent: u.CPUPercent,
+		MemBytes:   u.MemBytes,
+		Restarts:   u.Restarts,
+		OOMKills:   u.OOMKills,
+		LastExit:   u.LastExit,
+	}, now)
+	if err := saveResourceSamples(key, samples); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: recording resource history: %v\n", err)
+	}
+	if n := len(samples); n > 1 {
+		u.newRestarts, _ = sampleDelta(samples[n-2], samples[n-1])
+	}
+	h := summarizeResourceSamples(samples, now)
+	if withSamples {
+		h.Samples = samples
+	}
+	u.History = h
+	return &u
+}
+
+// cpuPercentSince derives a CPU percentage from cumulative CPU time: the delta
+// against the previous sample of the same run, else the average since start.
+func cpuPercentSince(samples []ResourceSample, r *resourceReading, now time.Time) float64 {
+	if n := len(samples); n > 0 {
+		prev := samples[n-1]
+		if prev.CPUNanos > 0 && r.CPUNanos >= prev.CPUNanos && prev.Started.Equal(r.Started.UTC()) {
+			if wall := now.Sub(prev.At); wall > 0 {
+				return roundPercent(float64(r.CPUNanos-prev.CPUNanos) / float64(wall.Nanoseconds()) * 100)
+			}
+		}
+	}
+	if !r.Started.IsZero() {
+		if wall := now.Sub(r.Started); wall > 0 {
+			return roundPercent(float64(r.CPUNanos) / float64(wall.Nanoseconds()) * 100)
+		}
+	}
+	return 0
+}
+
+func roundPercent(p float64) float64 {
+	return float64(int64(p*100+0.5)) / 100
+}
+
+// appendResourceSample appends s and drops samples that fell out of the
+// rolling window or exceed resourceHistoryMax.
+func appendResourceSample(samples []ResourceSample, s ResourceSample, now time.Time) []ResourceSample {
+	samples = append(samples, s)
+	cut := 0
+	for cut < len(samples) && now.Sub(samples[cut].At) > resourceHistoryKeep {
+		cut++
+	}
+	if len(samples)-cut > resourceHistoryMax {
+		cut = len(samples) - resourceHistoryMax
+	}
+	return samples[cut:]
+}
+
+// summarizeResourceSamples counts the restarts and OOM kills between
+// consecutive samples that both lie within resourceRecentWindow — a pair
+// reaching back past the window could carry older restarts. A counter that went
+// backwards was reset (the unit or container was recreated), so the new value
+// is all new; a changed start time with an unchanged counter is one restart.
+// An OOM is counted from the oom_kill counter, or from a restart (or a newly
+// stopped deployment) whose last exit was OOM.
+func summarizeResourceSamples(samples []ResourceSample, now time.Time) *ResourceHistory {
+	h := &ResourceHistory{Window: "1h"}
+	if len(samples) == 0 {
+		return h
+	}
+	h.Since = samples[0].At
+	for i := 1; i < len(samples); i++ {
+		if now.Sub(samples[i-1].At) > resourceRecentWindow {
+			continue
+		}
+		restarts, ooms := sampleDelta(samples[i-1], samples[i])
+		h.Restarts += restarts
+		h.OOMKills += ooms
+	}
+	return h
+}
+
+// sampleDelta returns the restarts and OOM kills between two consecutive
+// samples: the counters' growth, or one when the process was recreated (its
+// start time moved) or newly exited on an OOM without the counters moving.
+func sampleDelta(a, b ResourceSample) (restarts, ooms int) {
+	restarts = counterDelta(a.Restarts, b.Restarts)
+	if restarts == 0 && !a.Started.IsZero() && !b.Started.IsZero() && !a.Started.Equal(b.Started) {
+		restarts = 1
+	}
+	ooms = counterDelta(a.OOMKills, b.OOMKills)
+	if ooms == 0 && b.LastExit == "OOM" && (restarts > 0 || a.LastExit != "OOM") {
+		ooms = 1
+	}
+	return restarts, ooms
+}
+
+// journalRestarts records a deploy.restarted event (events.go) for every row,
+// nested ones included, whose restart counters moved since its previous
+// sample — so restarts systemd, the engine, libvirt or k8s performed on their
+// own show up in `charly events` whenever status is collected.
+func journalRestarts(rows []DeploymentStatus) {
+	for _, s := range rows {
+		journalRestarts(s.Nested)
+		if s.Resources == nil || s.Resources.newRestarts == 0 {
+			continue
+		}
+		deploy := deployKey(s.Image, s.Instance)
+		if s.Kind == SubstrateLocal {
+			deploy = s.Container // the deploy id; a local row's Image only counts its candies
+		}
+		ev := Event{Type: EventDeployRestarted, Deploy: deploy, Kind: s.Kind,
+			Message: fmt.Sprintf("restarted %d× since the last status check", s.Resources.newRestarts)}
+		if s.Resources.LastExit != "" {
+			ev.Message += ", last exit " + s.Resources.LastExit
+			ev.Detail = map[string]string{"last_exit": s.Resources.LastExit}
+		}
+		recordEvent(ev)
+	}
+}
+
+func counterDelta(prev, cur int) int {
+	if cur < prev {
+		return cur
+	}
+	return cur - prev
+}
+
+// resourceSummary renders the usage line of the detail view.
+func resourceSummary(u *ResourceUsage) string {
+	var parts []string
+	if u.CPUPercent > 0 {
+		parts = append(parts, fmt.Sprintf("CPU %.1f%%", u.CPUPercent))
+	}
+	if u.MemBytes > 0 {
+		mem := "mem " + humanBytes(u.MemBytes)
+		if u.MemLimit > 0 {
+			mem += " / " + humanBytes(u.MemLimit)
+		}
+		parts = append(parts, mem)
+	}
+	if u.BlockRead > 0 || u.BlockWrite > 0 {
+		parts = append(parts, fmt.Sprintf("io %s read / %s written", humanBytes(u.BlockRead), humanBytes(u.BlockWrite)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// healthSummary renders the restart line of the detail view, e.g.
+// "restarted 4× in the last hour, last exit OOM".
+func healthSummary(u *ResourceUsage) string {
+	var parts []string
+	if h := u.History; h != nil && h.Restarts > 0 {
+		parts = append(parts, fmt.Sprintf("restarted %d× in the last hour", h.Restarts))
+	} else if u.Restarts > 0 {
+		parts = append(parts, fmt.Sprintf("restarted %d× in total", u.Restarts))
+	}
+	ooms := u.OOMKills
+	if h := u.History; h != nil && h.OOMKills > ooms {
+		ooms = h.OOMKills
+	}
+	if ooms == 1 {
+		parts = append(parts, "1 OOM kill")
+	} else if ooms > 1 {
+		parts = append(parts, fmt.Sprintf("%d OOM kills", ooms))
+	}
+	if u.LastExit != "" {
+		parts = append(parts, "last exit "+u.LastExit)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// --- per-substrate readings ---
+
+// podResources reads the resource usage of pod-substrate snapshots keyed by
+// container name: engine stats for the running ones, and the restart record of
+// every one — the quadlet service's systemd counters in quadlet mode (a quadlet
+// container is recreated on each restart, so only the service counts them),
+// the engine's inspect counters otherwise.
+func (c *Collector) podResources(engine *EngineClient, snaps []ContainerSnapshot, withSamples bool) map[string]*ResourceUsage {
+	var running []string
+	for _, s := range snaps {
+		if s.State == "running" {
+			running = append(running, s.Name)
+		}
+	}
+	stats, err := engine.Stats(running)
+	if err != nil {
+		stats = nil
+	}
+	var units map[string]systemdUnitInfo
+	if c.rt.RunMode == "quadlet" {
+		names := make([]string, 0, len(snaps))
+		for _, s := range snaps {
+			names = append(names, serviceNameInstance(s.Box, s.Instance))
+		}
+		units = systemdShow(true, names)
+	}
+
+	now := time.Now()
+	out := map[string]*ResourceUsage{}
+	for _, s := range snaps {
+		r := resourceReading{Started: s.StartedAt}
+		measured := false
+		if st, ok := stats[s.Name]; ok {
+			r.Usage.CPUPercent = st.CPUPercent
+			r.Usage.MemBytes = st.MemBytes
+			r.Usage.MemLimit = st.MemLimit
+			r.Usage.BlockRead = st.BlockRead
+			r.Usage.BlockWrite = st.BlockWrite
+			measured = true
+		}
+		if u, ok := units[serviceNameInstance(s.Box, s.Instance)]; ok && u.LoadState == "loaded" {
+			applySystemdCounters(&r, u)
+			measured = true
+		} else if c.rt.RunMode != "quadlet" {
+			r.Usage.Restarts = s.Restarts
+			switch {
+			case s.OOMKilled:
+				r.Usage.LastExit = "OOM"
+			case s.State != "running" && s.ExitCode != 0:
+				r.Usage.LastExit = fmt.Sprintf("exit %d", s.ExitCode)
+			}
+			measured = measured || s.Restarts > 0 || r.Usage.LastExit != ""
+		}
+		if !measured {
+			continue
+		}
+		out[s.Name] = settleResources(resourceHistoryKey(SubstratePod, s.Name), &r, now, withSamples)
+	}
+	return out
+}
+
+// vmDomainStats reads `virsh domstats --raw` for the named libvirt domains,
+// keyed by domain name. Swappable for tests; an absent virsh or a failed call
+// yields no readings (the rows simply carry no usage).
+var vmDomainStats = func(domains []string) map[string]resourceReading {
+	if len(domains) == 0 {
+		return nil
+	}
+	virsh, err := exec.LookPath("virsh")
+	if err != nil {
+		return nil
+	}
+	args := append([]string{"-c", libvirtSessionURI, "domstats", "--raw", "--state", "--cpu-total", "--balloon", "--block"}, domains...)
+	out, err := exec.Command(virsh, args...).Output()
+	if err != nil {
+		return nil
+	}
+	return parseDomstats(string(out))
+}
+
+// parseDomstats parses `virsh domstats --raw` output: a "Domain: 'name'" line
+// followed by indented key=value stats. cpu.time is cumulative nanoseconds;
+// balloon.rss / balloon.current are KiB; block.<n>.rd.bytes / wr.bytes are
+// summed over every disk.
+func parseDomstats(out string) map[string]resourceReading {
+	readings := map[string]resourceReading{}
+	var name string
+	var r resourceReading
+	var state, reason string
+	flush := func() {
+		if name == "" {
+			return
+		}
+		// VIR_DOMAIN_CRASHED, or shut off for VIR_DOMAIN_SHUTOFF_CRASHED.
+		if state == "6" || state == "5" && reason == "3" {
+			r.Usage.LastExit = "crashed"
+		}
+		readings[name] = r
+	}
+	for line := range strings.SplitSeq(out, "\n") {
+		line = strings.TrimSpace(line)
+		if v, ok := strings.CutPrefix(line, "Domain: "); ok {
+			flush()
+			name, r, state, reason = strings.Trim(v, "'\""), resourceReading{}, "", ""
+			continue
+		}
+		k, v, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		n, _ := strconv.ParseInt(v, 10, 64)
+		switch {
+		case k == "state.state":
+			state = v
+		case k == "state.reason":
+			reason = v
+		case k == "cpu.time":
+			r.CPUNanos = n
+		case k == "balloon.rss":
+			r.Usage.MemBytes = n * 1024
+		case k == "balloon.current":
+			r.Usage.MemLimit = n * 1024
+		case strings.HasPrefix(k, "block.") && strings.HasSuffix(k, ".rd.bytes"):
+			r.Usage.BlockRead += n
+		case strings.HasPrefix(k, "block.") && strings.HasSuffix(k, ".wr.bytes"):
+			r.Usage.BlockWrite += n
+		}
+	}
+	flush()
+	return readings
+}
+
+// k8sWorkloadResources reads the live usage of a k8s deployment's pods
+// (selected by the generator's app=<name> label): `kubectl top pod` for CPU
+// and memory (metrics-server), and the pods' containerStatuses for the restart
+// record. Swappable for tests; an absent kubectl, cluster or metrics-server
+// degrades to whatever part answered.
+var k8sWorkloadResources = func(name, namespace, kubeContext string) (resourceReading, bool) {
+	var r resourceReading
+	kubectl, err := exec.LookPath("kubectl")
+	if err != nil {
+		return r, false
+	}
+	base := []string{"-l", "app=" + name}
+	if namespace != "" {
+		base = append(base, "-n", namespace)
+	}
+	if kubeContext != "" {
+		base = append(base, "--context", kubeContext)
+	}
+	measured := false
+	if out, err := exec.Command(kubectl, append([]string{"top", "pod", "--no-headers"}, base...)...).Output(); err == nil {
+		r.Usage.CPUPercent, r.Usage.MemBytes = parseKubectlTop(string(out))
+		measured = true
+	}
+	if out, err := exec.Command(kubectl, append([]string{"get", "pod", "-o", "json"}, base...)...).Output(); err == nil {
+		if applyK8sPodStatuses(&r, out) == nil {
+			measured = true
+		}
+	}
+	return r, measured
+}
+
+// parseKubectlTop sums `kubectl top pod --no-headers` rows ("app-7d9 3m 45Mi"):
+// CPU millicores as a percentage of one core, memory in bytes.
+func parseKubectlTop(out string) (float64, int64) {
+	var cpu float64
+	var mem int64
+	for line := range strings.SplitSeq(out, "\n") {
+		f := strings.Fields(line)
+		if len(f) < 3 {
+			continue
+		}
+		if m, ok := strings.CutSuffix(f[1], "m"); ok {
+			n, _ := strconv.ParseFloat(m, 64)
+			cpu += n / 10
+		} else {
+			n, _ := strconv.ParseFloat(f[1], 64)
+			cpu += n * 100
+		}
+		// k8s quantities ("45Mi", "120M", "1Gi") → the engine-stats units.
+		q := f[2]
+		if strings.HasSuffix(q, "i") || strings.HasSuffix(q, "k") || strings.HasSuffix(q, "M") || strings.HasSuffix(q, "G") {
+			q += "B"
+		}
+		if b, ok := parseHumanSize(q); ok {
+			mem += b
+		}
+	}
+	return roundPercent(cpu), mem
+}
+
+// applyK8sPodStatuses folds the restart counts and last terminations of a
+// `kubectl get pod -o json` list into a reading.
+func applyK8sPodStatuses(r *resourceReading, data []byte) error {
+	var list struct {
+		Items []struct {
+			Status struct {
+				ContainerStatuses []struct {
+					RestartCount int `json:"restartCount"`
+					LastState    struct {
+						Terminated *struct {
+							Reason   string    `json:"reason"`
+							ExitCode int       `json:"exitCode"`
+							Finished time.Time `json:"finishedAt"`
+						} `json:"terminated"`
+					} `json:"lastState"`
+				} `json:"containerStatuses"`
+			} `json:"status"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	var last time.Time
+	for _, pod := range list.Items {
+		for _, cs := range pod.Status.ContainerStatuses {
+			r.Usage.Restarts += cs.RestartCount
+			t := cs.LastState.Terminated
+			if t == nil {
+				continue
+			}
+			if t.Reason == "OOMKilled" {
+				r.Usage.OOMKills++
+			}
+			if t.Finished.After(last) || last.IsZero() {
+				last = t.Finished
+				if t.Reason == "OOMKilled" {
+					r.Usage.LastExit = "OOM"
+				} else {
+					r.Usage.LastExit = fmt.Sprintf("exit %d", t.ExitCode)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// localServiceResources reads the usage of the services a local deploy
+// enabled: /proc of each service's MainPID (summed) and the units' systemd
+// counters. user selects the systemd instance per unit.
+func localServiceResources(units map[string]bool) (resourceReading, bool) {
+	var r resourceReading
+	measured := false
+	for _, user := range []bool{true, false} {
+		var names []string
+		for u, isUser := range units {
+			if isUser == user {
+				names = append(names, u)
+			}
+		}
+		for _, u := range systemdShow(user, names) {
+			if u.LoadState != "loaded" {
+				continue
+			}
+			applySystemdCounters(&r, u)
+			measured = true
+			if u.MainPID <= 0 {
+				continue
+			}
+			if p, ok := procReading(u.MainPID); ok {
+				r.CPUNanos += p.CPUNanos
+				r.Usage.MemBytes += p.Usage.MemBytes
+				r.Usage.BlockRead += p.Usage.BlockRead
+				r.Usage.BlockWrite += p.Usage.BlockWrite
+				if r.Started.IsZero() || p.Started.Before(r.Started) {
+					r.Started = p.Started
+				}
+			}
+		}
+	}
+	return r, measured
+}
+
+// --- systemd + cgroup counters (pod and local substrates) ---
+
+// systemdUnitInfo is the slice of `systemctl show` the resource readings use.
+type systemdUnitInfo struct {
+	ID             string
+	LoadState      string
+	MainPID        int
+	NRestarts      int
+	Result         string
+	ExecMainStatus string
+	ControlGroup   string
+}
+
+// systemdShow runs one batched `systemctl [--user] show` over units. Swappable
+// for tests; a failure yields no entries.
+var systemdShow = func(user bool, units []string) map[string]systemdUnitInfo {
+	if len(units) == 0 {
+		return nil
+	}
+	args := []string{"show"}
+	if user {
+		args = append(args, "--user")
+	}
+	args = append(args, "-p", "Id,LoadState,MainPID,NRestarts,Result,ExecMainStatus,ControlGroup")
+	out, err := exec.Command("systemctl", append(args, units...)...).Output()
+	if err != nil {
+		return nil
+	}
+	return parseSystemdShow(string(out))
+}
+
+// parseSystemdShow splits `systemctl show` output (one blank-line separated
+// key=value block per unit) into infos keyed by unit Id.
+func parseSystemdShow(out string) map[string]systemdUnitInfo {
+	infos := map[string]systemdUnitInfo{}
+	for block := range strings.SplitSeq(out, "\n\n") {
+		var u systemdUnitInfo
+		for line := range strings.SplitSeq(block, "\n") {
+			k, v, ok := strings.Cut(line, "=")
+			if !ok {
+				continue
+			}
+			switch k {
+			case "Id":
+				u.ID = v
+			case "LoadState":
+				u.LoadState = v
+			case "MainPID":
+				u.MainPID, _ = strconv.Atoi(v)
+			case "NRestarts":
+				u.NRestarts, _ = strconv.Atoi(v)
+			case "Result":
+				u.Result = v
+			case "ExecMainStatus":
+				u.ExecMainStatus = v
+			case "ControlGroup":
+				u.ControlGroup = v
+			}
+		}
+		if u.ID != "" {
+			infos[u.ID] = u
+		}
+	}
+	return infos
+}
+
+// lastExit renders how the unit's main process last ended; "" for a clean or
+// unknown result.
+func (u systemdUnitInfo) lastExit() string {
+	switch u.Result {
+	case "oom-kill":
+		return "OOM"
+	case "exit-code":
+		return "exit " + u.ExecMainStatus
+	case "signal", "core-dump":
+		return "signal " + u.ExecMainStatus
+	case "timeout", "watchdog", "start-limit-hit":
+		return u.Result
+	}
+	return ""
+}
+
+// cgroupRoot is the cgroup v2 mount; swappable for tests.
+var cgroupRoot = "/sys/fs/cgroup"
+
+// cgroupOOMKills reads the oom_kill counter of a cgroup's memory.events (which
+// counts the whole subtree — the container cgroups nested under a quadlet
+// service included). 0 when the cgroup or the counter is absent.
+func cgroupOOMKills(cgroup string) int {
+	if cgroup == "" {
+		return 0
+	}
+	data, err := os.ReadFile(filepath.Join(cgroupRoot, cgroup, "memory.events"))
+	if err != nil {
+		return 0
+	}
+	for line := range strings.SplitSeq(string(data), "\n") {
+		if v, ok := strings.CutPrefix(line, "oom_kill "); ok {
+			n, _ := strconv.Atoi(strings.TrimSpace(v))
+			return n
+		}
+	}
+	return 0
+}
+
+// applySystemdCounters folds a unit's restart counters into a reading.
+func applySystemdCounters(r *resourceReading, u systemdUnitInfo) {
+	r.Usage.Restarts += u.NRestarts
+	r.Usage.OOMKills += cgroupOOMKills(u.ControlGroup)
+	if r.Usage.LastExit == "" {
+		r.Usage.LastExit = u.lastExit()
+	}
+}
+
+// --- /proc (local substrate) ---
+
+// procRoot is the proc mount; swappable for tests.
+var procRoot = "/proc"
+
+// procReading reads the cumulative CPU time, resident memory and block IO of
+// one process from /proc, plus its start time. /proc/<pid>/io is only
+// readable for the caller's own processes; its counters stay 0 otherwise.
+func procReading(pid int) (resourceReading, bool) {
+	var r resourceReading
+	dir := filepath.Join(procRoot, strconv.Itoa(pid))
+	stat, err := os.ReadFile(filepath.Join(dir, "stat"))
+	if err != nil {
+		return r, false
+	}
+	// Fields after the parenthesised comm; utime/stime are fields 14/15 and
+	// starttime is field 22 of the full line.
+	rest := string(stat)
+	if i := strings.LastIndexByte(rest, ')'); i >= 0 {
+		rest = rest[i+1:]
+	}
+	f := strings.Fields(rest)
+	if len(f) < 20 {
+		return r, false
+	}
+	utime, _ := strconv.ParseInt(f[11], 10, 64)
+	stime, _ := strconv.ParseInt(f[12], 10, 64)
+	start, _ := strconv.ParseInt(f[19], 10, 64)
+	r.CPUNanos = (utime + stime) * int64(time.Second/procClockTicks)
+	if boot := procBootTime(); !boot.IsZero() {
+		r.Started = boot.Add(time.Duration(start) * (time.Second / procClockTicks))
+	}
+	if kv := procKeyValues(filepath.Join(dir, "status")); kv["VmRSS"] != "" {
+		kb, _ := strconv.ParseInt(strings.TrimSuffix(kv["VmRSS"], " kB"), 10, 64)
+		r.Usage.MemBytes = kb * 1024
+	}
+	if kv := procKeyValues(filepath.Join(dir, "io")); kv != nil {
+		r.Usage.BlockRead, _ = strconv.ParseInt(kv["read_bytes"], 10, 64)
+		r.Usage.BlockWrite, _ = strconv.ParseInt(kv["write_bytes"], 10, 64)
+	}
+	return r, true
+}
+
+// procBootTime reads btime from /proc/stat.
+func procBootTime() time.Time {
+	f, err := os.Open(filepath.Join(procRoot, "stat"))
+	if err != nil {
+		return time.Time{}
+	}
+	defer f.Close() //nolint:errcheck
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if v, ok := strings.CutPrefix(sc.Text(), "btime "); ok {
+			secs, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+			if err == nil {
+				return time.Unix(secs, 0)
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// procKeyValues parses a "Key: value" /proc file; nil when unreadable.
+func procKeyValues(path string) map[string]string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	kv := map[string]string{}
+	for line := range strings.SplitSeq(string(data), "\n") {
+		if k, v, ok := strings.Cut(line, ":"); ok {
+			kv[k] = strings.TrimSpace(v)
+		}
+	}
+	return kv
+}
+
+// --- human-readable sizes (engine stats) ---
+
+// parseHumanSize parses the sizes `podman stats` / `docker stats` print
+// ("12.5MiB", "1.2MB", "0B", "3kB"). ok is false for "--" and other
+// unparseable cells.
+func parseHumanSize(s string) (int64, bool) {
+	s = strings.TrimSpace(s)
+	i := 0
+	for i < len(s) && (s[i] >= '0' && s[i] <= '9' || s[i] == '.') {
+		i++
+	}
+	num, err := strconv.ParseFloat(s[:i], 64)
+	if err != nil {
+		return 0, false
+	}
+	mult := map[string]float64{
+		"": 1, "B": 1,
+		"kB": 1e3, "KB": 1e3, "MB": 1e6, "GB": 1e9, "TB": 1e12,
+		"KiB": 1 << 10, "MiB": 1 << 20, "GiB": 1 << 30, "TiB": 1 << 40,
+	}
+	m, ok := mult[strings.TrimSpace(s[i:])]
+	if !ok {
+		return 0, false
+	}
+	return int64(num * m), true
+}
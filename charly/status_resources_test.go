@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseEngineStats(t *testing.T) {
+	out := "charly-app\t1.50%\t12.5MiB / 2GiB\t1.2MB / 0B\ncharly-db\t--\t-- / --\t-- / --\n"
+	got := parseEngineStats(out)
+	want := map[string]engineStatsRow{
+		"charly-app": {CPUPercent: 1.5, MemBytes: 12.5 * (1 << 20), MemLimit: 2 << 30, BlockRead: 1200000},
+		"charly-db":  {},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseEngineStats = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseInspect_RestartRecord(t *testing.T) {
+	rows, err := parseInspect([]byte(`[{"Name": "charly-app", "RestartCount": 3, "State": {"OOMKilled": true, "ExitCode": 137}}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || rows[0].Restarts != 3 || !rows[0].OOMKilled || rows[0].ExitCode != 137 {
+		t.Errorf("parseInspect = %+v", rows)
+	}
+}
+
+func TestParseSystemdShow(t *testing.T) {
+	out := "Id=charly-app.service\nLoadState=loaded\nMainPID=4242\nNRestarts=4\nResult=oom-kill\nExecMainStatus=9\nControlGroup=/user.slice/app.service\n\n" +
+		"Id=charly-gone.service\nLoadState=not-found\nNRestarts=0\nResult=success\n"
+	infos := parseSystemdShow(out)
+	app := infos["charly-app.service"]
+	if app.MainPID != 4242 || app.NRestarts != 4 || app.ControlGroup != "/user.slice/app.service" || app.lastExit() != "OOM" {
+		t.Errorf("app = %+v", app)
+	}
+	if infos["charly-gone.service"].LoadState != "not-found" {
+		t.Errorf("gone = %+v", infos["charly-gone.service"])
+	}
+	for u, want := range map[systemdUnitInfo]string{
+		{Result: "exit-code", ExecMainStatus: "1"}: "exit 1",
+		{Result: "signal", ExecMainStatus: "15"}:   "signal 15",
+		{Result: "success"}:                        "",
+	} {
+		if got := u.lastExit(); got != want {
+			t.Errorf("lastExit(%+v) = %q, want %q", u, got, want)
+		}
+	}
+}
+
+func TestCgroupOOMKills(t *testing.T) {
+	root := t.TempDir()
+	prev := cgroupRoot
+	cgroupRoot = root
+	t.Cleanup(func() { cgroupRoot = prev })
+	dir := filepath.Join(root, "user.slice", "app.service")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "memory.events"), []byte("low 0\nhigh 0\nmax 12\noom 3\noom_kill 2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := cgroupOOMKills("/user.slice/app.service"); got != 2 {
+		t.Errorf("cgroupOOMKills = %d, want 2", got)
+	}
+	if got := cgroupOOMKills("/missing"); got != 0 {
+		t.Errorf("missing cgroup = %d", got)
+	}
+}
+
+func TestParseDomstats(t *testing.T) {
+	out := `Domain: 'charly-arch'
+  state.state=1
+  state.reason=1
+  cpu.time=12000000000
+  balloon.current=4194304
+  balloon.rss=2097152
+  block.count=2
+  block.0.rd.bytes=1000
+  block.0.wr.bytes=200
+  block.1.rd.bytes=24
+  block.1.wr.bytes=0
+
+Domain: 'charly-old'
+  state.state=5
+  state.reason=3
+`
+	got := parseDomstats(out)
+	arch := got["charly-arch"]
+	if arch.CPUNanos != 12e9 || arch.Usage.MemBytes != 2<<30 || arch.Usage.MemLimit != 4<<30 || arch.Usage.BlockRead != 1024 || arch.Usage.BlockWrite != 200 {
+		t.Errorf("arch = %+v", arch)
+	}
+	if got["charly-old"].Usage.LastExit != "crashed" {
+		t.Errorf("old = %+v", got["charly-old"])
+	}
+}
+
+func TestK8sWorkloadReadings(t *testing.T) {
+	cpu, mem := parseKubectlTop("app-7d9-a   250m   45Mi\napp-7d9-b   1   120M\n")
+	if cpu != 125 || mem != 45<<20+120e6 {
+		t.Errorf("parseKubectlTop = %v, %d", cpu, mem)
+	}
+	var r resourceReading
+	pods := `{"items": [
+	  {"status": {"containerStatuses": [{"restartCount": 2, "lastState": {"terminated": {"reason": "OOMKilled", "exitCode": 137, "finishedAt": "2026-10-16T11:00:00Z"}}}]}},
+	  {"status": {"containerStatuses": [{"restartCount": 1, "lastState": {"terminated": {"reason": "Error", "exitCode": 1, "finishedAt": "2026-10-16T10:00:00Z"}}}]}}
+	]}`
+	if err := applyK8sPodStatuses(&r, []byte(pods)); err != nil {
+		t.Fatal(err)
+	}
+	if r.Usage.Restarts != 3 || r.Usage.OOMKills != 1 || r.Usage.LastExit != "OOM" {
+		t.Errorf("pod statuses = %+v", r.Usage)
+	}
+}
+
+func TestProcReading(t *testing.T) {
+	root := t.TempDir()
+	prev := procRoot
+	procRoot = root
+	t.Cleanup(func() { procRoot = prev })
+	dir := filepath.Join(root, "4242")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// comm with a space and a paren; utime 150 + stime 50 ticks, starttime 1000 ticks.
+	stat := "4242 (my (daemon)) S 1 4242 4242 0 -1 4194560 100 0 0 0 150 50 0 0 20 0 1 0 1000 1000000 200\n"
+	files := map[string]string{
+		filepath.Join(root, "stat"):  "cpu  1 2 3\nbtime 1760000000\n",
+		filepath.Join(dir, "stat"):   stat,
+		filepath.Join(dir, "status"): "Name:\tdaemon\nVmRSS:\t  2048 kB\n",
+		filepath.Join(dir, "io"):     "rchar: 10\nread_bytes: 4096\nwrite_bytes: 8192\n",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r, ok := procReading(4242)
+	if !ok {
+		t.Fatal("procReading failed")
+	}
+	if r.CPUNanos != 2e9 || r.Usage.MemBytes != 2048*1024 || r.Usage.BlockRead != 4096 || r.Usage.BlockWrite != 8192 {
+		t.Errorf("procReading = %+v", r)
+	}
+	if want := time.Unix(1760000010, 0); !r.Started.Equal(want) {
+		t.Errorf("started = %v, want %v", r.Started, want)
+	}
+	if _, ok := procReading(1); ok {
+		t.Error("missing pid read")
+	}
+}
+
+func TestSummarizeResourceSamples(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	start := func(m int) time.Time { return now.Add(-time.Duration(m) * time.Minute) }
+	samples := []ResourceSample{
+		{At: start(120), Restarts: 1},
+		{At: start(70), Restarts: 2},                                      // outside the hour
+		{At: start(50), Restarts: 4, OOMKills: 1, LastExit: "OOM"},        // previous sample stale: not counted
+		{At: start(40), Restarts: 5, OOMKills: 2, LastExit: "OOM"},        // +1 restart, +1 OOM
+		{At: start(30), Restarts: 1, LastExit: "exit 1"},                  // reset: +1
+		{At: start(20), Restarts: 1, Started: start(21), LastExit: "OOM"}, // stopped by an OOM
+		{At: start(10), Restarts: 1, Started: start(11), LastExit: "OOM"}, // recreated: +1, again after an OOM
+	}
+	h := summarizeResourceSamples(samples, now)
+	if h.Restarts != 3 || h.OOMKills != 3 || !h.Since.Equal(start(120)) {
+		t.Errorf("summary = %+v", h)
+	}
+
+	// A sample only just inside the hour whose predecessor is hours old: the
+	// restarts between them may all predate the window.
+	stale := []ResourceSample{
+		{At: start(180), Restarts: 0, Started: start(200)},
+		{At: start(5), Restarts: 3, Started: start(6), LastExit: "OOM"},
+	}
+	if h := summarizeResourceSamples(stale, now); h.Restarts != 0 || h.OOMKills != 0 {
+		t.Errorf("stale pair summary = %+v", h)
+	}
+}
+
+func TestSettleResources(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t0 := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	key := resourceHistoryKey(SubstrateVM, "charly-arch")
+	first := settleResources(key, &resourceReading{CPUNanos: 10e9, Usage: ResourceUsage{MemBytes: 1 << 30}}, t0, false)
+	if first.CPUPercent != 0 || first.History == nil || first.History.Restarts != 0 {
+		t.Errorf("first = %+v", first)
+	}
+	// 5s of CPU over 10s of wall time since the last sample: 50%.
+	second := settleResources(key, &resourceReading{CPUNanos: 15e9, Usage: ResourceUsage{Restarts: 2, LastExit: "OOM"}}, t0.Add(10*time.Second), true)
+	if second.CPUPercent != 50 || second.History.Restarts != 2 || second.History.OOMKills != 1 || len(second.History.Samples) != 2 {
+		t.Errorf("second = %+v (history %+v)", second, second.History)
+	}
+	if got := healthSummary(second); got != "restarted 2× in the last hour, 1 OOM kill, last exit OOM" {
+		t.Errorf("healthSummary = %q", got)
+	}
+
+	var buf bytes.Buffer
+	s := DeploymentStatus{Kind: SubstratePod, Image: "app", Status: "running", Container: "charly-app", RunMode: "quadlet",
+		Resources: &ResourceUsage{CPUPercent: 3.25, MemBytes: 512 << 20, MemLimit: 2 << 30, History: &ResourceHistory{Restarts: 4}, LastExit: "OOM"}}
+	if err := RenderDetail(&buf, s); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Usage:     CPU 3.2%, mem 512.0 MiB / 2.0 GiB\n", "Health:    restarted 4× in the last hour, last exit OOM\n"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("detail lacks %q:\n%s", want, buf.String())
+		}
+	}
+}
@@ -0,0 +1,408 @@
+package main
+
+// status_serve.go — `charly status serve`, the OpenMetrics exporter.
+//
+// The exporter runs the same Collector as `charly status` on a fixed interval
+// and keeps the last collection in memory; a scrape only renders that cached
+// snapshot, so scrape cost is independent of how many deployments there are
+// or how slow their probes answer. Each refresh additionally gathers what the
+// status table does not carry: TCP reachability of every published port, the
+// encrypted-volume state of pod deployments, the snapshot count of every VM,
+// and the resource arbiter's preemption leases.
+//
+// Every deployment series carries the labels box, instance, kind and source
+// (the DeploymentStatus Image / Instance / Kind / Source cells, the deploy id as
+// box for local rows); lease series use the claimant deployment's box /
+// instance / target with source="arbiter".
+//
+// The `serve` keyword shadows a deployment named "serve" (status's default
+// show subcommand takes the box positionally): a bare `charly status serve`
+// with such a deployment in charly.yml is rejected with a pointer to
+// `charly status show serve`, and an explicit --listen starts the exporter.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/overthinkos/overthink/charly/spec"
+)
+
+// defaultMetricsListen is the --listen default. The flag carries no kong
+// default so checkServeShadow can tell an explicit --listen apart.
+const defaultMetricsListen = ":9470"
+
+// checkServeShadow rejects a bare `charly status serve` while a deployment is
+// named "serve": kong routes the word to this command, so the operator most
+// likely wanted that deployment's status.
+func checkServeShadow(dc *BundleConfig, listen string) error {
+	if listen != "" || dc == nil {
+		return nil
+	}
+	if _, ok := dc.Bundle["serve"]; ok {
+		return fmt.Errorf("a deployment is named \"serve\": run 'charly status show serve' for its status, or pass --listen %s to start the metrics exporter", defaultMetricsListen)
+	}
+	return nil
+}
+
+// StatusServeCmd serves the deployment status as OpenMetrics.
+type StatusServeCmd struct {
+	Listen   string        `long:"listen" help:"Address to serve /metrics on (default :9470)"`
+	Interval time.Duration `long:"interval" default:"30s" help:"How often to re-collect (scrapes read the cached collection)"`
+	All      bool          `short:"a" long:"all" help:"Include enabled-but-not-running services"`
+	Nested   bool          `long:"nested" help:"Probe nested children + live k8s workloads (multi-hop, slower)"`
+}
+
+// Run collects once, then serves until interrupted while a ticker refreshes
+// the cached collection.
+func (c *StatusServeCmd) Run() error {
+	if c.Interval < time.Second {
+		return fmt.Errorf("--interval must be at least 1s")
+	}
+	dc, _ := LoadBundleConfig()
+	if err := checkServeShadow(dc, c.Listen); err != nil {
+		return err
+	}
+	if c.Listen == "" {
+		c.Listen = defaultMetricsListen
+	}
+	rt, err := ResolveRuntime()
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	cache := &metricsCache{}
+	refresh := func() {
+		col, err := NewCollector(rt)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: charly status serve: %v\n", err)
+			return
+		}
+		cache.store(collectMetrics(ctx, col, c.All, c.Nested))
+	}
+	refresh()
+	go func() {
+		t := time.NewTicker(c.Interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				refresh()
+			}
+		}
+	}()
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", cache)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintln(w, "charly status exporter — metrics at /metrics")
+	})
+	srv := &http.Server{Addr: c.Listen, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	errc := make(chan error, 1)
+	go func() { errc <- srv.ListenAndServe() }()
+	fmt.Fprintf(os.Stderr, "Serving OpenMetrics on %s/metrics (refresh every %s)\n", c.Listen, c.Interval)
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdown); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// metricsSnapshot is one collection: the status rows plus the extra state
+// gathered for the exporter.
+type metricsSnapshot struct {
+	At       time.Time
+	Duration time.Duration
+	Rows     []deployMetrics
+	Leases   *spec.PreemptLedger // nil when the arbiter could not be asked
+	Stranded []string
+}
+
+// deployMetrics is one deployment row and its exporter-only state.
+type deployMetrics struct {
+	Status    DeploymentStatus
+	Ports     []portReach
+	Volumes   []spec.EncVolumePlan
+	Snapshots map[string]int // VM snapshots by mode
+}
+
+// portReach is the reachability of one published port.
+type portReach struct {
+	Port      PortMapping
+	Reachable bool
+}
+
+// metricsCache holds the last snapshot and serves it.
+type metricsCache struct {
+	mu   sync.RWMutex
+	snap *metricsSnapshot
+}
+
+func (m *metricsCache) store(s *metricsSnapshot) {
+	m.mu.Lock()
+	m.snap = s
+	m.mu.Unlock()
+}
+
+// ServeHTTP renders the cached snapshot: OpenMetrics when the scraper accepts
+// it, the Prometheus text format otherwise.
+func (m *metricsCache) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.RLock()
+	snap := m.snap
+	m.mu.RUnlock()
+	if snap == nil {
+		http.Error(w, "no collection yet", http.StatusServiceUnavailable)
+		return
+	}
+	openMetrics := strings.Contains(r.Header.Get("Accept"), "application/openmetrics-text")
+	if openMetrics {
+		w.Header().Set("Content-Type", "application/openmetrics-text; version=1.0.0; charset=utf-8")
+	} else {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	}
+	_ = writeMetrics(w, snap, openMetrics)
+}
+
+// dialPort probes one TCP port; swappable for tests.
+var dialPort = func(ctx context.Context, addr string) bool {
+	d := net.Dialer{Timeout: 2 * time.Second}
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return false
+	}
+	_ = conn.Close()
+	return true
+}
+
+// collectMetrics runs one collection. Per-row extras degrade independently:
+// an unreadable charly.yml drops the volume series, an absent arbiter the
+// lease series, never the deployment series.
+func collectMetrics(ctx context.Context, col *Collector, includeAll, nested bool) *metricsSnapshot {
+	start := time.Now()
+	statuses, err := col.All(ctx, includeAll, nested)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: charly status serve: %v\n", err)
+	}
+	snap := &metricsSnapshot{At: start}
+	var flatten func(ss []DeploymentStatus)
+	flatten = func(ss []DeploymentStatus) {
+		for _, s := range ss {
+			snap.Rows = append(snap.Rows, deployMetrics{Status: s})
+			flatten(s.Nested)
+		}
+	}
+	flatten(statuses)
+
+	var wg sync.WaitGroup
+	for i := range snap.Rows {
+		row := &snap.Rows[i]
+		wg.Go(func() {
+			row.Ports = probePorts(ctx, row.Status)
+		})
+		switch row.Status.Kind {
+		case SubstratePod:
+			if row.Status.Image != "" {
+				row.Volumes, _ = encPlanFor(row.Status.Image, row.Status.Instance, "", deployStorageDir(row.Status.Image, row.Status.Instance))
+			}
+		case SubstrateVM:
+			if entries, err := ListSnapshots(row.Status.Image); err == nil {
+				row.Snapshots = map[string]int{}
+				for _, e := range entries {
+					row.Snapshots[e.Mode]++
+				}
+			}
+		}
+	}
+	if ledger, stranded, err := newResourceArbiter().Status(); err == nil {
+		snap.Leases, snap.Stranded = ledger, stranded
+	}
+	wg.Wait()
+	snap.Duration = time.Since(start)
+	return snap
+}
+
+// probePorts dials the published TCP ports of a running deployment, once per
+// bound host IP (an IPv4 plus IPv6 publish lists the port twice); the
+// wildcard host IP is probed on loopback, as HostPortFor does.
+func probePorts(ctx context.Context, s DeploymentStatus) []portReach {
+	if s.Status != "running" {
+		return nil
+	}
+	var out []portReach
+	seen := map[string]bool{}
+	for _, p := range s.Ports {
+		if p.HostPort == 0 || (p.Proto != "" && p.Proto != "tcp") {
+			continue
+		}
+		key := p.HostIP + "|" + strconv.Itoa(p.HostPort)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		ip := p.HostIP
+		if ip == "" || ip == "0.0.0.0" || ip == "::" {
+			ip = "127.0.0.1"
+		}
+		out = append(out, portReach{Port: p, Reachable: dialPort(ctx, net.JoinHostPort(ip, strconv.Itoa(p.HostPort)))})
+	}
+	return out
+}
+
+// metricFamily accumulates the samples of one gauge family.
+type metricFamily struct {
+	name, help string
+	samples    []string
+}
+
+func (f *metricFamily) add(labels []string, value float64) {
+	f.samples = append(f.samples, fmt.Sprintf("%s{%s} %s", f.name, strings.Join(labels, ","), strconv.FormatFloat(value, 'g', -1, 64)))
+}
+
+// metricLabel renders one label pair with the exposition-format escapes.
+func metricLabel(name, value string) string {
+	value = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+	return name + `="` + value + `"`
+}
+
+// deployLabels are the labels every deployment series carries. A local row's
+// Image only summarizes its candy count, so its box label is the deploy id.
+func deployLabels(s DeploymentStatus, extra ...string) []string {
+	box := s.Image
+	if s.Kind == SubstrateLocal {
+		box = s.Container
+	}
+	return append([]string{
+		metricLabel("box", box),
+		metricLabel("instance", s.Instance),
+		metricLabel("kind", string(s.Kind)),
+		metricLabel("source", s.Source),
+	}, extra...)
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// writeMetrics renders a snapshot in the exposition format; openMetrics adds
+// the OpenMetrics terminator.
+func writeMetrics(w io.Writer, snap *metricsSnapshot, openMetrics bool) error {
+	up := &metricFamily{name: "charly_deployment_up", help: "Whether the deployment is running (1) or not (0)."}
+	info := &metricFamily{name: "charly_deployment_info", help: "Deployment status and image, value always 1."}
+	probe := &metricFamily{name: "charly_probe_up", help: "Whether a status probe (cdp, vnc, supervisord, ...) reports ok."}
+	port := &metricFamily{name: "charly_port_reachable", help: "Whether a published TCP port accepts connections."}
+	encMounted := &metricFamily{name: "charly_encrypted_volume_mounted", help: "Whether an encrypted volume's plaintext view is mounted."}
+	encInit := &metricFamily{name: "charly_encrypted_volume_initialized", help: "Whether an encrypted volume's cipher directory is initialized."}
+	snaps := &metricFamily{name: "charly_vm_snapshots", help: "Number of VM snapshots by mode."}
+	cpu := &metricFamily{name: "charly_deployment_cpu_percent", help: "CPU use in percent of one core."}
+	mem := &metricFamily{name: "charly_deployment_memory_bytes", help: "Memory in use."}
+	restarts := &metricFamily{name: "charly_deployment_restarts", help: "Restart counter reported by the substrate (resets when the unit is recreated)."}
+	recent := &metricFamily{name: "charly_deployment_restarts_last_hour", help: "Restarts observed in the last hour of the status history."}
+	ooms := &metricFamily{name: "charly_deployment_oom_kills", help: "OOM kills reported by the substrate."}
+	lease := &metricFamily{name: "charly_preempt_lease", help: "Active resource-arbiter lease, value always 1."}
+	stranded := &metricFamily{name: "charly_preempt_lease_stranded", help: "Whether a lease's claimant is gone and its holders await restore."}
+	holders := &metricFamily{name: "charly_preempt_lease_preempted_holders", help: "Number of holders a lease stopped."}
+
+	for _, row := range snap.Rows {
+		s := row.Status
+		up.add(deployLabels(s), boolValue(s.Status == "running"))
+		info.add(deployLabels(s, metricLabel("status", s.Status), metricLabel("image_ref", s.ImageRef)), 1)
+		for _, t := range s.Tools {
+			probe.add(deployLabels(s, metricLabel("probe", t.Name)), boolValue(t.Status == "ok"))
+		}
+		for _, p := range row.Ports {
+			port.add(deployLabels(s, metricLabel("host_ip", p.Port.HostIP), metricLabel("port", strconv.Itoa(p.Port.HostPort)), metricLabel("protocol", "tcp")), boolValue(p.Reachable))
+		}
+		for _, v := range row.Volumes {
+			encMounted.add(deployLabels(s, metricLabel("volume", v.Name)), boolValue(v.Mounted))
+			encInit.add(deployLabels(s, metricLabel("volume", v.Name)), boolValue(v.Initialized))
+		}
+		modes := make([]string, 0, len(row.Snapshots))
+		for m := range row.Snapshots {
+			modes = append(modes, m)
+		}
+		sort.Strings(modes)
+		for _, m := range modes {
+			snaps.add(deployLabels(s, metricLabel("mode", m)), float64(row.Snapshots[m]))
+		}
+		if u := s.Resources; u != nil {
+			cpu.add(deployLabels(s), u.CPUPercent)
+			mem.add(deployLabels(s), float64(u.MemBytes))
+			restarts.add(deployLabels(s), float64(u.Restarts))
+			ooms.add(deployLabels(s), float64(u.OOMKills))
+			if u.History != nil {
+				recent.add(deployLabels(s), float64(u.History.Restarts))
+			}
+		}
+	}
+	if snap.Leases != nil {
+		strandedSet := map[string]bool{}
+		for _, c := range snap.Stranded {
+			strandedSet[c] = true
+		}
+		for _, l := range snap.Leases.Leases {
+			labels := []string{
+				metricLabel("box", l.Claim.Base),
+				metricLabel("instance", l.Claim.Instance),
+				metricLabel("kind", l.Claim.Target),
+				metricLabel("source", "arbiter"),
+				metricLabel("claimant", l.Claimant),
+			}
+			lease.add(append(labels,
+				metricLabel("tokens", strings.Join(l.Tokens, ",")),
+				metricLabel("mode", l.Mode),
+				metricLabel("shared", strconv.FormatBool(l.Shared)),
+				metricLabel("transient", strconv.FormatBool(l.Transient)),
+			), 1)
+			stranded.add(labels, boolValue(strandedSet[l.Claimant]))
+			holders.add(labels, float64(len(l.Preempted)))
+		}
+	}
+
+	var b strings.Builder
+	for _, f := range []*metricFamily{up, info, probe, port, encMounted, encInit, snaps, cpu, mem, restarts, recent, ooms, lease, stranded, holders} {
+		if len(f.samples) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s gauge\n", f.name, f.help, f.name)
+		for _, s := range f.samples {
+			b.WriteString(s)
+			b.WriteByte('\n')
+		}
+	}
+	fmt.Fprintf(&b, "# HELP charly_status_collect_timestamp_seconds When the cached collection was taken.\n# TYPE charly_status_collect_timestamp_seconds gauge\ncharly_status_collect_timestamp_seconds %d\n", snap.At.Unix())
+	fmt.Fprintf(&b, "# HELP charly_status_collect_duration_seconds How long the cached collection took.\n# TYPE charly_status_collect_duration_seconds gauge\ncharly_status_collect_duration_seconds %s\n", strconv.FormatFloat(snap.Duration.Seconds(), 'f', 3, 64))
+	if openMetrics {
+		b.WriteString("# EOF\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/kong"
+	"github.com/overthinkos/overthink/charly/spec"
+)
+
+func TestStatusGrammar(t *testing.T) {
+	parse := func(args ...string) (string, error) {
+		var g struct {
+			Status StatusCmd `cmd:""`
+		}
+		k, err := kong.New(&g, kong.Name("charly"), kong.Exit(func(int) {}), kong.Writers(io.Discard, io.Discard))
+		if err != nil {
+			t.Fatalf("kong.New: %v", err)
+		}
+		ctx, err := k.Parse(args)
+		if err != nil {
+			return "", err
+		}
+		if ctx.Command() == "status serve" && g.Status.Serve.Listen != ":9999" {
+			t.Errorf("listen = %q", g.Status.Serve.Listen)
+		}
+		if ctx.Command() == "status show <box>" && (g.Status.Show.Box != "app" || g.Status.Show.Instance != "blue" || !g.Status.Show.JSON) {
+			t.Errorf("show = %+v", g.Status.Show)
+		}
+		return ctx.Command(), nil
+	}
+	for args, want := range map[string]string{
+		"status":                          "status show",
+		"status app -i blue --json":       "status show <box>",
+		"status serve --listen :9999":     "status serve",
+		"status serve --listen :9999 -a ": "status serve",
+	} {
+		got, err := parse(strings.Fields(args)...)
+		if err != nil || got != want {
+			t.Errorf("%q → %q, %v; want %q", args, got, err, want)
+		}
+	}
+}
+
+func TestCheckServeShadow(t *testing.T) {
+	dc := &BundleConfig{Bundle: map[string]BundleNode{"serve": {}}}
+	if err := checkServeShadow(dc, ""); err == nil || !strings.Contains(err.Error(), "charly status show serve") {
+		t.Errorf("bare serve with a deployment named serve = %v", err)
+	}
+	if err := checkServeShadow(dc, ":9470"); err != nil {
+		t.Errorf("explicit --listen rejected: %v", err)
+	}
+	if err := checkServeShadow(&BundleConfig{Bundle: map[string]BundleNode{"app": {}}}, ""); err != nil {
+		t.Errorf("no serve deployment rejected: %v", err)
+	}
+	if err := checkServeShadow(nil, ""); err != nil {
+		t.Errorf("no charly.yml rejected: %v", err)
+	}
+}
+
+func TestProbePorts(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close() //nolint:errcheck
+	open := ln.Addr().(*net.TCPAddr).Port
+	s := DeploymentStatus{Status: "running", Ports: []PortMapping{
+		{HostIP: "0.0.0.0", HostPort: open, CtrPort: 80, Proto: "tcp"},
+		{HostIP: "::", HostPort: open, CtrPort: 80, Proto: "tcp"},
+		{HostIP: "0.0.0.0", HostPort: open, CtrPort: 80, Proto: "tcp"},
+		{HostPort: 5353, CtrPort: 53, Proto: "udp"},
+	}}
+	got := probePorts(context.Background(), s)
+	if len(got) != 2 || !got[0].Reachable || got[0].Port.HostPort != open || got[1].Port.HostIP != "::" {
+		t.Errorf("probePorts = %+v", got)
+	}
+	s.Status = "stopped"
+	if got := probePorts(context.Background(), s); got != nil {
+		t.Errorf("stopped deployment probed: %+v", got)
+	}
+}
+
+func TestWriteMetrics(t *testing.T) {
+	snap := &metricsSnapshot{
+		At:       time.Unix(1792152000, 0),
+		Duration: 1500 * time.Millisecond,
+		Rows: []deployMetrics{
+			{
+				Status: DeploymentStatus{Kind: SubstratePod, Source: "podman", Image: "app", Instance: "blue", Status: "running", ImageRef: "ghcr.io/test/app:1",
+					Tools:     []ToolStatus{{Name: "cdp", Status: "ok"}, {Name: "vnc", Status: "unreachable"}},
+					Resources: &ResourceUsage{CPUPercent: 2.5, MemBytes: 1024, Restarts: 3, History: &ResourceHistory{Restarts: 2}}},
+				Ports: []portReach{
+					{Port: PortMapping{HostIP: "0.0.0.0", HostPort: 8080, Proto: "tcp"}, Reachable: true},
+					{Port: PortMapping{HostIP: "::", HostPort: 8080, Proto: "tcp"}, Reachable: false},
+				},
+				Volumes: []spec.EncVolumePlan{{Name: "data", Initialized: true}},
+			},
+			{
+				Status:    DeploymentStatus{Kind: SubstrateVM, Source: "libvirt", Image: "arch", Status: "stopped"},
+				Snapshots: map[string]int{"internal": 1, "external": 2},
+			},
+			// Two local deploys with the same candy count stay distinct series.
+			{Status: DeploymentStatus{Kind: SubstrateLocal, Source: "ledger", Image: localDeployLabel(2), Container: "dev-tools", Status: "applied"}},
+			{Status: DeploymentStatus{Kind: SubstrateLocal, Source: "ledger", Image: localDeployLabel(2), Container: "editor", Status: "applied"}},
+		},
+		Leases: &spec.PreemptLedger{Leases: []spec.PreemptLease{{
+			Claimant: "arch", Claim: spec.HolderAddr{Base: "arch", Target: "vm"}, Tokens: []string{"gpu:0"},
+			Mode: "vfio", Preempted: []spec.PreemptedHolder{{Addr: spec.HolderAddr{Name: "app/blue"}}},
+		}}},
+		Stranded: []string{"arch"},
+	}
+	c := &metricsCache{}
+	c.store(snap)
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	req.Header.Set("Accept", "application/openmetrics-text; version=1.0.0")
+	rec := httptest.NewRecorder()
+	c.ServeHTTP(rec, req)
+	out := rec.Body.String()
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/openmetrics-text") {
+		t.Errorf("content type %q", ct)
+	}
+	pod := `box="app",instance="blue",kind="pod",source="podman"`
+	vm := `box="arch",instance="",kind="vm",source="libvirt"`
+	for _, want := range []string{
+		"# TYPE charly_deployment_up gauge\n",
+		"charly_deployment_up{" + pod + "} 1\n",
+		"charly_deployment_up{" + vm + "} 0\n",
+		`charly_deployment_info{` + pod + `,status="running",image_ref="ghcr.io/test/app:1"} 1`,
+		`charly_probe_up{` + pod + `,probe="cdp"} 1`,
+		`charly_probe_up{` + pod + `,probe="vnc"} 0`,
+		`charly_port_reachable{` + pod + `,host_ip="0.0.0.0",port="8080",protocol="tcp"} 1`,
+		`charly_port_reachable{` + pod + `,host_ip="::",port="8080",protocol="tcp"} 0`,
+		`charly_encrypted_volume_mounted{` + pod + `,volume="data"} 0`,
+		`charly_encrypted_volume_initialized{` + pod + `,volume="data"} 1`,
+		`charly_deployment_up{box="dev-tools",instance="",kind="local",source="ledger"} 0`,
+		`charly_deployment_up{box="editor",instance="",kind="local",source="ledger"} 0`,
+		`charly_vm_snapshots{` + vm + `,mode="external"} 2` + "\n" + `charly_vm_snapshots{` + vm + `,mode="internal"} 1`,
+		`charly_deployment_cpu_percent{` + pod + `} 2.5`,
+		`charly_deployment_restarts_last_hour{` + pod + `} 2`,
+		`charly_preempt_lease{box="arch",instance="",kind="vm",source="arbiter",claimant="arch",tokens="gpu:0",mode="vfio",shared="false",transient="false"} 1`,
+		`charly_preempt_lease_stranded{box="arch",instance="",kind="vm",source="arbiter",claimant="arch"} 1`,
+		`charly_preempt_lease_preempted_holders{box="arch",instance="",kind="vm",source="arbiter",claimant="arch"} 1`,
+		"charly_status_collect_timestamp_seconds " + strconv.Itoa(1792152000) + "\n",
+		"charly_status_collect_duration_seconds 1.500\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("metrics lack %q", want)
+		}
+	}
+	if !strings.HasSuffix(out, "# EOF\n") {
+		t.Error("OpenMetrics output lacks # EOF")
+	}
+
+	// The Prometheus text format has no terminator; an empty cache answers 503.
+	rec = httptest.NewRecorder()
+	c.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if strings.Contains(rec.Body.String(), "# EOF") {
+		t.Error("text format carries # EOF")
+	}
+	rec = httptest.NewRecorder()
+	(&metricsCache{}).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if rec.Code != 503 {
+		t.Errorf("empty cache answered %d", rec.Code)
+	}
+	if got := metricLabel("detail", "a \"b\"\\\n"); got != `detail="a \"b\"\\\n"` {
+		t.Errorf("metricLabel = %s", got)
+	}
+}
@@ -128,28 +128,34 @@ func TransferToRootful(imageRef string) error {
 //     BuilderRun, the check preflight, and `charly box pull` all go
 //     through (see charly/ensure_image.go).
 //
-// Returns ErrImageNotLocal (wrapped with the ref) only when ALL three
-// tiers fail.
+// A present or transferred image is re-checked against its trust policy
+// (enforceLocalImageTrust), as EnsureImagePresent does for its own
+// short-circuit. Returns ErrImageNotLocal (wrapped with the ref and the
+// underlying failure, a trust rejection included) only when ALL three tiers
+// fail.
 func EnsureImage(imageRef string, rt *ResolvedRuntime) error {
+	// Loads the project cfg if cwd has one; gracefully degrades to
+	// pull-only (and the default-less trust policy) when no project is
+	// reachable.
+	cfg, projectDir := loadProjectCfgFromCwd()
 	if LocalImageExists(rt.RunEngine, imageRef) {
-		return nil
+		return enforceLocalImageTrust(imageRef, cfg, projectDir)
 	}
 
 	// Cross-engine transfer first when applicable: it's faster than a
 	// network pull and works offline.
 	if rt.BuildEngine != rt.RunEngine && LocalImageExists(rt.BuildEngine, imageRef) {
-		return TransferImage(rt.BuildEngine, rt.RunEngine, imageRef)
+		if err := TransferImage(rt.BuildEngine, rt.RunEngine, imageRef); err != nil {
+			return err
+		}
+		return enforceLocalImageTrust(imageRef, cfg, projectDir)
 	}
 
-	// Generic ensure: pull, fall back to local build for project
-	// images. Loads the project cfg if cwd has one; gracefully
-	// degrades to pull-only when no project is reachable.
-	cfg, projectDir := loadProjectCfgFromCwd()
-	if err := EnsureImagePresent(context.Background(), imageRef, cfg, projectDir); err == nil {
-		return nil
+	// Generic ensure: pull, fall back to local build for project images.
+	if err := EnsureImagePresent(context.Background(), imageRef, cfg, projectDir); err != nil {
+		return fmt.Errorf("%w: %s: %w", ErrImageNotLocal, imageRef, err)
 	}
-
-	return fmt.Errorf("%w: %s", ErrImageNotLocal, imageRef)
+	return nil
 }
 
 // loadProjectCfgFromCwd returns the project config + dir when the
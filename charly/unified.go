@@ -1809,6 +1809,12 @@ func mergeBoxConfig(dst, src *BoxConfig) {
 	if dst.KeepCheckRuns == nil {
 		dst.KeepCheckRuns = src.KeepCheckRuns
 	}
+	if dst.Trust == nil {
+		dst.Trust = src.Trust
+	}
+	if dst.Audit == nil {
+		dst.Audit = src.Audit
+	}
 }
 
 // -----------------------------------------------------------------------------
@@ -2181,6 +2187,7 @@ func synthesizeInlineCandy(name string, il *InlineCandy, rootDir string) *Candy
 	layer.HasEnvironmentYml = fileExists(filepath.Join(layer.SourceDir, "environment.yml"))
 	layer.HasPackageJson = fileExists(filepath.Join(layer.SourceDir, "package.json"))
 	layer.HasCargoToml = fileExists(filepath.Join(layer.SourceDir, "Cargo.toml"))
+	layer.HasGoMod = fileExists(filepath.Join(layer.SourceDir, "go.mod"))
 	layer.HasSrcDir = dirExists(filepath.Join(layer.SourceDir, "src"))
 	layer.HasPixiLock = fileExists(filepath.Join(layer.SourceDir, "pixi.lock"))
 	svcFiles, _ := filepath.Glob(filepath.Join(layer.SourceDir, "*.service"))
@@ -2290,6 +2297,8 @@ func populateCandyFromYAML(layer *Candy, ly *CandyYAML) {
 	layer.apk = ly.Apk
 	layer.localpkg = ly.LocalPkg
 	layer.reboot = ly.Reboot
+	layer.goBuild = ly.Go
+	layer.supportedArch = ly.SupportedArch
 	layer.ExternalBuilder = ly.ExternalBuilder
 	layer.shell = ly.Shell
 }
@@ -21,6 +21,7 @@ import (
 	"os"
 	"regexp"
 	"strings"
+	"time"
 )
 
 // dispatchByDeployTarget resolves c.Box as a charly.yml entry and
@@ -67,6 +68,18 @@ func (c *UpdateCmd) dispatchByDeployTarget() error {
 		return err
 	}
 
+	if c.Undo {
+		if node.Target != "" && node.Target != "pod" && node.Target != "container" {
+			return fmt.Errorf("charly update --undo %s: only pod deployments record their pre-update state (target %s)", c.Box, node.Target)
+		}
+		st, stDir, err := loadUpdateState(c.Box, c.Instance)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Restoring %s to its state before the update of %s\n", deployKey(c.Box, c.Instance), st.Created.Local().Format(time.DateTime))
+		return restoreUpdateState(st, stDir)
+	}
+
 	// `charly update` obeys an EXPLICIT invocation on ANY target — the tool is
 	// fully capable; the disposable-only constraint is a discipline on the AI's
 	// AUTONOMOUS action (CLAUDE.md R10 + /charly-internals:disposable) and on the
@@ -109,7 +122,47 @@ func (c *UpdateCmd) dispatchByDeployTarget() error {
 			"(k8s is applied out-of-band via `kubectl apply -k` on the rendered Kustomize overlay)",
 			deployName, node.Target)
 	}
-	return lt.Rebuild(context.Background(), RebuildOpts{RebuildImage: c.Build})
+
+	// Record the pre-update state (pod deploys) so a failed update can be
+	// rolled back now (--rollback) or undone later (--undo).
+	var state *updateState
+	if node.Target == "pod" {
+		state, err = recordUpdateState(deployName, c.Instance, c.Rollback)
+		if err != nil {
+			if c.Rollback {
+				return fmt.Errorf("recording the pre-update state of %s: %w", deployName, err)
+			}
+			fmt.Fprintf(os.Stderr, "Warning: recording the pre-update state of %s: %v (--undo unavailable)\n", deployName, err)
+		}
+	} else if c.Rollback {
+		return fmt.Errorf("charly update --rollback %s: only pod deployments can be rolled back (target %s); use --verify", deployName, node.Target)
+	}
+
+	if err := lt.Rebuild(context.Background(), RebuildOpts{RebuildImage: c.Build}); err != nil {
+		if c.Rollback {
+			return rollbackUpdate(state, err.Error(), 0)
+		}
+		return err
+	}
+	if !c.Verify && !c.Rollback {
+		return nil
+	}
+	failed, err := verifyDeployment(deployName, c.Instance)
+	if err != nil {
+		if c.Rollback {
+			return rollbackUpdate(state, err.Error(), 0)
+		}
+		return err
+	}
+	if len(failed) == 0 {
+		fmt.Fprintf(os.Stderr, "Post-update checks of %s passed\n", deployKey(deployName, c.Instance))
+		return nil
+	}
+	why := fmt.Sprintf("%d post-update check(s) failed: %s", len(failed), strings.Join(failed, "; "))
+	if c.Rollback {
+		return rollbackUpdate(state, why, len(failed))
+	}
+	return &CheckFailedError{Failed: len(failed), Msg: fmt.Sprintf("update of %s: %s", deployKey(deployName, c.Instance), why)}
 }
 
 // quadletImageLineRe matches the `Image=<value>` directive on its own
@@ -138,6 +191,23 @@ func extractQuadletImageLine(path string) (string, error) {
 	return strings.TrimPrefix(m, "Image="), nil
 }
 
+// extractQuadletCharlyBin returns the charly binary a quadlet's encrypted-mount
+// ExecStartPre line runs ("ExecStartPre=<bin> config mount <box>"), or "".
+func extractQuadletCharlyBin(path string) string {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	for line := range strings.SplitSeq(string(content), "\n") {
+		if rest, ok := strings.CutPrefix(line, "ExecStartPre="); ok {
+			if bin, _, found := strings.Cut(rest, " config mount "); found {
+				return bin
+			}
+		}
+	}
+	return ""
+}
+
 // noteUpdateDisposability prints a one-line transparency note when an EXPLICIT
 // `charly update` targets a deploy that is NOT marked `disposable: true` (and not
 // ephemeral — see IsDisposable() for the implication chain). It NEVER refuses:
@@ -0,0 +1,356 @@
+package main
+
+// update_rollback.go — the health gate and the undo record of `charly update`.
+//
+// Before a pod deployment is updated, its pre-update state is recorded under
+// ~/.local/share/charly/update/<deploy>[-<instance>]/: the quadlet units it
+// owns (app .container, .pod, attached sidecar .container files), the image
+// refs those units run (app and sidecars) with the image IDs they resolved to,
+// and — with --rollback — a `charly volume backup` of its named and encrypted
+// volumes. One record per deployment; each update replaces it.
+//
+// --verify runs the deployment's baked runtime-context checks after the update
+// (`charly check live`, the same plan, as a child so its report streams as TAP
+// and the failing steps can be named). --rollback additionally restores the
+// record when the update or any check fails: verify the record is complete
+// (units, images, volume checksums) before touching anything, then stop,
+// unmount encrypted volumes, re-tag each image ref onto its recorded image ID,
+// put the recorded units back (removing units the update added), restore the
+// volume snapshot, start. `charly update --undo` applies the same restore
+// later, without updating.
+//
+// VM and local deploys rebuild their substrate in place (the VM domain is
+// destroyed and recreated), so --verify applies to every target while the
+// recorded state — and with it --rollback / --undo — is pod-only.
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// updateStateFile is the record's name inside its directory.
+const updateStateFile = "state.json"
+
+// updateState is the recorded pre-update state of one pod deployment.
+type updateState struct {
+	Deploy   string          `json:"deploy"`
+	Instance string          `json:"instance,omitempty"`
+	Engine   string          `json:"engine"`
+	Images   []recordedImage `json:"images,omitempty"`  // app unit's image first, then the sidecars'
+	Units    []string        `json:"units,omitempty"`   // quadlet files saved under units/
+	Volumes  bool            `json:"volumes,omitempty"` // volumes/ holds a `charly volume backup`
+	Created  time.Time       `json:"created"`
+}
+
+// recordedImage is an image ref a deployment ran and the image ID it resolved
+// to before the update.
+type recordedImage struct {
+	Ref string `json:"ref"`
+	ID  string `json:"id,omitempty"`
+}
+
+// updateStateDir returns the record directory of a deployment.
+func updateStateDir(deploy, instance string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("determining home directory: %w", err)
+	}
+	return filepath.Join(home, ".local", "share", "charly", "update", deployStorageDir(deploy, instance)), nil
+}
+
+// podDeployUnitFiles returns the quadlet files a pod deployment owns that
+// exist in qdir — exact names, as `charly remove` deletes them.
+func podDeployUnitFiles(qdir, deploy, instance string) []string {
+	names := []string{quadletFilenameInstance(deploy, instance), podQuadletFilenameInstance(deploy, instance)}
+	for _, sc := range resolveSidecarNames(deploy, instance) {
+		names = append(names, PodNameInstance(deploy, instance)+"-"+sc+".container")
+	}
+	var out []string
+	for _, n := range names {
+		if _, err := os.Stat(filepath.Join(qdir, n)); err == nil {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// recordUpdateState captures the pre-update state of a pod deployment,
+// replacing its previous record only once the new one is complete.
+func recordUpdateState(deploy, instance string, volumes bool) (*updateState, error) {
+	rt, err := ResolveRuntime()
+	if err != nil {
+		return nil, err
+	}
+	dir, err := updateStateDir(deploy, instance)
+	if err != nil {
+		return nil, err
+	}
+	tmp := dir + ".new"
+	if err := os.RemoveAll(tmp); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Join(tmp, "units"), 0o700); err != nil {
+		return nil, fmt.Errorf("creating %s: %w", tmp, err)
+	}
+	st := &updateState{
+		Deploy:   deploy,
+		Instance: instance,
+		Engine:   ResolveBoxEngineForDeploy(deploy, instance, rt.RunEngine),
+		Created:  time.Now().UTC(),
+	}
+	if qdir, err := quadletDir(); err == nil {
+		for _, unit := range podDeployUnitFiles(qdir, deploy, instance) {
+			data, err := os.ReadFile(filepath.Join(qdir, unit))
+			if err != nil {
+				return nil, err
+			}
+			if err := os.WriteFile(filepath.Join(tmp, "units", unit), data, 0o600); err != nil {
+				return nil, err
+			}
+			st.Units = append(st.Units, unit)
+		}
+		for _, ref := range unitImageRefs(qdir, st.Units) {
+			st.Images = append(st.Images, recordedImage{Ref: ref})
+		}
+	}
+	if len(st.Images) == 0 {
+		if ref, _ := containerImageRef(st.Engine, containerNameInstance(deploy, instance)); ref != "" {
+			st.Images = append(st.Images, recordedImage{Ref: ref})
+		}
+	}
+	for i := range st.Images {
+		st.Images[i].ID = engineImageID(st.Engine, st.Images[i].Ref)
+	}
+	if volumes {
+		backup := &VolumeBackupCmd{Box: deploy, Instance: instance, Output: filepath.Join(tmp, "volumes")}
+		switch err := backup.Run(); {
+		case err == nil:
+			st.Volumes = true
+		case errors.Is(err, errNoDeployVolumes):
+		default:
+			return nil, fmt.Errorf("volume snapshot: %w", err)
+		}
+	}
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(tmp, updateStateFile), append(data, '\n'), 0o600); err != nil {
+		return nil, err
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp, dir); err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+// unitImageRefs returns the distinct Image= refs of the .container units in
+// qdir, in unit order (the app unit comes first in podDeployUnitFiles).
+func unitImageRefs(qdir string, units []string) []string {
+	var refs []string
+	for _, unit := range units {
+		if !strings.HasSuffix(unit, ".container") {
+			continue
+		}
+		if ref, _ := extractQuadletImageLine(filepath.Join(qdir, unit)); ref != "" && !slices.Contains(refs, ref) {
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
+// loadUpdateState reads the recorded state of a deployment.
+func loadUpdateState(deploy, instance string) (*updateState, string, error) {
+	dir, err := updateStateDir(deploy, instance)
+	if err != nil {
+		return nil, "", err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, updateStateFile))
+	if os.IsNotExist(err) {
+		return nil, "", fmt.Errorf("no pre-update state recorded for %s (it is recorded by `charly update` of a pod deployment)", deployKey(deploy, instance))
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	st := &updateState{}
+	if err := json.Unmarshal(data, st); err != nil {
+		return nil, "", fmt.Errorf("%s: %w", filepath.Join(dir, updateStateFile), err)
+	}
+	return st, dir, nil
+}
+
+// restoreUpdateState puts a deployment back into its recorded state. The
+// whole record is checked first, so a restore that cannot complete fails
+// before the deployment is stopped or anything is rewritten.
+func restoreUpdateState(st *updateState, dir string) error {
+	if err := checkUpdateState(st, dir); err != nil {
+		return err
+	}
+	qdir, err := quadletDir()
+	if err != nil {
+		return err
+	}
+	_ = stopPodService(st.Deploy, st.Instance)
+	if st.Volumes {
+		// The volume restore refuses while encrypted volumes are mounted —
+		// tear them down the way `charly stop --unmount` does.
+		if err := encUnmount(st.Deploy, st.Instance, ""); err != nil {
+			return fmt.Errorf("unmounting encrypted volumes: %w", err)
+		}
+	}
+	for _, img := range st.Images {
+		if img.ID == "" || engineImageID(st.Engine, img.Ref) == img.ID {
+			continue
+		}
+		out, err := exec.Command(EngineBinary(st.Engine), "tag", img.ID, img.Ref).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("re-tagging %s onto %s: %w\n%s", img.Ref, shortImageID(img.ID), err, strings.TrimSpace(string(out)))
+		}
+		fmt.Fprintf(os.Stderr, "Restored %s → %s\n", img.Ref, shortImageID(img.ID))
+	}
+	if current := podDeployUnitFiles(qdir, st.Deploy, st.Instance); len(current) > 0 || len(st.Units) > 0 {
+		for _, unit := range current {
+			if !slices.Contains(st.Units, unit) {
+				if err := os.Remove(filepath.Join(qdir, unit)); err != nil {
+					return err
+				}
+				fmt.Fprintf(os.Stderr, "Removed %s\n", filepath.Join(qdir, unit))
+			}
+		}
+		for _, unit := range st.Units {
+			data, err := os.ReadFile(filepath.Join(dir, "units", unit))
+			if err != nil {
+				return err
+			}
+			if err := os.WriteFile(filepath.Join(qdir, unit), data, 0o644); err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "Restored %s\n", filepath.Join(qdir, unit))
+		}
+		if out, err := exec.Command("systemctl", "--user", "daemon-reload").CombinedOutput(); err != nil {
+			return fmt.Errorf("systemctl --user daemon-reload: %w\n%s", err, strings.TrimSpace(string(out)))
+		}
+	}
+	if st.Volumes {
+		restore := &VolumeRestoreCmd{Box: st.Deploy, Instance: st.Instance, Dir: filepath.Join(dir, "volumes")}
+		if err := restore.Run(); err != nil {
+			return fmt.Errorf("restoring the volume snapshot: %w", err)
+		}
+		if err := ensureEncryptedMounts(st.Deploy, st.Instance, false); err != nil {
+			return err
+		}
+	}
+	return startPodService(st.Deploy, st.Instance)
+}
+
+// checkUpdateState verifies that everything a restore needs is present: the
+// saved units, the recorded image IDs in the engine's store, and the volume
+// snapshot's archives against their manifest checksums.
+func checkUpdateState(st *updateState, dir string) error {
+	var bad []string
+	for _, unit := range st.Units {
+		if _, err := os.Stat(filepath.Join(dir, "units", unit)); err != nil {
+			bad = append(bad, fmt.Sprintf("unit %s: %v", unit, err))
+		}
+	}
+	for _, img := range st.Images {
+		if img.ID != "" && engineImageID(st.Engine, img.ID) == "" {
+			bad = append(bad, fmt.Sprintf("image %s: %s is no longer in the %s store", img.Ref, shortImageID(img.ID), st.Engine))
+		}
+	}
+	if st.Volumes {
+		vdir := filepath.Join(dir, "volumes")
+		m, err := readVolumeBackupManifest(vdir)
+		if err == nil {
+			err = verifyVolumeBackup(vdir, m.Volumes)
+		}
+		if err != nil {
+			bad = append(bad, fmt.Sprintf("volume snapshot: %v", err))
+		}
+	}
+	if len(bad) > 0 {
+		return fmt.Errorf("pre-update record of %s is incomplete — nothing restored:\n  %s", deployKey(st.Deploy, st.Instance), strings.Join(bad, "\n  "))
+	}
+	return nil
+}
+
+// engineImageID returns the image ID ref resolves to, or "".
+func engineImageID(engine, ref string) string {
+	out, err := exec.Command(EngineBinary(engine), "image", "inspect", "--format", "{{.Id}}", ref).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func shortImageID(id string) string {
+	id = strings.TrimPrefix(id, "sha256:")
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
+
+// verifyDeployment runs `charly check live` against a deployment and returns
+// the failing steps. An error means the checks could not run at all.
+func verifyDeployment(deploy, instance string) ([]string, error) {
+	args := []string{"check", "live", deploy, "--format", "tap"}
+	if instance != "" {
+		args = append(args, "-i", instance)
+	}
+	out, err := runCharlySubcommandCapture(args...)
+	fmt.Fprint(os.Stderr, out)
+	failed := tapFailures(out)
+	if err != nil && len(failed) == 0 {
+		return nil, fmt.Errorf("charly check live %s: %w", deployKey(deploy, instance), err)
+	}
+	return failed, nil
+}
+
+// tapFailures returns the descriptions of the `not ok` points of TAP output.
+func tapFailures(out string) []string {
+	var failed []string
+	sc := bufio.NewScanner(strings.NewReader(out))
+	for sc.Scan() {
+		rest, ok := strings.CutPrefix(sc.Text(), "not ok ")
+		if !ok {
+			continue
+		}
+		if _, desc, ok := strings.Cut(rest, " - "); ok {
+			failed = append(failed, strings.TrimSpace(desc))
+		} else {
+			failed = append(failed, strings.TrimSpace(rest))
+		}
+	}
+	return failed
+}
+
+// rollbackUpdate restores the recorded state after a failed update and
+// returns the error that reports why — a CheckFailedError when failed
+// post-update checks caused it.
+func rollbackUpdate(st *updateState, why string, failedChecks int) error {
+	dir, err := updateStateDir(st.Deploy, st.Instance)
+	if err != nil {
+		return err
+	}
+	key := deployKey(st.Deploy, st.Instance)
+	fmt.Fprintf(os.Stderr, "Rolling back %s: %s\n", key, why)
+	if err := restoreUpdateState(st, dir); err != nil {
+		return fmt.Errorf("update of %s failed (%s) and the rollback failed: %w", key, why, err)
+	}
+	if failedChecks > 0 {
+		return &CheckFailedError{Failed: failedChecks, Msg: fmt.Sprintf("update of %s rolled back: %s", key, why)}
+	}
+	return fmt.Errorf("update of %s rolled back: %s", key, why)
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTapFailures(t *testing.T) {
+	out := `Image: app (container: charly-app)
+TAP version 13
+1..3
+ok 1 - check the web server answers
+not ok 2 - check the database port is listening
+  ---
+  message: "port 5432 closed"
+  ...
+not ok 3 - check the health endpoint returns 200
+`
+	want := []string{"check the database port is listening", "check the health endpoint returns 200"}
+	if got := tapFailures(out); !reflect.DeepEqual(got, want) {
+		t.Errorf("tapFailures = %q, want %q", got, want)
+	}
+	if got := tapFailures("ok 1 - fine\n"); len(got) != 0 {
+		t.Errorf("passing run reported %q", got)
+	}
+}
+
+func TestPodDeployUnitFiles(t *testing.T) {
+	qdir := t.TempDir()
+	for _, f := range []string{"charly-app-blue.container", "charly-app-blue.pod", "charly-app-blue-extra.container", "charly-app.container"} {
+		if err := os.WriteFile(filepath.Join(qdir, f), []byte("[Container]\nImage=ghcr.io/test/app:1\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Exact names only: neither the un-instanced deploy's unit nor an
+	// unattached sidecar-looking file belongs to app/blue.
+	got := podDeployUnitFiles(qdir, "app", "blue")
+	want := []string{"charly-app-blue.container", "charly-app-blue.pod"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("podDeployUnitFiles = %q, want %q", got, want)
+	}
+}
+
+func TestLoadUpdateStateMissing(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	_, _, err := loadUpdateState("app", "blue")
+	if err == nil || !strings.Contains(err.Error(), "no pre-update state recorded for app/blue") {
+		t.Errorf("err = %v", err)
+	}
+	dir, err := updateStateDir("app", "blue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, updateStateFile), []byte(`{"deploy": "app", "instance": "blue", "images": [{"ref": "ghcr.io/test/app:1"}], "units": ["charly-app-blue.container"]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	st, gotDir, err := loadUpdateState("app", "blue")
+	if err != nil || gotDir != dir || len(st.Images) != 1 || st.Images[0].Ref != "ghcr.io/test/app:1" || len(st.Units) != 1 {
+		t.Errorf("loadUpdateState = %+v, %s, %v", st, gotDir, err)
+	}
+}
+
+// Every .container unit's image is recorded — sidecars too — app first and
+// without duplicates; the .pod unit has none.
+func TestUnitImageRefs(t *testing.T) {
+	qdir := t.TempDir()
+	for name, image := range map[string]string{
+		"charly-app.container":           "ghcr.io/test/app:1",
+		"charly-app-tailscale.container": "docker.io/tailscale/tailscale:v1.80",
+		"charly-app-relay.container":     "ghcr.io/test/app:1",
+	} {
+		mustWrite(t, filepath.Join(qdir, name), "[Container]\nImage="+image+"\n")
+	}
+	mustWrite(t, filepath.Join(qdir, "charly-app.pod"), "[Pod]\n")
+	got := unitImageRefs(qdir, []string{"charly-app.container", "charly-app.pod", "charly-app-relay.container", "charly-app-tailscale.container"})
+	want := []string{"ghcr.io/test/app:1", "docker.io/tailscale/tailscale:v1.80"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unitImageRefs = %q, want %q", got, want)
+	}
+}
+
+// A record with a missing unit or a damaged volume snapshot is refused as a
+// whole before the restore changes anything.
+func TestCheckUpdateState(t *testing.T) {
+	dir := t.TempDir()
+	mustMkdir(t, filepath.Join(dir, "units"))
+	mustWrite(t, filepath.Join(dir, "units", "charly-app.container"), "[Container]\n")
+	vdir := filepath.Join(dir, "volumes")
+	mustMkdir(t, vdir)
+	e, err := writeVolumeArchive(vdir, "data.tar.gz", func(w io.Writer) error {
+		_, err := w.Write([]byte("tar bytes"))
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Name, e.Kind = "data", "volume"
+	if err := writeVolumeBackupManifest(vdir, &VolumeBackupManifest{Box: "app", Volumes: []VolumeBackupEntry{e}}); err != nil {
+		t.Fatal(err)
+	}
+
+	st := &updateState{Deploy: "app", Engine: "podman", Units: []string{"charly-app.container"}, Volumes: true}
+	if err := checkUpdateState(st, dir); err != nil {
+		t.Fatalf("complete record: %v", err)
+	}
+
+	st.Units = append(st.Units, "charly-app.pod")
+	mustWrite(t, filepath.Join(vdir, "data.tar.gz"), "truncated")
+	err = checkUpdateState(st, dir)
+	if err == nil {
+		t.Fatal("expected the incomplete record to be refused")
+	}
+	for _, want := range []string{"unit charly-app.pod", "volume snapshot", "checksum mismatch", "nothing restored"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error missing %q:\n%v", want, err)
+		}
+	}
+}
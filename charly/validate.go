@@ -10,6 +10,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"cuelang.org/go/cue"
 	"gopkg.in/yaml.v3"
@@ -281,6 +282,9 @@ func Validate(cfg *Config, layers map[string]*Candy, dir string, opts ResolveOpt
 	// Validate no circular dependencies in images
 	validateBoxDAG(cfg, layers, dir, opts, errs)
 
+	// Validate every box's candies and distro support its target platforms
+	validateBoxPlatforms(cfg, layers, dir, opts, errs)
+
 	// Validate volumes
 	validateVolume(layers, errs)
 
@@ -290,6 +294,12 @@ func Validate(cfg *Config, layers map[string]*Candy, dir string, opts ResolveOpt
 	// Validate build-speed tunables (defaults.jobs / podman_jobs / cache / …)
 	validateBuildTunables(cfg, errs)
 
+	// Validate image trust policies (defaults.trust / box trust)
+	validateTrustPolicy(cfg, errs)
+
+	// Validate vulnerability-audit settings (defaults.audit / box audit)
+	validateAuditPolicy(cfg, errs)
+
 	// Validate aliases
 	validateAliases(cfg, layers, errs)
 
@@ -355,6 +365,9 @@ func Validate(cfg *Config, layers map[string]*Candy, dir string, opts ResolveOpt
 	// Validate every Op embedded in a candy/box plan step.
 	validateOps(cfg, layers, errs)
 
+	// Warn when charly.lock no longer matches the candy lists.
+	validateBoxLock(cfg, layers, dir, opts)
+
 	// Validate kind:local templates and target:local deployments.
 	validateLocalTemplates(dir, layers, errs)
 	validateLocalDeployments(dir, errs)
@@ -685,7 +698,7 @@ func validateCandyContents(layers map[string]*Candy, errs *ValidationError) {
 		// it builds + installs on a deploy target (LocalPkgInstallStep) / downloads at
 		// image build — so all legitimately ship no install files.
 		if !layer.HasInstallFiles() && len(layer.IncludedCandy) == 0 && !layer.HasData() && layer.Plugin == nil && layer.ExternalBuilder == "" && len(layer.LocalPkgFormats()) == 0 {
-			errs.Add("candy %q: must have at least one install file (candy manifest distro: packages, root.yml, pixi.toml, pyproject.toml, environment.yml, package.json, Cargo.toml, or user.yml), a candy: field, a localpkg:, an external_builder:, or a plugin: block", name)
+			errs.Add("candy %q: must have at least one install file (candy manifest distro: packages, root.yml, pixi.toml, pyproject.toml, environment.yml, package.json, Cargo.toml, go.mod, or user.yml), a candy: field, a localpkg:, an external_builder:, or a plugin: block", name)
 		}
 
 		// version: (mandatory CalVer) and status: (working|testing|broken enum)
@@ -736,6 +749,14 @@ func validateCandyContents(layers map[string]*Candy, errs *ValidationError) {
 			errs.Add("candy %q: Cargo.toml requires src/ directory", name)
 		}
 
+		// go: only tunes the go builder, which go.mod selects
+		if layer.goBuild != nil && !layer.HasGoMod {
+			errs.Add("candy %q: go: requires a go.mod in the candy directory", name)
+		}
+		if layer.goBuild != nil && layer.Plugin != nil {
+			errs.Add("candy %q: go: does not apply to a plugin: candy (its provider binary is built by the plugin machinery)", name)
+		}
+
 		// Validate depends references. Remote candies' deps were qualified to
 		// fully-qualified map keys at scan time (qualifyRemoteSiblingDeps), so a
 		// direct lookup covers both local short names and remote sibling refs.
@@ -1047,6 +1068,56 @@ func validateMergeConfig(cfg *Config, errs *ValidationError) {
 	}
 }
 
+// validateTrustPolicy checks the trust: blocks on defaults: and every box: a
+// known policy, and keys whenever the policy verifies anything.
+func validateTrustPolicy(cfg *Config, errs *ValidationError) {
+	check := func(name string, t *TrustConfig) {
+		if t == nil {
+			return
+		}
+		switch t.Policy {
+		case "", trustOff:
+		case trustWarn, trustEnforce:
+			if len(t.Key) == 0 {
+				errs.Add("%s: trust.policy %s needs at least one trust.key", name, t.Policy)
+			}
+		default:
+			errs.Add("%s: trust.policy must be one of off|warn|enforce, got %q", name, t.Policy)
+		}
+	}
+	check("defaults", cfg.Defaults.Trust)
+	for _, name := range cfg.BoxNames() {
+		check(name, cfg.Box[name].Trust)
+	}
+}
+
+// validateAuditPolicy checks the audit: blocks on defaults: and every box: a
+// known fail_on severity and ignore entries with an id and a YYYY-MM-DD until.
+func validateAuditPolicy(cfg *Config, errs *ValidationError) {
+	check := func(name string, a *AuditConfig) {
+		if a == nil {
+			return
+		}
+		if a.FailOn != "" && a.FailOn != "none" && severityRank(a.FailOn) < 1 {
+			errs.Add("%s: audit.fail_on must be one of none|low|medium|high|critical, got %q", name, a.FailOn)
+		}
+		for i, ig := range a.Ignore {
+			if ig.Id == "" {
+				errs.Add("%s: audit.ignore[%d] needs an id", name, i)
+			}
+			if ig.Until != "" {
+				if _, err := time.Parse(time.DateOnly, ig.Until); err != nil {
+					errs.Add("%s: audit.ignore[%d] (%s): until must be a YYYY-MM-DD date, got %q", name, i, ig.Id, ig.Until)
+				}
+			}
+		}
+	}
+	check("defaults", cfg.Defaults.Audit)
+	for _, name := range cfg.BoxNames() {
+		check(name, cfg.Box[name].Audit)
+	}
+}
+
 // validBuildCacheModes is the allow-list for defaults.cache / image.cache.
 // Empty string means "auto" (resolved at build time in cacheArgs).
 var validBuildCacheModes = map[string]bool{
@@ -1634,6 +1705,8 @@ func candyHasFile(layer *Candy, filename string) bool {
 		return layer.HasPackageJson
 	case "Cargo.toml":
 		return layer.HasCargoToml
+	case "go.mod":
+		return layer.GoBuildable()
 	default:
 		return fileExists(filepath.Join(layer.SourceDir, filename))
 	}
@@ -36,6 +36,7 @@ func (c *VmSnapshotCreateCmd) Run() error {
 		Description: c.Description,
 		Quiesce:     c.Quiesce,
 	})
+	recordSnapshotEvent(c.Vm, c.Name, "create", err)
 	if err != nil {
 		return err
 	}
@@ -84,11 +85,13 @@ type VmSnapshotDeleteCmd struct {
 }
 
 func (c *VmSnapshotDeleteCmd) Run() error {
-	if err := DeleteSnapshot(SnapshotDeleteOpts{
+	err := DeleteSnapshot(SnapshotDeleteOpts{
 		VmName:   c.Vm,
 		SnapName: c.Name,
 		Force:    c.Force,
-	}); err != nil {
+	})
+	recordSnapshotEvent(c.Vm, c.Name, "delete", err)
+	if err != nil {
 		return err
 	}
 	fmt.Printf("deleted snapshot %q on vm %q\n", c.Name, c.Vm)
@@ -102,7 +105,9 @@ type VmSnapshotRevertCmd struct {
 }
 
 func (c *VmSnapshotRevertCmd) Run() error {
-	if err := RevertSnapshot(c.Vm, c.Name); err != nil {
+	err := RevertSnapshot(c.Vm, c.Name)
+	recordSnapshotEvent(c.Vm, c.Name, "revert", err)
+	if err != nil {
 		return err
 	}
 	fmt.Printf("reverted vm %q to snapshot %q\n", c.Vm, c.Name)
@@ -117,6 +122,7 @@ type VmSnapshotPromoteCmd struct {
 
 func (c *VmSnapshotPromoteCmd) Run() error {
 	entry, err := PromoteSnapshot(c.Vm, c.Name)
+	recordSnapshotEvent(c.Vm, c.Name, "promote", err)
 	if err != nil {
 		return err
 	}
@@ -126,3 +132,9 @@ func (c *VmSnapshotPromoteCmd) Run() error {
 	}
 	return nil
 }
+
+// recordSnapshotEvent journals one snapshot operation as a vm.snapshot event.
+func recordSnapshotEvent(vm, snapshot, action string, err error) {
+	recordOutcome(Event{Type: EventVmSnapshot, Deploy: vm, Kind: SubstrateVM,
+		Detail: map[string]string{"action": action, "snapshot": snapshot}}, err)
+}
@@ -159,18 +159,20 @@ func (hd *HostDistro) PrimaryTag() string {
 // values; the embedded distro: vocabulary's distro key (fedora/debian/arch) is
 // the same token, so a resolved DistroDef name resolves here too.
 var distroIDToFormat = map[string]string{
-	"fedora":      "rpm",
-	"rhel":        "rpm",
-	"centos":      "rpm",
-	"rocky":       "rpm",
-	"almalinux":   "rpm",
-	"debian":      "deb",
-	"ubuntu":      "deb",
-	"arch":        "pac",
-	"archarm":     "pac",
-	"manjaro":     "pac",
-	"endeavouros": "pac",
-	"cachyos":     "pac",
+	"fedora":       "rpm",
+	"rhel":         "rpm",
+	"centos":       "rpm",
+	"rocky":        "rpm",
+	"almalinux":    "rpm",
+	"debian":       "deb",
+	"ubuntu":       "deb",
+	"arch":         "pac",
+	"archarm":      "pac",
+	"manjaro":      "pac",
+	"endeavouros":  "pac",
+	"cachyos":      "pac",
+	"alpine":       "apk",
+	"postmarketos": "apk",
 }
 
 // formatForDistroID maps an /etc/os-release-style distro ID (or an embedded
@@ -178,7 +180,7 @@ var distroIDToFormat = map[string]string{
 // table. Returns "" for an unknown ID.
 func formatForDistroID(id string) string { return distroIDToFormat[id] }
 
-// FormatHint returns the best-guess format name (rpm/deb/pac) based on the
+// FormatHint returns the best-guess format name (rpm/deb/pac/apk) based on the
 // host distro's ID / ID_LIKE, via the single distroIDToFormat table. Used when
 // the caller has no DistroDef in hand (e.g. the synthetic host-adhoc
 // image). For a resolved DistroDef, prefer DistroDef.PrimaryFormat.
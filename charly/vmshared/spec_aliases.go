@@ -23,6 +23,8 @@ type (
 	AliasYAML                = spec.AliasYAML
 	AndroidSpec              = spec.AndroidSpec
 	ApkPackageSpec           = spec.ApkPackageSpec
+	AuditConfig              = spec.AuditConfig
+	AuditIgnoreConfig        = spec.AuditIgnoreConfig
 	BoxConfig                = spec.BoxConfig
 	BuilderDef               = spec.BuilderDef
 	BundleNode               = spec.BundleNode
@@ -47,6 +49,7 @@ type (
 	EphemeralRuntime         = spec.EphemeralRuntime
 	ExtractYAML              = spec.ExtractYAML
 	FormatRule               = spec.FormatRule
+	GoBuildConfig            = spec.GoBuildConfig
 	GpuSelector              = spec.GpuSelector
 	HooksConfig              = spec.HooksConfig
 	InitDef                  = spec.InitDef
@@ -150,6 +153,7 @@ type (
 	TargetInstance           = spec.TargetInstance
 	TargetSequence           = spec.TargetSequence
 	TargetSpec               = spec.TargetSpec
+	TrustConfig              = spec.TrustConfig
 	TunnelYAML               = spec.TunnelYAML
 	VmCharlyInstall          = spec.VmCharlyInstall
 	VmChecksum               = spec.VmChecksum
@@ -14,6 +14,8 @@ type (
 	AliasConfig              = vmshared.AliasConfig
 	AliasYAML                = vmshared.AliasYAML
 	AlpineBootstrapDef       = vmshared.AlpineBootstrapDef
+	AuditConfig              = vmshared.AuditConfig
+	AuditIgnoreConfig        = vmshared.AuditIgnoreConfig
 	AndroidAdbEndpoint       = vmshared.AndroidAdbEndpoint
 	AndroidGoogleAccount     = vmshared.AndroidGoogleAccount
 	AndroidSpec              = vmshared.AndroidSpec
@@ -48,6 +50,7 @@ type (
 	EphemeralRuntime         = vmshared.EphemeralRuntime
 	ExtractYAML              = vmshared.ExtractYAML
 	FormatDef                = vmshared.FormatDef
+	GoBuildConfig            = vmshared.GoBuildConfig
 	GpuSelector              = vmshared.GpuSelector
 	HooksConfig              = vmshared.HooksConfig
 	HostDistro               = vmshared.HostDistro
@@ -99,6 +102,7 @@ type (
 	SSHTunnel                = vmshared.SSHTunnel
 	Step                     = vmshared.Step
 	StepKeyword              = vmshared.StepKeyword
+	TrustConfig              = vmshared.TrustConfig
 	TunnelYAML               = vmshared.TunnelYAML
 	VmCharlyInstall          = vmshared.VmCharlyInstall
 	VmCloudInit              = vmshared.VmCloudInit
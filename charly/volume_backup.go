@@ -0,0 +1,663 @@
+package main
+
+// volume_backup.go — `charly volume backup` / `charly volume restore`: a
+// checksummed, compressed snapshot of a deployment's charly-managed state
+// (engine named volumes — app and sidecar alike — plus gocryptfs encrypted
+// volumes) taken before a risky `charly update`, and its verified restore.
+//
+// A backup is a directory: one <volume>.tar.gz per named volume (exported
+// through `podman volume export`, so rootless subuid-owned files come out
+// intact), one <volume>.cipher.tar.gz per encrypted volume (the gocryptfs
+// CIPHER directory, gocryptfs.conf included — plaintext never touches the
+// backup), and manifest.json recording box, instance, the running image's
+// CalVer and each file's SHA-256. Restore verifies every checksum BEFORE it
+// touches a single volume.
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// volumeBackupManifestFile is the manifest's name inside a backup directory.
+const volumeBackupManifestFile = "manifest.json"
+
+// errNoDeployVolumes is returned by `charly volume backup` for a deployment
+// without named or initialized encrypted volumes.
+var errNoDeployVolumes = errors.New("no charly-managed volumes")
+
+// VolumeBackupManifest describes one `charly volume backup` directory.
+type VolumeBackupManifest struct {
+	Box      string              `json:"box"`
+	Instance string              `json:"instance,omitempty"`
+	Image    string              `json:"image,omitempty"`
+	Version  string              `json:"version,omitempty"` // ai.opencharly.version of the deployed image
+	Created  time.Time           `json:"created"`
+	Volumes  []VolumeBackupEntry `json:"volumes"`
+}
+
+// VolumeBackupEntry is one archived volume.
+type VolumeBackupEntry struct {
+	Name   string `json:"name"`   // short name (e.g. tailscale-state, secrets)
+	Kind   string `json:"kind"`   // "volume" (engine named volume) | "encrypted" (gocryptfs cipher dir)
+	Source string `json:"source"` // full engine volume name, or the cipher directory
+	File   string `json:"file"`   // archive file, relative to the backup directory
+	SHA256 string `json:"sha256"` // of File's bytes
+	Size   int64  `json:"size"`
+}
+
+// VolumeBackupCmd snapshots a deployment's named + encrypted volumes.
+type VolumeBackupCmd struct {
+	Box      string `arg:"" help:"Box / deploy name"`
+	Instance string `short:"i" long:"instance" help:"Instance name"`
+	Output   string `short:"o" long:"output" help:"Backup directory to create (default ./charly-backup-<deploy>-<timestamp>)"`
+	NoStop   bool   `long:"no-stop" help:"Do not stop a running deployment first (crash-consistent snapshot only)"`
+}
+
+func (c *VolumeBackupCmd) Run() (err error) {
+	rt, err := ResolveRuntime()
+	if err != nil {
+		return err
+	}
+	boxName := resolveBoxName(c.Box)
+	runEngine := ResolveBoxEngineForDeploy(boxName, c.Instance, rt.RunEngine)
+	bin := EngineBinary(runEngine)
+	ctrName := containerNameInstance(boxName, c.Instance)
+
+	named, err := deployNamedVolumes(bin, boxName, c.Instance)
+	if err != nil {
+		return err
+	}
+	encDirs, err := deployEncryptedCipherDirs(boxName, c.Instance)
+	if err != nil {
+		return err
+	}
+	var encrypted []encVolumeDirs
+	for _, ev := range encDirs {
+		if ev.Initialized {
+			encrypted = append(encrypted, ev)
+		}
+	}
+	if len(named) == 0 && len(encrypted) == 0 {
+		return fmt.Errorf("%w for %s (none declared by its quadlet units)", errNoDeployVolumes, ctrName)
+	}
+	if len(named) > 0 && runEngine != "podman" {
+		return fmt.Errorf("backing up named volumes needs `podman volume export`; %s runs on %s", boxName, runEngine)
+	}
+
+	dir := c.Output
+	if dir == "" {
+		dir = fmt.Sprintf("charly-backup-%s-%s", deployStorageDir(boxName, c.Instance), time.Now().UTC().Format("20060102T150405Z"))
+	}
+	if _, err := os.Stat(dir); err == nil {
+		return fmt.Errorf("backup directory %s already exists", dir)
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("creating backup directory: %w", err)
+	}
+	// A failed backup must not leave a half-written directory behind that a
+	// later restore could mistake for a complete one.
+	defer func() {
+		if err != nil {
+			_ = os.RemoveAll(dir)
+		}
+	}()
+
+	m := &VolumeBackupManifest{Box: boxName, Instance: c.Instance, Created: time.Now().UTC()}
+	if img, ierr := containerImageRef(runEngine, ctrName); ierr == nil {
+		m.Image = img
+		if labels, lerr := InspectLabels(runEngine, img); lerr == nil {
+			m.Version = labels[LabelVersion]
+		}
+	}
+
+	err = withDeploymentQuiesced(boxName, c.Instance, runEngine, c.NoStop, func() error {
+		for _, full := range named {
+			short := strings.TrimPrefix(full, ctrName+"-")
+			e, err := writeVolumeArchive(dir, short+".tar.gz", func(w io.Writer) error {
+				return exportNamedVolume(bin, full, w)
+			})
+			if err != nil {
+				return fmt.Errorf("backing up volume %s: %w", full, err)
+			}
+			e.Name, e.Kind, e.Source = short, "volume", full
+			m.Volumes = append(m.Volumes, e)
+		}
+		for _, ev := range encrypted {
+			e, err := writeVolumeArchive(dir, ev.Name+".cipher.tar.gz", func(w io.Writer) error {
+				return writeDirTar(ev.CipherDir, w)
+			})
+			if err != nil {
+				return fmt.Errorf("backing up encrypted volume %s: %w", ev.Name, err)
+			}
+			e.Name, e.Kind, e.Source = ev.Name, "encrypted", ev.CipherDir
+			m.Volumes = append(m.Volumes, e)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := writeVolumeBackupManifest(dir, m); err != nil {
+		return err
+	}
+	for _, e := range m.Volumes {
+		fmt.Printf("%s\t%s\t%s\t%d\n", e.Kind, e.Name, e.File, e.Size)
+	}
+	fmt.Fprintf(os.Stderr, "Backed up %d volume(s) of %s to %s\n", len(m.Volumes), boxName, dir)
+	return nil
+}
+
+// VolumeRestoreCmd restores a `charly volume backup` directory into the
+// deployment's volumes after verifying every archive's checksum.
+type VolumeRestoreCmd struct {
+	Box      string   `arg:"" help:"Box / deploy name"`
+	Dir      string   `arg:"" help:"Backup directory written by charly volume backup"`
+	Instance string   `short:"i" long:"instance" help:"Instance name"`
+	Volume   []string `long:"volume" help:"Restore only these volumes (short names; default all)"`
+}
+
+func (c *VolumeRestoreCmd) Run() error {
+	rt, err := ResolveRuntime()
+	if err != nil {
+		return err
+	}
+	boxName := resolveBoxName(c.Box)
+	runEngine := ResolveBoxEngineForDeploy(boxName, c.Instance, rt.RunEngine)
+	bin := EngineBinary(runEngine)
+	ctrName := containerNameInstance(boxName, c.Instance)
+
+	m, err := readVolumeBackupManifest(c.Dir)
+	if err != nil {
+		return err
+	}
+	if m.Box != boxName {
+		return fmt.Errorf("backup %s is of box %s, not %s", c.Dir, m.Box, boxName)
+	}
+	entries, err := selectBackupEntries(m, c.Volume)
+	if err != nil {
+		return err
+	}
+	if err := verifyVolumeBackup(c.Dir, entries); err != nil {
+		return err
+	}
+	encDirs, err := deployEncryptedCipherDirs(boxName, c.Instance)
+	if err != nil {
+		return err
+	}
+	cipherByName := make(map[string]encVolumeDirs, len(encDirs))
+	for _, ev := range encDirs {
+		cipherByName[ev.Name] = ev
+	}
+	declared, err := deployDeclaredVolumes(boxName, c.Instance)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if e.Kind == "volume" && runEngine != "podman" {
+			return fmt.Errorf("restoring named volumes needs `podman volume import`; %s runs on %s", boxName, runEngine)
+		}
+		if e.Kind == "volume" && !slices.Contains(declared, ctrName+"-"+e.Name) {
+			return fmt.Errorf("volume %s-%s is not declared by %s's quadlet units; refusing to restore into it", ctrName, e.Name, ctrName)
+		}
+		if e.Kind == "encrypted" {
+			if ev, ok := cipherByName[e.Name]; ok && isEncryptedMounted(ev.PlainDir) {
+				return fmt.Errorf("encrypted volume %s is mounted at %s; run `charly stop %s --unmount` first", e.Name, ev.PlainDir, boxName)
+			}
+		}
+	}
+
+	return withDeploymentQuiesced(boxName, c.Instance, runEngine, false, func() error {
+		for _, e := range entries {
+			switch e.Kind {
+			case "volume":
+				full := ctrName + "-" + e.Name
+				if err := importNamedVolume(bin, full, filepath.Join(c.Dir, e.File)); err != nil {
+					return fmt.Errorf("restoring volume %s: %w", full, err)
+				}
+				fmt.Fprintf(os.Stderr, "Restored volume %s\n", full)
+			case "encrypted":
+				cipher := e.Source
+				if ev, ok := cipherByName[e.Name]; ok {
+					cipher = ev.CipherDir
+				}
+				aside, err := restoreCipherDir(filepath.Join(c.Dir, e.File), cipher)
+				if err != nil {
+					return fmt.Errorf("restoring encrypted volume %s: %w", e.Name, err)
+				}
+				msg := fmt.Sprintf("Restored encrypted volume %s into %s", e.Name, cipher)
+				if aside != "" {
+					msg += " (previous cipher dir kept at " + aside + ")"
+				}
+				fmt.Fprintln(os.Stderr, msg)
+			}
+		}
+		return nil
+	})
+}
+
+// deployNamedVolumes returns the sorted engine volumes the deploy's own
+// quadlet units declare (app and sidecar alike) that exist. Selection is by
+// exact name, never by the charly-<box>[-<instance>]- prefix: that prefix also
+// matches a sibling instance's volumes and those of a box whose name merely
+// extends this one (app vs app-db), which are neither stopped nor ours.
+func deployNamedVolumes(bin, boxName, instance string) ([]string, error) {
+	declared, err := deployDeclaredVolumes(boxName, instance)
+	if err != nil || len(declared) == 0 {
+		return nil, err
+	}
+	out, err := exec.Command(bin, "volume", "ls", "--format", "{{.Name}}").Output()
+	if err != nil {
+		return nil, fmt.Errorf("listing volumes: %w", err)
+	}
+	var names []string
+	for n := range strings.SplitSeq(strings.TrimSpace(string(out)), "\n") {
+		if slices.Contains(declared, n) {
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// deployDeclaredVolumes returns the named volumes the deploy's quadlet units
+// (podDeployUnitFiles) mount, whether or not they exist yet.
+func deployDeclaredVolumes(boxName, instance string) ([]string, error) {
+	qdir, err := quadletDir()
+	if err != nil {
+		return nil, err
+	}
+	return unitNamedVolumes(qdir, podDeployUnitFiles(qdir, boxName, instance)), nil
+}
+
+// unitNamedVolumes collects the named-volume sources of the Volume= lines in
+// units' .container files — bind mounts (a path source) are skipped.
+func unitNamedVolumes(qdir string, units []string) []string {
+	var names []string
+	for _, unit := range units {
+		if !strings.HasSuffix(unit, ".container") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(qdir, unit))
+		if err != nil {
+			continue
+		}
+		for line := range strings.SplitSeq(string(data), "\n") {
+			src, ok := strings.CutPrefix(strings.TrimSpace(line), "Volume=")
+			if !ok {
+				continue
+			}
+			src, _, _ = strings.Cut(src, ":")
+			if src == "" || strings.ContainsAny(src, "/%~") || slices.Contains(names, src) {
+				continue
+			}
+			names = append(names, src)
+		}
+	}
+	return names
+}
+
+// encVolumeDirs locates one encrypted volume's gocryptfs directories.
+type encVolumeDirs struct {
+	Name        string
+	CipherDir   string
+	PlainDir    string
+	Initialized bool // gocryptfs.conf present — there is ciphertext to back up
+}
+
+// deployEncryptedCipherDirs resolves the deploy's configured encrypted volumes.
+func deployEncryptedCipherDirs(boxName, instance string) ([]encVolumeDirs, error) {
+	mounts, storagePath, err := loadEncryptedVolume(boxName, instance)
+	if err != nil {
+		return nil, err
+	}
+	var out []encVolumeDirs
+	for _, m := range mounts {
+		volDir := resolveEncVolumeDir(m, storagePath, deployStorageDir(boxName, instance))
+		cipher := filepath.Join(volDir, "cipher")
+		out = append(out, encVolumeDirs{
+			Name: m.Name, CipherDir: cipher, PlainDir: filepath.Join(volDir, "plain"),
+			Initialized: isEncryptedInitialized(cipher),
+		})
+	}
+	return out, nil
+}
+
+// withDeploymentQuiesced runs fn with the deployment stopped through the
+// regular stop/start path: a running deployment is stopped first and started
+// again afterwards (even when fn fails). noStop runs fn against the live
+// deployment instead.
+func withDeploymentQuiesced(boxName, instance, engine string, noStop bool, fn func() error) error {
+	if noStop || !containerRunning(engine, containerNameInstance(boxName, instance)) {
+		return fn()
+	}
+	if err := stopPodService(boxName, instance); err != nil {
+		return err
+	}
+	err := fn()
+	if serr := startPodService(boxName, instance); serr != nil {
+		return errors.Join(err, fmt.Errorf("restarting %s: %w", boxName, serr))
+	}
+	return err
+}
+
+// writeVolumeArchive gzips what produce writes into dir/file and returns the
+// entry's file, size and checksum.
+func writeVolumeArchive(dir, file string, produce func(io.Writer) error) (VolumeBackupEntry, error) {
+	f, err := os.OpenFile(filepath.Join(dir, file), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return VolumeBackupEntry{}, err
+	}
+	h := sha256.New()
+	cw := &countingWriter{w: io.MultiWriter(f, h)}
+	gz := gzip.NewWriter(cw)
+	if err := produce(gz); err != nil {
+		_ = f.Close()
+		return VolumeBackupEntry{}, err
+	}
+	if err := gz.Close(); err != nil {
+		_ = f.Close()
+		return VolumeBackupEntry{}, err
+	}
+	if err := f.Close(); err != nil {
+		return VolumeBackupEntry{}, err
+	}
+	return VolumeBackupEntry{File: file, SHA256: hex.EncodeToString(h.Sum(nil)), Size: cw.n}, nil
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func exportNamedVolume(bin, name string, w io.Writer) error {
+	var stderr strings.Builder
+	cmd := exec.Command(bin, "volume", "export", name)
+	cmd.Stdout = w
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// importNamedVolume replaces the volume's contents with the archive's tarball.
+// `podman volume import` merges over whatever the volume holds, so an existing
+// volume is removed and recreated empty first — the engine refuses an in-use
+// volume, which surfaces as an error instead of a half-merged restore. The
+// engine has no volume rename to swap through, so the previous contents are
+// exported aside first and put back if the import fails.
+func importNamedVolume(bin, name, archive string) error {
+	f, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer f.Close() //nolint:errcheck
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	var prev string
+	if exec.Command(bin, "volume", "exists", name).Run() == nil {
+		aside, err := os.CreateTemp("", "charly-volume-*.tar")
+		if err != nil {
+			return err
+		}
+		prev = aside.Name()
+		defer os.Remove(prev) //nolint:errcheck
+		err = exportNamedVolume(bin, name, aside)
+		if cerr := aside.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return fmt.Errorf("saving current contents before import: %w", err)
+		}
+		if out, err := exec.Command(bin, "volume", "rm", name).CombinedOutput(); err != nil {
+			return fmt.Errorf("removing volume before import: %s", strings.TrimSpace(string(out)))
+		}
+	}
+	err = createAndImportVolume(bin, name, gz)
+	if err != nil && prev != "" {
+		_ = exec.Command(bin, "volume", "rm", name).Run()
+		if pf, perr := os.Open(prev); perr == nil {
+			perr = createAndImportVolume(bin, name, pf)
+			_ = pf.Close()
+			if perr != nil {
+				return errors.Join(err, fmt.Errorf("putting back the previous contents: %w", perr))
+			}
+			return fmt.Errorf("%w (previous contents put back)", err)
+		}
+	}
+	return err
+}
+
+// createAndImportVolume creates name empty and imports the tar stream r.
+func createAndImportVolume(bin, name string, r io.Reader) error {
+	if out, err := exec.Command(bin, "volume", "create", name).CombinedOutput(); err != nil {
+		return fmt.Errorf("creating volume: %s", strings.TrimSpace(string(out)))
+	}
+	var stderr strings.Builder
+	cmd := exec.Command(bin, "volume", "import", name, "-")
+	cmd.Stdin = r
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// restoreCipherDir extracts archive into a sibling of cipher and swaps it in.
+// An existing cipher directory is renamed aside (returned), never deleted.
+func restoreCipherDir(archive, cipher string) (aside string, err error) {
+	tmp := cipher + ".restore"
+	if err := os.RemoveAll(tmp); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(tmp, 0o700); err != nil {
+		return "", err
+	}
+	f, err := os.Open(archive)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close() //nolint:errcheck
+	if err := extractDirTar(f, tmp); err != nil {
+		_ = os.RemoveAll(tmp)
+		return "", err
+	}
+	if _, err := os.Stat(cipher); err == nil {
+		aside = cipher + ".pre-restore-" + time.Now().UTC().Format("20060102T150405Z")
+		if err := os.Rename(cipher, aside); err != nil {
+			return "", err
+		}
+	}
+	return aside, os.Rename(tmp, cipher)
+}
+
+// writeDirTar writes dir's tree (regular files, directories, symlinks — the
+// entry types a gocryptfs cipher dir holds) as a tar stream.
+func writeDirTar(dir string, w io.Writer) error {
+	tw := tar.NewWriter(w)
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil || rel == "." {
+			return err
+		}
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(p); err != nil {
+				return err
+			}
+		}
+		hdr, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(rel)
+		if info.IsDir() {
+			hdr.Name += "/"
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close() //nolint:errcheck
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// extractDirTar unpacks a gzipped writeDirTar stream into dest, refusing any
+// entry that would land outside it.
+func extractDirTar(r io.Reader, dest string) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dest, filepath.FromSlash(hdr.Name))
+		if !strings.HasPrefix(target, filepath.Clean(dest)+string(os.PathSeparator)) {
+			return fmt.Errorf("archive entry %q escapes the destination", hdr.Name)
+		}
+		mode := os.FileMode(hdr.Mode).Perm()
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, mode|0o700); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if err := os.Symlink(hdr.Linkname, target); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0o700); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_EXCL|os.O_WRONLY, mode)
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(f, tr); err != nil {
+				_ = f.Close()
+				return err
+			}
+			if err := f.Close(); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("archive entry %q: unsupported type %q", hdr.Name, hdr.Typeflag)
+		}
+	}
+}
+
+func writeVolumeBackupManifest(dir string, m *VolumeBackupManifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, volumeBackupManifestFile), append(data, '\n'), 0o600)
+}
+
+func readVolumeBackupManifest(dir string) (*VolumeBackupManifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, volumeBackupManifestFile))
+	if err != nil {
+		return nil, fmt.Errorf("reading backup manifest: %w", err)
+	}
+	var m VolumeBackupManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", filepath.Join(dir, volumeBackupManifestFile), err)
+	}
+	return &m, nil
+}
+
+// selectBackupEntries filters the manifest to the requested short names
+// (all when none), failing on a name the backup does not hold.
+func selectBackupEntries(m *VolumeBackupManifest, names []string) ([]VolumeBackupEntry, error) {
+	if len(names) == 0 {
+		return m.Volumes, nil
+	}
+	var out []VolumeBackupEntry
+	for _, n := range names {
+		found := false
+		for _, e := range m.Volumes {
+			if e.Name == n {
+				out = append(out, e)
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("backup holds no volume %q", n)
+		}
+	}
+	return out, nil
+}
+
+// verifyVolumeBackup checks every entry's archive against its recorded
+// SHA-256, reporting all mismatches at once.
+func verifyVolumeBackup(dir string, entries []VolumeBackupEntry) error {
+	var bad []string
+	for _, e := range entries {
+		if filepath.IsAbs(e.File) || strings.Contains(e.File, "..") {
+			bad = append(bad, fmt.Sprintf("%s: invalid archive path %q", e.Name, e.File))
+			continue
+		}
+		f, err := os.Open(filepath.Join(dir, e.File))
+		if err != nil {
+			bad = append(bad, fmt.Sprintf("%s: %v", e.Name, err))
+			continue
+		}
+		h := sha256.New()
+		_, err = io.Copy(h, f)
+		_ = f.Close()
+		if err != nil {
+			bad = append(bad, fmt.Sprintf("%s: %v", e.Name, err))
+			continue
+		}
+		if got := hex.EncodeToString(h.Sum(nil)); got != e.SHA256 {
+			bad = append(bad, fmt.Sprintf("%s: checksum mismatch (%s, manifest %s)", e.File, got, e.SHA256))
+		}
+	}
+	if len(bad) > 0 {
+		return fmt.Errorf("backup %s failed verification — nothing restored:\n  %s", dir, strings.Join(bad, "\n  "))
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// An encrypted volume's cipher dir round-trips through backup + restore
+// byte-for-byte (gocryptfs.conf, nested dirs, symlinks), and the replaced
+// cipher dir is kept aside rather than deleted.
+func TestVolumeBackup_CipherDirRoundTrip(t *testing.T) {
+	src := t.TempDir()
+	cipher := filepath.Join(src, "charly-demo-secrets", "cipher")
+	mustMkdir(t, filepath.Join(cipher, "AbC"))
+	mustWrite(t, filepath.Join(cipher, "gocryptfs.conf"), `{"Version":2}`)
+	mustWrite(t, filepath.Join(cipher, "gocryptfs.diriv"), "iv")
+	mustWrite(t, filepath.Join(cipher, "AbC", "XyZ"), "ciphertext")
+	if err := os.Symlink("AbC/XyZ", filepath.Join(cipher, "lnk")); err != nil {
+		t.Fatal(err)
+	}
+
+	out := t.TempDir()
+	e, err := writeVolumeArchive(out, "secrets.cipher.tar.gz", func(w io.Writer) error { return writeDirTar(cipher, w) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Name, e.Kind, e.Source = "secrets", "encrypted", cipher
+	m := &VolumeBackupManifest{Box: "demo", Version: "2026.100.1200", Volumes: []VolumeBackupEntry{e}}
+	if err := writeVolumeBackupManifest(out, m); err != nil {
+		t.Fatal(err)
+	}
+	got, err := readVolumeBackupManifest(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Box != "demo" || got.Version != "2026.100.1200" || len(got.Volumes) != 1 || got.Volumes[0].SHA256 == "" || got.Volumes[0].Size == 0 {
+		t.Fatalf("manifest = %+v", got)
+	}
+	if err := verifyVolumeBackup(out, got.Volumes); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+
+	// Restore over a diverged cipher dir.
+	if err := os.WriteFile(filepath.Join(cipher, "gocryptfs.conf"), []byte("changed"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	aside, err := restoreCipherDir(filepath.Join(out, e.File), cipher)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(filepath.Join(aside, "gocryptfs.conf")); string(b) != "changed" {
+		t.Errorf("aside dir lost the previous cipher dir (got %q)", b)
+	}
+	if b, _ := os.ReadFile(filepath.Join(cipher, "gocryptfs.conf")); string(b) != `{"Version":2}` {
+		t.Errorf("restored gocryptfs.conf = %q", b)
+	}
+	if b, _ := os.ReadFile(filepath.Join(cipher, "AbC", "XyZ")); string(b) != "ciphertext" {
+		t.Errorf("restored nested file = %q", b)
+	}
+	if l, _ := os.Readlink(filepath.Join(cipher, "lnk")); l != "AbC/XyZ" {
+		t.Errorf("restored symlink = %q", l)
+	}
+}
+
+// A tampered or missing archive fails verification with every problem listed.
+func TestVerifyVolumeBackup_Mismatch(t *testing.T) {
+	dir := t.TempDir()
+	e, err := writeVolumeArchive(dir, "data.tar.gz", func(w io.Writer) error {
+		_, err := w.Write([]byte("tar bytes"))
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Name = "data"
+	f, err := os.OpenFile(filepath.Join(dir, "data.tar.gz"), os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.Write([]byte("x"))
+	_ = f.Close()
+	err = verifyVolumeBackup(dir, []VolumeBackupEntry{e, {Name: "gone", File: "gone.tar.gz"}, {Name: "evil", File: "../x"}})
+	if err == nil {
+		t.Fatal("expected verification failure")
+	}
+	for _, want := range []string{"data.tar.gz: checksum mismatch", "gone:", `invalid archive path "../x"`, "nothing restored"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error missing %q:\n%v", want, err)
+		}
+	}
+}
+
+func TestExtractDirTar_RejectsEscape(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	_ = tw.WriteHeader(&tar.Header{Name: "../../etc/passwd", Mode: 0o644, Size: 1, Typeflag: tar.TypeReg})
+	_, _ = tw.Write([]byte("x"))
+	_ = tw.Close()
+	_ = gz.Close()
+	if err := extractDirTar(&buf, t.TempDir()); err == nil || !strings.Contains(err.Error(), "escapes") {
+		t.Fatalf("err = %v, want escape rejection", err)
+	}
+}
+
+func TestSelectBackupEntries(t *testing.T) {
+	m := &VolumeBackupManifest{Volumes: []VolumeBackupEntry{{Name: "data"}, {Name: "tailscale-state"}, {Name: "secrets", Kind: "encrypted"}}}
+	if got, _ := selectBackupEntries(m, nil); len(got) != 3 {
+		t.Errorf("all = %d entries", len(got))
+	}
+	got, err := selectBackupEntries(m, []string{"secrets"})
+	if err != nil || len(got) != 1 || got[0].Kind != "encrypted" {
+		t.Errorf("secrets = %+v, %v", got, err)
+	}
+	if _, err := selectBackupEntries(m, []string{"nope"}); err == nil {
+		t.Error("expected an error for a volume the backup does not hold")
+	}
+}
+
+// A stopped deployment is backed up as-is — no stop/start round trip.
+func TestWithDeploymentQuiesced_NotRunning(t *testing.T) {
+	orig := containerRunning
+	containerRunning = func(string, string) bool { return false }
+	defer func() { containerRunning = orig }()
+	ran := false
+	if err := withDeploymentQuiesced("demo", "", "podman", false, func() error { ran = true; return nil }); err != nil || !ran {
+		t.Fatalf("ran=%v err=%v", ran, err)
+	}
+}
+
+// An existing named volume is saved aside, removed and recreated before the
+// import, so the restore replaces its contents instead of merging over them;
+// an in-use volume the engine refuses to remove aborts before anything is
+// imported, and a failed import puts the saved contents back.
+func TestImportNamedVolume_ReplacesContents(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "data.tar.gz")
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, _ = gz.Write([]byte("tar-bytes"))
+	_ = gz.Close()
+	mustWrite(t, archive, buf.String())
+
+	log := filepath.Join(dir, "engine.log")
+	engine := func(rmExit, importExit string) string {
+		p := filepath.Join(dir, "podman")
+		mustWrite(t, p, "#!/bin/sh\necho \"$*\" >> "+log+"\n"+
+			"case \"$2\" in\n  rm) exit "+rmExit+" ;;\n  export) printf prev-bytes ;;\n"+
+			"  import) cat > "+log+".in; grep -q prev "+log+".in || exit "+importExit+" ;;\nesac\n")
+		if err := os.Chmod(p, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		_ = os.Remove(log)
+		return p
+	}
+
+	if err := importNamedVolume(engine("0", "0"), "charly-app-data", archive); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := os.ReadFile(log)
+	if want := "volume exists charly-app-data\nvolume export charly-app-data\nvolume rm charly-app-data\nvolume create charly-app-data\nvolume import charly-app-data -\n"; string(got) != want {
+		t.Errorf("engine calls = %q, want %q", got, want)
+	}
+	if in, _ := os.ReadFile(log + ".in"); string(in) != "tar-bytes" {
+		t.Errorf("import stdin = %q, want the decompressed archive", in)
+	}
+
+	if err := importNamedVolume(engine("1", "0"), "charly-app-data", archive); err == nil {
+		t.Fatal("expected an error when the volume cannot be removed")
+	}
+	if got, _ := os.ReadFile(log); strings.Contains(string(got), "import") {
+		t.Errorf("imported despite the failed removal: %q", got)
+	}
+
+	err := importNamedVolume(engine("0", "1"), "charly-app-data", archive)
+	if err == nil || !strings.Contains(err.Error(), "previous contents put back") {
+		t.Fatalf("err = %v, want the import failure with the previous contents put back", err)
+	}
+	if in, _ := os.ReadFile(log + ".in"); string(in) != "prev-bytes" {
+		t.Errorf("last import stdin = %q, want the saved contents", in)
+	}
+}
+
+// Only the Volume= sources the deploy's own units name are its volumes: bind
+// mounts are skipped, and nothing is inferred from a name prefix.
+func TestUnitNamedVolumes(t *testing.T) {
+	qdir := t.TempDir()
+	mustWrite(t, filepath.Join(qdir, "charly-app.container"), "[Container]\nVolume=charly-app-data:/data:Z\nVolume=/srv/app:/srv\nVolume=%h/x:/x\n")
+	mustWrite(t, filepath.Join(qdir, "charly-app-relay.container"), "[Container]\nVolume=charly-app-relay-state:/state\nVolume=charly-app-data:/data\n")
+	mustWrite(t, filepath.Join(qdir, "charly-app.pod"), "[Pod]\nVolume=ignored:/x\n")
+	got := unitNamedVolumes(qdir, []string{"charly-app.container", "charly-app-relay.container", "charly-app.pod"})
+	if want := []string{"charly-app-data", "charly-app-relay-state"}; !slices.Equal(got, want) {
+		t.Errorf("volumes = %v, want %v", got, want)
+	}
+}
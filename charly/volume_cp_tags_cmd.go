@@ -35,8 +35,10 @@ func resolveSidecarContainer(box, instance, sidecar string) (engine, name string
 
 // VolumeCmd groups the named-volume verbs.
 type VolumeCmd struct {
-	List  VolumeListCmd  `cmd:"" help:"List a deployment's charly-managed named volumes with their backing mountpoints"`
-	Reset VolumeResetCmd `cmd:"" help:"Remove ONE named volume so the next start recreates it fresh (e.g. wipe a sidecar's state volume to force re-auth)"`
+	List    VolumeListCmd    `cmd:"" help:"List a deployment's charly-managed named volumes with their backing mountpoints"`
+	Reset   VolumeResetCmd   `cmd:"" help:"Remove ONE named volume so the next start recreates it fresh (e.g. wipe a sidecar's state volume to force re-auth)"`
+	Backup  VolumeBackupCmd  `cmd:"" help:"Snapshot a deployment's named and encrypted volumes into a checksummed backup directory (stops and restarts a running deployment)"`
+	Restore VolumeRestoreCmd `cmd:"" help:"Restore a backup directory into a deployment's volumes after verifying every checksum"`
 }
 
 // VolumeListCmd lists the engine-side named volumes belonging to a